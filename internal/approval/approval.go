@@ -0,0 +1,241 @@
+// Package approval implements two-person approval of production actions. The
+// operator running an action signs an approval request file, a second
+// operator countersigns it with 'pscale approve', and only then the action is
+// executed.
+package approval
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/planetscale/cli/internal/config"
+)
+
+const keyName = "approval.key"
+
+// Action is a production-affecting action that needs approval.
+type Action struct {
+	Command      string `json:"command"`
+	Organization string `json:"org"`
+	Database     string `json:"database"`
+	Branch       string `json:"branch,omitempty"`
+	Number       uint64 `json:"number,omitempty"`
+}
+
+// String returns a description of the action.
+func (a *Action) String() string {
+	s := fmt.Sprintf("%s %s/%s", a.Command, a.Organization, a.Database)
+	if a.Branch != "" {
+		s += "/" + a.Branch
+	}
+	if a.Number != 0 {
+		s += fmt.Sprintf("/%d", a.Number)
+	}
+	return s
+}
+
+// Request is an approval request. It's signed by the requester and becomes
+// valid once countersigned by an approver.
+type Request struct {
+	Action       Action    `json:"action"`
+	Requester    string    `json:"requester"`
+	RequesterKey []byte    `json:"requester_key"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Signature    []byte    `json:"signature"`
+
+	Approver         string     `json:"approver,omitempty"`
+	ApproverKey      []byte     `json:"approver_key,omitempty"`
+	ApprovedAt       *time.Time `json:"approved_at,omitempty"`
+	Countersignature []byte     `json:"countersignature,omitempty"`
+}
+
+// NewRequest returns a request for the action signed with the key of the
+// requester. It expires after the given duration.
+func NewRequest(action *Action, requester string, key ed25519.PrivateKey, expiry time.Duration) *Request {
+	now := time.Now().UTC()
+	r := &Request{
+		Action:       *action,
+		Requester:    requester,
+		RequesterKey: key.Public().(ed25519.PublicKey),
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(expiry),
+	}
+	r.Signature = ed25519.Sign(key, r.payload())
+	return r
+}
+
+// payload returns the signed content of the request.
+func (r *Request) payload() []byte {
+	out, _ := json.Marshal(struct {
+		Action       Action    `json:"action"`
+		Requester    string    `json:"requester"`
+		RequesterKey []byte    `json:"requester_key"`
+		CreatedAt    time.Time `json:"created_at"`
+		ExpiresAt    time.Time `json:"expires_at"`
+	}{r.Action, r.Requester, r.RequesterKey, r.CreatedAt, r.ExpiresAt})
+	return out
+}
+
+// countersigned returns the content countersigned by the approver.
+func (r *Request) countersigned() []byte {
+	out, _ := json.Marshal(struct {
+		Signature   []byte    `json:"signature"`
+		Approver    string    `json:"approver"`
+		ApproverKey []byte    `json:"approver_key"`
+		ApprovedAt  time.Time `json:"approved_at"`
+	}{r.Signature, r.Approver, r.ApproverKey, *r.ApprovedAt})
+	return out
+}
+
+// Approve countersigns the request with the key of the approver, who has to
+// be a different operator than the requester.
+func (r *Request) Approve(approver string, key ed25519.PrivateKey, now time.Time) error {
+	if err := r.verifyRequest(now); err != nil {
+		return err
+	}
+
+	pub := key.Public().(ed25519.PublicKey)
+	if bytes.Equal(pub, r.RequesterKey) {
+		return errors.New("the request can't be approved by its requester")
+	}
+
+	approvedAt := now.UTC()
+	r.Approver = approver
+	r.ApproverKey = pub
+	r.ApprovedAt = &approvedAt
+	r.Countersignature = ed25519.Sign(key, r.countersigned())
+	return nil
+}
+
+// Verify verifies that the request approves the given action, requested
+// with the given key and approved by one of the trusted approvers. Approvers
+// are base64 encoded public keys. If there are none, no approval is
+// accepted.
+func (r *Request) Verify(action *Action, requesterKey ed25519.PublicKey, approvers []string, now time.Time) error {
+	if r.Action != *action {
+		return fmt.Errorf("the approval is for %q, not for %q", r.Action.String(), action.String())
+	}
+
+	if !bytes.Equal(r.RequesterKey, requesterKey) {
+		return errors.New("the approval was requested by another operator")
+	}
+
+	if err := r.verifyRequest(now); err != nil {
+		return err
+	}
+
+	if r.ApprovedAt == nil || len(r.Countersignature) == 0 {
+		return errors.New("the request isn't approved yet")
+	}
+
+	if len(r.ApproverKey) != ed25519.PublicKeySize || bytes.Equal(r.ApproverKey, r.RequesterKey) {
+		return errors.New("the request must be approved by a second operator")
+	}
+
+	if !ed25519.Verify(r.ApproverKey, r.countersigned(), r.Countersignature) {
+		return errors.New("invalid countersignature")
+	}
+
+	if len(approvers) == 0 {
+		return errors.New("no trusted approvers are configured, add the keys shown by 'pscale approve --show-key' to \"approval.approvers\"")
+	}
+
+	approverKey := EncodeKey(r.ApproverKey)
+	for _, a := range approvers {
+		if a == approverKey {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("approver %s (%s) is not a trusted approver of the organization", r.Approver, approverKey)
+}
+
+func (r *Request) verifyRequest(now time.Time) error {
+	if len(r.RequesterKey) != ed25519.PublicKeySize || !ed25519.Verify(r.RequesterKey, r.payload(), r.Signature) {
+		return errors.New("invalid signature of the approval request")
+	}
+
+	if now.After(r.ExpiresAt) {
+		return fmt.Errorf("the approval request expired at %s", r.ExpiresAt.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// Write writes the request to the given file.
+func (r *Request) Write(file string) error {
+	out, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, out, 0644)
+}
+
+// Read reads a request from the given file.
+func Read(file string) (*Request, error) {
+	out, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Request{}
+	if err := json.Unmarshal(out, r); err != nil {
+		return nil, fmt.Errorf("can't parse approval request %q: %s", file, err)
+	}
+
+	return r, nil
+}
+
+// EncodeKey returns the base64 encoding of the public key, as used for the
+// trusted approvers.
+func EncodeKey(key ed25519.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// LoadKey returns the approval key of this machine, generating it on first
+// use.
+func LoadKey() (ed25519.PrivateKey, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	p := path.Join(dir, keyName)
+	out, err := ioutil.ReadFile(p)
+	if err == nil {
+		if len(out) != ed25519.PrivateKeySize {
+			return nil, fmt.Errorf("invalid approval key %q", p)
+		}
+		return ed25519.PrivateKey(out), nil
+	}
+
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0771); err != nil {
+		return nil, fmt.Errorf("error creating config directory: %s", err)
+	}
+
+	if err := ioutil.WriteFile(p, key, 0600); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
@@ -0,0 +1,78 @@
+package approval
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func newKey(c *qt.C) ed25519.PrivateKey {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	c.Assert(err, qt.IsNil)
+	return key
+}
+
+func TestApprove(t *testing.T) {
+	c := qt.New(t)
+
+	requester, approver := newKey(c), newKey(c)
+	action := &Action{Command: "deploy-request deploy", Organization: "planetscale", Database: "mydb", Number: 7}
+
+	req := NewRequest(action, "alice@host", requester, time.Hour)
+	requesterKey := requester.Public().(ed25519.PublicKey)
+
+	err := req.Verify(action, requesterKey, nil, time.Now())
+	c.Assert(err, qt.ErrorMatches, "the request isn't approved yet")
+
+	err = req.Approve("alice@host", requester, time.Now())
+	c.Assert(err, qt.ErrorMatches, "the request can't be approved by its requester")
+
+	err = req.Approve("bob@host", approver, time.Now())
+	c.Assert(err, qt.IsNil)
+
+	// the request survives a round trip through the file
+	file := filepath.Join(t.TempDir(), "approval.json")
+	c.Assert(req.Write(file), qt.IsNil)
+	req, err = Read(file)
+	c.Assert(err, qt.IsNil)
+
+	trusted := []string{EncodeKey(approver.Public().(ed25519.PublicKey))}
+	c.Assert(req.Verify(action, requesterKey, trusted, time.Now()), qt.IsNil)
+
+	// approvals aren't accepted without trusted approvers
+	err = req.Verify(action, requesterKey, nil, time.Now())
+	c.Assert(err, qt.ErrorMatches, `no trusted approvers are configured, .*`)
+
+	untrusted := []string{EncodeKey(newKey(c).Public().(ed25519.PublicKey))}
+	err = req.Verify(action, requesterKey, untrusted, time.Now())
+	c.Assert(err, qt.ErrorMatches, `approver bob@host \(.*\) is not a trusted approver of the organization`)
+
+	other := *action
+	other.Number = 8
+	err = req.Verify(&other, requesterKey, nil, time.Now())
+	c.Assert(err, qt.ErrorMatches, `the approval is for "deploy-request deploy planetscale/mydb/7", not for "deploy-request deploy planetscale/mydb/8"`)
+
+	err = req.Verify(action, approver.Public().(ed25519.PublicKey), nil, time.Now())
+	c.Assert(err, qt.ErrorMatches, "the approval was requested by another operator")
+
+	err = req.Verify(action, requesterKey, nil, time.Now().Add(2*time.Hour))
+	c.Assert(err, qt.ErrorMatches, "the approval request expired at .*")
+}
+
+func TestVerifyTampered(t *testing.T) {
+	c := qt.New(t)
+
+	requester, approver := newKey(c), newKey(c)
+	action := &Action{Command: "database delete", Organization: "planetscale", Database: "mydb"}
+
+	req := NewRequest(action, "alice@host", requester, time.Hour)
+	c.Assert(req.Approve("bob@host", approver, time.Now()), qt.IsNil)
+
+	req.ExpiresAt = req.ExpiresAt.Add(24 * time.Hour)
+	err := req.Verify(action, requester.Public().(ed25519.PublicKey), nil, time.Now())
+	c.Assert(err, qt.ErrorMatches, "invalid signature of the approval request")
+}
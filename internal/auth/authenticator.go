@@ -31,6 +31,8 @@ const (
 type Authenticator interface {
 	VerifyDevice(ctx context.Context) (*DeviceVerification, error)
 	GetAccessTokenForDevice(ctx context.Context, v *DeviceVerification) (string, error)
+	GetTokenForDevice(ctx context.Context, v *DeviceVerification) (*OAuthTokenResponse, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*OAuthTokenResponse, error)
 	RevokeToken(ctx context.Context, token string) error
 }
 
@@ -173,13 +175,23 @@ func (d *DeviceAuthenticator) VerifyDevice(ctx context.Context) (*DeviceVerifica
 // GetAccessTokenForDevice uses the device verification response to fetch an
 // access token.
 func (d *DeviceAuthenticator) GetAccessTokenForDevice(ctx context.Context, v *DeviceVerification) (string, error) {
-	var accessToken string
+	tokenRes, err := d.GetTokenForDevice(ctx, v)
+	if err != nil {
+		return "", err
+	}
+	return tokenRes.AccessToken, nil
+}
+
+// GetTokenForDevice uses the device verification response to fetch an
+// access token, along with its refresh token and expiry.
+func (d *DeviceAuthenticator) GetTokenForDevice(ctx context.Context, v *DeviceVerification) (*OAuthTokenResponse, error) {
+	var tokenRes *OAuthTokenResponse
 	var err error
 
 	for {
 		time.Sleep(v.CheckInterval)
-		accessToken, err = d.requestToken(ctx, v.DeviceCode, d.ClientID)
-		if accessToken == "" && err == nil {
+		tokenRes, err = d.requestToken(ctx, v.DeviceCode, d.ClientID)
+		if tokenRes == nil && err == nil {
 			if d.Clock.Now().After(v.ExpiresAt) {
 				err = errors.New("authentication timed out")
 			} else {
@@ -189,7 +201,35 @@ func (d *DeviceAuthenticator) GetAccessTokenForDevice(ctx context.Context, v *De
 
 		break
 	}
-	return accessToken, err
+	return tokenRes, err
+}
+
+// RefreshToken exchanges a refresh token for a new access token.
+func (d *DeviceAuthenticator) RefreshToken(ctx context.Context, refreshToken string) (*OAuthTokenResponse, error) {
+	payload := strings.NewReader(fmt.Sprintf("grant_type=refresh_token&refresh_token=%s&client_id=%s&client_secret=%s",
+		url.QueryEscape(refreshToken), d.ClientID, d.ClientSecret))
+	req, err := d.NewFormRequest(ctx, http.MethodPost, "oauth/token", payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating request")
+	}
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "error performing http request")
+	}
+
+	defer res.Body.Close()
+
+	if _, err := checkErrorResponse(res); err != nil {
+		return nil, err
+	}
+
+	tokenRes := &OAuthTokenResponse{}
+	if err := json.NewDecoder(res.Body).Decode(tokenRes); err != nil {
+		return nil, errors.Wrap(err, "error decoding token response")
+	}
+
+	return tokenRes, nil
 }
 
 // OAuthTokenResponse contains the information returned after fetching an access
@@ -201,38 +241,38 @@ type OAuthTokenResponse struct {
 	ExpiresIn    int    `json:"expires_in"`
 }
 
-func (d *DeviceAuthenticator) requestToken(ctx context.Context, deviceCode string, clientID string) (string, error) {
+func (d *DeviceAuthenticator) requestToken(ctx context.Context, deviceCode string, clientID string) (*OAuthTokenResponse, error) {
 	payload := strings.NewReader(fmt.Sprintf("grant_type=urn:ietf:params:oauth:grant-type:device_code&device_code=%s&client_id=%s", deviceCode, clientID))
 	req, err := d.NewFormRequest(ctx, http.MethodPost, "oauth/token", payload)
 	if err != nil {
-		return "", errors.Wrap(err, "error creating request")
+		return nil, errors.Wrap(err, "error creating request")
 	}
 
 	res, err := d.client.Do(req)
 	if err != nil {
-		return "", errors.Wrap(err, "error performing http request")
+		return nil, errors.Wrap(err, "error performing http request")
 	}
 
 	defer res.Body.Close()
 
 	isRetryable, err := checkErrorResponse(res)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// Bail early so the token fetching is retried.
 	if isRetryable {
-		return "", nil
+		return nil, nil
 	}
 
 	tokenRes := &OAuthTokenResponse{}
 
 	err = json.NewDecoder(res.Body).Decode(tokenRes)
 	if err != nil {
-		return "", errors.Wrap(err, "error decoding token response")
+		return nil, errors.Wrap(err, "error decoding token response")
 	}
 
-	return tokenRes.AccessToken, nil
+	return tokenRes, nil
 }
 
 // RevokeToken revokes an access token.
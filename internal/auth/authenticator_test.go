@@ -125,3 +125,30 @@ func setupServer(fn func(mux *http.ServeMux)) (*httptest.Server, func()) {
 		server.Close()
 	}
 }
+
+func TestRefreshToken(t *testing.T) {
+	srv, cleanup := setupServer(func(mux *http.ServeMux) {
+		mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+			payload, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, "grant_type=refresh_token&refresh_token=some%2Frefresh&client_id=some-client-id&client_secret=some-client-secret", string(payload))
+			w.Write([]byte(`{"access_token": "new-token", "refresh_token": "new-refresh", "expires_in": 7200}`)) // nolint:errcheck
+		})
+	})
+	defer cleanup()
+
+	client, err := New(cleanhttp.DefaultClient(), testClientID, testClientSecret, SetBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := client.RefreshToken(context.Background(), "some/refresh")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, &OAuthTokenResponse{AccessToken: "new-token", RefreshToken: "new-refresh", ExpiresIn: 7200}, res)
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultOIDCAudience is the audience requested for CI identity tokens
+	// that are exchanged for a PlanetScale access token.
+	DefaultOIDCAudience = "planetscale"
+
+	tokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+	idTokenType            = "urn:ietf:params:oauth:token-type:id_token"
+)
+
+// ErrNoOIDCProvider is returned if no supported CI identity provider could be
+// detected from the environment.
+var ErrNoOIDCProvider = errors.New("no OIDC identity token found. Supported providers are " +
+	"GitHub Actions (requires 'id-token: write' permission), GitLab CI (requires an " +
+	"'id_tokens' entry named PSCALE_OIDC_TOKEN) or an explicit PSCALE_OIDC_TOKEN environment variable")
+
+// CIIdentityToken returns an OIDC identity token issued by the CI provider the
+// CLI is running in, together with the name of the provider.
+func CIIdentityToken(ctx context.Context, client *http.Client, audience string) (string, string, error) {
+	if token := os.Getenv("PSCALE_OIDC_TOKEN"); token != "" {
+		return token, "environment", nil
+	}
+
+	reqURL := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+	reqToken := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+	if reqURL != "" && reqToken != "" {
+		token, err := githubIdentityToken(ctx, client, reqURL, reqToken, audience)
+		if err != nil {
+			return "", "", err
+		}
+		return token, "github-actions", nil
+	}
+
+	if token := os.Getenv("CI_JOB_JWT_V2"); token != "" {
+		return token, "gitlab-ci", nil
+	}
+
+	return "", "", ErrNoOIDCProvider
+}
+
+// githubIdentityToken requests an identity token from the GitHub Actions
+// token service.
+func githubIdentityToken(ctx context.Context, client *http.Client, reqURL, reqToken, audience string) (string, error) {
+	u, err := url.Parse(reqURL)
+	if err != nil {
+		return "", errors.Wrap(err, "error parsing ACTIONS_ID_TOKEN_REQUEST_URL")
+	}
+
+	if audience != "" {
+		q := u.Query()
+		q.Set("audience", audience)
+		u.RawQuery = q.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "bearer "+reqToken)
+	req.Header.Set("Accept", jsonMediaType)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "error requesting GitHub Actions identity token")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return "", fmt.Errorf("error requesting GitHub Actions identity token: %s", res.Status)
+	}
+
+	var tokenRes struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&tokenRes); err != nil {
+		return "", errors.Wrap(err, "error decoding GitHub Actions identity token response")
+	}
+
+	if tokenRes.Value == "" {
+		return "", errors.New("GitHub Actions returned an empty identity token")
+	}
+
+	return tokenRes.Value, nil
+}
+
+// ExchangeIDToken exchanges a CI provider's OIDC identity token for a
+// short-lived PlanetScale access token (RFC 8693).
+func (d *DeviceAuthenticator) ExchangeIDToken(ctx context.Context, idToken string) (*OAuthTokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", tokenExchangeGrantType)
+	form.Set("client_id", d.ClientID)
+	form.Set("subject_token", idToken)
+	form.Set("subject_token_type", idTokenType)
+
+	req, err := d.NewFormRequest(ctx, http.MethodPost, "oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating request")
+	}
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "error performing http request")
+	}
+	defer res.Body.Close()
+
+	if _, err = checkErrorResponse(res); err != nil {
+		return nil, err
+	}
+
+	tokenRes := &OAuthTokenResponse{}
+	if err := json.NewDecoder(res.Body).Decode(tokenRes); err != nil {
+		return nil, errors.Wrap(err, "error decoding token response")
+	}
+
+	if tokenRes.AccessToken == "" {
+		return nil, errors.New("token exchange returned an empty access token")
+	}
+
+	return tokenRes, nil
+}
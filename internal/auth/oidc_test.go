@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExchangeIDToken(t *testing.T) {
+	srv, cleanup := setupServer(func(mux *http.ServeMux) {
+		mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseForm(); err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, tokenExchangeGrantType, r.PostForm.Get("grant_type"))
+			assert.Equal(t, idTokenType, r.PostForm.Get("subject_token_type"))
+			assert.Equal(t, "ci-id-token", r.PostForm.Get("subject_token"))
+			assert.Equal(t, testClientID, r.PostForm.Get("client_id"))
+
+			_, err := w.Write([]byte(`{"access_token": "short-lived", "expires_in": 900}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	})
+	t.Cleanup(cleanup)
+
+	authenticator, err := New(cleanhttp.DefaultClient(), testClientID, testClientSecret, SetBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("error creating client: %s", err.Error())
+	}
+
+	got, err := authenticator.ExchangeIDToken(context.TODO(), "ci-id-token")
+	if err != nil {
+		t.Fatalf("unexpected error exchanging token: %v", err)
+	}
+
+	assert.Equal(t, "short-lived", got.AccessToken)
+	assert.Equal(t, 900, got.ExpiresIn)
+}
+
+func TestCIIdentityToken_GitHubActions(t *testing.T) {
+	srv, cleanup := setupServer(func(mux *http.ServeMux) {
+		mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "bearer request-token", r.Header.Get("Authorization"))
+			assert.Equal(t, "planetscale", r.URL.Query().Get("audience"))
+
+			_, err := w.Write([]byte(`{"value": "github-id-token"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	})
+	t.Cleanup(cleanup)
+
+	setenv(t, "PSCALE_OIDC_TOKEN", "")
+	setenv(t, "ACTIONS_ID_TOKEN_REQUEST_URL", srv.URL+"/token?api-version=2.0")
+	setenv(t, "ACTIONS_ID_TOKEN_REQUEST_TOKEN", "request-token")
+
+	token, provider, err := CIIdentityToken(context.TODO(), cleanhttp.DefaultClient(), DefaultOIDCAudience)
+	if err != nil {
+		t.Fatalf("unexpected error fetching identity token: %v", err)
+	}
+
+	assert.Equal(t, "github-id-token", token)
+	assert.Equal(t, "github-actions", provider)
+}
+
+func setenv(t *testing.T, key, value string) {
+	old, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+// Package certcache caches the CA certificates of database endpoints, so
+// clients can verify an endpoint on systems without a usable CA bundle.
+package certcache
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/planetscale/cli/internal/config"
+)
+
+const ext = ".pem"
+
+// Dir returns the directory of the cached certificates.
+func Dir() (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "cache", "certs"), nil
+}
+
+// Path returns the path of the PEM bundle cached for the host.
+func Path(host string) (string, error) {
+	if host == "" || strings.ContainsAny(host, `/\`) || strings.HasPrefix(host, ".") {
+		return "", fmt.Errorf("invalid host %q", host)
+	}
+
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, host+ext), nil
+}
+
+// Write replaces the bundle of the host with the certificates.
+func Write(host string, certs []*x509.Certificate) (string, error) {
+	p, err := Path(host)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0771); err != nil {
+		return "", err
+	}
+
+	var out []byte
+	for _, c := range certs {
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+	}
+
+	tmp := p + ".tmp"
+	if err := ioutil.WriteFile(tmp, out, 0644); err != nil {
+		return "", err
+	}
+	return p, os.Rename(tmp, p)
+}
+
+// Bundle returns the path of the bundle cached for the host, or an empty
+// string if there's none.
+func Bundle(host string) string {
+	p, err := Path(host)
+	if err != nil {
+		return ""
+	}
+
+	if _, err := os.Stat(p); err != nil {
+		return ""
+	}
+	return p
+}
+
+// Hosts returns the hosts with a cached bundle, sorted by name.
+func Hosts() ([]string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var hosts []string
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), ext) {
+			hosts = append(hosts, strings.TrimSuffix(f.Name(), ext))
+		}
+	}
+
+	sort.Strings(hosts)
+	return hosts, nil
+}
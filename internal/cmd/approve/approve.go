@@ -0,0 +1,127 @@
+package approve
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/planetscale/cli/internal/approval"
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+)
+
+// request returns a table-serializable approval request model.
+type request struct {
+	Action     string `header:"action" json:"action"`
+	Requester  string `header:"requester" json:"requester"`
+	CreatedAt  int64  `header:"created_at,timestamp(ms|utc|human)" json:"created_at"`
+	ExpiresAt  int64  `header:"expires_at,timestamp(ms|utc|human)" json:"expires_at"`
+	Approver   string `header:"approver" json:"approver"`
+	ApprovedAt int64  `header:"approved_at,timestamp(ms|utc|human)" json:"approved_at"`
+}
+
+// ApproveCmd countersigns approval requests of production-affecting commands.
+func ApproveCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		showKey bool
+		force   bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "approve <file>",
+		Short: "Approve a production-affecting command requested by another operator",
+		Long: `Approve a production-affecting command requested by another operator.
+
+If "approval.required" is set for an organization, commands such as
+'pscale deploy-request deploy', 'pscale branch promote' and
+'pscale database delete' write a signed approval request file instead of
+executing. A second operator countersigns the file with 'pscale approve', and
+the requester reruns the command with --approval <file>.
+
+Only the approvers in "approval.approvers", the public keys shown by
+'pscale approve --show-key', are trusted. Without any, no approval is accepted.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := approval.LoadKey()
+			if err != nil {
+				return err
+			}
+
+			if flags.showKey {
+				pub := approval.EncodeKey(key.Public().(ed25519.PublicKey))
+				if ch.Printer.Format() == printer.Human {
+					ch.Printer.Println(pub)
+					return nil
+				}
+				return ch.Printer.PrintResource(map[string]string{"key": pub})
+			}
+
+			if len(args) == 0 {
+				return errors.New("missing argument <file>")
+			}
+			file := args[0]
+
+			req, err := approval.Read(file)
+			if err != nil {
+				return err
+			}
+
+			if !flags.force {
+				if !printer.IsTTY || ch.Printer.Format() != printer.Human {
+					return fmt.Errorf("cannot confirm approval of %q (run with -force to override)", req.Action.String())
+				}
+
+				ch.Printer.Printf("%s requested %s, created at %s.\n", printer.BoldBlue(req.Requester),
+					printer.Bold(req.Action.String()), req.CreatedAt.Format(time.RFC3339))
+
+				confirmed := false
+				prompt := &survey.Confirm{Message: "Approve the request?"}
+				if err := survey.AskOne(prompt, &confirmed); err != nil {
+					return err
+				}
+
+				if !confirmed {
+					return errors.New("the request was not approved")
+				}
+			}
+
+			if err := req.Approve(cmdutil.Operator(), key, time.Now()); err != nil {
+				return fmt.Errorf("can't approve %s: %s", file, err)
+			}
+
+			if err := req.Write(file); err != nil {
+				return err
+			}
+
+			if ch.Printer.Format() == printer.Human {
+				ch.Printer.Printf("Approved %s, send %s back to %s.\n",
+					printer.BoldBlue(req.Action.String()), file, printer.BoldBlue(req.Requester))
+				return nil
+			}
+
+			return ch.Printer.PrintResource(toRequest(req))
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.showKey, "show-key", false, "Show the public key of this machine, to add it to the trusted approvers")
+	cmd.Flags().BoolVar(&flags.force, "force", false, "Approve without confirmation")
+	return cmd
+}
+
+func toRequest(r *approval.Request) *request {
+	req := &request{
+		Action:    r.Action.String(),
+		Requester: r.Requester,
+		CreatedAt: printer.GetMilliseconds(r.CreatedAt),
+		ExpiresAt: printer.GetMilliseconds(r.ExpiresAt),
+		Approver:  r.Approver,
+	}
+	if r.ApprovedAt != nil {
+		req.ApprovedAt = printer.GetMilliseconds(*r.ApprovedAt)
+	}
+	return req
+}
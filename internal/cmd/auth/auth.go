@@ -1,7 +1,13 @@
 package auth
 
 import (
+	"context"
+	"time"
+
+	"github.com/planetscale/cli/internal/auth"
 	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+
 	"github.com/spf13/cobra"
 )
 
@@ -15,5 +21,54 @@ func AuthCmd(ch *cmdutil.Helper) *cobra.Command {
 
 	cmd.AddCommand(LoginCmd(ch))
 	cmd.AddCommand(LogoutCmd(ch))
+	cmd.AddCommand(ScopesCmd(ch))
+	cmd.AddCommand(SwitchCmd(ch))
 	return cmd
 }
+
+// resolveAuthURL returns the Auth API URL passed with --api-url, falling back
+// to the mirror in air-gapped mode.
+func resolveAuthURL(cmd *cobra.Command, ch *cmdutil.Helper, flagValue string) string {
+	if m := ch.Config.Mirror; m != nil && m.AuthURL != "" && !cmd.Flags().Changed("api-url") {
+		return m.AuthURL
+	}
+	return flagValue
+}
+
+// TokenRefresher returns a refresher of the tokens of logged in users.
+func TokenRefresher(cfg *config.Config) config.TokenRefresher {
+	return func(ctx context.Context, t *config.Token) (*config.Token, error) {
+		authURL := t.AuthURL
+		if authURL == "" {
+			authURL = auth.DefaultBaseURL
+			if cfg.Mirror != nil && cfg.Mirror.AuthURL != "" {
+				authURL = cfg.Mirror.AuthURL
+			}
+		}
+
+		authenticator, err := auth.New(cfg.HTTPClient(), auth.OAuthClientID, auth.OAuthClientSecret, auth.SetBaseURL(authURL))
+		if err != nil {
+			return nil, err
+		}
+
+		tokenRes, err := authenticator.RefreshToken(ctx, t.RefreshToken)
+		if err != nil {
+			return nil, err
+		}
+
+		return toToken(tokenRes, authURL), nil
+	}
+}
+
+// toToken returns the token to store for the response of the Auth API.
+func toToken(res *auth.OAuthTokenResponse, authURL string) *config.Token {
+	t := &config.Token{
+		AccessToken:  res.AccessToken,
+		RefreshToken: res.RefreshToken,
+		AuthURL:      authURL,
+	}
+	if res.ExpiresIn > 0 {
+		t.ExpiresAt = time.Now().Add(time.Duration(res.ExpiresIn) * time.Second).UTC()
+	}
+	return t
+}
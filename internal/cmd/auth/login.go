@@ -2,9 +2,10 @@ package auth
 
 import (
 	"context"
-	"io/ioutil"
+	"fmt"
 	"os"
 	"runtime"
+	"time"
 
 	"github.com/planetscale/cli/internal/auth"
 	"github.com/planetscale/cli/internal/cmdutil"
@@ -13,9 +14,9 @@ import (
 	"github.com/planetscale/planetscale-go/planetscale"
 
 	"github.com/fatih/color"
-	"github.com/hashicorp/go-cleanhttp"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
 )
 
 // LoginCmd is the command for logging into a PlanetScale account.
@@ -23,17 +24,30 @@ func LoginCmd(ch *cmdutil.Helper) *cobra.Command {
 	var clientID string
 	var clientSecret string
 	var authURL string
+	var tokenStorage string
+	var oidc bool
+	var oidcAudience string
+	var noBrowser bool
 
 	cmd := &cobra.Command{
 		Use:   "login",
 		Args:  cobra.ExactArgs(0),
 		Short: "Authenticate with the PlanetScale API",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if !printer.IsTTY {
-				return errors.New("The 'login' command requires an interactive shell")
+			authURL := resolveAuthURL(cmd, ch, authURL)
+			if oidc {
+				return loginWithOIDC(cmd.Context(), ch, clientID, clientSecret, authURL, oidcAudience, tokenStorage)
 			}
 
-			authenticator, err := auth.New(cleanhttp.DefaultClient(), clientID, clientSecret, auth.SetBaseURL(authURL))
+			if !noBrowser && headless(os.Getenv, runtime.GOOS) {
+				noBrowser = true
+			}
+
+			if !printer.IsTTY && !noBrowser {
+				return errors.New("The 'login' command requires an interactive shell, run it with --no-browser to log in from another device")
+			}
+
+			authenticator, err := auth.New(ch.Config.HTTPClient(), clientID, clientSecret, auth.SetBaseURL(authURL))
 			if err != nil {
 				return err
 			}
@@ -45,27 +59,40 @@ func LoginCmd(ch *cmdutil.Helper) *cobra.Command {
 				return err
 			}
 
-			openCmd := cmdutil.OpenBrowser(runtime.GOOS, deviceVerification.VerificationCompleteURL)
-			err = openCmd.Run()
-			if err != nil {
-				ch.Printer.Printf("Failed to open a browser: %s\n", printer.BoldRed(err.Error()))
+			if noBrowser {
+				// the user confirms on another device, so the code and the
+				// URL are printed in any output format
+				verifyURL := deviceVerification.VerificationURL
+				if verifyURL == "" {
+					verifyURL = deviceVerification.VerificationCompleteURL
+				}
+
+				fmt.Fprintf(os.Stderr, "To log in, open this URL in a browser on any device:\n\n  %s\n\n", printer.Bold(verifyURL))
+				fmt.Fprintf(os.Stderr, "and enter the confirmation code: %s\n\n", printer.BoldBlue(deviceVerification.UserCode))
+			} else {
+				openCmd := cmdutil.OpenBrowser(runtime.GOOS, deviceVerification.VerificationCompleteURL)
+				err = openCmd.Run()
+				if err != nil {
+					ch.Printer.Printf("Failed to open a browser: %s\n", printer.BoldRed(err.Error()))
+				}
+
+				bold := color.New(color.Bold)
+				bold.Printf("\nConfirmation Code: ")
+				boldGreen := bold.Add(color.FgGreen)
+				boldGreen.Fprintln(color.Output, deviceVerification.UserCode)
+
+				ch.Printer.Printf("\nIf something goes wrong, copy and paste this URL into your browser: %s\n\n", printer.Bold(deviceVerification.VerificationCompleteURL))
 			}
 
-			bold := color.New(color.Bold)
-			bold.Printf("\nConfirmation Code: ")
-			boldGreen := bold.Add(color.FgGreen)
-			boldGreen.Fprintln(color.Output, deviceVerification.UserCode)
-
-			ch.Printer.Printf("\nIf something goes wrong, copy and paste this URL into your browser: %s\n\n", printer.Bold(deviceVerification.VerificationCompleteURL))
-
 			end := ch.Printer.PrintProgress("Waiting for confirmation...")
 			defer end()
-			accessToken, err := authenticator.GetAccessTokenForDevice(ctx, deviceVerification)
+			tokenRes, err := authenticator.GetTokenForDevice(ctx, deviceVerification)
 			if err != nil {
 				return err
 			}
+			accessToken := tokenRes.AccessToken
 
-			err = writeAccessToken(ctx, accessToken)
+			err = writeAccessToken(ctx, tokenStorage, toToken(tokenRes, authURL))
 			if err != nil {
 				return errors.Wrap(err, "error logging in")
 			}
@@ -75,7 +102,7 @@ func LoginCmd(ch *cmdutil.Helper) *cobra.Command {
 			end()
 			ch.Printer.Println("Successfully logged in.")
 
-			err = writeDefaultOrganization(ctx, accessToken, authURL)
+			err = writeDefaultOrganization(ctx, ch, accessToken, authURL)
 			if err != nil {
 				return err
 			}
@@ -87,11 +114,72 @@ func LoginCmd(ch *cmdutil.Helper) *cobra.Command {
 	cmd.Flags().StringVar(&clientID, "client-id", auth.OAuthClientID, "The client ID for the PlanetScale CLI application.")
 	cmd.Flags().StringVar(&clientSecret, "client-secret", auth.OAuthClientSecret, "The client ID for the PlanetScale CLI application")
 	cmd.Flags().StringVar(&authURL, "api-url", auth.DefaultBaseURL, "The PlanetScale Auth API base URL.")
+	cmd.Flags().StringVar(&tokenStorage, "token-storage", "",
+		"Where to store the access token. Possible values: [file, hardware, encrypted]. The hardware storage seals the token with the TPM2 chip (Linux) or the Keychain (macOS). The encrypted storage encrypts it with a passphrase, read from PSCALE_KEYRING_PASSPHRASE or prompted for.")
+
+	cmd.Flags().BoolVar(&oidc, "oidc", false,
+		"Exchange the OIDC identity token of the CI environment (GitHub Actions, GitLab CI) for a short-lived access token.")
+	cmd.Flags().StringVar(&oidcAudience, "oidc-audience", auth.DefaultOIDCAudience, "The audience to request for the OIDC identity token.")
+	cmd.Flags().BoolVar(&noBrowser, "no-browser", false,
+		"Don't open a browser, print the URL and confirmation code to log in from another device instead. This is the default over SSH and without a display.")
 
 	return cmd
 }
 
-func writeDefaultOrganization(ctx context.Context, accessToken, authURL string) error {
+// headless reports whether there's no browser to open, i.e. when logged in
+// over SSH or on a Linux machine without a display.
+func headless(getenv func(string) string, goos string) bool {
+	if getenv("SSH_CONNECTION") != "" || getenv("SSH_TTY") != "" {
+		return true
+	}
+
+	switch goos {
+	case "darwin", "windows":
+		return false
+	}
+
+	return getenv("DISPLAY") == "" && getenv("WAYLAND_DISPLAY") == ""
+}
+
+// loginWithOIDC logs in non-interactively by exchanging the CI provider's
+// identity token for a short-lived access token.
+func loginWithOIDC(ctx context.Context, ch *cmdutil.Helper, clientID, clientSecret, authURL, audience, tokenStorage string) error {
+	httpClient := ch.Config.HTTPClient()
+
+	idToken, provider, err := auth.CIIdentityToken(ctx, httpClient, audience)
+	if err != nil {
+		return err
+	}
+
+	authenticator, err := auth.New(httpClient, clientID, clientSecret, auth.SetBaseURL(authURL))
+	if err != nil {
+		return err
+	}
+
+	end := ch.Printer.PrintProgress(fmt.Sprintf("Exchanging %s identity token...", provider))
+	defer end()
+
+	tokenRes, err := authenticator.ExchangeIDToken(ctx, idToken)
+	if err != nil {
+		return errors.Wrap(err, "error exchanging OIDC identity token")
+	}
+
+	if err := writeAccessToken(ctx, tokenStorage, toToken(tokenRes, authURL)); err != nil {
+		return errors.Wrap(err, "error logging in")
+	}
+	end()
+
+	if tokenRes.ExpiresIn > 0 {
+		ch.Printer.Printf("Successfully logged in via %s OIDC (token expires in %s).\n",
+			provider, time.Duration(tokenRes.ExpiresIn)*time.Second)
+	} else {
+		ch.Printer.Printf("Successfully logged in via %s OIDC.\n", provider)
+	}
+
+	return writeDefaultOrganization(ctx, ch, tokenRes.AccessToken, authURL)
+}
+
+func writeDefaultOrganization(ctx context.Context, ch *cmdutil.Helper, accessToken, authURL string) error {
 	// After successfully logging in, attempt to set the org by default.
 	client, err := planetscale.NewClient(
 		planetscale.WithAccessToken(accessToken),
@@ -106,45 +194,47 @@ func writeDefaultOrganization(ctx context.Context, accessToken, authURL string)
 		return cmdutil.HandleError(err)
 	}
 
-	if len(orgs) > 0 {
-		defaultOrg := orgs[0].Name
-		writableConfig := &config.FileConfig{
-			Organization: defaultOrg,
-		}
+	if len(orgs) == 0 {
+		return nil
+	}
 
-		err := writableConfig.WriteDefault()
-		if err != nil {
-			return err
+	configFile, err := config.DefaultConfigPath()
+	if err != nil {
+		return err
+	}
+
+	// only the organization is set, the other values of the file, i.e. the
+	// defaults of organizations and the other profiles, are kept
+	defaultOrg := orgs[0].Name
+	if profile := config.ActiveProfile(); profile != "" {
+		// logging into a profile only sets its organization if it has none
+		if fileCfg, err := ch.ConfigFS.DefaultConfig(); err == nil {
+			if p := fileCfg.Profile(profile); p != nil && p.Organization != "" {
+				return nil
+			}
 		}
+		return config.SetValues(configFile, yaml.MapSlice{{Key: "profiles." + profile + ".org", Value: defaultOrg}})
 	}
 
-	return nil
+	return config.SetValues(configFile, yaml.MapSlice{{Key: "org", Value: defaultOrg}})
 }
 
-func writeAccessToken(ctx context.Context, accessToken string) error {
-	configDir, err := config.ConfigDir()
-	if err != nil {
-		return err
+func writeAccessToken(ctx context.Context, storage string, token *config.Token) error {
+	if storage == "" {
+		storage = os.Getenv("PSCALE_TOKEN_STORAGE")
 	}
 
-	_, err = os.Stat(configDir)
-	if os.IsNotExist(err) {
-		err := os.MkdirAll(configDir, 0771)
-		if err != nil {
-			return errors.Wrap(err, "error creating config directory")
-		}
-	} else if err != nil {
+	store, err := config.OpenTokenStore(storage)
+	if err != nil {
 		return err
 	}
 
-	tokenPath, err := config.AccessTokenPath()
+	encoded, err := token.Encode()
 	if err != nil {
 		return err
 	}
 
-	tokenBytes := []byte(accessToken)
-	err = ioutil.WriteFile(tokenPath, tokenBytes, config.TokenFileMode)
-	if err != nil {
+	if err := store.Write(encoded); err != nil {
 		return errors.Wrap(err, "error writing token")
 	}
 
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestHeadless(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		goos string
+		want bool
+	}{
+		{name: "ssh", env: map[string]string{"SSH_CONNECTION": "10.0.0.1 22 10.0.0.2 22", "DISPLAY": ":0"}, goos: "linux", want: true},
+		{name: "ssh on macos", env: map[string]string{"SSH_TTY": "/dev/ttys001"}, goos: "darwin", want: true},
+		{name: "linux desktop", env: map[string]string{"DISPLAY": ":0"}, goos: "linux", want: false},
+		{name: "wayland", env: map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, goos: "linux", want: false},
+		{name: "linux container", env: map[string]string{}, goos: "linux", want: true},
+		{name: "macos", env: map[string]string{}, goos: "darwin", want: false},
+		{name: "windows", env: map[string]string{}, goos: "windows", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+			getenv := func(key string) string { return tt.env[key] }
+			c.Assert(headless(getenv, tt.goos), qt.Equals, tt.want)
+		})
+	}
+}
+
+func TestWriteDefaultOrganization(t *testing.T) {
+	c := qt.New(t)
+
+	testutil.TempHome(t)
+	c.Cleanup(func() { config.SetProfile("") }) // nolint:errcheck
+
+	srv, closeSrv := testutil.SetupServer(func(mux *http.ServeMux) {
+		mux.HandleFunc("/v1/organizations", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"data": [{"name": "acme"}]}`)
+		})
+	})
+	defer closeSrv()
+
+	configFile, err := config.DefaultConfigPath()
+	c.Assert(err, qt.IsNil)
+	c.Assert(os.MkdirAll(filepath.Dir(configFile), 0771), qt.IsNil)
+	existing := "org: old\norgs:\n  old:\n    region: eu-west\nmirror:\n  api-url: https://mirror.internal\nprofiles:\n  work:\n    org: bigcorp\n"
+	c.Assert(ioutil.WriteFile(configFile, []byte(existing), 0644), qt.IsNil)
+
+	ch := &cmdutil.Helper{ConfigFS: config.NewConfigFS(testutil.OSFS{})}
+
+	// only the organization changes
+	c.Assert(writeDefaultOrganization(context.Background(), ch, "token", srv.URL), qt.IsNil)
+	out, err := ioutil.ReadFile(configFile)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, "org: acme\norgs:\n  old:\n    region: eu-west\nmirror:\n  api-url: https://mirror.internal\nprofiles:\n  work:\n    org: bigcorp\n")
+
+	// profiles keep their organization, new ones get the default
+	c.Assert(config.SetProfile("work"), qt.IsNil)
+	c.Assert(writeDefaultOrganization(context.Background(), ch, "token", srv.URL), qt.IsNil)
+	c.Assert(config.SetProfile("home"), qt.IsNil)
+	c.Assert(writeDefaultOrganization(context.Background(), ch, "token", srv.URL), qt.IsNil)
+
+	out, err = ioutil.ReadFile(configFile)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, "org: acme\norgs:\n  old:\n    region: eu-west\nmirror:\n  api-url: https://mirror.internal\nprofiles:\n  work:\n    org: bigcorp\n  home:\n    org: acme\n")
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/planetscale/cli/internal/config"
 	"github.com/planetscale/cli/internal/printer"
 
-	"github.com/hashicorp/go-cleanhttp"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -25,6 +24,9 @@ func LogoutCmd(ch *cmdutil.Helper) *cobra.Command {
 		Args:  cobra.NoArgs,
 		Short: "Log out of the PlanetScale API",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ch.Config.LoadToken(); err != nil {
+				return err
+			}
 			if ch.Config.AccessToken == "" {
 				ch.Printer.Println("Already logged out. Exiting...")
 				return nil
@@ -35,7 +37,8 @@ func LogoutCmd(ch *cmdutil.Helper) *cobra.Command {
 				_ = waitForEnter(cmd.InOrStdin())
 			}
 
-			authenticator, err := auth.New(cleanhttp.DefaultClient(), clientID, clientSecret, auth.SetBaseURL(apiURL))
+			authenticator, err := auth.New(ch.Config.HTTPClient(), clientID, clientSecret,
+				auth.SetBaseURL(resolveAuthURL(cmd, ch, apiURL)))
 			if err != nil {
 				return err
 			}
@@ -47,7 +50,7 @@ func LogoutCmd(ch *cmdutil.Helper) *cobra.Command {
 			if err != nil {
 				return err
 			}
-			err = deleteAccessToken()
+			err = deleteAccessToken(ch)
 			if err != nil {
 				return err
 			}
@@ -64,17 +67,19 @@ func LogoutCmd(ch *cmdutil.Helper) *cobra.Command {
 	return cmd
 }
 
-func deleteAccessToken() error {
-	tokenPath, err := config.AccessTokenPath()
+func deleteAccessToken(ch *cmdutil.Helper) error {
+	store, err := config.NewTokenStore()
 	if err != nil {
 		return err
 	}
 
-	err = os.Remove(tokenPath)
-	if err != nil {
-		if !os.IsNotExist(err) {
-			return errors.Wrap(err, "error removing access token file")
-		}
+	if err := store.Delete(); err != nil {
+		return errors.Wrap(err, "error removing access token")
+	}
+
+	// the config file holds the other profiles too
+	if fileCfg, err := ch.ConfigFS.DefaultConfig(); err == nil && len(fileCfg.Profiles) != 0 {
+		return nil
 	}
 
 	configFile, err := config.DefaultConfigPath()
@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+// commandAccesses are the command groups of the CLI with the service token
+// accesses they need. Commands are separated by slashes, as the table joins
+// the groups with commas.
+var commandAccesses = []struct {
+	commands string
+	accesses []string
+}{
+	{commands: "database list/show", accesses: []string{"read_database"}},
+	{commands: "database delete", accesses: []string{"delete_database"}},
+	{commands: "branch list/show/schema/diff", accesses: []string{"read_branch"}},
+	{commands: "branch create", accesses: []string{"create_branch"}},
+	{commands: "branch delete", accesses: []string{"delete_branch"}},
+	{commands: "branch delete (production)", accesses: []string{"delete_production_branch"}},
+	{commands: "connect/shell", accesses: []string{"connect_branch"}},
+	{commands: "connect/shell (production)", accesses: []string{"connect_production_branch"}},
+	{commands: "deploy-request list/show/diff", accesses: []string{"read_deploy_request"}},
+	{commands: "deploy-request create/close", accesses: []string{"create_deploy_request"}},
+	{commands: "deploy-request review --approve", accesses: []string{"approve_deploy_request"}},
+	{commands: "deploy-request review --comment", accesses: []string{"create_comment"}},
+	{commands: "backup list/show", accesses: []string{"read_backups"}},
+	{commands: "backup create", accesses: []string{"write_backups"}},
+	{commands: "backup delete", accesses: []string{"delete_backups"}},
+	{commands: "backup restore", accesses: []string{"restore_backup"}},
+}
+
+// Scope is the accesses of the current credential to a database, with the
+// command groups they unlock.
+type Scope struct {
+	Database string   `header:"database" json:"database"`
+	Accesses []string `header:"accesses" json:"accesses"`
+	Commands []string `header:"commands,none" json:"commands"`
+}
+
+// ScopesCmd shows the accesses of the current credential.
+func ScopesCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "scopes",
+		Short: "Show the accesses of the current credential and the commands they unlock",
+		Long: `Show the accesses of the current credential and the commands they unlock.
+
+Service tokens only have the accesses granted with 'pscale service-token
+add-access'. The accesses are shown by database, with the command groups of
+the CLI they unlock, to help creating service tokens with just the accesses a
+script needs. Logged in users have the access of their role in the
+organization instead.`,
+		Args:              cobra.NoArgs,
+		PersistentPreRunE: cmdutil.CheckAuthentication(ch.Config),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			// the client resolves the service token of credential sources
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			if ch.Config.ServiceTokenID == "" {
+				if ch.Printer.Format() == printer.Human {
+					ch.Printer.Printf("You're logged in as a user, with the access of your role in organization %s. Scopes only apply to service tokens.\n",
+						printer.BoldBlue(ch.Config.Organization))
+					return nil
+				}
+
+				return ch.Printer.PrintResource(map[string]string{
+					"credential":   "user",
+					"organization": ch.Config.Organization,
+				})
+			}
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Fetching the accesses of service token %s", printer.BoldBlue(ch.Config.ServiceTokenID)))
+			defer end()
+
+			accesses, err := client.ServiceTokens.GetAccess(ctx, &ps.GetServiceTokenAccessRequest{
+				Organization: ch.Config.Organization,
+				ID:           ch.Config.ServiceTokenID,
+			})
+			if err != nil {
+				switch cmdutil.ErrCode(err) {
+				case ps.ErrNotFound:
+					return fmt.Errorf("can't read the accesses of service token %s in organization %s, it may lack the read_service_tokens access",
+						printer.BoldBlue(ch.Config.ServiceTokenID), printer.BoldBlue(ch.Config.Organization))
+				default:
+					return cmdutil.HandleError(err)
+				}
+			}
+
+			end()
+
+			scopes := toScopes(accesses)
+			if len(scopes) == 0 && ch.Printer.Format() == printer.Human {
+				ch.Printer.Printf("Service token %s has no accesses in organization %s.\n",
+					printer.BoldBlue(ch.Config.ServiceTokenID), printer.BoldBlue(ch.Config.Organization))
+				return nil
+			}
+
+			return ch.Printer.PrintResource(scopes)
+		},
+	}
+
+	cmd.Flags().StringVar(&ch.Config.Organization, "org", ch.Config.Organization, "The organization for the current user")
+
+	return cmd
+}
+
+// toScopes groups the accesses by database, sorted by database name.
+func toScopes(accesses []*ps.ServiceTokenAccess) []*Scope {
+	byDatabase := make(map[string]*Scope)
+	for _, a := range accesses {
+		s, ok := byDatabase[a.Resource.Name]
+		if !ok {
+			s = &Scope{Database: a.Resource.Name}
+			byDatabase[a.Resource.Name] = s
+		}
+		s.Accesses = append(s.Accesses, a.Access)
+	}
+
+	scopes := make([]*Scope, 0, len(byDatabase))
+	for _, s := range byDatabase {
+		sort.Strings(s.Accesses)
+		s.Commands = unlockedCommands(s.Accesses)
+		scopes = append(scopes, s)
+	}
+
+	sort.Slice(scopes, func(i, j int) bool { return scopes[i].Database < scopes[j].Database })
+	return scopes
+}
+
+// unlockedCommands returns the command groups the accesses unlock.
+func unlockedCommands(accesses []string) []string {
+	granted := make(map[string]bool, len(accesses))
+	for _, a := range accesses {
+		granted[a] = true
+	}
+
+	commands := []string{}
+	for _, c := range commandAccesses {
+		unlocked := true
+		for _, a := range c.accesses {
+			unlocked = unlocked && granted[a]
+		}
+		if unlocked {
+			commands = append(commands, c.commands)
+		}
+	}
+	return commands
+}
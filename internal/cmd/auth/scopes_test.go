@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/printer"
+
+	qt "github.com/frankban/quicktest"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+func TestScopesCmd(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	svc := &mock.ServiceTokenService{
+		GetAccessFn: func(ctx context.Context, req *ps.GetServiceTokenAccessRequest) ([]*ps.ServiceTokenAccess, error) {
+			c.Assert(req.Organization, qt.Equals, "planetscale")
+			c.Assert(req.ID, qt.Equals, "token-id")
+
+			return []*ps.ServiceTokenAccess{
+				{Access: "read_branch", Resource: ps.Database{Name: "mydb"}},
+				{Access: "connect_branch", Resource: ps.Database{Name: "mydb"}},
+				{Access: "read_deploy_request", Resource: ps.Database{Name: "analytics"}},
+			}, nil
+		},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config: &config.Config{
+			Organization:   "planetscale",
+			ServiceTokenID: "token-id",
+			ServiceToken:   "secret",
+		},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{
+				ServiceTokens: svc,
+			}, nil
+		},
+	}
+
+	cmd := ScopesCmd(ch)
+	cmd.SetArgs([]string{})
+	err := cmd.Execute()
+
+	c.Assert(err, qt.IsNil)
+	c.Assert(svc.GetAccessFnInvoked, qt.IsTrue)
+	c.Assert(buf.String(), qt.JSONEquals, []*Scope{
+		{
+			Database: "analytics",
+			Accesses: []string{"read_deploy_request"},
+			Commands: []string{"deploy-request list/show/diff"},
+		},
+		{
+			Database: "mydb",
+			Accesses: []string{"connect_branch", "read_branch"},
+			Commands: []string{"branch list/show/schema/diff", "connect/shell"},
+		},
+	})
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// SwitchCmd changes the current profile.
+func SwitchCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "switch <profile>",
+		Short: "Switch the profile used by default",
+		Long: `Switch the profile used by default.
+
+Profiles are defined in the "profiles" key of the global config file. Each
+profile has its own credentials, organization and API URL. Log into a new
+profile with 'pscale auth login --profile <profile>'.`,
+		Args: cmdutil.RequiredArgs("profile"),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			fileCfg, err := ch.ConfigFS.DefaultConfig()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			return fileCfg.ProfileNames(), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			fileCfg, err := ch.ConfigFS.DefaultConfig()
+			if err != nil {
+				return fmt.Errorf("profile %s is not defined, log into it with 'pscale auth login --profile %s'",
+					printer.BoldBlue(name), name)
+			}
+
+			if fileCfg.Profile(name) == nil {
+				msg := fmt.Sprintf("profile %s is not defined", printer.BoldBlue(name))
+				if names := fileCfg.ProfileNames(); len(names) != 0 {
+					msg += fmt.Sprintf(", available profiles: %s", strings.Join(names, ", "))
+				}
+				return errors.New(msg)
+			}
+
+			configFile, err := config.DefaultConfigPath()
+			if err != nil {
+				return err
+			}
+
+			// the other values of the file are kept as they are
+			if err := config.SetValues(configFile, yaml.MapSlice{{Key: "current-profile", Value: name}}); err != nil {
+				return err
+			}
+
+			ch.Printer.Printf("Switched to profile %s.\n", printer.BoldBlue(name))
+			return nil
+		},
+	}
+
+	return cmd
+}
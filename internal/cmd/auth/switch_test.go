@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestAuth_SwitchCmd(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	path, err := config.DefaultConfigPath()
+	c.Assert(err, qt.IsNil)
+	c.Assert(os.MkdirAll(filepath.Dir(path), 0771), qt.IsNil)
+	c.Assert(ioutil.WriteFile(path, []byte(`org: personal
+profiles:
+  work:
+    org: acme
+    api-url: https://api.acme.example/
+  personal:
+    org: personal
+mirror:
+  api-url: https://mirror.internal
+`), 0644), qt.IsNil)
+
+	var buf bytes.Buffer
+	format := printer.Human
+	p := printer.NewPrinter(&format)
+	p.SetHumanOutput(&buf)
+
+	ch := &cmdutil.Helper{
+		Printer:  p,
+		Config:   &config.Config{},
+		ConfigFS: config.NewConfigFS(testutil.OSFS{}),
+	}
+
+	cmd := SwitchCmd(ch)
+	cmd.SetArgs([]string{"work"})
+	err = cmd.Execute()
+	c.Assert(err, qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "Switched to profile work.\n")
+
+	fileCfg, err := ch.ConfigFS.DefaultConfig()
+	c.Assert(err, qt.IsNil)
+	c.Assert(fileCfg.CurrentProfile, qt.Equals, "work")
+	c.Assert(fileCfg.Organization, qt.Equals, "personal")
+	c.Assert(fileCfg.Profile("work"), qt.DeepEquals, &config.Profile{
+		Organization: "acme",
+		BaseURL:      "https://api.acme.example/",
+	})
+
+	// keys that FileConfig doesn't know about are kept
+	out, err := ioutil.ReadFile(path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Contains, "mirror:\n  api-url: https://mirror.internal\n")
+
+	cmd = SwitchCmd(ch)
+	cmd.SetArgs([]string{"school"})
+	err = cmd.Execute()
+	c.Assert(err, qt.ErrorMatches, "profile school is not defined, available profiles: personal, work")
+}
@@ -13,10 +13,11 @@ import (
 func CreateCmd(ch *cmdutil.Helper) *cobra.Command {
 	createReq := &ps.CreateBackupRequest{}
 	cmd := &cobra.Command{
-		Use:     "create <database> <branch>",
-		Short:   "Backup a branch's data and schema",
-		Args:    cmdutil.RequiredArgs("database", "branch"),
-		Aliases: []string{"b"},
+		Use:               "create <database> <branch>",
+		Short:             "Backup a branch's data and schema",
+		Args:              cmdutil.RequiredArgs("database", "branch"),
+		ValidArgsFunction: cmdutil.BranchCompletion(ch),
+		Aliases:           []string{"b"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			database := args[0]
@@ -47,7 +48,7 @@ func CreateCmd(ch *cmdutil.Helper) *cobra.Command {
 
 			end()
 
-			if ch.Printer.Format() == printer.Human {
+			if ch.Printer.Format() == printer.Human && !ch.Printer.Quiet() {
 				ch.Printer.Printf("Backup %s was successfully created.\n", printer.BoldBlue(bkp.Name))
 				return nil
 			}
@@ -19,10 +19,11 @@ func DeleteCmd(ch *cmdutil.Helper) *cobra.Command {
 	var force bool
 
 	cmd := &cobra.Command{
-		Use:     "delete <database> <branch> <backup>",
-		Short:   "Delete a branch backup",
-		Args:    cmdutil.RequiredArgs("database", "branch", "backup"),
-		Aliases: []string{"rm"},
+		Use:               "delete <database> <branch> <backup>",
+		Short:             "Delete a branch backup",
+		Args:              cmdutil.RequiredArgs("database", "branch", "backup"),
+		ValidArgsFunction: cmdutil.BranchCompletion(ch),
+		Aliases:           []string{"rm"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			database := args[0]
@@ -34,7 +35,7 @@ func DeleteCmd(ch *cmdutil.Helper) *cobra.Command {
 				return err
 			}
 
-			if !force {
+			if !force && !cmdutil.AutoApproved(ctx, ch, client, database, branch) {
 				if ch.Printer.Format() != printer.Human {
 					return fmt.Errorf("cannot delete backup with the output format %q (run with -force to override)", ch.Printer.Format())
 				}
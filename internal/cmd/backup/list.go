@@ -14,10 +14,11 @@ import (
 // ListCmd encapsulates the command for listing backups for a branch.
 func ListCmd(ch *cmdutil.Helper) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "list <database> <branch>",
-		Short:   "List all backups of a branch",
-		Args:    cmdutil.RequiredArgs("database", "branch"),
-		Aliases: []string{"ls"},
+		Use:               "list <database> <branch>",
+		Short:             "List all backups of a branch",
+		Args:              cmdutil.RequiredArgs("database", "branch"),
+		ValidArgsFunction: cmdutil.BranchCompletion(ch),
+		Aliases:           []string{"ls"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			database := args[0]
@@ -14,9 +14,10 @@ import (
 
 func ShowCmd(ch *cmdutil.Helper) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "show <database> <branch> <backup>",
-		Short: "Show a specific backup of a branch",
-		Args:  cmdutil.RequiredArgs("database", "branch", "backup"),
+		Use:               "show <database> <branch> <backup>",
+		Short:             "Show a specific backup of a branch",
+		Args:              cmdutil.RequiredArgs("database", "branch", "backup"),
+		ValidArgsFunction: cmdutil.BranchCompletion(ch),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			database := args[0]
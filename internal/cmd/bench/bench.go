@@ -0,0 +1,24 @@
+package bench
+
+import (
+	"github.com/planetscale/cli/internal/cmdutil"
+
+	"github.com/spf13/cobra"
+)
+
+// BenchCmd encapsulates the commands for load testing a branch.
+func BenchCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "bench <command>",
+		Short:             "Load test a branch before a launch",
+		PersistentPreRunE: cmdutil.CheckAuthentication(ch.Config),
+	}
+
+	cmd.PersistentFlags().StringVar(&ch.Config.Organization, "org", ch.Config.Organization,
+		"The organization for the current user")
+	cmd.MarkPersistentFlagRequired("org") // nolint:errcheck
+
+	cmd.AddCommand(ConnectionsCmd(ch))
+
+	return cmd
+}
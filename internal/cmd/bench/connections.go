@@ -0,0 +1,290 @@
+package bench
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+// conn is a connection held open by the benchmark.
+type conn interface {
+	PingContext(ctx context.Context) error
+	Close() error
+}
+
+// dialer opens a connection to the branch.
+type dialer func(ctx context.Context) (conn, error)
+
+// ConnectionsCmd opens and holds many connections to a branch to validate the
+// headroom of its maximum connections.
+func ConnectionsCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		target         int
+		ramp           time.Duration
+		hold           time.Duration
+		connectTimeout time.Duration
+		direct         bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "connections <database> <branch>",
+		Short: "Open and hold many connections to a branch",
+		Long: `Open and hold many connections to a branch.
+
+The --target connections are opened evenly over --ramp, through a local tunnel
+by default or directly to the branch with a temporary password with --direct,
+and held for --hold. The connections that fail to open and the ones dropped
+while they were held are reported with the connect latencies, to validate the
+headroom of the maximum connections before a launch.`,
+		Args: cmdutil.RequiredArgs("database", "branch"),
+		Example: `Open 500 connections over a minute and hold them for 30 seconds:
+
+  pscale bench connections mydb main --target 500 --ramp 60s`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+
+			if flags.target < 1 {
+				return errors.New("--target must be at least 1")
+			}
+
+			var dial dialer
+			if flags.direct {
+				db, cleanup, err := openDirect(ctx, ch, database, branch)
+				if err != nil {
+					return err
+				}
+				defer cleanup()
+				dial = sqlDialer(db)
+			} else {
+				db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.ReaderRole)
+				if err != nil {
+					return err
+				}
+				defer closeDB()
+				dial = sqlDialer(db)
+			}
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Opening %d connections to %s over %s",
+				flags.target, printer.BoldBlue(branch), flags.ramp))
+			res := holdConnections(ctx, dial, flags.target, flags.ramp, flags.hold, flags.connectTimeout)
+			end()
+
+			return ch.Printer.PrintResource(res)
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.target, "target", 100, "The number of connections to open")
+	cmd.Flags().DurationVar(&flags.ramp, "ramp", 30*time.Second, "The duration over which the connections are opened")
+	cmd.Flags().DurationVar(&flags.hold, "hold", 30*time.Second, "How long the connections are held once opened")
+	cmd.Flags().DurationVar(&flags.connectTimeout, "connect-timeout", 10*time.Second, "How long a connection may take to open")
+	cmd.Flags().BoolVar(&flags.direct, "direct", false, "Connect directly with a temporary password instead of through a tunnel")
+
+	return cmd
+}
+
+// sqlDialer opens dedicated connections of the pool, which grows with every
+// connection held.
+func sqlDialer(db *sql.DB) dialer {
+	db.SetMaxOpenConns(0)
+	db.SetMaxIdleConns(0)
+	return func(ctx context.Context) (conn, error) {
+		c, err := db.Conn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.PingContext(ctx); err != nil {
+			c.Close()
+			return nil, err
+		}
+		return c, nil
+	}
+}
+
+// openDirect creates a temporary password for the branch and connects to its
+// host with it. The password is deleted with the returned function.
+func openDirect(ctx context.Context, ch *cmdutil.Helper, database, branch string) (*sql.DB, func(), error) {
+	client, err := ch.Client()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	name, err := cmdutil.ResourceName(ch, "password", "", database)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pass, err := client.Passwords.Create(ctx, &ps.DatabaseBranchPasswordRequest{
+		Organization: ch.Config.Organization,
+		Database:     database,
+		Branch:       branch,
+		Role:         cmdutil.ReaderRole.ToString(),
+		DisplayName:  name,
+	})
+	if err != nil {
+		switch cmdutil.ErrCode(err) {
+		case ps.ErrNotFound:
+			return nil, nil, fmt.Errorf("branch %s does not exist in database %s (organization: %s)",
+				printer.BoldBlue(branch), printer.BoldBlue(database), printer.BoldBlue(ch.Config.Organization))
+		default:
+			return nil, nil, cmdutil.HandleError(err)
+		}
+	}
+
+	deletePassword := func() {
+		// the password is deleted even if the benchmark was interrupted
+		err := client.Passwords.Delete(context.Background(), &ps.DeleteDatabaseBranchPasswordRequest{
+			Organization: ch.Config.Organization,
+			Database:     database,
+			Branch:       branch,
+			PasswordId:   pass.PublicID,
+		})
+		if err != nil {
+			ch.Printer.Printf("%s can't delete the temporary password %s: %s\n",
+				printer.BoldRed("Warning:"), printer.BoldBlue(pass.Name), err)
+		}
+	}
+
+	addr := net.JoinHostPort(pass.Branch.AccessHostURL, cmdutil.MySQLPort)
+	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s)/%s?tls=true", pass.PublicID, pass.PlainText, addr, database))
+	if err != nil {
+		deletePassword()
+		return nil, nil, err
+	}
+
+	return db, func() {
+		db.Close()
+		deletePassword()
+	}, nil
+}
+
+// connectionsResult is the outcome of holding the connections. The latencies
+// are in milliseconds.
+type connectionsResult struct {
+	Target  int     `header:"target" json:"target"`
+	Opened  int     `header:"opened" json:"opened"`
+	Failed  int     `header:"failed" json:"failed"`
+	Dropped int     `header:"dropped" json:"dropped"`
+	P50     float64 `header:"p50 (ms)" json:"connect_p50_ms"`
+	P95     float64 `header:"p95 (ms)" json:"connect_p95_ms"`
+	P99     float64 `header:"p99 (ms)" json:"connect_p99_ms"`
+	Max     float64 `header:"max (ms)" json:"connect_max_ms"`
+	Errors  string  `header:"errors,n/a" json:"-"`
+
+	ErrorCounts []*errorCount `json:"errors" csv:"-"`
+}
+
+// errorCount is the number of connections that failed with an error.
+type errorCount struct {
+	Error string `json:"error"`
+	Count int    `json:"count"`
+}
+
+// holdConnections opens the connections evenly over the ramp, holds the ones
+// that opened for the given duration and closes them. The connections that
+// fail a ping at the end of the hold are counted as dropped.
+func holdConnections(ctx context.Context, dial dialer, target int, ramp, hold, connectTimeout time.Duration) *connectionsResult {
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		conns     []conn
+		latencies []float64
+		errs      = make(map[string]int)
+	)
+
+	interval := ramp / time.Duration(target)
+	for i := 0; i < target; i++ {
+		if i > 0 && interval > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(interval):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			dialCtx, cancel := context.WithTimeout(ctx, connectTimeout)
+			defer cancel()
+
+			start := time.Now()
+			c, err := dial(dialCtx)
+			took := time.Since(start)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[err.Error()]++
+				return
+			}
+			conns = append(conns, c)
+			latencies = append(latencies, float64(took)/float64(time.Millisecond))
+		}()
+	}
+	wg.Wait()
+
+	if hold > 0 {
+		select {
+		case <-ctx.Done():
+		case <-time.After(hold):
+		}
+	}
+
+	res := &connectionsResult{Target: target, Opened: len(conns), ErrorCounts: []*errorCount{}}
+	for _, c := range conns {
+		pingCtx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+		if err := c.PingContext(pingCtx); err != nil {
+			res.Dropped++
+		}
+		cancel()
+		c.Close()
+	}
+
+	for msg, n := range errs {
+		res.Failed += n
+		res.ErrorCounts = append(res.ErrorCounts, &errorCount{Error: msg, Count: n})
+	}
+	sort.Slice(res.ErrorCounts, func(i, j int) bool {
+		if res.ErrorCounts[i].Count != res.ErrorCounts[j].Count {
+			return res.ErrorCounts[i].Count > res.ErrorCounts[j].Count
+		}
+		return res.ErrorCounts[i].Error < res.ErrorCounts[j].Error
+	})
+	summary := make([]string, 0, len(res.ErrorCounts))
+	for _, e := range res.ErrorCounts {
+		summary = append(summary, fmt.Sprintf("%s (%d)", e.Error, e.Count))
+	}
+	res.Errors = strings.Join(summary, ", ")
+
+	if len(latencies) > 0 {
+		sort.Float64s(latencies)
+		res.P50 = round(cmdutil.Percentile(latencies, 50))
+		res.P95 = round(cmdutil.Percentile(latencies, 95))
+		res.P99 = round(cmdutil.Percentile(latencies, 99))
+		res.Max = round(latencies[len(latencies)-1])
+	}
+	return res
+}
+
+// round rounds milliseconds to the microsecond.
+func round(ms float64) float64 {
+	return math.Round(ms*1000) / 1000
+}
@@ -0,0 +1,67 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+type fakeConn struct {
+	dropped bool
+	closed  bool
+}
+
+func (f *fakeConn) PingContext(ctx context.Context) error {
+	if f.dropped {
+		return errors.New("connection reset")
+	}
+	return nil
+}
+
+func (f *fakeConn) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestHoldConnections(t *testing.T) {
+	c := qt.New(t)
+
+	var mu sync.Mutex
+	var conns []*fakeConn
+	dialed := 0
+	dial := func(ctx context.Context) (conn, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		dialed++
+		switch {
+		case dialed > 8:
+			return nil, errors.New("too many connections")
+		case dialed == 8:
+			return nil, errors.New("i/o timeout")
+		}
+		f := &fakeConn{dropped: dialed == 1}
+		conns = append(conns, f)
+		return f, nil
+	}
+
+	res := holdConnections(context.Background(), dial, 10, 10*time.Millisecond, 0, time.Second)
+
+	c.Assert(res.Target, qt.Equals, 10)
+	c.Assert(res.Opened, qt.Equals, 7)
+	c.Assert(res.Failed, qt.Equals, 3)
+	c.Assert(res.Dropped, qt.Equals, 1)
+	c.Assert(res.ErrorCounts, qt.DeepEquals, []*errorCount{
+		{Error: "too many connections", Count: 2},
+		{Error: "i/o timeout", Count: 1},
+	})
+	c.Assert(res.Errors, qt.Equals, "too many connections (2), i/o timeout (1)")
+
+	for _, f := range conns {
+		c.Assert(f.closed, qt.IsTrue)
+	}
+}
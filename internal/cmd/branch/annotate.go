@@ -0,0 +1,86 @@
+package branch
+
+import (
+	"fmt"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+// AnnotateCmd is the command for setting and showing the notes and links of
+// a branch.
+func AnnotateCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags cmdutil.AnnotationFlags
+
+	cmd := &cobra.Command{
+		Use:   "annotate <database> <branch>",
+		Short: "Set or show the notes and links of a branch",
+		Long: `Set or show the notes and links of a branch.
+
+Annotations are local only: they're stored in the pscale configuration
+directory of this machine and aren't shared with other users or machines.
+They're shown in the output of 'pscale branch list'. Without any flags, the
+current annotation is shown.`,
+		Args:              cmdutil.RequiredArgs("database", "branch"),
+		ValidArgsFunction: cmdutil.BranchCompletion(ch),
+		Example: `Add a note and a ticket link to a branch:
+
+  pscale branch annotate mydb add-users --note "Adds the users table" --link https://example.com/TICKET-42`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+			key := config.BranchKey(ch.Config.Organization, database, branch)
+			name := fmt.Sprintf("Branch %s", printer.BoldBlue(branch))
+
+			annotations, err := ch.ConfigFS.Annotations()
+			if err != nil {
+				return err
+			}
+
+			if !flags.Changed(cmd) {
+				return cmdutil.PrintAnnotation(ch.Printer, name, annotations.Branches[key])
+			}
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			_, err = client.DatabaseBranches.Get(ctx, &ps.GetDatabaseBranchRequest{
+				Organization: ch.Config.Organization,
+				Database:     database,
+				Branch:       branch,
+			})
+			if err != nil {
+				switch cmdutil.ErrCode(err) {
+				case ps.ErrNotFound:
+					return fmt.Errorf("branch %s does not exist in database %s (organization: %s)",
+						printer.BoldBlue(branch), printer.BoldBlue(database), printer.BoldBlue(ch.Config.Organization))
+				default:
+					return cmdutil.HandleError(err)
+				}
+			}
+
+			a := flags.Apply(cmd, annotations.Branches[key])
+			if a == nil {
+				delete(annotations.Branches, key)
+			} else {
+				annotations.Branches[key] = a
+			}
+
+			if err := annotations.Write(); err != nil {
+				return err
+			}
+
+			return cmdutil.PrintAnnotation(ch.Printer, name, a)
+		},
+	}
+
+	flags.Register(cmd)
+
+	return cmd
+}
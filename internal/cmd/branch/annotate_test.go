@@ -0,0 +1,56 @@
+package branch
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestBranch_AnnotateCmd_Show(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	annotationsPath, err := config.AnnotationsPath()
+	c.Assert(err, qt.IsNil)
+
+	testfs := testutil.MemFS{
+		annotationsPath: &fstest.MapFile{Data: []byte(`branches:
+  planetscale/mydb/dev:
+    note: Adds the users table
+    links:
+    - https://example.com/TICKET-42
+    updated-at: 2022-03-01T10:00:00Z
+`)},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer:  p,
+		ConfigFS: config.NewConfigFS(testfs),
+		Config: &config.Config{
+			Organization: "planetscale",
+		},
+	}
+
+	cmd := AnnotateCmd(ch)
+	cmd.SetArgs([]string{"mydb", "dev"})
+	err = cmd.Execute()
+	c.Assert(err, qt.IsNil)
+
+	res := map[string]interface{}{
+		"note":       "Adds the users table",
+		"links":      []string{"https://example.com/TICKET-42"},
+		"updated_at": "2022-03-01T10:00:00Z",
+	}
+	c.Assert(buf.String(), qt.JSONEquals, res)
+}
@@ -26,10 +26,15 @@ func BranchCmd(ch *cmdutil.Helper) *cobra.Command {
 	cmd.AddCommand(DeleteCmd(ch))
 	cmd.AddCommand(ShowCmd(ch))
 	cmd.AddCommand(SwitchCmd(ch))
+	cmd.AddCommand(DevCmd(ch))
 	cmd.AddCommand(DiffCmd(ch))
 	cmd.AddCommand(SchemaCmd(ch))
 	cmd.AddCommand(RefreshSchemaCmd(ch))
 	cmd.AddCommand(PromoteCmd(ch))
+	cmd.AddCommand(AnnotateCmd(ch))
+	cmd.AddCommand(ReportCmd(ch))
+	cmd.AddCommand(TreeCmd(ch))
+	cmd.AddCommand(LagCmd(ch))
 
 	return cmd
 }
@@ -41,6 +46,7 @@ type DatabaseBranch struct {
 	Ready        bool   `header:"ready" json:"ready"`
 	CreatedAt    int64  `header:"created_at,timestamp(ms|utc|human)" json:"created_at"`
 	UpdatedAt    int64  `header:"updated_at,timestamp(ms|utc|human)" json:"updated_at"`
+	Annotation   string `header:"annotation" json:"-"`
 
 	orig *ps.DatabaseBranch
 }
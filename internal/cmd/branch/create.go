@@ -3,9 +3,11 @@ package branch
 import (
 	"fmt"
 	"net/url"
+	"time"
 
 	"github.com/pkg/browser"
 	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/cost"
 	"github.com/planetscale/cli/internal/printer"
 	ps "github.com/planetscale/planetscale-go/planetscale"
 	"github.com/spf13/cobra"
@@ -13,50 +15,31 @@ import (
 
 func CreateCmd(ch *cmdutil.Helper) *cobra.Command {
 	createReq := &ps.CreateDatabaseBranchRequest{}
+	var dedupeWindow time.Duration
 
 	cmd := &cobra.Command{
-		Use:     "create <source-database> <branch> [options]",
-		Short:   "Create a new branch from a database",
-		Args:    cmdutil.RequiredArgs("source-database", "branch"),
-		Aliases: []string{"b"},
-		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			if len(args) != 0 {
-				return nil, cobra.ShellCompDirectiveNoFileComp
-			}
-
-			client, err := ch.Client()
-			if err != nil {
-				return nil, cobra.ShellCompDirectiveNoFileComp
-			}
-
-			org := ch.Config.Organization // --org flag
-			if org == "" {
-				cfg, err := ch.ConfigFS.DefaultConfig()
-				if err != nil {
-					return nil, cobra.ShellCompDirectiveNoFileComp
-				}
+		Use:   "create <source-database> [branch] [options]",
+		Short: "Create a new branch from a database",
+		Long: `Create a new branch from a database.
+
+If the branch name is omitted, it's generated from the naming template of the
+organization (see 'naming' in your config file).`,
+		Args:              cmdutil.RequiredArgs("source-database"),
+		ValidArgsFunction: cmdutil.DatabaseCompletion(ch),
+		Aliases:           []string{"b"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			source := args[0]
 
-				org = cfg.Organization
+			var branch string
+			if len(args) > 1 {
+				branch = args[1]
 			}
 
-			databases, err := client.Databases.List(cmd.Context(), &ps.ListDatabasesRequest{
-				Organization: org,
-			})
+			branch, err := cmdutil.ResourceName(ch, "branch", branch, source)
 			if err != nil {
-				return nil, cobra.ShellCompDirectiveNoFileComp
-			}
-
-			candidates := make([]string, 0, len(databases))
-			for _, db := range databases {
-				candidates = append(candidates, db.Name)
+				return err
 			}
 
-			return candidates, cobra.ShellCompDirectiveNoFileComp
-		},
-		RunE: func(cmd *cobra.Command, args []string) error {
-			source := args[0]
-			branch := args[1]
-
 			createReq.Database = source
 			createReq.Name = branch
 			createReq.Organization = ch.Config.Organization
@@ -83,6 +66,26 @@ func CreateCmd(ch *cmdutil.Helper) *cobra.Command {
 				return err
 			}
 
+			if dedupeWindow > 0 {
+				existing, err := client.DatabaseBranches.Get(cmd.Context(), &ps.GetDatabaseBranchRequest{
+					Organization: ch.Config.Organization,
+					Database:     source,
+					Branch:       branch,
+				})
+				if err == nil && cmdutil.IsDuplicate(existing.CreatedAt, dedupeWindow) {
+					if ch.Printer.Format() == printer.Human && !ch.Printer.Quiet() {
+						ch.Printer.Printf("Branch %s was already created at %s, skipping creation.\n",
+							printer.BoldBlue(existing.Name), existing.CreatedAt.Format(time.RFC3339))
+						return nil
+					}
+
+					return ch.Printer.PrintResource(ToDatabaseBranch(existing))
+				}
+			}
+
+			cmdutil.WarnBudget(cmd.Context(), ch, client, fmt.Sprintf("branch %s", printer.BoldBlue(branch)),
+				&cost.Usage{DevelopmentBranches: 1})
+
 			end := ch.Printer.PrintProgress(fmt.Sprintf("Creating branch from %s...", printer.BoldBlue(source)))
 			defer end()
 			dbBranch, err := client.DatabaseBranches.Create(cmd.Context(), createReq)
@@ -98,7 +101,7 @@ func CreateCmd(ch *cmdutil.Helper) *cobra.Command {
 
 			end()
 
-			if ch.Printer.Format() == printer.Human {
+			if ch.Printer.Format() == printer.Human && !ch.Printer.Quiet() {
 				ch.Printer.Printf("Branch %s was successfully created.\n", printer.BoldBlue(dbBranch.Name))
 				return nil
 			}
@@ -133,6 +136,7 @@ func CreateCmd(ch *cmdutil.Helper) *cobra.Command {
 		return regionStrs, cobra.ShellCompDirectiveDefault
 	})
 	cmd.Flags().BoolP("web", "w", false, "Create a branch in your web browser")
+	cmdutil.DedupeWindowFlag(cmd, &dedupeWindow)
 
 	return cmd
 }
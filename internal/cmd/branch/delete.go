@@ -16,28 +16,43 @@ import (
 
 func DeleteCmd(ch *cmdutil.Helper) *cobra.Command {
 	var force bool
+	var override bool
+	var cond string
 
 	cmd := &cobra.Command{
-		Use:     "delete <database> <branch>",
-		Short:   "Delete a branch from a database",
-		Args:    cmdutil.RequiredArgs("database", "branch"),
-		Aliases: []string{"rm"},
+		Use:               "delete <database> <branch>",
+		Short:             "Delete a branch from a database",
+		Args:              cmdutil.RequiredArgs("database", "branch"),
+		ValidArgsFunction: cmdutil.BranchCompletion(ch),
+		Aliases:           []string{"rm"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			source := args[0]
 			branch := args[1]
 
+			if err := cmdutil.CheckMaintenance(ch, source, override); err != nil {
+				return err
+			}
+
 			client, err := ch.Client()
 			if err != nil {
 				return err
 			}
 
-			if !force {
-				if ch.Printer.Format() != printer.Human {
-					return fmt.Errorf("cannot delete branch with the output format %q (run with -force to override)", ch.Printer.Format())
-				}
+			branch, err = cmdutil.ConfirmBranch(ctx, client, ch.Config.Organization, source, branch)
+			if err != nil {
+				return err
+			}
+
+			if ch.Config.IsProtectedBranch(branch) {
+				return fmt.Errorf("branch %s is protected in organization %s and can't be deleted (see 'protected-branches' in your config file)",
+					printer.BoldBlue(branch), printer.BoldBlue(ch.Config.Organization))
+			}
+
+			force = force || ch.Config.AssumeYes
 
-				_, err := client.DatabaseBranches.Get(ctx, &planetscale.GetDatabaseBranchRequest{
+			if !force || cond != "" {
+				b, err := client.DatabaseBranches.Get(ctx, &planetscale.GetDatabaseBranchRequest{
 					Organization: ch.Config.Organization,
 					Database:     source,
 					Branch:       branch,
@@ -52,6 +67,18 @@ func DeleteCmd(ch *cmdutil.Helper) *cobra.Command {
 					}
 				}
 
+				if err := cmdutil.CheckCondition(cond, b); err != nil {
+					return err
+				}
+
+				force = force || ch.Config.AutoApproves(b.Production)
+			}
+
+			if !force && ch.Printer.Format() != printer.Human {
+				return fmt.Errorf("cannot delete branch with the output format %q (run with -force to override)", ch.Printer.Format())
+			}
+
+			if !force {
 				confirmationName := fmt.Sprintf("%s/%s", source, branch)
 				if !printer.IsTTY {
 					return fmt.Errorf("cannot confirm deletion of branch %q (run with -force to override)", confirmationName)
@@ -109,5 +136,7 @@ func DeleteCmd(ch *cmdutil.Helper) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&force, "force", false, "Delete a branch without confirmation")
+	cmdutil.MaintenanceFlag(cmd, &override)
+	cmdutil.ConditionFlag(cmd, &cond)
 	return cmd
 }
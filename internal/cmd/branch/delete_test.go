@@ -27,6 +27,9 @@ func TestBranch_DeleteCmd(t *testing.T) {
 	branch := "development"
 
 	svc := &mock.DatabaseBranchesService{
+		GetFn: func(ctx context.Context, req *ps.GetDatabaseBranchRequest) (*ps.DatabaseBranch, error) {
+			return &ps.DatabaseBranch{Name: req.Branch}, nil
+		},
 		DeleteFn: func(ctx context.Context, req *ps.DeleteDatabaseBranchRequest) error {
 			c.Assert(req.Branch, qt.Equals, branch)
 			c.Assert(req.Database, qt.Equals, db)
@@ -59,5 +62,114 @@ func TestBranch_DeleteCmd(t *testing.T) {
 
 	c.Assert(err, qt.IsNil)
 	c.Assert(svc.DeleteFnInvoked, qt.IsTrue)
+	// full names aren't looked up in the list of branches
+	c.Assert(svc.ListFnInvoked, qt.IsFalse)
 	c.Assert(buf.String(), qt.JSONEquals, res)
 }
+
+func TestBranch_DeleteCmd_ProtectedBranch(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	org := "planetscale"
+	db := "planetscale"
+	branch := "main"
+
+	svc := &mock.DatabaseBranchesService{
+		GetFn: func(ctx context.Context, req *ps.GetDatabaseBranchRequest) (*ps.DatabaseBranch, error) {
+			if req.Branch != branch {
+				return nil, &ps.Error{Code: ps.ErrNotFound}
+			}
+			return &ps.DatabaseBranch{Name: branch}, nil
+		},
+		ListFn: func(ctx context.Context, req *ps.ListDatabaseBranchesRequest) ([]*ps.DatabaseBranch, error) {
+			return []*ps.DatabaseBranch{{Name: branch}}, nil
+		},
+		DeleteFn: func(ctx context.Context, req *ps.DeleteDatabaseBranchRequest) error {
+			return nil
+		},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config: &config.Config{
+			Organization:      org,
+			ProtectedBranches: []string{"main"},
+		},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{
+				DatabaseBranches: svc,
+			}, nil
+
+		},
+	}
+
+	cmd := DeleteCmd(ch)
+	cmd.SetArgs([]string{db, branch, "--force"})
+	err := cmd.Execute()
+
+	c.Assert(err, qt.ErrorMatches, ".*is protected.*")
+	c.Assert(svc.DeleteFnInvoked, qt.IsFalse)
+
+	// a prefix of a protected branch isn't deleted either
+	c.Patch(&printer.IsTTY, false)
+	cmd = DeleteCmd(ch)
+	cmd.SetArgs([]string{db, "ma", "--force"})
+	err = cmd.Execute()
+
+	c.Assert(err, qt.ErrorMatches, `"ma" is only a prefix of branch main, use its full name`)
+	c.Assert(svc.DeleteFnInvoked, qt.IsFalse)
+}
+
+func TestBranch_DeleteCmd_AutoApprove(t *testing.T) {
+	for _, production := range []bool{false, true} {
+		c := qt.New(t)
+
+		var buf bytes.Buffer
+		format := printer.JSON
+		p := printer.NewPrinter(&format)
+		p.SetResourceOutput(&buf)
+
+		svc := &mock.DatabaseBranchesService{
+			ListFn: func(ctx context.Context, req *ps.ListDatabaseBranchesRequest) ([]*ps.DatabaseBranch, error) {
+				return []*ps.DatabaseBranch{{Name: "dev"}}, nil
+			},
+			GetFn: func(ctx context.Context, req *ps.GetDatabaseBranchRequest) (*ps.DatabaseBranch, error) {
+				return &ps.DatabaseBranch{Name: req.Branch, Production: production}, nil
+			},
+			DeleteFn: func(ctx context.Context, req *ps.DeleteDatabaseBranchRequest) error {
+				return nil
+			},
+		}
+
+		ch := &cmdutil.Helper{
+			Printer: p,
+			Config: &config.Config{
+				Organization: "planetscale",
+				AutoApprove:  true,
+			},
+			Client: func() (*ps.Client, error) {
+				return &ps.Client{
+					DatabaseBranches: svc,
+				}, nil
+			},
+		}
+
+		cmd := DeleteCmd(ch)
+		cmd.SetArgs([]string{"mydb", "dev"})
+		err := cmd.Execute()
+
+		// production branches are never auto-approved
+		if production {
+			c.Assert(err, qt.ErrorMatches, "cannot delete branch with the output format.*")
+			c.Assert(svc.DeleteFnInvoked, qt.IsFalse)
+		} else {
+			c.Assert(err, qt.IsNil)
+			c.Assert(svc.DeleteFnInvoked, qt.IsTrue)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package branch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/naming"
+	"github.com/planetscale/cli/internal/printer"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultDevBranchTemplate names the dev branches if the organization has no
+// dev-branch naming convention.
+const defaultDevBranchTemplate = "{{user}}-{{git_branch}}"
+
+// readyInterval is how often the readiness of a new dev branch is checked,
+// it's replaced in tests.
+var readyInterval = time.Second
+
+// DevBranch is the dev branch of a developer with the credentials to
+// connect to it.
+type DevBranch struct {
+	Database string `header:"database" json:"database"`
+	Branch   string `header:"branch" json:"branch"`
+	Created  bool   `header:"created" json:"created"`
+	Host     string `header:"host" json:"host"`
+	Username string `header:"username" json:"username"`
+	Password string `header:"password" json:"password"`
+}
+
+// DevCmd resolves the dev branch of the current developer.
+func DevCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		from    string
+		noSave  bool
+		timeout time.Duration
+	}
+
+	cmd := &cobra.Command{
+		Use:   "dev [database]",
+		Short: "Use your own dev branch of a database, creating it if needed",
+		Long: `Use your own dev branch of a database, creating it if needed.
+
+The name of the branch is generated from the dev-branch naming convention of
+the organization (see 'naming' in your config file), or from
+"{{user}}-{{git_branch}}" by default. If the branch doesn't exist, it's
+created from --from and the command waits until it's ready. The database and
+the branch are set in the project config file and a new password for the
+branch is printed.
+
+The database defaults to the one of the project config file.`,
+		Example: `Create or reuse your dev branch of mydb:
+
+  pscale branch dev mydb
+
+Create the dev branch from another branch, without changing the project
+config file:
+
+  pscale branch dev mydb --from staging --no-save`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: cmdutil.DatabaseCompletion(ch),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			database := ch.Config.Database
+			if len(args) > 0 {
+				database = args[0]
+			}
+			if database == "" {
+				return errors.New("the argument <database> is missing and no database is set in the project config file")
+			}
+
+			rule := ch.Config.Naming.Rule("dev-branch")
+			if rule == nil || rule.Template == "" {
+				rule = &config.NameRule{Template: defaultDevBranchTemplate}
+			}
+			name, err := naming.Generate(rule, database)
+			if err != nil {
+				return err
+			}
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			dev := &DevBranch{Database: database, Branch: name}
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Looking up dev branch %s...", printer.BoldBlue(name)))
+			defer end()
+
+			_, err = client.DatabaseBranches.Get(ctx, &ps.GetDatabaseBranchRequest{
+				Organization: ch.Config.Organization,
+				Database:     database,
+				Branch:       name,
+			})
+			switch {
+			case err == nil:
+			case cmdutil.ErrCode(err) == ps.ErrNotFound:
+				end()
+				end = ch.Printer.PrintProgress(fmt.Sprintf("Creating dev branch %s from %s...", printer.BoldBlue(name), printer.BoldBlue(flags.from)))
+
+				_, err := client.DatabaseBranches.Create(ctx, &ps.CreateDatabaseBranchRequest{
+					Organization: ch.Config.Organization,
+					Database:     database,
+					Name:         name,
+					ParentBranch: flags.from,
+				})
+				if err != nil {
+					switch cmdutil.ErrCode(err) {
+					case ps.ErrNotFound:
+						return fmt.Errorf("database %s does not exist in organization %s",
+							printer.BoldBlue(database), printer.BoldBlue(ch.Config.Organization))
+					default:
+						return cmdutil.HandleError(err)
+					}
+				}
+
+				if err := waitBranchReady(ctx, client, ch.Config.Organization, database, name, flags.timeout); err != nil {
+					return err
+				}
+				dev.Created = true
+			default:
+				return cmdutil.HandleError(err)
+			}
+
+			end()
+
+			if !flags.noSave {
+				if err := saveDevBranch(ch, database, name); err != nil {
+					return err
+				}
+			}
+
+			end = ch.Printer.PrintProgress(fmt.Sprintf("Creating password of %s/%s...", printer.BoldBlue(database), printer.BoldBlue(name)))
+			pass, err := client.Passwords.Create(ctx, &ps.DatabaseBranchPasswordRequest{
+				Organization: ch.Config.Organization,
+				Database:     database,
+				Branch:       name,
+				DisplayName:  fmt.Sprintf("%s-%s", name, time.Now().UTC().Format("20060102150405")),
+			})
+			if err != nil {
+				return cmdutil.HandleError(err)
+			}
+			end()
+
+			dev.Host = pass.Branch.AccessHostURL
+			dev.Username = pass.PublicID
+			dev.Password = pass.PlainText
+
+			if ch.Printer.Format() == printer.Human {
+				action := "Using"
+				if dev.Created {
+					action = "Created"
+				}
+				saveWarning := printer.BoldRed("Please save the password below as it will not be shown again")
+				ch.Printer.Printf("%s dev branch %s of database %s.\n%s\n\n",
+					action, printer.BoldBlue(name), printer.BoldBlue(database), saveWarning)
+			}
+
+			return ch.Printer.PrintResource(dev)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.from, "from", "main", "Branch the dev branch is created from if it doesn't exist")
+	cmd.Flags().BoolVar(&flags.noSave, "no-save", false, "Don't set the database and branch in the project config file")
+	cmd.Flags().DurationVar(&flags.timeout, "timeout", 5*time.Minute, "Time to wait for a new dev branch to be ready")
+
+	return cmd
+}
+
+// waitBranchReady waits until the branch is ready.
+func waitBranchReady(ctx context.Context, client *ps.Client, org, database, branch string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(readyInterval)
+	defer ticker.Stop()
+
+	for {
+		b, err := client.DatabaseBranches.Get(ctx, &ps.GetDatabaseBranchRequest{
+			Organization: org,
+			Database:     database,
+			Branch:       branch,
+		})
+		if err != nil && ctx.Err() == nil {
+			return cmdutil.HandleError(err)
+		}
+		if err == nil && b.Ready {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("branch %s isn't ready after %s", printer.BoldBlue(branch), timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// saveDevBranch sets the database and the branch in the project config
+// file, keeping its other values. The file is left as it is if it already
+// resolves to the branch, i.e. with "branch: ${env:USER}-dev".
+func saveDevBranch(ch *cmdutil.Helper, database, branch string) error {
+	if cfg, err := ch.ConfigFS.ProjectConfig(); err == nil && cfg.Database == database && cfg.Branch == branch {
+		return nil
+	}
+
+	path, err := config.ProjectConfigPath()
+	if err != nil {
+		return err
+	}
+
+	values := []struct{ key, value string }{
+		{"org", ch.Config.Organization},
+		{"database", database},
+		{"branch", branch},
+	}
+	for _, v := range values {
+		if err := config.SetValue(path, v.key, v.value); err != nil {
+			return fmt.Errorf("error writing project configuration file: %s", err)
+		}
+	}
+	return nil
+}
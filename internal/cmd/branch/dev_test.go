@@ -0,0 +1,112 @@
+package branch
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+func TestBranch_DevCmd(t *testing.T) {
+	c := qt.New(t)
+
+	c.Patch(&readyInterval, time.Millisecond)
+
+	// the project config file is written to the root of the git repository
+	dir := t.TempDir()
+	c.Assert(os.Mkdir(filepath.Join(dir, ".git"), 0755), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, ".pscale.yml"), []byte("org: planetscale\nschema-dir: schema\n"), 0644), qt.IsNil)
+	wd, err := os.Getwd()
+	c.Assert(err, qt.IsNil)
+	c.Assert(os.Chdir(dir), qt.IsNil)
+	c.Cleanup(func() { os.Chdir(wd) }) // nolint:errcheck
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	org := "planetscale"
+	db := "mydb"
+	branch := "mydb-dev"
+
+	gets := 0
+	svc := &mock.DatabaseBranchesService{
+		GetFn: func(ctx context.Context, req *ps.GetDatabaseBranchRequest) (*ps.DatabaseBranch, error) {
+			c.Assert(req.Branch, qt.Equals, branch)
+			gets++
+			switch gets {
+			case 1:
+				return nil, &ps.Error{Code: ps.ErrNotFound}
+			case 2:
+				return &ps.DatabaseBranch{Name: branch}, nil
+			}
+			return &ps.DatabaseBranch{Name: branch, Ready: true}, nil
+		},
+		CreateFn: func(ctx context.Context, req *ps.CreateDatabaseBranchRequest) (*ps.DatabaseBranch, error) {
+			c.Assert(req.Name, qt.Equals, branch)
+			c.Assert(req.Database, qt.Equals, db)
+			c.Assert(req.ParentBranch, qt.Equals, "main")
+			return &ps.DatabaseBranch{Name: branch}, nil
+		},
+	}
+	passwords := &mock.PasswordsService{
+		CreateFn: func(ctx context.Context, req *ps.DatabaseBranchPasswordRequest) (*ps.DatabaseBranchPassword, error) {
+			c.Assert(req.Branch, qt.Equals, branch)
+			return &ps.DatabaseBranchPassword{
+				PublicID:  "user1",
+				PlainText: "secret",
+				Branch:    ps.DatabaseBranch{Name: branch, AccessHostURL: "aws.connect.psdb.cloud"},
+			}, nil
+		},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config: &config.Config{
+			Organization: org,
+			Naming: &config.Naming{
+				DevBranch: &config.NameRule{Template: "{{database}}-dev"},
+			},
+		},
+		ConfigFS: config.NewConfigFS(testutil.OSFS{}),
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{
+				DatabaseBranches: svc,
+				Passwords:        passwords,
+			}, nil
+		},
+	}
+
+	cmd := DevCmd(ch)
+	cmd.SetArgs([]string{db})
+	err = cmd.Execute()
+	c.Assert(err, qt.IsNil)
+	c.Assert(svc.CreateFnInvoked, qt.IsTrue)
+	c.Assert(gets, qt.Equals, 3)
+
+	c.Assert(buf.String(), qt.JSONEquals, &DevBranch{
+		Database: db,
+		Branch:   branch,
+		Created:  true,
+		Host:     "aws.connect.psdb.cloud",
+		Username: "user1",
+		Password: "secret",
+	})
+
+	out, err := ioutil.ReadFile(filepath.Join(dir, ".pscale.yml"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, "org: planetscale\nschema-dir: schema\ndatabase: mydb\nbranch: mydb-dev\n")
+}
@@ -1,13 +1,12 @@
 package branch
 
 import (
-	"bufio"
 	"fmt"
 	"strings"
 
-	"github.com/fatih/color"
 	"github.com/planetscale/cli/internal/cmdutil"
 	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/schemadiff"
 	"github.com/planetscale/planetscale-go/planetscale"
 
 	"github.com/spf13/cobra"
@@ -16,17 +15,46 @@ import (
 // DiffCmd is the command for showing the diff of a branch.
 func DiffCmd(ch *cmdutil.Helper) *cobra.Command {
 	var flags struct {
-		web bool
+		web        bool
+		diffFormat string
+		noIgnore   bool
+		local      bool
+		schemaDir  string
 	}
 
 	cmd := &cobra.Command{
-		Use:   "diff <database> <branch>",
-		Short: "Show the diff of a branch",
-		Args:  cmdutil.RequiredArgs("database", "branch"),
+		Use:               "diff <database> <branch>",
+		Short:             "Show the diff of a branch",
+		Args:              cmdutil.RequiredArgs("database", "branch"),
+		ValidArgsFunction: cmdutil.BranchCompletion(ch),
+		Example: `Show the old and new schema side by side:
+
+  pscale branch diff mydb dev --diff-format side-by-side
+
+Compare the schema of a branch with the CREATE TABLE statements in the .sql
+files of the project, i.e. before opening a deploy request. The files are
+read from the schema-dir of .pscale.yml, or the "schema" directory. The
+command exits with status 2 if they differ:
+
+  pscale branch diff mydb main --local
+  pscale branch diff mydb main --local --schema-dir db/migrations --format json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			database, branch := args[0], args[1]
 
+			diffFormat, err := schemadiff.ParseFormat(flags.diffFormat)
+			if err != nil {
+				return err
+			}
+
+			if flags.local || flags.schemaDir != "" {
+				dir, err := localSchemaDir(ch, flags.schemaDir)
+				if err != nil {
+					return err
+				}
+				return diffLocal(ctx, ch, database, branch, dir, diffFormat, flags.noIgnore)
+			}
+
 			client, err := ch.Client()
 			if err != nil {
 				return err
@@ -47,34 +75,42 @@ func DiffCmd(ch *cmdutil.Helper) *cobra.Command {
 				}
 			}
 
+			if !flags.noIgnore {
+				ignore, err := schemadiff.ProjectIgnore()
+				if err != nil {
+					return err
+				}
+				diffs = ignore.ApplyDiffs(diffs)
+			}
+
 			if ch.Printer.Format() != printer.Human {
 				return ch.Printer.PrintResource(diffs)
 			}
 
 			// human readable output
-			for _, df := range diffs {
-				ch.Printer.Println("--", printer.BoldBlue(df.Name), "--")
-				scanner := bufio.NewScanner(strings.NewReader(strings.TrimSpace(df.Raw)))
-				for scanner.Scan() {
-					txt := scanner.Text()
-					if strings.HasPrefix(txt, "+") {
-						ch.Printer.Println(color.New(color.FgGreen).Add(color.Bold).Sprint(txt)) //nolint: errcheck
-					} else if strings.HasPrefix(txt, "-") {
-						ch.Printer.Println(color.New(color.FgRed).Add(color.Bold).Sprint(txt)) //nolint: errcheck
-					} else {
-						ch.Printer.Println(txt)
-					}
-				}
-				if err := scanner.Err(); err != nil {
-					return fmt.Errorf("reading diff raw: %s", err)
-				}
+			var b strings.Builder
+			title := fmt.Sprintf("Branch %s/%s", database, branch)
+			if err := schemadiff.Render(&b, diffFormat, title, schemadiff.FromDiffs(diffs)); err != nil {
+				return err
 			}
+			ch.Printer.Print(b.String())
 
 			return nil
 		},
 	}
 
 	cmd.PersistentFlags().BoolVar(&flags.web, "web", false, "Open in your web browser")
+	cmd.Flags().StringVar(&flags.diffFormat, "diff-format", string(schemadiff.FormatUnified),
+		fmt.Sprintf("Format of the human readable diff. Possible values: [%s]", strings.Join(schemadiff.Formats, ", ")))
+	cmd.Flags().BoolVar(&flags.noIgnore, "no-ignore", false,
+		fmt.Sprintf("Show all differences, including the ones excluded by the %s file", schemadiff.IgnoreFile))
+	cmd.Flags().BoolVar(&flags.local, "local", false,
+		"Compare the schema of the branch with the local schema files instead of showing its changes")
+	cmd.Flags().StringVar(&flags.schemaDir, "schema-dir", "",
+		"Directory of the local schema files for --local, overrides the schema-dir of the project config")
+	cmd.RegisterFlagCompletionFunc("diff-format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) { // nolint:errcheck
+		return schemadiff.Formats, cobra.ShellCompDirectiveDefault
+	})
 
 	return cmd
 }
@@ -0,0 +1,186 @@
+package branch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/schemacache"
+	"github.com/planetscale/cli/internal/schemadiff"
+	"github.com/planetscale/planetscale-go/planetscale"
+)
+
+const (
+	// defaultSchemaDir is the directory of the local schema files, relative
+	// to the project root, if the project config doesn't set schema-dir.
+	defaultSchemaDir = "schema"
+
+	// driftExitCode is the exit code if the branch schema differs from the
+	// local schema, so scripts can tell drift from failures.
+	driftExitCode = 2
+)
+
+// localDiff returns a table-serializable diff between the schema of a branch
+// and the local schema files.
+type localDiff struct {
+	Database  string            `json:"database"`
+	Branch    string            `json:"branch"`
+	SchemaDir string            `json:"schema_dir"`
+	Drift     bool              `json:"drift"`
+	Tables    []*localTableDiff `json:"tables"`
+}
+
+type localTableDiff struct {
+	Name string `json:"name"`
+
+	// Status is "branch_only" for tables missing locally, "local_only" for
+	// tables missing in the branch and "modified" otherwise.
+	Status string `json:"status"`
+
+	// Diff is the unified diff from the branch to the local schema.
+	Diff string `json:"diff"`
+}
+
+// diffLocal compares the schema of the branch with the schema files in dir
+// and returns an error with driftExitCode if they differ.
+func diffLocal(ctx context.Context, ch *cmdutil.Helper, database, branch, dir string, diffFormat schemadiff.Format, noIgnore bool) error {
+	local, err := schemadiff.ReadSchemaDir(os.DirFS(dir), ".")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("schema directory %s doesn't exist, pass --schema-dir or set schema-dir in %s", dir, config.ProjectConfigFile())
+		}
+		return fmt.Errorf("reading the schema in %s: %s", dir, err)
+	}
+
+	client, err := ch.Client()
+	if err != nil {
+		return err
+	}
+
+	schemas, err := schemacache.Schema(ctx, client, &planetscale.BranchSchemaRequest{
+		Organization: ch.Config.Organization,
+		Database:     database,
+		Branch:       branch,
+	}, false)
+	if err != nil {
+		switch cmdutil.ErrCode(err) {
+		case planetscale.ErrNotFound:
+			return fmt.Errorf("branch %s does not exist in database %s (organization: %s)",
+				printer.BoldBlue(branch), printer.BoldBlue(database), printer.BoldBlue(ch.Config.Organization))
+		default:
+			return cmdutil.HandleError(err)
+		}
+	}
+
+	remote := make([]*schemadiff.Table, 0, len(schemas))
+	for _, s := range schemas {
+		t, err := schemadiff.ParseCreateTable(s.Raw)
+		if err != nil {
+			return fmt.Errorf("parsing the schema of branch %s: %s", branch, err)
+		}
+		remote = append(remote, t)
+	}
+
+	diffs := schemadiff.CompareTables(remote, local)
+	if !noIgnore {
+		ignore, err := schemadiff.ProjectIgnore()
+		if err != nil {
+			return err
+		}
+		diffs = ignore.Apply(diffs)
+	}
+
+	if ch.Printer.Format() != printer.Human {
+		if err := ch.Printer.PrintResource(toLocalDiff(database, branch, dir, diffs)); err != nil {
+			return err
+		}
+	} else if len(diffs) == 0 {
+		ch.Printer.Printf("The schema of branch %s matches the schema in %s.\n", printer.BoldBlue(branch), printer.BoldBlue(dir))
+	} else {
+		var b strings.Builder
+		title := fmt.Sprintf("Branch %s/%s and %s", database, branch, dir)
+		if err := schemadiff.Render(&b, diffFormat, title, diffs); err != nil {
+			return err
+		}
+		ch.Printer.Print(b.String())
+	}
+
+	if len(diffs) == 0 {
+		return nil
+	}
+
+	return &cmdutil.Error{
+		Msg:      fmt.Sprintf("the schema of branch %s differs from the schema in %s in %d table(s)", branch, dir, len(diffs)),
+		ExitCode: driftExitCode,
+	}
+}
+
+// localSchemaDir returns the directory of the local schema files, either
+// the given one or the one configured for the project.
+func localSchemaDir(ch *cmdutil.Helper, dir string) (string, error) {
+	if dir != "" {
+		return dir, nil
+	}
+
+	dir = defaultSchemaDir
+	if ch.ConfigFS != nil {
+		if cfg, err := ch.ConfigFS.ProjectConfig(); err == nil && cfg.SchemaDir != "" {
+			dir = cfg.SchemaDir
+		}
+	}
+
+	if filepath.IsAbs(dir) {
+		return dir, nil
+	}
+
+	cfgPath, err := config.ProjectConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(cfgPath), dir), nil
+}
+
+func toLocalDiff(database, branch, dir string, diffs []*schemadiff.TableDiff) *localDiff {
+	res := &localDiff{
+		Database:  database,
+		Branch:    branch,
+		SchemaDir: dir,
+		Drift:     len(diffs) > 0,
+		Tables:    make([]*localTableDiff, 0, len(diffs)),
+	}
+
+	for _, df := range diffs {
+		inserted, deleted, equal := 0, 0, 0
+		var b strings.Builder
+		for _, l := range df.Lines {
+			switch l.Op {
+			case schemadiff.Insert:
+				inserted++
+			case schemadiff.Delete:
+				deleted++
+			default:
+				equal++
+			}
+			b.WriteString(l.String() + "\n")
+		}
+
+		status := "modified"
+		switch {
+		case inserted == 0 && equal == 0:
+			status = "branch_only"
+		case deleted == 0 && equal == 0:
+			status = "local_only"
+		}
+
+		res.Tables = append(res.Tables, &localTableDiff{Name: df.Name, Status: status, Diff: b.String()})
+	}
+
+	return res
+}
@@ -3,13 +3,18 @@ package branch
 import (
 	"bytes"
 	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
+	"time"
 
 	qt "github.com/frankban/quicktest"
 	"github.com/planetscale/cli/internal/cmdutil"
 	"github.com/planetscale/cli/internal/config"
 	"github.com/planetscale/cli/internal/mock"
 	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
 
 	ps "github.com/planetscale/planetscale-go/planetscale"
 )
@@ -63,3 +68,82 @@ func TestBranchDiffCmd(t *testing.T) {
 
 	c.Assert(buf.String(), qt.JSONEquals, res)
 }
+
+func TestBranchDiffCmd_Local(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	org := "planetscale"
+	db := "planetscale"
+	branch := "main"
+
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "users.sql"), []byte("CREATE TABLE `users` (\n"+
+		"  `id` bigint NOT NULL,\n"+
+		"  `email` varchar(320) NOT NULL,\n"+
+		"  PRIMARY KEY (`id`)\n"+
+		");\n"), 0644)
+	c.Assert(err, qt.IsNil)
+
+	svc := &mock.DatabaseBranchesService{
+		GetFn: func(ctx context.Context, req *ps.GetDatabaseBranchRequest) (*ps.DatabaseBranch, error) {
+			return &ps.DatabaseBranch{Name: branch, UpdatedAt: time.Unix(1632700000, 0)}, nil
+		},
+		SchemaFn: func(ctx context.Context, req *ps.BranchSchemaRequest) ([]*ps.Diff, error) {
+			c.Assert(req.Branch, qt.Equals, branch)
+
+			return []*ps.Diff{{
+				Name: "users",
+				Raw: "CREATE TABLE `users` (\n" +
+					"  `id` bigint NOT NULL,\n" +
+					"  `email` varchar(255) NOT NULL,\n" +
+					"  PRIMARY KEY (`id`)\n" +
+					") ENGINE=InnoDB",
+			}}, nil
+		},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config: &config.Config{
+			Organization: org,
+		},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{
+				DatabaseBranches: svc,
+			}, nil
+		},
+	}
+
+	cmd := DiffCmd(ch)
+	cmd.SetArgs([]string{db, branch, "--schema-dir", dir, "--no-ignore"})
+	err = cmd.Execute()
+
+	var cmdErr *cmdutil.Error
+	c.Assert(errors.As(err, &cmdErr), qt.IsTrue)
+	c.Assert(cmdErr.ExitCode, qt.Equals, driftExitCode)
+	c.Assert(svc.DiffFnInvoked, qt.IsFalse)
+
+	c.Assert(buf.String(), qt.JSONEquals, &localDiff{
+		Database:  db,
+		Branch:    branch,
+		SchemaDir: dir,
+		Drift:     true,
+		Tables: []*localTableDiff{{
+			Name:   "users",
+			Status: "modified",
+			Diff: " CREATE TABLE `users` (\n" +
+				"   `id` bigint NOT NULL,\n" +
+				"-  `email` varchar(255) NOT NULL,\n" +
+				"+  `email` varchar(320) NOT NULL,\n" +
+				"   PRIMARY KEY (`id`)\n" +
+				"-) ENGINE=InnoDB\n" +
+				"+)\n",
+		}},
+	})
+}
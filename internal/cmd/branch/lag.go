@@ -0,0 +1,219 @@
+package branch
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+
+	"github.com/spf13/cobra"
+)
+
+// lagTable is the table the probes are written to.
+const lagTable = "_pscale_lag_probe"
+
+// lagPollInterval is how often the reader polls for a probe, which is the
+// resolution of the measured lag.
+var lagPollInterval = 5 * time.Millisecond
+
+// LagCmd measures the replication lag observed by the readers of a branch.
+func LagCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		probes   int
+		interval time.Duration
+		timeout  time.Duration
+	}
+
+	cmd := &cobra.Command{
+		Use:   "lag <database> <branch>",
+		Short: "Measure the replication lag seen through reader credentials",
+		Long: `Measure the replication lag seen through reader credentials.
+
+Each probe writes a value with writer credentials and polls for it with reader
+credentials, every 5ms, until it's read back. The time it took is the lag an
+application reading its own writes through a reader observes, which explains
+stale reads. The probes are written to the ` + lagTable + ` table, which
+is created for the measurement and dropped afterwards, so the branch must
+allow schema changes.`,
+		Args: cmdutil.RequiredArgs("database", "branch"),
+		Example: `Measure the lag with 50 probes, one every 200ms:
+
+  pscale branch lag mydb dev --probes 50 --interval 200ms`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+
+			if flags.probes < 1 {
+				return errors.New("--probes must be at least 1")
+			}
+
+			writer, closeWriter, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.AdministratorRole)
+			if err != nil {
+				return err
+			}
+			defer closeWriter()
+
+			reader, closeReader, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.ReaderRole)
+			if err != nil {
+				return err
+			}
+			defer closeReader()
+
+			dropTable, err := createLagTable(ctx, writer)
+			if err != nil {
+				return fmt.Errorf("couldn't create the %s table: %s", lagTable, err)
+			}
+			defer dropTable()
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Probing the replication lag of %s with %d probes",
+				printer.BoldBlue(branch), flags.probes))
+			res := probeLag(ctx, writeProbe(writer), readProbe(reader), flags.probes, flags.interval, flags.timeout)
+			end()
+
+			return ch.Printer.PrintResource(res)
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.probes, "probes", 20, "The number of probes")
+	cmd.Flags().DurationVar(&flags.interval, "interval", 500*time.Millisecond, "The time between two probes")
+	cmd.Flags().DurationVar(&flags.timeout, "timeout", 10*time.Second, "How long a probe is polled for before it times out")
+
+	return cmd
+}
+
+// createLagTable creates the table of the probes, unless it exists, and
+// returns the function dropping it if it was created.
+func createLagTable(ctx context.Context, db *sql.DB) (func(), error) {
+	var n int
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM information_schema.tables
+WHERE table_schema = DATABASE() AND table_name = ?`, lagTable).Scan(&n)
+	if err != nil {
+		return nil, err
+	}
+	if n > 0 {
+		return func() {}, nil
+	}
+
+	_, err = db.ExecContext(ctx, "CREATE TABLE "+lagTable+" (id int NOT NULL PRIMARY KEY, token varchar(64) NOT NULL)")
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		db.ExecContext(context.Background(), "DROP TABLE "+lagTable) // nolint:errcheck
+	}, nil
+}
+
+func writeProbe(db *sql.DB) func(context.Context, string) error {
+	return func(ctx context.Context, token string) error {
+		_, err := db.ExecContext(ctx, "REPLACE INTO "+lagTable+" (id, token) VALUES (1, ?)", token)
+		return err
+	}
+}
+
+func readProbe(db *sql.DB) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		var token string
+		err := db.QueryRowContext(ctx, "SELECT token FROM "+lagTable+" WHERE id = 1").Scan(&token)
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return token, err
+	}
+}
+
+// lagResult is the distribution of the lag of the probes, in milliseconds.
+type lagResult struct {
+	Probes   int     `header:"probes" json:"probes"`
+	Observed int     `header:"observed" json:"observed"`
+	TimedOut int     `header:"timed out" json:"timed_out"`
+	Failed   int     `header:"failed" json:"failed"`
+	Min      float64 `header:"min (ms)" json:"min_ms"`
+	P50      float64 `header:"p50 (ms)" json:"p50_ms"`
+	P95      float64 `header:"p95 (ms)" json:"p95_ms"`
+	P99      float64 `header:"p99 (ms)" json:"p99_ms"`
+	Max      float64 `header:"max (ms)" json:"max_ms"`
+
+	Samples []float64 `json:"samples_ms" csv:"-"`
+	Errors  []string  `json:"errors,omitempty" csv:"-"`
+}
+
+// probeLag writes the probes one after the other and measures how long each
+// took to be read back. The probes that failed to be written or read are
+// counted as failed, with their errors.
+func probeLag(ctx context.Context, write func(context.Context, string) error, read func(context.Context) (string, error), probes int, interval, timeout time.Duration) *lagResult {
+	res := &lagResult{Probes: probes, Samples: []float64{}}
+
+	for i := 0; i < probes && ctx.Err() == nil; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				continue
+			case <-time.After(interval):
+			}
+		}
+
+		lag, err := probe(ctx, write, read, strconv.FormatInt(time.Now().UnixNano(), 36), timeout)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			res.TimedOut++
+		case err != nil:
+			res.Failed++
+			res.Errors = append(res.Errors, err.Error())
+		default:
+			res.Observed++
+			res.Samples = append(res.Samples, math.Round(float64(lag)/float64(time.Microsecond))/1000)
+		}
+	}
+
+	if len(res.Samples) > 0 {
+		sorted := append([]float64(nil), res.Samples...)
+		sort.Float64s(sorted)
+		res.Min = sorted[0]
+		res.P50 = cmdutil.Percentile(sorted, 50)
+		res.P95 = cmdutil.Percentile(sorted, 95)
+		res.P99 = cmdutil.Percentile(sorted, 99)
+		res.Max = sorted[len(sorted)-1]
+	}
+	return res
+}
+
+// probe writes the token and returns the time until it was read back.
+func probe(ctx context.Context, write func(context.Context, string) error, read func(context.Context) (string, error), token string, timeout time.Duration) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := write(ctx, token); err != nil {
+		return 0, fmt.Errorf("write: %s", err)
+	}
+	written := time.Now()
+
+	ticker := time.NewTicker(lagPollInterval)
+	defer ticker.Stop()
+
+	for {
+		got, err := read(ctx)
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		if err != nil {
+			return 0, fmt.Errorf("read: %s", err)
+		}
+		if got == token {
+			return time.Since(written), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
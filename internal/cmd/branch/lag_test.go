@@ -0,0 +1,60 @@
+package branch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestProbeLag(t *testing.T) {
+	c := qt.New(t)
+
+	c.Patch(&lagPollInterval, time.Millisecond)
+
+	// the replica applies the writes 20ms late, it stops replicating after
+	// the third write and fails to be read on the fifth probe
+	var mu sync.Mutex
+	var replica string
+	writes := 0
+	write := func(ctx context.Context, token string) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		writes++
+		if writes > 3 {
+			return nil
+		}
+		time.AfterFunc(20*time.Millisecond, func() {
+			mu.Lock()
+			defer mu.Unlock()
+			replica = token
+		})
+		return nil
+	}
+	read := func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if writes == 5 {
+			return "", errors.New("connection refused")
+		}
+		return replica, nil
+	}
+
+	res := probeLag(context.Background(), write, read, 5, time.Millisecond, 200*time.Millisecond)
+
+	c.Assert(res.Probes, qt.Equals, 5)
+	c.Assert(res.Observed, qt.Equals, 3)
+	c.Assert(res.TimedOut, qt.Equals, 1)
+	c.Assert(res.Failed, qt.Equals, 1)
+	c.Assert(res.Errors, qt.DeepEquals, []string{"read: connection refused"})
+	c.Assert(res.Samples, qt.HasLen, 3)
+	for _, s := range res.Samples {
+		c.Assert(s >= 20, qt.IsTrue, qt.Commentf("lag of %gms", s))
+	}
+	c.Assert(res.Min <= res.P50 && res.P50 <= res.Max, qt.IsTrue)
+}
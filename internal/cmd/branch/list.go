@@ -1,9 +1,12 @@
 package branch
 
 import (
+	"context"
 	"fmt"
+	"sync"
 
 	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
 	"github.com/planetscale/cli/internal/printer"
 	"github.com/planetscale/planetscale-go/planetscale"
 
@@ -13,11 +16,14 @@ import (
 
 // ListCmd encapsulates the command for listing branches for a database.
 func ListCmd(ch *cmdutil.Helper) *cobra.Command {
+	var pages cmdutil.Pages
+
 	cmd := &cobra.Command{
-		Use:     "list <database>",
-		Short:   "List all branches of a database",
-		Args:    cmdutil.RequiredArgs("database"),
-		Aliases: []string{"ls"},
+		Use:               "list <database>",
+		Short:             "List all branches of a database",
+		Args:              cmdutil.RequiredArgs("database"),
+		ValidArgsFunction: cmdutil.DatabaseCompletion(ch),
+		Aliases:           []string{"ls"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			database := args[0]
@@ -44,9 +50,17 @@ func ListCmd(ch *cmdutil.Helper) *cobra.Command {
 			end := ch.Printer.PrintProgress(fmt.Sprintf("Fetching branches for %s", printer.BoldBlue(database)))
 			defer end()
 
-			branches, err := client.DatabaseBranches.List(ctx, &planetscale.ListDatabaseBranchesRequest{
-				Organization: ch.Config.Organization,
-				Database:     database,
+			var mu sync.Mutex
+			byPage := make(map[int][]*planetscale.DatabaseBranch)
+			n, err := cmdutil.FetchPages(ctx, pages, func(ctx context.Context, page int) (int, error) {
+				branches, err := client.DatabaseBranches.List(ctx, &planetscale.ListDatabaseBranchesRequest{
+					Organization: ch.Config.Organization,
+					Database:     database,
+				})
+				mu.Lock()
+				byPage[page] = branches
+				mu.Unlock()
+				return len(branches), err
 			})
 			if err != nil {
 				switch cmdutil.ErrCode(err) {
@@ -59,15 +73,34 @@ func ListCmd(ch *cmdutil.Helper) *cobra.Command {
 			}
 			end()
 
+			var branches []*planetscale.DatabaseBranch
+			for page := 1; page <= n; page++ {
+				branches = append(branches, byPage[page]...)
+			}
+			if pages.Limit > 0 && len(branches) > pages.Limit {
+				branches = branches[:pages.Limit]
+			}
+
 			if len(branches) == 0 && ch.Printer.Format() == printer.Human {
 				ch.Printer.Printf("No branches exist in %s.\n", printer.BoldBlue(database))
 				return nil
 			}
 
-			return ch.Printer.PrintResource(toDatabaseBranches(branches))
+			annotations, err := ch.ConfigFS.Annotations()
+			if err != nil {
+				return err
+			}
+
+			bs := toDatabaseBranches(branches)
+			for _, b := range bs {
+				b.Annotation = annotations.Branches[config.BranchKey(ch.Config.Organization, database, b.Name)].String()
+			}
+
+			return ch.Printer.PrintResource(bs)
 		},
 	}
 
 	cmd.Flags().BoolP("web", "w", false, "List branches in your web browser.")
+	cmdutil.PageFlags(cmd, &pages)
 	return cmd
 }
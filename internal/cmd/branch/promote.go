@@ -7,7 +7,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/planetscale/cli/internal/approval"
 	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
 	"github.com/planetscale/cli/internal/printer"
 	"github.com/spf13/cobra"
 
@@ -16,46 +18,15 @@ import (
 
 func PromoteCmd(ch *cmdutil.Helper) *cobra.Command {
 	promoteReq := &ps.PromoteRequest{}
+	var approvalFile string
+	var override bool
 
 	cmd := &cobra.Command{
-		Use:     "promote <database> <branch> [options]",
-		Short:   "Promote a new branch from a database",
-		Args:    cmdutil.RequiredArgs("source-database", "branch"),
-		Aliases: []string{"b"},
-		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			if len(args) != 0 {
-				return nil, cobra.ShellCompDirectiveNoFileComp
-			}
-
-			client, err := ch.Client()
-			if err != nil {
-				return nil, cobra.ShellCompDirectiveNoFileComp
-			}
-
-			org := ch.Config.Organization // --org flag
-			if org == "" {
-				cfg, err := ch.ConfigFS.DefaultConfig()
-				if err != nil {
-					return nil, cobra.ShellCompDirectiveNoFileComp
-				}
-
-				org = cfg.Organization
-			}
-
-			databases, err := client.Databases.List(cmd.Context(), &ps.ListDatabasesRequest{
-				Organization: org,
-			})
-			if err != nil {
-				return nil, cobra.ShellCompDirectiveNoFileComp
-			}
-
-			candidates := make([]string, 0, len(databases))
-			for _, db := range databases {
-				candidates = append(candidates, db.Name)
-			}
-
-			return candidates, cobra.ShellCompDirectiveNoFileComp
-		},
+		Use:               "promote <database> <branch> [options]",
+		Short:             "Promote a new branch from a database",
+		Args:              cmdutil.RequiredArgs("source-database", "branch"),
+		ValidArgsFunction: cmdutil.BranchCompletion(ch),
+		Aliases:           []string{"b"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			source := args[0]
 			branch := args[1]
@@ -69,6 +40,20 @@ func PromoteCmd(ch *cmdutil.Helper) *cobra.Command {
 				return err
 			}
 
+			if err := cmdutil.CheckMaintenance(ch, source, override); err != nil {
+				return err
+			}
+
+			action := &approval.Action{
+				Command:      "branch promote",
+				Organization: ch.Config.Organization,
+				Database:     source,
+				Branch:       branch,
+			}
+			if err := cmdutil.CheckApproval(ch, action, approvalFile); err != nil {
+				return err
+			}
+
 			end := ch.Printer.PrintProgress(fmt.Sprintf("Promoting %s branch in %s to production...", printer.BoldBlue(branch), printer.BoldBlue(source)))
 			defer end()
 			promotionRequest, err := client.DatabaseBranches.Promote(cmd.Context(), promoteReq)
@@ -83,13 +68,8 @@ func PromoteCmd(ch *cmdutil.Helper) *cobra.Command {
 			}
 
 			if promotionRequest.State == "pending" {
-				getReq := &ps.GetPromotionRequestRequest{
-					Organization: ch.Config.Organization,
-					Database:     source,
-					Branch:       branch,
-				}
-
-				promotionRequest, err = waitPromoteState(cmd.Context(), client, getReq)
+				op := config.NewOperation(config.OperationPromote, ch.Config.Organization, source, branch, 0)
+				promotionRequest, err = WaitPromotion(cmd.Context(), client, op)
 				if err != nil {
 					switch cmdutil.ErrCode(err) {
 					case ps.ErrNotFound:
@@ -132,13 +112,26 @@ func PromoteCmd(ch *cmdutil.Helper) *cobra.Command {
 		},
 	}
 
+	cmdutil.ApprovalFlag(cmd, &approvalFile)
+	cmdutil.MaintenanceFlag(cmd, &override)
 	return cmd
 }
 
-func waitPromoteState(ctx context.Context, client *ps.Client, getReq *ps.GetPromotionRequestRequest) (*ps.BranchPromotionRequest, error) {
+// WaitPromotion waits until the promotion request of the branch isn't pending
+// anymore. The operation is persisted while waiting, so a wait that timed out
+// or was interrupted can be resumed with 'pscale resume'.
+func WaitPromotion(ctx context.Context, client *ps.Client, op *config.Operation) (*ps.BranchPromotionRequest, error) {
 	ctx, cancel := context.WithTimeout(ctx, 1*time.Minute)
 	defer cancel()
 
+	_ = op.Save() // resuming is best-effort
+
+	getReq := &ps.GetPromotionRequestRequest{
+		Organization: op.Organization,
+		Database:     op.Database,
+		Branch:       op.Branch,
+	}
+
 	ticker := time.NewTicker(time.Second)
 
 	var promotionRequest *ps.BranchPromotionRequest
@@ -154,6 +147,7 @@ func waitPromoteState(ctx context.Context, client *ps.Client, getReq *ps.GetProm
 			}
 
 			if promotionRequest.State != "pending" {
+				_ = op.Done()
 				return promotionRequest, nil
 			}
 		}
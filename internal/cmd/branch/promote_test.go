@@ -10,12 +10,15 @@ import (
 	"github.com/planetscale/cli/internal/config"
 	"github.com/planetscale/cli/internal/mock"
 	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
 	ps "github.com/planetscale/planetscale-go/planetscale"
 )
 
 func TestBranch_PromoteCmd(t *testing.T) {
 	c := qt.New(t)
 
+	testutil.TempHome(t)
+
 	var buf bytes.Buffer
 	format := printer.JSON
 	p := printer.NewPrinter(&format)
@@ -41,6 +44,11 @@ func TestBranch_PromoteCmd(t *testing.T) {
 			}, nil
 		},
 		GetPromotionRequestFn: func(ctx context.Context, req *ps.GetPromotionRequestRequest) (*ps.BranchPromotionRequest, error) {
+			ops, err := config.NewConfigFS(testutil.OSFS{}).Operations()
+			c.Assert(err, qt.IsNil)
+			c.Assert(ops, qt.HasLen, 1)
+			c.Assert(ops[0].ID, qt.Equals, "promote.planetscale.planetscale.development")
+
 			return &ps.BranchPromotionRequest{
 				Branch: branch,
 				State:  "promoted",
@@ -77,4 +85,8 @@ func TestBranch_PromoteCmd(t *testing.T) {
 	c.Assert(svc.GetFnInvoked, qt.IsTrue)
 	c.Assert(svc.GetPromotionRequestFnInvoked, qt.IsTrue)
 	c.Assert(buf.String(), qt.JSONEquals, res)
+
+	ops, err := config.NewConfigFS(testutil.OSFS{}).Operations()
+	c.Assert(err, qt.IsNil)
+	c.Assert(ops, qt.HasLen, 0)
 }
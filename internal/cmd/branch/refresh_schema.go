@@ -11,9 +11,10 @@ import (
 
 func RefreshSchemaCmd(ch *cmdutil.Helper) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "refresh-schema <database> <branch>",
-		Short: "Refresh the schema for a database branch",
-		Args:  cmdutil.RequiredArgs("database", "branch"),
+		Use:               "refresh-schema <database> <branch>",
+		Short:             "Refresh the schema for a database branch",
+		Args:              cmdutil.RequiredArgs("database", "branch"),
+		ValidArgsFunction: cmdutil.BranchCompletion(ch),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			database, branch := args[0], args[1]
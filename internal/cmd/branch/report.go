@@ -0,0 +1,231 @@
+package branch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+// ReportCmd reports the stale branches of the organization with their
+// creators.
+func ReportCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		stale   age
+		webhook string
+	}
+	flags.stale = age(30 * 24 * time.Hour)
+
+	cmd := &cobra.Command{
+		Use:   "report [database]",
+		Short: "Report the stale branches with their creators",
+		Long: `Report the stale branches with their creators.
+
+Development branches that weren't updated within --stale are reported, of the
+database or of all databases of the organization. Each branch is attributed
+to the member that created it, according to the audit log. The report can be
+exported with --format csv, or posted to a Slack or other webhook for a
+cleanup campaign.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: cmdutil.DatabaseCompletion(ch),
+		Example: `  pscale branch report --stale 30d
+  pscale branch report mydb --stale 2w --format csv > stale.csv
+  pscale branch report --webhook https://hooks.slack.com/services/...`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			var databases []string
+			if len(args) == 1 {
+				databases = args
+			} else {
+				dbs, err := client.Databases.List(ctx, &ps.ListDatabasesRequest{Organization: ch.Config.Organization})
+				if err != nil {
+					return cmdutil.HandleError(err)
+				}
+				for _, db := range dbs {
+					databases = append(databases, db.Name)
+				}
+			}
+
+			end := ch.Printer.PrintProgress("Looking for stale branches...")
+			stale, err := staleBranches(ctx, ch.Config.Organization, client, databases, time.Duration(flags.stale), time.Now())
+			end()
+			if err != nil {
+				return err
+			}
+
+			if flags.webhook != "" {
+				if err := cmdutil.PostWebhook(ctx, flags.webhook, reportPayload(ch.Config.Organization, flags.stale, stale)); err != nil {
+					return err
+				}
+			}
+
+			if len(stale) == 0 && ch.Printer.Format() == printer.Human {
+				ch.Printer.Printf("No branches of organization %s are older than %s.\n",
+					printer.BoldBlue(ch.Config.Organization), flags.stale)
+				return nil
+			}
+			return ch.Printer.PrintResource(stale)
+		},
+	}
+
+	cmd.Flags().Var(&flags.stale, "stale", "Report the branches not updated within this age, such as 30d or 2w")
+	cmd.Flags().StringVar(&flags.webhook, "webhook", "", "Post the report as JSON to this URL, such as a Slack incoming webhook")
+
+	return cmd
+}
+
+// staleBranch is a branch that wasn't updated for a while.
+type staleBranch struct {
+	Database  string `header:"database" json:"database"`
+	Branch    string `header:"branch" json:"branch"`
+	Owner     string `header:"owner,n/a" json:"owner"`
+	IdleDays  int    `header:"idle days" json:"idle_days"`
+	UpdatedAt int64  `header:"updated_at,timestamp(ms|utc|human)" json:"updated_at"`
+}
+
+// staleBranches returns the development branches of the databases that
+// weren't updated within maxAge, the longest idle first, attributed to their
+// creators.
+func staleBranches(ctx context.Context, org string, client *ps.Client, databases []string, maxAge time.Duration, now time.Time) ([]*staleBranch, error) {
+	stale := make([]*staleBranch, 0)
+	for _, database := range databases {
+		branches, err := client.DatabaseBranches.List(ctx, &ps.ListDatabaseBranchesRequest{
+			Organization: org,
+			Database:     database,
+		})
+		if err != nil {
+			switch cmdutil.ErrCode(err) {
+			case ps.ErrNotFound:
+				return nil, fmt.Errorf("database %s does not exist in organization %s",
+					printer.BoldBlue(database), printer.BoldBlue(org))
+			default:
+				return nil, cmdutil.HandleError(err)
+			}
+		}
+
+		for _, b := range branches {
+			if b.Production || now.Sub(b.UpdatedAt) < maxAge {
+				continue
+			}
+
+			stale = append(stale, &staleBranch{
+				Database:  database,
+				Branch:    b.Name,
+				IdleDays:  int(now.Sub(b.UpdatedAt) / (24 * time.Hour)),
+				UpdatedAt: b.UpdatedAt.UTC().UnixNano() / int64(time.Millisecond),
+			})
+		}
+	}
+
+	if len(stale) == 0 {
+		return stale, nil
+	}
+
+	logs, err := client.AuditLogs.List(ctx, &ps.ListAuditLogsRequest{
+		Organization: org,
+		Events:       []ps.AuditLogEvent{ps.AuditLogEventBranchCreated},
+	})
+	if err != nil {
+		return nil, cmdutil.HandleError(err)
+	}
+
+	for _, s := range stale {
+		s.Owner = branchCreator(logs, s.Database, s.Branch)
+	}
+
+	sort.SliceStable(stale, func(i, j int) bool { return stale[i].IdleDays > stale[j].IdleDays })
+	return stale, nil
+}
+
+// branchCreator returns the actor of the latest creation of the branch in
+// the audit logs.
+func branchCreator(logs []*ps.AuditLog, database, branch string) string {
+	var creator string
+	var created *time.Time
+	for _, l := range logs {
+		if l.AuditAction != string(ps.AuditLogEventBranchCreated) || l.AuditableDisplayName != branch {
+			continue
+		}
+		if l.TargetDisplayName != "" && l.TargetDisplayName != database {
+			continue
+		}
+		if created == nil || l.CreatedAt.After(*created) {
+			creator, created = l.ActorDisplayName, &l.CreatedAt
+		}
+	}
+	return creator
+}
+
+// reportPayload returns the webhook payload of the report, of which the text
+// is shown by Slack.
+func reportPayload(org string, stale age, branches []*staleBranch) map[string]interface{} {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d branches of organization %s weren't updated within %s.", len(branches), org, stale)
+	for _, s := range branches {
+		owner := s.Owner
+		if owner == "" {
+			owner = "unknown"
+		}
+		fmt.Fprintf(&b, "\n• %s/%s, idle for %d days, created by %s", s.Database, s.Branch, s.IdleDays, owner)
+	}
+
+	return map[string]interface{}{
+		"text":     b.String(),
+		"org":      org,
+		"branches": branches,
+	}
+}
+
+// age is a duration flag that also accepts days and weeks, such as 30d or
+// 2w.
+type age time.Duration
+
+func (a age) String() string {
+	d := time.Duration(a)
+	switch {
+	case d != 0 && d%(7*24*time.Hour) == 0:
+		return fmt.Sprintf("%dw", d/(7*24*time.Hour))
+	case d != 0 && d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
+
+func (a *age) Set(s string) error {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if strings.HasSuffix(s, suffix) {
+			v, err := strconv.Atoi(strings.TrimSuffix(s, suffix))
+			if err != nil || v < 0 {
+				return fmt.Errorf("invalid age %q", s)
+			}
+			*a = age(time.Duration(v) * unit)
+			return nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return errors.New("use a duration such as 30d, 2w or 12h")
+	}
+	*a = age(d)
+	return nil
+}
+
+func (a *age) Type() string {
+	return "age"
+}
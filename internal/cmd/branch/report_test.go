@@ -0,0 +1,84 @@
+package branch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/printer"
+
+	qt "github.com/frankban/quicktest"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+func TestBranch_ReportCmd(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	now := time.Now()
+	branches := &mock.DatabaseBranchesService{
+		ListFn: func(ctx context.Context, req *ps.ListDatabaseBranchesRequest) ([]*ps.DatabaseBranch, error) {
+			c.Assert(req.Database, qt.Equals, "mydb")
+			return []*ps.DatabaseBranch{
+				{Name: "main", Production: true, UpdatedAt: now.Add(-90 * 24 * time.Hour)},
+				{Name: "fresh", UpdatedAt: now.Add(-time.Hour)},
+				{Name: "old", UpdatedAt: now.Add(-40 * 24 * time.Hour)},
+				{Name: "older", UpdatedAt: now.Add(-60 * 24 * time.Hour)},
+			}, nil
+		},
+	}
+	logs := &mock.AuditLogService{
+		ListFn: func(ctx context.Context, req *ps.ListAuditLogsRequest) ([]*ps.AuditLog, error) {
+			c.Assert(req.Events, qt.DeepEquals, []ps.AuditLogEvent{ps.AuditLogEventBranchCreated})
+			return []*ps.AuditLog{
+				{AuditAction: "branch.created", ActorDisplayName: "alice", AuditableDisplayName: "old", TargetDisplayName: "mydb"},
+				{AuditAction: "branch.created", ActorDisplayName: "bob", AuditableDisplayName: "old", TargetDisplayName: "otherdb"},
+			}, nil
+		},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config:  &config.Config{Organization: "planetscale"},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{DatabaseBranches: branches, AuditLogs: logs}, nil
+		},
+	}
+
+	cmd := ReportCmd(ch)
+	cmd.SetArgs([]string{"mydb", "--stale", "30d"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	var got []*staleBranch
+	c.Assert(json.Unmarshal(buf.Bytes(), &got), qt.IsNil)
+	c.Assert(got, qt.HasLen, 2)
+	c.Assert(got[0].Branch, qt.Equals, "older")
+	c.Assert(got[0].Owner, qt.Equals, "")
+	c.Assert(got[0].IdleDays, qt.Equals, 60)
+	c.Assert(got[1].Branch, qt.Equals, "old")
+	c.Assert(got[1].Owner, qt.Equals, "alice")
+}
+
+func TestAge(t *testing.T) {
+	c := qt.New(t)
+
+	var a age
+	c.Assert(a.Set("30d"), qt.IsNil)
+	c.Assert(time.Duration(a), qt.Equals, 30*24*time.Hour)
+	c.Assert(a.String(), qt.Equals, "30d")
+	c.Assert(a.Set("2w"), qt.IsNil)
+	c.Assert(a.String(), qt.Equals, "2w")
+	c.Assert(a.Set("12h"), qt.IsNil)
+	c.Assert(a.String(), qt.Equals, "12h0m0s")
+	c.Assert(a.Set("xd"), qt.ErrorMatches, `invalid age "xd"`)
+	c.Assert(a.Set("soon"), qt.ErrorMatches, `use a duration such as 30d, 2w or 12h`)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/pkg/browser"
 	"github.com/planetscale/cli/internal/cmdutil"
 	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/schemacache"
 	"github.com/planetscale/planetscale-go/planetscale"
 	"github.com/spf13/cobra"
 )
@@ -16,13 +17,19 @@ import (
 // SchemaCmd is the command for showing the schema of a branch.
 func SchemaCmd(ch *cmdutil.Helper) *cobra.Command {
 	var flags struct {
-		web bool
+		web     bool
+		refresh bool
 	}
 
 	cmd := &cobra.Command{
 		Use:   "schema <database> <branch>",
 		Short: "Show the schema of a branch",
-		Args:  cmdutil.RequiredArgs("database", "branch"),
+		Long: `Show the schema of a branch.
+
+The schema is cached locally and only fetched again once the branch was
+updated, pass --refresh to always fetch it.`,
+		Args:              cmdutil.RequiredArgs("database", "branch"),
+		ValidArgsFunction: cmdutil.BranchCompletion(ch),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			database, branch := args[0], args[1]
@@ -36,11 +43,11 @@ func SchemaCmd(ch *cmdutil.Helper) *cobra.Command {
 				return err
 			}
 
-			schemas, err := client.DatabaseBranches.Schema(ctx, &planetscale.BranchSchemaRequest{
+			schemas, err := schemacache.Schema(ctx, client, &planetscale.BranchSchemaRequest{
 				Organization: ch.Config.Organization,
 				Database:     database,
 				Branch:       branch,
-			})
+			}, flags.refresh)
 			if err != nil {
 				switch cmdutil.ErrCode(err) {
 				case planetscale.ErrNotFound:
@@ -79,6 +86,7 @@ func SchemaCmd(ch *cmdutil.Helper) *cobra.Command {
 	}
 
 	cmd.PersistentFlags().BoolVar(&flags.web, "web", false, "Open in your web browser")
+	cmd.Flags().BoolVar(&flags.refresh, "refresh", false, "Fetch the schema even if the branch is unchanged since it was cached")
 
 	return cmd
 }
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"context"
 	"testing"
+	"time"
 
 	"github.com/planetscale/cli/internal/cmdutil"
 	"github.com/planetscale/cli/internal/config"
 	"github.com/planetscale/cli/internal/mock"
 	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
 
 	qt "github.com/frankban/quicktest"
 	ps "github.com/planetscale/planetscale-go/planetscale"
@@ -16,6 +18,7 @@ import (
 
 func TestBranchSchemaCmd(t *testing.T) {
 	c := qt.New(t)
+	testutil.TempHome(t)
 
 	var buf bytes.Buffer
 	format := printer.JSON
@@ -32,6 +35,9 @@ func TestBranchSchemaCmd(t *testing.T) {
 	}
 
 	svc := &mock.DatabaseBranchesService{
+		GetFn: func(ctx context.Context, req *ps.GetDatabaseBranchRequest) (*ps.DatabaseBranch, error) {
+			return &ps.DatabaseBranch{Name: branch, UpdatedAt: time.Unix(1632700000, 0)}, nil
+		},
 		SchemaFn: func(ctx context.Context, req *ps.BranchSchemaRequest) ([]*ps.Diff, error) {
 			c.Assert(req.Organization, qt.Equals, org)
 			c.Assert(req.Database, qt.Equals, db)
@@ -62,4 +68,22 @@ func TestBranchSchemaCmd(t *testing.T) {
 	c.Assert(svc.SchemaFnInvoked, qt.IsTrue)
 
 	c.Assert(buf.String(), qt.JSONEquals, res)
+
+	// the unchanged branch is served from the cache
+	buf.Reset()
+	svc.SchemaFnInvoked = false
+	cmd = SchemaCmd(ch)
+	cmd.SetArgs([]string{db, branch})
+	err = cmd.Execute()
+
+	c.Assert(err, qt.IsNil)
+	c.Assert(svc.SchemaFnInvoked, qt.IsFalse)
+	c.Assert(buf.String(), qt.JSONEquals, res)
+
+	cmd = SchemaCmd(ch)
+	cmd.SetArgs([]string{db, branch, "--refresh"})
+	err = cmd.Execute()
+
+	c.Assert(err, qt.IsNil)
+	c.Assert(svc.SchemaFnInvoked, qt.IsTrue)
 }
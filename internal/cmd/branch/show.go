@@ -14,9 +14,10 @@ import (
 
 func ShowCmd(ch *cmdutil.Helper) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "show <source-database> <branch>",
-		Short: "Show a specific branch of a database",
-		Args:  cmdutil.RequiredArgs("source-database", "branch"),
+		Use:               "show <source-database> <branch>",
+		Short:             "Show a specific branch of a database",
+		Args:              cmdutil.RequiredArgs("source-database", "branch"),
+		ValidArgsFunction: cmdutil.BranchCompletion(ch),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			source := args[0]
@@ -41,6 +42,11 @@ func ShowCmd(ch *cmdutil.Helper) *cobra.Command {
 				return err
 			}
 
+			branch, err = cmdutil.ResolveBranch(ctx, client, ch.Config.Organization, source, branch)
+			if err != nil {
+				return err
+			}
+
 			end := ch.Printer.PrintProgress(fmt.Sprintf("Fetching branch %s for %s", printer.BoldBlue(branch), printer.BoldBlue(source)))
 			defer end()
 			b, err := client.DatabaseBranches.Get(ctx, &planetscale.GetDatabaseBranchRequest{
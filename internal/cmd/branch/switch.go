@@ -3,6 +3,7 @@ package branch
 import (
 	"fmt"
 	"net/http"
+	"os"
 
 	"github.com/pkg/errors"
 	"github.com/planetscale/cli/internal/cmdutil"
@@ -11,6 +12,7 @@ import (
 	ps "github.com/planetscale/planetscale-go/planetscale"
 
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
 )
 
 func SwitchCmd(ch *cmdutil.Helper) *cobra.Command {
@@ -66,13 +68,23 @@ func SwitchCmd(ch *cmdutil.Helper) *cobra.Command {
 				end()
 			}
 
-			cfg := config.FileConfig{
-				Organization: ch.Config.Organization,
-				Database:     ch.Config.Database,
-				Branch:       branch,
+			cfgFile, err := config.ProjectConfigPath()
+			if err != nil {
+				return err
+			}
+
+			// only the branch of an existing project configuration changes,
+			// the other values are kept.
+			values := yaml.MapSlice{{Key: "branch", Value: branch}}
+			if _, err := os.Stat(cfgFile); os.IsNotExist(err) {
+				values = yaml.MapSlice{
+					{Key: "org", Value: ch.Config.Organization},
+					{Key: "database", Value: ch.Config.Database},
+					{Key: "branch", Value: branch},
+				}
 			}
 
-			if err := cfg.WriteProject(); err != nil {
+			if err := config.SetValues(cfgFile, values); err != nil {
 				return errors.Wrap(err, "error writing project configuration file")
 			}
 
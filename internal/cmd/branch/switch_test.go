@@ -0,0 +1,67 @@
+package branch
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+
+	qt "github.com/frankban/quicktest"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestBranch_SwitchCmd(t *testing.T) {
+	c := qt.New(t)
+
+	// only the branch changes, the other keys of the file are kept
+	dir := t.TempDir()
+	c.Assert(os.Mkdir(filepath.Join(dir, ".git"), 0755), qt.IsNil)
+	project := "org: planetscale\ndatabase: mydb\nbranch: main\nschema-dir: schema\naliases:\n  prod:\n    branch: main\n"
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, ".pscale.yml"), []byte(project), 0644), qt.IsNil)
+	wd, err := os.Getwd()
+	c.Assert(err, qt.IsNil)
+	c.Assert(os.Chdir(dir), qt.IsNil)
+	c.Cleanup(func() { os.Chdir(wd) }) // nolint:errcheck
+
+	var buf bytes.Buffer
+	format := printer.Human
+	p := printer.NewPrinter(&format)
+	p.SetHumanOutput(&buf)
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config: &config.Config{
+			AccessToken:  "token",
+			BaseURL:      "https://api.planetscale.test",
+			Organization: "planetscale",
+			Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				c.Assert(req.URL.Path, qt.Equals, "/v1/organizations/planetscale/databases/mydb/branches/feature")
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       ioutil.NopCloser(strings.NewReader(`{"name": "feature"}`)),
+				}, nil
+			}),
+		},
+	}
+
+	cmd := SwitchCmd(ch)
+	cmd.SetArgs([]string{"feature", "--database", "mydb"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	out, err := ioutil.ReadFile(filepath.Join(dir, ".pscale.yml"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, strings.Replace(project, "branch: main\nschema", "branch: feature\nschema", 1))
+}
@@ -0,0 +1,256 @@
+package branch
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+// BranchNode is a branch with the branches created from it.
+type BranchNode struct {
+	Name       string        `json:"name"`
+	Production bool          `json:"production"`
+	Ready      bool          `json:"ready"`
+	CreatedAt  time.Time     `json:"created_at"`
+	Children   []*BranchNode `json:"children"`
+}
+
+// BranchTree is the hierarchy of the branches of a database. Its roots are
+// the branches without a parent, or whose parent was deleted.
+type BranchTree struct {
+	Database string
+	Roots    []*BranchNode
+
+	now time.Time
+}
+
+// treeRow is a branch of the tree in the CSV output.
+type treeRow struct {
+	Name       string    `json:"name"`
+	Parent     string    `json:"parent"`
+	Depth      int       `json:"depth"`
+	Production bool      `json:"production"`
+	Ready      bool      `json:"ready"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TreeCmd renders the hierarchy of the branches of a database.
+func TreeCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tree <database>",
+		Short: "Show the parent and child branches of a database as a tree",
+		Long: `Show the parent and child branches of a database as a tree.
+
+Each branch is annotated with its state and its age. With --format dot, the
+tree is printed as a Graphviz graph.`,
+		Example: `Show the branches of mydb as a tree:
+
+  pscale branch tree mydb
+
+Render the branches of mydb as an image with Graphviz:
+
+  pscale branch tree mydb --format dot | dot -Tpng -o branches.png`,
+		Args:              cmdutil.RequiredArgs("database"),
+		ValidArgsFunction: cmdutil.DatabaseCompletion(ch),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database := args[0]
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Fetching branches for %s", printer.BoldBlue(database)))
+			defer end()
+
+			branches, err := client.DatabaseBranches.List(ctx, &ps.ListDatabaseBranchesRequest{
+				Organization: ch.Config.Organization,
+				Database:     database,
+			})
+			if err != nil {
+				switch cmdutil.ErrCode(err) {
+				case ps.ErrNotFound:
+					return fmt.Errorf("database %s does not exist in organization %s",
+						printer.BoldBlue(database), printer.BoldBlue(ch.Config.Organization))
+				default:
+					return cmdutil.HandleError(err)
+				}
+			}
+
+			end()
+
+			tree := newBranchTree(database, branches, time.Now())
+			if ch.Printer.Format() == printer.Human && !ch.Printer.Quiet() {
+				ch.Printer.Print(tree.String())
+				return nil
+			}
+
+			return ch.Printer.PrintResource(tree)
+		},
+	}
+
+	return cmd
+}
+
+// newBranchTree builds the hierarchy of the branches, with the children of
+// each branch sorted by name.
+func newBranchTree(database string, branches []*ps.DatabaseBranch, now time.Time) *BranchTree {
+	nodes := make(map[string]*BranchNode, len(branches))
+	for _, b := range branches {
+		nodes[b.Name] = &BranchNode{
+			Name:       b.Name,
+			Production: b.Production,
+			Ready:      b.Ready,
+			CreatedAt:  b.CreatedAt,
+			Children:   []*BranchNode{},
+		}
+	}
+
+	tree := &BranchTree{Database: database, Roots: []*BranchNode{}, now: now}
+	for _, b := range branches {
+		n := nodes[b.Name]
+		if parent, ok := nodes[b.ParentBranch]; ok && b.ParentBranch != b.Name {
+			parent.Children = append(parent.Children, n)
+		} else {
+			tree.Roots = append(tree.Roots, n)
+		}
+	}
+
+	for _, n := range nodes {
+		sortNodes(n.Children)
+	}
+	sortNodes(tree.Roots)
+	return tree
+}
+
+func sortNodes(nodes []*BranchNode) {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+}
+
+// String renders the tree with box-drawing characters.
+func (t *BranchTree) String() string {
+	var b strings.Builder
+	for _, n := range t.Roots {
+		fmt.Fprintf(&b, "%s %s\n", printer.BoldBlue(n.Name), t.annotation(n))
+		t.writeChildren(&b, n, "")
+	}
+	return b.String()
+}
+
+func (t *BranchTree) writeChildren(b *strings.Builder, n *BranchNode, indent string) {
+	for i, c := range n.Children {
+		branch, next := "├── ", "│   "
+		if i == len(n.Children)-1 {
+			branch, next = "└── ", "    "
+		}
+
+		fmt.Fprintf(b, "%s%s%s %s\n", indent, branch, printer.BoldBlue(c.Name), t.annotation(c))
+		t.writeChildren(b, c, indent+next)
+	}
+}
+
+// annotation returns the state and the age of the branch, i.e.
+// "(production, ready, 3d)".
+func (t *BranchTree) annotation(n *BranchNode) string {
+	var notes []string
+	if n.Production {
+		notes = append(notes, "production")
+	}
+	if n.Ready {
+		notes = append(notes, "ready")
+	} else {
+		notes = append(notes, "not ready")
+	}
+	notes = append(notes, shortAge(t.now.Sub(n.CreatedAt)))
+	return "(" + strings.Join(notes, ", ") + ")"
+}
+
+// shortAge returns the duration in its largest unit, i.e. "3d" or "5h".
+func shortAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return "just now"
+}
+
+// MarshalDot renders the tree as a Graphviz graph. Production branches are
+// drawn with a bold outline.
+func (t *BranchTree) MarshalDot() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %q {\n", t.Database)
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+
+	var walk func(nodes []*BranchNode)
+	walk = func(nodes []*BranchNode) {
+		for _, n := range nodes {
+			label := fmt.Sprintf("%s\n%s", n.Name, strings.Trim(t.annotation(n), "()"))
+			style := ""
+			if n.Production {
+				style = ", style=bold"
+			}
+			fmt.Fprintf(&b, "  %q [label=%q%s];\n", n.Name, label, style)
+			for _, c := range n.Children {
+				fmt.Fprintf(&b, "  %q -> %q;\n", n.Name, c.Name)
+			}
+			walk(n.Children)
+		}
+	}
+	walk(t.Roots)
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Identifier returns the names of the branches in the order of the tree, one
+// per line.
+func (t *BranchTree) Identifier() string {
+	var names []string
+	for _, row := range t.MarshalCSVValue().([]*treeRow) {
+		names = append(names, row.Name)
+	}
+	return strings.Join(names, "\n")
+}
+
+// MarshalJSON prints the roots of the tree with their children.
+func (t *BranchTree) MarshalJSON() ([]byte, error) {
+	return json.MarshalIndent(t.Roots, "", "  ")
+}
+
+// MarshalCSVValue flattens the tree, every branch is listed with its parent
+// and its depth in the tree.
+func (t *BranchTree) MarshalCSVValue() interface{} {
+	rows := []*treeRow{}
+
+	var walk func(nodes []*BranchNode, parent string, depth int)
+	walk = func(nodes []*BranchNode, parent string, depth int) {
+		for _, n := range nodes {
+			rows = append(rows, &treeRow{
+				Name:       n.Name,
+				Parent:     parent,
+				Depth:      depth,
+				Production: n.Production,
+				Ready:      n.Ready,
+				CreatedAt:  n.CreatedAt,
+			})
+			walk(n.Children, n.Name, depth+1)
+		}
+	}
+	walk(t.Roots, "", 0)
+
+	return rows
+}
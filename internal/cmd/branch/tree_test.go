@@ -0,0 +1,89 @@
+package branch
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/fatih/color"
+	qt "github.com/frankban/quicktest"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+func TestBranch_Tree(t *testing.T) {
+	c := qt.New(t)
+
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	branches := []*ps.DatabaseBranch{
+		{Name: "staging", ParentBranch: "main", Ready: true, CreatedAt: now.AddDate(0, 0, -10)},
+		{Name: "main", Production: true, Ready: true, CreatedAt: now.AddDate(0, 0, -120)},
+		{Name: "feature", ParentBranch: "dev", CreatedAt: now.Add(-2 * time.Hour)},
+		{Name: "dev", ParentBranch: "main", Ready: true, CreatedAt: now.AddDate(0, 0, -3)},
+		{Name: "orphan", ParentBranch: "deleted", Ready: true, CreatedAt: now.Add(-30 * time.Second)},
+	}
+
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	tree := newBranchTree("mydb", branches, now)
+
+	c.Assert(tree.String(), qt.Equals, `main (production, ready, 120d)
+├── dev (ready, 3d)
+│   └── feature (not ready, 2h)
+└── staging (ready, 10d)
+orphan (ready, just now)
+`)
+
+	c.Assert(tree.MarshalDot(), qt.Equals, `digraph "mydb" {
+  rankdir=LR;
+  node [shape=box];
+  "main" [label="main\nproduction, ready, 120d", style=bold];
+  "main" -> "dev";
+  "main" -> "staging";
+  "dev" [label="dev\nready, 3d"];
+  "dev" -> "feature";
+  "feature" [label="feature\nnot ready, 2h"];
+  "staging" [label="staging\nready, 10d"];
+  "orphan" [label="orphan\nready, just now"];
+}
+`)
+
+	c.Assert(tree.Identifier(), qt.Equals, "main\ndev\nfeature\nstaging\norphan")
+}
+
+func TestBranch_TreeCmd_Dot(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	format := printer.Dot
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	svc := &mock.DatabaseBranchesService{
+		ListFn: func(ctx context.Context, req *ps.ListDatabaseBranchesRequest) ([]*ps.DatabaseBranch, error) {
+			c.Assert(req.Database, qt.Equals, "mydb")
+			return []*ps.DatabaseBranch{{Name: "main", Production: true, Ready: true, CreatedAt: time.Now()}}, nil
+		},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config:  &config.Config{Organization: "planetscale"},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{DatabaseBranches: svc}, nil
+		},
+	}
+
+	cmd := TreeCmd(ch)
+	cmd.SetArgs([]string{"mydb"})
+	err := cmd.Execute()
+	c.Assert(err, qt.IsNil)
+	c.Assert(buf.String(), qt.Contains, `"main" [label="main\nproduction, ready, just now", style=bold];`)
+}
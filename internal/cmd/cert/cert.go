@@ -0,0 +1,89 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/spf13/cobra"
+)
+
+// CertCmd encapsulates the commands for inspecting the TLS certificates of
+// database endpoints.
+func CertCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cert <command>",
+		Short: "Inspect the TLS certificates of database endpoints",
+	}
+
+	cmd.AddCommand(ShowCmd(ch))
+	cmd.AddCommand(RefreshCmd(ch))
+
+	return cmd
+}
+
+// certificate returns a table-serializable certificate of a chain.
+type certificate struct {
+	Position    string   `header:"position" json:"position"`
+	Subject     string   `header:"subject" json:"subject"`
+	Issuer      string   `header:"issuer" json:"issuer"`
+	NotBefore   int64    `header:"-" json:"not_before"`
+	NotAfter    int64    `header:"expires_at,timestamp(ms|utc|human)" json:"not_after"`
+	Pin         string   `header:"pin" json:"pin"`
+	Fingerprint string   `header:"-" json:"fingerprint"`
+	DNSNames    []string `header:"-" json:"dns_names,omitempty"`
+}
+
+func toCertificates(chain []*x509.Certificate) []*certificate {
+	certs := make([]*certificate, 0, len(chain))
+	for i, c := range chain {
+		position := "intermediate"
+		switch {
+		case i == 0:
+			position = "leaf"
+		case bytes.Equal(c.RawSubject, c.RawIssuer):
+			position = "root"
+		}
+
+		fingerprint := sha256.Sum256(c.Raw)
+		certs = append(certs, &certificate{
+			Position:    position,
+			Subject:     name(c.Subject.CommonName, c.Subject.String()),
+			Issuer:      name(c.Issuer.CommonName, c.Issuer.String()),
+			NotBefore:   printer.GetMilliseconds(c.NotBefore),
+			NotAfter:    printer.GetMilliseconds(c.NotAfter),
+			Pin:         pin(c),
+			Fingerprint: hexColons(fingerprint[:]),
+			DNSNames:    c.DNSNames,
+		})
+	}
+	return certs
+}
+
+// pin returns the SHA-256 pin of the public key of the certificate, as
+// used by HPKP and most pinning libraries.
+func pin(c *x509.Certificate) string {
+	sum := sha256.Sum256(c.RawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func hexColons(b []byte) string {
+	parts := make([]string, len(b))
+	for i, v := range b {
+		parts[i] = fmt.Sprintf("%02X", v)
+	}
+	return strings.Join(parts, ":")
+}
+
+func name(commonName, full string) string {
+	if commonName != "" {
+		return commonName
+	}
+	return full
+}
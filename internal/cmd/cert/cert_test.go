@@ -0,0 +1,208 @@
+package cert
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/certcache"
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestShowCmd(t *testing.T) {
+	c := qt.New(t)
+
+	ca, serverCert := testCerts(c)
+	addr := serveMySQL(c, serverCert)
+
+	for _, trusted := range []bool{true, false} {
+		roots = nil
+		if trusted {
+			roots = x509.NewCertPool()
+			roots.AddCert(ca)
+		}
+
+		var buf bytes.Buffer
+		format := printer.JSON
+		p := printer.NewPrinter(&format)
+		p.SetResourceOutput(&buf)
+
+		cmd := ShowCmd(&cmdutil.Helper{Printer: p, Config: &config.Config{}})
+		cmd.SetArgs([]string{addr})
+		c.Assert(cmd.Execute(), qt.IsNil)
+
+		var res chain
+		c.Assert(json.Unmarshal(buf.Bytes(), &res), qt.IsNil)
+		c.Assert(res.Host, qt.Equals, "127.0.0.1")
+		c.Assert(res.Verified, qt.Equals, trusted)
+		c.Assert(res.Certificates[0].Position, qt.Equals, "leaf")
+		c.Assert(res.Certificates[0].Pin, qt.Equals, pin(serverCert.Leaf))
+
+		if trusted {
+			c.Assert(res.Certificates, qt.HasLen, 2)
+			c.Assert(res.Certificates[1].Position, qt.Equals, "root")
+			c.Assert(res.Certificates[1].Subject, qt.Equals, "pscale test CA")
+		} else {
+			c.Assert(res.Error, qt.Not(qt.Equals), "")
+		}
+	}
+	roots = nil
+}
+
+func TestRefreshCmd(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	ca, serverCert := testCerts(c)
+	addr := serveMySQL(c, serverCert)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+	ch := &cmdutil.Helper{Printer: p, Config: &config.Config{}}
+
+	// untrusted chains aren't cached
+	cmd := RefreshCmd(ch)
+	cmd.SetArgs([]string{addr})
+	c.Assert(cmd.Execute(), qt.ErrorMatches, "not caching the CA certificates of 127.0.0.1.*")
+
+	roots = x509.NewCertPool()
+	roots.AddCert(ca)
+	defer func() { roots = nil }()
+
+	cmd = RefreshCmd(ch)
+	cmd.SetArgs([]string{addr})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	hosts, err := certcache.Hosts()
+	c.Assert(err, qt.IsNil)
+	c.Assert(hosts, qt.DeepEquals, []string{"127.0.0.1"})
+
+	file := certcache.Bundle("127.0.0.1")
+	c.Assert(buf.String(), qt.JSONEquals, []*cached{{
+		Host:      "127.0.0.1",
+		File:      file,
+		Count:     1,
+		ExpiresAt: printer.GetMilliseconds(ca.NotAfter),
+	}})
+
+	out, err := ioutil.ReadFile(file)
+	c.Assert(err, qt.IsNil)
+	pool := x509.NewCertPool()
+	c.Assert(pool.AppendCertsFromPEM(out), qt.IsTrue)
+	_, err = serverCert.Leaf.Verify(x509.VerifyOptions{DNSName: "127.0.0.1", Roots: pool})
+	c.Assert(err, qt.IsNil)
+}
+
+func TestServerCapabilities(t *testing.T) {
+	c := qt.New(t)
+
+	caps, err := serverCapabilities(greeting(clientSSL | clientProtocol41))
+	c.Assert(err, qt.IsNil)
+	c.Assert(caps&clientSSL, qt.Not(qt.Equals), uint32(0))
+
+	_, err = serverCapabilities([]byte("\xff\x15\x04#28000Access denied"))
+	c.Assert(err, qt.ErrorMatches, "the MySQL server refused the connection: 28000Access denied")
+
+	_, err = serverCapabilities([]byte("HTTP/1.1 400"))
+	c.Assert(err, qt.ErrorMatches, "the server doesn't speak the MySQL protocol.*")
+}
+
+// testCerts returns a CA and a certificate for 127.0.0.1 issued by it.
+func testCerts(c *qt.C) (*x509.Certificate, tls.Certificate) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, qt.IsNil)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "pscale test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour).Truncate(time.Second),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	c.Assert(err, qt.IsNil)
+	ca, err := x509.ParseCertificate(caDER)
+	c.Assert(err, qt.IsNil)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, qt.IsNil)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(12 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	c.Assert(err, qt.IsNil)
+	leaf, err := x509.ParseCertificate(der)
+	c.Assert(err, qt.IsNil)
+
+	return ca, tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// serveMySQL starts a server switching MySQL connections to TLS and
+// returns its address.
+func serveMySQL(c *qt.C, cert tls.Certificate) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, qt.IsNil)
+	c.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				payload := greeting(clientSSL | clientProtocol41 | clientSecureConnection)
+				header := []byte{byte(len(payload)), 0, 0, 0}
+				if _, err := conn.Write(append(header, payload...)); err != nil {
+					return
+				}
+				if _, err := io.ReadFull(conn, make([]byte, 36)); err != nil {
+					return
+				}
+
+				_ = tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{cert}}).Handshake()
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+// greeting returns the initial handshake packet of a MySQL server.
+func greeting(caps uint16) []byte {
+	p := []byte{10}
+	p = append(p, "8.0.23-vitess\x00"...)
+	p = append(p, 1, 0, 0, 0)         // connection id
+	p = append(p, make([]byte, 8)...) // auth data
+	p = append(p, 0)                  // filler
+	p = append(p, byte(caps), byte(caps>>8))
+	return append(p, 45, 2, 0)
+}
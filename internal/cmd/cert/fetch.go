@@ -0,0 +1,168 @@
+package cert
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	protocolMySQL = "mysql"
+	protocolTLS   = "tls"
+
+	defaultPort  = "3306"
+	fetchTimeout = 10 * time.Second
+
+	// the capability flags of the MySQL protocol
+	clientProtocol41       = 0x00000200
+	clientSSL              = 0x00000800
+	clientSecureConnection = 0x00008000
+)
+
+// dialFunc connects to an address, i.e. config.Config.DialContext.
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// fetchChain returns the certificates the server at addr presents for host,
+// leaf first. MySQL servers are asked to upgrade the connection to TLS,
+// other servers are expected to speak TLS right away.
+func fetchChain(ctx context.Context, dial dialFunc, addr, host, protocol string) ([]*x509.Certificate, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, fetchTimeout)
+		defer cancel()
+	}
+
+	conn, err := dial(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	switch protocol {
+	case protocolMySQL:
+		if err := requestSSL(conn); err != nil {
+			return nil, err
+		}
+	case protocolTLS:
+	default:
+		return nil, fmt.Errorf("unsupported protocol %q, supported protocols are: %s, %s", protocol, protocolMySQL, protocolTLS)
+	}
+
+	// the chain is verified separately, so it can be shown even if it's
+	// invalid
+	tconn := tls.Client(conn, &tls.Config{ServerName: host, InsecureSkipVerify: true}) // nolint: gosec
+	if err := tconn.Handshake(); err != nil {
+		return nil, fmt.Errorf("TLS handshake failed: %s", err)
+	}
+
+	return tconn.ConnectionState().PeerCertificates, nil
+}
+
+// requestSSL reads the handshake of a MySQL server and asks it to switch
+// to TLS.
+func requestSSL(conn io.ReadWriter) error {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return fmt.Errorf("reading the MySQL handshake: %s", err)
+	}
+
+	length := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return fmt.Errorf("reading the MySQL handshake: %s", err)
+	}
+
+	caps, err := serverCapabilities(payload)
+	if err != nil {
+		return err
+	}
+	if caps&clientSSL == 0 {
+		return errors.New("the MySQL server doesn't support TLS")
+	}
+
+	req := make([]byte, 4+32)
+	req[0] = 32 // the length of the payload
+	req[3] = header[3] + 1
+	binary.LittleEndian.PutUint32(req[4:], clientProtocol41|clientSSL|clientSecureConnection)
+	binary.LittleEndian.PutUint32(req[8:], 1<<24) // max packet size
+	req[12] = 45                                  // utf8mb4_general_ci
+
+	_, err = conn.Write(req)
+	return err
+}
+
+// serverCapabilities returns the lower capability flags of the initial
+// handshake packet of a MySQL server.
+func serverCapabilities(payload []byte) (uint32, error) {
+	if len(payload) > 0 && payload[0] == 0xff {
+		msg := ""
+		if len(payload) > 3 {
+			msg = strings.TrimPrefix(string(payload[3:]), "#")
+		}
+		return 0, fmt.Errorf("the MySQL server refused the connection: %s", msg)
+	}
+
+	if len(payload) == 0 || payload[0] != 10 {
+		return 0, errors.New("the server doesn't speak the MySQL protocol, use --protocol tls for TLS servers")
+	}
+
+	// protocol version, null terminated server version, connection id,
+	// first part of the auth data and a filler precede the flags
+	end := strings.IndexByte(string(payload[1:]), 0)
+	if end < 0 {
+		return 0, errors.New("invalid MySQL handshake")
+	}
+	i := 1 + end + 1 + 4 + 8 + 1
+	if len(payload) < i+2 {
+		return 0, errors.New("invalid MySQL handshake")
+	}
+
+	return uint32(binary.LittleEndian.Uint16(payload[i:])), nil
+}
+
+// roots are the trusted root certificates, the ones of the system if nil.
+var roots *x509.CertPool
+
+// verifyChain verifies the chain for host against the trusted roots and
+// returns the verified chain, which ends with the trusted root.
+func verifyChain(chain []*x509.Certificate, host string) ([]*x509.Certificate, error) {
+	if len(chain) == 0 {
+		return nil, errors.New("the server presented no certificates")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range chain[1:] {
+		intermediates.AddCert(c)
+	}
+
+	chains, err := chain[0].Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Intermediates: intermediates,
+		Roots:         roots,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chains[0], nil
+}
+
+// splitHost returns the address to dial and the host name of the argument,
+// which is a host with an optional port.
+func splitHost(arg string) (addr, host string) {
+	if h, _, err := net.SplitHostPort(arg); err == nil {
+		return arg, h
+	}
+	return net.JoinHostPort(arg, defaultPort), arg
+}
@@ -0,0 +1,103 @@
+package cert
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/planetscale/cli/internal/certcache"
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/spf13/cobra"
+)
+
+// cached returns a table-serializable CA bundle of a host.
+type cached struct {
+	Host      string `header:"host" json:"host"`
+	File      string `header:"file" json:"file"`
+	Count     int    `header:"certificates" json:"certificates"`
+	ExpiresAt int64  `header:"expires_at,timestamp(ms|utc|human)" json:"expires_at"`
+}
+
+// RefreshCmd is the command for updating the cached CA certificates.
+func RefreshCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		protocol string
+	}
+
+	cmd := &cobra.Command{
+		Use:   "refresh [host...]",
+		Short: "Update the cached CA certificates of database endpoints",
+		Long: `Update the cached CA certificates of database endpoints.
+
+The chain of each host is verified against the CA certificates of the system
+and its CA certificates are cached, i.e. for pscale connect --emit odbc on
+systems without a CA bundle. Without hosts, the certificates of all cached
+hosts are updated.`,
+		Example: `  pscale cert refresh aws.connect.psdb.cloud
+  pscale cert refresh`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hosts := args
+			if len(hosts) == 0 {
+				var err error
+				hosts, err = certcache.Hosts()
+				if err != nil {
+					return err
+				}
+				if len(hosts) == 0 {
+					return errors.New("no CA certificates are cached yet, run 'pscale cert refresh <host>' first")
+				}
+			}
+
+			var refreshed []*cached
+			for _, arg := range hosts {
+				addr, host := splitHost(arg)
+
+				end := ch.Printer.PrintProgress(fmt.Sprintf("Fetching the certificate chain of %s...", printer.BoldBlue(addr)))
+				presented, err := fetchChain(cmd.Context(), ch.Config.DialContext, addr, host, flags.protocol)
+				end()
+				if err != nil {
+					return fmt.Errorf("fetching the certificate chain of %s: %s", addr, err)
+				}
+
+				// only trusted certificates are cached, anything else would
+				// let clients trust whoever answered
+				verified, err := verifyChain(presented, host)
+				if err != nil {
+					return fmt.Errorf("not caching the CA certificates of %s, the chain isn't trusted: %s", host, err)
+				}
+
+				cas := verified
+				if len(cas) > 1 {
+					cas = cas[1:]
+				}
+
+				file, err := certcache.Write(host, cas)
+				if err != nil {
+					return fmt.Errorf("caching the CA certificates of %s: %s", host, err)
+				}
+
+				expires := cas[0].NotAfter
+				for _, c := range cas[1:] {
+					if c.NotAfter.Before(expires) {
+						expires = c.NotAfter
+					}
+				}
+
+				refreshed = append(refreshed, &cached{
+					Host:      host,
+					File:      file,
+					Count:     len(cas),
+					ExpiresAt: printer.GetMilliseconds(expires),
+				})
+			}
+
+			return ch.Printer.PrintResource(refreshed)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.protocol, "protocol", protocolMySQL,
+		fmt.Sprintf("The protocol of the endpoints, %s servers are asked to switch to TLS. Possible values: [%s, %s]", protocolMySQL, protocolMySQL, protocolTLS))
+
+	return cmd
+}
@@ -0,0 +1,93 @@
+package cert
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/spf13/cobra"
+)
+
+// expiryWarning is how long before its expiry a certificate is pointed out.
+const expiryWarning = 30 * 24 * time.Hour
+
+// chain returns a serializable certificate chain of a host.
+type chain struct {
+	Host         string         `json:"host"`
+	Verified     bool           `json:"verified"`
+	Error        string         `json:"error,omitempty"`
+	Certificates []*certificate `json:"certificates"`
+}
+
+// ShowCmd is the command for showing the certificate chain of a host.
+func ShowCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		protocol string
+	}
+
+	cmd := &cobra.Command{
+		Use:   "show <host>",
+		Short: "Show the certificate chain, expiry and pins of a database endpoint",
+		Long: `Show the certificate chain, expiry and pins of a database endpoint.
+
+The chain is verified against the CA certificates of the system. If it's
+valid, the verified chain up to the trusted root is shown, otherwise the
+certificates presented by the server. The pins are the SHA-256 hashes of the
+public keys, for clients pinning the certificates of the endpoint.`,
+		Example: `  pscale cert show aws.connect.psdb.cloud
+  pscale cert show aws.connect.psdb.cloud:443 --protocol tls`,
+		Args: cmdutil.RequiredArgs("host"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			addr, host := splitHost(args[0])
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Fetching the certificate chain of %s...", printer.BoldBlue(addr)))
+			defer end()
+
+			presented, err := fetchChain(cmd.Context(), ch.Config.DialContext, addr, host, flags.protocol)
+			if err != nil {
+				return fmt.Errorf("fetching the certificate chain of %s: %s", addr, err)
+			}
+			end()
+
+			res := &chain{Host: host}
+			verified, err := verifyChain(presented, host)
+			if err != nil {
+				res.Error = err.Error()
+				res.Certificates = toCertificates(presented)
+			} else {
+				res.Verified = true
+				res.Certificates = toCertificates(verified)
+			}
+
+			if ch.Printer.Format() != printer.Human {
+				return ch.Printer.PrintResource(res)
+			}
+
+			if err := ch.Printer.PrintResource(res.Certificates); err != nil {
+				return err
+			}
+			ch.Printer.Println()
+
+			if !res.Verified {
+				fmt.Fprintf(os.Stderr, "%s the chain isn't trusted: %s\n", printer.BoldRed("Warning:"), res.Error)
+				return nil
+			}
+
+			left := time.Until(presented[0].NotAfter)
+			ch.Printer.Printf("The chain is valid for %s, the certificate expires in %d days.\n",
+				printer.BoldBlue(host), int(left.Hours()/24))
+			if left < expiryWarning {
+				fmt.Fprintf(os.Stderr, "%s the certificate expires soon, clients pinning it need to be updated.\n", printer.BoldRed("Warning:"))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.protocol, "protocol", protocolMySQL,
+		fmt.Sprintf("The protocol of the endpoint, %s servers are asked to switch to TLS. Possible values: [%s, %s]", protocolMySQL, protocolMySQL, protocolTLS))
+
+	return cmd
+}
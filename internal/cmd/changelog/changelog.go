@@ -0,0 +1,30 @@
+package changelog
+
+import (
+	"github.com/planetscale/cli/internal/cmdutil"
+
+	"github.com/spf13/cobra"
+)
+
+// ChangelogCmd encapsulates the commands for summarizing the schema changes
+// deployed to a database.
+func ChangelogCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "changelog <command>",
+		Short: "Summarize the schema changes deployed to a database",
+		Long: `Summarize the schema changes deployed to a database.
+
+The changelog lists the deploy requests whose deployment completed, with
+their notes and schema changes, and who deployed them according to the audit
+log of the organization.`,
+		PersistentPreRunE: cmdutil.CheckAuthentication(ch.Config),
+	}
+
+	cmd.PersistentFlags().StringVar(&ch.Config.Organization, "org", ch.Config.Organization,
+		"The organization for the current user")
+	cmd.MarkPersistentFlagRequired("org") // nolint:errcheck
+
+	cmd.AddCommand(GenerateCmd(ch))
+
+	return cmd
+}
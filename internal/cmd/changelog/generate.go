@@ -0,0 +1,190 @@
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+// Entry is a deploy request in the changelog.
+type Entry struct {
+	Number     uint64    `header:"number" json:"number"`
+	Branch     string    `header:"branch" json:"branch"`
+	IntoBranch string    `header:"into" json:"into_branch"`
+	DeployedAt time.Time `json:"deployed_at"`
+	DeployedBy string    `header:"deployed_by,n/a" json:"deployed_by,omitempty"`
+	Tables     string    `header:"tables" json:"-"`
+	Notes      string    `json:"notes" csv:"-"`
+	Changes    []*Change `json:"changes" csv:"-"`
+}
+
+// Change is the change of the schema of a single table.
+type Change struct {
+	Table string `json:"table"`
+	DDL   string `json:"ddl"`
+}
+
+// GenerateCmd generates the changelog of the schema changes deployed to a
+// database.
+func GenerateCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		since string
+		into  string
+	}
+
+	cmd := &cobra.Command{
+		Use:   "generate <database>",
+		Short: "Generate a Markdown changelog of the schema changes deployed since a release",
+		Long: `Generate a Markdown changelog of the schema changes deployed since a release.
+
+--since is a git revision, such as the tag of the previous release, a date or
+a duration such as 30d. The changelog is printed as Markdown, to be included in
+release notes, or as the list of deploy requests with --format json or csv.`,
+		Example: `Generate the changelog of the schema changes since the v1.2.0 tag:
+
+  pscale changelog generate mydb --since v1.2.0 > schema-changes.md`,
+		Args:              cmdutil.RequiredArgs("database"),
+		ValidArgsFunction: cmdutil.DatabaseCompletion(ch),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database := args[0]
+
+			since, err := cmdutil.ParseSince(flags.since)
+			if err != nil {
+				return err
+			}
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Collecting the deploy requests of %s since %s...",
+				printer.BoldBlue(database), since.Format(time.RFC3339)))
+			defer end()
+
+			entries, err := collect(ctx, client, ch.Config.Organization, database, flags.into, since)
+			if err != nil {
+				switch cmdutil.ErrCode(err) {
+				case ps.ErrNotFound:
+					return fmt.Errorf("database %s does not exist in organization %s",
+						printer.BoldBlue(database), printer.BoldBlue(ch.Config.Organization))
+				default:
+					return cmdutil.HandleError(err)
+				}
+			}
+
+			end()
+
+			if ch.Printer.Format() == printer.Human {
+				ch.Printer.Print(markdown(database, flags.since, entries))
+				return nil
+			}
+
+			return ch.Printer.PrintResource(entries)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.since, "since", "", "Git revision, date or duration the changelog starts from, such as v1.2.0")
+	cmd.Flags().StringVar(&flags.into, "into", "", "Only include the deploy requests deployed into this branch")
+	cmd.MarkFlagRequired("since") // nolint:errcheck
+
+	return cmd
+}
+
+// collect returns the deploy requests deployed since the given time, oldest
+// first.
+func collect(ctx context.Context, client *ps.Client, org, database, into string, since time.Time) ([]*Entry, error) {
+	drs, err := client.DeployRequests.List(ctx, &ps.ListDeployRequestsRequest{
+		Organization: org,
+		Database:     database,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deployers := cmdutil.Deployers(ctx, client, org)
+
+	entries := make([]*Entry, 0)
+	for _, dr := range drs {
+		d := dr.Deployment
+		if d == nil || d.State != "complete" || d.FinishedAt == nil || d.FinishedAt.Before(since) {
+			continue
+		}
+		if into != "" && dr.IntoBranch != into {
+			continue
+		}
+
+		diffs, err := client.DeployRequests.Diff(ctx, &ps.DiffRequest{
+			Organization: org,
+			Database:     database,
+			Number:       dr.Number,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		e := &Entry{
+			Number:     dr.Number,
+			Branch:     dr.Branch,
+			IntoBranch: dr.IntoBranch,
+			DeployedAt: d.FinishedAt.UTC(),
+			DeployedBy: deployers[dr.ID],
+			Notes:      strings.TrimSpace(dr.Notes),
+			Changes:    []*Change{},
+		}
+		tables := make([]string, 0, len(diffs))
+		for _, diff := range diffs {
+			tables = append(tables, diff.Name)
+			e.Changes = append(e.Changes, &Change{Table: diff.Name, DDL: strings.TrimSpace(diff.Raw)})
+		}
+		e.Tables = strings.Join(tables, ", ")
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].DeployedAt.Before(entries[j].DeployedAt) })
+	return entries, nil
+}
+
+// markdown returns the changelog as Markdown.
+func markdown(database, since string, entries []*Entry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Schema changes of %s since %s\n\n", database, since)
+
+	if len(entries) == 0 {
+		b.WriteString("No schema changes were deployed.\n")
+		return b.String()
+	}
+
+	for _, e := range entries {
+		fmt.Fprintf(&b, "### #%d %s\n\n", e.Number, e.Branch)
+
+		fmt.Fprintf(&b, "Deployed into `%s` on %s", e.IntoBranch, e.DeployedAt.Format("2006-01-02"))
+		if e.DeployedBy != "" {
+			fmt.Fprintf(&b, " by %s", e.DeployedBy)
+		}
+		b.WriteString(".\n\n")
+
+		if e.Notes != "" {
+			b.WriteString(e.Notes + "\n\n")
+		}
+
+		for _, c := range e.Changes {
+			fmt.Fprintf(&b, "- `%s`\n\n  ```sql\n", c.Table)
+			for _, line := range strings.Split(c.DDL, "\n") {
+				fmt.Fprintf(&b, "  %s\n", line)
+			}
+			b.WriteString("  ```\n\n")
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,88 @@
+package changelog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/printer"
+
+	qt "github.com/frankban/quicktest"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+func TestChangelog_GenerateCmd(t *testing.T) {
+	c := qt.New(t)
+
+	release := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	deployed := func(days int) *ps.Deployment {
+		t := release.AddDate(0, 0, days)
+		return &ps.Deployment{State: "complete", FinishedAt: &t}
+	}
+
+	drs := &mock.DeployRequestsService{
+		ListFn: func(ctx context.Context, req *ps.ListDeployRequestsRequest) ([]*ps.DeployRequest, error) {
+			return []*ps.DeployRequest{
+				{ID: "dr3", Number: 3, Branch: "add-orders", IntoBranch: "main", Deployment: deployed(5)},
+				{ID: "dr2", Number: 2, Branch: "add-users", IntoBranch: "main", Notes: "Store the users.\n", Deployment: deployed(2)},
+				{ID: "dr1", Number: 1, Branch: "old", IntoBranch: "main", Deployment: deployed(-2)},
+				{ID: "dr4", Number: 4, Branch: "wip", IntoBranch: "main", State: "open"},
+			}, nil
+		},
+		DiffFn: func(ctx context.Context, req *ps.DiffRequest) ([]*ps.Diff, error) {
+			switch req.Number {
+			case 2:
+				return []*ps.Diff{{Name: "users", Raw: "CREATE TABLE `users` (\n  `id` bigint\n);"}}, nil
+			case 3:
+				return []*ps.Diff{{Name: "orders", Raw: "CREATE TABLE `orders` (`id` bigint);"}}, nil
+			}
+			c.Fatalf("unexpected diff of deploy request #%d", req.Number)
+			return nil, nil
+		},
+	}
+	logs := &mock.AuditLogService{
+		ListFn: func(ctx context.Context, req *ps.ListAuditLogsRequest) ([]*ps.AuditLog, error) {
+			return []*ps.AuditLog{{AuditableID: "dr2", ActorDisplayName: "Jane Doe"}}, nil
+		},
+	}
+
+	var buf bytes.Buffer
+	format := printer.Human
+	p := printer.NewPrinter(&format)
+	p.SetHumanOutput(&buf)
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config:  &config.Config{Organization: "planetscale"},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{DeployRequests: drs, AuditLogs: logs}, nil
+		},
+	}
+
+	cmd := GenerateCmd(ch)
+	cmd.SetArgs([]string{"mydb", "--since", "2026-09-01T00:00:00Z"})
+	err := cmd.Execute()
+	c.Assert(err, qt.IsNil)
+
+	c.Assert(buf.String(), qt.Contains, "## Schema changes of mydb since 2026-09-01T00:00:00Z\n\n"+
+		"### #2 add-users\n\n"+
+		"Deployed into `main` on 2026-09-03 by Jane Doe.\n\n"+
+		"Store the users.\n\n"+
+		"- `users`\n\n"+
+		"  ```sql\n"+
+		"  CREATE TABLE `users` (\n"+
+		"    `id` bigint\n"+
+		"  );\n"+
+		"  ```\n\n"+
+		"### #3 add-orders\n\n"+
+		"Deployed into `main` on 2026-09-06.\n\n"+
+		"- `orders`\n\n"+
+		"  ```sql\n"+
+		"  CREATE TABLE `orders` (`id` bigint);\n"+
+		"  ```\n")
+}
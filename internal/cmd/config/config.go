@@ -0,0 +1,174 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// ConfigCmd encapsulates the commands for inspecting the configuration.
+func ConfigCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config <command>",
+		Short: "View, change and compare the configuration of pscale",
+		Long: `View, change and compare the configuration of pscale.
+
+The effective configuration is resolved from the following sources, each
+overriding the previous ones:
+
+  1. the defaults of pscale
+  2. the system config file of the machine, /etc/pscale/pscale.yml or
+     %ProgramData%\pscale\pscale.yml on Windows
+  3. the global config file
+  4. the project config file (.pscale.yml) in the root of the git repository
+  5. PLANETSCALE_* environment variables, i.e. PLANETSCALE_TIMEOUTS_READ for
+     "timeouts.read"
+  6. command line flags
+
+Values of the project config file can use ${env:VAR} for environment variables
+and ${git:branch} for the current git branch, i.e. branch: "dev-${env:USER}",
+so a single committed file works for all developers and CI.
+
+On managed machines, administrators can enforce values with a root-owned
+config file setting "locked: true", /etc/pscale/config.yml or
+%ProgramData%\pscale\config.yml on Windows. Its values override all of the
+sources above.`,
+	}
+
+	cmd.AddCommand(ViewCmd(ch))
+	cmd.AddCommand(DiffCmd(ch))
+	cmd.AddCommand(GetCmd(ch))
+	cmd.AddCommand(ImportCmd(ch))
+	cmd.AddCommand(SetCmd(ch))
+	cmd.AddCommand(ListCmd(ch))
+
+	return cmd
+}
+
+// wellKnownKeys are configuration keys that can be set without being present
+// in any config file.
+var wellKnownKeys = []string{
+	"org", "database", "branch", "schema-dir", "env",
+	"api-url", "api-token", "service-token", "service-token-id",
+	"format", "debug", "no-color", "no-header",
+	"timeouts.read", "timeouts.mutate", "retries.max", "retries.backoff",
+	"retries.max-wait", "no-retry",
+	"max-concurrent-requests", "max-requests-per-minute",
+	"mirror.api-url", "mirror.auth-url", "mirror.app-url",
+	"mirror.docs-url", "mirror.releases-url", "mirror.proxy",
+	"dns.resolve", "dns.resolver",
+}
+
+// fileLayers returns the layers of the global and project configuration
+// files. A layer is nil if its file doesn't exist.
+func fileLayers(ch *cmdutil.Helper) (*config.Layer, *config.Layer, error) {
+	globalPath, err := config.DefaultConfigPath()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	projectPath, err := config.ProjectConfigPath()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	global, err := ch.ConfigFS.NewLayer(config.SourceGlobal, globalPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	project, err := ch.ConfigFS.NewLayer(config.SourceProject, projectPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	return global, project, nil
+}
+
+// systemLayers returns the layers of the system and the locked config files
+// of the machine. A layer is nil if its file doesn't exist.
+func systemLayers(ch *cmdutil.Helper) (*config.Layer, *config.Layer, error) {
+	system, err := ch.ConfigFS.NewLayer(config.SourceSystem, config.SystemConfigPath())
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	locked, err := ch.ConfigFS.LockedLayer(config.LockedConfigPath())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return system, locked, nil
+}
+
+// layerKeys returns all keys defined in the given layers along with the well
+// known keys.
+func layerKeys(layers ...*config.Layer) []string {
+	keys := append([]string{}, wellKnownKeys...)
+	for _, l := range layers {
+		if l != nil {
+			keys = append(keys, l.Keys()...)
+		}
+	}
+	return keys
+}
+
+// effectiveValues resolves the effective configuration from the defaults, the
+// given file layers, the environment, the global flags of cmd and the locked
+// layer. The variables of the project file are interpolated and sensitive
+// values are redacted.
+func effectiveValues(cmd *cobra.Command, system, global, project, locked *config.Layer) ([]*config.Value, error) {
+	project, err := project.Interpolate()
+	if err != nil {
+		return nil, fmt.Errorf("can't interpolate the project config file: %s", err)
+	}
+
+	flagLayer := &config.Layer{
+		Source: config.SourceFlag,
+		Origin: "command line",
+		Values: make(map[string]string),
+	}
+
+	local := cmd.LocalNonPersistentFlags()
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if local.Lookup(f.Name) != nil {
+			return
+		}
+		flagLayer.Values[f.Name] = f.Value.String()
+	})
+
+	env := config.EnvLayer(layerKeys(system, global, project))
+	values := config.Resolve(config.DefaultLayer(), system, global, project, env, flagLayer, locked)
+
+	// the selected environment overrides the values of the files and the
+	// environment variables, like it does when running commands
+	if name := lookup(values, "env"); name != nil {
+		source := project
+		if cfgFile := cmd.Flags().Lookup("config"); cfgFile != nil && cfgFile.Changed {
+			source = global
+		}
+		environment := config.EnvironmentLayer(source, name.Value)
+		values = config.Resolve(config.DefaultLayer(), system, global, project, env, environment, flagLayer, locked)
+	}
+	for _, v := range values {
+		v.Value = config.Redact(v.Key, v.Value)
+	}
+
+	return values, nil
+}
+
+// lookup returns the value of key, or nil if it isn't set.
+func lookup(values []*config.Value, key string) *config.Value {
+	for _, v := range values {
+		if v.Key == key {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"sort"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/spf13/cobra"
+)
+
+// configDiff is a single configuration key that differs between the global
+// and the project configuration.
+type configDiff struct {
+	Key     string `header:"key" json:"key"`
+	Global  string `header:"global" json:"global"`
+	Project string `header:"project" json:"project"`
+	Status  string `header:"status" json:"status"`
+}
+
+// DiffCmd is the command for comparing the project and global configuration.
+func DiffCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare the project configuration with the global configuration",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			global, project, err := fileLayers(ch)
+			if err != nil {
+				return err
+			}
+
+			diffs := diffLayers(global, project)
+			if len(diffs) == 0 && ch.Printer.Format() == printer.Human {
+				ch.Printer.Println("The project and global configuration don't differ.")
+				return nil
+			}
+
+			return ch.Printer.PrintResource(diffs)
+		},
+	}
+
+	return cmd
+}
+
+// diffLayers returns the keys that are only set in one of the layers or have
+// different values. Values in the project configuration override the
+// global configuration.
+func diffLayers(global, project *config.Layer) []*configDiff {
+	if global == nil {
+		global = &config.Layer{}
+	}
+	if project == nil {
+		project = &config.Layer{}
+	}
+
+	keys := make(map[string]bool)
+	for k := range global.Values {
+		keys[k] = true
+	}
+	for k := range project.Values {
+		keys[k] = true
+	}
+
+	diffs := make([]*configDiff, 0)
+	for k := range keys {
+		g, inGlobal := global.Values[k]
+		p, inProject := project.Values[k]
+
+		d := &configDiff{
+			Key:     k,
+			Global:  config.Redact(k, g),
+			Project: config.Redact(k, p),
+		}
+
+		switch {
+		case inGlobal && !inProject:
+			d.Status = "global only"
+		case !inGlobal && inProject:
+			d.Status = "project only"
+		case g != p:
+			d.Status = "overridden by project"
+		default:
+			continue
+		}
+
+		diffs = append(diffs, d)
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Key < diffs[j].Key })
+	return diffs
+}
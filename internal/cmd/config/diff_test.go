@@ -0,0 +1,50 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestConfig_DiffCmd(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	globalPath, err := config.DefaultConfigPath()
+	c.Assert(err, qt.IsNil)
+	projectPath, err := config.ProjectConfigPath()
+	c.Assert(err, qt.IsNil)
+
+	testfs := testutil.MemFS{
+		globalPath:  &fstest.MapFile{Data: []byte("org: global-org\nformat: json\n")},
+		projectPath: &fstest.MapFile{Data: []byte("org: project-org\nformat: json\ndatabase: mydb\n")},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer:  p,
+		ConfigFS: config.NewConfigFS(testfs),
+	}
+
+	cmd := DiffCmd(ch)
+	cmd.SetArgs([]string{})
+	err = cmd.Execute()
+	c.Assert(err, qt.IsNil)
+
+	res := []*configDiff{
+		{Key: "database", Project: "mydb", Status: "project only"},
+		{Key: "org", Global: "global-org", Project: "project-org", Status: "overridden by project"},
+	}
+
+	c.Assert(buf.String(), qt.JSONEquals, res)
+}
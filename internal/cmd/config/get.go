@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/spf13/cobra"
+)
+
+// GetCmd is the command for reading a single effective configuration value.
+func GetCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Show the effective value of a configuration key",
+		Args:  cmdutil.RequiredArgs("key"),
+		Example: `Show the organization used by pscale:
+
+  pscale config get org
+
+Show where the read timeout is configured:
+
+  pscale config get timeouts.read --format json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key := args[0]
+
+			global, project, err := fileLayers(ch)
+			if err != nil {
+				return err
+			}
+			system, locked, err := systemLayers(ch)
+			if err != nil {
+				return err
+			}
+
+			values, err := effectiveValues(cmd, system, global, project, locked)
+			if err != nil {
+				return err
+			}
+
+			v := lookup(values, key)
+			if v == nil {
+				return fmt.Errorf("configuration key %s is not set", printer.BoldBlue(key))
+			}
+
+			if ch.Printer.Format() == printer.Human {
+				ch.Printer.Println(v.Value)
+				return nil
+			}
+
+			return ch.Printer.PrintResource(v)
+		},
+	}
+
+	return cmd
+}
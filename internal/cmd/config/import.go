@@ -0,0 +1,241 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/promptutil"
+
+	"github.com/AlecAivazis/survey/v2"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+	"github.com/spf13/cobra"
+)
+
+// ImportCmd is the command for writing the project config file from a
+// resource of the dashboard.
+func ImportCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		fromURL string
+	}
+
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Write the project config file from a database or branch of the dashboard",
+		Long: `Write the project config file from a database or branch of the dashboard.
+
+The organization, database and branch are taken from the --from-url of any
+page of the dashboard, such as the page of a branch or a deploy request, whose
+branch is used. Without --from-url, the database and branch of the current
+organization are selected interactively.
+
+The values are written to the project config file (.pscale.yml) at the root of
+the git repository, its other values are kept.`,
+		Args: cobra.NoArgs,
+		Example: `  pscale config import --from-url https://app.planetscale.com/acme/mydb/branches/dev
+  pscale config import`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			var res *dashboardResource
+			var err error
+			if flags.fromURL != "" {
+				res, err = parseDashboardURL(flags.fromURL, cmdutil.ApplicationURL)
+				if err != nil {
+					return err
+				}
+				if res.deployRequest != 0 {
+					if res.branch, err = deployRequestBranch(ctx, ch, res); err != nil {
+						return err
+					}
+				}
+			} else {
+				if res, err = selectResource(ctx, cmd, ch); err != nil {
+					return err
+				}
+			}
+
+			path, err := config.ProjectConfigPath()
+			if err != nil {
+				return err
+			}
+
+			imported := &importedConfig{Organization: res.org, Database: res.database, Branch: res.branch, Config: path}
+			for _, kv := range [][2]string{{"org", res.org}, {"database", res.database}, {"branch", res.branch}} {
+				if kv[1] == "" {
+					continue
+				}
+				if err := config.SetValue(path, kv[0], kv[1]); err != nil {
+					return err
+				}
+			}
+
+			if ch.Printer.Format() != printer.Human {
+				return ch.Printer.PrintResource(imported)
+			}
+
+			ch.Printer.Printf("Wrote organization %s", printer.BoldBlue(res.org))
+			if res.database != "" {
+				ch.Printer.Printf(", database %s", printer.BoldBlue(res.database))
+			}
+			if res.branch != "" {
+				ch.Printer.Printf(", branch %s", printer.BoldBlue(res.branch))
+			}
+			ch.Printer.Printf(" to %s.\n", path)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.fromURL, "from-url", "", "The URL of a page of the dashboard, such as the page of a branch")
+
+	return cmd
+}
+
+// importedConfig are the values written by ImportCmd.
+type importedConfig struct {
+	Organization string `json:"org"`
+	Database     string `json:"database,omitempty"`
+	Branch       string `json:"branch,omitempty"`
+	Config       string `json:"config"`
+}
+
+// dashboardResource is the resource a page of the dashboard shows.
+type dashboardResource struct {
+	org           string
+	database      string
+	branch        string
+	deployRequest uint64
+}
+
+// databasePages are the pages of a database that don't belong to a branch.
+var databasePages = map[string]bool{
+	"branches":        true,
+	"deploy-requests": true,
+	"insights":        true,
+	"settings":        true,
+}
+
+// parseDashboardURL returns the resource of a page of the dashboard at
+// appURL, such as /acme/mydb/branches/dev, /acme/mydb/dev/schema or
+// /acme/mydb/deploy-requests/7.
+func parseDashboardURL(raw, appURL string) (*dashboardResource, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("%q is not a URL of the dashboard", raw)
+	}
+	if app, err := url.Parse(appURL); err == nil && !strings.EqualFold(u.Host, app.Host) {
+		return nil, fmt.Errorf("%s is not a URL of the dashboard at %s", raw, appURL)
+	}
+
+	var segs []string
+	for _, s := range strings.Split(u.Path, "/") {
+		if s != "" {
+			segs = append(segs, s)
+		}
+	}
+	if len(segs) == 0 {
+		return nil, fmt.Errorf("%s isn't the URL of an organization, database or branch", raw)
+	}
+
+	res := &dashboardResource{org: segs[0]}
+	if len(segs) < 2 || segs[1] == "settings" {
+		return res, nil
+	}
+	res.database = segs[1]
+	if len(segs) < 3 {
+		return res, nil
+	}
+
+	switch page := segs[2]; {
+	case page == "branches" && len(segs) > 3:
+		res.branch = segs[3]
+	case page == "deploy-requests" && len(segs) > 3:
+		n, err := strconv.ParseUint(segs[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s isn't the URL of a deploy request", raw)
+		}
+		res.deployRequest = n
+	case !databasePages[page]:
+		res.branch = page
+	}
+	return res, nil
+}
+
+// deployRequestBranch returns the branch of the deploy request of the
+// resource.
+func deployRequestBranch(ctx context.Context, ch *cmdutil.Helper, res *dashboardResource) (string, error) {
+	client, err := ch.Client()
+	if err != nil {
+		return "", err
+	}
+
+	dr, err := client.DeployRequests.Get(ctx, &ps.GetDeployRequestRequest{
+		Organization: res.org,
+		Database:     res.database,
+		Number:       res.deployRequest,
+	})
+	if err != nil {
+		switch cmdutil.ErrCode(err) {
+		case ps.ErrNotFound:
+			return "", fmt.Errorf("deploy request '%s/%d' does not exist in organization %s",
+				printer.BoldBlue(res.database), res.deployRequest, printer.BoldBlue(res.org))
+		default:
+			return "", cmdutil.HandleError(err)
+		}
+	}
+	return dr.Branch, nil
+}
+
+// selectResource prompts for the database and branch of the current
+// organization.
+func selectResource(ctx context.Context, cmd *cobra.Command, ch *cmdutil.Helper) (*dashboardResource, error) {
+	if !printer.IsTTY || ch.Printer.Format() != printer.Human {
+		return nil, errors.New("--from-url is required when not running interactively")
+	}
+	if ch.Config.Organization == "" {
+		return nil, errors.New("no organization is set, run 'pscale org switch' first")
+	}
+	if err := cmdutil.CheckAuthentication(ch.Config)(cmd, nil); err != nil {
+		return nil, err
+	}
+
+	client, err := ch.Client()
+	if err != nil {
+		return nil, err
+	}
+
+	databases, err := client.Databases.List(ctx, &ps.ListDatabasesRequest{Organization: ch.Config.Organization})
+	if err != nil {
+		return nil, cmdutil.HandleError(err)
+	}
+	if len(databases) == 0 {
+		return nil, fmt.Errorf("organization %s has no databases", printer.BoldBlue(ch.Config.Organization))
+	}
+
+	names := make([]string, 0, len(databases))
+	for _, db := range databases {
+		names = append(names, db.Name)
+	}
+
+	res := &dashboardResource{org: ch.Config.Organization}
+	err = survey.AskOne(&survey.Select{
+		Message: "Select the database of the project:",
+		Options: names,
+		VimMode: true,
+	}, &res.database)
+	if err != nil {
+		return nil, err
+	}
+
+	res.branch, err = promptutil.GetBranch(ctx, client, res.org, res.database)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
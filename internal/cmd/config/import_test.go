@@ -0,0 +1,102 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+func TestParseDashboardURL(t *testing.T) {
+	c := qt.New(t)
+
+	tests := []struct {
+		url  string
+		want *dashboardResource
+		err  string
+	}{
+		{url: "https://app.planetscale.com/acme", want: &dashboardResource{org: "acme"}},
+		{url: "https://app.planetscale.com/acme/settings/audit-log", want: &dashboardResource{org: "acme"}},
+		{url: "https://app.planetscale.com/acme/mydb", want: &dashboardResource{org: "acme", database: "mydb"}},
+		{url: "https://app.planetscale.com/acme/mydb/branches", want: &dashboardResource{org: "acme", database: "mydb"}},
+		{url: "https://app.planetscale.com/acme/mydb/settings/passwords", want: &dashboardResource{org: "acme", database: "mydb"}},
+		{url: "https://app.planetscale.com/acme/mydb/branches/dev", want: &dashboardResource{org: "acme", database: "mydb", branch: "dev"}},
+		{url: "https://app.planetscale.com/acme/mydb/dev/schema?tab=diff", want: &dashboardResource{org: "acme", database: "mydb", branch: "dev"}},
+		{url: "https://app.planetscale.com/acme/mydb/deploy-requests/7/diff", want: &dashboardResource{org: "acme", database: "mydb", deployRequest: 7}},
+		{url: "https://app.planetscale.com/acme/mydb/deploy-requests/new", err: ".* isn't the URL of a deploy request"},
+		{url: "https://app.planetscale.com/", err: ".* isn't the URL of an organization, database or branch"},
+		{url: "https://planetscale.com/docs", err: ".* is not a URL of the dashboard at https://app.planetscale.com"},
+		{url: "acme/mydb", err: `"acme/mydb" is not a URL of the dashboard`},
+	}
+
+	for _, tt := range tests {
+		res, err := parseDashboardURL(tt.url, "https://app.planetscale.com")
+		if tt.err != "" {
+			c.Assert(err, qt.ErrorMatches, tt.err, qt.Commentf(tt.url))
+			continue
+		}
+		c.Assert(err, qt.IsNil, qt.Commentf(tt.url))
+		c.Assert(*res, qt.Equals, *tt.want, qt.Commentf(tt.url))
+	}
+}
+
+func TestConfig_ImportCmd(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	c.Assert(err, qt.IsNil)
+	c.Assert(os.Mkdir(filepath.Join(root, ".git"), 0755), qt.IsNil)
+	projectPath := filepath.Join(root, ".pscale.yml")
+	c.Assert(ioutil.WriteFile(projectPath, []byte("schema-dir: db/schema\n"), 0644), qt.IsNil)
+
+	wd, err := os.Getwd()
+	c.Assert(err, qt.IsNil)
+	c.Assert(os.Chdir(root), qt.IsNil)
+	defer os.Chdir(wd) // nolint: errcheck
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	svc := &mock.DeployRequestsService{
+		GetFn: func(ctx context.Context, req *ps.GetDeployRequestRequest) (*ps.DeployRequest, error) {
+			c.Assert(req.Organization, qt.Equals, "acme")
+			c.Assert(req.Database, qt.Equals, "mydb")
+			c.Assert(req.Number, qt.Equals, uint64(7))
+			return &ps.DeployRequest{Number: 7, Branch: "add-index", IntoBranch: "main"}, nil
+		},
+	}
+	ch := &cmdutil.Helper{
+		Printer:  p,
+		Config:   &config.Config{AccessToken: "token"},
+		ConfigFS: config.NewConfigFS(testutil.OSFS{}),
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{DeployRequests: svc}, nil
+		},
+	}
+
+	cmd := ImportCmd(ch)
+	cmd.SetArgs([]string{"--from-url", cmdutil.ApplicationURL + "/acme/mydb/deploy-requests/7"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(svc.GetFnInvoked, qt.IsTrue)
+	c.Assert(buf.String(), qt.JSONEquals, &importedConfig{
+		Organization: "acme", Database: "mydb", Branch: "add-index", Config: projectPath,
+	})
+
+	out, err := ioutil.ReadFile(projectPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, "schema-dir: db/schema\norg: acme\ndatabase: mydb\nbranch: add-index\n")
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/spf13/cobra"
+)
+
+// ListCmd is the command for listing the effective configuration.
+func ListCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List the effective configuration with the source of each value",
+		Args:    cobra.NoArgs,
+		Aliases: []string{"ls"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			global, project, err := fileLayers(ch)
+			if err != nil {
+				return err
+			}
+			system, locked, err := systemLayers(ch)
+			if err != nil {
+				return err
+			}
+
+			values, err := effectiveValues(cmd, system, global, project, locked)
+			if err != nil {
+				return err
+			}
+			if len(values) == 0 && ch.Printer.Format() == printer.Human {
+				ch.Printer.Println("No configuration is set.")
+				return nil
+			}
+
+			return ch.Printer.PrintResource(values)
+		},
+	}
+
+	return cmd
+}
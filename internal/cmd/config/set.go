@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/spf13/cobra"
+)
+
+// SetCmd is the command for changing a value of a configuration file.
+func SetCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		project bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a configuration key in the global or project config file",
+		Args:  cmdutil.RequiredArgs("key", "value"),
+		Example: `Set the default organization:
+
+  pscale config set org acme
+
+Use a different database in the current git repository:
+
+  pscale config set database mydb --project`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, value := args[0], args[1]
+
+			system, locked, err := systemLayers(ch)
+			if err != nil {
+				return err
+			}
+			if locked != nil {
+				if _, ok := locked.Values[key]; ok {
+					return fmt.Errorf("%s is locked by %s and can't be changed", printer.BoldBlue(key), locked.Origin)
+				}
+			}
+
+			source := config.SourceGlobal
+			path, err := config.DefaultConfigPath()
+			if flags.project {
+				source = config.SourceProject
+				path, err = config.ProjectConfigPath()
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := config.SetValue(path, key, value); err != nil {
+				return err
+			}
+
+			global, project, err := fileLayers(ch)
+			if err != nil {
+				return err
+			}
+
+			values, err := effectiveValues(cmd, system, global, project, locked)
+			if err != nil {
+				return err
+			}
+
+			// warn if the new value isn't effective
+			if v := lookup(values, key); v != nil && v.Source != source {
+				ch.Printer.Printf("%s is overridden by %s (%s).\n", printer.BoldBlue(key), v.Source, v.Origin)
+			}
+
+			if ch.Printer.Format() == printer.Human {
+				ch.Printer.Printf("Set %s to %s in %s.\n", printer.BoldBlue(key),
+					printer.BoldBlue(config.Redact(key, value)), path)
+				return nil
+			}
+
+			return ch.Printer.PrintResource(&config.Value{
+				Key:    key,
+				Value:  config.Redact(key, value),
+				Source: source,
+				Origin: path,
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.project, "project", false,
+		"Set the key in the project config file instead of the global config file")
+
+	return cmd
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestConfig_SetGetCmd(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	globalPath, err := config.DefaultConfigPath()
+	c.Assert(err, qt.IsNil)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	ch := &cmdutil.Helper{
+		Printer:  p,
+		ConfigFS: config.NewConfigFS(testutil.OSFS{}),
+	}
+
+	cmd := SetCmd(ch)
+	cmd.SetArgs([]string{"timeouts.read", "10s"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(buf.String(), qt.JSONEquals, &config.Value{
+		Key: "timeouts.read", Value: "10s", Source: config.SourceGlobal, Origin: globalPath,
+	})
+
+	out, err := ioutil.ReadFile(globalPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, "timeouts:\n  read: 10s\n")
+
+	buf.Reset()
+	cmd = GetCmd(ch)
+	cmd.SetArgs([]string{"timeouts.read"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(buf.String(), qt.JSONEquals, &config.Value{
+		Key: "timeouts.read", Value: "10s", Source: config.SourceGlobal, Origin: globalPath,
+	})
+
+	os.Setenv("PLANETSCALE_TIMEOUTS_READ", "20s")
+	defer os.Unsetenv("PLANETSCALE_TIMEOUTS_READ")
+
+	buf.Reset()
+	cmd = GetCmd(ch)
+	cmd.SetArgs([]string{"timeouts.read"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(buf.String(), qt.JSONEquals, &config.Value{
+		Key: "timeouts.read", Value: "20s", Source: config.SourceEnv, Origin: "PLANETSCALE_TIMEOUTS_READ",
+	})
+
+	cmd = GetCmd(ch)
+	cmd.SetArgs([]string{"region"})
+	c.Assert(cmd.Execute(), qt.ErrorMatches, "configuration key .*region.* is not set")
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"errors"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/spf13/cobra"
+)
+
+// ViewCmd is the command for viewing the configuration.
+func ViewCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		effective bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "view",
+		Short: "View the configuration files or the effective configuration",
+		Args:  cobra.NoArgs,
+		Example: `Show the global and project configuration files:
+
+  pscale config view
+
+Show the fully merged configuration, annotated with the source of each value:
+
+  pscale config view --effective`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			global, project, err := fileLayers(ch)
+			if err != nil {
+				return err
+			}
+			system, locked, err := systemLayers(ch)
+			if err != nil {
+				return err
+			}
+
+			if !flags.effective {
+				return printLayers(ch, global, project)
+			}
+
+			values, err := effectiveValues(cmd, system, global, project, locked)
+			if err != nil {
+				return err
+			}
+			if len(values) == 0 && ch.Printer.Format() == printer.Human {
+				ch.Printer.Println("No configuration is set.")
+				return nil
+			}
+
+			return ch.Printer.PrintResource(values)
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.effective, "effective", false,
+		"Show the fully merged configuration with the source of each value")
+
+	return cmd
+}
+
+// printLayers prints the values of each configuration file.
+func printLayers(ch *cmdutil.Helper, layers ...*config.Layer) error {
+	var values []*config.Value
+	for _, l := range layers {
+		if l == nil {
+			continue
+		}
+
+		for _, k := range l.Keys() {
+			values = append(values, &config.Value{
+				Key:    k,
+				Value:  config.Redact(k, l.Values[k]),
+				Source: l.Source,
+				Origin: l.Origin,
+			})
+		}
+	}
+
+	if len(values) == 0 {
+		return errors.New("no configuration file exists, run 'pscale auth login' or 'pscale org switch' to create one")
+	}
+
+	return ch.Printer.PrintResource(values)
+}
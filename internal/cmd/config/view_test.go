@@ -0,0 +1,140 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestConfig_ViewCmd_Effective(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	globalPath, err := config.DefaultConfigPath()
+	c.Assert(err, qt.IsNil)
+	projectPath, err := config.ProjectConfigPath()
+	c.Assert(err, qt.IsNil)
+
+	testfs := testutil.MemFS{
+		globalPath:  &fstest.MapFile{Data: []byte("org: global-org\nservice-token: secret\ntimeouts:\n  read: 5s\n")},
+		projectPath: &fstest.MapFile{Data: []byte("org: project-org\ndatabase: mydb\n")},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer:  p,
+		ConfigFS: config.NewConfigFS(testfs),
+	}
+
+	cmd := ViewCmd(ch)
+	cmd.SetArgs([]string{"--effective"})
+	err = cmd.Execute()
+	c.Assert(err, qt.IsNil)
+
+	res := []*config.Value{
+		{Key: "database", Value: "mydb", Source: config.SourceProject, Origin: projectPath},
+		{Key: "org", Value: "project-org", Source: config.SourceProject, Origin: projectPath},
+		{Key: "retries.backoff", Value: "500ms", Source: config.SourceDefault, Origin: "default"},
+		{Key: "retries.max", Value: "2", Source: config.SourceDefault, Origin: "default"},
+		{Key: "retries.max-wait", Value: "30s", Source: config.SourceDefault, Origin: "default"},
+		{Key: "service-token", Value: "********", Source: config.SourceGlobal, Origin: globalPath},
+		{Key: "timeouts.mutate", Value: "60s", Source: config.SourceDefault, Origin: "default"},
+		{Key: "timeouts.read", Value: "5s", Source: config.SourceGlobal, Origin: globalPath},
+	}
+
+	c.Assert(buf.String(), qt.JSONEquals, res)
+}
+
+func TestConfig_ViewCmd_Environment(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	projectPath, err := config.ProjectConfigPath()
+	c.Assert(err, qt.IsNil)
+
+	testfs := testutil.MemFS{
+		projectPath: &fstest.MapFile{Data: []byte("org: acme\ndatabase: mydb\nbranch: dev\nenvironments:\n  production:\n    branch: main\n")},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer:  p,
+		ConfigFS: config.NewConfigFS(testfs),
+	}
+
+	os.Setenv("PLANETSCALE_ENV", "production")
+	defer os.Unsetenv("PLANETSCALE_ENV")
+
+	cmd := ViewCmd(ch)
+	cmd.SetArgs([]string{"--effective"})
+	err = cmd.Execute()
+	c.Assert(err, qt.IsNil)
+
+	var values []*config.Value
+	c.Assert(json.Unmarshal(buf.Bytes(), &values), qt.IsNil)
+	c.Assert(lookup(values, "branch"), qt.DeepEquals, &config.Value{
+		Key: "branch", Value: "main", Source: config.SourceEnvironment, Origin: projectPath,
+	})
+	c.Assert(lookup(values, "database").Source, qt.Equals, config.SourceProject)
+}
+
+func TestConfig_GetCmd_System(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	globalPath, err := config.DefaultConfigPath()
+	c.Assert(err, qt.IsNil)
+	systemPath := config.SystemConfigPath()
+
+	testfs := testutil.MemFS{
+		systemPath: &fstest.MapFile{Data: []byte("org: acme\nmirror:\n  proxy: http://proxy.acme.internal:3128\n")},
+		globalPath: &fstest.MapFile{Data: []byte("org: personal\n")},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer:  p,
+		ConfigFS: config.NewConfigFS(testfs),
+	}
+
+	cmd := GetCmd(ch)
+	cmd.SetArgs([]string{"mirror.proxy"})
+	err = cmd.Execute()
+	c.Assert(err, qt.IsNil)
+	c.Assert(buf.String(), qt.JSONEquals, &config.Value{
+		Key:    "mirror.proxy",
+		Value:  "http://proxy.acme.internal:3128",
+		Source: config.SourceSystem,
+		Origin: systemPath,
+	})
+
+	buf.Reset()
+	cmd = GetCmd(ch)
+	cmd.SetArgs([]string{"org"})
+	err = cmd.Execute()
+	c.Assert(err, qt.IsNil)
+	c.Assert(buf.String(), qt.JSONEquals, &config.Value{
+		Key:    "org",
+		Value:  "personal",
+		Source: config.SourceGlobal,
+		Origin: globalPath,
+	})
+}
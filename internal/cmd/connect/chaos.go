@@ -0,0 +1,99 @@
+package connect
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// errChaosReset is returned for the connections reset by the chaos mode.
+var errChaosReset = errors.New("connection reset by chaos mode")
+
+// chaos degrades the connections of the tunnel to test how applications
+// cope with a slow or unreliable network. It's applied to the requests of
+// the clients, hence works for plaintext and TLS connections alike.
+type chaos struct {
+	// latency is added to every request, varied by up to jitter.
+	latency time.Duration
+	jitter  time.Duration
+
+	// resetRate is the probability of a connection being reset on a
+	// request, between 0 and 1.
+	resetRate float64
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func newChaos(latency, jitter time.Duration, resetRate float64) *chaos {
+	return &chaos{
+		latency:   latency,
+		jitter:    jitter,
+		resetRate: resetRate,
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// enabled reports whether the chaos mode changes anything.
+func (c *chaos) enabled() bool {
+	return c.latency > 0 || c.jitter > 0 || c.resetRate > 0
+}
+
+// delay returns how long to hold back the next request.
+func (c *chaos) delay() time.Duration {
+	d := c.latency
+	if c.jitter > 0 {
+		c.mu.Lock()
+		d += time.Duration(c.rand.Int63n(int64(2*c.jitter)+1)) - c.jitter
+		c.mu.Unlock()
+	}
+
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// reset reports whether to reset the connection on the next request.
+func (c *chaos) reset() bool {
+	if c.resetRate <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rand.Float64() < c.resetRate
+}
+
+// chaosWriter applies the chaos mode to the requests written to the proxy.
+type chaosWriter struct {
+	w     io.Writer
+	chaos *chaos
+	conn  *forwardedConn
+}
+
+func (w *chaosWriter) Write(p []byte) (int, error) {
+	if d := w.chaos.delay(); d > 0 {
+		time.Sleep(d)
+	}
+
+	if w.chaos.reset() {
+		w.conn.reset()
+		return 0, errChaosReset
+	}
+
+	return w.w.Write(p)
+}
+
+// reset closes both ends of the connection, sending a TCP reset to the
+// client rather than closing it gracefully.
+func (c *forwardedConn) reset() {
+	if tcp, ok := c.client.(*net.TCPConn); ok {
+		_ = tcp.SetLinger(0)
+	}
+	c.client.Close()
+	c.server.Close()
+}
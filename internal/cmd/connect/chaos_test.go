@@ -0,0 +1,66 @@
+package connect
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestChaos_Delay(t *testing.T) {
+	c := qt.New(t)
+
+	ch := newChaos(50*time.Millisecond, 20*time.Millisecond, 0)
+	c.Assert(ch.enabled(), qt.IsTrue)
+
+	for i := 0; i < 100; i++ {
+		d := ch.delay()
+		c.Assert(d >= 30*time.Millisecond && d <= 70*time.Millisecond, qt.IsTrue, qt.Commentf("delay %s", d))
+	}
+
+	// the jitter never makes the delay negative
+	ch = newChaos(0, 10*time.Millisecond, 0)
+	for i := 0; i < 100; i++ {
+		c.Assert(ch.delay() >= 0, qt.IsTrue)
+	}
+
+	c.Assert(newChaos(0, 0, 0).enabled(), qt.IsFalse)
+}
+
+func TestChaos_Reset(t *testing.T) {
+	c := qt.New(t)
+
+	never := newChaos(0, 0, 0)
+	always := newChaos(0, 0, 1)
+	for i := 0; i < 100; i++ {
+		c.Assert(never.reset(), qt.IsFalse)
+		c.Assert(always.reset(), qt.IsTrue)
+	}
+}
+
+func TestChaosWriter(t *testing.T) {
+	c := qt.New(t)
+
+	client, _ := net.Pipe()
+	server, _ := net.Pipe()
+	conn := &forwardedConn{client: client, server: server}
+
+	var buf bytes.Buffer
+	w := &chaosWriter{w: &buf, chaos: newChaos(0, 0, 0), conn: conn}
+	_, err := w.Write([]byte("select 1"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "select 1")
+
+	w.chaos = newChaos(0, 0, 1)
+	_, err = w.Write([]byte("select 2"))
+	c.Assert(err, qt.Equals, errChaosReset)
+	c.Assert(buf.String(), qt.Equals, "select 1")
+
+	// both ends of the connection are closed
+	_, err = client.Write([]byte("x"))
+	c.Assert(err, qt.IsNotNil)
+	_, err = server.Write([]byte("x"))
+	c.Assert(err, qt.IsNotNil)
+}
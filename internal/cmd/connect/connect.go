@@ -9,12 +9,15 @@ import (
 	"os/exec"
 	"os/signal"
 	"runtime"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/planetscale/cli/internal/cmdutil"
 	"github.com/planetscale/cli/internal/printer"
 	"github.com/planetscale/cli/internal/promptutil"
 	"github.com/planetscale/cli/internal/proxyutil"
+	tunnelstate "github.com/planetscale/cli/internal/tunnel"
 	"github.com/planetscale/planetscale-go/planetscale"
 
 	"github.com/mattn/go-shellwords"
@@ -31,13 +34,35 @@ func ConnectCmd(ch *cmdutil.Helper) *cobra.Command {
 		execCommandProtocol string
 		execCommandEnvURL   string
 		role                string
+		onTunnelUp          string
+		onTunnelDown        string
+		onCredentialRotated string
+		autoPort            bool
+		envFile             string
+		listen              []string
+		logQueries          string
+		sanitizeQueries     bool
+		maxConnections      int
+		queueTimeout        time.Duration
+		idleTimeout         time.Duration
+		reuseConnections    bool
+		chaosLatency        time.Duration
+		chaosJitter         time.Duration
+		chaosResetRate      float64
+		namedPipe           string
+		daemon              bool
+		emit                string
+		odbcFile            string
+		socks5Proxy         string
+		stats               bool
 	}
 
 	cmd := &cobra.Command{
 		Use: "connect [database] [branch]",
 		// we only require database, because we deduct branch automatically
-		Args:  cmdutil.RequiredArgs("database"),
-		Short: "Create a secure connection to a database and branch for a local client",
+		Args:              cmdutil.RequiredArgs("database"),
+		ValidArgsFunction: cmdutil.BranchCompletion(ch),
+		Short:             "Create a secure connection to a database and branch for a local client",
 		Example: `The connect subcommand establishes a secure connection between your host and PlanetScale.
 
 By default, if no branch names are given and there is only one branch, it
@@ -49,7 +74,66 @@ If there are multiple branches for the given database, you'll be prompted to
 choose one. To connect to a specific branch, pass the branch as a second
 argument:
 
-  pscale connect mydatabase mybranch`,
+  pscale connect mydatabase mybranch
+
+Run a command whenever the tunnel comes up, goes down or its credentials are
+rotated, i.e. to restart an application server. The hooks get the
+PLANETSCALE_EVENT, PLANETSCALE_ORG, PLANETSCALE_DATABASE_NAME,
+PLANETSCALE_BRANCH_NAME and PLANETSCALE_DATABASE_HOST environment variables.
+Hooks can also be set in the config file, i.e. "on-tunnel-up":
+
+  pscale connect mydatabase mybranch --on-tunnel-up "systemctl restart myapp"
+
+If the port is in use, pick the next free one and write the connection details
+to an env file for the application:
+
+  pscale connect mydatabase mybranch --port 3306 --auto-port --env-file .env
+
+Listen on specific addresses, i.e. IPv6 or the IPv4 and IPv6 loopback at once.
+Hooks, --execute and --env-file use the first address:
+
+  pscale connect mydatabase mybranch --listen 127.0.0.1:3306 --listen [::1]:3306
+
+Log the statements sent through the tunnel, with the time until the server
+started responding. The client has to connect without TLS for its statements
+to be logged:
+
+  pscale connect mydatabase mybranch --log-queries queries.log --log-queries-sanitize
+
+Protect a small branch from the connection storms of local tools by capping
+the connections of the tunnel. Excess clients wait for a free connection:
+
+  pscale connect mydatabase mybranch --max-connections 10 --queue-timeout 1m
+
+Keep the connections of clients that quit for the next client logging in with
+the same credentials, instead of connecting again. The clients have to connect
+with TLS disabled for their connections to be kept:
+
+  pscale connect mydatabase mybranch --max-connections 10 --reuse-connections
+
+Test how an application copes with a slow and unreliable network with the
+chaos mode, i.e. 50ms (±20ms) of added latency and a reset of 1% of requests:
+
+  pscale connect mydatabase mybranch --chaos-latency 50ms --chaos-jitter 20ms --chaos-reset-rate 0.01
+
+On Windows, expose the tunnel as a named pipe in addition to TCP, for clients
+connecting with i.e. --protocol=PIPE --socket=pscale:
+
+  pscale connect mydatabase mybranch --named-pipe pscale
+
+Connect directly, without a tunnel, from ODBC or JDBC clients. A password is
+created for the branch and written to ~/.odbc.ini as the data source
+pscale-mydatabase-mybranch, or printed as a JDBC URL:
+
+  pscale connect mydatabase mybranch --emit odbc
+  pscale connect mydatabase mybranch --emit jdbc
+
+Keep tunnels to several branches running in the background, with the main
+branch on a Unix socket:
+
+  pscale connect mydatabase main --daemon --listen unix:///tmp/main.sock
+  pscale connect mydatabase dev --daemon --port 3307
+  pscale tunnel list`,
 		PersistentPreRunE: cmdutil.CheckAuthentication(ch.Config),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx, cancel := context.WithCancel(cmd.Context())
@@ -102,18 +186,270 @@ argument:
 				return errors.New("database branch is not ready yet")
 			}
 
-			localAddr := net.JoinHostPort(flags.host, flags.port)
+			if flags.emit != "" {
+				return emitConfig(ctx, ch, client, flags.emit, flags.odbcFile, role, database, branch)
+			}
+
+			if flags.daemon {
+				if flags.execCommand != "" {
+					return errors.New("--execute can't be used with --daemon")
+				}
+				return startDaemon(ch, os.Args[1:], ch.Config.Organization, database, branch, len(args) == 2)
+			}
+
+			listenAddrs := []string{net.JoinHostPort(flags.host, flags.port)}
+			if len(flags.listen) != 0 {
+				if cmd.Flags().Changed("host") || cmd.Flags().Changed("port") {
+					return errors.New("--listen can't be used together with --host or --port")
+				}
+
+				listenAddrs, err = parseListenAddrs(flags.listen)
+				if err != nil {
+					return err
+				}
+			}
+
+			for i, addr := range listenAddrs {
+				if flags.autoPort && !isUnixAddr(addr) {
+					host, port, _ := net.SplitHostPort(addr)
+					free, err := nextFreePort(host, port)
+					if err != nil {
+						return err
+					}
+					if free != port {
+						ch.Printer.Printf("Port %s is already in use, using port %s instead.\n", port, free)
+					}
+					addr = net.JoinHostPort(host, free)
+					listenAddrs[i] = addr
+				}
+
+				if isAllInterfaces(addr) {
+					fmt.Fprintf(os.Stderr, "%s listening on %s, all network interfaces. The database is reachable by anyone who can reach this machine.\n",
+						printer.BoldRed("Warning:"), addr)
+				}
+			}
+
+			localAddr := listenAddrs[0]
+
+			h := &hooks{
+				commands: map[string]string{
+					eventTunnelUp:          flags.onTunnelUp,
+					eventTunnelDown:        flags.onTunnelDown,
+					eventCredentialRotated: flags.onCredentialRotated,
+				},
+				printer:  ch.Printer,
+				org:      ch.Config.Organization,
+				database: database,
+				branch:   branch,
+			}
+
+			remoteAddr, err := proxyutil.RemoteAddr(ctx, ch.Config, client, database, branch, flags.remoteAddr)
+			if err != nil {
+				return cmdutil.HandleError(err)
+			}
+
+			// the proxy can't dial through SOCKS5 itself, it connects to a
+			// local forwarder instead
+			if flags.socks5Proxy != "" {
+				target := remoteAddr
+				if target == "" {
+					target = proxyutil.AccessAddr(dbBranch.AccessHostURL)
+				}
 
+				remoteAddr, err = proxyutil.ForwardSOCKS5(ctx, flags.socks5Proxy, target, func(err error) {
+					fmt.Fprintf(os.Stderr, "%s %s\n", printer.BoldRed("Error:"), err)
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			certSource := proxyutil.NewRemoteCertSource(client, role)
 			proxyOpts := proxy.Options{
-				CertSource: proxyutil.NewRemoteCertSource(client, role),
+				CertSource: &hookCertSource{CertSource: certSource, hooks: h},
 				LocalAddr:  localAddr,
-				RemoteAddr: flags.remoteAddr,
+				RemoteAddr: remoteAddr,
 				Instance:   fmt.Sprintf("%s/%s/%s", ch.Config.Organization, database, branch),
 				Logger:     cmdutil.NewZapLogger(ch.Debug()),
 			}
 
+			if flags.maxConnections < 0 {
+				return errors.New("--max-connections can't be negative")
+			}
+
+			if flags.chaosLatency < 0 || flags.chaosJitter < 0 {
+				return errors.New("--chaos-latency and --chaos-jitter can't be negative")
+			}
+			if flags.chaosResetRate < 0 || flags.chaosResetRate > 1 {
+				return errors.New("--chaos-reset-rate must be between 0 and 1")
+			}
+			chaos := newChaos(flags.chaosLatency, flags.chaosJitter, flags.chaosResetRate)
+
+			// background tunnels count their traffic for pscale tunnel
+			// list whenever they can
+			daemonID := os.Getenv(tunnelstate.EnvID)
+			var stats *tunnelStats
+			if flags.stats || (daemonID != "" && len(listenAddrs) == 1) {
+				stats = newTunnelStats()
+			}
+
+			var front *frontend
+			if flags.logQueries != "" || flags.maxConnections > 0 || flags.reuseConnections || chaos.enabled() || stats != nil {
+				if len(listenAddrs) > 1 {
+					return errors.New("--log-queries, --max-connections, --reuse-connections, --stats and the chaos mode can only be used with a single listen address")
+				}
+
+				l, err := listenLocal(localAddr)
+				if err != nil && isAddrInUse(err) && len(flags.listen) == 0 {
+					ch.Printer.Printf("Tried address %s, but it's already in use. Picking up a random port ...\n", localAddr)
+					l, err = net.Listen("tcp", net.JoinHostPort(flags.host, "0"))
+				}
+				if err != nil {
+					return err
+				}
+
+				front = &frontend{listener: l, stats: stats}
+				defer front.Close()
+
+				if flags.logQueries != "" {
+					front.log, err = newQueryLog(flags.logQueries, flags.sanitizeQueries)
+					if err != nil {
+						return err
+					}
+				}
+
+				if flags.maxConnections > 0 {
+					front.limit = newConnLimiter(flags.maxConnections, flags.queueTimeout, flags.idleTimeout)
+				}
+
+				if flags.reuseConnections {
+					front.pool = newConnPool(flags.idleTimeout)
+				}
+
+				if chaos.enabled() {
+					front.chaos = chaos
+					fmt.Fprintf(os.Stderr, "%s chaos mode is enabled, connections are slowed down by %s (±%s) and reset at a rate of %g.\n",
+						printer.BoldRed("Warning:"), flags.chaosLatency, flags.chaosJitter, flags.chaosResetRate)
+				}
+
+				go func() {
+					if err := front.serve(ctx); err != nil {
+						ch.Printer.Printf("listener error: %s\n", err)
+						cancel()
+					}
+				}()
+
+				// the clients connect to the frontend, which forwards
+				// them to the proxy
+				proxyOpts.LocalAddr = net.JoinHostPort("127.0.0.1", "0")
+			}
+
+			var pipe *frontend
+			if flags.namedPipe != "" {
+				path := pipePath(flags.namedPipe)
+				l, err := listenPipe(path)
+				if err != nil {
+					return err
+				}
+
+				// the pipe forwards its clients to the address of the
+				// tunnel, so they're treated like any other client
+				pipe = &frontend{listener: l, stats: stats}
+				defer pipe.Close()
+
+				go func() {
+					if err := pipe.serve(ctx); err != nil {
+						ch.Printer.Printf("named pipe error: %s\n", err)
+						cancel()
+					}
+				}()
+			}
+
+			// set if this is a tunnel started with --daemon, its state is
+			// saved whenever it's established
+			var daemon *tunnelstate.Tunnel
+			var daemonMu sync.Mutex
+			if daemonID != "" {
+				daemon = &tunnelstate.Tunnel{
+					ID:       daemonID,
+					PID:      os.Getpid(),
+					Org:      ch.Config.Organization,
+					Database: database,
+					Branch:   branch,
+				}
+				defer func() {
+					// the log of a tunnel that was never established is
+					// kept, it tells why
+					if !daemon.StartedAt.IsZero() {
+						_ = tunnelstate.Remove(daemon.ID)
+					}
+				}()
+			}
+
 			proxyReady := make(chan string, 1)
 
+			onReady := func(addr string) {
+				if daemon != nil {
+					daemonMu.Lock()
+					daemon.Addrs = append([]string{addr}, listenAddrs[1:]...)
+					if flags.namedPipe != "" {
+						daemon.Addrs = append(daemon.Addrs, pipePath(flags.namedPipe))
+					}
+					if daemon.StartedAt.IsZero() {
+						daemon.StartedAt = time.Now().UTC()
+					}
+
+					if err := tunnelstate.Save(daemon); err != nil {
+						ch.Printer.Printf("Couldn't save the state of the tunnel: %s\n", err)
+					}
+					daemonMu.Unlock()
+				}
+
+				if pipe != nil {
+					pipe.setUpstream(addr)
+					ch.Printer.Printf("Named pipe to connect your application: %s\n", printer.BoldBlue(pipe.listener.Addr().String()))
+				}
+
+				if ch.Printer.Format() != printer.Human {
+					_ = ch.Printer.PrintResource(toTunnel(ch.Config.Organization, database, branch, addr))
+				}
+
+				if flags.envFile != "" {
+					if err := writeEnvFile(flags.envFile, flags.execCommandEnvURL, flags.execCommandProtocol, database, branch, addr); err != nil {
+						ch.Printer.Printf("Couldn't update env file %s: %s\n", flags.envFile, err)
+					}
+				}
+
+				h.up(addr)
+			}
+
+			if stats != nil && daemon != nil {
+				var last *tunnelstate.Stats
+				stop := reportStats(stats, 2*time.Second, func(st *tunnelstate.Stats) {
+					if last != nil && sameTraffic(last, st) {
+						return
+					}
+					last = st
+
+					daemonMu.Lock()
+					defer daemonMu.Unlock()
+					if daemon.StartedAt.IsZero() {
+						return // not established yet
+					}
+					daemon.Stats = st
+					_ = tunnelstate.Save(daemon)
+				})
+				defer stop()
+			} else if flags.stats && ch.Printer.Format() == printer.Human && printer.IsTTY && flags.execCommand == "" {
+				stop := reportStats(stats, time.Second, func(st *tunnelstate.Stats) {
+					ch.Printer.Printf("\r\033[K%s", statusLine(st, time.Now()))
+				})
+				defer func() {
+					stop()
+					ch.Printer.Println()
+				}()
+			}
+
 			var executeCh chan error
 			if flags.execCommand != "" {
 				executeCh = make(chan error, 1)
@@ -136,12 +472,34 @@ argument:
 				}()
 			}
 
-			err = runProxy(ctx, ch, proxyOpts, database, branch, proxyReady)
+			// the other listeners fetch their own certificates, which
+			// mustn't count as rotations of the tunnel's credentials
+			extraOpts := proxyOpts
+			extraOpts.CertSource = certSource
+			listenErr, err := startListeners(ctx, cancel, ch, extraOpts, listenAddrs[1:])
+			if err != nil {
+				return err
+			}
+
+			if daemon != nil {
+				// a new proxy is created for every attempt, fetching new
+				// credentials
+				err = keepRunning(ctx, ch, func() error {
+					return runProxy(ctx, ch, proxyOpts, database, branch, make(chan string, 1), onReady, h, front)
+				})
+			} else {
+				err = runProxy(ctx, ch, proxyOpts, database, branch, proxyReady, onReady, h, front)
+			}
+			select {
+			case lerr := <-listenErr:
+				return lerr
+			default:
+			}
 			if err != nil {
-				if isAddrInUse(err) {
+				if isAddrInUse(err) && len(flags.listen) == 0 && front == nil {
 					ch.Printer.Printf("Tried address %s, but it's already in use. Picking up a random port ...\n", localAddr)
 					proxyOpts.LocalAddr = net.JoinHostPort(flags.host, "0")
-					return runProxy(ctx, ch, proxyOpts, database, branch, proxyReady)
+					return runProxy(ctx, ch, proxyOpts, database, branch, proxyReady, onReady, h, nil)
 				}
 				return err
 			}
@@ -158,9 +516,16 @@ argument:
 
 	cmd.PersistentFlags().StringVar(&ch.Config.Organization, "org", ch.Config.Organization, "The organization for the current user")
 	cmd.PersistentFlags().StringVar(&flags.host, "host", "127.0.0.1", "Local host to bind and listen for connections")
-	cmd.PersistentFlags().StringVar(&flags.port, "port", "3306", "Local port to bind and listen for connections")
+	cmd.PersistentFlags().StringVar(&flags.port, "port", "3306", "Local port to bind and listen for connections, 0 picks a random free port")
+	cmd.PersistentFlags().StringSliceVar(&flags.listen, "listen", nil,
+		"Local addresses to listen for connections on, i.e. 127.0.0.1:3306, [::1]:3306 or unix:///tmp/pscale.sock. Can be given multiple times, overrides --host and --port")
+	cmd.PersistentFlags().BoolVar(&flags.autoPort, "auto-port", false, "Use the next free port if the local port is already in use")
+	cmd.PersistentFlags().StringVar(&flags.envFile, "env-file", "",
+		"Write the connection details of the tunnel to this env file, other variables in the file are kept")
 	cmd.PersistentFlags().StringVar(&flags.remoteAddr, "remote-addr", "",
 		"PlanetScale Database remote network address. By default the remote address is populated automatically from the PlanetScale API.")
+	cmd.PersistentFlags().StringVar(&flags.socks5Proxy, "socks5-proxy", "",
+		"Connect to PlanetScale through this SOCKS5 proxy, given as host:port or socks5://[user:password@]host:port. API requests still use the HTTP proxy settings")
 	cmd.MarkPersistentFlagRequired("org") // nolint:errcheck
 	cmd.PersistentFlags().StringVar(&flags.execCommand, "execute", "", "Run this command after successfully connecting to the database.")
 	cmd.PersistentFlags().StringVar(&flags.execCommandProtocol, "execute-protocol",
@@ -170,6 +535,46 @@ argument:
 	cmd.PersistentFlags().StringVar(&flags.role, "role",
 		"reader", "Role defines the access level, allowed values are : reader, writer, readwriter, admin. By default it is reader.")
 
+	cmd.PersistentFlags().StringVar(&flags.onTunnelUp, "on-tunnel-up", "",
+		"Run this command whenever the tunnel is established.")
+	cmd.PersistentFlags().StringVar(&flags.onTunnelDown, "on-tunnel-down", "",
+		"Run this command whenever the tunnel is closed.")
+	cmd.PersistentFlags().StringVar(&flags.onCredentialRotated, "on-credential-rotated", "",
+		"Run this command whenever the credentials of the tunnel are rotated.")
+	cmd.PersistentFlags().StringVar(&flags.logQueries, "log-queries", "",
+		"Log the statements sent through the tunnel, with their durations, to this file.")
+	cmd.PersistentFlags().BoolVar(&flags.sanitizeQueries, "log-queries-sanitize", false,
+		"Replace the literals of the logged statements with '?'.")
+	cmd.PersistentFlags().IntVar(&flags.maxConnections, "max-connections", 0,
+		"Limit the connections to the database branch, clients exceeding it wait for a free connection. 0 means no limit.")
+	cmd.PersistentFlags().DurationVar(&flags.queueTimeout, "queue-timeout", 30*time.Second,
+		"How long a client waits for a free connection with --max-connections before it's disconnected, 0 waits forever.")
+	cmd.PersistentFlags().DurationVar(&flags.idleTimeout, "idle-timeout", time.Minute,
+		"Close connections idle for this long while other clients wait with --max-connections, or kept for this long with --reuse-connections. 0 keeps them open.")
+	cmd.PersistentFlags().BoolVar(&flags.reuseConnections, "reuse-connections", false,
+		"Keep the connections of clients that quit and hand them to the next client logging in with the same credentials.")
+
+	cmd.PersistentFlags().BoolVar(&flags.stats, "stats", false,
+		"Show the open connections and the bytes sent through the tunnel in a status line, updated every second.")
+
+	cmd.PersistentFlags().DurationVar(&flags.chaosLatency, "chaos-latency", 0,
+		"Chaos mode: add this latency to every request sent through the tunnel.")
+	cmd.PersistentFlags().DurationVar(&flags.chaosJitter, "chaos-jitter", 0,
+		"Chaos mode: vary the added latency randomly by up to this duration.")
+	cmd.PersistentFlags().Float64Var(&flags.chaosResetRate, "chaos-reset-rate", 0,
+		"Chaos mode: the probability, between 0 and 1, of a connection being reset on every request.")
+	cmd.PersistentFlags().StringVar(&flags.emit, "emit", "",
+		"Instead of a tunnel, create a password and emit the config for connecting directly. Possible values: [odbc, jdbc]")
+	cmd.PersistentFlags().StringVar(&flags.odbcFile, "odbc-ini", "",
+		"The odbc.ini file the data source is written to with --emit odbc (default $ODBCINI or ~/.odbc.ini)")
+	cmd.RegisterFlagCompletionFunc("emit", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) { // nolint:errcheck
+		return []string{emitODBC, emitJDBC}, cobra.ShellCompDirectiveDefault
+	})
+	cmd.PersistentFlags().BoolVar(&flags.daemon, "daemon", false,
+		"Run the tunnel in the background, reconnecting whenever it fails. Manage it with pscale tunnel list and pscale tunnel stop.")
+	cmd.PersistentFlags().StringVar(&flags.namedPipe, "named-pipe", "",
+		`Also expose the tunnel as a Windows named pipe with this name, i.e. pscale or \\.\pipe\pscale.`)
+
 	cmd.PersistentFlags().MarkHidden("role")
 	return cmd
 }
@@ -181,6 +586,9 @@ func runProxy(
 	proxyOpts proxy.Options,
 	database, branch string,
 	ready chan string,
+	onReady func(addr string),
+	h *hooks,
+	front *frontend,
 ) error {
 	p, err := proxy.NewClient(proxyOpts)
 	if err != nil {
@@ -196,15 +604,23 @@ func runProxy(
 			return
 		}
 
+		if front != nil {
+			front.setUpstream(addr.String())
+			addr = front.listener.Addr()
+		}
+
 		ch.Printer.Printf("Secure connection to database %s and branch %s is established!.\n\nLocal address to connect your application: %s (press ctrl-c to quit)\n",
 			printer.BoldBlue(database),
 			printer.BoldBlue(branch),
 			printer.BoldBlue(addr.String()),
 		)
 		ready <- addr.String()
+		onReady(addr.String())
 	}(ready)
 
-	return p.Run(ctx)
+	err = p.Run(ctx)
+	h.fire(eventTunnelDown)
+	return err
 }
 
 // runCommand runs the given command with several environment variables exposed
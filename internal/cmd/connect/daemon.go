@@ -0,0 +1,138 @@
+package connect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	tunnelstate "github.com/planetscale/cli/internal/tunnel"
+)
+
+const (
+	// daemonStartTimeout is how long to wait for a background tunnel to
+	// be established.
+	daemonStartTimeout = time.Minute
+
+	// minReconnectDelay and maxReconnectDelay bound the backoff of a
+	// background tunnel re-establishing the tunnel.
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+)
+
+// startDaemon runs the tunnel in the background with the arguments of this
+// invocation and waits until it's established.
+func startDaemon(ch *cmdutil.Helper, osArgs []string, org, database, branch string, hasBranch bool) error {
+	id := tunnelstate.NewID()
+	p, err := tunnelstate.Start(id, daemonArgs(osArgs, branch, hasBranch))
+	if err != nil {
+		return err
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		_, _ = p.Wait()
+		close(exited)
+	}()
+
+	end := ch.Printer.PrintProgress(fmt.Sprintf("Starting a tunnel to %s/%s in the background...", printer.BoldBlue(database), printer.BoldBlue(branch)))
+	defer end()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(daemonStartTimeout)
+
+	for {
+		select {
+		case <-ticker.C:
+			t, err := tunnelstate.Get(id)
+			if err != nil {
+				if errors.Is(err, tunnelstate.ErrNotFound) {
+					continue
+				}
+				return err
+			}
+			end()
+
+			if ch.Printer.Format() != printer.Human {
+				res := toTunnel(org, database, branch, t.Addrs[0])
+				res.ID = t.ID
+				return ch.Printer.PrintResource(res)
+			}
+
+			ch.Printer.Printf("Tunnel %s to database %s and branch %s is running in the background.\n\nLocal address to connect your application: %s\n\nStop it with: pscale tunnel stop %s\n",
+				printer.BoldBlue(t.ID),
+				printer.BoldBlue(database),
+				printer.BoldBlue(branch),
+				printer.BoldBlue(strings.Join(t.Addrs, ", ")),
+				t.ID,
+			)
+			return nil
+		case <-exited:
+			logPath, _ := tunnelstate.LogPath(id)
+			out, _ := ioutil.ReadFile(logPath)
+			_ = tunnelstate.Remove(id)
+			return fmt.Errorf("the tunnel exited before it was established:\n%s", strings.TrimSpace(string(out)))
+		case <-timeout:
+			logPath, _ := tunnelstate.LogPath(id)
+			return fmt.Errorf("the tunnel wasn't established within %s, see its log at %s", daemonStartTimeout, logPath)
+		}
+	}
+}
+
+// daemonArgs returns the arguments for running the tunnel of this
+// invocation in the foreground. The branch is added if it was picked
+// interactively, the tunnel can't prompt for it.
+func daemonArgs(osArgs []string, branch string, hasBranch bool) []string {
+	args := make([]string, 0, len(osArgs)+1)
+	for _, arg := range osArgs {
+		if arg == "--daemon" || strings.HasPrefix(arg, "--daemon=") {
+			continue
+		}
+		args = append(args, arg)
+	}
+
+	if !hasBranch {
+		args = append(args, branch)
+	}
+	return args
+}
+
+// keepRunning runs the tunnel until ctx is done. Whenever it fails, i.e.
+// because the network was down, it's run again with new credentials after
+// an increasing delay.
+func keepRunning(ctx context.Context, ch *cmdutil.Helper, run func() error) error {
+	delay := minReconnectDelay
+	for {
+		started := time.Now()
+		err := run()
+		if err == nil || ctx.Err() != nil {
+			return nil
+		}
+		if isAddrInUse(err) {
+			return err // retrying doesn't help
+		}
+
+		// the tunnel was up for a while, it's a new failure
+		if time.Since(started) > maxReconnectDelay {
+			delay = minReconnectDelay
+		}
+
+		ch.Printer.Printf("The tunnel failed: %s. Reconnecting in %s ...\n", err, delay)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil
+		}
+
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
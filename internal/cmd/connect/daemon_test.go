@@ -0,0 +1,45 @@
+package connect
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestDaemonArgs(t *testing.T) {
+	c := qt.New(t)
+
+	got := daemonArgs([]string{"connect", "mydb", "dev", "--daemon", "--port", "3307"}, "dev", true)
+	c.Assert(got, qt.DeepEquals, []string{"connect", "mydb", "dev", "--port", "3307"})
+
+	// the branch picked interactively is passed to the tunnel
+	got = daemonArgs([]string{"--org", "acme", "connect", "mydb", "--daemon=true"}, "main", false)
+	c.Assert(got, qt.DeepEquals, []string{"--org", "acme", "connect", "mydb", "main"})
+}
+
+func TestKeepRunning(t *testing.T) {
+	c := qt.New(t)
+
+	format := printer.Human
+	ch := &cmdutil.Helper{Printer: printer.NewPrinter(&format)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runs := 0
+	err := keepRunning(ctx, ch, func() error {
+		runs++
+		if runs == 2 {
+			cancel() // i.e. the tunnel was stopped
+			return nil
+		}
+		return errors.New("couldn't retrieve certs")
+	})
+	c.Assert(err, qt.IsNil)
+	c.Assert(runs, qt.Equals, 2)
+}
@@ -0,0 +1,223 @@
+package connect
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/planetscale/cli/internal/certcache"
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	emitODBC = "odbc"
+	emitJDBC = "jdbc"
+
+	odbcDriver = "MySQL ODBC 8.0 Unicode Driver"
+)
+
+// caBundles are the usual locations of the system CA certificates, which
+// the ODBC driver needs to verify the server.
+var caBundles = []string{
+	"/etc/ssl/certs/ca-certificates.crt", // Debian, Ubuntu, Arch
+	"/etc/pki/tls/certs/ca-bundle.crt",   // Fedora, RHEL
+	"/etc/ssl/ca-bundle.pem",             // openSUSE
+	"/etc/ssl/cert.pem",                  // macOS, Alpine
+}
+
+// emitted returns a table-serializable model of generated client config.
+type emitted struct {
+	Format   string `header:"format" json:"format"`
+	Database string `header:"database" json:"database"`
+	Branch   string `header:"branch" json:"branch"`
+	Host     string `header:"host" json:"host"`
+	Username string `header:"username" json:"username"`
+	Password string `header:"-" json:"password"`
+	DSN      string `header:"dsn" json:"dsn,omitempty"`
+	File     string `header:"file" json:"file,omitempty"`
+	URL      string `header:"-" json:"url,omitempty"`
+}
+
+// emitConfig creates a password for the branch and writes an ODBC data
+// source or prints a JDBC URL for connecting directly, without a tunnel.
+func emitConfig(ctx context.Context, ch *cmdutil.Helper, client *planetscale.Client, format, odbcFile string, role cmdutil.PasswordRole, database, branch string) error {
+	if format != emitODBC && format != emitJDBC {
+		return fmt.Errorf("unsupported --emit format %q, supported formats are: %s, %s", format, emitODBC, emitJDBC)
+	}
+
+	name, err := cmdutil.ResourceName(ch, "password", "", database)
+	if err != nil {
+		return err
+	}
+
+	end := ch.Printer.PrintProgress(fmt.Sprintf("Creating password of %s/%s...", printer.BoldBlue(database), printer.BoldBlue(branch)))
+	defer end()
+
+	pass, err := client.Passwords.Create(ctx, &planetscale.DatabaseBranchPasswordRequest{
+		Organization: ch.Config.Organization,
+		Database:     database,
+		Branch:       branch,
+		Role:         role.ToString(),
+		DisplayName:  name,
+	})
+	if err != nil {
+		return cmdutil.HandleError(err)
+	}
+	end()
+
+	res := &emitted{
+		Format:   format,
+		Database: database,
+		Branch:   branch,
+		Host:     pass.Branch.AccessHostURL,
+		Username: pass.PublicID,
+		Password: pass.PlainText,
+	}
+
+	if format == emitJDBC {
+		res.URL = jdbcURL(res)
+		if ch.Printer.Format() == printer.Human {
+			ch.Printer.Printf("Password %s was created, the JDBC URL below contains it and can't be shown again:\n\n",
+				printer.BoldBlue(pass.Name))
+			ch.Printer.Println(res.URL)
+			return nil
+		}
+		return ch.Printer.PrintResource(res)
+	}
+
+	if odbcFile == "" {
+		odbcFile, err = defaultODBCFile()
+		if err != nil {
+			return err
+		}
+	}
+
+	res.DSN = fmt.Sprintf("pscale-%s-%s", database, branch)
+	res.File = odbcFile
+
+	// the CA certificates cached with pscale cert refresh are the ones the
+	// user asked for, prefer them over the ones of the system
+	ca := certcache.Bundle(res.Host)
+	if ca == "" {
+		ca = systemCABundle()
+	}
+	if ca == "" {
+		fmt.Fprintf(os.Stderr, "%s no CA certificates found, the data source requires TLS but doesn't verify the server. Run 'pscale cert refresh %s' to cache them.\n",
+			printer.BoldRed("Warning:"), res.Host)
+	}
+
+	if err := writeODBCSection(odbcFile, res.DSN, odbcStanza(res, ca)); err != nil {
+		return fmt.Errorf("writing %s: %s", odbcFile, err)
+	}
+
+	if ch.Printer.Format() == printer.Human {
+		ch.Printer.Printf("Password %s was created and data source %s was written to %s.\n",
+			printer.BoldBlue(pass.Name), printer.BoldBlue(res.DSN), printer.BoldBlue(odbcFile))
+		return nil
+	}
+	return ch.Printer.PrintResource(res)
+}
+
+// jdbcURL returns the MySQL Connector/J URL for the password. The server
+// is verified against the CA certificates of the JVM.
+func jdbcURL(e *emitted) string {
+	q := url.Values{}
+	q.Set("user", e.Username)
+	q.Set("password", e.Password)
+	q.Set("sslMode", "VERIFY_IDENTITY")
+
+	return fmt.Sprintf("jdbc:mysql://%s:%s/%s?%s", e.Host, cmdutil.MySQLPort, url.PathEscape(e.Database), q.Encode())
+}
+
+// odbcStanza returns the lines of the odbc.ini section of the data source.
+// Without a CA bundle the connection is encrypted but not verified.
+func odbcStanza(e *emitted, ca string) []string {
+	lines := []string{
+		"Driver   = " + odbcDriver,
+		"Server   = " + e.Host,
+		"Port     = " + cmdutil.MySQLPort,
+		"Database = " + e.Database,
+		"User     = " + e.Username,
+		"Password = " + e.Password,
+	}
+
+	if ca == "" {
+		return append(lines, "SSLMODE  = REQUIRED")
+	}
+	return append(lines, "SSLMODE  = VERIFY_IDENTITY", "SSLCA    = "+ca)
+}
+
+// writeODBCSection writes the section to the odbc.ini file, replacing the
+// section with the same name. The other sections are kept.
+func writeODBCSection(path, name string, lines []string) error {
+	existing, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var out []string
+	skip := false
+	scanner := bufio.NewScanner(strings.NewReader(string(existing)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			skip = strings.EqualFold(strings.TrimSpace(trimmed[1:len(trimmed)-1]), name)
+		}
+		if !skip {
+			out = append(out, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for len(out) > 0 && strings.TrimSpace(out[len(out)-1]) == "" {
+		out = out[:len(out)-1]
+	}
+	if len(out) > 0 {
+		out = append(out, "")
+	}
+
+	out = append(out, "["+name+"]")
+	out = append(out, lines...)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// the file contains passwords
+	return ioutil.WriteFile(path, []byte(strings.Join(out, "\n")+"\n"), 0600)
+}
+
+// defaultODBCFile returns the odbc.ini file of the user, the one set with
+// ODBCINI or ~/.odbc.ini.
+func defaultODBCFile() (string, error) {
+	if f := os.Getenv("ODBCINI"); f != "" {
+		return f, nil
+	}
+
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".odbc.ini"), nil
+}
+
+func systemCABundle() string {
+	for _, p := range caBundles {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
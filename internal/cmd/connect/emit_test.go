@@ -0,0 +1,60 @@
+package connect
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestJDBCURL(t *testing.T) {
+	c := qt.New(t)
+
+	got := jdbcURL(&emitted{
+		Database: "mydb",
+		Host:     "aws.connect.psdb.cloud",
+		Username: "abc123",
+		Password: "pscale_pw_a&b",
+	})
+	c.Assert(got, qt.Equals, "jdbc:mysql://aws.connect.psdb.cloud:3306/mydb?password=pscale_pw_a%26b&sslMode=VERIFY_IDENTITY&user=abc123")
+}
+
+func TestWriteODBCSection(t *testing.T) {
+	c := qt.New(t)
+
+	path := filepath.Join(t.TempDir(), ".odbc.ini")
+	err := ioutil.WriteFile(path, []byte("[other]\nDriver = SQLite3\n\n[pscale-mydb-main]\nServer = old.example.com\n\n[last]\nDriver = PostgreSQL\n"), 0644)
+	c.Assert(err, qt.IsNil)
+
+	e := &emitted{
+		Database: "mydb",
+		Host:     "aws.connect.psdb.cloud",
+		Username: "abc123",
+		Password: "pscale_pw_secret",
+	}
+	err = writeODBCSection(path, "pscale-mydb-main", odbcStanza(e, "/etc/ssl/cert.pem"))
+	c.Assert(err, qt.IsNil)
+
+	out, err := ioutil.ReadFile(path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, `[other]
+Driver = SQLite3
+
+[last]
+Driver = PostgreSQL
+
+[pscale-mydb-main]
+Driver   = MySQL ODBC 8.0 Unicode Driver
+Server   = aws.connect.psdb.cloud
+Port     = 3306
+Database = mydb
+User     = abc123
+Password = pscale_pw_secret
+SSLMODE  = VERIFY_IDENTITY
+SSLCA    = /etc/ssl/cert.pem
+`)
+
+	// without a CA bundle the server isn't verified
+	c.Assert(odbcStanza(e, "")[6], qt.Equals, "SSLMODE  = REQUIRED")
+}
@@ -0,0 +1,451 @@
+package connect
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// frontend listens in front of the proxy when the connections of the
+// clients have to be inspected, limited or degraded, and forwards them to
+// the proxy.
+type frontend struct {
+	listener net.Listener
+
+	// log, if set, logs the statements of the clients.
+	log *queryLog
+
+	// limit, if set, caps the connections forwarded to the proxy.
+	limit *connLimiter
+
+	// chaos, if set, degrades the connections.
+	chaos *chaos
+
+	// stats, if set, counts the traffic of the connections.
+	stats *tunnelStats
+
+	// pool, if set, keeps the connections of clients that quit for the
+	// next clients.
+	pool *connPool
+
+	upstream atomic.Value // string, the address of the proxy
+	conns    uint64
+}
+
+// setUpstream sets the local address of the proxy the connections are
+// forwarded to.
+func (f *frontend) setUpstream(addr string) {
+	f.upstream.Store(addr)
+}
+
+// Close stops listening, closes the kept connections and the query log.
+func (f *frontend) Close() error {
+	f.listener.Close()
+	if f.pool != nil {
+		f.pool.expire(true)
+	}
+	if f.log != nil {
+		return f.log.Close()
+	}
+	return nil
+}
+
+// serve accepts connections until ctx is done.
+func (f *frontend) serve(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		f.listener.Close()
+	}()
+
+	if f.pool != nil {
+		go f.pool.closeIdle(ctx, f.limit)
+	}
+
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		go f.handle(ctx, conn, atomic.AddUint64(&f.conns, 1))
+	}
+}
+
+func (f *frontend) handle(ctx context.Context, client net.Conn, id uint64) {
+	defer client.Close()
+
+	addr, _ := f.upstream.Load().(string)
+	server, err := f.connect(ctx, client, addr)
+	if err != nil {
+		f.note(id, err.Error())
+		return
+	}
+
+	keep := false
+	defer func() {
+		if keep {
+			f.pool.put(server)
+		} else {
+			server.close()
+		}
+	}()
+
+	var bytesIn, bytesOut *int64
+	if f.stats != nil {
+		cs := f.stats.open()
+		defer f.stats.close(cs)
+		bytesIn, bytesOut = &cs.in, &cs.out
+	}
+
+	c := &forwardedConn{client: client, server: server}
+	c.touch()
+
+	if f.limit != nil && f.limit.idleTimeout > 0 {
+		stop := make(chan struct{})
+		defer close(stop)
+		go f.limit.closeIdle(c, stop)
+	}
+
+	var mu sync.Mutex
+	var pending string
+	var started time.Time
+
+	var readErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := server.Read(buf)
+			if n > 0 {
+				c.touch()
+
+				mu.Lock()
+				if pending != "" {
+					f.log.write(id, pending, time.Since(started))
+					pending = ""
+				}
+				mu.Unlock()
+
+				if _, werr := client.Write(buf[:n]); werr != nil {
+					readErr = werr
+					return
+				}
+				if bytesIn != nil {
+					atomic.AddInt64(bytesIn, int64(n))
+				}
+			}
+			if err != nil {
+				// unblock the reads of the client
+				client.Close()
+				readErr = err
+				return
+			}
+		}
+	}()
+
+	var upstream io.Writer = server
+	if f.chaos != nil {
+		upstream = &chaosWriter{w: server, chaos: f.chaos, conn: c}
+	}
+	if bytesOut != nil {
+		upstream = &countingWriter{w: upstream, n: bytesOut}
+	}
+
+	// the handshake response is only compared for connections that might be
+	// kept, reused ones have it already
+	handshake := f.pool != nil && server.login == nil
+	err = inspectPackets(client, &touchWriter{w: upstream, c: c}, f.pool != nil, func(seq byte, payload []byte) bool {
+		if handshake && seq == 1 {
+			handshake = false
+			server.login = parseLogin(payload)
+		}
+
+		if f.log == nil {
+			// nothing to inspect but the COM_QUIT of connections that
+			// might be kept
+			return server.login != nil
+		}
+
+		if seq == 1 && len(payload) == 32 && binary.LittleEndian.Uint32(payload)&clientSSL != 0 {
+			f.log.note(id, "the client uses TLS, its statements can't be logged. Connect with TLS disabled, i.e. --ssl-mode=DISABLED")
+			return false
+		}
+
+		// commands are the only packets of the client starting a new sequence
+		if seq == 0 && len(payload) > 0 && payload[0] == comQuery {
+			mu.Lock()
+			pending = string(payload[1:])
+			started = time.Now()
+			mu.Unlock()
+		}
+		return true
+	})
+	if err == errQuit && server.login != nil {
+		// stop forwarding the packets of the server, the connection is
+		// kept for the next client once its session is reset
+		server.SetReadDeadline(time.Now()) // nolint:errcheck
+		<-done
+		server.SetReadDeadline(time.Time{}) // nolint:errcheck
+
+		var nerr net.Error
+		keep = errors.As(readErr, &nerr) && nerr.Timeout() && server.reset() == nil
+		return
+	}
+	if err != nil {
+		server.Close()
+	}
+
+	<-done
+}
+
+// connect returns a connection to the proxy for the client. With a pool, the
+// greeting of the server is forwarded to the client already, and the client
+// might get a connection kept from another client.
+func (f *frontend) connect(ctx context.Context, client net.Conn, addr string) (*upstreamConn, error) {
+	if f.pool == nil {
+		return f.dial(ctx, addr)
+	}
+
+	if kept := f.pool.get(addr); kept != nil {
+		return f.reuse(ctx, client, kept)
+	}
+
+	server, err := f.dial(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// the greeting is replayed to the clients the connection is handed to
+	_, greeting, err := readPacket(server)
+	if err == nil {
+		err = writePacket(client, 0, greeting)
+	}
+	if err != nil {
+		server.close()
+		return nil, err
+	}
+
+	server.greeting = greeting
+	return server, nil
+}
+
+// reuse replays the greeting of the kept connection to the client, and hands
+// the connection over if the client logs in like the previous one. With the
+// same scramble in the greeting, the same handshake response means the same
+// credentials.
+//
+// Other clients log in to a new connection with their handshake response,
+// like pscale shell does when switching branches.
+func (f *frontend) reuse(ctx context.Context, client net.Conn, kept *upstreamConn) (*upstreamConn, error) {
+	var resp []byte
+	err := writePacket(client, 0, kept.greeting)
+	if err == nil {
+		_, resp, err = readPacket(client)
+	}
+	if err != nil {
+		f.pool.put(kept)
+		return nil, err
+	}
+
+	l := parseLogin(resp)
+	switch {
+	case l == nil || *l != *kept.login:
+		f.pool.put(kept)
+	case kept.ready(l.db) != nil:
+		// the connection was closed while it was kept
+		kept.close()
+	default:
+		if err := writePacket(client, 2, okPacket); err != nil {
+			kept.close()
+			return nil, err
+		}
+		return kept, nil
+	}
+
+	server, err := f.dial(ctx, kept.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// the client got the greeting of the kept connection instead
+	_, _, err = readPacket(server)
+	if err == nil {
+		err = writePacket(server, 1, resp)
+	}
+	if err != nil {
+		server.close()
+		return nil, err
+	}
+
+	server.greeting, server.login = kept.greeting, l
+	return server, nil
+}
+
+// dial connects to the proxy, once the limiter has a free connection.
+func (f *frontend) dial(ctx context.Context, addr string) (*upstreamConn, error) {
+	var release func()
+	if f.limit != nil {
+		var err error
+		if release, err = f.limit.acquire(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		if release != nil {
+			release()
+		}
+		return nil, fmt.Errorf("can't connect to the tunnel: %s", err)
+	}
+	return &upstreamConn{Conn: conn, addr: addr, release: release}, nil
+}
+
+func (f *frontend) note(id uint64, msg string) {
+	if f.log != nil {
+		f.log.note(id, msg)
+	}
+}
+
+// forwardedConn is a client connection forwarded to the proxy.
+type forwardedConn struct {
+	client, server net.Conn
+	lastActive     int64 // unix nanoseconds
+}
+
+func (c *forwardedConn) touch() {
+	atomic.StoreInt64(&c.lastActive, time.Now().UnixNano())
+}
+
+func (c *forwardedConn) idle() time.Duration {
+	return time.Since(time.Unix(0, atomic.LoadInt64(&c.lastActive)))
+}
+
+// touchWriter marks the connection active on every write.
+type touchWriter struct {
+	w io.Writer
+	c *forwardedConn
+}
+
+func (t *touchWriter) Write(p []byte) (int, error) {
+	t.c.touch()
+	return t.w.Write(p)
+}
+
+// connLimiter caps the number of connections to the proxy. The connections
+// of clients exceeding it are queued until another connection is closed.
+//
+// MySQL sessions are stateful, so a connection can't be handed from one
+// client to another. Instead, connections idle for longer than idleTimeout
+// are closed while other clients are waiting.
+type connLimiter struct {
+	slots        chan struct{}
+	queueTimeout time.Duration
+	idleTimeout  time.Duration
+	waiting      int32
+}
+
+func newConnLimiter(max int, queueTimeout, idleTimeout time.Duration) *connLimiter {
+	return &connLimiter{
+		slots:        make(chan struct{}, max),
+		queueTimeout: queueTimeout,
+		idleTimeout:  idleTimeout,
+	}
+}
+
+// acquire waits for a free connection, up to the queue timeout. The
+// returned function frees it again.
+func (l *connLimiter) acquire(ctx context.Context) (func(), error) {
+	release := func() { <-l.slots }
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	atomic.AddInt32(&l.waiting, 1)
+	defer atomic.AddInt32(&l.waiting, -1)
+
+	var timeout <-chan time.Time
+	if l.queueTimeout > 0 {
+		t := time.NewTimer(l.queueTimeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return release, nil
+	case <-timeout:
+		return nil, fmt.Errorf("all %d connections are in use, gave up waiting after %s", cap(l.slots), l.queueTimeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// closeIdle closes the connection once it's idle for longer than the idle
+// timeout while other clients are waiting, until stop is closed.
+func (l *connLimiter) closeIdle(c *forwardedConn, stop <-chan struct{}) {
+	interval := l.idleTimeout / 4
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			if atomic.LoadInt32(&l.waiting) > 0 && c.idle() > l.idleTimeout {
+				c.client.Close()
+				c.server.Close()
+				return
+			}
+		}
+	}
+}
+
+// inspectPackets copies the MySQL packets of src to dst, calling fn with
+// each of them until it returns false. The rest of src is copied as is. With
+// quit set, a COM_QUIT isn't copied but ends the copy with errQuit.
+func inspectPackets(src io.Reader, dst io.Writer, quit bool, fn func(seq byte, payload []byte) bool) error {
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(src, header); err != nil {
+			return err
+		}
+
+		length := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(src, payload); err != nil {
+			return err
+		}
+
+		if quit && header[3] == 0 && length == 1 && payload[0] == comQuit {
+			return errQuit
+		}
+
+		if _, err := dst.Write(append(header, payload...)); err != nil {
+			return err
+		}
+
+		if !fn(header[3], payload) {
+			_, err := io.Copy(dst, src)
+			return err
+		}
+	}
+}
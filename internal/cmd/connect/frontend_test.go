@@ -0,0 +1,175 @@
+package connect
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestFrontend_QueryLog(t *testing.T) {
+	tests := []struct {
+		name      string
+		handshake []byte
+		want      string
+	}{
+		{
+			name:      "plaintext",
+			handshake: make([]byte, 40),
+			want:      `conn=1 duration=\S+ SELECT \* FROM t WHERE name = \?`,
+		},
+		{
+			name:      "tls",
+			handshake: sslRequest(),
+			want:      `conn=1 # the client uses TLS.*`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+
+			upstream, err := net.Listen("tcp", "127.0.0.1:0")
+			c.Assert(err, qt.IsNil)
+			defer upstream.Close()
+
+			go fakeMySQL(upstream)
+
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			c.Assert(err, qt.IsNil)
+
+			var buf bytes.Buffer
+			front := &frontend{listener: l, log: &queryLog{w: &buf, sanitize: true}}
+			front.setUpstream(upstream.Addr().String())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go front.serve(ctx) // nolint:errcheck
+
+			conn, err := net.Dial("tcp", l.Addr().String())
+			c.Assert(err, qt.IsNil)
+			defer conn.Close()
+
+			_, _, err = readPacket(conn) // greeting
+			c.Assert(err, qt.IsNil)
+
+			c.Assert(writePacket(conn, 1, tt.handshake), qt.IsNil)
+			_, _, err = readPacket(conn) // OK
+			c.Assert(err, qt.IsNil)
+
+			c.Assert(writePacket(conn, 0, append([]byte{comQuery}, "SELECT * FROM t WHERE name = 'x'"...)), qt.IsNil)
+			_, _, err = readPacket(conn) // OK
+			c.Assert(err, qt.IsNil)
+
+			front.log.mu.Lock()
+			out := strings.TrimSpace(buf.String())
+			front.log.mu.Unlock()
+
+			c.Assert(out, qt.Matches, `\S+ `+tt.want)
+		})
+	}
+}
+
+func TestConnLimiter(t *testing.T) {
+	c := qt.New(t)
+	ctx := context.Background()
+
+	l := newConnLimiter(1, 20*time.Millisecond, 0)
+
+	release, err := l.acquire(ctx)
+	c.Assert(err, qt.IsNil)
+
+	_, err = l.acquire(ctx)
+	c.Assert(err, qt.ErrorMatches, "all 1 connections are in use, gave up waiting after 20ms")
+
+	// a queued client gets the connection once it's freed
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		release()
+	}()
+
+	l.queueTimeout = time.Second
+	release, err = l.acquire(ctx)
+	c.Assert(err, qt.IsNil)
+	release()
+}
+
+func TestConnLimiter_CloseIdle(t *testing.T) {
+	c := qt.New(t)
+
+	l := newConnLimiter(1, 0, 10*time.Millisecond)
+
+	client, clientPeer := net.Pipe()
+	server, serverPeer := net.Pipe()
+	defer clientPeer.Close()
+	defer serverPeer.Close()
+
+	conn := &forwardedConn{client: client, server: server}
+	conn.touch()
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	closed := make(chan struct{})
+	go func() {
+		l.closeIdle(conn, stop)
+		close(closed)
+	}()
+
+	// idle connections are kept while nobody is waiting
+	select {
+	case <-closed:
+		c.Fatal("idle connection closed without waiting clients")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	atomic.AddInt32(&l.waiting, 1)
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		c.Fatal("idle connection wasn't closed for a waiting client")
+	}
+
+	_, err := clientPeer.Read(make([]byte, 1))
+	c.Assert(err, qt.Equals, io.EOF)
+}
+
+func sslRequest() []byte {
+	p := make([]byte, 32)
+	binary.LittleEndian.PutUint32(p, clientSSL)
+	return p
+}
+
+// fakeMySQL answers the handshake and a single statement of a client.
+func fakeMySQL(l net.Listener) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	ok := []byte{0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00}
+
+	if err := writePacket(conn, 0, []byte{0x0a}); err != nil {
+		return
+	}
+	if _, _, err := readPacket(conn); err != nil {
+		return
+	}
+	if err := writePacket(conn, 2, ok); err != nil {
+		return
+	}
+	if _, _, err := readPacket(conn); err != nil {
+		return
+	}
+	time.Sleep(5 * time.Millisecond)
+	_ = writePacket(conn, 1, ok)
+}
@@ -0,0 +1,117 @@
+package connect
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/mattn/go-shellwords"
+	"github.com/planetscale/sql-proxy/proxy"
+)
+
+// Tunnel events hook commands are run for.
+const (
+	eventTunnelUp          = "tunnel-up"
+	eventTunnelDown        = "tunnel-down"
+	eventCredentialRotated = "credential-rotated"
+)
+
+// hookTimeout limits how long a single hook command may run.
+const hookTimeout = time.Minute
+
+// hooks runs the user's commands when the tunnel changes. Commands are run
+// one at a time, in the order of the events.
+type hooks struct {
+	commands map[string]string
+	printer  *printer.Printer
+
+	org, database, branch string
+
+	mu   sync.Mutex
+	addr string // set while the tunnel is up
+}
+
+// fire runs the hook command of the event, if there's one. A failing hook is
+// reported but doesn't affect the tunnel.
+func (h *hooks) fire(event string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if event == eventTunnelDown {
+		if h.addr == "" {
+			return // the tunnel never came up
+		}
+		defer func() { h.addr = "" }()
+	}
+
+	command := h.commands[event]
+	if command == "" {
+		return
+	}
+
+	if err := h.run(event, command); err != nil {
+		h.printer.Printf("%s hook failed: %s\n", event, err)
+	}
+}
+
+// up records the local address of the tunnel and fires tunnel-up.
+func (h *hooks) up(addr string) {
+	h.mu.Lock()
+	h.addr = addr
+	h.mu.Unlock()
+
+	h.fire(eventTunnelUp)
+}
+
+func (h *hooks) run(event, command string) error {
+	args, err := shellwords.Parse(command)
+	if err != nil {
+		return fmt.Errorf("failed to parse command: %s", err)
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"PLANETSCALE_EVENT="+event,
+		"PLANETSCALE_ORG="+h.org,
+		"PLANETSCALE_DATABASE_NAME="+h.database,
+		"PLANETSCALE_BRANCH_NAME="+h.branch,
+		"PLANETSCALE_DATABASE_HOST="+h.addr,
+	)
+
+	return cmd.Run()
+}
+
+// hookCertSource fires credential-rotated whenever the proxy fetches a new
+// certificate after the first one, i.e. once the cached one expired.
+type hookCertSource struct {
+	proxy.CertSource
+	hooks  *hooks
+	issued int32
+}
+
+func (s *hookCertSource) Cert(ctx context.Context, org, db, branch string) (*proxy.Cert, error) {
+	cert, err := s.CertSource.Cert(ctx, org, db, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	if atomic.AddInt32(&s.issued, 1) > 1 {
+		go s.hooks.fire(eventCredentialRotated)
+	}
+
+	return cert, nil
+}
@@ -0,0 +1,63 @@
+package connect
+
+import (
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/planetscale/cli/internal/printer"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/planetscale/sql-proxy/proxy"
+)
+
+type fakeCertSource struct{}
+
+func (fakeCertSource) Cert(ctx context.Context, org, db, branch string) (*proxy.Cert, error) {
+	return &proxy.Cert{}, nil
+}
+
+func TestHooks(t *testing.T) {
+	c := qt.New(t)
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		c.Skip("sh is not available")
+	}
+
+	out := filepath.Join(t.TempDir(), "events")
+	command := `sh -c 'echo "$PLANETSCALE_EVENT $PLANETSCALE_DATABASE_NAME/$PLANETSCALE_BRANCH_NAME $PLANETSCALE_DATABASE_HOST" >> ` + out + `'`
+
+	format := printer.Human
+	h := &hooks{
+		commands: map[string]string{
+			eventTunnelUp:          command,
+			eventTunnelDown:        command,
+			eventCredentialRotated: command,
+		},
+		printer:  printer.NewPrinter(&format),
+		org:      "planetscale",
+		database: "mydb",
+		branch:   "main",
+	}
+
+	// down without up is ignored
+	h.fire(eventTunnelDown)
+
+	h.up("127.0.0.1:3306")
+
+	certs := &hookCertSource{CertSource: fakeCertSource{}, hooks: h}
+	_, err := certs.Cert(context.Background(), "planetscale", "mydb", "main")
+	c.Assert(err, qt.IsNil)
+	h.fire(eventCredentialRotated)
+
+	h.fire(eventTunnelDown)
+
+	events, err := ioutil.ReadFile(out)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(events), qt.Equals, `tunnel-up mydb/main 127.0.0.1:3306
+credential-rotated mydb/main 127.0.0.1:3306
+tunnel-down mydb/main 127.0.0.1:3306
+`)
+}
@@ -0,0 +1,121 @@
+package connect
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/planetscale/sql-proxy/proxy"
+)
+
+// unixPrefix is the prefix of the Unix socket listen addresses, as
+// understood by the proxy.
+const unixPrefix = "unix://"
+
+// parseListenAddrs validates the --listen addresses. IPv6 hosts have to be
+// in brackets, i.e. "[::1]:3306", Unix sockets prefixed with unix://, i.e.
+// "unix:///tmp/pscale.sock".
+func parseListenAddrs(addrs []string) ([]string, error) {
+	parsed := make([]string, 0, len(addrs))
+	seen := map[string]bool{}
+
+	for _, addr := range addrs {
+		if isUnixAddr(addr) {
+			if strings.TrimPrefix(addr, unixPrefix) == "" {
+				return nil, fmt.Errorf("invalid listen address %q, the path of the socket is missing", addr)
+			}
+		} else {
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid listen address %q, it must be host:port, i.e. 127.0.0.1:3306 or [::1]:3306, or a Unix socket, i.e. unix:///tmp/pscale.sock", addr)
+			}
+
+			if p, err := strconv.Atoi(port); err != nil || p < 0 || p > 65535 {
+				return nil, fmt.Errorf("invalid port in listen address %q", addr)
+			}
+
+			addr = net.JoinHostPort(host, port)
+		}
+
+		if seen[addr] {
+			return nil, fmt.Errorf("listen address %s is given more than once", addr)
+		}
+		seen[addr] = true
+
+		parsed = append(parsed, addr)
+	}
+
+	return parsed, nil
+}
+
+// isUnixAddr reports whether the address is a Unix socket.
+func isUnixAddr(addr string) bool {
+	return strings.HasPrefix(addr, unixPrefix)
+}
+
+// listenLocal listens on the TCP address or Unix socket. A socket left
+// behind by an earlier tunnel is replaced.
+func listenLocal(addr string) (net.Listener, error) {
+	if !isUnixAddr(addr) {
+		return net.Listen("tcp", addr)
+	}
+
+	p := strings.TrimPrefix(addr, unixPrefix)
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("can't remove the socket %s: %s", p, err)
+	}
+	return net.Listen("unix", p)
+}
+
+// isAllInterfaces reports whether the address listens on all network
+// interfaces, making the tunnel reachable from other machines.
+func isAllInterfaces(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsUnspecified()
+}
+
+// startListeners runs a proxy for each of the additional addresses. If any
+// of them fails, the error is sent on the returned channel and cancel is
+// called to stop the other proxies.
+func startListeners(ctx context.Context, cancel context.CancelFunc, ch *cmdutil.Helper, opts proxy.Options, addrs []string) (<-chan error, error) {
+	errs := make(chan error, len(addrs))
+
+	for _, addr := range addrs {
+		opts.LocalAddr = addr
+		p, err := proxy.NewClient(opts)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't create proxy client: %s", err)
+		}
+
+		go func() {
+			laddr, err := p.LocalAddr()
+			if err != nil {
+				return
+			}
+			ch.Printer.Printf("Also listening on %s\n", printer.BoldBlue(laddr.String()))
+		}()
+
+		go func(addr string) {
+			if err := p.Run(ctx); err != nil {
+				errs <- fmt.Errorf("listening on %s: %s", addr, err)
+				cancel()
+			}
+		}(addr)
+	}
+
+	return errs, nil
+}
@@ -0,0 +1,84 @@
+package connect
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestParseListenAddrs(t *testing.T) {
+	tests := []struct {
+		name    string
+		addrs   []string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:  "ipv4 and ipv6",
+			addrs: []string{"127.0.0.1:3306", "[::1]:3306"},
+			want:  []string{"127.0.0.1:3306", "[::1]:3306"},
+		},
+		{
+			name:  "all interfaces",
+			addrs: []string{":3306", "[::]:0"},
+			want:  []string{":3306", "[::]:0"},
+		},
+		{
+			name:    "ipv6 without brackets",
+			addrs:   []string{"::1:3306"},
+			wantErr: `invalid listen address "::1:3306".*`,
+		},
+		{
+			name:    "missing port",
+			addrs:   []string{"127.0.0.1"},
+			wantErr: `invalid listen address "127.0.0.1".*`,
+		},
+		{
+			name:    "invalid port",
+			addrs:   []string{"127.0.0.1:70000"},
+			wantErr: `invalid port in listen address "127.0.0.1:70000"`,
+		},
+		{
+			name:  "unix socket",
+			addrs: []string{"127.0.0.1:3306", "unix:///tmp/pscale.sock"},
+			want:  []string{"127.0.0.1:3306", "unix:///tmp/pscale.sock"},
+		},
+		{
+			name:    "unix socket without path",
+			addrs:   []string{"unix://"},
+			wantErr: `invalid listen address "unix://", the path of the socket is missing`,
+		},
+		{
+			name:    "duplicate",
+			addrs:   []string{"[::1]:3306", "[::1]:3306"},
+			wantErr: `listen address \[::1\]:3306 is given more than once`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+
+			got, err := parseListenAddrs(tt.addrs)
+			if tt.wantErr != "" {
+				c.Assert(err, qt.ErrorMatches, tt.wantErr)
+				return
+			}
+
+			c.Assert(err, qt.IsNil)
+			c.Assert(got, qt.DeepEquals, tt.want)
+		})
+	}
+}
+
+func TestIsAllInterfaces(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert(isAllInterfaces(":3306"), qt.IsTrue)
+	c.Assert(isAllInterfaces("0.0.0.0:3306"), qt.IsTrue)
+	c.Assert(isAllInterfaces("[::]:3306"), qt.IsTrue)
+	c.Assert(isAllInterfaces("127.0.0.1:3306"), qt.IsFalse)
+	c.Assert(isAllInterfaces("[::1]:3306"), qt.IsFalse)
+	c.Assert(isAllInterfaces("localhost:3306"), qt.IsFalse)
+	c.Assert(isAllInterfaces("unix:///tmp/pscale.sock"), qt.IsFalse)
+}
@@ -0,0 +1,21 @@
+package connect
+
+import "strings"
+
+// pipePrefix is the prefix of the paths of local Windows named pipes.
+const pipePrefix = `\\.\pipe\`
+
+// pipePath returns the path of the named pipe with the given name. Full
+// paths, i.e. \\.\pipe\pscale, are returned as is.
+func pipePath(name string) string {
+	if strings.HasPrefix(strings.ToLower(name), pipePrefix) {
+		return name
+	}
+	return pipePrefix + strings.TrimLeft(name, `\/`)
+}
+
+// pipeAddr is the net.Addr of a named pipe.
+type pipeAddr string
+
+func (a pipeAddr) Network() string { return "pipe" }
+func (a pipeAddr) String() string  { return string(a) }
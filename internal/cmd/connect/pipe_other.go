@@ -0,0 +1,13 @@
+//go:build !windows
+// +build !windows
+
+package connect
+
+import (
+	"errors"
+	"net"
+)
+
+func listenPipe(path string) (net.Listener, error) {
+	return nil, errors.New("named pipes are only supported on Windows")
+}
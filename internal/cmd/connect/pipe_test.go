@@ -0,0 +1,26 @@
+package connect
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestPipePath(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "pscale", want: `\\.\pipe\pscale`},
+		{name: `\pscale`, want: `\\.\pipe\pscale`},
+		{name: `\\.\pipe\pscale`, want: `\\.\pipe\pscale`},
+		{name: `\\.\PIPE\MySQL`, want: `\\.\PIPE\MySQL`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+			c.Assert(pipePath(tt.name), qt.Equals, tt.want)
+		})
+	}
+}
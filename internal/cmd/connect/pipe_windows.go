@@ -0,0 +1,200 @@
+package connect
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+const pipeBufferSize = 64 * 1024
+
+// pipeListener accepts the connections to a named pipe. Every client is
+// served by its own instance of the pipe, the next one is created once a
+// client connects to the current one.
+type pipeListener struct {
+	path string
+
+	mu     sync.Mutex
+	handle windows.Handle // the instance waiting for a client
+	closed bool
+}
+
+// listenPipe creates the named pipe with the given path. It fails if the
+// pipe exists already, i.e. if another tunnel is using it.
+func listenPipe(path string) (net.Listener, error) {
+	h, err := createPipe(path, true)
+	if err != nil {
+		return nil, &net.OpError{Op: "listen", Net: "pipe", Addr: pipeAddr(path), Err: err}
+	}
+
+	return &pipeListener{path: path, handle: h}, nil
+}
+
+func createPipe(path string, first bool) (windows.Handle, error) {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return windows.InvalidHandle, err
+	}
+
+	flags := uint32(windows.PIPE_ACCESS_DUPLEX | windows.FILE_FLAG_OVERLAPPED)
+	if first {
+		flags |= windows.FILE_FLAG_FIRST_PIPE_INSTANCE
+	}
+
+	return windows.CreateNamedPipe(
+		name,
+		flags,
+		windows.PIPE_TYPE_BYTE|windows.PIPE_READMODE_BYTE|windows.PIPE_WAIT|windows.PIPE_REJECT_REMOTE_CLIENTS,
+		windows.PIPE_UNLIMITED_INSTANCES,
+		pipeBufferSize,
+		pipeBufferSize,
+		0,
+		nil,
+	)
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	h, closed := l.handle, l.closed
+	l.mu.Unlock()
+	if closed {
+		return nil, net.ErrClosed
+	}
+
+	_, err := overlapped(h, func(ov *windows.Overlapped) (uint32, error) {
+		return 0, windows.ConnectNamedPipe(h, ov)
+	})
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil, net.ErrClosed
+	}
+	if err != nil && err != windows.ERROR_PIPE_CONNECTED {
+		return nil, &net.OpError{Op: "accept", Net: "pipe", Addr: pipeAddr(l.path), Err: err}
+	}
+
+	next, err := createPipe(l.path, false)
+	if err != nil {
+		windows.CloseHandle(h)
+		l.handle = windows.InvalidHandle
+		l.closed = true
+		return nil, &net.OpError{Op: "accept", Net: "pipe", Addr: pipeAddr(l.path), Err: err}
+	}
+	l.handle = next
+
+	return &pipeConn{handle: h, addr: pipeAddr(l.path)}, nil
+}
+
+// Close removes the pipe, the connected clients are kept.
+func (l *pipeListener) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+
+	// unblocks a pending Accept
+	_ = windows.CancelIoEx(l.handle, nil)
+	return windows.CloseHandle(l.handle)
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr(l.path)
+}
+
+// pipeConn is a client connected to an instance of the pipe.
+type pipeConn struct {
+	handle windows.Handle
+	addr   pipeAddr
+
+	closeOnce sync.Once
+}
+
+func (c *pipeConn) Read(p []byte) (int, error) {
+	n, err := overlapped(c.handle, func(ov *windows.Overlapped) (uint32, error) {
+		var done uint32
+		err := windows.ReadFile(c.handle, p, &done, ov)
+		return done, err
+	})
+	switch err {
+	case nil:
+		if n == 0 && len(p) > 0 {
+			return 0, io.EOF
+		}
+		return int(n), nil
+	case windows.ERROR_BROKEN_PIPE, windows.ERROR_PIPE_NOT_CONNECTED:
+		return int(n), io.EOF
+	default:
+		return int(n), c.opError("read", err)
+	}
+}
+
+func (c *pipeConn) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		n, err := overlapped(c.handle, func(ov *windows.Overlapped) (uint32, error) {
+			var done uint32
+			err := windows.WriteFile(c.handle, p[written:], &done, ov)
+			return done, err
+		})
+		written += int(n)
+		if err != nil {
+			return written, c.opError("write", err)
+		}
+	}
+	return written, nil
+}
+
+// Close disconnects the client, cancelling pending reads and writes.
+func (c *pipeConn) Close() error {
+	err := net.ErrClosed
+	c.closeOnce.Do(func() {
+		_ = windows.CancelIoEx(c.handle, nil)
+		err = windows.CloseHandle(c.handle)
+	})
+	return err
+}
+
+func (c *pipeConn) opError(op string, err error) error {
+	if err == windows.ERROR_OPERATION_ABORTED || err == windows.ERROR_INVALID_HANDLE {
+		err = net.ErrClosed
+	}
+	return &net.OpError{Op: op, Net: "pipe", Addr: c.addr, Err: err}
+}
+
+func (c *pipeConn) LocalAddr() net.Addr  { return c.addr }
+func (c *pipeConn) RemoteAddr() net.Addr { return c.addr }
+
+var errPipeDeadline = errors.New("deadlines aren't supported on named pipes")
+
+func (c *pipeConn) SetDeadline(t time.Time) error      { return errPipeDeadline }
+func (c *pipeConn) SetReadDeadline(t time.Time) error  { return errPipeDeadline }
+func (c *pipeConn) SetWriteDeadline(t time.Time) error { return errPipeDeadline }
+
+// overlapped runs the I/O operation fn on h and waits for its completion.
+// The pipes are opened for overlapped I/O, so reads and writes of a
+// connection don't block each other.
+func overlapped(h windows.Handle, fn func(ov *windows.Overlapped) (uint32, error)) (uint32, error) {
+	event, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer windows.CloseHandle(event)
+
+	ov := &windows.Overlapped{HEvent: event}
+	n, err := fn(ov)
+	if err != windows.ERROR_IO_PENDING {
+		return n, err
+	}
+
+	err = windows.GetOverlappedResult(h, ov, &n, true)
+	return n, err
+}
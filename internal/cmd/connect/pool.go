@@ -0,0 +1,293 @@
+package connect
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	comQuit            = 0x01
+	comInitDB          = 0x02
+	comPing            = 0x0e
+	comResetConnection = 0x1f
+
+	clientConnectWithDB        = 0x00000008
+	clientCompress             = 0x00000020
+	clientProtocol41           = 0x00000200
+	clientSecureConnection     = 0x00008000
+	clientPluginAuth           = 0x00080000
+	clientConnectAttrs         = 0x00100000
+	clientPluginAuthLenencData = 0x00200000
+
+	// commandTimeout limits the commands the frontend sends on its own.
+	commandTimeout = 5 * time.Second
+)
+
+// errQuit is returned by inspectPackets for the COM_QUIT of a client, which
+// isn't forwarded when the connection is kept for the next client.
+var errQuit = errors.New("client quit")
+
+// okPacket has no affected rows or insert ID, autocommit and no warnings.
+var okPacket = []byte{0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00}
+
+// upstreamConn is a connection to the proxy.
+type upstreamConn struct {
+	net.Conn
+	addr string
+
+	// greeting is the handshake of the server, login the handshake
+	// response of the client. They're set if the connection can be kept
+	// for the next client.
+	greeting []byte
+	login    *login
+
+	release   func() // frees the connection of the limiter, if any
+	closeOnce sync.Once
+	idleSince time.Time
+}
+
+// close closes the connection and frees it in the limiter.
+func (c *upstreamConn) close() {
+	c.closeOnce.Do(func() {
+		c.Conn.Close()
+		if c.release != nil {
+			c.release()
+		}
+	})
+}
+
+// reset resets the session of the connection for the next client.
+func (c *upstreamConn) reset() error {
+	return c.command([]byte{comResetConnection})
+}
+
+// ready checks the kept connection is still open, and selects the default
+// database of the next client.
+func (c *upstreamConn) ready(db string) error {
+	if db == "" {
+		return c.command([]byte{comPing})
+	}
+	return c.command(append([]byte{comInitDB}, db...))
+}
+
+func (c *upstreamConn) command(cmd []byte) error {
+	c.SetDeadline(time.Now().Add(commandTimeout)) // nolint:errcheck
+	defer c.SetDeadline(time.Time{})              // nolint:errcheck
+
+	if err := writePacket(c, 0, cmd); err != nil {
+		return err
+	}
+
+	_, resp, err := readPacket(c)
+	if err != nil {
+		return err
+	}
+	if len(resp) == 0 || resp[0] != 0x00 {
+		return fmt.Errorf("command 0x%02x failed", cmd[0])
+	}
+	return nil
+}
+
+// connPool keeps the connections of clients that quit for the next clients,
+// up to the idle timeout. A kept connection still counts against the
+// limiter, so kept connections are closed while other clients wait.
+type connPool struct {
+	idleTimeout time.Duration
+
+	mu   sync.Mutex
+	idle []*upstreamConn
+}
+
+func newConnPool(idleTimeout time.Duration) *connPool {
+	return &connPool{idleTimeout: idleTimeout}
+}
+
+// get returns the most recently kept connection to addr, or nil if there's
+// none. Connections to other addresses are closed, they're left over from
+// before the tunnel was re-established.
+func (p *connPool) get(addr string) *upstreamConn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	idle := p.idle[:0]
+	for _, c := range p.idle {
+		if c.addr != addr {
+			c.close()
+			continue
+		}
+		idle = append(idle, c)
+	}
+
+	var kept *upstreamConn
+	if n := len(idle); n > 0 {
+		kept, idle = idle[n-1], idle[:n-1]
+	}
+	p.idle = idle
+	return kept
+}
+
+// put keeps the connection for the next client.
+func (p *connPool) put(c *upstreamConn) {
+	c.idleSince = time.Now()
+
+	p.mu.Lock()
+	p.idle = append(p.idle, c)
+	p.mu.Unlock()
+}
+
+// expire closes the connections kept for longer than the idle timeout, or
+// all of them if all is set.
+func (p *connPool) expire(all bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	idle := p.idle[:0]
+	for _, c := range p.idle {
+		if all || (p.idleTimeout > 0 && time.Since(c.idleSince) > p.idleTimeout) {
+			c.close()
+			continue
+		}
+		idle = append(idle, c)
+	}
+	p.idle = idle
+}
+
+// closeIdle expires the kept connections until ctx is done, and closes all
+// of them whenever clients wait for a free connection of the limiter.
+func (p *connPool) closeIdle(ctx context.Context, l *connLimiter) {
+	interval := p.idleTimeout / 4
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.expire(true)
+			return
+		case <-t.C:
+			p.expire(l != nil && atomic.LoadInt32(&l.waiting) > 0)
+		}
+	}
+}
+
+// login is the handshake response of a client, without the connection
+// attributes.
+type login struct {
+	caps    uint32
+	charset byte
+	user    string
+	auth    string
+	db      string
+	plugin  string
+}
+
+// parseLogin parses the handshake response of a client. It returns nil for
+// clients upgrading the connection to TLS or compressing it, their
+// connections can't be kept.
+func parseLogin(p []byte) *login {
+	if len(p) < 32 {
+		return nil
+	}
+
+	caps := binary.LittleEndian.Uint32(p)
+	if caps&clientProtocol41 == 0 || caps&(clientSSL|clientCompress) != 0 {
+		return nil
+	}
+
+	// capabilities, max packet size, character set and a filler
+	l := &login{caps: caps &^ clientConnectAttrs, charset: p[8]}
+	rest := p[32:]
+
+	var ok bool
+	if l.user, rest, ok = cutNUL(rest); !ok {
+		return nil
+	}
+
+	switch {
+	case caps&clientPluginAuthLenencData != 0:
+		n, size := lenenc(rest)
+		if size == 0 || uint64(len(rest)-size) < n {
+			return nil
+		}
+		l.auth, rest = string(rest[size:size+int(n)]), rest[size+int(n):]
+	case caps&clientSecureConnection != 0:
+		if len(rest) == 0 || len(rest) < 1+int(rest[0]) {
+			return nil
+		}
+		l.auth, rest = string(rest[1:1+int(rest[0])]), rest[1+int(rest[0]):]
+	default:
+		if l.auth, rest, ok = cutNUL(rest); !ok {
+			return nil
+		}
+	}
+
+	if caps&clientConnectWithDB != 0 {
+		if l.db, rest, ok = cutNUL(rest); !ok {
+			return nil
+		}
+	}
+	if caps&clientPluginAuth != 0 {
+		if l.plugin, _, ok = cutNUL(rest); !ok {
+			return nil
+		}
+	}
+	return l
+}
+
+// cutNUL returns the NUL terminated string at the start of p and the rest.
+func cutNUL(p []byte) (string, []byte, bool) {
+	for i, b := range p {
+		if b == 0 {
+			return string(p[:i]), p[i+1:], true
+		}
+	}
+	return "", nil, false
+}
+
+// lenenc returns the length encoded integer at the start of p and its size,
+// which is 0 if p is too short.
+func lenenc(p []byte) (uint64, int) {
+	if len(p) == 0 {
+		return 0, 0
+	}
+
+	switch {
+	case p[0] < 0xfb:
+		return uint64(p[0]), 1
+	case p[0] == 0xfc && len(p) >= 3:
+		return uint64(binary.LittleEndian.Uint16(p[1:])), 3
+	case p[0] == 0xfd && len(p) >= 4:
+		return uint64(p[1]) | uint64(p[2])<<8 | uint64(p[3])<<16, 4
+	case p[0] == 0xfe && len(p) >= 9:
+		return binary.LittleEndian.Uint64(p[1:]), 9
+	}
+	return 0, 0
+}
+
+func readPacket(r io.Reader) (byte, []byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+
+	payload := make([]byte, int(header[0])|int(header[1])<<8|int(header[2])<<16)
+	_, err := io.ReadFull(r, payload)
+	return header[3], payload, err
+}
+
+func writePacket(w io.Writer, seq byte, payload []byte) error {
+	header := []byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), seq}
+	_, err := w.Write(append(header, payload...))
+	return err
+}
@@ -0,0 +1,230 @@
+package connect
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestParseLogin(t *testing.T) {
+	c := qt.New(t)
+
+	l := parseLogin(testLogin("alice", "db"))
+	c.Assert(l, qt.IsNotNil)
+	c.Assert(*l, qt.Equals, login{
+		caps:    clientProtocol41 | clientSecureConnection | clientConnectWithDB,
+		charset: 33,
+		user:    "alice",
+		auth:    "auth-alice",
+		db:      "db",
+	})
+
+	// clients upgrading to TLS can't be compared
+	c.Assert(parseLogin(sslRequest()), qt.IsNil)
+	c.Assert(parseLogin(testLogin("alice", "db")[:36]), qt.IsNil)
+}
+
+func TestFrontend_ReuseConnections(t *testing.T) {
+	c := qt.New(t)
+
+	upstream, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, qt.IsNil)
+	defer upstream.Close()
+
+	server := &fakePoolServer{}
+	go server.serve(upstream)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, qt.IsNil)
+
+	front := &frontend{listener: l, pool: newConnPool(time.Minute)}
+	front.setUpstream(upstream.Addr().String())
+	defer front.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go front.serve(ctx) // nolint:errcheck
+
+	kept := func() int {
+		front.pool.mu.Lock()
+		defer front.pool.mu.Unlock()
+		return len(front.pool.idle)
+	}
+	waitKept := func(n int) {
+		for i := 0; kept() != n; i++ {
+			if i == 100 {
+				c.Fatalf("%d connections kept, want %d", kept(), n)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	alice := dialFrontend(c, l.Addr().String(), "alice")
+	c.Assert(alice.greeting, qt.DeepEquals, []byte{0x0a, 1})
+	alice.quit(c)
+	waitKept(1)
+
+	// the same client gets the kept connection, with its greeting
+	again := dialFrontend(c, l.Addr().String(), "alice")
+	c.Assert(again.greeting, qt.DeepEquals, []byte{0x0a, 1})
+	c.Assert(kept(), qt.Equals, 0)
+
+	// others get a new one
+	bob := dialFrontend(c, l.Addr().String(), "bob")
+	c.Assert(bob.greeting, qt.DeepEquals, []byte{0x0a, 2})
+
+	again.quit(c)
+	waitKept(1)
+
+	// clients logging in differently than the previous one get a new
+	// connection, the kept one stays
+	other := dialFrontend(c, l.Addr().String(), "carol")
+	c.Assert(other.greeting, qt.DeepEquals, []byte{0x0a, 1})
+	c.Assert(kept(), qt.Equals, 1)
+
+	bob.quit(c)
+	waitKept(2)
+	other.quit(c)
+	waitKept(3)
+
+	c.Assert(server.commands(), qt.DeepEquals, []string{
+		"1: login alice",
+		"1: query",
+		"1: reset",
+		"1: init db",
+		"1: query",
+		"2: login bob",
+		"2: query",
+		"1: reset",
+		"3: login carol",
+		"3: query",
+		"2: reset",
+		"3: reset",
+	})
+}
+
+// frontendClient is a client of the frontend that logged in and sent a
+// statement.
+type frontendClient struct {
+	net.Conn
+	greeting []byte
+}
+
+func dialFrontend(c *qt.C, addr, user string) *frontendClient {
+	conn, err := net.Dial("tcp", addr)
+	c.Assert(err, qt.IsNil)
+
+	_, greeting, err := readPacket(conn)
+	c.Assert(err, qt.IsNil)
+
+	c.Assert(writePacket(conn, 1, testLogin(user, "db")), qt.IsNil)
+	seq, ok, err := readPacket(conn)
+	c.Assert(err, qt.IsNil)
+	c.Assert(seq, qt.Equals, byte(2))
+	c.Assert(ok, qt.DeepEquals, okPacket)
+
+	c.Assert(writePacket(conn, 0, []byte{comQuery, 'S'}), qt.IsNil)
+	_, _, err = readPacket(conn)
+	c.Assert(err, qt.IsNil)
+
+	return &frontendClient{Conn: conn, greeting: greeting}
+}
+
+// quit sends a COM_QUIT, after which the frontend closes the connection.
+func (f *frontendClient) quit(c *qt.C) {
+	c.Assert(writePacket(f, 0, []byte{comQuit}), qt.IsNil)
+	_, err := f.Read(make([]byte, 1))
+	c.Assert(err, qt.Equals, io.EOF)
+	f.Close()
+}
+
+// testLogin returns the handshake response of a client. The auth response
+// depends on the user only, as if all greetings had the same scramble.
+func testLogin(user, db string) []byte {
+	p := make([]byte, 32)
+	binary.LittleEndian.PutUint32(p, clientProtocol41|clientSecureConnection|clientConnectWithDB)
+	binary.LittleEndian.PutUint32(p[4:], 1<<24)
+	p[8] = 33
+
+	auth := "auth-" + user
+	p = append(p, user+"\x00"...)
+	p = append(p, byte(len(auth)))
+	p = append(p, auth...)
+	return append(p, db+"\x00"...)
+}
+
+// fakePoolServer answers the logins and commands of any number of
+// connections, and records them.
+type fakePoolServer struct {
+	mu   sync.Mutex
+	cmds []string
+	n    int
+}
+
+func (s *fakePoolServer) serve(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.n++
+		id := s.n
+		s.mu.Unlock()
+
+		go s.handle(conn, id)
+	}
+}
+
+func (s *fakePoolServer) handle(conn net.Conn, id int) {
+	defer conn.Close()
+
+	if err := writePacket(conn, 0, []byte{0x0a, byte(id)}); err != nil {
+		return
+	}
+	_, p, err := readPacket(conn)
+	if err != nil {
+		return
+	}
+	s.record(id, "login "+parseLogin(p).user)
+	if err := writePacket(conn, 2, okPacket); err != nil {
+		return
+	}
+
+	names := map[byte]string{
+		comQuery:           "query",
+		comInitDB:          "init db",
+		comPing:            "ping",
+		comResetConnection: "reset",
+	}
+	for {
+		_, p, err := readPacket(conn)
+		if err != nil || p[0] == comQuit {
+			return
+		}
+		s.record(id, names[p[0]])
+		if err := writePacket(conn, 1, okPacket); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakePoolServer) record(id int, cmd string) {
+	s.mu.Lock()
+	s.cmds = append(s.cmds, fmt.Sprintf("%d: %s", id, cmd))
+	s.mu.Unlock()
+}
+
+func (s *fakePoolServer) commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.cmds...)
+}
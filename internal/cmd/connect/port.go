@@ -0,0 +1,120 @@
+package connect
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxPortAttempts is how many ports after the requested one are tried with
+// --auto-port.
+const maxPortAttempts = 100
+
+// tunnel returns a table-serializable model of an established tunnel.
+type tunnel struct {
+	ID       string `header:"-" json:"id,omitempty"`
+	Org      string `header:"org" json:"org"`
+	Database string `header:"database" json:"database"`
+	Branch   string `header:"branch" json:"branch"`
+	Address  string `header:"address" json:"address"`
+	Host     string `header:"host" json:"host"`
+	Port     int    `header:"port" json:"port"`
+}
+
+func toTunnel(org, database, branch, addr string) *tunnel {
+	t := &tunnel{Org: org, Database: database, Branch: branch, Address: addr}
+	if host, port, err := net.SplitHostPort(addr); err == nil {
+		t.Host = host
+		t.Port, _ = strconv.Atoi(port)
+	}
+	return t
+}
+
+// nextFreePort returns the given port if it's free on host, otherwise the
+// next free port after it. Port 0 is returned as is, for the listener to
+// pick a random port.
+func nextFreePort(host, port string) (string, error) {
+	start, err := strconv.Atoi(port)
+	if err != nil {
+		return "", fmt.Errorf("invalid port %q: %s", port, err)
+	}
+	if start == 0 {
+		return port, nil
+	}
+
+	for p := start; p < start+maxPortAttempts && p <= 65535; p++ {
+		l, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(p)))
+		if err != nil {
+			continue
+		}
+		l.Close()
+		return strconv.Itoa(p), nil
+	}
+
+	return "", fmt.Errorf("no free port found between %d and %d", start, start+maxPortAttempts-1)
+}
+
+// writeEnvFile sets the connection details of the tunnel in the env file,
+// replacing their previous values and keeping all other lines.
+func writeEnvFile(path, urlEnv, protocol, database, branch, addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+
+	vars := [][2]string{
+		{urlEnv, fmt.Sprintf("%s://root@%s/%s", protocol, addr, database)},
+		{"PLANETSCALE_DATABASE_HOST", addr},
+		{"PLANETSCALE_DATABASE_PORT", port},
+		{"PLANETSCALE_DATABASE_NAME", database},
+		{"PLANETSCALE_BRANCH_NAME", branch},
+	}
+
+	out, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var lines []string
+	if len(out) != 0 {
+		lines = strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	}
+
+	for _, v := range vars {
+		line := v[0] + "=" + v[1]
+
+		found := false
+		for i, l := range lines {
+			l = strings.TrimSpace(l)
+			name := strings.TrimPrefix(l, "export ")
+			if strings.HasPrefix(name, v[0]+"=") {
+				lines[i] = strings.TrimSuffix(l, name) + line
+				found = true
+			}
+		}
+		if !found {
+			lines = append(lines, line)
+		}
+	}
+
+	// write atomically, an application may read the file any time
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
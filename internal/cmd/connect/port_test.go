@@ -0,0 +1,50 @@
+package connect
+
+import (
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestNextFreePort(t *testing.T) {
+	c := qt.New(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, qt.IsNil)
+	defer l.Close()
+
+	busy := l.Addr().(*net.TCPAddr).Port
+
+	port, err := nextFreePort("127.0.0.1", strconv.Itoa(busy))
+	c.Assert(err, qt.IsNil)
+	c.Assert(port, qt.Not(qt.Equals), strconv.Itoa(busy))
+
+	port, err = nextFreePort("127.0.0.1", "0")
+	c.Assert(err, qt.IsNil)
+	c.Assert(port, qt.Equals, "0")
+}
+
+func TestWriteEnvFile(t *testing.T) {
+	c := qt.New(t)
+
+	path := filepath.Join(t.TempDir(), ".env")
+	err := ioutil.WriteFile(path, []byte("APP_ENV=dev\nexport DATABASE_URL=mysql2://root@127.0.0.1:3306/mydb\n"), 0600)
+	c.Assert(err, qt.IsNil)
+
+	err = writeEnvFile(path, "DATABASE_URL", "mysql2", "mydb", "main", "127.0.0.1:3307")
+	c.Assert(err, qt.IsNil)
+
+	out, err := ioutil.ReadFile(path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, `APP_ENV=dev
+export DATABASE_URL=mysql2://root@127.0.0.1:3307/mydb
+PLANETSCALE_DATABASE_HOST=127.0.0.1:3307
+PLANETSCALE_DATABASE_PORT=3307
+PLANETSCALE_DATABASE_NAME=mydb
+PLANETSCALE_BRANCH_NAME=main
+`)
+}
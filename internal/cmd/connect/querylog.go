@@ -0,0 +1,126 @@
+package connect
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	comQuery = 0x03
+
+	// clientSSL is the capability flag of a client upgrading the
+	// connection to TLS, after which the traffic can't be inspected.
+	clientSSL = 0x0800
+
+	// maxLoggedQuery limits the length of a logged statement.
+	maxLoggedQuery = 4096
+)
+
+// queryLog writes the statements of all inspected connections to a log, one
+// line per statement. The duration of a statement is the time until the
+// server started responding to it.
+type queryLog struct {
+	mu       sync.Mutex
+	w        io.Writer
+	sanitize bool
+	file     *os.File
+}
+
+// newQueryLog returns a query log appending to the file at path.
+func newQueryLog(path string, sanitize bool) (*queryLog, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("can't open query log: %s", err)
+	}
+
+	return &queryLog{w: f, sanitize: sanitize, file: f}, nil
+}
+
+func (q *queryLog) Close() error {
+	if q.file == nil {
+		return nil
+	}
+	return q.file.Close()
+}
+
+func (q *queryLog) write(conn uint64, query string, d time.Duration) {
+	if q.sanitize {
+		query = sanitizeQuery(query)
+	}
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > maxLoggedQuery {
+		query = query[:maxLoggedQuery] + "..."
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	fmt.Fprintf(q.w, "%s conn=%d duration=%s %s\n", time.Now().UTC().Format(time.RFC3339Nano), conn, d, query)
+}
+
+func (q *queryLog) note(conn uint64, msg string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	fmt.Fprintf(q.w, "%s conn=%d # %s\n", time.Now().UTC().Format(time.RFC3339Nano), conn, msg)
+}
+
+// sanitizeQuery replaces the string and number literals of query with "?".
+func sanitizeQuery(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"':
+			// skip to the closing quote, honoring escapes and doubled quotes
+			j := i + 1
+			for ; j < len(query); j++ {
+				if query[j] == '\\' {
+					j++
+					continue
+				}
+				if query[j] == c {
+					if j+1 < len(query) && query[j+1] == c {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			b.WriteByte('?')
+			i = j
+		case c == '`':
+			// quoted identifiers are kept
+			j := strings.IndexByte(query[i+1:], '`')
+			if j < 0 {
+				b.WriteString(query[i:])
+				return b.String()
+			}
+			b.WriteString(query[i : i+j+2])
+			i += j + 1
+		case isDigit(c) && (i == 0 || !isIdentChar(query[i-1])):
+			j := i
+			for j < len(query) && (isIdentChar(query[j]) || query[j] == '.') {
+				j++
+			}
+			b.WriteByte('?')
+			i = j - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentChar(c byte) bool {
+	return isDigit(c) || c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,38 @@
+package connect
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestSanitizeQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: "SELECT * FROM users WHERE id = 42",
+			want:  "SELECT * FROM users WHERE id = ?",
+		},
+		{
+			query: `INSERT INTO t (a, b, c) VALUES ('it''s', "say \"hi\"", 1.5)`,
+			want:  "INSERT INTO t (a, b, c) VALUES (?, ?, ?)",
+		},
+		{
+			query: "SELECT `col1`, t2.col2 FROM `table 'x'` t2 LIMIT 10",
+			want:  "SELECT `col1`, t2.col2 FROM `table 'x'` t2 LIMIT ?",
+		},
+		{
+			query: "UPDATE t SET name = 'a\\'b' WHERE id IN (1,2,3)",
+			want:  "UPDATE t SET name = ? WHERE id IN (?,?,?)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			c := qt.New(t)
+			c.Assert(sanitizeQuery(tt.query), qt.Equals, tt.want)
+		})
+	}
+}
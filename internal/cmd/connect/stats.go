@@ -0,0 +1,153 @@
+package connect
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/planetscale/cli/internal/printer"
+	tunnelstate "github.com/planetscale/cli/internal/tunnel"
+)
+
+// tunnelStats counts the bytes and connections forwarded by the frontends of
+// a tunnel.
+type tunnelStats struct {
+	mu     sync.Mutex
+	nextID uint64
+	total  int
+	conns  map[*connStats]struct{}
+
+	// in and out are the bytes of the closed connections
+	in, out int64
+}
+
+// connStats is the traffic of a single connection. in and out are updated
+// atomically by the goroutines forwarding it.
+type connStats struct {
+	id      uint64
+	started time.Time
+	in, out int64
+}
+
+func newTunnelStats() *tunnelStats {
+	return &tunnelStats{conns: make(map[*connStats]struct{})}
+}
+
+// open registers a new connection.
+func (s *tunnelStats) open() *connStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	s.total++
+	c := &connStats{id: s.nextID, started: time.Now()}
+	s.conns[c] = struct{}{}
+	return c
+}
+
+// close removes the connection, its bytes are kept in the totals.
+func (s *tunnelStats) close(c *connStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.conns, c)
+	s.in += atomic.LoadInt64(&c.in)
+	s.out += atomic.LoadInt64(&c.out)
+}
+
+// snapshot returns the current traffic, the open connections from the
+// oldest to the newest.
+func (s *tunnelStats) snapshot() *tunnelstate.Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := &tunnelstate.Stats{
+		BytesIn:           s.in,
+		BytesOut:          s.out,
+		ActiveConnections: len(s.conns),
+		TotalConnections:  s.total,
+		UpdatedAt:         time.Now().UTC(),
+	}
+
+	for c := range s.conns {
+		cs := &tunnelstate.ConnStats{
+			ID:        c.id,
+			StartedAt: c.started.UTC(),
+			BytesIn:   atomic.LoadInt64(&c.in),
+			BytesOut:  atomic.LoadInt64(&c.out),
+		}
+		st.BytesIn += cs.BytesIn
+		st.BytesOut += cs.BytesOut
+		st.Connections = append(st.Connections, cs)
+	}
+	sort.Slice(st.Connections, func(i, j int) bool {
+		return st.Connections[i].ID < st.Connections[j].ID
+	})
+
+	return st
+}
+
+// statusLine summarizes the traffic in a single line, i.e. "2 connections
+// (longest 5m2s), 1.2 MB in, 340.0 kB out".
+func statusLine(st *tunnelstate.Stats, now time.Time) string {
+	conns := fmt.Sprintf("%d connections", st.ActiveConnections)
+	if st.ActiveConnections == 1 {
+		conns = "1 connection"
+	}
+	if len(st.Connections) > 0 {
+		longest := now.Sub(st.Connections[0].StartedAt).Round(time.Second)
+		conns += fmt.Sprintf(" (longest %s)", longest)
+	}
+
+	return fmt.Sprintf("%s, %s in, %s out", conns, printer.Bytes(st.BytesIn), printer.Bytes(st.BytesOut))
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	atomic.AddInt64(c.n, int64(n))
+	return n, err
+}
+
+// reportStats calls report with the traffic every interval until the
+// returned function is called.
+func reportStats(s *tunnelStats, interval time.Duration, report func(*tunnelstate.Stats)) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				report(s.snapshot())
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+// sameTraffic reports whether no bytes or connections were added between
+// the snapshots a and b.
+func sameTraffic(a, b *tunnelstate.Stats) bool {
+	return a.BytesIn == b.BytesIn && a.BytesOut == b.BytesOut &&
+		a.ActiveConnections == b.ActiveConnections && a.TotalConnections == b.TotalConnections
+}
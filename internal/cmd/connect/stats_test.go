@@ -0,0 +1,81 @@
+package connect
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	tunnelstate "github.com/planetscale/cli/internal/tunnel"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestFrontend_Stats(t *testing.T) {
+	c := qt.New(t)
+
+	upstream, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, qt.IsNil)
+	defer upstream.Close()
+
+	go fakeMySQL(upstream)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, qt.IsNil)
+
+	stats := newTunnelStats()
+	front := &frontend{listener: l, stats: stats}
+	front.setUpstream(upstream.Addr().String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go front.serve(ctx) // nolint:errcheck
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	c.Assert(err, qt.IsNil)
+
+	_, _, err = readPacket(conn) // greeting
+	c.Assert(err, qt.IsNil)
+
+	st := stats.snapshot()
+	c.Assert(st.ActiveConnections, qt.Equals, 1)
+	c.Assert(st.Connections, qt.HasLen, 1)
+
+	c.Assert(writePacket(conn, 1, make([]byte, 40)), qt.IsNil)
+	_, _, err = readPacket(conn) // OK
+	c.Assert(err, qt.IsNil)
+	c.Assert(writePacket(conn, 0, []byte{comQuery, 'S'}), qt.IsNil)
+	_, _, err = readPacket(conn) // OK
+	c.Assert(err, qt.IsNil)
+	conn.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for stats.snapshot().ActiveConnections != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	st = stats.snapshot()
+	c.Assert(st.ActiveConnections, qt.Equals, 0)
+	c.Assert(st.TotalConnections, qt.Equals, 1)
+	c.Assert(st.Connections, qt.HasLen, 0)
+	c.Assert(st.BytesIn, qt.Equals, int64(5+11+11))
+	c.Assert(st.BytesOut, qt.Equals, int64(44+6))
+}
+
+func TestStatusLine(t *testing.T) {
+	c := qt.New(t)
+
+	now := time.Now()
+	st := &tunnelstate.Stats{
+		BytesIn:           1500000,
+		BytesOut:          340000,
+		ActiveConnections: 2,
+		Connections: []*tunnelstate.ConnStats{
+			{ID: 1, StartedAt: now.Add(-5*time.Minute - 2*time.Second)},
+			{ID: 2, StartedAt: now.Add(-time.Second)},
+		},
+	}
+	c.Assert(statusLine(st, now), qt.Equals, "2 connections (longest 5m2s), 1.5 MB in, 340.0 kB out")
+
+	c.Assert(statusLine(&tunnelstate.Stats{}, now), qt.Equals, "0 connections, 0 B in, 0 B out")
+}
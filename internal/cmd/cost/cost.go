@@ -0,0 +1,23 @@
+package cost
+
+import (
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/spf13/cobra"
+)
+
+// CostCmd encapsulates the commands for estimating costs.
+func CostCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "cost <command>",
+		Short:             "Estimate the monthly cost of an organization",
+		PersistentPreRunE: cmdutil.CheckAuthentication(ch.Config),
+	}
+
+	cmd.PersistentFlags().StringVar(&ch.Config.Organization, "org", ch.Config.Organization,
+		"The organization for the current user")
+	cmd.MarkPersistentFlagRequired("org") // nolint:errcheck
+
+	cmd.AddCommand(EstimateCmd(ch))
+
+	return cmd
+}
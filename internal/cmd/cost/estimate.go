@@ -0,0 +1,130 @@
+package cost
+
+import (
+	"fmt"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/cost"
+	"github.com/planetscale/cli/internal/printer"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+type estimate struct {
+	Organization string       `json:"organization"`
+	Plan         string       `json:"plan,omitempty"`
+	Items        []*cost.Item `json:"items"`
+	Total        float64      `json:"total"`
+	Budget       float64      `json:"budget,omitempty"`
+	OverBudget   bool         `json:"over_budget"`
+}
+
+// EstimateCmd projects the monthly cost of the branches, storage and row
+// operations of an organization.
+func EstimateCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		plan        string
+		budget      float64
+		rowsRead    float64
+		rowsWritten float64
+	}
+
+	cmd := &cobra.Command{
+		Use:   "estimate [database...]",
+		Short: "Project the monthly cost of databases based on the plan pricing",
+		Long: `Project the monthly cost of databases based on the plan pricing.
+
+Branches are counted from the given databases, or all databases of the
+organization, and storage is approximated by the latest backup of each
+production branch. Row operations can't be queried and are passed via
+--rows-read and --rows-written.
+
+The plan, a custom pricing and a monthly budget can be configured per
+organization in the config file:
+
+  orgs:
+    my-org:
+      plan: scaler
+      budget: 100`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			plan := ch.Config.Plan
+			if cmd.Flags().Changed("plan") {
+				plan = flags.plan
+			}
+
+			pricing, err := ch.Config.PlanPricing(plan)
+			if err != nil {
+				return err
+			}
+
+			budget := ch.Config.Budget
+			if cmd.Flags().Changed("budget") {
+				budget = flags.budget
+			}
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			end := ch.Printer.PrintProgress("Estimating monthly costs...")
+			defer end()
+
+			usage, err := cost.Collect(ctx, client, ch.Config.Organization, args...)
+			if err != nil {
+				switch cmdutil.ErrCode(err) {
+				case ps.ErrNotFound:
+					return fmt.Errorf("organization %s or one of the databases does not exist", printer.BoldBlue(ch.Config.Organization))
+				default:
+					return cmdutil.HandleError(err)
+				}
+			}
+			usage.RowsRead = flags.rowsRead
+			usage.RowsWritten = flags.rowsWritten
+
+			e := cost.Calculate(pricing, usage)
+			end()
+
+			res := &estimate{
+				Organization: ch.Config.Organization,
+				Plan:         plan,
+				Items:        e.Items,
+				Total:        e.Total,
+				Budget:       budget,
+				OverBudget:   budget > 0 && e.Total > budget,
+			}
+
+			switch ch.Printer.Format() {
+			case printer.Human:
+				if err := ch.Printer.PrintResource(e.Items); err != nil {
+					return err
+				}
+
+				ch.Printer.Printf("Estimated monthly cost of %s: %s\n",
+					printer.BoldBlue(ch.Config.Organization), printer.Bold(fmt.Sprintf("$%.2f", e.Total)))
+				if res.OverBudget {
+					ch.Printer.Printf("%s the estimate exceeds the monthly budget of $%.2f.\n", printer.BoldRed("Warning:"), budget)
+				}
+				return nil
+			case printer.CSV:
+				return ch.Printer.PrintResource(e.Items)
+			default:
+				return ch.Printer.PrintResource(res)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.plan, "plan", "", "The plan to estimate the cost for, overrides the configured plan")
+	cmd.Flags().Float64Var(&flags.budget, "budget", 0, "The monthly budget to check the estimate against, overrides the configured budget")
+	cmd.Flags().Float64Var(&flags.rowsRead, "rows-read", 0, "The projected rows read per month, in billions")
+	cmd.Flags().Float64Var(&flags.rowsWritten, "rows-written", 0, "The projected rows written per month, in millions")
+	cmd.RegisterFlagCompletionFunc("plan", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) { // nolint:errcheck
+		return config.PlanNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return cmd
+}
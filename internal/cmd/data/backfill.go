@@ -0,0 +1,323 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+
+	"github.com/spf13/cobra"
+)
+
+// throttleInterval is how often the replication lag is checked again while
+// the backfill is throttled. It's replaced in tests.
+var throttleInterval = time.Second
+
+// BackfillCmd runs a statement over a range of keys in batches.
+func BackfillCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		sql     string
+		table   string
+		key     string
+		start   int64
+		end     int64
+		batch   int64
+		sleep   time.Duration
+		maxLag  time.Duration
+		restart bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "backfill <database> <branch>",
+		Short: "Run a statement over a range of keys in batches",
+		Long: `Run a statement over a range of keys in batches.
+
+The --sql statement has two placeholders, the first and the last key of a
+batch, and is run through a tunnel for every batch of --batch keys from --start
+to --end, which default to the smallest and the largest value of the --key
+column of --table. Between batches, the backfill sleeps for --sleep and waits
+while the replication lag reported by Vitess exceeds --max-lag.
+
+The progress is checkpointed after every batch, so an interrupted backfill
+continues with the next batch when the same statement is run again on the
+branch, unless --restart is set.`,
+		Args: cmdutil.RequiredArgs("database", "branch"),
+		Example: `  pscale data backfill mydb main --table users \
+    --sql "UPDATE users SET email_lower = LOWER(email) WHERE id BETWEEN ? AND ?" \
+    --batch 5000 --sleep 100ms`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+
+			if strings.Count(flags.sql, "?") != 2 {
+				return errors.New("--sql must have two placeholders, the first and the last key of a batch")
+			}
+			if flags.batch < 1 {
+				return errors.New("--batch must be at least 1")
+			}
+			hasRange := cmd.Flags().Changed("start") && cmd.Flags().Changed("end")
+			if !hasRange && flags.table == "" {
+				return errors.New("--table is required, unless both --start and --end are set")
+			}
+
+			id := config.BackfillID(ch.Config.Organization, database, branch, flags.sql)
+			run, err := config.ReadBackfillRun(id)
+			switch {
+			case errors.Is(err, config.ErrNoBackfillRun) || (err == nil && flags.restart):
+				run = nil
+			case err != nil:
+				return err
+			default:
+				ch.Printer.Printf("Continuing the backfill started at %s from key %d.\n",
+					run.StartedAt.Format("2006-01-02 15:04"), run.Next)
+			}
+
+			db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.ReadWriterRole)
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			if run == nil {
+				run = &config.BackfillRun{
+					ID:           id,
+					Organization: ch.Config.Organization,
+					Database:     database,
+					Branch:       branch,
+					SQL:          flags.sql,
+					StartedAt:    time.Now().UTC(),
+					Start:        flags.start,
+					End:          flags.end,
+				}
+				if !hasRange {
+					empty, err := keyRange(ctx, db, flags.table, flags.key, run)
+					if err != nil {
+						return err
+					}
+					if empty {
+						ch.Printer.Printf("Table %s is empty, there's nothing to backfill.\n", printer.BoldBlue(flags.table))
+						return nil
+					}
+				}
+				run.Next = run.Start
+			}
+
+			b := &backfiller{
+				run:      run,
+				batch:    flags.batch,
+				sleep:    flags.sleep,
+				maxLag:   flags.maxLag,
+				log:      logOutput(ch),
+				progress: ch.Printer.Progress,
+				exec: func(ctx context.Context, first, last int64) (int64, error) {
+					res, err := db.ExecContext(ctx, flags.sql, first, last)
+					if err != nil {
+						return 0, err
+					}
+					return res.RowsAffected()
+				},
+				lag: func(ctx context.Context) (time.Duration, error) {
+					return replicationLag(ctx, db)
+				},
+			}
+			if err := b.execute(ctx); err != nil {
+				return err
+			}
+
+			if ch.Printer.Format() != printer.Human {
+				return ch.Printer.PrintResource(run)
+			}
+			ch.Printer.Printf("Backfilled %d rows of keys %d to %d.\n", run.Rows, run.Start, run.End)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.sql, "sql", "", "The statement run for every batch, with the first and the last key as placeholders")
+	cmd.Flags().StringVar(&flags.table, "table", "", "The table whose keys are backfilled")
+	cmd.Flags().StringVar(&flags.key, "key", "id", "The integer column of --table the batches are ranges of")
+	cmd.Flags().Int64Var(&flags.start, "start", 0, "The first key, instead of the smallest key of --table")
+	cmd.Flags().Int64Var(&flags.end, "end", 0, "The last key, instead of the largest key of --table")
+	cmd.Flags().Int64Var(&flags.batch, "batch", 1000, "The number of keys in a batch")
+	cmd.Flags().DurationVar(&flags.sleep, "sleep", 0, "The time to sleep between batches")
+	cmd.Flags().DurationVar(&flags.maxLag, "max-lag", time.Second, "Wait while the replication lag exceeds this duration, 0 disables it")
+	cmd.Flags().BoolVar(&flags.restart, "restart", false, "Ignore the checkpoint of a previous run of the backfill")
+	cmd.MarkFlagRequired("sql") // nolint:errcheck
+
+	return cmd
+}
+
+// keyRange sets the range of the run to the smallest and the largest key of
+// the table, and returns whether the table is empty.
+func keyRange(ctx context.Context, db *sql.DB, table, key string, run *config.BackfillRun) (bool, error) {
+	var start, end sql.NullInt64
+	err := db.QueryRowContext(ctx, fmt.Sprintf("SELECT MIN(%s), MAX(%s) FROM %s",
+		cmdutil.QuoteIdent(key), cmdutil.QuoteIdent(key), cmdutil.QuoteIdent(table))).Scan(&start, &end)
+	if err != nil {
+		return false, fmt.Errorf("couldn't read the range of keys of table %s: %s", table, err)
+	}
+	if !start.Valid {
+		return true, nil
+	}
+
+	run.Start, run.End = start.Int64, end.Int64
+	return false, nil
+}
+
+// replicationLag returns the largest replication lag of the replicas, as
+// reported by Vitess.
+func replicationLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	rows, err := db.QueryContext(ctx, "SHOW VITESS_REPLICATION_STATUS")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	lagColumn := -1
+	for i, c := range columns {
+		if strings.EqualFold(c, "ReplicationLag") {
+			lagColumn = i
+		}
+	}
+	if lagColumn < 0 {
+		return 0, errors.New("the replication status has no lag")
+	}
+
+	var max time.Duration
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return 0, err
+		}
+
+		seconds, err := strconv.ParseFloat(strings.TrimSuffix(values[lagColumn].String, "s"), 64)
+		if err != nil {
+			continue
+		}
+		if lag := time.Duration(seconds * float64(time.Second)); lag > max {
+			max = lag
+		}
+	}
+	return max, rows.Err()
+}
+
+// backfiller runs the batches of a backfill.
+type backfiller struct {
+	run    *config.BackfillRun
+	batch  int64
+	sleep  time.Duration
+	maxLag time.Duration
+	log    io.Writer
+
+	// progress reports the keys done of the phase, if set.
+	progress func(phase string, done, total int64)
+	// exec runs the statement for the keys from first to last, and
+	// returns the number of rows affected.
+	exec func(ctx context.Context, first, last int64) (int64, error)
+	// lag returns the replication lag.
+	lag func(ctx context.Context) (time.Duration, error)
+}
+
+// execute runs the remaining batches, saving the run after each of them.
+// The checkpoint is removed once the backfill finished.
+func (b *backfiller) execute(ctx context.Context) error {
+	total := b.run.End - b.run.Start + 1
+	for b.run.Next <= b.run.End {
+		if err := b.throttle(ctx); err != nil {
+			return err
+		}
+
+		first, last := b.run.Next, b.run.Next+b.batch-1
+		if last > b.run.End {
+			last = b.run.End
+		}
+
+		rows, err := b.exec(ctx, first, last)
+		if err != nil {
+			return fmt.Errorf("batch of keys %d to %d failed: %s\n\nRun the command again to continue the backfill", first, last, err)
+		}
+
+		b.run.Next = last + 1
+		b.run.Rows += rows
+		if err := b.run.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(b.log, "[%5.1f%%] keys %d to %d: %d rows\n",
+			float64(last-b.run.Start+1)/float64(total)*100, first, last, rows)
+		if b.progress != nil {
+			b.progress("backfill", last-b.run.Start+1, total)
+		}
+
+		if b.sleep > 0 && b.run.Next <= b.run.End {
+			if err := wait(ctx, b.sleep); err != nil {
+				return err
+			}
+		}
+	}
+
+	return b.run.Remove()
+}
+
+// throttle waits while the replication lag exceeds the maximum. If the lag
+// can't be read, the backfill isn't throttled anymore.
+func (b *backfiller) throttle(ctx context.Context) error {
+	if b.maxLag <= 0 || b.lag == nil {
+		return nil
+	}
+
+	for {
+		lag, err := b.lag(ctx)
+		if err != nil {
+			fmt.Fprintf(b.log, "can't read the replication lag, the backfill isn't throttled: %s\n", err)
+			b.lag = nil
+			return nil
+		}
+		if lag <= b.maxLag {
+			return nil
+		}
+
+		fmt.Fprintf(b.log, "replication lag of %s exceeds %s, waiting\n", lag, b.maxLag)
+		if err := wait(ctx, throttleInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// logOutput returns the output the steps of long-running commands are
+// logged to, which is stderr unless it's reserved for progress events.
+func logOutput(ch *cmdutil.Helper) io.Writer {
+	if ch.Printer.ProgressJSON() {
+		return ioutil.Discard
+	}
+	return os.Stderr
+}
+
+func wait(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,75 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestBackfiller_Execute(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+	c.Patch(&throttleInterval, time.Millisecond)
+
+	sql := "UPDATE users SET x = 1 WHERE id BETWEEN ? AND ?"
+	run := &config.BackfillRun{
+		ID:    config.BackfillID("org", "mydb", "main", sql),
+		SQL:   sql,
+		Start: 1,
+		End:   25,
+		Next:  1,
+	}
+
+	type batch struct{ First, Last int64 }
+	var batches []batch
+	exec := func(ctx context.Context, first, last int64) (int64, error) {
+		if first == 11 && len(batches) == 1 {
+			batches = append(batches, batch{first, last})
+			return 0, errors.New("lock wait timeout exceeded")
+		}
+		batches = append(batches, batch{first, last})
+		return last - first + 1, nil
+	}
+
+	// the replica lags behind once, then catches up
+	lags := []time.Duration{0, 3 * time.Second, 0}
+	lag := func(ctx context.Context) (time.Duration, error) {
+		if len(lags) == 0 {
+			return 0, errors.New("unknown statement")
+		}
+		l := lags[0]
+		lags = lags[1:]
+		return l, nil
+	}
+
+	var log bytes.Buffer
+	b := &backfiller{run: run, batch: 10, maxLag: time.Second, log: &log, exec: exec, lag: lag}
+	err := b.execute(context.Background())
+	c.Assert(err, qt.ErrorMatches, `(?s)batch of keys 11 to 20 failed: lock wait timeout exceeded.*`)
+
+	saved, err := config.ReadBackfillRun(run.ID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(saved.Next, qt.Equals, int64(11))
+	c.Assert(saved.Rows, qt.Equals, int64(10))
+
+	// the backfill continues from the checkpoint and removes it once done
+	b = &backfiller{run: saved, batch: 10, maxLag: time.Second, log: &log, exec: exec, lag: lag}
+	c.Assert(b.execute(context.Background()), qt.IsNil)
+	c.Assert(batches, qt.DeepEquals, []batch{{1, 10}, {11, 20}, {11, 20}, {21, 25}})
+	c.Assert(saved.Rows, qt.Equals, int64(25))
+
+	c.Assert(log.String(), qt.Contains, "[ 40.0%] keys 1 to 10: 10 rows\n")
+	c.Assert(log.String(), qt.Contains, "replication lag of 3s exceeds 1s, waiting\n")
+	c.Assert(log.String(), qt.Contains, "can't read the replication lag, the backfill isn't throttled: unknown statement\n")
+	c.Assert(log.String(), qt.Contains, "[100.0%] keys 21 to 25: 5 rows\n")
+
+	_, err = config.ReadBackfillRun(run.ID)
+	c.Assert(err, qt.Equals, config.ErrNoBackfillRun)
+}
@@ -0,0 +1,27 @@
+package data
+
+import (
+	"github.com/planetscale/cli/internal/cmdutil"
+
+	"github.com/spf13/cobra"
+)
+
+// DataCmd encapsulates the commands for managing the data of a branch.
+func DataCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "data <command>",
+		Short:             "Manage the data held by a branch",
+		PersistentPreRunE: cmdutil.CheckAuthentication(ch.Config),
+	}
+
+	cmd.PersistentFlags().StringVar(&ch.Config.Organization, "org", ch.Config.Organization,
+		"The organization for the current user")
+	cmd.MarkPersistentFlagRequired("org") // nolint:errcheck
+
+	cmd.AddCommand(BackfillCmd(ch))
+	cmd.AddCommand(EncryptColumnCmd(ch))
+	cmd.AddCommand(ScanPIICmd(ch))
+	cmd.AddCommand(TenantCmd(ch))
+
+	return cmd
+}
@@ -0,0 +1,488 @@
+package data
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// columnKeyEnv holds the key of the encryption if --key-file isn't set.
+	columnKeyEnv = "PSCALE_COLUMN_KEY"
+
+	// encryptedPrefix is the prefix of the encrypted values, which
+	// identifies the format of the value.
+	encryptedPrefix = "v1:"
+)
+
+// encryptionStep is a step of the encryption of a column.
+type encryptionStep struct {
+	name        string
+	description string
+	run         func(*encrypter, context.Context) error
+}
+
+// encryptionSteps are the steps of the encryption of a column.
+var encryptionSteps = []encryptionStep{
+	{"add-column", "Add the encrypted column to the table", (*encrypter).addColumn},
+	{"backfill", "Encrypt the values of the existing rows in batches", (*encrypter).backfill},
+	{"verify", "Verify every encrypted value matches its plaintext", (*encrypter).verify},
+	{"swap", "Swap the columns, so the column holds the encrypted values", (*encrypter).swap},
+}
+
+// EncryptColumnCmd encrypts the values of a column with application-level
+// encryption.
+func EncryptColumnCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		keyFile   string
+		encrypted string
+		batchSize int
+		force     bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "encrypt-column <database> <branch> <table> <column>",
+		Short: "Encrypt the values of a column with application-level encryption",
+		Long: fmt.Sprintf(`Encrypt the values of a column with application-level encryption.
+
+The column is encrypted in four steps, each confirmed before it runs unless
+--force is set:
+
+  1. add-column: the encrypted column, <column>_encrypted by default, is added.
+  2. backfill: the values of the existing rows are encrypted in batches of
+     --batch-size rows, through a tunnel.
+  3. verify: every encrypted value is decrypted and compared with its
+     plaintext. Rows that don't match are backfilled again on the next run.
+  4. swap: the plaintext column is renamed to <column>_plaintext and the
+     encrypted column to <column>. Drop the plaintext column once the
+     application reads the encrypted values.
+
+Before the backfill, deploy the application writing the encrypted column too.
+The values are encrypted with AES-256-GCM and stored as %q followed by the
+base64 of the nonce and the ciphertext. The key is the base64 of 32 bytes,
+read from --key-file or the %s environment variable.
+
+The state of the run is saved after every step and batch, so an interrupted
+run continues where it stopped when the command is run again. The table must
+have a primary key of a single column, and the branch must allow schema
+changes.`, encryptedPrefix, columnKeyEnv),
+		Args:    cmdutil.RequiredArgs("database", "branch", "table", "column"),
+		Example: `  pscale data encrypt-column mydb dev users ssn --key-file column.key`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch, table, column := args[0], args[1], args[2], args[3]
+
+			aead, err := readColumnKey(flags.keyFile)
+			if err != nil {
+				return err
+			}
+
+			if flags.batchSize < 1 {
+				return errors.New("--batch-size must be at least 1")
+			}
+			if flags.encrypted == "" {
+				flags.encrypted = column + "_encrypted"
+			}
+
+			force := flags.force || ch.Config.AssumeYes
+			if !force && (!printer.IsTTY || ch.Printer.Format() != printer.Human) {
+				return errors.New("cannot confirm the steps of the encryption (run with -force to override)")
+			}
+
+			run, err := config.ReadEncryptionRun(config.NewEncryptionRun(ch.Config.Organization, database, branch, table, column, "").ID)
+			switch {
+			case errors.Is(err, config.ErrNoEncryptionRun):
+				run = config.NewEncryptionRun(ch.Config.Organization, database, branch, table, column, flags.encrypted)
+			case err != nil:
+				return err
+			default:
+				ch.Printer.Printf("Continuing the encryption of %s started at %s.\n",
+					printer.BoldBlue(table+"."+column), run.StartedAt.Format("2006-01-02 15:04"))
+			}
+
+			db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.AdministratorRole)
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			e := &encrypter{
+				db:        db,
+				aead:      aead,
+				run:       run,
+				batchSize: flags.batchSize,
+				log:       logOutput(ch),
+				progress:  ch.Printer.Progress,
+			}
+			if !force {
+				e.confirm = confirmStep
+			}
+
+			if err := e.execute(ctx, encryptionSteps); err != nil {
+				return err
+			}
+
+			ch.Printer.Printf("Column %s holds the encrypted values, drop %s once the application reads them.\n",
+				printer.BoldBlue(table+"."+column), printer.BoldBlue(column+"_plaintext"))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.keyFile, "key-file", "", "The file holding the base64 encoded key, instead of "+columnKeyEnv)
+	cmd.Flags().StringVar(&flags.encrypted, "encrypted-column", "", "The name of the encrypted column, <column>_encrypted by default")
+	cmd.Flags().IntVar(&flags.batchSize, "batch-size", 500, "The number of rows encrypted in a batch")
+	cmd.Flags().BoolVar(&flags.force, "force", false, "Run the steps without confirmation")
+
+	return cmd
+}
+
+// readColumnKey returns the cipher of the key in the file, or in the
+// environment if path is empty.
+func readColumnKey(path string) (cipher.AEAD, error) {
+	encoded := os.Getenv(columnKeyEnv)
+	if path != "" {
+		out, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		encoded = string(out)
+	}
+	if encoded == "" {
+		return nil, fmt.Errorf("no key is set, use --key-file or %s", columnKeyEnv)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("the key isn't base64 encoded: %s", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("the key must be 32 bytes, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptValue encrypts the value with a random nonce.
+func encryptValue(aead cipher.AEAD, plaintext string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptValue decrypts a value encrypted by encryptValue.
+func decryptValue(aead cipher.AEAD, value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return "", errors.New("unknown format of encrypted value")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// confirmStep asks to confirm the step before it runs.
+func confirmStep(s encryptionStep) error {
+	confirmed := false
+	prompt := &survey.Confirm{
+		Message: fmt.Sprintf("%s: %s?", printer.BoldBlue(s.name), s.description),
+	}
+	if err := survey.AskOne(prompt, &confirmed); err != nil {
+		return err
+	}
+
+	if !confirmed {
+		return fmt.Errorf("step %s was skipped, run the command again to continue", s.name)
+	}
+	return nil
+}
+
+// encrypter runs the steps of the encryption of a column.
+type encrypter struct {
+	db        *sql.DB
+	aead      cipher.AEAD
+	run       *config.EncryptionRun
+	batchSize int
+	log       io.Writer
+
+	// progress reports the steps done, if set.
+	progress func(phase string, done, total int64)
+	// confirm confirms the steps before they run, if set.
+	confirm func(encryptionStep) error
+
+	// primaryKey is the primary key of the table.
+	primaryKey string
+}
+
+// execute runs the steps that aren't done yet, saving the run after each of
+// them. The state of the run is removed once all steps are done.
+func (e *encrypter) execute(ctx context.Context, steps []encryptionStep) error {
+	for i, s := range steps {
+		if e.run.IsDone(s.name) {
+			fmt.Fprintf(e.log, "%d/%d %s: already done\n", i+1, len(steps), s.name)
+			continue
+		}
+
+		if e.confirm != nil {
+			if err := e.confirm(s); err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintf(e.log, "%d/%d %s: %s\n", i+1, len(steps), s.name, s.description)
+		if err := s.run(e, ctx); err != nil {
+			return fmt.Errorf("encryption step %s failed: %s", s.name, err)
+		}
+
+		e.run.Done = append(e.run.Done, s.name)
+		if err := e.run.Save(); err != nil {
+			return err
+		}
+		if e.progress != nil {
+			e.progress("encrypt-column", int64(i+1), int64(len(steps)))
+		}
+	}
+
+	return e.run.Remove()
+}
+
+func (e *encrypter) table() string {
+	return cmdutil.QuoteIdent(e.run.Table)
+}
+
+// columnExists returns whether the table has the column.
+func (e *encrypter) columnExists(ctx context.Context, column string) (bool, error) {
+	var n int
+	err := e.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM information_schema.columns
+WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?`, e.run.Table, column).Scan(&n)
+	return n > 0, err
+}
+
+// readPrimaryKey reads the primary key of the table, which must be a single
+// column.
+func (e *encrypter) readPrimaryKey(ctx context.Context) (string, error) {
+	if e.primaryKey != "" {
+		return e.primaryKey, nil
+	}
+
+	rows, err := e.db.QueryContext(ctx, `SELECT column_name FROM information_schema.key_column_usage
+WHERE table_schema = DATABASE() AND table_name = ? AND constraint_name = 'PRIMARY'`, e.run.Table)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			return "", err
+		}
+		columns = append(columns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	if len(columns) != 1 {
+		return "", fmt.Errorf("table %s must have a primary key of a single column", e.run.Table)
+	}
+	e.primaryKey = columns[0]
+	return e.primaryKey, nil
+}
+
+func (e *encrypter) addColumn(ctx context.Context) error {
+	exists, err := e.columnExists(ctx, e.run.EncryptedColumn)
+	if err != nil {
+		return err
+	}
+	if exists {
+		fmt.Fprintf(e.log, "column %s already exists\n", e.run.EncryptedColumn)
+		return nil
+	}
+
+	_, err = e.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s TEXT NULL",
+		e.table(), cmdutil.QuoteIdent(e.run.EncryptedColumn)))
+	return err
+}
+
+// batch returns the primary keys, the plaintext and the encrypted values of
+// the next rows after the cursor.
+func (e *encrypter) batch(ctx context.Context, cursor string) ([]string, []sql.NullString, []sql.NullString, error) {
+	pk, err := e.readPrimaryKey(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	query := fmt.Sprintf("SELECT %s, %s, %s FROM %s", cmdutil.QuoteIdent(pk), cmdutil.QuoteIdent(e.run.Column),
+		cmdutil.QuoteIdent(e.run.EncryptedColumn), e.table())
+	var args []interface{}
+	if cursor != "" {
+		query += fmt.Sprintf(" WHERE %s > ?", cmdutil.QuoteIdent(pk))
+		args = append(args, cursor)
+	}
+	query += fmt.Sprintf(" ORDER BY %s LIMIT %d", cmdutil.QuoteIdent(pk), e.batchSize)
+
+	rows, err := e.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	var plain, encrypted []sql.NullString
+	for rows.Next() {
+		var k string
+		var p, enc sql.NullString
+		if err := rows.Scan(&k, &p, &enc); err != nil {
+			return nil, nil, nil, err
+		}
+		keys = append(keys, k)
+		plain = append(plain, p)
+		encrypted = append(encrypted, enc)
+	}
+	return keys, plain, encrypted, rows.Err()
+}
+
+func (e *encrypter) backfill(ctx context.Context) error {
+	pk, err := e.readPrimaryKey(ctx)
+	if err != nil {
+		return err
+	}
+	update := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", e.table(), cmdutil.QuoteIdent(e.run.EncryptedColumn), cmdutil.QuoteIdent(pk))
+
+	for {
+		keys, plain, _, err := e.batch(ctx, e.run.Cursor)
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+
+		tx, err := e.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		for i, k := range keys {
+			var value interface{}
+			if plain[i].Valid {
+				if value, err = encryptValue(e.aead, plain[i].String); err != nil {
+					tx.Rollback() // nolint:errcheck
+					return err
+				}
+			}
+			if _, err := tx.ExecContext(ctx, update, value, k); err != nil {
+				tx.Rollback() // nolint:errcheck
+				return err
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+
+		e.run.Cursor = keys[len(keys)-1]
+		e.run.Backfilled += int64(len(keys))
+		if err := e.run.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(e.log, "backfilled %d rows\n", e.run.Backfilled)
+	}
+}
+
+func (e *encrypter) verify(ctx context.Context) error {
+	var checked, mismatched int64
+	cursor := ""
+	for {
+		keys, plain, encrypted, err := e.batch(ctx, cursor)
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			break
+		}
+
+		for i := range keys {
+			checked++
+			if !matches(e.aead, plain[i], encrypted[i]) {
+				mismatched++
+			}
+		}
+		cursor = keys[len(keys)-1]
+	}
+	fmt.Fprintf(e.log, "verified %d rows\n", checked)
+
+	if mismatched > 0 {
+		// the rows are backfilled again on the next run
+		e.run.Cursor, e.run.Backfilled = "", 0
+		e.run.Done = removeStep(e.run.Done, "backfill")
+		if err := e.run.Save(); err != nil {
+			return err
+		}
+		return fmt.Errorf("%d of %d rows don't match their plaintext, make sure the application writes the encrypted column and run the command again to backfill them", mismatched, checked)
+	}
+	return nil
+}
+
+// matches returns whether the encrypted value decrypts to the plaintext.
+func matches(aead cipher.AEAD, plain, encrypted sql.NullString) bool {
+	if !plain.Valid || !encrypted.Valid {
+		return plain.Valid == encrypted.Valid
+	}
+
+	decrypted, err := decryptValue(aead, encrypted.String)
+	return err == nil && decrypted == plain.String
+}
+
+func removeStep(done []string, step string) []string {
+	steps := make([]string, 0, len(done))
+	for _, s := range done {
+		if s != step {
+			steps = append(steps, s)
+		}
+	}
+	return steps
+}
+
+func (e *encrypter) swap(ctx context.Context) error {
+	_, err := e.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s, RENAME COLUMN %s TO %s",
+		e.table(),
+		cmdutil.QuoteIdent(e.run.Column), cmdutil.QuoteIdent(e.run.Column+"_plaintext"),
+		cmdutil.QuoteIdent(e.run.EncryptedColumn), cmdutil.QuoteIdent(e.run.Column)))
+	return err
+}
@@ -0,0 +1,95 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestColumnEncryption(t *testing.T) {
+	c := qt.New(t)
+
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32))
+	path := filepath.Join(c.TempDir(), "column.key")
+	c.Assert(ioutil.WriteFile(path, []byte(key+"\n"), 0o600), qt.IsNil)
+
+	aead, err := readColumnKey(path)
+	c.Assert(err, qt.IsNil)
+
+	encrypted, err := encryptValue(aead, "078-05-1120")
+	c.Assert(err, qt.IsNil)
+	c.Assert(encrypted, qt.Matches, `v1:[A-Za-z0-9+/=]+`)
+
+	decrypted, err := decryptValue(aead, encrypted)
+	c.Assert(err, qt.IsNil)
+	c.Assert(decrypted, qt.Equals, "078-05-1120")
+
+	c.Assert(matches(aead, sql.NullString{String: "078-05-1120", Valid: true}, sql.NullString{String: encrypted, Valid: true}), qt.IsTrue)
+	c.Assert(matches(aead, sql.NullString{String: "other", Valid: true}, sql.NullString{String: encrypted, Valid: true}), qt.IsFalse)
+	c.Assert(matches(aead, sql.NullString{String: "078-05-1120", Valid: true}, sql.NullString{}), qt.IsFalse)
+	c.Assert(matches(aead, sql.NullString{}, sql.NullString{}), qt.IsTrue)
+
+	_, err = decryptValue(aead, "078-05-1120")
+	c.Assert(err, qt.ErrorMatches, "unknown format of encrypted value")
+
+	c.Setenv(columnKeyEnv, "")
+	_, err = readColumnKey("")
+	c.Assert(err, qt.ErrorMatches, "no key is set, use --key-file or PSCALE_COLUMN_KEY")
+
+	c.Setenv(columnKeyEnv, base64.StdEncoding.EncodeToString([]byte("short")))
+	_, err = readColumnKey("")
+	c.Assert(err, qt.ErrorMatches, "the key must be 32 bytes, got 5")
+}
+
+func TestEncrypter_Execute(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	var ran []string
+	fail := true
+	steps := []encryptionStep{
+		{name: "first", run: func(e *encrypter, ctx context.Context) error {
+			ran = append(ran, "first")
+			return nil
+		}},
+		{name: "second", run: func(e *encrypter, ctx context.Context) error {
+			ran = append(ran, "second")
+			if fail {
+				return errors.New("connection lost")
+			}
+			return nil
+		}},
+	}
+
+	run := config.NewEncryptionRun("org", "mydb", "dev", "users", "ssn", "ssn_encrypted")
+	var log bytes.Buffer
+	e := &encrypter{run: run, log: &log}
+
+	err := e.execute(context.Background(), steps)
+	c.Assert(err, qt.ErrorMatches, "encryption step second failed: connection lost")
+
+	saved, err := config.ReadEncryptionRun(run.ID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(saved.Done, qt.DeepEquals, []string{"first"})
+
+	// the run continues where it stopped and is removed once done
+	fail = false
+	ran = nil
+	e = &encrypter{run: saved, log: &log}
+	c.Assert(e.execute(context.Background(), steps), qt.IsNil)
+	c.Assert(ran, qt.DeepEquals, []string{"second"})
+	c.Assert(log.String(), qt.Contains, "1/2 first: already done\n")
+
+	_, err = config.ReadEncryptionRun(run.ID)
+	c.Assert(err, qt.Equals, config.ErrNoEncryptionRun)
+}
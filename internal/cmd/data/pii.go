@@ -0,0 +1,359 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// piiPatterns are the kinds of PII recognized in the values of columns, in
+// the order they're tried.
+var piiPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+	check   func(string) bool
+}{
+	{kind: "email", pattern: regexp.MustCompile(`^[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}$`)},
+	{kind: "credit-card", pattern: regexp.MustCompile(`^(?:\d[ -]?){12,18}\d$`), check: luhn},
+	{kind: "national-id", pattern: regexp.MustCompile(`^(?:\d{3}-\d{2}-\d{4}|[A-CEGHJ-PR-TW-Z]{2}\d{6}[A-D])$`)},
+	{kind: "phone", pattern: regexp.MustCompile(`^\+?(?:\(?\d{1,4}\)?[ .-]?){2,5}\d{2,4}$`), check: phoneDigits},
+}
+
+// piiNames are the hints in the names of columns holding PII.
+var piiNames = []struct {
+	kind  string
+	names []string
+}{
+	{kind: "email", names: []string{"email", "e_mail"}},
+	{kind: "phone", names: []string{"phone", "mobile", "msisdn"}},
+	{kind: "national-id", names: []string{"ssn", "social_security", "national_id", "nino", "tax_id", "passport"}},
+	{kind: "credit-card", names: []string{"card_number", "credit_card", "cc_number", "pan"}},
+	{kind: "name", names: []string{"first_name", "last_name", "full_name", "surname"}},
+	{kind: "address", names: []string{"address", "street", "postcode", "zip_code"}},
+	{kind: "birth-date", names: []string{"birth", "dob"}},
+}
+
+// textTypes are the types of the columns whose values are scanned.
+var textTypes = []string{"char", "varchar", "tinytext", "text", "mediumtext", "longtext"}
+
+// ScanPIICmd flags the columns likely holding personally identifiable
+// information.
+func ScanPIICmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		sample   int
+		minMatch float64
+		tables   []string
+		spec     string
+	}
+
+	cmd := &cobra.Command{
+		Use:   "scan-pii <database> <branch>",
+		Short: "Flag the columns likely holding personal data",
+		Long: `Flag the columns likely holding personal data.
+
+The first --sample rows of every table are read and the values of their text
+columns matched against the patterns of emails, phone numbers, credit card
+numbers and national IDs, such as US social security and UK national insurance
+numbers. A column is flagged if at least --min-match percent of its sampled
+values match, or if its name suggests personal data, such as birth_date.
+
+With --spec, the flagged columns are written to a masking spec, to be
+reviewed, listing each column with its kind and the suggested strategy to
+mask it.`,
+		Args: cmdutil.RequiredArgs("database", "branch"),
+		Example: `  pscale data scan-pii mydb main
+  pscale data scan-pii mydb main --sample 1000 --spec masking.yml`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+
+			db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.ReaderRole)
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Sampling the tables of %s", printer.BoldBlue(branch)))
+			findings, err := scanPII(ctx, db, flags.tables, flags.sample, flags.minMatch)
+			end()
+			if err != nil {
+				return err
+			}
+
+			if flags.spec != "" {
+				if err := writeMaskingSpec(flags.spec, findings); err != nil {
+					return err
+				}
+			}
+
+			if len(findings) == 0 && ch.Printer.Format() == printer.Human {
+				ch.Printer.Printf("No columns likely holding personal data were found on branch %s.\n", printer.BoldBlue(branch))
+				return nil
+			}
+			return ch.Printer.PrintResource(findings)
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.sample, "sample", 100, "The number of rows sampled from each table")
+	cmd.Flags().Float64Var(&flags.minMatch, "min-match", 50, "The percentage of the sampled values of a column that must match")
+	cmd.Flags().StringSliceVar(&flags.tables, "tables", nil, "Only scan these tables")
+	cmd.Flags().StringVar(&flags.spec, "spec", "", "Write the flagged columns to this masking spec file")
+
+	return cmd
+}
+
+// piiFinding is a column likely holding PII. Match is the percentage of the
+// sampled values matching the kind, if it wasn't flagged by its name only.
+type piiFinding struct {
+	Table   string  `header:"table" json:"table"`
+	Column  string  `header:"column" json:"column"`
+	Kind    string  `header:"kind" json:"kind"`
+	Match   float64 `header:"match %" json:"match_percent"`
+	Sampled int     `header:"sampled" json:"sampled"`
+	Reason  string  `header:"reason" json:"reason"`
+}
+
+// scanPII samples the text columns of the tables and returns the ones
+// flagged, sorted by table and column.
+func scanPII(ctx context.Context, db *sql.DB, only []string, sample int, minMatch float64) ([]*piiFinding, error) {
+	columns, err := textColumns(ctx, db, only)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]string, 0, len(columns))
+	for t := range columns {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+
+	findings := make([]*piiFinding, 0)
+	for _, table := range tables {
+		values, err := sampleColumns(ctx, db, table, columns[table], sample)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't sample table %s: %s", table, err)
+		}
+		for _, col := range columns[table] {
+			if f := classifyColumn(table, col, values[col], minMatch); f != nil {
+				findings = append(findings, f)
+			}
+		}
+	}
+	return findings, nil
+}
+
+// textColumns returns the text columns of the tables of the database by
+// table.
+func textColumns(ctx context.Context, db *sql.DB, only []string) (map[string][]string, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`SELECT table_name, column_name
+FROM information_schema.columns
+WHERE table_schema = DATABASE() AND data_type IN ('%s')
+ORDER BY table_name, ordinal_position`, strings.Join(textTypes, "', '")))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	include := make(map[string]bool, len(only))
+	for _, t := range only {
+		include[t] = true
+	}
+
+	columns := make(map[string][]string)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, err
+		}
+		if len(include) > 0 && !include[table] {
+			continue
+		}
+		columns[table] = append(columns[table], column)
+	}
+	return columns, rows.Err()
+}
+
+// sampleColumns returns the non-empty values of the columns in the first
+// rows of the table.
+func sampleColumns(ctx context.Context, db *sql.DB, table string, columns []string, limit int) (map[string][]string, error) {
+	quoted := make([]string, 0, len(columns))
+	for _, c := range columns {
+		quoted = append(quoted, cmdutil.QuoteIdent(c))
+	}
+
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s LIMIT %d",
+		strings.Join(quoted, ", "), cmdutil.QuoteIdent(table), limit))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make(map[string][]string, len(columns))
+	for rows.Next() {
+		row := make([]sql.NullString, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		for i, v := range row {
+			if s := strings.TrimSpace(v.String); v.Valid && s != "" {
+				values[columns[i]] = append(values[columns[i]], s)
+			}
+		}
+	}
+	return values, rows.Err()
+}
+
+// classifyColumn flags the column by its values, or else by its name.
+func classifyColumn(table, column string, values []string, minMatch float64) *piiFinding {
+	f := &piiFinding{Table: table, Column: column, Sampled: len(values)}
+
+	if kind, match := classifyValues(values); kind != "" && match >= minMatch {
+		f.Kind, f.Match, f.Reason = kind, match, "values"
+		return f
+	}
+	if kind := nameHint(column); kind != "" {
+		f.Kind, f.Reason = kind, "name"
+		return f
+	}
+	return nil
+}
+
+// classifyValues returns the kind of PII most values match, with the
+// percentage of the values matching it.
+func classifyValues(values []string) (string, float64) {
+	if len(values) == 0 {
+		return "", 0
+	}
+
+	counts := make(map[string]int)
+	for _, v := range values {
+		for _, p := range piiPatterns {
+			if p.pattern.MatchString(v) && (p.check == nil || p.check(v)) {
+				counts[p.kind]++
+				break
+			}
+		}
+	}
+
+	var kind string
+	var best int
+	for _, p := range piiPatterns {
+		if counts[p.kind] > best {
+			kind, best = p.kind, counts[p.kind]
+		}
+	}
+	return kind, math.Round(float64(best)/float64(len(values))*1000) / 10
+}
+
+// nameHint returns the kind of PII the name of the column suggests.
+func nameHint(column string) string {
+	name := strings.ToLower(column)
+	for _, h := range piiNames {
+		for _, n := range h.names {
+			if name == n || (len(n) > 3 && strings.Contains(name, n)) {
+				return h.kind
+			}
+		}
+	}
+	return ""
+}
+
+// luhn checks the checksum of credit card numbers.
+func luhn(s string) bool {
+	var sum int
+	double := false
+	digits := 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
+
+// datePattern matches dates, which look like phone numbers.
+var datePattern = regexp.MustCompile(`^\d{4}[-./]\d{1,2}[-./]\d{1,2}$|^\d{1,2}[-./]\d{1,2}[-./]\d{4}$`)
+
+// phoneDigits checks phone numbers have as many digits as the international
+// numbering plan allows, and aren't dates.
+func phoneDigits(s string) bool {
+	if datePattern.MatchString(s) {
+		return false
+	}
+
+	digits := 0
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	return digits >= 7 && digits <= 15
+}
+
+// maskingSpec lists the columns to mask when copying the data of a branch.
+type maskingSpec struct {
+	Columns []*maskedColumn `yaml:"columns"`
+}
+
+// maskedColumn is a column to mask, with the strategy masking it.
+type maskedColumn struct {
+	Table    string `yaml:"table"`
+	Column   string `yaml:"column"`
+	Kind     string `yaml:"kind"`
+	Strategy string `yaml:"strategy"`
+}
+
+// maskingStrategies are the strategies suggested by kind. Emails are hashed
+// so they stay unique, the other kinds are replaced with fake values.
+var maskingStrategies = map[string]string{
+	"email":       "hash",
+	"credit-card": "redact",
+	"national-id": "redact",
+}
+
+// writeMaskingSpec writes the findings to a masking spec.
+func writeMaskingSpec(path string, findings []*piiFinding) error {
+	spec := &maskingSpec{Columns: make([]*maskedColumn, 0, len(findings))}
+	for _, f := range findings {
+		strategy, ok := maskingStrategies[f.Kind]
+		if !ok {
+			strategy = "fake"
+		}
+		spec.Columns = append(spec.Columns, &maskedColumn{Table: f.Table, Column: f.Column, Kind: f.Kind, Strategy: strategy})
+	}
+
+	out, err := yaml.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	out = append([]byte("# Generated by 'pscale data scan-pii', review the columns before using it.\n"), out...)
+	return ioutil.WriteFile(path, out, 0o644)
+}
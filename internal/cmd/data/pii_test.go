@@ -0,0 +1,84 @@
+package data
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestClassifyColumn(t *testing.T) {
+	c := qt.New(t)
+
+	tests := []struct {
+		column string
+		values []string
+		want   *piiFinding
+	}{
+		{
+			column: "contact",
+			values: []string{"jane@example.com", "john.doe+tag@mail.example.org", "n/a"},
+			want:   &piiFinding{Table: "users", Column: "contact", Kind: "email", Match: 66.7, Sampled: 3, Reason: "values"},
+		},
+		{
+			column: "number",
+			values: []string{"+1 (415) 555-0100", "+44 20 7946 0958"},
+			want:   &piiFinding{Table: "users", Column: "number", Kind: "phone", Match: 100, Sampled: 2, Reason: "values"},
+		},
+		{
+			column: "card",
+			values: []string{"4111 1111 1111 1111", "4111 1111 1111 1112"},
+			want:   &piiFinding{Table: "users", Column: "card", Kind: "credit-card", Match: 50, Sampled: 2, Reason: "values"},
+		},
+		{
+			column: "identifier",
+			values: []string{"078-05-1120", "AB123456C"},
+			want:   &piiFinding{Table: "users", Column: "identifier", Kind: "national-id", Match: 100, Sampled: 2, Reason: "values"},
+		},
+		{
+			column: "birth_date",
+			values: []string{"1990-01-01"},
+			want:   &piiFinding{Table: "users", Column: "birth_date", Kind: "birth-date", Sampled: 1, Reason: "name"},
+		},
+		{
+			column: "status",
+			values: []string{"active", "12", "jane@example.com"},
+		},
+		{
+			column: "panel",
+			values: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		c.Run(tt.column, func(c *qt.C) {
+			c.Assert(classifyColumn("users", tt.column, tt.values, 50), qt.DeepEquals, tt.want)
+		})
+	}
+}
+
+func TestWriteMaskingSpec(t *testing.T) {
+	c := qt.New(t)
+
+	path := filepath.Join(c.TempDir(), "masking.yml")
+	err := writeMaskingSpec(path, []*piiFinding{
+		{Table: "users", Column: "email", Kind: "email"},
+		{Table: "users", Column: "phone", Kind: "phone"},
+	})
+	c.Assert(err, qt.IsNil)
+
+	out, err := ioutil.ReadFile(path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, `# Generated by 'pscale data scan-pii', review the columns before using it.
+columns:
+- table: users
+  column: email
+  kind: email
+  strategy: hash
+- table: users
+  column: phone
+  kind: phone
+  strategy: fake
+`)
+}
@@ -0,0 +1,171 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// tenancy is the file mapping the tables of a schema to the column holding
+// the key of their tenant.
+type tenancy struct {
+	Tables []*tenantTable `yaml:"tables"`
+}
+
+// tenantTable is a table with the rows of many tenants.
+type tenantTable struct {
+	Table  string `yaml:"table"`
+	Column string `yaml:"column"`
+}
+
+// readTenancy reads and validates the tenancy file.
+func readTenancy(path string) (*tenancy, error) {
+	out, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &tenancy{}
+	if err := yaml.UnmarshalStrict(out, t); err != nil {
+		return nil, fmt.Errorf("can't unmarshal file %q: %s", path, err)
+	}
+	if len(t.Tables) == 0 {
+		return nil, fmt.Errorf("file %q doesn't list any tables", path)
+	}
+
+	seen := make(map[string]bool, len(t.Tables))
+	for i, tt := range t.Tables {
+		if tt.Table == "" || tt.Column == "" {
+			return nil, fmt.Errorf("table %d of %q: table and column are required", i+1, path)
+		}
+		if seen[tt.Table] {
+			return nil, fmt.Errorf("table %d of %q: table %s is listed more than once", i+1, path, tt.Table)
+		}
+		seen[tt.Table] = true
+	}
+	return t, nil
+}
+
+// where returns the condition selecting the rows of the tenant.
+func (t *tenantTable) where() string {
+	return fmt.Sprintf("WHERE %s = ?", cmdutil.QuoteIdent(t.Column))
+}
+
+func (t *tenantTable) countQuery() string {
+	return fmt.Sprintf("SELECT COUNT(*) FROM %s %s", cmdutil.QuoteIdent(t.Table), t.where())
+}
+
+func (t *tenantTable) selectQuery() string {
+	return fmt.Sprintf("SELECT * FROM %s %s", cmdutil.QuoteIdent(t.Table), t.where())
+}
+
+func (t *tenantTable) deleteQuery() string {
+	return fmt.Sprintf("DELETE FROM %s %s", cmdutil.QuoteIdent(t.Table), t.where())
+}
+
+// tenantCount is the number of rows of a tenant in a table.
+type tenantCount struct {
+	Table  string `header:"table" json:"table"`
+	Column string `header:"column" json:"column"`
+	Rows   int64  `header:"rows" json:"rows"`
+}
+
+// queryer is implemented by *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// countTenant returns the number of rows of the tenant in each table.
+func countTenant(ctx context.Context, db queryer, t *tenancy, tenantID string) ([]*tenantCount, error) {
+	counts := make([]*tenantCount, 0, len(t.Tables))
+	for _, tt := range t.Tables {
+		c := &tenantCount{Table: tt.Table, Column: tt.Column}
+		if err := db.QueryRowContext(ctx, tt.countQuery(), tenantID).Scan(&c.Rows); err != nil {
+			return nil, fmt.Errorf("couldn't count the rows of table %s: %s", tt.Table, err)
+		}
+		counts = append(counts, c)
+	}
+	return counts, nil
+}
+
+// exportTenant returns the rows of the tenant by table. The values are
+// returned as strings, as they're read from the server.
+func exportTenant(ctx context.Context, db *sql.DB, t *tenancy, tenantID string) (map[string][]map[string]interface{}, error) {
+	export := make(map[string][]map[string]interface{}, len(t.Tables))
+	for _, tt := range t.Tables {
+		rows, err := exportRows(ctx, db, tt, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't export the rows of table %s: %s", tt.Table, err)
+		}
+		export[tt.Table] = rows
+	}
+	return export, nil
+}
+
+func exportRows(ctx context.Context, db *sql.DB, tt *tenantTable, tenantID string) ([]map[string]interface{}, error) {
+	rows, err := db.QueryContext(ctx, tt.selectQuery(), tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0)
+	for rows.Next() {
+		values := make([]sql.RawBytes, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			if values[i] == nil {
+				row[col] = nil
+			} else {
+				row[col] = string(values[i])
+			}
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// deleteTenant deletes the rows of the tenant in a transaction, from the
+// last table listed to the first, so the rows referencing others are deleted
+// first. It returns the number of rows deleted from each table.
+func deleteTenant(ctx context.Context, db *sql.DB, t *tenancy, tenantID string) ([]*tenantCount, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	deleted := make([]*tenantCount, len(t.Tables))
+	for i := len(t.Tables) - 1; i >= 0; i-- {
+		tt := t.Tables[i]
+		res, err := tx.ExecContext(ctx, tt.deleteQuery(), tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't delete the rows of table %s: %s", tt.Table, err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		deleted[i] = &tenantCount{Table: tt.Table, Column: tt.Column, Rows: n}
+	}
+
+	return deleted, tx.Commit()
+}
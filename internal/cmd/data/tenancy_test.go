@@ -0,0 +1,46 @@
+package data
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestReadTenancy(t *testing.T) {
+	c := qt.New(t)
+
+	write := func(content string) string {
+		path := filepath.Join(c.TempDir(), "tenancy.yml")
+		c.Assert(ioutil.WriteFile(path, []byte(content), 0o600), qt.IsNil)
+		return path
+	}
+
+	tn, err := readTenancy(write(`tables:
+  - table: accounts
+    column: id
+  - table: order` + "`" + `s
+    column: account_id
+`))
+	c.Assert(err, qt.IsNil)
+	c.Assert(tn.Tables, qt.DeepEquals, []*tenantTable{
+		{Table: "accounts", Column: "id"},
+		{Table: "order`s", Column: "account_id"},
+	})
+	c.Assert(tn.Tables[1].countQuery(), qt.Equals, "SELECT COUNT(*) FROM `order``s` WHERE `account_id` = ?")
+	c.Assert(tn.Tables[1].selectQuery(), qt.Equals, "SELECT * FROM `order``s` WHERE `account_id` = ?")
+	c.Assert(tn.Tables[1].deleteQuery(), qt.Equals, "DELETE FROM `order``s` WHERE `account_id` = ?")
+
+	_, err = readTenancy(write("tables: []\n"))
+	c.Assert(err, qt.ErrorMatches, `file ".*" doesn't list any tables`)
+
+	_, err = readTenancy(write("tables:\n  - table: accounts\n"))
+	c.Assert(err, qt.ErrorMatches, `table 1 of ".*": table and column are required`)
+
+	_, err = readTenancy(write("tables:\n  - {table: a, column: id}\n  - {table: a, column: id}\n"))
+	c.Assert(err, qt.ErrorMatches, `table 2 of ".*": table a is listed more than once`)
+
+	_, err = readTenancy(write("tabels: []\n"))
+	c.Assert(err, qt.ErrorMatches, `(?s)can.t unmarshal file .*field tabels not found.*`)
+}
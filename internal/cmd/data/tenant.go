@@ -0,0 +1,253 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/spf13/cobra"
+)
+
+// tenantFlags are the flags shared by the tenant commands.
+type tenantFlags struct {
+	tenantID string
+	file     string
+}
+
+// TenantCmd encapsulates the commands for the rows of a single tenant.
+func TenantCmd(ch *cmdutil.Helper) *cobra.Command {
+	flags := &tenantFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "tenant <command>",
+		Short: "Export or delete all the rows of a tenant",
+		Long: `Export or delete all the rows of a tenant.
+
+The tables holding the rows of tenants are listed in a tenancy file, with the
+column holding the key of the tenant:
+
+  tables:
+    - table: accounts
+      column: id
+    - table: orders
+      column: account_id
+
+The rows are deleted from the last table listed to the first, so list the
+tables referenced by others first. Both commands print the number of rows of
+the tenant in each table with --dry-run.`,
+	}
+
+	cmd.PersistentFlags().StringVar(&flags.tenantID, "tenant-id", "", "The key of the tenant")
+	cmd.PersistentFlags().StringVar(&flags.file, "file", "", "The tenancy file mapping the tables to their tenant column")
+	cmd.MarkPersistentFlagRequired("tenant-id") // nolint:errcheck
+	cmd.MarkPersistentFlagRequired("file")      // nolint:errcheck
+
+	cmd.AddCommand(TenantExportCmd(ch, flags))
+	cmd.AddCommand(TenantDeleteCmd(ch, flags))
+
+	return cmd
+}
+
+// tenantExport is the document the rows of a tenant are exported to.
+type tenantExport struct {
+	TenantID string                              `json:"tenant_id"`
+	Tables   map[string][]map[string]interface{} `json:"tables"`
+}
+
+// TenantExportCmd exports all the rows of a tenant as JSON.
+func TenantExportCmd(ch *cmdutil.Helper, tf *tenantFlags) *cobra.Command {
+	var flags struct {
+		output string
+		dryRun bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "export <database> <branch>",
+		Short: "Export all the rows of a tenant as JSON",
+		Long: `Export all the rows of a tenant as JSON.
+
+The rows are exported by table to a single JSON document, for the portability
+of the data of the tenant, which is written to --output, or printed.`,
+		Args:    cmdutil.RequiredArgs("database", "branch"),
+		Example: `  pscale data tenant export mydb main --tenant-id 42 --file tenancy.yml --output tenant-42.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+
+			t, err := readTenancy(tf.file)
+			if err != nil {
+				return err
+			}
+
+			db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.ReaderRole)
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			if flags.dryRun {
+				counts, err := countTenant(ctx, db, t, tf.tenantID)
+				if err != nil {
+					return err
+				}
+				return ch.Printer.PrintResource(counts)
+			}
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Exporting the rows of tenant %s", printer.BoldBlue(tf.tenantID)))
+			tables, err := exportTenant(ctx, db, t, tf.tenantID)
+			end()
+			if err != nil {
+				return err
+			}
+
+			out, err := json.MarshalIndent(&tenantExport{TenantID: tf.tenantID, Tables: tables}, "", "  ")
+			if err != nil {
+				return err
+			}
+			out = append(out, '\n')
+
+			if flags.output == "" {
+				_, err := cmd.OutOrStdout().Write(out)
+				return err
+			}
+			if err := ioutil.WriteFile(flags.output, out, 0o600); err != nil {
+				return err
+			}
+
+			rows := 0
+			for _, r := range tables {
+				rows += len(r)
+			}
+			ch.Printer.Printf("Exported %d rows of tenant %s from %d tables to %s.\n",
+				rows, printer.BoldBlue(tf.tenantID), len(tables), printer.BoldBlue(flags.output))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "The file to write the rows to, instead of printing them")
+	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Only print the number of rows of the tenant in each table")
+
+	return cmd
+}
+
+// TenantDeleteCmd deletes all the rows of a tenant.
+func TenantDeleteCmd(ch *cmdutil.Helper, tf *tenantFlags) *cobra.Command {
+	var flags struct {
+		force  bool
+		dryRun bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "delete <database> <branch>",
+		Short: "Delete all the rows of a tenant",
+		Long: `Delete all the rows of a tenant.
+
+The number of rows of the tenant in each table is printed first and the
+deletion is confirmed by typing the key of the tenant, unless --force is set.
+The rows are deleted in a single transaction, so either all or none of them
+are deleted.`,
+		Args:    cmdutil.RequiredArgs("database", "branch"),
+		Example: `  pscale data tenant delete mydb main --tenant-id 42 --file tenancy.yml`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+
+			t, err := readTenancy(tf.file)
+			if err != nil {
+				return err
+			}
+
+			db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.ReadWriterRole)
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			counts, err := countTenant(ctx, db, t, tf.tenantID)
+			if err != nil {
+				return err
+			}
+			if flags.dryRun {
+				return ch.Printer.PrintResource(counts)
+			}
+
+			var total int64
+			for _, c := range counts {
+				total += c.Rows
+			}
+			if total == 0 {
+				ch.Printer.Printf("No rows of tenant %s were found.\n", printer.BoldBlue(tf.tenantID))
+				return nil
+			}
+
+			force := flags.force || ch.Config.AssumeYes
+			if !force {
+				if ch.Printer.Format() != printer.Human {
+					return fmt.Errorf("cannot delete the rows of the tenant with the output format %q (run with -force to override)", ch.Printer.Format())
+				}
+				if !printer.IsTTY {
+					return fmt.Errorf("cannot confirm the deletion of the rows of tenant %q (run with -force to override)", tf.tenantID)
+				}
+
+				if err := ch.Printer.PrintResource(counts); err != nil {
+					return err
+				}
+				if err := confirmTenant(tf.tenantID, total); err != nil {
+					return err
+				}
+			}
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Deleting %d rows of tenant %s", total, printer.BoldBlue(tf.tenantID)))
+			deleted, err := deleteTenant(ctx, db, t, tf.tenantID)
+			end()
+			if err != nil {
+				return err
+			}
+
+			if ch.Printer.Format() != printer.Human {
+				return ch.Printer.PrintResource(deleted)
+			}
+
+			var n int64
+			for _, d := range deleted {
+				n += d.Rows
+			}
+			ch.Printer.Printf("Deleted %d rows of tenant %s from %d tables.\n", n, printer.BoldBlue(tf.tenantID), len(deleted))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.force, "force", false, "Delete the rows without confirmation")
+	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Only print the number of rows of the tenant in each table")
+
+	return cmd
+}
+
+// confirmTenant asks to type the key of the tenant to confirm the deletion.
+func confirmTenant(tenantID string, rows int64) error {
+	prompt := &survey.Input{
+		Message: fmt.Sprintf("%s %s %s", printer.Bold(fmt.Sprintf("%d rows will be deleted. Please type", rows)),
+			printer.BoldBlue(tenantID), printer.Bold("to confirm:")),
+	}
+
+	var userInput string
+	if err := survey.AskOne(prompt, &userInput); err != nil {
+		if err == terminal.InterruptErr {
+			os.Exit(0)
+		}
+		return err
+	}
+
+	if userInput != tenantID {
+		return errors.New("incorrect tenant entered, skipping the deletion")
+	}
+	return nil
+}
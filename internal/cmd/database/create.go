@@ -5,6 +5,7 @@ import (
 	"net/url"
 
 	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/cost"
 	"github.com/planetscale/cli/internal/printer"
 
 	ps "github.com/planetscale/planetscale-go/planetscale"
@@ -29,7 +30,10 @@ func CreateCmd(ch *cmdutil.Helper) *cobra.Command {
 			}
 
 			createReq.Organization = ch.Config.Organization
-			createReq.Name = args[0]
+			createReq.Name, err = cmdutil.ResourceName(ch, "database", args[0], "")
+			if err != nil {
+				return err
+			}
 
 			if web {
 				ch.Printer.Println("🌐  Redirecting you to create a database in your web browser.")
@@ -45,6 +49,9 @@ func CreateCmd(ch *cmdutil.Helper) *cobra.Command {
 				return err
 			}
 
+			cmdutil.WarnBudget(ctx, ch, client, fmt.Sprintf("database %s", printer.BoldBlue(createReq.Name)),
+				&cost.Usage{ProductionBranches: 1})
+
 			end := ch.Printer.PrintProgress("Creating database...")
 			defer end()
 			database, err := client.Databases.Create(ctx, createReq)
@@ -59,7 +66,7 @@ func CreateCmd(ch *cmdutil.Helper) *cobra.Command {
 
 			end()
 
-			if ch.Printer.Format() == printer.Human {
+			if ch.Printer.Format() == printer.Human && !ch.Printer.Quiet() {
 				ch.Printer.Printf("Database %s was successfully created.\n", printer.BoldBlue(database.Name))
 				return nil
 			}
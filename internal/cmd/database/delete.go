@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/planetscale/cli/internal/approval"
 	"github.com/planetscale/cli/internal/cmdutil"
 	"github.com/planetscale/cli/internal/printer"
 
@@ -19,12 +20,20 @@ import (
 // user.
 func DeleteCmd(ch *cmdutil.Helper) *cobra.Command {
 	var force bool
+	var finalDumpDest, finalDumpBranch string
+	var approvalFile string
+	var override bool
+	var cond string
 
 	cmd := &cobra.Command{
-		Use:     "delete <database>",
-		Short:   "Delete a database instance",
-		Args:    cmdutil.RequiredArgs("database"),
-		Aliases: []string{"rm"},
+		Use:               "delete <database>",
+		Short:             "Delete a database instance",
+		Args:              cmdutil.RequiredArgs("database"),
+		ValidArgsFunction: cmdutil.DatabaseCompletion(ch),
+		Aliases:           []string{"rm"},
+		Example: `Take a final dump of the main branch to S3 before deleting the database:
+
+  pscale database delete mydb --final-dump s3://backups/mydb`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			name := args[0]
@@ -34,7 +43,42 @@ func DeleteCmd(ch *cmdutil.Helper) *cobra.Command {
 				return err
 			}
 
-			if !force {
+			if cond != "" {
+				db, err := client.Databases.Get(ctx, &planetscale.GetDatabaseRequest{
+					Organization: ch.Config.Organization,
+					Database:     name,
+				})
+				if err != nil {
+					switch cmdutil.ErrCode(err) {
+					case planetscale.ErrNotFound:
+						return fmt.Errorf("database %s does not exist in organization %s",
+							printer.BoldBlue(name), printer.BoldBlue(ch.Config.Organization))
+					default:
+						return cmdutil.HandleError(err)
+					}
+				}
+
+				if err := cmdutil.CheckCondition(cond, db); err != nil {
+					return err
+				}
+			}
+
+			if err := cmdutil.CheckMaintenance(ch, name, override); err != nil {
+				return err
+			}
+
+			action := &approval.Action{
+				Command:      "database delete",
+				Organization: ch.Config.Organization,
+				Database:     name,
+			}
+			if err := cmdutil.CheckApproval(ch, action, approvalFile); err != nil {
+				return err
+			}
+
+			// databases hold production branches, hence only --yes skips
+			// the confirmation, auto-approving profiles don't
+			if !force && !ch.Config.AutoApproves(true) {
 				if ch.Printer.Format() != printer.Human {
 					return fmt.Errorf("cannot delete database with the output format %q (run with -force to override)", ch.Printer.Format())
 				}
@@ -78,6 +122,13 @@ func DeleteCmd(ch *cmdutil.Helper) *cobra.Command {
 				}
 			}
 
+			if finalDumpDest != "" {
+				if err := finalDump(ch, cmd, client, name, finalDumpBranch, finalDumpDest); err != nil {
+					return fmt.Errorf("%s, database %s was not deleted", err, printer.BoldBlue(name))
+				}
+				ch.Printer.Printf("Final dump of %s was verified and saved to %s.\n", printer.BoldBlue(name), printer.Bold(finalDumpDest))
+			}
+
 			end := ch.Printer.PrintProgress(fmt.Sprintf("Deleting database %s...", printer.BoldBlue(name)))
 			defer end()
 
@@ -102,15 +153,24 @@ func DeleteCmd(ch *cmdutil.Helper) *cobra.Command {
 				return nil
 			}
 
-			return ch.Printer.PrintResource(
-				map[string]string{
-					"result":   "database deleted",
-					"database": name,
-				},
-			)
+			res := map[string]string{
+				"result":   "database deleted",
+				"database": name,
+			}
+			if finalDumpDest != "" {
+				res["final_dump"] = finalDumpDest
+			}
+
+			return ch.Printer.PrintResource(res)
 		},
 	}
 
 	cmd.Flags().BoolVar(&force, "force", false, "Delete a databse without confirmation")
+	cmd.Flags().StringVar(&finalDumpDest, "final-dump", "",
+		"Dump the database to the given directory or S3 location (s3://bucket/prefix) and only delete it once the dump is verified")
+	cmd.Flags().StringVar(&finalDumpBranch, "final-dump-branch", "main", "Branch to take the final dump of")
+	cmdutil.ApprovalFlag(cmd, &approvalFile)
+	cmdutil.MaintenanceFlag(cmd, &override)
+	cmdutil.ConditionFlag(cmd, &cond)
 	return cmd
 }
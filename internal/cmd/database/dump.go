@@ -25,16 +25,19 @@ type dumpFlags struct {
 	localAddr string
 	tables    string
 	output    string
+	threads   int
+	gzip      bool
 }
 
 // DumpCmd encapsulates the commands for dumping a database
 func DumpCmd(ch *cmdutil.Helper) *cobra.Command {
 	f := &dumpFlags{}
 	cmd := &cobra.Command{
-		Use:   "dump <database> <branch> [options]",
-		Short: "Backup and dump your database",
-		Args:  cmdutil.RequiredArgs("database", "branch"),
-		RunE:  func(cmd *cobra.Command, args []string) error { return dump(ch, cmd, f, args) },
+		Use:               "dump <database> <branch> [options]",
+		Short:             "Backup and dump your database",
+		Args:              cmdutil.RequiredArgs("database", "branch"),
+		ValidArgsFunction: cmdutil.BranchCompletion(ch),
+		RunE:              func(cmd *cobra.Command, args []string) error { return dump(ch, cmd, f, args) },
 	}
 
 	cmd.PersistentFlags().StringVar(&f.localAddr, "local-addr",
@@ -43,6 +46,8 @@ func DumpCmd(ch *cmdutil.Helper) *cobra.Command {
 		"Comma separated string of tables to dump. By default all tables are dumped.")
 	cmd.PersistentFlags().StringVar(&f.output, "output", "",
 		"Output directory of the dump. By default the dump is saved to a folder in the current directory.")
+	cmd.PersistentFlags().IntVar(&f.threads, "threads", 16, "Number of tables to dump in parallel.")
+	cmd.PersistentFlags().BoolVar(&f.gzip, "gzip", false, "Write the schema and data files gzipped.")
 
 	return cmd
 }
@@ -54,6 +59,10 @@ func dump(ch *cmdutil.Helper, cmd *cobra.Command, flags *dumpFlags, args []strin
 	database := args[0]
 	branch := args[1]
 
+	if flags.threads < 1 {
+		return errors.New("--threads must be at least 1")
+	}
+
 	client, err := ch.Client()
 	if err != nil {
 		return err
@@ -65,9 +74,15 @@ func dump(ch *cmdutil.Helper, cmd *cobra.Command, flags *dumpFlags, args []strin
 		localAddr = flags.localAddr
 	}
 
+	remoteAddr, err := proxyutil.RemoteAddr(ctx, ch.Config, client, database, branch, "")
+	if err != nil {
+		return cmdutil.HandleError(err)
+	}
+
 	proxyOpts := proxy.Options{
 		CertSource: proxyutil.NewRemoteCertSource(client, cmdutil.ReaderRole),
 		LocalAddr:  localAddr,
+		RemoteAddr: remoteAddr,
 		Instance:   fmt.Sprintf("%s/%s/%s", ch.Config.Organization, database, branch),
 		Logger:     cmdutil.NewZapLogger(ch.Debug()),
 	}
@@ -144,6 +159,8 @@ func dump(ch *cmdutil.Helper, cmd *cobra.Command, flags *dumpFlags, args []strin
 	cfg.ChunksizeInMB = 128
 	cfg.SessionVars = "set workload=olap;"
 	cfg.Outdir = dir
+	cfg.Threads = flags.threads
+	cfg.Compress = flags.gzip
 
 	if flags.tables != "" {
 		cfg.Table = flags.tables
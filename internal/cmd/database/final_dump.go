@@ -0,0 +1,159 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+	exec "golang.org/x/sys/execabs"
+)
+
+const s3Scheme = "s3://"
+
+// finalDump dumps the given branch of the database to dest, which is either
+// a local directory or an S3 location (s3://bucket/prefix), and verifies
+// that every table of the branch was dumped.
+func finalDump(ch *cmdutil.Helper, cmd *cobra.Command, client *ps.Client, database, branch, dest string) error {
+	ctx := cmd.Context()
+
+	schemas, err := client.DatabaseBranches.Schema(ctx, &ps.BranchSchemaRequest{
+		Organization: ch.Config.Organization,
+		Database:     database,
+		Branch:       branch,
+	})
+	if err != nil {
+		switch cmdutil.ErrCode(err) {
+		case ps.ErrNotFound:
+			return fmt.Errorf("branch %s does not exist in database %s (organization: %s)",
+				printer.BoldBlue(branch), printer.BoldBlue(database), printer.BoldBlue(ch.Config.Organization))
+		default:
+			return cmdutil.HandleError(err)
+		}
+	}
+
+	tables := make([]string, 0, len(schemas))
+	for _, s := range schemas {
+		tables = append(tables, s.Name)
+	}
+
+	dir := dest
+	if strings.HasPrefix(dest, s3Scheme) {
+		tmp, err := ioutil.TempDir("", "pscale-final-dump-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+
+		dir = filepath.Join(tmp, fmt.Sprintf("pscale_dump_%s_%s", database, branch))
+	}
+
+	if err := dump(ch, cmd, &dumpFlags{output: dir}, []string{database, branch}); err != nil {
+		return fmt.Errorf("final dump failed: %s", err)
+	}
+
+	files, err := verifyDump(dir, tables)
+	if err != nil {
+		return fmt.Errorf("final dump couldn't be verified: %s", err)
+	}
+
+	if dir == dest {
+		return nil
+	}
+
+	end := ch.Printer.PrintProgress(fmt.Sprintf("Uploading final dump to %s", printer.BoldBlue(dest)))
+	defer end()
+
+	if err := uploadS3(dir, dest, files); err != nil {
+		return fmt.Errorf("final dump couldn't be uploaded: %s", err)
+	}
+
+	return nil
+}
+
+// verifyDump checks that the dump in dir contains a non-empty schema file
+// for each of the tables. It returns the files of the dump.
+func verifyDump(dir string, tables []string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(entries))
+	schemas := make(map[string]bool)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		files = append(files, e.Name())
+
+		if strings.HasSuffix(e.Name(), "-schema.sql") && e.Size() > 0 {
+			name := strings.TrimSuffix(e.Name(), "-schema.sql")
+			schemas[name[strings.Index(name, ".")+1:]] = true
+		}
+	}
+
+	var missing []string
+	for _, t := range tables {
+		if !schemas[t] {
+			missing = append(missing, t)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("schema of tables %s is missing from %s", strings.Join(missing, ", "), dir)
+	}
+
+	return files, nil
+}
+
+// uploadS3 copies the dump to S3 with the AWS CLI and verifies that all
+// files exist in the bucket.
+func uploadS3(dir, dest string, files []string) error {
+	aws, err := exec.LookPath("aws")
+	if err != nil {
+		return fmt.Errorf("the AWS CLI ('aws') is required to upload dumps to S3")
+	}
+
+	dest = strings.TrimSuffix(dest, "/") + "/"
+
+	var stderr bytes.Buffer
+	upload := exec.Command(aws, "s3", "cp", "--recursive", "--only-show-errors", dir, dest)
+	upload.Stderr = &stderr
+	if err := upload.Run(); err != nil {
+		return fmt.Errorf("aws s3 cp: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	stderr.Reset()
+	var stdout bytes.Buffer
+	list := exec.Command(aws, "s3", "ls", dest)
+	list.Stdout = &stdout
+	list.Stderr = &stderr
+	if err := list.Run(); err != nil {
+		return fmt.Errorf("aws s3 ls: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	uploaded := make(map[string]bool)
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			uploaded[fields[len(fields)-1]] = true
+		}
+	}
+
+	for _, f := range files {
+		if !uploaded[f] {
+			return fmt.Errorf("%s is missing in %s", f, dest)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestVerifyDump(t *testing.T) {
+	c := qt.New(t)
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"mydb.users-schema.sql": "CREATE TABLE `users` (`id` bigint);",
+		"mydb.users.00001.sql":  "INSERT INTO `users` VALUES (1);",
+		"mydb.posts-schema.sql": "",
+	} {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		c.Assert(err, qt.IsNil)
+	}
+
+	files, err := verifyDump(dir, []string{"users"})
+	c.Assert(err, qt.IsNil)
+	c.Assert(files, qt.HasLen, 3)
+
+	_, err = verifyDump(dir, []string{"users", "posts", "tags"})
+	c.Assert(err, qt.ErrorMatches, "schema of tables posts, tags is missing from .*")
+}
@@ -1,7 +1,9 @@
 package database
 
 import (
+	"context"
 	"fmt"
+	"sync"
 
 	"github.com/planetscale/cli/internal/cmdutil"
 	"github.com/planetscale/cli/internal/printer"
@@ -14,6 +16,8 @@ import (
 
 // ListCmd is the command for listing all databases for an authenticated user.
 func ListCmd(ch *cmdutil.Helper) *cobra.Command {
+	var pages cmdutil.Pages
+
 	cmd := &cobra.Command{
 		Use:     "list",
 		Short:   "List databases",
@@ -41,8 +45,17 @@ func ListCmd(ch *cmdutil.Helper) *cobra.Command {
 
 			end := ch.Printer.PrintProgress("Fetching databases...")
 			defer end()
-			databases, err := client.Databases.List(ctx, &planetscale.ListDatabasesRequest{
-				Organization: ch.Config.Organization,
+
+			var mu sync.Mutex
+			byPage := make(map[int][]*planetscale.Database)
+			n, err := cmdutil.FetchPages(ctx, pages, func(ctx context.Context, page int) (int, error) {
+				databases, err := client.Databases.List(ctx, &planetscale.ListDatabasesRequest{
+					Organization: ch.Config.Organization,
+				})
+				mu.Lock()
+				byPage[page] = databases
+				mu.Unlock()
+				return len(databases), err
 			})
 			if err != nil {
 				switch cmdutil.ErrCode(err) {
@@ -55,6 +68,14 @@ func ListCmd(ch *cmdutil.Helper) *cobra.Command {
 
 			end()
 
+			var databases []*planetscale.Database
+			for page := 1; page <= n; page++ {
+				databases = append(databases, byPage[page]...)
+			}
+			if pages.Limit > 0 && len(databases) > pages.Limit {
+				databases = databases[:pages.Limit]
+			}
+
 			if len(databases) == 0 && ch.Printer.Format() == printer.Human {
 				ch.Printer.Println("No databases have been created yet.")
 				return nil
@@ -66,6 +87,7 @@ func ListCmd(ch *cmdutil.Helper) *cobra.Command {
 	}
 
 	cmd.Flags().BoolP("web", "w", false, "Open in your web browser")
+	cmdutil.PageFlags(cmd, &pages)
 
 	return cmd
 }
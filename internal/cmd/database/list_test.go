@@ -3,12 +3,14 @@ package database
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/planetscale/cli/internal/cmdutil"
 	"github.com/planetscale/cli/internal/config"
 	"github.com/planetscale/cli/internal/mock"
 	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/transport"
 	ps "github.com/planetscale/planetscale-go/planetscale"
 
 	qt "github.com/frankban/quicktest"
@@ -56,3 +58,73 @@ func TestDatabase_ListCmd(t *testing.T) {
 	c.Assert(svc.ListFnInvoked, qt.IsTrue)
 	c.Assert(buf.String(), qt.JSONEquals, dbs)
 }
+
+func TestDatabase_ListCmdPages(t *testing.T) {
+	var dbs []*ps.Database
+	for i := 0; i < 7; i++ {
+		dbs = append(dbs, &ps.Database{Name: fmt.Sprintf("db%d", i)})
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		// maxSize caps the page size like the API does, which then
+		// returns the pagination metadata.
+		maxSize int
+		want    []*ps.Database
+	}{
+		{"all pages", []string{"--page-size", "2"}, 0, dbs},
+		{"limit", []string{"--page-size", "2", "--limit", "3"}, 0, dbs[:3]},
+		{"single page", []string{"--page-size", "10"}, 0, dbs},
+		{"capped page size", []string{"--page-size", "10"}, 3, dbs},
+		{"capped page size with limit", []string{"--page-size", "10", "--limit", "5"}, 2, dbs[:5]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+
+			var buf bytes.Buffer
+			format := printer.JSON
+			p := printer.NewPrinter(&format)
+			p.SetResourceOutput(&buf)
+
+			svc := &mock.DatabaseService{
+				ListFn: func(ctx context.Context, req *ps.ListDatabasesRequest) ([]*ps.Database, error) {
+					page, ok := transport.PageFromContext(ctx)
+					c.Check(ok, qt.IsTrue)
+
+					size := page.Size
+					if tt.maxSize > 0 && size > tt.maxSize {
+						size = tt.maxSize
+					}
+					start := (page.Number - 1) * size
+					if start >= len(dbs) {
+						return nil, nil
+					}
+					end := start + size
+					if end > len(dbs) {
+						end = len(dbs)
+					}
+					if tt.maxSize > 0 {
+						page.Info.Known, page.Info.Next = true, end < len(dbs)
+					}
+					return dbs[start:end], nil
+				},
+			}
+
+			ch := &cmdutil.Helper{
+				Printer: p,
+				Config:  &config.Config{Organization: "planetscale"},
+				Client: func() (*ps.Client, error) {
+					return &ps.Client{Databases: svc}, nil
+				},
+			}
+
+			cmd := ListCmd(ch)
+			cmd.SetArgs(tt.args)
+			c.Assert(cmd.Execute(), qt.IsNil)
+			c.Assert(buf.String(), qt.JSONEquals, tt.want)
+		})
+	}
+}
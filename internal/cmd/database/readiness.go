@@ -0,0 +1,323 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/expr"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+
+	ps "github.com/planetscale/planetscale-go/planetscale"
+	"github.com/spf13/cobra"
+)
+
+// readinessExitCode is the exit code if any check fails, so CI checks can
+// tell it from failures of the command.
+const readinessExitCode = 2
+
+// The statuses of readiness checks.
+const (
+	readinessPass = "pass"
+	readinessFail = "fail"
+	readinessSkip = "skip"
+)
+
+// ReadinessCmd is the command for checking whether a database is ready for
+// production.
+func ReadinessCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		maxBackupAge   string
+		maxPasswordAge string
+		maxErrorRate   float64
+		skipInsights   bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "readiness <database>",
+		Short: "Check whether a database is ready for production",
+		Long: fmt.Sprintf(`Check whether a database is ready for production.
+
+The checklist covers:
+
+  production branch  a branch is promoted to production, so its schema is
+                     only changed with deploy requests
+  backups            each production branch has a successful backup within
+                     --max-backup-age
+  passwords          no password of a production branch is older than
+                     --max-password-age
+  insights           the error rate of the recent statements of the
+                     production branch is at most --max-error-rate percent
+
+Each check passes or fails, failed checks come with the command fixing them.
+If any check fails, the command exits with status %d, so it can gate a launch
+in CI.`, readinessExitCode),
+		Args: cmdutil.RequiredArgs("database"),
+		Example: `  pscale database readiness mydb
+  pscale database readiness mydb --max-password-age 30d --format json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database := args[0]
+
+			opts := &readinessOptions{maxErrorRate: flags.maxErrorRate}
+			var err error
+			if opts.maxBackupAge, err = expr.ParseDuration(flags.maxBackupAge); err != nil {
+				return fmt.Errorf("invalid --max-backup-age: %s", err)
+			}
+			if opts.maxPasswordAge, err = expr.ParseDuration(flags.maxPasswordAge); err != nil {
+				return fmt.Errorf("invalid --max-password-age: %s", err)
+			}
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Checking the readiness of %s", printer.BoldBlue(database)))
+			checks, production, err := checkReadiness(ctx, client, ch.Config.Organization, database, opts, time.Now())
+			end()
+			if err != nil {
+				switch cmdutil.ErrCode(err) {
+				case ps.ErrNotFound:
+					return fmt.Errorf("database %s does not exist in organization %s",
+						printer.BoldBlue(database), printer.BoldBlue(ch.Config.Organization))
+				default:
+					return cmdutil.HandleError(err)
+				}
+			}
+
+			insights := &readinessCheck{Check: "insights", Status: readinessSkip}
+			switch {
+			case flags.skipInsights:
+				insights.Detail = "skipped with --skip-insights"
+			case production == "":
+				insights.Detail = "no production branch"
+			default:
+				db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, production, cmdutil.ReaderRole)
+				if err != nil {
+					return err
+				}
+				defer closeDB()
+
+				total, failed, err := statementErrors(ctx, db)
+				if err != nil {
+					insights.Detail = err.Error()
+				} else {
+					insights = checkErrorRate(database, production, total, failed, opts.maxErrorRate)
+				}
+			}
+			checks = append(checks, insights)
+
+			if err := ch.Printer.PrintResource(checks); err != nil {
+				return err
+			}
+
+			failed := 0
+			for _, c := range checks {
+				if c.Status == readinessFail {
+					failed++
+				}
+			}
+			if failed == 0 {
+				return nil
+			}
+
+			return &cmdutil.Error{
+				Msg:      fmt.Sprintf("%d readiness check(s) of database %s failed", failed, database),
+				ExitCode: readinessExitCode,
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.maxBackupAge, "max-backup-age", "1d", "The maximum age of the latest backup of production branches, i.e. 12h or 7d")
+	cmd.Flags().StringVar(&flags.maxPasswordAge, "max-password-age", "90d", "The maximum age of the passwords of production branches")
+	cmd.Flags().Float64Var(&flags.maxErrorRate, "max-error-rate", 1, "The maximum percentage of recent statements failing on the production branch")
+	cmd.Flags().BoolVar(&flags.skipInsights, "skip-insights", false, "Don't check the recent statements, which needs a connection to the production branch")
+
+	return cmd
+}
+
+// readinessCheck is the outcome of a check of the readiness checklist, with
+// the command fixing it if it failed.
+type readinessCheck struct {
+	Check  string `header:"check" json:"check"`
+	Status string `header:"status" json:"status"`
+	Detail string `header:"detail,n/a" json:"detail,omitempty"`
+	Fix    string `header:"fix,n/a" json:"fix,omitempty"`
+}
+
+type readinessOptions struct {
+	maxBackupAge   time.Duration
+	maxPasswordAge time.Duration
+	maxErrorRate   float64
+}
+
+// checkReadiness runs the checks of the checklist using the API, and returns
+// them with the production branch the insights are checked on.
+func checkReadiness(ctx context.Context, client *ps.Client, org, database string, opts *readinessOptions, now time.Time) ([]*readinessCheck, string, error) {
+	branches, err := client.DatabaseBranches.List(ctx, &ps.ListDatabaseBranchesRequest{
+		Organization: org,
+		Database:     database,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	var production []*ps.DatabaseBranch
+	for _, b := range branches {
+		if b.Production {
+			production = append(production, b)
+		}
+	}
+	sort.Slice(production, func(i, j int) bool { return production[i].Name < production[j].Name })
+
+	promoted := &readinessCheck{Check: "production branch", Status: readinessPass}
+	if len(production) == 0 {
+		promoted.Status = readinessFail
+		promoted.Detail = "no branch is promoted to production, schema changes aren't safe"
+		promoted.Fix = fmt.Sprintf("pscale branch promote %s %s", database, promotionCandidate(branches))
+
+		skipped := "no production branch"
+		return []*readinessCheck{
+			promoted,
+			{Check: "backups", Status: readinessSkip, Detail: skipped},
+			{Check: "passwords", Status: readinessSkip, Detail: skipped},
+		}, "", nil
+	}
+
+	names := make([]string, 0, len(production))
+	for _, b := range production {
+		names = append(names, b.Name)
+	}
+	promoted.Detail = strings.Join(names, ", ")
+
+	backups := &readinessCheck{Check: "backups", Status: readinessPass}
+	passwords := &readinessCheck{Check: "passwords", Status: readinessPass}
+	var noBackup, oldPasswords []string
+	for _, b := range production {
+		list, err := client.Backups.List(ctx, &ps.ListBackupsRequest{
+			Organization: org,
+			Database:     database,
+			Branch:       b.Name,
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		if latest := latestBackup(list); latest == nil || now.Sub(latest.CompletedAt) > opts.maxBackupAge {
+			noBackup = append(noBackup, b.Name)
+			backups.Fix = joinFix(backups.Fix, fmt.Sprintf("pscale backup create %s %s", database, b.Name))
+		}
+
+		pws, err := client.Passwords.List(ctx, &ps.ListDatabaseBranchPasswordRequest{
+			Organization: org,
+			Database:     database,
+			Branch:       b.Name,
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		for _, pw := range pws {
+			if now.Sub(pw.CreatedAt) > opts.maxPasswordAge {
+				oldPasswords = append(oldPasswords, fmt.Sprintf("%s/%s", b.Name, pw.Name))
+				passwords.Fix = joinFix(passwords.Fix, fmt.Sprintf("pscale password delete %s %s %s", database, b.Name, pw.PublicID))
+			}
+		}
+	}
+
+	if len(noBackup) > 0 {
+		backups.Status = readinessFail
+		backups.Detail = fmt.Sprintf("no successful backup within %s: %s", formatAge(opts.maxBackupAge), strings.Join(noBackup, ", "))
+	}
+	if len(oldPasswords) > 0 {
+		passwords.Status = readinessFail
+		passwords.Detail = fmt.Sprintf("%d password(s) older than %s: %s", len(oldPasswords), formatAge(opts.maxPasswordAge), strings.Join(oldPasswords, ", "))
+	}
+
+	return []*readinessCheck{promoted, backups, passwords}, production[0].Name, nil
+}
+
+// promotionCandidate returns the branch suggested for promotion: main if
+// it exists, else the oldest branch.
+func promotionCandidate(branches []*ps.DatabaseBranch) string {
+	if len(branches) == 0 {
+		return "<branch>"
+	}
+
+	oldest := branches[0]
+	for _, b := range branches {
+		if b.Name == "main" {
+			return b.Name
+		}
+		if b.CreatedAt.Before(oldest.CreatedAt) {
+			oldest = b
+		}
+	}
+	return oldest.Name
+}
+
+// latestBackup returns the latest successful backup.
+func latestBackup(backups []*ps.Backup) *ps.Backup {
+	var latest *ps.Backup
+	for _, b := range backups {
+		if b.State != "success" {
+			continue
+		}
+		if latest == nil || b.CompletedAt.After(latest.CompletedAt) {
+			latest = b
+		}
+	}
+	return latest
+}
+
+// statementErrors returns the number of statements in the statement history
+// of the database, and how many of them failed.
+func statementErrors(ctx context.Context, db *sql.DB) (int64, int64, error) {
+	var total, failed sql.NullInt64
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*), SUM(errors > 0)
+FROM performance_schema.events_statements_history_long
+WHERE current_schema = DATABASE()`).Scan(&total, &failed)
+	if err != nil {
+		return 0, 0, fmt.Errorf("the statement history isn't available: %s", err)
+	}
+	return total.Int64, failed.Int64, nil
+}
+
+// checkErrorRate checks the error rate of the recent statements of the
+// production branch.
+func checkErrorRate(database, branch string, total, failed int64, maxErrorRate float64) *readinessCheck {
+	c := &readinessCheck{Check: "insights", Status: readinessPass}
+	if total == 0 {
+		c.Status = readinessSkip
+		c.Detail = fmt.Sprintf("no recent statements on %s", branch)
+		return c
+	}
+
+	rate := math.Round(float64(failed)/float64(total)*10000) / 100
+	c.Detail = fmt.Sprintf("%g%% of %d recent statements on %s failed", rate, total, branch)
+	if rate > maxErrorRate {
+		c.Status = readinessFail
+		c.Fix = fmt.Sprintf("pscale insights anomalies %s %s", database, branch)
+	}
+	return c
+}
+
+func joinFix(fix, cmd string) string {
+	if fix == "" {
+		return cmd
+	}
+	return fix + "; " + cmd
+}
+
+// formatAge formats whole days as such, i.e. "90d".
+func formatAge(d time.Duration) string {
+	if d >= 24*time.Hour && d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/mock"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestCheckReadiness(t *testing.T) {
+	c := qt.New(t)
+
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	branches := &mock.DatabaseBranchesService{
+		ListFn: func(ctx context.Context, req *ps.ListDatabaseBranchesRequest) ([]*ps.DatabaseBranch, error) {
+			c.Assert(req.Organization, qt.Equals, "planetscale")
+			c.Assert(req.Database, qt.Equals, "mydb")
+			return []*ps.DatabaseBranch{
+				{Name: "main", Production: true},
+				{Name: "dev"},
+			}, nil
+		},
+	}
+	backups := &mock.BackupsService{
+		ListFn: func(ctx context.Context, req *ps.ListBackupsRequest) ([]*ps.Backup, error) {
+			c.Assert(req.Branch, qt.Equals, "main")
+			return []*ps.Backup{
+				{Name: "old", State: "success", CompletedAt: now.Add(-3 * day)},
+				{Name: "failed", State: "failed", CompletedAt: now.Add(-time.Hour)},
+			}, nil
+		},
+	}
+	passwords := &mock.PasswordsService{
+		ListFn: func(ctx context.Context, req *ps.ListDatabaseBranchPasswordRequest) ([]*ps.DatabaseBranchPassword, error) {
+			c.Assert(req.Branch, qt.Equals, "main")
+			return []*ps.DatabaseBranchPassword{
+				{Name: "app", PublicID: "abc", CreatedAt: now.Add(-100 * day)},
+				{Name: "ci", PublicID: "def", CreatedAt: now.Add(-10 * day)},
+			}, nil
+		},
+	}
+	client := &ps.Client{DatabaseBranches: branches, Backups: backups, Passwords: passwords}
+
+	opts := &readinessOptions{maxBackupAge: day, maxPasswordAge: 90 * day}
+	checks, production, err := checkReadiness(context.Background(), client, "planetscale", "mydb", opts, now)
+	c.Assert(err, qt.IsNil)
+	c.Assert(production, qt.Equals, "main")
+	c.Assert(checks, qt.DeepEquals, []*readinessCheck{
+		{Check: "production branch", Status: readinessPass, Detail: "main"},
+		{
+			Check:  "backups",
+			Status: readinessFail,
+			Detail: "no successful backup within 1d: main",
+			Fix:    "pscale backup create mydb main",
+		},
+		{
+			Check:  "passwords",
+			Status: readinessFail,
+			Detail: "1 password(s) older than 90d: main/app",
+			Fix:    "pscale password delete mydb main abc",
+		},
+	})
+
+	// Without a production branch, the other checks are skipped.
+	branches.ListFn = func(ctx context.Context, req *ps.ListDatabaseBranchesRequest) ([]*ps.DatabaseBranch, error) {
+		return []*ps.DatabaseBranch{
+			{Name: "dev", CreatedAt: now},
+			{Name: "first", CreatedAt: now.Add(-day)},
+		}, nil
+	}
+	backups.ListFnInvoked = false
+
+	checks, production, err = checkReadiness(context.Background(), client, "planetscale", "mydb", opts, now)
+	c.Assert(err, qt.IsNil)
+	c.Assert(production, qt.Equals, "")
+	c.Assert(backups.ListFnInvoked, qt.IsFalse)
+	c.Assert(checks, qt.HasLen, 3)
+	c.Assert(checks[0].Status, qt.Equals, readinessFail)
+	c.Assert(checks[0].Fix, qt.Equals, "pscale branch promote mydb first")
+	c.Assert(checks[1].Status, qt.Equals, readinessSkip)
+	c.Assert(checks[2].Status, qt.Equals, readinessSkip)
+}
+
+func TestCheckErrorRate(t *testing.T) {
+	c := qt.New(t)
+
+	check := checkErrorRate("mydb", "main", 0, 0, 1)
+	c.Assert(check.Status, qt.Equals, readinessSkip)
+
+	check = checkErrorRate("mydb", "main", 1000, 5, 1)
+	c.Assert(check.Status, qt.Equals, readinessPass)
+	c.Assert(check.Detail, qt.Equals, "0.5% of 1000 recent statements on main failed")
+	c.Assert(check.Fix, qt.Equals, "")
+
+	check = checkErrorRate("mydb", "main", 1000, 25, 1)
+	c.Assert(check.Status, qt.Equals, readinessFail)
+	c.Assert(check.Fix, qt.Equals, "pscale insights anomalies mydb main")
+}
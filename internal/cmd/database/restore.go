@@ -19,22 +19,29 @@ import (
 type restoreFlags struct {
 	localAddr string
 	dir       string
+	threads   int
+	resume    bool
 }
 
 // RestoreCmd encapsulates the commands for restore a database
 func RestoreCmd(ch *cmdutil.Helper) *cobra.Command {
 	f := &restoreFlags{}
 	cmd := &cobra.Command{
-		Use:   "restore-dump <database> <branch> [options]",
-		Short: "Restore your database from a local dump directory",
-		Args:  cmdutil.RequiredArgs("database", "branch"),
-		RunE:  func(cmd *cobra.Command, args []string) error { return restore(ch, cmd, f, args) },
+		Use:               "restore-dump <database> <branch> [options]",
+		Short:             "Restore your database from a local dump directory",
+		Aliases:           []string{"restore"},
+		Args:              cmdutil.RequiredArgs("database", "branch"),
+		ValidArgsFunction: cmdutil.BranchCompletion(ch),
+		RunE:              func(cmd *cobra.Command, args []string) error { return restore(ch, cmd, f, args) },
 	}
 
 	cmd.PersistentFlags().StringVar(&f.localAddr, "local-addr",
 		"", "Local address to bind and listen for connections. By default the proxy binds to 127.0.0.1 with a random port.")
 	cmd.PersistentFlags().StringVar(&f.dir, "dir", "",
 		"Directory containing the files to be used for the restore (required)")
+	cmd.PersistentFlags().IntVar(&f.threads, "threads", 16, "Number of files to restore in parallel.")
+	cmd.PersistentFlags().BoolVar(&f.resume, "resume", false,
+		"Continue an interrupted restore of the directory, skipping the files it already restored.")
 
 	return cmd
 }
@@ -50,6 +57,10 @@ func restore(ch *cmdutil.Helper, cmd *cobra.Command, flags *restoreFlags, args [
 		return errors.New("--dir flag is missing, it's needed to restore the database")
 	}
 
+	if flags.threads < 1 {
+		return errors.New("--threads must be at least 1")
+	}
+
 	client, err := ch.Client()
 	if err != nil {
 		return err
@@ -61,9 +72,15 @@ func restore(ch *cmdutil.Helper, cmd *cobra.Command, flags *restoreFlags, args [
 		localAddr = flags.localAddr
 	}
 
+	remoteAddr, err := proxyutil.RemoteAddr(ctx, ch.Config, client, database, branch, "")
+	if err != nil {
+		return cmdutil.HandleError(err)
+	}
+
 	proxyOpts := proxy.Options{
 		CertSource: proxyutil.NewRemoteCertSource(client, cmdutil.AdministratorRole),
 		LocalAddr:  localAddr,
+		RemoteAddr: remoteAddr,
 		Instance:   fmt.Sprintf("%s/%s/%s", ch.Config.Organization, database, branch),
 		Logger:     cmdutil.NewZapLogger(ch.Debug()),
 	}
@@ -112,6 +129,8 @@ func restore(ch *cmdutil.Helper, cmd *cobra.Command, flags *restoreFlags, args [
 	cfg.Debug = ch.Debug()
 	cfg.IntervalMs = 10 * 1000
 	cfg.Outdir = flags.dir
+	cfg.Threads = flags.threads
+	cfg.Resume = flags.resume
 
 	loader, err := dumper.NewLoader(cfg)
 	if err != nil {
@@ -127,7 +146,7 @@ func restore(ch *cmdutil.Helper, cmd *cobra.Command, flags *restoreFlags, args [
 	start := time.Now()
 	err = loader.Run(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to restore database: %s", err)
+		return fmt.Errorf("failed to restore database: %s\nrun the command again with --resume to continue the restore", err)
 	}
 
 	end()
@@ -3,15 +3,18 @@ package database
 import (
 	"bytes"
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/planetscale/cli/internal/cmdutil"
 	"github.com/planetscale/cli/internal/config"
 	"github.com/planetscale/cli/internal/mock"
 	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
 	ps "github.com/planetscale/planetscale-go/planetscale"
 
 	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
 )
 
 func TestDatabase_ShowCmd(t *testing.T) {
@@ -57,3 +60,53 @@ func TestDatabase_ShowCmd(t *testing.T) {
 	c.Assert(svc.GetFnInvoked, qt.IsTrue)
 	c.Assert(buf.String(), qt.JSONEquals, res)
 }
+
+func TestDatabase_ShowCmd_Completion(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	var listErr error
+	svc := &mock.DatabaseService{
+		ListFn: func(ctx context.Context, req *ps.ListDatabasesRequest) ([]*ps.Database, error) {
+			c.Assert(req.Organization, qt.Equals, "planetscale")
+			if listErr != nil {
+				return nil, listErr
+			}
+			return []*ps.Database{{Name: "foo"}, {Name: "bar"}}, nil
+		},
+	}
+
+	format := printer.Human
+	ch := &cmdutil.Helper{
+		Printer: printer.NewPrinter(&format),
+		Config:  &config.Config{Organization: "planetscale"},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{Databases: svc}, nil
+		},
+	}
+
+	cmd := ShowCmd(ch)
+	candidates, directive := cmd.ValidArgsFunction(cmd, nil, "")
+	c.Assert(candidates, qt.DeepEquals, []string{"foo", "bar"})
+	c.Assert(directive, qt.Equals, cobra.ShellCompDirectiveNoFileComp)
+	c.Assert(svc.ListFnInvoked, qt.IsTrue)
+
+	// the cached candidates are used while they're recent
+	svc.ListFnInvoked = false
+	candidates, _ = cmd.ValidArgsFunction(cmd, nil, "")
+	c.Assert(candidates, qt.DeepEquals, []string{"foo", "bar"})
+	c.Assert(svc.ListFnInvoked, qt.IsFalse)
+
+	// the database argument is the only one completed
+	candidates, _ = cmd.ValidArgsFunction(cmd, []string{"foo"}, "")
+	c.Assert(candidates, qt.HasLen, 0)
+
+	// another organization isn't cached and the API is unreachable
+	listErr = errors.New("offline")
+	ch.Config.Organization = "other"
+	svc.ListFn = func(ctx context.Context, req *ps.ListDatabasesRequest) ([]*ps.Database, error) {
+		return nil, listErr
+	}
+	candidates, _ = cmd.ValidArgsFunction(cmd, nil, "")
+	c.Assert(candidates, qt.HasLen, 0)
+}
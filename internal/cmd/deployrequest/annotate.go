@@ -0,0 +1,91 @@
+package deployrequest
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+// AnnotateCmd is the command for setting and showing the notes and links of
+// a deploy request.
+func AnnotateCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags cmdutil.AnnotationFlags
+
+	cmd := &cobra.Command{
+		Use:   "annotate <database> <number>",
+		Short: "Set or show the notes and links of a deploy request",
+		Long: `Set or show the notes and links of a deploy request.
+
+Annotations are local only: they're stored in the pscale configuration
+directory of this machine and aren't shared with other users or machines.
+They're shown in the output of 'pscale deploy-request list'. Without any
+flags, the current annotation is shown.`,
+		Args:              cmdutil.RequiredArgs("database", "number"),
+		ValidArgsFunction: cmdutil.DeployRequestCompletion(ch),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database := args[0]
+			number := args[1]
+
+			n, err := strconv.ParseUint(number, 10, 64)
+			if err != nil {
+				return fmt.Errorf("the argument <number> is invalid: %s", err)
+			}
+
+			key := config.DeployRequestKey(ch.Config.Organization, database, n)
+			name := fmt.Sprintf("Deploy request %s/%s", printer.BoldBlue(database), printer.BoldBlue(number))
+
+			annotations, err := ch.ConfigFS.Annotations()
+			if err != nil {
+				return err
+			}
+
+			if !flags.Changed(cmd) {
+				return cmdutil.PrintAnnotation(ch.Printer, name, annotations.DeployRequests[key])
+			}
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			_, err = client.DeployRequests.Get(ctx, &planetscale.GetDeployRequestRequest{
+				Organization: ch.Config.Organization,
+				Database:     database,
+				Number:       n,
+			})
+			if err != nil {
+				switch cmdutil.ErrCode(err) {
+				case planetscale.ErrNotFound:
+					return fmt.Errorf("deploy request '%s/%s' does not exist in organization %s",
+						printer.BoldBlue(database), printer.BoldBlue(number), printer.BoldBlue(ch.Config.Organization))
+				default:
+					return cmdutil.HandleError(err)
+				}
+			}
+
+			a := flags.Apply(cmd, annotations.DeployRequests[key])
+			if a == nil {
+				delete(annotations.DeployRequests, key)
+			} else {
+				annotations.DeployRequests[key] = a
+			}
+
+			if err := annotations.Write(); err != nil {
+				return err
+			}
+
+			return cmdutil.PrintAnnotation(ch.Printer, name, a)
+		},
+	}
+
+	flags.Register(cmd)
+
+	return cmd
+}
@@ -2,7 +2,6 @@ package deployrequest
 
 import (
 	"fmt"
-	"strconv"
 
 	"github.com/planetscale/cli/internal/cmdutil"
 	"github.com/planetscale/cli/internal/printer"
@@ -13,10 +12,13 @@ import (
 
 // CloseCmd is the command for closing deploy requests.
 func CloseCmd(ch *cmdutil.Helper) *cobra.Command {
+	var cond string
+
 	cmd := &cobra.Command{
-		Use:   "close <database> <number>",
-		Short: "Close a deploy request",
-		Args:  cmdutil.RequiredArgs("database", "number"),
+		Use:               "close <database> <number>",
+		Short:             "Close a deploy request",
+		Args:              cmdutil.RequiredArgs("database", "number"),
+		ValidArgsFunction: cmdutil.DeployRequestCompletion(ch),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			database := args[0]
@@ -27,9 +29,13 @@ func CloseCmd(ch *cmdutil.Helper) *cobra.Command {
 				return err
 			}
 
-			n, err := strconv.ParseUint(number, 10, 64)
+			n, err := cmdutil.ConfirmDeployRequest(ctx, client, ch.Config.Organization, database, number)
 			if err != nil {
-				return fmt.Errorf("the argument <number> is invalid: %s", err)
+				return err
+			}
+
+			if err := checkCondition(ctx, ch, client, database, n, cond); err != nil {
+				return err
 			}
 
 			dr, err := client.DeployRequests.CloseDeploy(ctx, &planetscale.CloseDeployRequestRequest{
@@ -58,5 +64,6 @@ func CloseCmd(ch *cmdutil.Helper) *cobra.Command {
 		},
 	}
 
+	cmdutil.ConditionFlag(cmd, &cond)
 	return cmd
 }
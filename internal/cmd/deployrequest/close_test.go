@@ -3,6 +3,7 @@ package deployrequest
 import (
 	"bytes"
 	"context"
+	"errors"
 	"strconv"
 	"testing"
 
@@ -60,3 +61,38 @@ func TestDeployRequest_CloseCmd(t *testing.T) {
 	res := &DeployRequest{Number: number}
 	c.Assert(buf.String(), qt.JSONEquals, res)
 }
+
+func TestDeployRequest_CloseCmd_Condition(t *testing.T) {
+	c := qt.New(t)
+
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&bytes.Buffer{})
+
+	svc := &mock.DeployRequestsService{
+		GetFn: func(ctx context.Context, req *ps.GetDeployRequestRequest) (*ps.DeployRequest, error) {
+			return &ps.DeployRequest{Number: req.Number, State: "closed"}, nil
+		},
+		CloseFn: func(ctx context.Context, req *ps.CloseDeployRequestRequest) (*ps.DeployRequest, error) {
+			return &ps.DeployRequest{Number: req.Number}, nil
+		},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config:  &config.Config{Organization: "planetscale"},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{DeployRequests: svc}, nil
+		},
+	}
+
+	cmd := CloseCmd(ch)
+	cmd.SetArgs([]string{"planetscale", "10", "--if", `state=="open"`})
+	err := cmd.Execute()
+
+	var cmdErr *cmdutil.Error
+	c.Assert(errors.As(err, &cmdErr), qt.IsTrue)
+	c.Assert(cmdErr.ExitCode, qt.Equals, cmdutil.ConditionFalseExitCode)
+	c.Assert(svc.GetFnInvoked, qt.IsTrue)
+	c.Assert(svc.CloseFnInvoked, qt.IsFalse)
+}
@@ -2,6 +2,7 @@ package deployrequest
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/planetscale/cli/internal/cmdutil"
 	"github.com/planetscale/cli/internal/printer"
@@ -13,18 +14,43 @@ import (
 // CreateCmd is the command for creating deploy requests.
 func CreateCmd(ch *cmdutil.Helper) *cobra.Command {
 	var flags struct {
-		deployTo string
+		deployTo     string
+		notes        string
+		notesFromGit bool
+		gitBase      string
 	}
 
 	cmd := &cobra.Command{
 		Use:   "create <database> <branch> [flags]",
 		Short: "Create a deploy request from a branch",
-		Args:  cmdutil.RequiredArgs("database", "branch"),
+		Long: `Create a deploy request from a branch.
+
+With --notes-from-git, the notes of the deploy request list the commits of the
+current git branch since --git-base, so reviewers can link the schema changes
+to the changes of the application. If GH_TOKEN or GITHUB_TOKEN is set, the
+title and the URL of the open pull request of the git branch on GitHub are
+added as well.`,
+		Example: `Create a deploy request with the commits of the current git branch as notes:
+
+  pscale deploy-request create mydb add-users-table --notes-from-git`,
+		Args:              cmdutil.RequiredArgs("database", "branch"),
+		ValidArgsFunction: cmdutil.BranchCompletion(ch),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			database := args[0]
 			branch := args[1]
 
+			notes := flags.notes
+			if flags.notesFromGit {
+				generated, err := gitNotes(ctx, flags.gitBase, func(err error) {
+					ch.Printer.Printf("%s\n", printer.BoldRed(err.Error()))
+				})
+				if err != nil {
+					return fmt.Errorf("can't generate the notes from git: %s", err)
+				}
+				notes = strings.TrimSpace(notes + "\n\n" + generated)
+			}
+
 			client, err := ch.Client()
 			if err != nil {
 				return err
@@ -38,6 +64,7 @@ func CreateCmd(ch *cmdutil.Helper) *cobra.Command {
 				Database:     database,
 				Branch:       branch,
 				IntoBranch:   flags.deployTo,
+				Notes:        notes,
 			})
 			if err != nil {
 				switch cmdutil.ErrCode(err) {
@@ -50,7 +77,7 @@ func CreateCmd(ch *cmdutil.Helper) *cobra.Command {
 			}
 			end()
 
-			if ch.Printer.Format() == printer.Human {
+			if ch.Printer.Format() == printer.Human && !ch.Printer.Quiet() {
 				number := fmt.Sprintf("#%d", dr.Number)
 				ch.Printer.Printf("Deploy request %s successfully created.\n", printer.BoldBlue(number))
 				return nil
@@ -61,6 +88,9 @@ func CreateCmd(ch *cmdutil.Helper) *cobra.Command {
 	}
 
 	cmd.PersistentFlags().StringVar(&flags.deployTo, "deploy-to", "main", "Branch to deploy the branch. By default it's set to 'main'")
+	cmd.Flags().StringVar(&flags.notes, "notes", "", "Notes of the deploy request")
+	cmd.Flags().BoolVar(&flags.notesFromGit, "notes-from-git", false, "Generate the notes from the commits and the pull request of the current git branch")
+	cmd.Flags().StringVar(&flags.gitBase, "git-base", "", "Git revision the commits of the notes are listed from (default: the default branch of origin, or main)")
 
 	return cmd
 }
@@ -1,9 +1,11 @@
 package deployrequest
 
 import (
+	"context"
 	"fmt"
-	"strconv"
+	"time"
 
+	"github.com/planetscale/cli/internal/approval"
 	"github.com/planetscale/cli/internal/cmdutil"
 	"github.com/planetscale/cli/internal/printer"
 	"github.com/planetscale/planetscale-go/planetscale"
@@ -13,10 +15,18 @@ import (
 
 // DeployCmd is the command for deploying deploy requests.
 func DeployCmd(ch *cmdutil.Helper) *cobra.Command {
+	var wait bool
+	var timeout time.Duration
+	var approvalFile string
+	var override bool
+	var cond string
+	var saveRollback bool
+
 	cmd := &cobra.Command{
-		Use:   "deploy <database> <number>",
-		Short: "Deploy a specific deploy request",
-		Args:  cmdutil.RequiredArgs("database", "number"),
+		Use:               "deploy <database> <number>",
+		Short:             "Deploy a specific deploy request",
+		Args:              cmdutil.RequiredArgs("database", "number"),
+		ValidArgsFunction: cmdutil.DeployRequestCompletion(ch),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			database := args[0]
@@ -27,9 +37,33 @@ func DeployCmd(ch *cmdutil.Helper) *cobra.Command {
 				return err
 			}
 
-			n, err := strconv.ParseUint(number, 10, 64)
+			n, err := cmdutil.ConfirmDeployRequest(ctx, client, ch.Config.Organization, database, number)
 			if err != nil {
-				return fmt.Errorf("the argument <number> is invalid: %s", err)
+				return err
+			}
+
+			if err := checkCondition(ctx, ch, client, database, n, cond); err != nil {
+				return err
+			}
+
+			if err := cmdutil.CheckMaintenance(ch, database, override); err != nil {
+				return err
+			}
+
+			action := &approval.Action{
+				Command:      "deploy-request deploy",
+				Organization: ch.Config.Organization,
+				Database:     database,
+				Number:       n,
+			}
+			if err := cmdutil.CheckApproval(ch, action, approvalFile); err != nil {
+				return err
+			}
+
+			if saveRollback {
+				if err := saveDeployRollback(ctx, ch, client, database, n); err != nil {
+					return fmt.Errorf("couldn't save the rollback manifest: %s", cmdutil.HandleError(err))
+				}
 			}
 
 			dr, err := client.DeployRequests.Deploy(ctx, &planetscale.PerformDeployRequest{
@@ -47,6 +81,10 @@ func DeployCmd(ch *cmdutil.Helper) *cobra.Command {
 				}
 			}
 
+			if wait {
+				return waitAndReport(ctx, ch, client, database, n, timeout)
+			}
+
 			if ch.Printer.Format() == printer.Human {
 				ch.Printer.Printf("Successfully queued %s from %s for deployment to %s.\n",
 					dr.ID, dr.Branch, dr.IntoBranch)
@@ -57,5 +95,51 @@ func DeployCmd(ch *cmdutil.Helper) *cobra.Command {
 		},
 	}
 
+	cmd.Flags().BoolVar(&wait, "wait", false, "Wait until the deployment finished, an interrupted wait can be resumed with 'pscale resume'")
+	cmd.Flags().DurationVar(&timeout, "timeout", defaultWaitTimeout, "Fail if the deployment didn't finish within this duration. Used with --wait")
+	cmdutil.ApprovalFlag(cmd, &approvalFile)
+	cmdutil.MaintenanceFlag(cmd, &override)
+	cmdutil.ConditionFlag(cmd, &cond)
+	cmd.Flags().BoolVar(&saveRollback, "save-rollback", false, "Save the schema of the branch deployed to before deploying, for 'pscale release rollback deploy-<database>-<number>'")
 	return cmd
 }
+
+// saveDeployRollback saves the rollback manifest of the deploy request,
+// which 'pscale release rollback' executes.
+func saveDeployRollback(ctx context.Context, ch *cmdutil.Helper, client *planetscale.Client, database string, number uint64) error {
+	dr, err := client.DeployRequests.Get(ctx, &planetscale.GetDeployRequestRequest{
+		Organization: ch.Config.Organization,
+		Database:     database,
+		Number:       number,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = SaveRollback(ctx, client, RollbackID(database, number), ch.Config.Organization, database, dr, nil)
+	return err
+}
+
+// checkCondition checks the --if condition against the deploy request.
+func checkCondition(ctx context.Context, ch *cmdutil.Helper, client *planetscale.Client, database string, number uint64, cond string) error {
+	if cond == "" {
+		return nil
+	}
+
+	dr, err := client.DeployRequests.Get(ctx, &planetscale.GetDeployRequestRequest{
+		Organization: ch.Config.Organization,
+		Database:     database,
+		Number:       number,
+	})
+	if err != nil {
+		switch cmdutil.ErrCode(err) {
+		case planetscale.ErrNotFound:
+			return fmt.Errorf("deploy request '%s/%s' does not exist in organization %s",
+				printer.BoldBlue(database), printer.BoldBlue(number), printer.BoldBlue(ch.Config.Organization))
+		default:
+			return cmdutil.HandleError(err)
+		}
+	}
+
+	return cmdutil.CheckCondition(cond, dr)
+}
@@ -5,11 +5,13 @@ import (
 	"context"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/planetscale/cli/internal/cmdutil"
 	"github.com/planetscale/cli/internal/config"
 	"github.com/planetscale/cli/internal/mock"
 	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
 
 	qt "github.com/frankban/quicktest"
 	ps "github.com/planetscale/planetscale-go/planetscale"
@@ -60,3 +62,76 @@ func TestDeployRequest_DeployCmd(t *testing.T) {
 	res := &DeployRequest{Number: number}
 	c.Assert(buf.String(), qt.JSONEquals, res)
 }
+
+func TestDeployRequest_DeployCmd_SaveRollback(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	db := "planetscale"
+	var number uint64 = 10
+	opened := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	svc := &mock.DeployRequestsService{
+		GetFn: func(ctx context.Context, req *ps.GetDeployRequestRequest) (*ps.DeployRequest, error) {
+			return &ps.DeployRequest{Number: number, IntoBranch: "main", CreatedAt: opened}, nil
+		},
+		DeployFn: func(ctx context.Context, req *ps.PerformDeployRequest) (*ps.DeployRequest, error) {
+			return &ps.DeployRequest{Number: number}, nil
+		},
+	}
+	branches := &mock.DatabaseBranchesService{
+		SchemaFn: func(ctx context.Context, req *ps.BranchSchemaRequest) ([]*ps.Diff, error) {
+			c.Assert(req.Branch, qt.Equals, "main")
+			return []*ps.Diff{{Name: "t", Raw: "CREATE TABLE t (id int)"}}, nil
+		},
+	}
+
+	format := printer.JSON
+	ch := &cmdutil.Helper{
+		Printer: printer.NewPrinter(&format),
+		Config:  &config.Config{Organization: "planetscale"},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{DeployRequests: svc, DatabaseBranches: branches}, nil
+		},
+	}
+
+	cmd := DeployCmd(ch)
+	cmd.SetArgs([]string{db, strconv.FormatUint(number, 10), "--save-rollback"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(svc.DeployFnInvoked, qt.IsTrue)
+
+	rollback, err := config.ReadRollback(RollbackID(db, number))
+	c.Assert(err, qt.IsNil)
+	c.Assert(rollback.Branch, qt.Equals, "main")
+	c.Assert(rollback.Schema, qt.Equals, "CREATE TABLE t (id int);\n\n")
+	c.Assert(rollback.OpenedAt.Equal(opened), qt.IsTrue)
+}
+
+func TestDeployRequest_DeployCmd_Maintenance(t *testing.T) {
+	c := qt.New(t)
+
+	format := printer.JSON
+	svc := &mock.DeployRequestsService{}
+	ch := &cmdutil.Helper{
+		Printer: printer.NewPrinter(&format),
+		Config: &config.Config{
+			Organization: "planetscale",
+			Maintenance: map[string]*config.Maintenance{
+				"*": {
+					Enforce: true,
+					Freezes: []*config.MaintenanceWindow{{Name: "forever", From: "2000-01-01", Until: "2999-12-31"}},
+				},
+			},
+		},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{DeployRequests: svc}, nil
+		},
+	}
+
+	cmd := DeployCmd(ch)
+	cmd.SetArgs([]string{"planetscale", "10"})
+	err := cmd.Execute()
+
+	c.Assert(err, qt.ErrorMatches, `(?s)changes to database .* are not allowed now: .* is inside the change freeze forever \(run with --override to proceed anyway\)`)
+	c.Assert(svc.DeployFnInvoked, qt.IsFalse)
+}
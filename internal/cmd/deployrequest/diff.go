@@ -1,15 +1,13 @@
 package deployrequest
 
 import (
-	"bufio"
 	"fmt"
-	"strconv"
 	"strings"
 
-	"github.com/fatih/color"
 	"github.com/pkg/browser"
 	"github.com/planetscale/cli/internal/cmdutil"
 	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/schemadiff"
 	"github.com/planetscale/planetscale-go/planetscale"
 
 	"github.com/spf13/cobra"
@@ -18,13 +16,19 @@ import (
 // DiffCmd is the command for showing the diff of a deploy request.
 func DiffCmd(ch *cmdutil.Helper) *cobra.Command {
 	var flags struct {
-		web bool
+		web        bool
+		diffFormat string
+		noIgnore   bool
 	}
 
 	cmd := &cobra.Command{
-		Use:   "diff <database> <number>",
-		Short: "Show the diff of a deploy request",
-		Args:  cmdutil.RequiredArgs("database", "number"),
+		Use:               "diff <database> <number>",
+		Short:             "Show the diff of a deploy request",
+		Args:              cmdutil.RequiredArgs("database", "number"),
+		ValidArgsFunction: cmdutil.DeployRequestCompletion(ch),
+		Example: `Export the diff of a deploy request as an HTML file:
+
+  pscale deploy-request diff mydb 10 --diff-format html > diff.html`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			database := args[0]
@@ -35,14 +39,19 @@ func DiffCmd(ch *cmdutil.Helper) *cobra.Command {
 				return browser.OpenURL(fmt.Sprintf("%s/%s/%s/deploy-requests/%s/diff", cmdutil.ApplicationURL, ch.Config.Organization, database, number))
 			}
 
+			diffFormat, err := schemadiff.ParseFormat(flags.diffFormat)
+			if err != nil {
+				return err
+			}
+
 			client, err := ch.Client()
 			if err != nil {
 				return err
 			}
 
-			n, err := strconv.ParseUint(number, 10, 64)
+			n, err := cmdutil.ResolveDeployRequest(ctx, client, ch.Config.Organization, database, number)
 			if err != nil {
-				return fmt.Errorf("the argument <number> is invalid: %s", err)
+				return err
 			}
 
 			diffs, err := client.DeployRequests.Diff(ctx, &planetscale.DiffRequest{
@@ -60,34 +69,48 @@ func DiffCmd(ch *cmdutil.Helper) *cobra.Command {
 				}
 			}
 
+			if !flags.noIgnore {
+				ignore, err := schemadiff.ProjectIgnore()
+				if err != nil {
+					return err
+				}
+				diffs = ignore.ApplyDiffs(diffs)
+			}
+
 			if ch.Printer.Format() != printer.Human {
 				return ch.Printer.PrintResource(diffs)
 			}
 
 			// human readable output
-			for _, df := range diffs {
-				ch.Printer.Println("--", printer.BoldBlue(df.Name), "--")
-				scanner := bufio.NewScanner(strings.NewReader(strings.TrimSpace(df.Raw)))
-				for scanner.Scan() {
-					txt := scanner.Text()
-					if strings.HasPrefix(txt, "+") {
-						ch.Printer.Println(color.New(color.FgGreen).Add(color.Bold).Sprint(txt)) //nolint: errcheck
-					} else if strings.HasPrefix(txt, "-") {
-						ch.Printer.Println(color.New(color.FgRed).Add(color.Bold).Sprint(txt)) //nolint: errcheck
-					} else {
-						ch.Printer.Println(txt)
-					}
-				}
-				if err := scanner.Err(); err != nil {
-					return fmt.Errorf("reading diff raw: %s", err)
-				}
+			var b strings.Builder
+			title := fmt.Sprintf("Deploy request %s/%s", database, number)
+			if err := schemadiff.Render(&b, diffFormat, title, schemadiff.FromDiffs(diffs)); err != nil {
+				return err
 			}
+			ch.Printer.Print(b.String())
 
 			return nil
 		},
 	}
 
 	cmd.PersistentFlags().BoolVar(&flags.web, "web", false, "Open in your web browser")
+	cmd.Flags().StringVar(&flags.diffFormat, "diff-format", string(schemadiff.FormatUnified),
+		fmt.Sprintf("Format of the human readable diff. Possible values: [%s]", strings.Join(schemadiff.Formats, ", ")))
+	cmd.Flags().BoolVar(&flags.noIgnore, "no-ignore", false,
+		fmt.Sprintf("Show all differences, including the ones excluded by the %s file", schemadiff.IgnoreFile))
+	cmd.RegisterFlagCompletionFunc("diff-format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) { // nolint:errcheck
+		return schemadiff.Formats, cobra.ShellCompDirectiveDefault
+	})
 
 	return cmd
 }
+
+// printDiff prints the diff of a single table as a unified diff.
+func printDiff(ch *cmdutil.Helper, df *planetscale.Diff) error {
+	var b strings.Builder
+	if err := schemadiff.Render(&b, schemadiff.FormatUnified, "", schemadiff.FromDiffs([]*planetscale.Diff{df})); err != nil {
+		return err
+	}
+	ch.Printer.Print(b.String())
+	return nil
+}
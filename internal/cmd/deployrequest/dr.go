@@ -1,6 +1,8 @@
 package deployrequest
 
 import (
+	"strconv"
+
 	"github.com/planetscale/cli/internal/cmdutil"
 	"github.com/planetscale/cli/internal/printer"
 	"github.com/planetscale/planetscale-go/planetscale"
@@ -20,13 +22,17 @@ func DeployRequestCmd(ch *cmdutil.Helper) *cobra.Command {
 	cmd.PersistentFlags().StringVar(&ch.Config.Organization, "org", ch.Config.Organization, "The organization for the current user")
 	cmd.MarkPersistentFlagRequired("org") // nolint:errcheck
 
+	cmd.AddCommand(AnnotateCmd(ch))
 	cmd.AddCommand(CloseCmd(ch))
 	cmd.AddCommand(CreateCmd(ch))
 	cmd.AddCommand(DeployCmd(ch))
 	cmd.AddCommand(DiffCmd(ch))
+	cmd.AddCommand(HistoryCmd(ch))
 	cmd.AddCommand(ListCmd(ch))
+	cmd.AddCommand(PerfCheckCmd(ch))
 	cmd.AddCommand(ReviewCmd(ch))
 	cmd.AddCommand(ShowCmd(ch))
+	cmd.AddCommand(WaitCmd(ch))
 
 	return cmd
 }
@@ -46,6 +52,8 @@ type DeployRequest struct {
 	CreatedAt  int64            `header:"created_at,timestamp(ms|utc|human)" json:"created_at"`
 	UpdatedAt  int64            `header:"updated_at,timestamp(ms|utc|human)" json:"updated_at"`
 	ClosedAt   *int64           `header:"closed_at,timestamp(ms|utc|human),-" json:"closed_at"`
+
+	Annotation string `header:"annotation" json:"annotation,omitempty"`
 }
 
 type inlineDeployment struct {
@@ -57,6 +65,12 @@ type inlineDeployment struct {
 	FinishedAt *int64 `header:"finished_at,timestamp(ms|utc|human),-" json:"finished_at"`
 }
 
+// Identifier returns the number of the deploy request, which the commands
+// take as argument, rather than its ID.
+func (d *DeployRequest) Identifier() string {
+	return strconv.FormatUint(d.Number, 10)
+}
+
 func (d *DeployRequest) MarshalCSVValue() interface{} {
 	return []*DeployRequest{d}
 }
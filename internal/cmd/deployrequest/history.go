@@ -0,0 +1,214 @@
+package deployrequest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+// ganttWidth is the width of the bars of the timeline.
+const ganttWidth = 40
+
+// Deploy is a deployment of a deploy request in the history of a database.
+type Deploy struct {
+	Number     uint64 `header:"number" json:"number"`
+	Branch     string `header:"branch" json:"branch"`
+	IntoBranch string `header:"into" json:"into_branch"`
+	State      string `header:"state" json:"state"`
+	DeployedBy string `header:"deployed by,n/a" json:"deployed_by,omitempty"`
+	Started    int64  `header:"started,timestamp(ms|utc|human)" json:"-"`
+	Duration   string `header:"duration,n/a" json:"-"`
+	Tables     string `header:"tables,n/a" json:"-"`
+
+	StartedAt       time.Time  `json:"started_at" csv:"-"`
+	FinishedAt      *time.Time `json:"finished_at" csv:"-"`
+	DurationSeconds float64    `json:"duration_seconds" csv:"-"`
+	TableNames      []string   `json:"tables" csv:"-"`
+}
+
+// HistoryCmd shows the timeline of the deployments of a database.
+func HistoryCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		since string
+		gantt bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "history <database>",
+		Short: "Show the timeline of the deployments of a database",
+		Long: `Show the timeline of the deployments of a database.
+
+Every deployment started since --since, a duration such as 30d, a date or a
+git revision, is listed with who deployed it according to the audit log, when
+it started, how long it took and the tables it changed. With --gantt, the
+deployments are drawn on a timeline instead.`,
+		Example: `Show the deployments of the last 30 days on a timeline:
+
+  pscale deploy-request history mydb --since 30d --gantt`,
+		Args:              cmdutil.RequiredArgs("database"),
+		ValidArgsFunction: cmdutil.DatabaseCompletion(ch),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database := args[0]
+
+			since, err := cmdutil.ParseSince(flags.since)
+			if err != nil {
+				return err
+			}
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Fetching the deployments of %s", printer.BoldBlue(database)))
+			defer end()
+
+			deploys, err := deployHistory(ctx, client, ch.Config.Organization, database, since)
+			if err != nil {
+				switch cmdutil.ErrCode(err) {
+				case ps.ErrNotFound:
+					return fmt.Errorf("database %s does not exist in organization %s",
+						printer.BoldBlue(database), printer.BoldBlue(ch.Config.Organization))
+				default:
+					return cmdutil.HandleError(err)
+				}
+			}
+			end()
+
+			if len(deploys) == 0 && ch.Printer.Format() == printer.Human {
+				ch.Printer.Printf("No deployments of %s were started since %s.\n",
+					printer.BoldBlue(database), since.Format(time.RFC3339))
+				return nil
+			}
+
+			if flags.gantt && ch.Printer.Format() == printer.Human && !ch.Printer.Quiet() {
+				ch.Printer.Print(gantt(deploys, time.Now()))
+				return nil
+			}
+
+			return ch.Printer.PrintResource(deploys)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.since, "since", "30d", "Duration, date or git revision the history starts from")
+	cmd.Flags().BoolVar(&flags.gantt, "gantt", false, "Draw the deployments on a timeline")
+
+	return cmd
+}
+
+// deployHistory returns the deployments started since the given time, oldest
+// first.
+func deployHistory(ctx context.Context, client *ps.Client, org, database string, since time.Time) ([]*Deploy, error) {
+	drs, err := client.DeployRequests.List(ctx, &ps.ListDeployRequestsRequest{
+		Organization: org,
+		Database:     database,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deployers := cmdutil.Deployers(ctx, client, org)
+
+	deploys := make([]*Deploy, 0)
+	for _, dr := range drs {
+		d := dr.Deployment
+		if d == nil || d.StartedAt == nil || d.StartedAt.Before(since) {
+			continue
+		}
+
+		diffs, err := client.DeployRequests.Diff(ctx, &ps.DiffRequest{
+			Organization: org,
+			Database:     database,
+			Number:       dr.Number,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		tables := make([]string, 0, len(diffs))
+		for _, diff := range diffs {
+			tables = append(tables, diff.Name)
+		}
+
+		deploy := &Deploy{
+			Number:     dr.Number,
+			Branch:     dr.Branch,
+			IntoBranch: dr.IntoBranch,
+			State:      d.State,
+			DeployedBy: deployers[dr.ID],
+			Started:    d.StartedAt.UTC().UnixNano() / int64(time.Millisecond),
+			Tables:     strings.Join(tables, ", "),
+			StartedAt:  *d.StartedAt,
+			FinishedAt: d.FinishedAt,
+			TableNames: tables,
+		}
+		if d.FinishedAt != nil {
+			took := d.FinishedAt.Sub(*d.StartedAt)
+			deploy.Duration = took.Round(time.Second).String()
+			deploy.DurationSeconds = took.Seconds()
+		}
+		deploys = append(deploys, deploy)
+	}
+
+	sort.Slice(deploys, func(i, j int) bool { return deploys[i].StartedAt.Before(deploys[j].StartedAt) })
+	return deploys, nil
+}
+
+// gantt draws the deployments as bars on a timeline from the start of the
+// first deployment to the end of the last one. Running deployments end now.
+func gantt(deploys []*Deploy, now time.Time) string {
+	finished := func(d *Deploy) time.Time {
+		if d.FinishedAt != nil {
+			return *d.FinishedAt
+		}
+		return now
+	}
+
+	start, end := deploys[0].StartedAt, finished(deploys[0])
+	labels := make([]string, len(deploys))
+	labelWidth := 0
+	for i, d := range deploys {
+		if f := finished(d); f.After(end) {
+			end = f
+		}
+		labels[i] = fmt.Sprintf("#%d %s", d.Number, d.Branch)
+		if len(labels[i]) > labelWidth {
+			labelWidth = len(labels[i])
+		}
+	}
+
+	span := end.Sub(start)
+	column := func(t time.Time) int {
+		if span <= 0 {
+			return 0
+		}
+		return int(float64(t.Sub(start)) / float64(span) * float64(ganttWidth-1))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-*s  %s\n", labelWidth, "", start.UTC().Format("2006-01-02 15:04"))
+	for i, d := range deploys {
+		from, to := column(d.StartedAt), column(finished(d))
+		bar := strings.Repeat(" ", from) + strings.Repeat("█", to-from+1) + strings.Repeat(" ", ganttWidth-1-to)
+
+		details := d.State
+		if d.Duration != "" {
+			details += ", " + d.Duration
+		}
+		if d.DeployedBy != "" {
+			details += ", by " + d.DeployedBy
+		}
+		fmt.Fprintf(&b, "%-*s |%s| %s\n", labelWidth, labels[i], bar, details)
+	}
+	fmt.Fprintf(&b, "%-*s  %*s\n", labelWidth, "", ganttWidth, end.UTC().Format("2006-01-02 15:04"))
+	return b.String()
+}
@@ -0,0 +1,106 @@
+package deployrequest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/printer"
+
+	qt "github.com/frankban/quicktest"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+func TestDeployRequest_HistoryCmd(t *testing.T) {
+	c := qt.New(t)
+
+	since := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	deployment := func(hours, minutes int) *ps.Deployment {
+		started := since.Add(time.Duration(hours) * time.Hour)
+		finished := started.Add(time.Duration(minutes) * time.Minute)
+		return &ps.Deployment{State: "complete", StartedAt: &started, FinishedAt: &finished}
+	}
+
+	drs := &mock.DeployRequestsService{
+		ListFn: func(ctx context.Context, req *ps.ListDeployRequestsRequest) ([]*ps.DeployRequest, error) {
+			return []*ps.DeployRequest{
+				{ID: "dr3", Number: 3, Branch: "add-orders", IntoBranch: "main", Deployment: deployment(48, 30)},
+				{ID: "dr2", Number: 2, Branch: "add-users", IntoBranch: "main", Deployment: deployment(2, 5)},
+				{ID: "dr1", Number: 1, Branch: "old", IntoBranch: "main", Deployment: deployment(-2, 5)},
+				{ID: "dr4", Number: 4, Branch: "wip", IntoBranch: "main", State: "open"},
+			}, nil
+		},
+		DiffFn: func(ctx context.Context, req *ps.DiffRequest) ([]*ps.Diff, error) {
+			switch req.Number {
+			case 2:
+				return []*ps.Diff{{Name: "users"}, {Name: "accounts"}}, nil
+			case 3:
+				return []*ps.Diff{{Name: "orders"}}, nil
+			}
+			c.Fatalf("unexpected diff of deploy request #%d", req.Number)
+			return nil, nil
+		},
+	}
+	logs := &mock.AuditLogService{
+		ListFn: func(ctx context.Context, req *ps.ListAuditLogsRequest) ([]*ps.AuditLog, error) {
+			return []*ps.AuditLog{{AuditableID: "dr2", ActorDisplayName: "Jane Doe"}}, nil
+		},
+	}
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config:  &config.Config{Organization: "planetscale"},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{DeployRequests: drs, AuditLogs: logs}, nil
+		},
+	}
+
+	cmd := HistoryCmd(ch)
+	cmd.SetArgs([]string{"mydb", "--since", "2026-09-01T00:00:00Z"})
+	err := cmd.Execute()
+	c.Assert(err, qt.IsNil)
+
+	var deploys []*Deploy
+	c.Assert(json.Unmarshal(buf.Bytes(), &deploys), qt.IsNil)
+	c.Assert(deploys, qt.HasLen, 2)
+
+	c.Assert(deploys[0].Number, qt.Equals, uint64(2))
+	c.Assert(deploys[0].DeployedBy, qt.Equals, "Jane Doe")
+	c.Assert(deploys[0].DurationSeconds, qt.Equals, float64(300))
+	c.Assert(deploys[0].TableNames, qt.DeepEquals, []string{"users", "accounts"})
+
+	c.Assert(deploys[1].Number, qt.Equals, uint64(3))
+	c.Assert(deploys[1].DeployedBy, qt.Equals, "")
+	c.Assert(deploys[1].TableNames, qt.DeepEquals, []string{"orders"})
+}
+
+func TestDeployRequest_HistoryGantt(t *testing.T) {
+	c := qt.New(t)
+
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	first, second := start.Add(time.Hour), start.Add(2*time.Hour)
+
+	deploys := []*Deploy{
+		{Number: 1, Branch: "a", State: "complete", Duration: "1h0m0s", DeployedBy: "Jane Doe", StartedAt: start, FinishedAt: &first},
+		{Number: 12, Branch: "b", State: "in_progress", StartedAt: first},
+	}
+
+	out := gantt(deploys, second)
+
+	c.Assert(out, qt.Contains, "#1 a  |"+strings.Repeat("█", 20)+strings.Repeat(" ", 20)+"| complete, 1h0m0s, by Jane Doe\n")
+	c.Assert(out, qt.Contains, "#12 b |"+strings.Repeat(" ", 19)+strings.Repeat("█", 21)+"| in_progress\n")
+	c.Assert(out, qt.Contains, "2026-09-01 00:00")
+	c.Assert(out, qt.Contains, "2026-09-01 02:00")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
 	"github.com/planetscale/cli/internal/printer"
 	"github.com/planetscale/planetscale-go/planetscale"
 
@@ -14,10 +15,11 @@ import (
 // ListCmd is the command for listing deploy requests.
 func ListCmd(ch *cmdutil.Helper) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "list <database>",
-		Short:   "List all deploy requests for a database",
-		Aliases: []string{"ls"},
-		Args:    cmdutil.RequiredArgs("database"),
+		Use:               "list <database>",
+		Short:             "List all deploy requests for a database",
+		Aliases:           []string{"ls"},
+		Args:              cmdutil.RequiredArgs("database"),
+		ValidArgsFunction: cmdutil.DatabaseCompletion(ch),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			database := args[0]
@@ -64,7 +66,17 @@ func ListCmd(ch *cmdutil.Helper) *cobra.Command {
 				return nil
 			}
 
-			return ch.Printer.PrintResource(toDeployRequests(deployRequests))
+			annotations, err := ch.ConfigFS.Annotations()
+			if err != nil {
+				return err
+			}
+
+			requests := toDeployRequests(deployRequests)
+			for _, dr := range requests {
+				dr.Annotation = annotations.DeployRequests[config.DeployRequestKey(ch.Config.Organization, database, dr.Number)].String()
+			}
+
+			return ch.Printer.PrintResource(requests)
 		},
 		TraverseChildren: true,
 	}
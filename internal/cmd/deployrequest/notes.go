@@ -0,0 +1,157 @@
+package deployrequest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	exec "golang.org/x/sys/execabs"
+)
+
+// maxNoteCommits is the maximum number of commits listed in the notes
+// generated from git.
+const maxNoteCommits = 50
+
+// These are replaced in tests.
+var (
+	gitOutput = func(args ...string) (string, error) {
+		out, err := exec.Command("git", args...).Output()
+		if err != nil {
+			return "", fmt.Errorf("git %s failed: %s", strings.Join(args, " "), err)
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+	githubAPIURL = "https://api.github.com"
+)
+
+// githubRemote matches the owner and the repository of GitHub remote URLs,
+// i.e. "git@github.com:owner/repo.git" or "https://github.com/owner/repo".
+var githubRemote = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(?:\.git)?/?$`)
+
+// pullRequest is an open pull request of a GitHub repository.
+type pullRequest struct {
+	Title   string `json:"title"`
+	HTMLURL string `json:"html_url"`
+}
+
+// gitNotes returns the notes of a deploy request generated from the commits
+// of the current git branch since base, and the title of its open pull
+// request on GitHub if a GH_TOKEN or GITHUB_TOKEN is set. If base is empty,
+// it's the default branch of the origin remote, or main. The pull request is
+// optional, failing to look it up is passed to warn.
+func gitNotes(ctx context.Context, base string, warn func(err error)) (string, error) {
+	if base == "" {
+		base = defaultGitBase()
+	}
+
+	log, err := gitOutput("log", "--no-merges", "--format=%s", fmt.Sprintf("-n%d", maxNoteCommits), base+"..HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	var commits []string
+	if log != "" {
+		commits = strings.Split(log, "\n")
+	}
+
+	pr, err := currentPullRequest(ctx)
+	if err != nil {
+		warn(err)
+	}
+	if pr == nil && len(commits) == 0 {
+		return "", fmt.Errorf("no commits found since %s to generate the notes from", base)
+	}
+
+	var b strings.Builder
+	if pr != nil {
+		fmt.Fprintf(&b, "%s\n\nPull request: %s\n", pr.Title, pr.HTMLURL)
+	}
+	if len(commits) > 0 {
+		if pr != nil {
+			b.WriteString("\n")
+		}
+		b.WriteString("Commits:\n")
+		for _, c := range commits {
+			fmt.Fprintf(&b, "- %s\n", c)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// defaultGitBase returns the default branch of the origin remote, or main if
+// it isn't known.
+func defaultGitBase() string {
+	if _, err := gitOutput("rev-parse", "--verify", "--quiet", "origin/HEAD"); err == nil {
+		return "origin/HEAD"
+	}
+	return "main"
+}
+
+// currentPullRequest returns the open pull request of the current branch, or
+// nil if there is none, no GitHub token is set or the origin remote isn't on
+// GitHub.
+func currentPullRequest(ctx context.Context) (*pullRequest, error) {
+	token := os.Getenv("GH_TOKEN")
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	if token == "" {
+		return nil, nil
+	}
+
+	remote, err := gitOutput("remote", "get-url", "origin")
+	if err != nil {
+		return nil, nil
+	}
+	m := githubRemote.FindStringSubmatch(remote)
+	if m == nil {
+		return nil, nil
+	}
+	owner, repo := m[1], m[2]
+
+	branch, err := gitOutput("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	addr := fmt.Sprintf("%s/repos/%s/%s/pulls?state=open&head=%s", githubAPIURL,
+		url.PathEscape(owner), url.PathEscape(repo), url.QueryEscape(owner+":"+branch))
+	req, err := http.NewRequestWithContext(ctx, "GET", addr, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Authorization", fmt.Sprintf("token %s", token))
+
+	client := &http.Client{Timeout: time.Second * 15}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("can't look up the pull request of %s: %s", branch, err)
+	}
+	defer resp.Body.Close()
+
+	out, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("can't look up the pull request of %s: %s", branch, resp.Status)
+	}
+
+	var prs []*pullRequest
+	if err := json.Unmarshal(out, &prs); err != nil {
+		return nil, errors.New("can't look up the pull request: unexpected response from GitHub")
+	}
+	if len(prs) == 0 {
+		return nil, nil
+	}
+	return prs[0], nil
+}
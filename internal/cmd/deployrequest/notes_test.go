@@ -0,0 +1,62 @@
+package deployrequest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestGitNotes(t *testing.T) {
+	c := qt.New(t)
+
+	git := map[string]string{
+		"rev-parse --verify --quiet origin/HEAD":             "",
+		"log --no-merges --format=%s -n50 origin/HEAD..HEAD": "Add users table\nStore user emails",
+		"remote get-url origin":                              "git@github.com:acme/app.git",
+		"rev-parse --abbrev-ref HEAD":                        "add-users",
+	}
+	c.Patch(&gitOutput, func(args ...string) (string, error) {
+		out, ok := git[strings.Join(args, " ")]
+		if !ok {
+			return "", fmt.Errorf("unexpected git command %q", args)
+		}
+		return out, nil
+	})
+
+	notes, err := gitNotes(context.Background(), "", func(err error) { c.Fatal(err) })
+	c.Assert(err, qt.IsNil)
+	c.Assert(notes, qt.Equals, "Commits:\n- Add users table\n- Store user emails")
+
+	srv, closeSrv := testutil.SetupServer(func(mux *http.ServeMux) {
+		mux.HandleFunc("/repos/acme/app/pulls", func(w http.ResponseWriter, r *http.Request) {
+			c.Assert(r.URL.Query().Get("head"), qt.Equals, "acme:add-users")
+			c.Assert(r.Header.Get("Authorization"), qt.Equals, "token gh-token")
+			fmt.Fprint(w, `[{"title": "Add user accounts", "html_url": "https://github.com/acme/app/pull/7"}]`)
+		})
+	})
+	defer closeSrv()
+	c.Patch(&githubAPIURL, srv.URL)
+	c.Setenv("GH_TOKEN", "gh-token")
+
+	notes, err = gitNotes(context.Background(), "", func(err error) { c.Fatal(err) })
+	c.Assert(err, qt.IsNil)
+	c.Assert(notes, qt.Equals, `Add user accounts
+
+Pull request: https://github.com/acme/app/pull/7
+
+Commits:
+- Add users table
+- Store user emails`)
+
+	git["log --no-merges --format=%s -n50 main..HEAD"] = ""
+	c.Setenv("GH_TOKEN", "")
+	c.Setenv("GITHUB_TOKEN", "")
+	_, err = gitNotes(context.Background(), "main", func(err error) { c.Fatal(err) })
+	c.Assert(err, qt.ErrorMatches, "no commits found since main to generate the notes from")
+}
@@ -0,0 +1,398 @@
+package deployrequest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+	"github.com/planetscale/cli/internal/sqlfmt"
+	"github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+// perfRegressionExitCode is the exit code if the plan of any query got
+// worse, so CI checks can tell it from failures.
+const perfRegressionExitCode = 2
+
+// PerfCheckCmd is the command for checking whether a deploy request makes
+// the plans of recent production queries worse.
+func PerfCheckCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		sample    int
+		threshold float64
+		execute   bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "perf-check <database> <number>",
+		Short: "Check whether a deploy request makes the plans of production queries worse",
+		Long: fmt.Sprintf(`Check whether a deploy request makes the plans of production queries worse.
+
+A sample of the recent queries of the branch the deploy request deploys to,
+one per fingerprint and the most frequent first, is read from the statement
+history of performance_schema. Each query is explained on both branches, and
+flagged if it fails on the deploy request branch, a table is accessed with a
+worse access type, such as a full scan instead of an index lookup, or the
+estimated rows grow by more than --threshold times.
+
+With --execute, SELECT queries are also run on both branches and flagged if
+they're more than --threshold times slower. As development branches don't hold
+the production data, row estimates and timings are only indicative.
+
+If any query is flagged, the command exits with status %d.`, perfRegressionExitCode),
+		Args:              cmdutil.RequiredArgs("database", "number"),
+		ValidArgsFunction: cmdutil.DeployRequestCompletion(ch),
+		Example: `  pscale deploy-request perf-check mydb 10
+  pscale deploy-request perf-check mydb 10 --sample 100 --execute`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database := args[0]
+			number := args[1]
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			n, err := cmdutil.ResolveDeployRequest(ctx, client, ch.Config.Organization, database, number)
+			if err != nil {
+				return err
+			}
+
+			dr, err := client.DeployRequests.Get(ctx, &planetscale.GetDeployRequestRequest{
+				Organization: ch.Config.Organization,
+				Database:     database,
+				Number:       n,
+			})
+			if err != nil {
+				switch cmdutil.ErrCode(err) {
+				case planetscale.ErrNotFound:
+					return fmt.Errorf("deploy request '%s/%s' does not exist in organization %s",
+						printer.BoldBlue(database), printer.BoldBlue(number), printer.BoldBlue(ch.Config.Organization))
+				default:
+					return cmdutil.HandleError(err)
+				}
+			}
+
+			base, closeBase, err := proxyutil.OpenBranch(ctx, ch, database, dr.IntoBranch, cmdutil.ReaderRole)
+			if err != nil {
+				return err
+			}
+			defer closeBase()
+
+			head, closeHead, err := proxyutil.OpenBranch(ctx, ch, database, dr.Branch, cmdutil.ReaderRole)
+			if err != nil {
+				return err
+			}
+			defer closeHead()
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Reading the recent queries of %s", printer.BoldBlue(dr.IntoBranch)))
+			queries, err := readQuerySample(ctx, base, flags.sample)
+			end()
+			if err != nil {
+				return err
+			}
+			if len(queries) == 0 {
+				return fmt.Errorf("no recent queries were found on branch %s", printer.BoldBlue(dr.IntoBranch))
+			}
+
+			end = ch.Printer.PrintProgress(fmt.Sprintf("Comparing %d queries on %s and %s",
+				len(queries), printer.BoldBlue(dr.IntoBranch), printer.BoldBlue(dr.Branch)))
+			defer end()
+
+			results := make([]*perfResult, 0, len(queries))
+			worse := 0
+			for _, q := range queries {
+				r, err := checkQuery(ctx, base, head, q, flags.threshold, flags.execute)
+				if err != nil {
+					return err
+				}
+				if r.Status != "ok" {
+					worse++
+				}
+				results = append(results, r)
+			}
+			end()
+
+			if err := ch.Printer.PrintResource(results); err != nil {
+				return err
+			}
+			if worse == 0 {
+				return nil
+			}
+
+			return &cmdutil.Error{
+				Msg:      fmt.Sprintf("%d query(s) perform worse on branch %s", worse, dr.Branch),
+				ExitCode: perfRegressionExitCode,
+			}
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.sample, "sample", 50, "The number of distinct queries to check")
+	cmd.Flags().Float64Var(&flags.threshold, "threshold", 2, "Flag queries whose row estimates or timings grow by more than this factor")
+	cmd.Flags().BoolVar(&flags.execute, "execute", false, "Also run the SELECT queries on both branches and compare their timings")
+
+	return cmd
+}
+
+// perfResult is the comparison of a query on the base and the deploy
+// request branch.
+type perfResult struct {
+	Query  string `header:"query" json:"query"`
+	Before string `header:"before" json:"before"`
+	After  string `header:"after" json:"after"`
+	Status string `header:"status" json:"status"`
+	Reason string `header:"reason,n/a" json:"reason,omitempty"`
+}
+
+// readQuerySample returns a query of each of the n most frequent
+// fingerprints of the statement history that can be explained.
+func readQuerySample(ctx context.Context, db *sql.DB, n int) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT sql_text
+FROM performance_schema.events_statements_history_long
+WHERE current_schema = DATABASE() AND sql_text IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("the statement history isn't available: %s", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	examples := make(map[string]string)
+	for rows.Next() {
+		var text string
+		if err := rows.Scan(&text); err != nil {
+			return nil, err
+		}
+		if !explainable(text) {
+			continue
+		}
+
+		fp := sqlfmt.Fingerprint(text)
+		if _, ok := examples[fp]; !ok {
+			examples[fp] = text
+		}
+		counts[fp]++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	fingerprints := make([]string, 0, len(counts))
+	for fp := range counts {
+		fingerprints = append(fingerprints, fp)
+	}
+	sort.Slice(fingerprints, func(i, j int) bool {
+		if counts[fingerprints[i]] != counts[fingerprints[j]] {
+			return counts[fingerprints[i]] > counts[fingerprints[j]]
+		}
+		return fingerprints[i] < fingerprints[j]
+	})
+	if len(fingerprints) > n {
+		fingerprints = fingerprints[:n]
+	}
+
+	queries := make([]string, 0, len(fingerprints))
+	for _, fp := range fingerprints {
+		queries = append(queries, examples[fp])
+	}
+	return queries, nil
+}
+
+// explainable reports whether the statement is a query EXPLAIN supports.
+// Truncated statements, ending in "...", are left out.
+func explainable(stmt string) bool {
+	stmt = strings.TrimSpace(stmt)
+	if strings.HasSuffix(stmt, "...") {
+		return false
+	}
+
+	verb := strings.ToUpper(strings.SplitN(stmt, " ", 2)[0])
+	return verb == "SELECT" || verb == "UPDATE" || verb == "DELETE"
+}
+
+// checkQuery explains, and with execute runs, the query on both branches and
+// compares the outcome. Queries failing on the base branch are skipped.
+func checkQuery(ctx context.Context, base, head *sql.DB, query string, threshold float64, execute bool) (*perfResult, error) {
+	r := &perfResult{Query: sqlfmt.Fingerprint(query), Status: "ok"}
+
+	before, err := explainQuery(ctx, base, query)
+	if err != nil {
+		r.Status, r.Reason = "skipped", fmt.Sprintf("explain failed on the base branch: %s", err)
+		return r, nil
+	}
+	r.Before = planSummary(before)
+
+	after, err := explainQuery(ctx, head, query)
+	if err != nil {
+		r.Status, r.Reason = "failed", err.Error()
+		return r, nil
+	}
+	r.After = planSummary(after)
+
+	if reason := comparePlans(before, after, threshold); reason != "" {
+		r.Status, r.Reason = "worse", reason
+		return r, nil
+	}
+
+	if !execute || !strings.EqualFold(strings.SplitN(strings.TrimSpace(query), " ", 2)[0], "SELECT") {
+		return r, nil
+	}
+
+	beforeTime, err := timeQuery(ctx, base, query)
+	if err != nil {
+		return r, nil
+	}
+	afterTime, err := timeQuery(ctx, head, query)
+	if err != nil {
+		r.Status, r.Reason = "failed", err.Error()
+		return r, nil
+	}
+	r.Before += fmt.Sprintf(" %s", beforeTime.Round(time.Microsecond))
+	r.After += fmt.Sprintf(" %s", afterTime.Round(time.Microsecond))
+	if float64(afterTime) > float64(beforeTime)*threshold {
+		r.Status, r.Reason = "worse", fmt.Sprintf("took %s instead of %s",
+			afterTime.Round(time.Microsecond), beforeTime.Round(time.Microsecond))
+	}
+	return r, nil
+}
+
+// planStep is how EXPLAIN accesses a table.
+type planStep struct {
+	table      string
+	accessType string
+	key        string
+	rows       int64
+}
+
+// explainQuery returns the steps of the plan of the query.
+func explainQuery(ctx context.Context, db *sql.DB, query string) ([]planStep, error) {
+	rows, err := db.QueryContext(ctx, "EXPLAIN "+query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var steps []planStep
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		var step planStep
+		for i, c := range columns {
+			switch strings.ToLower(c) {
+			case "table":
+				step.table = values[i].String
+			case "type":
+				step.accessType = values[i].String
+			case "key":
+				step.key = values[i].String
+			case "rows":
+				step.rows, _ = strconv.ParseInt(values[i].String, 10, 64)
+			}
+		}
+		steps = append(steps, step)
+	}
+	return steps, rows.Err()
+}
+
+// timeQuery returns how long running the query, and reading its rows, took.
+func timeQuery(ctx context.Context, db *sql.DB, query string) (time.Duration, error) {
+	start := time.Now()
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+	}
+	return time.Since(start), rows.Err()
+}
+
+// accessTypeRanks orders the access types of EXPLAIN from the best to the
+// worst. Unknown types rank as 0 and are never flagged.
+var accessTypeRanks = map[string]int{
+	"system":          1,
+	"const":           2,
+	"eq_ref":          3,
+	"ref":             4,
+	"fulltext":        5,
+	"ref_or_null":     6,
+	"unique_subquery": 7,
+	"index_subquery":  8,
+	"range":           9,
+	"index_merge":     10,
+	"index":           11,
+	"ALL":             12,
+}
+
+// comparePlans returns why the plan after is worse than the plan before, or
+// "" if it isn't.
+func comparePlans(before, after []planStep, threshold float64) string {
+	var rowsBefore, rowsAfter int64
+	for _, s := range before {
+		rowsBefore += s.rows
+	}
+	for _, s := range after {
+		rowsAfter += s.rows
+	}
+
+	for _, a := range after {
+		for _, b := range before {
+			if a.table != b.table {
+				continue
+			}
+
+			ra, rb := accessTypeRanks[a.accessType], accessTypeRanks[b.accessType]
+			if ra > 0 && rb > 0 && ra > rb {
+				return fmt.Sprintf("%s is accessed by %s instead of %s", a.table, a.accessType, b.accessType)
+			}
+			if b.key != "" && a.key == "" {
+				return fmt.Sprintf("%s no longer uses index %s", a.table, b.key)
+			}
+			break
+		}
+	}
+
+	if rowsBefore > 0 && float64(rowsAfter) > float64(rowsBefore)*threshold {
+		return fmt.Sprintf("estimated rows grew from %d to %d", rowsBefore, rowsAfter)
+	}
+	return ""
+}
+
+// planSummary returns the steps of the plan in a line, such as
+// "users:ref(idx_email) orders:ALL".
+func planSummary(steps []planStep) string {
+	parts := make([]string, 0, len(steps))
+	for _, s := range steps {
+		if s.table == "" {
+			continue
+		}
+
+		part := s.table + ":" + s.accessType
+		if s.key != "" {
+			part += "(" + s.key + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
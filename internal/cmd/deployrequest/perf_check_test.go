@@ -0,0 +1,74 @@
+package deployrequest
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestComparePlans(t *testing.T) {
+	before := []planStep{
+		{table: "users", accessType: "ref", key: "idx_email", rows: 1},
+		{table: "orders", accessType: "ref", key: "idx_user", rows: 10},
+	}
+
+	tests := []struct {
+		name   string
+		after  []planStep
+		reason string
+	}{
+		{
+			name:  "same",
+			after: before,
+		},
+		{
+			name: "full scan",
+			after: []planStep{
+				{table: "users", accessType: "ALL", rows: 1000},
+				{table: "orders", accessType: "ref", key: "idx_user", rows: 10},
+			},
+			reason: "users is accessed by ALL instead of ref",
+		},
+		{
+			name: "other index",
+			after: []planStep{
+				{table: "users", accessType: "ref", key: "idx_email", rows: 1},
+				{table: "orders", accessType: "ref", key: "idx_user_created", rows: 12},
+			},
+		},
+		{
+			name: "more rows",
+			after: []planStep{
+				{table: "users", accessType: "ref", key: "idx_email", rows: 1},
+				{table: "orders", accessType: "ref", key: "idx_status", rows: 40},
+			},
+			reason: "estimated rows grew from 11 to 41",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+			c.Assert(comparePlans(before, tt.after, 2), qt.Equals, tt.reason)
+		})
+	}
+}
+
+func TestPlanSummary(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert(planSummary([]planStep{
+		{table: "users", accessType: "ref", key: "idx_email"},
+		{table: "orders", accessType: "ALL"},
+		{accessType: ""},
+	}), qt.Equals, "users:ref(idx_email) orders:ALL")
+}
+
+func TestExplainable(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert(explainable("select * from users"), qt.IsTrue)
+	c.Assert(explainable(" DELETE FROM logs WHERE id = 1"), qt.IsTrue)
+	c.Assert(explainable("INSERT INTO logs VALUES (1)"), qt.IsFalse)
+	c.Assert(explainable("SELECT * FROM users WHERE id IN (1, 2, 3, ..."), qt.IsFalse)
+}
@@ -1,31 +1,46 @@
 package deployrequest
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"strconv"
+	"strings"
 
 	"github.com/planetscale/cli/internal/cmdutil"
 	"github.com/planetscale/cli/internal/printer"
 	"github.com/planetscale/planetscale-go/planetscale"
 
+	"github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/cobra"
 )
 
+// errReviewCanceled is returned when the interactive review is aborted.
+var errReviewCanceled = errors.New("review canceled")
+
 // ReviewCmd is the command for reviewing (approve, comment, etc.) a deploy
 // request.
 func ReviewCmd(ch *cmdutil.Helper) *cobra.Command {
 	var flags struct {
-		approve bool
-		comment string
+		approve     bool
+		comment     string
+		interactive bool
 	}
 
 	cmd := &cobra.Command{
-		Use:   "review <database> <number>",
-		Short: "Review a deploy request (approve, comment, etc...)",
-		Args:  cmdutil.RequiredArgs("database", "number"),
+		Use:               "review <database> <number>",
+		Short:             "Review a deploy request (approve, comment, etc...)",
+		Args:              cmdutil.RequiredArgs("database", "number"),
+		ValidArgsFunction: cmdutil.DeployRequestCompletion(ch),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if !flags.approve && flags.comment == "" {
+			if flags.interactive {
+				if flags.approve || flags.comment != "" {
+					return errors.New("--interactive can't be used together with --approve or --comment")
+				}
+
+				if !printer.IsTTY || ch.Printer.Format() != printer.Human {
+					return errors.New("--interactive requires a terminal and the human output format")
+				}
+			} else if !flags.approve && flags.comment == "" {
 				return errors.New("neither --approve nor --comment is set")
 			}
 
@@ -33,12 +48,12 @@ func ReviewCmd(ch *cmdutil.Helper) *cobra.Command {
 			database := args[0]
 			number := args[1]
 
-			n, err := strconv.ParseUint(number, 10, 64)
+			client, err := ch.Client()
 			if err != nil {
-				return fmt.Errorf("the argument <number> is invalid: %s", err)
+				return err
 			}
 
-			client, err := ch.Client()
+			n, err := cmdutil.ResolveDeployRequest(ctx, client, ch.Config.Organization, database, number)
 			if err != nil {
 				return err
 			}
@@ -48,12 +63,24 @@ func ReviewCmd(ch *cmdutil.Helper) *cobra.Command {
 				action = planetscale.ReviewApprove
 			}
 
+			comment := flags.comment
+			if flags.interactive {
+				action, comment, err = interactiveReview(ctx, ch, client, database, n)
+				if err == errReviewCanceled {
+					ch.Printer.Println("Review canceled, nothing was submitted.")
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
 			drr, err := client.DeployRequests.CreateReview(ctx, &planetscale.ReviewDeployRequestRequest{
 				Organization: ch.Config.Organization,
 				Database:     database,
 				Number:       n,
 				ReviewAction: action,
-				CommentText:  flags.comment,
+				CommentText:  comment,
 			})
 			if err != nil {
 				switch cmdutil.ErrCode(err) {
@@ -84,6 +111,135 @@ func ReviewCmd(ch *cmdutil.Helper) *cobra.Command {
 
 	cmd.PersistentFlags().BoolVar(&flags.approve, "approve", false, "Approve a deploy request")
 	cmd.PersistentFlags().StringVar(&flags.comment, "comment", "", "Comment on a deploy request")
+	cmd.PersistentFlags().BoolVar(&flags.interactive, "interactive", false,
+		"Review the diff of each table one by one and submit the accumulated review at the end")
 
 	return cmd
 }
+
+// tableReview is the review of a single table of a deploy request.
+type tableReview struct {
+	table    string
+	approved bool
+	comment  string
+}
+
+// interactiveReview presents the diff of each table and asks whether to
+// approve, comment or skip it. It returns the review action and comment to
+// submit.
+func interactiveReview(ctx context.Context, ch *cmdutil.Helper, client *planetscale.Client, database string, number uint64) (planetscale.ReviewAction, string, error) {
+	diffs, err := client.DeployRequests.Diff(ctx, &planetscale.DiffRequest{
+		Organization: ch.Config.Organization,
+		Database:     database,
+		Number:       number,
+	})
+	if err != nil {
+		switch cmdutil.ErrCode(err) {
+		case planetscale.ErrNotFound:
+			return 0, "", fmt.Errorf("deploy request '%s/%d' does not exist in organization %s",
+				printer.BoldBlue(database), number, printer.BoldBlue(ch.Config.Organization))
+		default:
+			return 0, "", cmdutil.HandleError(err)
+		}
+	}
+
+	if len(diffs) == 0 {
+		return 0, "", errors.New("the deploy request doesn't contain any schema changes")
+	}
+
+	reviews := make([]*tableReview, 0, len(diffs))
+	for i, df := range diffs {
+		if err := printDiff(ch, df); err != nil {
+			return 0, "", err
+		}
+		ch.Printer.Println()
+
+		var choice string
+		err := survey.AskOne(&survey.Select{
+			Message: fmt.Sprintf("Table %s (%d/%d):", df.Name, i+1, len(diffs)),
+			Options: []string{"approve", "comment", "skip", "quit"},
+		}, &choice)
+		if err != nil {
+			return 0, "", err
+		}
+
+		r := &tableReview{table: df.Name}
+		switch choice {
+		case "approve":
+			r.approved = true
+		case "comment":
+			if err := survey.AskOne(&survey.Multiline{
+				Message: fmt.Sprintf("Comment on %s:", df.Name),
+			}, &r.comment); err != nil {
+				return 0, "", err
+			}
+		case "quit":
+			return 0, "", errReviewCanceled
+		}
+		reviews = append(reviews, r)
+	}
+
+	body := reviewBody(reviews)
+
+	defaultAction := "comment"
+	if allApproved(reviews) {
+		defaultAction = "approve"
+	}
+
+	var submit string
+	err = survey.AskOne(&survey.Select{
+		Message: "Submit review:",
+		Options: []string{"approve", "comment", "cancel"},
+		Default: defaultAction,
+	}, &submit)
+	if err != nil {
+		return 0, "", err
+	}
+
+	switch submit {
+	case "approve":
+		return planetscale.ReviewApprove, body, nil
+	case "comment":
+		if body == "" {
+			return 0, "", errors.New("the review doesn't contain any comments")
+		}
+		return planetscale.ReviewComment, body, nil
+	default:
+		return 0, "", errReviewCanceled
+	}
+}
+
+// reviewBody returns the review comment accumulated from the table reviews.
+func reviewBody(reviews []*tableReview) string {
+	var approved, comments []string
+	for _, r := range reviews {
+		if r.approved {
+			approved = append(approved, fmt.Sprintf("`%s`", r.table))
+		}
+		if c := strings.TrimSpace(r.comment); c != "" {
+			comments = append(comments, fmt.Sprintf("`%s`: %s", r.table, c))
+		}
+	}
+
+	// a review approving every table doesn't need to list them
+	if len(comments) == 0 && len(approved) == len(reviews) {
+		return ""
+	}
+
+	var parts []string
+	if len(approved) > 0 {
+		parts = append(parts, "Approved tables: "+strings.Join(approved, ", "))
+	}
+	parts = append(parts, comments...)
+
+	return strings.Join(parts, "\n\n")
+}
+
+func allApproved(reviews []*tableReview) bool {
+	for _, r := range reviews {
+		if !r.approved {
+			return false
+		}
+	}
+	return len(reviews) > 0
+}
@@ -67,3 +67,18 @@ func TestDeployRequest_ReviewCmd(t *testing.T) {
 
 	c.Assert(buf.String(), qt.JSONEquals, res)
 }
+
+func TestDeployRequest_ReviewBody(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert(reviewBody([]*tableReview{
+		{table: "users", approved: true},
+		{table: "posts", approved: true},
+	}), qt.Equals, "")
+
+	c.Assert(reviewBody([]*tableReview{
+		{table: "users", approved: true},
+		{table: "posts", comment: "please add an index on author_id\n"},
+		{table: "tags"},
+	}), qt.Equals, "Approved tables: `users`\n\n`posts`: please add an index on author_id")
+}
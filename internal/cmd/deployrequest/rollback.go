@@ -0,0 +1,59 @@
+package deployrequest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/config"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+// RollbackID returns the ID of the rollback manifest of a deploy request
+// deployed with 'pscale deploy-request deploy --save-rollback'.
+func RollbackID(database string, number uint64) string {
+	return fmt.Sprintf("deploy-%s-%d", database, number)
+}
+
+// SaveRollback saves the manifest for rolling back the deployment of the
+// deploy request, before it's deployed. It records the schema of the branch
+// deployed to, the passwords to delete are listed by the rollback.
+func SaveRollback(ctx context.Context, client *ps.Client, id, org, database string, dr *ps.DeployRequest, verify []*config.RollbackQuery) (*config.Rollback, error) {
+	schema, err := BranchSchema(ctx, client, org, database, dr.IntoBranch)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &config.Rollback{
+		ID:           id,
+		Organization: org,
+		Database:     database,
+		Number:       dr.Number,
+		CreatedAt:    time.Now().UTC(),
+		Branch:       dr.IntoBranch,
+		Schema:       schema,
+		OpenedAt:     dr.CreatedAt,
+		Verify:       verify,
+	}
+	return r, r.Save()
+}
+
+// BranchSchema returns the schema of the branch as CREATE TABLE statements.
+func BranchSchema(ctx context.Context, client *ps.Client, org, database, branch string) (string, error) {
+	schemas, err := client.DatabaseBranches.Schema(ctx, &ps.BranchSchemaRequest{
+		Organization: org,
+		Database:     database,
+		Branch:       branch,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, s := range schemas {
+		b.WriteString(strings.TrimSuffix(strings.TrimSpace(s.Raw), ";"))
+		b.WriteString(";\n\n")
+	}
+	return b.String(), nil
+}
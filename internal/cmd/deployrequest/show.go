@@ -2,7 +2,6 @@ package deployrequest
 
 import (
 	"fmt"
-	"strconv"
 
 	"github.com/pkg/browser"
 	"github.com/planetscale/cli/internal/cmdutil"
@@ -19,9 +18,10 @@ func ShowCmd(ch *cmdutil.Helper) *cobra.Command {
 	}
 
 	cmd := &cobra.Command{
-		Use:   "show <database> <number>",
-		Short: "Show a specific deploy request",
-		Args:  cmdutil.RequiredArgs("database", "number"),
+		Use:               "show <database> <number>",
+		Short:             "Show a specific deploy request",
+		Args:              cmdutil.RequiredArgs("database", "number"),
+		ValidArgsFunction: cmdutil.DeployRequestCompletion(ch),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			database := args[0]
@@ -37,9 +37,9 @@ func ShowCmd(ch *cmdutil.Helper) *cobra.Command {
 				return err
 			}
 
-			n, err := strconv.ParseUint(number, 10, 64)
+			n, err := cmdutil.ResolveDeployRequest(ctx, client, ch.Config.Organization, database, number)
 			if err != nil {
-				return fmt.Errorf("the argument <number> is invalid: %s", err)
+				return err
 			}
 
 			dr, err := client.DeployRequests.Get(ctx, &planetscale.GetDeployRequestRequest{
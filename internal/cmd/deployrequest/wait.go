@@ -0,0 +1,239 @@
+package deployrequest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+// pollInterval is the interval deploy requests are initially polled with
+// while waiting, it backs off up to maxPollInterval while the state doesn't
+// change. It's replaced in tests.
+var (
+	pollInterval    = 2 * time.Second
+	maxPollInterval = 30 * time.Second
+)
+
+// statusOut is where state changes are streamed to while waiting.
+var statusOut io.Writer = os.Stderr
+
+// defaultWaitTimeout is how long a deployment is waited for by default.
+const defaultWaitTimeout = 30 * time.Minute
+
+// finishedStates are the states of a deployment that's not going to change
+// anymore.
+var finishedStates = map[string]bool{
+	"complete":        true,
+	"complete_error":  true,
+	"complete_cancel": true,
+	"cancelled":       true,
+	"error":           true,
+	"no_changes":      true,
+}
+
+// WaitCmd is the command for waiting until a deploy request is deployed.
+func WaitCmd(ch *cmdutil.Helper) *cobra.Command {
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:               "wait <database> <number>",
+		Short:             "Wait until a deploy request is deployed",
+		Args:              cmdutil.RequiredArgs("database", "number"),
+		ValidArgsFunction: cmdutil.DeployRequestCompletion(ch),
+		Long: `Wait until a deploy request is deployed.
+
+State changes are written to stderr. The command fails if the deployment
+failed or was cancelled, if the deploy request has schema conflicts or was
+closed without being deployed, or if the timeout is exceeded. This allows CI
+pipelines to gate on a deployment:
+
+  pscale deploy-request create mydb my-branch
+  pscale deploy-request deploy mydb 7
+  pscale deploy-request wait mydb 7 --timeout 1h`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			database := args[0]
+			number := args[1]
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			n, err := cmdutil.ResolveDeployRequest(cmd.Context(), client, ch.Config.Organization, database, number)
+			if err != nil {
+				return err
+			}
+
+			return waitAndReport(cmd.Context(), ch, client, database, n, timeout)
+		},
+	}
+
+	cmd.Flags().DurationVar(&timeout, "timeout", defaultWaitTimeout, "Fail if the deployment didn't finish within this duration")
+	return cmd
+}
+
+// waitAndReport waits for the deployment of the deploy request and prints
+// it. An error is returned if the deployment didn't succeed.
+func waitAndReport(ctx context.Context, ch *cmdutil.Helper, client *ps.Client, database string, number uint64, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	end := ch.Printer.PrintProgress(fmt.Sprintf("Waiting for deploy request %s/%s to be deployed...",
+		printer.BoldBlue(database), printer.BoldBlue(number)))
+	defer end()
+
+	op := config.NewOperation(config.OperationDeploy, ch.Config.Organization, database, "", number)
+	dr, err := waitDeployment(ctx, client, op, func(dr *ps.DeployRequest) {
+		fmt.Fprintf(statusOut, "%s deploy request %s/%d: %s\n",
+			time.Now().Format(time.RFC3339), database, number, DeploymentState(dr))
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded) || ctx.Err() == context.DeadlineExceeded:
+			return fmt.Errorf("deploy request %s/%d wasn't deployed within %s, resume waiting with 'pscale resume %s'",
+				database, number, timeout, op.ID)
+		case cmdutil.ErrCode(err) == ps.ErrNotFound:
+			return fmt.Errorf("deploy request '%s/%d' does not exist in organization %s",
+				printer.BoldBlue(database), number, printer.BoldBlue(ch.Config.Organization))
+		default:
+			return cmdutil.HandleError(err)
+		}
+	}
+	end()
+
+	if err := DeploymentError(dr); err != nil {
+		if ch.Printer.Format() != printer.Human {
+			_ = ch.Printer.PrintResource(toDeployRequest(dr))
+		}
+		return err
+	}
+
+	if ch.Printer.Format() == printer.Human {
+		ch.Printer.Printf("Deployment of %s from %s to %s finished with state %s.\n",
+			dr.ID, dr.Branch, dr.IntoBranch, printer.BoldBlue(DeploymentState(dr)))
+		return nil
+	}
+
+	return ch.Printer.PrintResource(toDeployRequest(dr))
+}
+
+// DeploymentError returns an error if the deployment of the finished deploy
+// request didn't succeed.
+func DeploymentError(dr *ps.DeployRequest) error {
+	d := dr.Deployment
+	if d == nil {
+		return fmt.Errorf("deploy request %d has no deployment", dr.Number)
+	}
+
+	if conflicts := schemaConflicts(d); len(conflicts) != 0 {
+		return fmt.Errorf("deploy request %d can't be deployed because of schema conflicts:\n\n%s",
+			dr.Number, strings.Join(conflicts, "\n"))
+	}
+
+	switch d.State {
+	case "complete", "no_changes":
+		return nil
+	case "complete_cancel", "cancelled":
+		return fmt.Errorf("deployment of deploy request %d was cancelled", dr.Number)
+	case "complete_error", "error":
+		return fmt.Errorf("deployment of deploy request %d failed with state %s", dr.Number, d.State)
+	}
+
+	if dr.State == "closed" {
+		return fmt.Errorf("deploy request %d was closed without being deployed", dr.Number)
+	}
+
+	return fmt.Errorf("deployment of deploy request %d stopped with state %s", dr.Number, d.State)
+}
+
+// schemaConflicts returns the lint errors preventing the deployment.
+func schemaConflicts(d *ps.Deployment) []string {
+	if d.Deployable || d.State == "pending" {
+		return nil
+	}
+
+	conflicts := make([]string, 0, len(d.LintErrors))
+	for _, e := range d.LintErrors {
+		conflicts = append(conflicts, "• "+e.ErrorDescription)
+	}
+	return conflicts
+}
+
+// WaitDeployment polls the deploy request until its deployment finished. The
+// operation is persisted while waiting, so an interrupted wait can be resumed
+// with 'pscale resume'.
+func WaitDeployment(ctx context.Context, client *ps.Client, op *config.Operation) (*ps.DeployRequest, error) {
+	return waitDeployment(ctx, client, op, nil)
+}
+
+// waitDeployment is WaitDeployment calling onChange whenever the state of
+// the deploy request changed.
+func waitDeployment(ctx context.Context, client *ps.Client, op *config.Operation, onChange func(*ps.DeployRequest)) (*ps.DeployRequest, error) {
+	_ = op.Save() // resuming is best-effort
+
+	interval := pollInterval
+	var last string
+
+	for {
+		dr, err := client.DeployRequests.Get(ctx, &ps.GetDeployRequestRequest{
+			Organization: op.Organization,
+			Database:     op.Database,
+			Number:       op.Number,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if state := dr.State + "/" + DeploymentState(dr); state != last {
+			last = state
+			interval = pollInterval
+			if onChange != nil {
+				onChange(dr)
+			}
+		} else {
+			interval *= 2
+			if interval > maxPollInterval {
+				interval = maxPollInterval
+			}
+		}
+
+		if DeploymentFinished(dr) {
+			_ = op.Done()
+			return dr, nil
+		}
+
+		t := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// DeploymentFinished returns whether the deployment of the deploy request
+// isn't going to change anymore.
+func DeploymentFinished(dr *ps.DeployRequest) bool {
+	d := dr.Deployment
+	return d == nil || d.FinishedAt != nil || finishedStates[d.State] || dr.State == "closed" || len(schemaConflicts(d)) != 0
+}
+
+// DeploymentState returns the state of the deployment of the deploy request.
+func DeploymentState(dr *ps.DeployRequest) string {
+	if dr.Deployment == nil {
+		return dr.State
+	}
+	return dr.Deployment.State
+}
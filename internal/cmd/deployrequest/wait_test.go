@@ -0,0 +1,145 @@
+package deployrequest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+func TestDeployRequest_WaitCmd(t *testing.T) {
+	testutil.TempHome(t)
+
+	defer func(d time.Duration) { pollInterval = d }(pollInterval)
+	pollInterval = time.Millisecond
+
+	tests := []struct {
+		name    string
+		states  []*ps.Deployment
+		drState string
+		err     string
+	}{
+		{
+			name: "complete",
+			states: []*ps.Deployment{
+				{State: "queued", Deployable: true},
+				{State: "in_progress", Deployable: true},
+				{State: "complete", Deployable: true},
+			},
+		},
+		{
+			name: "error",
+			states: []*ps.Deployment{
+				{State: "in_progress", Deployable: true},
+				{State: "complete_error", Deployable: true},
+			},
+			err: "deployment of deploy request 7 failed with state complete_error",
+		},
+		{
+			name: "schema conflict",
+			states: []*ps.Deployment{
+				{State: "pending"},
+				{State: "ready", LintErrors: []*ps.DeploymentLintError{{ErrorDescription: "table foo has no primary key"}}},
+			},
+			err: "deploy request 7 can't be deployed because of schema conflicts:\n\n• table foo has no primary key",
+		},
+		{
+			name:    "closed",
+			states:  []*ps.Deployment{{State: "ready", Deployable: true}},
+			drState: "closed",
+			err:     "deploy request 7 was closed without being deployed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+
+			var status bytes.Buffer
+			defer func(w io.Writer) { statusOut = w }(statusOut)
+			statusOut = &status
+
+			var buf bytes.Buffer
+			format := printer.JSON
+			p := printer.NewPrinter(&format)
+			p.SetResourceOutput(&buf)
+
+			i := 0
+			svc := &mock.DeployRequestsService{
+				GetFn: func(ctx context.Context, req *ps.GetDeployRequestRequest) (*ps.DeployRequest, error) {
+					c.Assert(req.Number, qt.Equals, uint64(7))
+
+					d := tt.states[i]
+					if i < len(tt.states)-1 {
+						i++
+					}
+
+					state := tt.drState
+					if state == "" {
+						state = "open"
+					}
+					return &ps.DeployRequest{Number: 7, State: state, Deployment: d}, nil
+				},
+			}
+
+			ch := &cmdutil.Helper{
+				Printer: p,
+				Config:  &config.Config{Organization: "planetscale"},
+				Client: func() (*ps.Client, error) {
+					return &ps.Client{DeployRequests: svc}, nil
+				},
+			}
+
+			cmd := WaitCmd(ch)
+			cmd.SetArgs([]string{"mydb", "7"})
+			err := cmd.Execute()
+			if tt.err != "" {
+				c.Assert(err, qt.ErrorMatches, tt.err)
+			} else {
+				c.Assert(err, qt.IsNil)
+			}
+
+			c.Assert(bytes.Count(status.Bytes(), []byte("\n")), qt.Equals, len(tt.states))
+		})
+	}
+}
+
+func TestDeployRequest_WaitCmd_Timeout(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	defer func(d time.Duration) { pollInterval = d }(pollInterval)
+	pollInterval = time.Millisecond
+	defer func(w io.Writer) { statusOut = w }(statusOut)
+	statusOut = &bytes.Buffer{}
+
+	format := printer.JSON
+	svc := &mock.DeployRequestsService{
+		GetFn: func(ctx context.Context, req *ps.GetDeployRequestRequest) (*ps.DeployRequest, error) {
+			return &ps.DeployRequest{Number: 7, State: "open", Deployment: &ps.Deployment{State: "in_progress", Deployable: true}}, nil
+		},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer: printer.NewPrinter(&format),
+		Config:  &config.Config{Organization: "planetscale"},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{DeployRequests: svc}, nil
+		},
+	}
+
+	cmd := WaitCmd(ch)
+	cmd.SetArgs([]string{"mydb", "7", "--timeout", "20ms"})
+	err := cmd.Execute()
+	c.Assert(err, qt.ErrorMatches, `deploy request mydb/7 wasn't deployed within 20ms.*`)
+}
@@ -0,0 +1,59 @@
+package edit
+
+import (
+	"errors"
+	"os"
+	"runtime"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+
+	"github.com/mattn/go-shellwords"
+	"github.com/spf13/cobra"
+	exec "golang.org/x/sys/execabs"
+)
+
+// EditCmd encapsulates the commands for editing resources in an editor.
+func EditCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "edit <command>",
+		Short:             "Edit resources in your editor",
+		PersistentPreRunE: cmdutil.CheckAuthentication(ch.Config),
+	}
+
+	cmd.PersistentFlags().StringVar(&ch.Config.Organization, "org", ch.Config.Organization, "The organization for the current user")
+	cmd.MarkPersistentFlagRequired("org") // nolint:errcheck
+
+	cmd.AddCommand(SchemaCmd(ch))
+
+	return cmd
+}
+
+// runEditor opens the file at the given path in the editor defined by
+// $VISUAL or $EDITOR and waits until it's closed.
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	args, err := shellwords.Parse(editor)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errors.New("the editor command is empty, please set $EDITOR")
+	}
+
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
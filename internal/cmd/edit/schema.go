@@ -0,0 +1,283 @@
+package edit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+	"github.com/planetscale/cli/internal/schemadiff"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+	"github.com/planetscale/sql-proxy/proxy"
+
+	"github.com/AlecAivazis/survey/v2"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/spf13/cobra"
+)
+
+// SchemaCmd is the command for editing the schema of a branch in an editor.
+func SchemaCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		dryRun bool
+		force  bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "schema <database> <branch> [table]",
+		Short: "Edit the schema of a development branch in your editor",
+		Long: `Edit the schema of a development branch in your editor.
+
+The CREATE TABLE statements of the branch, or of a single table, are opened in
+$EDITOR. Once the editor is closed, the changes are shown together with the DDL
+statements that apply them, which are executed on the branch after
+confirmation. Removing a statement drops the table, adding one creates it.`,
+		Args: cobra.RangeArgs(2, 3),
+		Example: `Edit the users table of the dev branch:
+
+  pscale edit schema mydb dev users
+
+Only show the DDL statements without applying them:
+
+  pscale edit schema mydb dev --dry-run`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+			table := ""
+			if len(args) == 3 {
+				table = args[2]
+			}
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			dbBranch, err := client.DatabaseBranches.Get(ctx, &ps.GetDatabaseBranchRequest{
+				Organization: ch.Config.Organization,
+				Database:     database,
+				Branch:       branch,
+			})
+			if err != nil {
+				switch cmdutil.ErrCode(err) {
+				case ps.ErrNotFound:
+					return fmt.Errorf("branch %s does not exist in database %s (organization: %s)",
+						printer.BoldBlue(branch), printer.BoldBlue(database), printer.BoldBlue(ch.Config.Organization))
+				default:
+					return cmdutil.HandleError(err)
+				}
+			}
+
+			if dbBranch.Production {
+				return fmt.Errorf("branch %s is a production branch, edit a development branch and open a deploy request instead",
+					printer.BoldBlue(branch))
+			}
+
+			if !dbBranch.Ready {
+				return errors.New("database branch is not ready yet, please try again in a few minutes")
+			}
+
+			schemas, err := client.DatabaseBranches.Schema(ctx, &ps.BranchSchemaRequest{
+				Organization: ch.Config.Organization,
+				Database:     database,
+				Branch:       branch,
+			})
+			if err != nil {
+				return cmdutil.HandleError(err)
+			}
+
+			original, err := schemaFile(schemas, table)
+			if err != nil {
+				return err
+			}
+
+			edited, err := editSchema(original)
+			if err != nil {
+				return err
+			}
+
+			lines := schemadiff.Lines(original, edited)
+			if !schemadiff.HasChanges(lines) {
+				ch.Printer.Println("No changes were made to the schema.")
+				return nil
+			}
+
+			stmts, err := schemadiff.Statements(original, edited)
+			if err != nil {
+				return fmt.Errorf("couldn't generate DDL from the edited schema: %s", err)
+			}
+
+			if len(stmts) == 0 {
+				ch.Printer.Println("The edited schema doesn't contain any changes.")
+				return nil
+			}
+
+			if ch.Printer.Format() == printer.Human {
+				var b strings.Builder
+				schemadiff.WriteUnified(&b, lines, 3)
+				ch.Printer.Println(printer.Bold("Changes:"))
+				ch.Printer.Println(b.String())
+				ch.Printer.Println(printer.Bold("DDL:"))
+				for _, stmt := range stmts {
+					ch.Printer.Printf("%s;\n", stmt)
+				}
+				ch.Printer.Println()
+			}
+
+			if flags.dryRun {
+				if ch.Printer.Format() != printer.Human {
+					return ch.Printer.PrintResource(map[string]interface{}{"statements": stmts, "applied": false})
+				}
+				return nil
+			}
+
+			if !flags.force && !ch.Config.AutoApproves(dbBranch.Production) {
+				if !printer.IsTTY || ch.Printer.Format() != printer.Human {
+					return errors.New("cannot confirm applying the schema changes (run with -force to override)")
+				}
+
+				confirmed := false
+				prompt := &survey.Confirm{
+					Message: fmt.Sprintf("Apply the changes to branch %s?", printer.BoldBlue(branch)),
+				}
+				if err := survey.AskOne(prompt, &confirmed); err != nil {
+					return err
+				}
+
+				if !confirmed {
+					return errors.New("schema changes were not applied")
+				}
+			}
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Applying schema changes to %s", printer.BoldBlue(branch)))
+			defer end()
+
+			if err := applyStatements(ctx, ch, client, database, branch, stmts); err != nil {
+				return err
+			}
+
+			end()
+
+			if ch.Printer.Format() == printer.Human {
+				ch.Printer.Printf("Schema changes were successfully applied to branch %s.\n", printer.BoldBlue(branch))
+				return nil
+			}
+
+			return ch.Printer.PrintResource(map[string]interface{}{"statements": stmts, "applied": true})
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "Show the DDL statements without applying them")
+	cmd.Flags().BoolVar(&flags.force, "force", false, "Apply the changes without confirmation")
+
+	return cmd
+}
+
+// schemaFile returns the content of the file that is opened in the editor.
+func schemaFile(schemas []*ps.Diff, table string) (string, error) {
+	var b strings.Builder
+	for _, s := range schemas {
+		if table != "" && s.Name != table {
+			continue
+		}
+
+		b.WriteString(strings.TrimSuffix(strings.TrimSpace(s.Raw), ";"))
+		b.WriteString(";\n\n")
+	}
+
+	if table != "" && b.Len() == 0 {
+		return "", fmt.Errorf("table %s does not exist in the branch schema", printer.BoldBlue(table))
+	}
+
+	return b.String(), nil
+}
+
+// editSchema opens the given schema in the editor and returns the edited
+// content.
+func editSchema(schema string) (string, error) {
+	f, err := ioutil.TempFile("", "pscale-schema-*.sql")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(schema); err != nil {
+		f.Close()
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	if err := runEditor(f.Name()); err != nil {
+		return "", fmt.Errorf("editor exited with an error: %s", err)
+	}
+
+	out, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+// applyStatements executes the statements on the branch through a local
+// proxy with an administrator certificate.
+func applyStatements(ctx context.Context, ch *cmdutil.Helper, client *ps.Client, database, branch string, stmts []string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	remoteAddr, err := proxyutil.RemoteAddr(ctx, ch.Config, client, database, branch, "")
+	if err != nil {
+		return cmdutil.HandleError(err)
+	}
+
+	p, err := proxy.NewClient(proxy.Options{
+		CertSource: proxyutil.NewRemoteCertSource(client, cmdutil.AdministratorRole),
+		LocalAddr:  "127.0.0.1:0",
+		RemoteAddr: remoteAddr,
+		Instance:   fmt.Sprintf("%s/%s/%s", ch.Config.Organization, database, branch),
+		Logger:     cmdutil.NewZapLogger(ch.Debug()),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't create proxy client: %s", err)
+	}
+
+	go func() {
+		if err := p.Run(ctx); err != nil && ch.Debug() {
+			ch.Printer.Println("proxy error: ", err)
+		}
+	}()
+
+	addr, err := p.LocalAddr()
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/%s", addr.String(), database))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, stmt := range stmts {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to apply %q: %s", firstLine(stmt), err)
+		}
+	}
+
+	return nil
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
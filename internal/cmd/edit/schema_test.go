@@ -0,0 +1,103 @@
+package edit
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/printer"
+
+	qt "github.com/frankban/quicktest"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+func TestEdit_SchemaCmd_DryRun(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	org := "planetscale"
+	db := "planetscale"
+	branch := "dev"
+
+	svc := &mock.DatabaseBranchesService{
+		GetFn: func(ctx context.Context, req *ps.GetDatabaseBranchRequest) (*ps.DatabaseBranch, error) {
+			c.Assert(req.Branch, qt.Equals, branch)
+			return &ps.DatabaseBranch{Name: branch, Ready: true}, nil
+		},
+		SchemaFn: func(ctx context.Context, req *ps.BranchSchemaRequest) ([]*ps.Diff, error) {
+			return []*ps.Diff{
+				{Name: "users", Raw: "CREATE TABLE `users` (\n  `id` bigint NOT NULL,\n  `email` varchar(255),\n  PRIMARY KEY (`id`)\n)"},
+				{Name: "posts", Raw: "CREATE TABLE `posts` (\n  `id` bigint NOT NULL,\n  PRIMARY KEY (`id`)\n)"},
+			}, nil
+		},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config: &config.Config{
+			Organization: org,
+		},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{
+				DatabaseBranches: svc,
+			}, nil
+		},
+	}
+
+	old, ok := os.LookupEnv("VISUAL")
+	os.Setenv("VISUAL", `sh -c 'sed "s/varchar(255)/varchar(320) NOT NULL/" "$0" > "$0.tmp" && mv "$0.tmp" "$0"'`)
+	defer func() {
+		if ok {
+			os.Setenv("VISUAL", old)
+		} else {
+			os.Unsetenv("VISUAL")
+		}
+	}()
+
+	cmd := SchemaCmd(ch)
+	cmd.SetArgs([]string{db, branch, "users", "--dry-run"})
+	err := cmd.Execute()
+	c.Assert(err, qt.IsNil)
+	c.Assert(svc.SchemaFnInvoked, qt.IsTrue)
+
+	res := map[string]interface{}{
+		"statements": []string{"ALTER TABLE `users`\n  MODIFY COLUMN `email` varchar(320) NOT NULL"},
+		"applied":    false,
+	}
+	c.Assert(buf.String(), qt.JSONEquals, res)
+}
+
+func TestEdit_SchemaCmd_ProductionBranch(t *testing.T) {
+	c := qt.New(t)
+
+	format := printer.Human
+	p := printer.NewPrinter(&format)
+
+	svc := &mock.DatabaseBranchesService{
+		GetFn: func(ctx context.Context, req *ps.GetDatabaseBranchRequest) (*ps.DatabaseBranch, error) {
+			return &ps.DatabaseBranch{Name: req.Branch, Ready: true, Production: true}, nil
+		},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config:  &config.Config{Organization: "planetscale"},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{DatabaseBranches: svc}, nil
+		},
+	}
+
+	cmd := SchemaCmd(ch)
+	cmd.SetArgs([]string{"planetscale", "main"})
+	err := cmd.Execute()
+	c.Assert(err, qt.ErrorMatches, ".*is a production branch.*")
+	c.Assert(svc.SchemaFnInvoked, qt.IsFalse)
+}
@@ -0,0 +1,197 @@
+package insights
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/expr"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+
+	"github.com/spf13/cobra"
+)
+
+// minBaseline is the number of intervals of the baseline a metric needs to
+// be compared with.
+const minBaseline = 3
+
+// AnomaliesCmd flags the queries whose latency or throughput shifted
+// significantly from their baseline.
+func AnomaliesCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		window    string
+		interval  string
+		threshold float64
+	}
+
+	cmd := &cobra.Command{
+		Use:   "anomalies <database> <branch>",
+		Short: "Flag the queries whose latency or throughput shifted significantly",
+		Long: `Flag the queries whose latency or throughput shifted significantly.
+
+The executions within --window are read from the statement history of
+performance_schema, grouped by their fingerprint and split in intervals of
+--interval. The 95th percentile latency and the throughput of the latest
+interval are compared with the mean and the standard deviation of the earlier
+ones, the baseline, and a metric is flagged if it's more than --threshold
+standard deviations away from it. Use --format json to feed the anomalies to
+an alerting pipeline.`,
+		Args: cmdutil.RequiredArgs("database", "branch"),
+		Example: `  pscale insights anomalies mydb main --window 7d
+  pscale insights anomalies mydb main --window 24h --interval 15m --format json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+
+			window, err := expr.ParseDuration(flags.window)
+			if err != nil {
+				return fmt.Errorf("invalid --window: %s", err)
+			}
+			interval, err := expr.ParseDuration(flags.interval)
+			if err != nil {
+				return fmt.Errorf("invalid --interval: %s", err)
+			}
+			if interval <= 0 || window < interval*(minBaseline+1) {
+				return fmt.Errorf("--window must be at least %d times --interval", minBaseline+1)
+			}
+
+			db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.ReaderRole)
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			now := time.Now()
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Reading the recent queries of %s", printer.BoldBlue(branch)))
+			executions, err := readExecutions(ctx, db, now.Add(-window))
+			end()
+			if err != nil {
+				return err
+			}
+
+			anomalies := detectAnomalies(executions, now, window, interval, flags.threshold)
+			if len(anomalies) == 0 && ch.Printer.Format() == printer.Human {
+				ch.Printer.Printf("No anomalies were found on branch %s in the last %s.\n", printer.BoldBlue(branch), flags.window)
+				return nil
+			}
+
+			return ch.Printer.PrintResource(anomalies)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.window, "window", "7d", "The duration of the executions to analyze, such as 7d")
+	cmd.Flags().StringVar(&flags.interval, "interval", "1h", "The duration of the intervals compared with each other")
+	cmd.Flags().Float64Var(&flags.threshold, "threshold", 3, "The number of standard deviations a metric must shift by")
+
+	return cmd
+}
+
+// anomaly is a metric of a query that shifted from its baseline. The latency
+// is in milliseconds and the throughput in queries per minute.
+type anomaly struct {
+	Fingerprint string  `header:"fingerprint" json:"fingerprint"`
+	Metric      string  `header:"metric" json:"metric"`
+	Baseline    float64 `header:"baseline" json:"baseline"`
+	Current     float64 `header:"current" json:"current"`
+	Change      float64 `header:"change %" json:"change_percent"`
+	ZScore      float64 `header:"z-score" json:"z_score"`
+}
+
+// detectAnomalies compares the p95 latency and the throughput of each query
+// in the latest interval with the earlier intervals of the window, the most
+// significant shifts first.
+func detectAnomalies(executions []*execution, now time.Time, window, interval time.Duration, threshold float64) []*anomaly {
+	intervals := int(window / interval)
+
+	// the durations of each query by interval, the latest one first
+	byFingerprint := make(map[string][][]float64)
+	for _, e := range executions {
+		i := int(now.Sub(e.at) / interval)
+		if i < 0 || i >= intervals {
+			continue
+		}
+		if _, ok := byFingerprint[e.fingerprint]; !ok {
+			byFingerprint[e.fingerprint] = make([][]float64, intervals)
+		}
+		byFingerprint[e.fingerprint][i] = append(byFingerprint[e.fingerprint][i], e.duration)
+	}
+
+	anomalies := make([]*anomaly, 0)
+	for fp, durations := range byFingerprint {
+		var latencies, throughputs []float64
+		for _, ds := range durations[1:] {
+			throughputs = append(throughputs, float64(len(ds))/interval.Minutes())
+			if len(ds) > 0 {
+				sort.Float64s(ds)
+				latencies = append(latencies, cmdutil.Percentile(ds, 95))
+			}
+		}
+
+		if current := durations[0]; len(current) > 0 && len(latencies) >= minBaseline {
+			sort.Float64s(current)
+			if a := compare(latencies, cmdutil.Percentile(current, 95), threshold); a != nil {
+				a.Fingerprint, a.Metric = fp, "p95 latency (ms)"
+				anomalies = append(anomalies, a)
+			}
+		}
+
+		current := float64(len(durations[0])) / interval.Minutes()
+		if a := compare(throughputs, current, threshold); a != nil {
+			a.Fingerprint, a.Metric = fp, "throughput (qpm)"
+			anomalies = append(anomalies, a)
+		}
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool {
+		zi, zj := math.Abs(anomalies[i].ZScore), math.Abs(anomalies[j].ZScore)
+		if zi != zj {
+			return zi > zj
+		}
+		if anomalies[i].Fingerprint != anomalies[j].Fingerprint {
+			return anomalies[i].Fingerprint < anomalies[j].Fingerprint
+		}
+		return anomalies[i].Metric < anomalies[j].Metric
+	})
+	return anomalies
+}
+
+// compare returns an anomaly if the current value is at least threshold
+// standard deviations away from the mean of the baseline, or nil. The
+// standard deviation is at least 5% of the mean, so the slightest change of a
+// steady metric isn't flagged.
+func compare(baseline []float64, current, threshold float64) *anomaly {
+	var mean float64
+	for _, v := range baseline {
+		mean += v
+	}
+	mean /= float64(len(baseline))
+	if mean == 0 {
+		// the query is new, it has no baseline
+		return nil
+	}
+
+	var variance float64
+	for _, v := range baseline {
+		variance += (v - mean) * (v - mean)
+	}
+	stddev := math.Max(math.Sqrt(variance/float64(len(baseline))), mean*0.05)
+
+	z := (current - mean) / stddev
+	if math.Abs(z) < threshold {
+		return nil
+	}
+
+	return &anomaly{
+		Baseline: round(mean),
+		Current:  round(current),
+		Change:   round((current - mean) / mean * 100),
+		ZScore:   round(z),
+	}
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
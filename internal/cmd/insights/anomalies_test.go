@@ -0,0 +1,43 @@
+package insights
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestDetectAnomalies(t *testing.T) {
+	c := qt.New(t)
+
+	now := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	var executions []*execution
+	add := func(fingerprint string, hoursAgo, count int, duration float64) {
+		for i := 0; i < count; i++ {
+			executions = append(executions, &execution{
+				at:          now.Add(-time.Duration(hoursAgo)*time.Hour - time.Minute),
+				fingerprint: fingerprint,
+				duration:    duration,
+			})
+		}
+	}
+
+	for h := 1; h <= 5; h++ {
+		add("select * from users where id = ?", h, 60, 10)
+		add("select * from orders", h, 60, 5)
+		add("select * from stable", h, 60, float64(10+h%2))
+	}
+	// users became slower, orders stopped being queried
+	add("select * from users where id = ?", 0, 60, 80)
+	add("select * from stable", 0, 60, 10)
+	// new queries have no baseline
+	add("select * from new", 0, 600, 10)
+	// executions outside of the window are ignored
+	add("select * from stable", 30, 6000, 1000)
+
+	c.Assert(detectAnomalies(executions, now, 6*time.Hour, time.Hour, 3), qt.DeepEquals, []*anomaly{
+		{Fingerprint: "select * from users where id = ?", Metric: "p95 latency (ms)", Baseline: 10, Current: 80, Change: 700, ZScore: 140},
+		{Fingerprint: "select * from orders", Metric: "throughput (qpm)", Baseline: 1, Current: 0, Change: -100, ZScore: -20},
+	})
+}
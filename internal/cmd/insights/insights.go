@@ -0,0 +1,42 @@
+package insights
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+
+	"github.com/spf13/cobra"
+)
+
+// InsightsCmd encapsulates the commands analyzing how a branch is used.
+func InsightsCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "insights <command>",
+		Short:             "Analyze how the schema of a branch is used",
+		PersistentPreRunE: cmdutil.CheckAuthentication(ch.Config),
+	}
+
+	cmd.PersistentFlags().StringVar(&ch.Config.Organization, "org", ch.Config.Organization,
+		"The organization for the current user")
+	cmd.MarkPersistentFlagRequired("org") // nolint:errcheck
+
+	cmd.AddCommand(AnomaliesCmd(ch))
+	cmd.AddCommand(SlowQueriesCmd(ch))
+	cmd.AddCommand(UnusedIndexesCmd(ch))
+	cmd.AddCommand(WatchCmd(ch))
+
+	return cmd
+}
+
+// readUptime returns how long the server has been running, which is the
+// time the statistics of performance_schema cover.
+func readUptime(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	var name string
+	var uptime int64
+	if err := db.QueryRowContext(ctx, "SHOW GLOBAL STATUS LIKE 'Uptime'").Scan(&name, &uptime); err != nil {
+		return 0, err
+	}
+	return time.Duration(uptime) * time.Second, nil
+}
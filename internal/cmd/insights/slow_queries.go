@@ -0,0 +1,199 @@
+package insights
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+	"github.com/planetscale/cli/internal/sqlfmt"
+
+	"github.com/spf13/cobra"
+)
+
+// SlowQueriesCmd encapsulates the commands for the slow queries of a branch.
+func SlowQueriesCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "slow-queries <command>",
+		Short: "Export and aggregate the slow queries of a branch",
+	}
+
+	cmd.AddCommand(SlowQueriesExportCmd(ch))
+
+	return cmd
+}
+
+// SlowQueriesExportCmd exports the slow queries of a branch, or a digest of
+// them.
+func SlowQueriesExportCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		since       time.Duration
+		minDuration time.Duration
+		digest      bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "export <database> <branch>",
+		Short: "Export the slow queries of a branch",
+		Long: `Export the slow queries of a branch.
+
+The statements that took longer than --min-duration, by default the
+long_query_time of the server, are read from the statement history of
+performance_schema, which holds the latest statements of the server. Use
+--format json or csv to save them.
+
+With --digest, the queries are aggregated locally by their fingerprint, the
+query with its literals replaced by ?, and shown with their count and latency
+percentiles, the ones taking the most time in total first.`,
+		Args: cmdutil.RequiredArgs("database", "branch"),
+		Example: `  pscale insights slow-queries export mydb main --since 24h --format json > slow.json
+  pscale insights slow-queries export mydb main --min-duration 100ms --digest`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+
+			db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.ReaderRole)
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Reading the slow queries of %s", printer.BoldBlue(branch)))
+			queries, err := readSlowQueries(ctx, db, time.Now().Add(-flags.since), flags.minDuration)
+			end()
+			if err != nil {
+				return err
+			}
+
+			if len(queries) == 0 && ch.Printer.Format() == printer.Human {
+				ch.Printer.Printf("No slow queries were found on branch %s in the last %s.\n", printer.BoldBlue(branch), flags.since)
+				return nil
+			}
+
+			if flags.digest {
+				return ch.Printer.PrintResource(digestQueries(queries))
+			}
+			return ch.Printer.PrintResource(queries)
+		},
+	}
+
+	cmd.Flags().DurationVar(&flags.since, "since", 24*time.Hour, "Export the queries that started within this duration")
+	cmd.Flags().DurationVar(&flags.minDuration, "min-duration", 0,
+		"Export the queries that took at least this duration. By default the long_query_time of the server")
+	cmd.Flags().BoolVar(&flags.digest, "digest", false, "Aggregate the queries by their fingerprint")
+
+	return cmd
+}
+
+// slowQuery is a statement that took longer than the threshold.
+type slowQuery struct {
+	Time         int64   `header:"time,timestamp(ms|utc|human)" json:"time"`
+	Duration     float64 `header:"duration (ms)" json:"duration_ms"`
+	RowsExamined int64   `header:"rows examined" json:"rows_examined"`
+	RowsSent     int64   `header:"rows sent" json:"rows_sent"`
+	Query        string  `header:"query" json:"query"`
+}
+
+// readSlowQueries returns the statements of the database in the statement
+// history that started after since and took at least minDuration, or the
+// long_query_time of the server if it's 0, the latest first.
+func readSlowQueries(ctx context.Context, db *sql.DB, since time.Time, minDuration time.Duration) ([]*slowQuery, error) {
+	if minDuration == 0 {
+		var seconds float64
+		if err := db.QueryRowContext(ctx, "SELECT @@long_query_time").Scan(&seconds); err != nil {
+			return nil, err
+		}
+		minDuration = time.Duration(seconds * float64(time.Second))
+	}
+
+	uptime, err := readUptime(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	started := time.Now().Add(-uptime)
+
+	// the timers are in picoseconds, starting with the server
+	rows, err := db.QueryContext(ctx, `SELECT timer_start, timer_wait, rows_examined, rows_sent, sql_text
+FROM performance_schema.events_statements_history_long
+WHERE current_schema = DATABASE() AND sql_text IS NOT NULL AND timer_wait >= ?
+ORDER BY timer_start DESC`, minDuration.Nanoseconds()*1000)
+	if err != nil {
+		return nil, fmt.Errorf("the statement history isn't available: %s", err)
+	}
+	defer rows.Close()
+
+	queries := make([]*slowQuery, 0)
+	for rows.Next() {
+		var start, wait uint64
+		q := &slowQuery{}
+		if err := rows.Scan(&start, &wait, &q.RowsExamined, &q.RowsSent, &q.Query); err != nil {
+			return nil, err
+		}
+
+		at := started.Add(time.Duration(start / 1000))
+		if at.Before(since) {
+			continue
+		}
+		q.Time = at.UnixNano() / int64(time.Millisecond)
+		q.Duration = float64(wait) / 1e9
+		queries = append(queries, q)
+	}
+	return queries, rows.Err()
+}
+
+// queryDigest aggregates the queries with the same fingerprint. The latencies
+// are in milliseconds.
+type queryDigest struct {
+	Fingerprint  string  `header:"fingerprint" json:"fingerprint"`
+	Count        int     `header:"count" json:"count"`
+	Total        float64 `header:"total (ms)" json:"total_ms"`
+	P50          float64 `header:"p50" json:"p50_ms"`
+	P95          float64 `header:"p95" json:"p95_ms"`
+	P99          float64 `header:"p99" json:"p99_ms"`
+	Max          float64 `header:"max" json:"max_ms"`
+	RowsExamined int64   `header:"rows examined" json:"rows_examined"`
+}
+
+// digestQueries aggregates the queries by their fingerprint, the ones taking
+// the most time in total first.
+func digestQueries(queries []*slowQuery) []*queryDigest {
+	durations := make(map[string][]float64)
+	digests := make(map[string]*queryDigest)
+	for _, q := range queries {
+		fp := sqlfmt.Fingerprint(q.Query)
+		d, ok := digests[fp]
+		if !ok {
+			d = &queryDigest{Fingerprint: fp}
+			digests[fp] = d
+		}
+		d.Count++
+		d.Total += q.Duration
+		d.RowsExamined += q.RowsExamined
+		durations[fp] = append(durations[fp], q.Duration)
+	}
+
+	result := make([]*queryDigest, 0, len(digests))
+	for fp, d := range digests {
+		ds := durations[fp]
+		sort.Float64s(ds)
+		d.P50 = cmdutil.Percentile(ds, 50)
+		d.P95 = cmdutil.Percentile(ds, 95)
+		d.P99 = cmdutil.Percentile(ds, 99)
+		d.Max = ds[len(ds)-1]
+		d.Total = math.Round(d.Total*1000) / 1000
+		result = append(result, d)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Total != result[j].Total {
+			return result[i].Total > result[j].Total
+		}
+		return result[i].Fingerprint < result[j].Fingerprint
+	})
+	return result
+}
@@ -0,0 +1,26 @@
+package insights
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestDigestQueries(t *testing.T) {
+	c := qt.New(t)
+
+	var queries []*slowQuery
+	for i := 1; i <= 10; i++ {
+		queries = append(queries, &slowQuery{
+			Duration:     float64(i * 100),
+			RowsExamined: 10,
+			Query:        "SELECT * FROM users WHERE id = " + string(rune('0'+i%10)),
+		})
+	}
+	queries = append(queries, &slowQuery{Duration: 2000, RowsExamined: 5, Query: "DELETE FROM logs WHERE id IN (1, 2)"})
+
+	c.Assert(digestQueries(queries), qt.DeepEquals, []*queryDigest{
+		{Fingerprint: "select * from users where id = ?", Count: 10, Total: 5500, P50: 500, P95: 1000, P99: 1000, Max: 1000, RowsExamined: 100},
+		{Fingerprint: "delete from logs where id in (?+)", Count: 1, Total: 2000, P50: 2000, P95: 2000, P99: 2000, Max: 2000, RowsExamined: 5},
+	})
+}
@@ -0,0 +1,240 @@
+package insights
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+
+	"github.com/spf13/cobra"
+)
+
+// UnusedIndexesCmd lists the indexes that weren't used since the server
+// started.
+func UnusedIndexesCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		generateDrops bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "unused-indexes <database> <branch>",
+		Short: "List the indexes that were never used",
+		Long: `List the indexes that were never used.
+
+The index usage statistics of performance_schema are correlated with the
+indexes of the schema. The statistics cover the time since the server started,
+which is shown, so indexes only used by rare queries, such as monthly reports,
+may be listed. Primary keys are never listed.
+
+With --generate-drops, the statements dropping the unused indexes are printed
+for review, they're never applied. Unique indexes are left out, as they
+enforce constraints even if no query uses them.`,
+		Args: cmdutil.RequiredArgs("database", "branch"),
+		Example: `  pscale insights unused-indexes mydb main
+  pscale insights unused-indexes mydb main --generate-drops`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+
+			db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.ReaderRole)
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Reading the index usage of %s", printer.BoldBlue(branch)))
+			stats, err := readIndexStats(ctx, db)
+			end()
+			if err != nil {
+				return err
+			}
+
+			unused := stats.unused()
+			if flags.generateDrops {
+				stmts := dropStatements(unused)
+				if ch.Printer.Format() != printer.Human {
+					return ch.Printer.PrintResource(map[string]interface{}{"statements": stmts, "applied": false})
+				}
+
+				if len(stmts) == 0 {
+					ch.Printer.Println("There are no unused indexes to drop.")
+					return nil
+				}
+				ch.Printer.Printf("-- Review before applying: the statistics cover the last %s.\n", stats.uptime)
+				for _, stmt := range stmts {
+					ch.Printer.Printf("%s;\n", stmt)
+				}
+				return nil
+			}
+
+			if ch.Printer.Format() != printer.Human {
+				return ch.Printer.PrintResource(unused)
+			}
+
+			ch.Printer.Printf("Index usage since the server started %s ago.\n\n", stats.uptime)
+			if len(unused) == 0 {
+				ch.Printer.Printf("All indexes of branch %s were used.\n", printer.BoldBlue(branch))
+				return nil
+			}
+			return ch.Printer.PrintResource(unused)
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.generateDrops, "generate-drops", false,
+		"Print the statements dropping the unused indexes, which aren't unique, for review")
+
+	return cmd
+}
+
+// indexKey identifies an index by its table and name.
+type indexKey struct {
+	table string
+	index string
+}
+
+type index struct {
+	indexKey
+	columns string
+	unique  bool
+}
+
+// indexStats are the indexes of a database with their usage.
+type indexStats struct {
+	indexes []*index
+
+	// used is the number of reads, writes and deletes through each index,
+	// indexes of tables that weren't accessed are missing
+	used map[indexKey]uint64
+
+	// sizes of the indexes in bytes, if they're available
+	sizes map[indexKey]int64
+
+	uptime time.Duration
+}
+
+// unusedIndex is an index that wasn't used.
+type unusedIndex struct {
+	Table     string `header:"table" json:"table"`
+	Index     string `header:"index" json:"index"`
+	Columns   string `header:"columns" json:"columns"`
+	Unique    bool   `header:"unique" json:"unique"`
+	Size      string `header:"size,n/a" json:"-"`
+	SizeBytes int64  `json:"size_bytes,omitempty"`
+}
+
+// readIndexStats reads the indexes of the database, other than primary keys,
+// and their usage. The sizes of the indexes are only read if they're
+// accessible.
+func readIndexStats(ctx context.Context, db *sql.DB) (*indexStats, error) {
+	stats := &indexStats{
+		used:  make(map[indexKey]uint64),
+		sizes: make(map[indexKey]int64),
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT table_name, index_name, MIN(non_unique),
+  GROUP_CONCAT(column_name ORDER BY seq_in_index SEPARATOR ', ')
+FROM information_schema.statistics
+WHERE table_schema = DATABASE() AND index_name <> 'PRIMARY'
+GROUP BY table_name, index_name
+ORDER BY table_name, index_name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		idx := &index{}
+		var nonUnique int
+		if err := rows.Scan(&idx.table, &idx.index, &nonUnique, &idx.columns); err != nil {
+			return nil, err
+		}
+		idx.unique = nonUnique == 0
+		stats.indexes = append(stats.indexes, idx)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.QueryContext(ctx, `SELECT object_name, index_name, count_star
+FROM performance_schema.table_io_waits_summary_by_index_usage
+WHERE object_schema = DATABASE() AND index_name IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("the index usage statistics aren't available: %s", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key indexKey
+		var count uint64
+		if err := rows.Scan(&key.table, &key.index, &count); err != nil {
+			return nil, err
+		}
+		stats.used[key] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if stats.uptime, err = readUptime(ctx, db); err != nil {
+		return nil, err
+	}
+
+	// the size is the number of pages of the index
+	rows, err = db.QueryContext(ctx, `SELECT table_name, index_name, stat_value * @@innodb_page_size
+FROM mysql.innodb_index_stats
+WHERE database_name = DATABASE() AND stat_name = 'size'`)
+	if err != nil {
+		return stats, nil
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key indexKey
+		var size int64
+		if err := rows.Scan(&key.table, &key.index, &size); err != nil {
+			return nil, err
+		}
+		stats.sizes[key] = size
+	}
+	return stats, rows.Err()
+}
+
+// unused returns the indexes that weren't used.
+func (s *indexStats) unused() []*unusedIndex {
+	unused := make([]*unusedIndex, 0)
+	for _, idx := range s.indexes {
+		if s.used[idx.indexKey] > 0 {
+			continue
+		}
+
+		u := &unusedIndex{
+			Table:   idx.table,
+			Index:   idx.index,
+			Columns: idx.columns,
+			Unique:  idx.unique,
+		}
+		if size, ok := s.sizes[idx.indexKey]; ok {
+			u.Size = printer.Bytes(size)
+			u.SizeBytes = size
+		}
+		unused = append(unused, u)
+	}
+	return unused
+}
+
+// dropStatements returns the statements dropping the unused indexes that
+// aren't unique.
+func dropStatements(unused []*unusedIndex) []string {
+	stmts := make([]string, 0, len(unused))
+	for _, u := range unused {
+		if u.Unique {
+			continue
+		}
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s DROP INDEX %s", cmdutil.QuoteIdent(u.Table), cmdutil.QuoteIdent(u.Index)))
+	}
+	return stmts
+}
@@ -0,0 +1,39 @@
+package insights
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestIndexStats_Unused(t *testing.T) {
+	c := qt.New(t)
+
+	stats := &indexStats{
+		indexes: []*index{
+			{indexKey: indexKey{"orders", "idx_status"}, columns: "status"},
+			{indexKey: indexKey{"orders", "idx_user"}, columns: "user_id, created_at"},
+			{indexKey: indexKey{"users", "email"}, columns: "email", unique: true},
+			{indexKey: indexKey{"users", "idx_name"}, columns: "name"},
+		},
+		used: map[indexKey]uint64{
+			{"orders", "idx_status"}: 0,
+			{"orders", "idx_user"}:   1024,
+		},
+		sizes: map[indexKey]int64{
+			{"orders", "idx_status"}: 16384,
+		},
+	}
+
+	unused := stats.unused()
+	c.Assert(unused, qt.DeepEquals, []*unusedIndex{
+		{Table: "orders", Index: "idx_status", Columns: "status", Size: "16.4 kB", SizeBytes: 16384},
+		{Table: "users", Index: "email", Columns: "email", Unique: true},
+		{Table: "users", Index: "idx_name", Columns: "name"},
+	})
+
+	c.Assert(dropStatements(unused), qt.DeepEquals, []string{
+		"ALTER TABLE `orders` DROP INDEX `idx_status`",
+		"ALTER TABLE `users` DROP INDEX `idx_name`",
+	})
+}
@@ -0,0 +1,397 @@
+package insights
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+	"github.com/planetscale/cli/internal/sqlfmt"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// regressionExitCode is the exit code if any watched query regressed, so CI
+// checks can tell it from failures.
+const regressionExitCode = 2
+
+// WatchCmd checks the watched queries of the project for regressions.
+func WatchCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		since   time.Duration
+		webhook string
+	}
+
+	cmd := &cobra.Command{
+		Use:   "watch <database> <branch>",
+		Short: "Check the watched queries for latency and error rate regressions",
+		Long: fmt.Sprintf(`Check the watched queries for latency and error rate regressions.
+
+The watched queries are kept in the project configuration file, so they can be
+shared and checked in CI, and are managed with the add, list and remove
+subcommands. The executions of each query within --since are read from the
+statement history of performance_schema. If the 95th percentile latency or the
+error rate of any query exceeds its thresholds, the command exits with status
+%d, after posting the regressions as JSON to the --webhook URL, if any.`, regressionExitCode),
+		Args: cmdutil.RequiredArgs("database", "branch"),
+		Example: `Check the queries after a deployment:
+
+  pscale insights watch mydb main --since 15m --webhook https://example.com/hooks/pscale`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+
+			watch, err := watchedQueries(ch.ConfigFS.ProjectConfig)
+			if err != nil {
+				return err
+			}
+			if len(watch) == 0 {
+				return errors.New("no queries are watched, add one with 'pscale insights watch add'")
+			}
+
+			db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.ReaderRole)
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Reading the recent queries of %s", printer.BoldBlue(branch)))
+			executions, err := readExecutions(ctx, db, time.Now().Add(-flags.since))
+			end()
+			if err != nil {
+				return err
+			}
+
+			results := checkWatched(watch, executions)
+			if err := ch.Printer.PrintResource(results); err != nil {
+				return err
+			}
+
+			var regressed []*watchResult
+			for _, r := range results {
+				if r.Status == "regressed" {
+					regressed = append(regressed, r)
+				}
+			}
+			if len(regressed) == 0 {
+				return nil
+			}
+
+			if flags.webhook != "" {
+				err := cmdutil.PostWebhook(ctx, flags.webhook, map[string]interface{}{
+					"organization": ch.Config.Organization,
+					"database":     database,
+					"branch":       branch,
+					"regressions":  regressed,
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			return &cmdutil.Error{
+				Msg:      fmt.Sprintf("%d watched query(s) regressed", len(regressed)),
+				ExitCode: regressionExitCode,
+			}
+		},
+	}
+
+	cmd.Flags().DurationVar(&flags.since, "since", time.Hour, "Check the executions within this duration")
+	cmd.Flags().StringVar(&flags.webhook, "webhook", "", "Post the regressions as JSON to this URL")
+
+	cmd.AddCommand(WatchAddCmd(ch))
+	cmd.AddCommand(WatchListCmd(ch))
+	cmd.AddCommand(WatchRemoveCmd(ch))
+
+	return cmd
+}
+
+// WatchAddCmd adds a query to the watched queries of the project.
+func WatchAddCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		name         string
+		maxP95       time.Duration
+		maxErrorRate float64
+	}
+
+	cmd := &cobra.Command{
+		Use:   "add <query>",
+		Short: "Watch a query, or the fingerprint of a query",
+		Long: `Watch a query, or the fingerprint of a query.
+
+The query is watched by its fingerprint, so a query with literals matches all
+of its executions with different values. Fingerprints printed by 'pscale
+insights slow-queries export --digest' can be added as they are.`,
+		Args: cmdutil.RequiredArgs("query"),
+		Example: `  pscale insights watch add "SELECT * FROM users WHERE email = 'a@example.com'" --name user-by-email --max-p95 50ms
+  pscale insights watch add "select * from orders where user_id in (?+)" --max-error-rate 1`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.maxP95 == 0 && flags.maxErrorRate == 0 {
+				return errors.New("at least one of --max-p95 and --max-error-rate is required")
+			}
+
+			watch, err := watchedQueries(ch.ConfigFS.RawProjectConfig)
+			if err != nil {
+				return err
+			}
+
+			w := &config.WatchedQuery{
+				Fingerprint:  sqlfmt.Fingerprint(args[0]),
+				Name:         flags.name,
+				MaxP95:       flags.maxP95,
+				MaxErrorRate: flags.maxErrorRate,
+			}
+			for _, existing := range watch {
+				if existing.Fingerprint == w.Fingerprint || (w.Name != "" && existing.Name == w.Name) {
+					return fmt.Errorf("query %s is already watched", printer.BoldBlue(watchName(existing)))
+				}
+			}
+
+			if err := writeWatchedQueries(ch, append(watch, w)); err != nil {
+				return err
+			}
+
+			if ch.Printer.Format() != printer.Human {
+				return ch.Printer.PrintResource(w)
+			}
+			ch.Printer.Printf("Query %s is watched.\n", printer.BoldBlue(watchName(w)))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.name, "name", "", "The name of the query in the output")
+	cmd.Flags().DurationVar(&flags.maxP95, "max-p95", 0, "The 95th percentile latency the query may not exceed")
+	cmd.Flags().Float64Var(&flags.maxErrorRate, "max-error-rate", 0, "The percentage of executions that may fail")
+
+	return cmd
+}
+
+// WatchListCmd lists the watched queries of the project.
+func WatchListCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List the watched queries",
+		Args:    cobra.NoArgs,
+		Aliases: []string{"ls"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			watch, err := watchedQueries(ch.ConfigFS.ProjectConfig)
+			if err != nil {
+				return err
+			}
+
+			if len(watch) == 0 && ch.Printer.Format() == printer.Human {
+				ch.Printer.Println("No queries are watched.")
+				return nil
+			}
+
+			watched := make([]*watchedQuery, 0, len(watch))
+			for _, w := range watch {
+				watched = append(watched, &watchedQuery{
+					Name:         w.Name,
+					Fingerprint:  w.Fingerprint,
+					MaxP95:       w.MaxP95.String(),
+					MaxErrorRate: w.MaxErrorRate,
+				})
+			}
+			return ch.Printer.PrintResource(watched)
+		},
+	}
+
+	return cmd
+}
+
+// WatchRemoveCmd removes a query from the watched queries of the project.
+func WatchRemoveCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove <name|query>",
+		Short: "Stop watching a query",
+		Args:  cmdutil.RequiredArgs("name"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			watch, err := watchedQueries(ch.ConfigFS.RawProjectConfig)
+			if err != nil {
+				return err
+			}
+
+			fingerprint := sqlfmt.Fingerprint(args[0])
+			for i, w := range watch {
+				if w.Name != args[0] && w.Fingerprint != fingerprint {
+					continue
+				}
+
+				if err := writeWatchedQueries(ch, append(watch[:i:i], watch[i+1:]...)); err != nil {
+					return err
+				}
+				ch.Printer.Printf("Query %s is no longer watched.\n", printer.BoldBlue(watchName(w)))
+				return nil
+			}
+
+			return fmt.Errorf("query %s isn't watched", printer.BoldBlue(args[0]))
+		},
+	}
+
+	return cmd
+}
+
+// watchedQueries returns the watched queries of the project configuration
+// read with read, or none if the project has no configuration. Commands
+// changing them read the configuration with ConfigFS.RawProjectConfig, so
+// they're written back as they are and not with their variables
+// interpolated.
+func watchedQueries(read func() (*config.FileConfig, error)) ([]*config.WatchedQuery, error) {
+	cfg, err := read()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Watch, nil
+}
+
+// writeWatchedQueries writes the watched queries to the project
+// configuration and keeps its other values. A new configuration is written
+// for the organization if the project has none.
+func writeWatchedQueries(ch *cmdutil.Helper, watch []*config.WatchedQuery) error {
+	cfgFile, err := config.ProjectConfigPath()
+	if err != nil {
+		return err
+	}
+
+	values := yaml.MapSlice{{Key: "watch", Value: watch}}
+	if len(watch) == 0 {
+		values[0].Value = nil
+	}
+	if _, err := os.Stat(cfgFile); os.IsNotExist(err) {
+		values = append(yaml.MapSlice{{Key: "org", Value: ch.Config.Organization}}, values...)
+	}
+
+	return config.SetValues(cfgFile, values)
+}
+
+func watchName(w *config.WatchedQuery) string {
+	if w.Name != "" {
+		return w.Name
+	}
+	return w.Fingerprint
+}
+
+// watchedQuery is a watched query as it's listed.
+type watchedQuery struct {
+	Name         string  `header:"name,n/a" json:"name,omitempty"`
+	Fingerprint  string  `header:"fingerprint" json:"fingerprint"`
+	MaxP95       string  `header:"max p95" json:"max_p95"`
+	MaxErrorRate float64 `header:"max error rate %" json:"max_error_rate"`
+}
+
+// execution is an execution of a statement.
+type execution struct {
+	at          time.Time
+	fingerprint string
+	duration    float64 // in milliseconds
+	failed      bool
+}
+
+// readExecutions returns the executions of the statements of the database in
+// the statement history that started after since.
+func readExecutions(ctx context.Context, db *sql.DB, since time.Time) ([]*execution, error) {
+	uptime, err := readUptime(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	started := time.Now().Add(-uptime)
+
+	// the timers are in picoseconds, starting with the server
+	rows, err := db.QueryContext(ctx, `SELECT timer_start, timer_wait, errors, sql_text
+FROM performance_schema.events_statements_history_long
+WHERE current_schema = DATABASE() AND sql_text IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("the statement history isn't available: %s", err)
+	}
+	defer rows.Close()
+
+	var executions []*execution
+	for rows.Next() {
+		var start, wait, errs uint64
+		var text string
+		if err := rows.Scan(&start, &wait, &errs, &text); err != nil {
+			return nil, err
+		}
+
+		at := started.Add(time.Duration(start / 1000))
+		if at.Before(since) {
+			continue
+		}
+		executions = append(executions, &execution{
+			at:          at,
+			fingerprint: sqlfmt.Fingerprint(text),
+			duration:    float64(wait) / 1e9,
+			failed:      errs > 0,
+		})
+	}
+	return executions, rows.Err()
+}
+
+// watchResult is the outcome of checking a watched query.
+type watchResult struct {
+	Query     string  `header:"query" json:"query"`
+	Count     int     `header:"count" json:"count"`
+	P95       float64 `header:"p95 (ms)" json:"p95_ms"`
+	ErrorRate float64 `header:"error rate %" json:"error_rate"`
+	Status    string  `header:"status" json:"status"`
+	Reason    string  `header:"reason,n/a" json:"reason,omitempty"`
+}
+
+// checkWatched checks the executions of the watched queries against their
+// thresholds. Queries without executions have the status "no data".
+func checkWatched(watched []*config.WatchedQuery, executions []*execution) []*watchResult {
+	byFingerprint := make(map[string][]*execution)
+	for _, e := range executions {
+		byFingerprint[e.fingerprint] = append(byFingerprint[e.fingerprint], e)
+	}
+
+	results := make([]*watchResult, 0, len(watched))
+	for _, w := range watched {
+		r := &watchResult{Query: watchName(w), Status: "ok"}
+		results = append(results, r)
+
+		execs := byFingerprint[w.Fingerprint]
+		if len(execs) == 0 {
+			r.Status = "no data"
+			continue
+		}
+
+		durations := make([]float64, 0, len(execs))
+		failed := 0
+		for _, e := range execs {
+			durations = append(durations, e.duration)
+			if e.failed {
+				failed++
+			}
+		}
+		sort.Float64s(durations)
+		r.Count = len(execs)
+		r.P95 = cmdutil.Percentile(durations, 95)
+		r.ErrorRate = math.Round(float64(failed)/float64(len(execs))*10000) / 100
+
+		maxP95 := float64(w.MaxP95) / float64(time.Millisecond)
+		switch {
+		case w.MaxP95 > 0 && r.P95 > maxP95:
+			r.Status = "regressed"
+			r.Reason = fmt.Sprintf("p95 exceeds %s", w.MaxP95)
+		case w.MaxErrorRate > 0 && r.ErrorRate > w.MaxErrorRate:
+			r.Status = "regressed"
+			r.Reason = fmt.Sprintf("error rate exceeds %g%%", w.MaxErrorRate)
+		}
+	}
+	return results
+}
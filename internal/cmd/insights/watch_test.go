@@ -0,0 +1,88 @@
+package insights
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestCheckWatched(t *testing.T) {
+	c := qt.New(t)
+
+	watched := []*config.WatchedQuery{
+		{Name: "user", Fingerprint: "select * from users where id = ?", MaxP95: 50 * time.Millisecond},
+		{Fingerprint: "delete from logs where id in (?+)", MaxErrorRate: 10},
+		{Fingerprint: "select * from orders", MaxP95: time.Second},
+	}
+
+	var executions []*execution
+	for i := 1; i <= 20; i++ {
+		executions = append(executions, &execution{fingerprint: "select * from users where id = ?", duration: float64(i * 3)})
+	}
+	executions = append(executions,
+		&execution{fingerprint: "delete from logs where id in (?+)", duration: 10},
+		&execution{fingerprint: "delete from logs where id in (?+)", duration: 10, failed: true},
+	)
+
+	c.Assert(checkWatched(watched, executions), qt.DeepEquals, []*watchResult{
+		{Query: "user", Count: 20, P95: 57, Status: "regressed", Reason: "p95 exceeds 50ms"},
+		{Query: "delete from logs where id in (?+)", Count: 2, P95: 10, ErrorRate: 50, Status: "regressed", Reason: "error rate exceeds 10%"},
+		{Query: "select * from orders", Status: "no data"},
+	})
+}
+
+func TestWatchAddRemoveCmd(t *testing.T) {
+	c := qt.New(t)
+
+	// the variables of the project config are written back as they are
+	dir := t.TempDir()
+	project := filepath.Join(dir, ".pscale.yml")
+	c.Assert(ioutil.WriteFile(project, []byte("org: acme\nbranch: ${env:USER}-dev\n"), 0644), qt.IsNil)
+	wd, err := os.Getwd()
+	c.Assert(err, qt.IsNil)
+	c.Assert(os.Chdir(dir), qt.IsNil)
+	c.Cleanup(func() { os.Chdir(wd) }) // nolint:errcheck
+
+	var buf bytes.Buffer
+	format := printer.Human
+	p := printer.NewPrinter(&format)
+	p.SetHumanOutput(&buf)
+
+	ch := &cmdutil.Helper{
+		Printer:  p,
+		Config:   &config.Config{Organization: "acme"},
+		ConfigFS: config.NewConfigFS(testutil.OSFS{}),
+	}
+
+	cmd := WatchAddCmd(ch)
+	cmd.SetArgs([]string{"select * from users where id = 1", "--name", "user", "--max-p95", "50ms"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	out, err := ioutil.ReadFile(project)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, `org: acme
+branch: ${env:USER}-dev
+watch:
+- fingerprint: select * from users where id = ?
+  name: user
+  max-p95: 50ms
+`)
+
+	cmd = WatchRemoveCmd(ch)
+	cmd.SetArgs([]string{"user"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	out, err = ioutil.ReadFile(project)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, "org: acme\nbranch: ${env:USER}-dev\n")
+}
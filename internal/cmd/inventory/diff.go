@@ -0,0 +1,128 @@
+package inventory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/spf13/cobra"
+)
+
+// change is a difference between two snapshots.
+type change struct {
+	Change   string `header:"change" json:"change"`
+	Kind     string `header:"kind" json:"kind"`
+	Resource string `header:"resource" json:"resource"`
+	Details  string `header:"details,n/a" json:"details,omitempty"`
+}
+
+// DiffCmd compares two snapshots.
+func DiffCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <from> <to>",
+		Short: "Show the resources that appeared, disappeared or changed between two snapshots",
+		Long: `Show the resources that appeared, disappeared or changed between two snapshots.
+
+Snapshots are passed by their ID, as listed by 'pscale inventory list', or as
+the path of a snapshot file.`,
+		Args:    cmdutil.RequiredArgs("from", "to"),
+		Example: `  pscale inventory diff acme-20261008T090000Z acme-20261015T090000Z`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, err := readSnapshot(args[0])
+			if err != nil {
+				return err
+			}
+			to, err := readSnapshot(args[1])
+			if err != nil {
+				return err
+			}
+
+			changes := diffSnapshots(from, to)
+			if len(changes) == 0 && ch.Printer.Format() == printer.Human {
+				ch.Printer.Printf("Nothing changed between %s and %s.\n", printer.BoldBlue(from.ID), printer.BoldBlue(to.ID))
+				return nil
+			}
+			return ch.Printer.PrintResource(changes)
+		},
+	}
+
+	return cmd
+}
+
+// diffSnapshots returns the resources that were added, removed or changed
+// from the snapshot from to the snapshot to, ordered by kind and name.
+func diffSnapshots(from, to *snapshot) []*change {
+	type key struct{ kind, name string }
+	before := make(map[key]*resource, len(from.Resources))
+	for _, r := range from.Resources {
+		before[key{r.Kind, r.Name}] = r
+	}
+
+	changes := make([]*change, 0)
+	seen := make(map[key]bool, len(to.Resources))
+	for _, r := range to.Resources {
+		k := key{r.Kind, r.Name}
+		seen[k] = true
+
+		old, ok := before[k]
+		if !ok {
+			changes = append(changes, &change{Change: "added", Kind: r.Kind, Resource: r.Name})
+			continue
+		}
+		if details := attributeChanges(old.Attributes, r.Attributes); details != "" {
+			changes = append(changes, &change{Change: "changed", Kind: r.Kind, Resource: r.Name, Details: details})
+		}
+	}
+
+	for _, r := range from.Resources {
+		if !seen[key{r.Kind, r.Name}] {
+			changes = append(changes, &change{Change: "removed", Kind: r.Kind, Resource: r.Name})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return changes[i].Kind < changes[j].Kind
+		}
+		return changes[i].Resource < changes[j].Resource
+	})
+	return changes
+}
+
+// attributeChanges describes the changed attributes, such as
+// "region: us-east → eu-west".
+func attributeChanges(old, new map[string]string) string {
+	names := make(map[string]bool)
+	for n := range old {
+		names[n] = true
+	}
+	for n := range new {
+		names[n] = true
+	}
+
+	sorted := make([]string, 0, len(names))
+	for n := range names {
+		sorted = append(sorted, n)
+	}
+	sort.Strings(sorted)
+
+	var details []string
+	for _, n := range sorted {
+		o, nv := old[n], new[n]
+		if o == nv {
+			continue
+		}
+		details = append(details, fmt.Sprintf("%s: %s → %s", n, orNone(o), orNone(nv)))
+	}
+	return strings.Join(details, "; ")
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
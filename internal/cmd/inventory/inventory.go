@@ -0,0 +1,33 @@
+package inventory
+
+import (
+	"github.com/planetscale/cli/internal/cmdutil"
+
+	"github.com/spf13/cobra"
+)
+
+// InventoryCmd encapsulates the commands for snapshotting the resources of an
+// organization and comparing the snapshots.
+func InventoryCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inventory <command>",
+		Short: "Snapshot the resources of an organization and compare snapshots",
+		Long: `Snapshot the resources of an organization and compare snapshots.
+
+A snapshot records the databases, branches, passwords and service tokens of
+the organization, with the access of the tokens, in the config directory.
+Comparing snapshots shows what appeared, disappeared or changed in between,
+such as for a weekly review of the organization.`,
+		PersistentPreRunE: cmdutil.CheckAuthentication(ch.Config),
+	}
+
+	cmd.PersistentFlags().StringVar(&ch.Config.Organization, "org", ch.Config.Organization,
+		"The organization for the current user")
+	cmd.MarkPersistentFlagRequired("org") // nolint:errcheck
+
+	cmd.AddCommand(SnapshotCmd(ch))
+	cmd.AddCommand(ListCmd(ch))
+	cmd.AddCommand(DiffCmd(ch))
+
+	return cmd
+}
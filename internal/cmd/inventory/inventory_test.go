@@ -0,0 +1,145 @@
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+func TestInventory_SnapshotCmd(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	org := "planetscale"
+	databases := &mock.DatabaseService{
+		ListFn: func(ctx context.Context, req *ps.ListDatabasesRequest) ([]*ps.Database, error) {
+			c.Assert(req.Organization, qt.Equals, org)
+			return []*ps.Database{{Name: "mydb", Region: ps.Region{Slug: "us-east"}}}, nil
+		},
+	}
+	branches := &mock.DatabaseBranchesService{
+		ListFn: func(ctx context.Context, req *ps.ListDatabaseBranchesRequest) ([]*ps.DatabaseBranch, error) {
+			c.Assert(req.Database, qt.Equals, "mydb")
+			return []*ps.DatabaseBranch{{Name: "main", Production: true}}, nil
+		},
+	}
+	passwords := &mock.PasswordsService{
+		ListFn: func(ctx context.Context, req *ps.ListDatabaseBranchPasswordRequest) ([]*ps.DatabaseBranchPassword, error) {
+			c.Assert(req.Branch, qt.Equals, "main")
+			return []*ps.DatabaseBranchPassword{{Name: "app", PublicID: "pw1", Role: "writer"}}, nil
+		},
+	}
+	tokens := &mock.ServiceTokenService{
+		ListFn: func(ctx context.Context, req *ps.ListServiceTokensRequest) ([]*ps.ServiceToken, error) {
+			return []*ps.ServiceToken{{ID: "tok1"}}, nil
+		},
+		GetAccessFn: func(ctx context.Context, req *ps.GetServiceTokenAccessRequest) ([]*ps.ServiceTokenAccess, error) {
+			c.Assert(req.ID, qt.Equals, "tok1")
+			return []*ps.ServiceTokenAccess{
+				{Access: "read_branch", Resource: ps.Database{Name: "mydb"}},
+				{Access: "delete_branch", Resource: ps.Database{Name: "mydb"}},
+			}, nil
+		},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config:  &config.Config{Organization: org},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{
+				Databases:        databases,
+				DatabaseBranches: branches,
+				Passwords:        passwords,
+				ServiceTokens:    tokens,
+			}, nil
+		},
+	}
+
+	cmd := SnapshotCmd(ch)
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	var summary snapshotSummary
+	c.Assert(json.Unmarshal(buf.Bytes(), &summary), qt.IsNil)
+	c.Assert(summary.Resources, qt.Equals, 4)
+
+	s, err := readSnapshot(summary.ID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(s.Resources[2].Name, qt.Equals, "mydb/main/app (pw1)")
+	c.Assert(s.Resources[3].Attributes, qt.DeepEquals, map[string]string{"access:mydb": "delete_branch,read_branch"})
+
+	snapshots, err := listSnapshots(org)
+	c.Assert(err, qt.IsNil)
+	c.Assert(snapshots, qt.HasLen, 1)
+}
+
+func TestInventory_DiffCmd(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	from := &snapshot{
+		ID:           "planetscale-1",
+		Organization: "planetscale",
+		TakenAt:      now.Add(-7 * 24 * time.Hour),
+		Resources: []*resource{
+			{Kind: "database", Name: "mydb", Attributes: map[string]string{"region": "us-east"}},
+			{Kind: "branch", Name: "mydb/old"},
+			{Kind: "service-token", Name: "tok1", Attributes: map[string]string{"access:mydb": "read_branch"}},
+		},
+	}
+	to := &snapshot{
+		ID:           "planetscale-2",
+		Organization: "planetscale",
+		TakenAt:      now,
+		Resources: []*resource{
+			{Kind: "database", Name: "mydb", Attributes: map[string]string{"region": "us-east"}},
+			{Kind: "branch", Name: "mydb/new"},
+			{Kind: "service-token", Name: "tok1", Attributes: map[string]string{"access:mydb": "delete_branch,read_branch", "access:otherdb": "read_branch"}},
+		},
+	}
+	c.Assert(from.save(), qt.IsNil)
+	c.Assert(to.save(), qt.IsNil)
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config:  &config.Config{Organization: "planetscale"},
+	}
+
+	cmd := DiffCmd(ch)
+	cmd.SetArgs([]string{from.ID, to.ID})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	var got []*change
+	c.Assert(json.Unmarshal(buf.Bytes(), &got), qt.IsNil)
+	c.Assert(got, qt.DeepEquals, []*change{
+		{Change: "added", Kind: "branch", Resource: "mydb/new"},
+		{Change: "removed", Kind: "branch", Resource: "mydb/old"},
+		{
+			Change:   "changed",
+			Kind:     "service-token",
+			Resource: "tok1",
+			Details:  "access:mydb: read_branch → delete_branch,read_branch; access:otherdb: none → read_branch",
+		},
+	})
+}
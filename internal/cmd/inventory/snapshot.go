@@ -0,0 +1,299 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+const snapshotsDir = "inventory"
+
+// snapshot is the inventory of an organization at a point in time.
+type snapshot struct {
+	ID           string      `json:"id"`
+	Organization string      `json:"org"`
+	TakenAt      time.Time   `json:"taken_at"`
+	Resources    []*resource `json:"resources"`
+}
+
+// resource is a resource of the organization, identified by its kind and
+// its name, such as "mydb/main" for a branch.
+type resource struct {
+	Kind       string            `json:"kind"`
+	Name       string            `json:"name"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// snapshotSummary returns a table-serializable snapshot.
+type snapshotSummary struct {
+	ID        string `header:"id" json:"id"`
+	Org       string `header:"org" json:"org"`
+	TakenAt   int64  `header:"taken_at,timestamp(ms|utc|human)" json:"taken_at"`
+	Resources int    `header:"resources" json:"resources"`
+}
+
+func (s *snapshot) summary() *snapshotSummary {
+	return &snapshotSummary{
+		ID:        s.ID,
+		Org:       s.Organization,
+		TakenAt:   s.TakenAt.UnixNano() / int64(time.Millisecond),
+		Resources: len(s.Resources),
+	}
+}
+
+// SnapshotCmd saves a snapshot of the resources of the organization.
+func SnapshotCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Save a snapshot of the resources of the organization",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Taking an inventory of organization %s...", printer.BoldBlue(ch.Config.Organization)))
+			s, err := takeSnapshot(cmd.Context(), client, ch.Config.Organization, time.Now().UTC())
+			end()
+			if err != nil {
+				return err
+			}
+
+			if err := s.save(); err != nil {
+				return err
+			}
+
+			if ch.Printer.Format() != printer.Human {
+				return ch.Printer.PrintResource(s.summary())
+			}
+			ch.Printer.Printf("Saved snapshot %s of organization %s with %d resources.\n",
+				printer.BoldBlue(s.ID), printer.BoldBlue(s.Organization), len(s.Resources))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// ListCmd lists the saved snapshots of the organization.
+func ListCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List the saved snapshots of the organization",
+		Args:    cobra.NoArgs,
+		Aliases: []string{"ls"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			snapshots, err := listSnapshots(ch.Config.Organization)
+			if err != nil {
+				return err
+			}
+
+			if len(snapshots) == 0 && ch.Printer.Format() == printer.Human {
+				ch.Printer.Printf("No snapshots of organization %s exist, save one with 'pscale inventory snapshot'.\n",
+					printer.BoldBlue(ch.Config.Organization))
+				return nil
+			}
+
+			summaries := make([]*snapshotSummary, 0, len(snapshots))
+			for _, s := range snapshots {
+				summaries = append(summaries, s.summary())
+			}
+			return ch.Printer.PrintResource(summaries)
+		},
+	}
+
+	return cmd
+}
+
+// takeSnapshot returns the inventory of the organization.
+func takeSnapshot(ctx context.Context, client *ps.Client, org string, now time.Time) (*snapshot, error) {
+	s := &snapshot{
+		ID:           fmt.Sprintf("%s-%s", org, now.Format("20060102T150405Z")),
+		Organization: org,
+		TakenAt:      now,
+	}
+
+	databases, err := client.Databases.List(ctx, &ps.ListDatabasesRequest{Organization: org})
+	if err != nil {
+		return nil, cmdutil.HandleError(err)
+	}
+
+	for _, db := range databases {
+		s.add("database", db.Name, map[string]string{
+			"region": db.Region.Slug,
+			"state":  string(db.State),
+		})
+
+		branches, err := client.DatabaseBranches.List(ctx, &ps.ListDatabaseBranchesRequest{
+			Organization: org,
+			Database:     db.Name,
+		})
+		if err != nil {
+			return nil, cmdutil.HandleError(err)
+		}
+
+		for _, b := range branches {
+			s.add("branch", db.Name+"/"+b.Name, map[string]string{
+				"parent":     b.ParentBranch,
+				"production": fmt.Sprint(b.Production),
+				"region":     b.Region.Slug,
+			})
+
+			passwords, err := client.Passwords.List(ctx, &ps.ListDatabaseBranchPasswordRequest{
+				Organization: org,
+				Database:     db.Name,
+				Branch:       b.Name,
+			})
+			if err != nil {
+				return nil, cmdutil.HandleError(err)
+			}
+
+			for _, p := range passwords {
+				s.add("password", fmt.Sprintf("%s/%s/%s (%s)", db.Name, b.Name, p.Name, p.PublicID), map[string]string{
+					"role": p.Role,
+				})
+			}
+		}
+	}
+
+	tokens, err := client.ServiceTokens.List(ctx, &ps.ListServiceTokensRequest{Organization: org})
+	if err != nil {
+		return nil, cmdutil.HandleError(err)
+	}
+
+	for _, t := range tokens {
+		accesses, err := client.ServiceTokens.GetAccess(ctx, &ps.GetServiceTokenAccessRequest{
+			Organization: org,
+			ID:           t.ID,
+		})
+		if err != nil {
+			return nil, cmdutil.HandleError(err)
+		}
+
+		byResource := make(map[string][]string)
+		for _, a := range accesses {
+			name := "access:" + a.Resource.Name
+			if a.Resource.Name == "" {
+				name = "access:" + org
+			}
+			byResource[name] = append(byResource[name], a.Access)
+		}
+
+		attrs := make(map[string]string, len(byResource))
+		for name, access := range byResource {
+			sort.Strings(access)
+			attrs[name] = strings.Join(access, ",")
+		}
+		s.add("service-token", t.ID, attrs)
+	}
+
+	return s, nil
+}
+
+func (s *snapshot) add(kind, name string, attrs map[string]string) {
+	s.Resources = append(s.Resources, &resource{Kind: kind, Name: name, Attributes: attrs})
+}
+
+// snapshotsPath returns the directory snapshots are saved in.
+func snapshotsPath() (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, snapshotsDir), nil
+}
+
+func (s *snapshot) save() error {
+	dir, err := snapshotsPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0771); err != nil {
+		return fmt.Errorf("error creating inventory directory: %s", err)
+	}
+
+	out, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("can't marshal snapshot: %s", err)
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, s.ID+".json"), out, 0600)
+}
+
+// readSnapshot reads the snapshot with the given ID, or from the given file.
+func readSnapshot(ref string) (*snapshot, error) {
+	p := ref
+	if _, err := os.Stat(ref); err != nil {
+		dir, err := snapshotsPath()
+		if err != nil {
+			return nil, err
+		}
+		p = filepath.Join(dir, ref+".json")
+	}
+
+	out, err := ioutil.ReadFile(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("snapshot %s does not exist (run 'pscale inventory list' to list the snapshots)", printer.BoldBlue(ref))
+		}
+		return nil, err
+	}
+
+	var s snapshot
+	if err := json.Unmarshal(out, &s); err != nil {
+		return nil, fmt.Errorf("can't unmarshal file %q: %s", p, err)
+	}
+	return &s, nil
+}
+
+// listSnapshots returns the saved snapshots of the organization, oldest
+// first.
+func listSnapshots(org string) ([]*snapshot, error) {
+	dir, err := snapshotsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var snapshots []*snapshot
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), org+"-") || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+
+		s, err := readSnapshot(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if s.Organization == org {
+			snapshots = append(snapshots, s)
+		}
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].TakenAt.Before(snapshots[j].TakenAt) })
+	return snapshots, nil
+}
@@ -0,0 +1,24 @@
+package journal
+
+import (
+	"github.com/planetscale/cli/internal/cmdutil"
+
+	"github.com/spf13/cobra"
+)
+
+// JournalCmd encapsulates the commands for inspecting the local journal of
+// mutating API requests.
+func JournalCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "journal <command>",
+		Short: "Inspect the changes made with pscale on this machine",
+		Long: `Inspect the changes made with pscale on this machine.
+
+Every mutating API request, such as creating a branch or deploying a deploy
+request, is recorded in a local journal in the config directory.`,
+	}
+
+	cmd.AddCommand(ListCmd(ch))
+
+	return cmd
+}
@@ -0,0 +1,66 @@
+package journal
+
+import (
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/journal"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/spf13/cobra"
+)
+
+// entry returns a table-serializable journal entry.
+type entry struct {
+	Time      int64  `header:"time,timestamp(ms|utc|human)" json:"time"`
+	Command   string `header:"command" json:"command"`
+	Method    string `header:"method" json:"method"`
+	Resource  string `header:"resource" json:"resource"`
+	Result    string `header:"result" json:"result"`
+	Reason    string `header:"reason" json:"reason,omitempty"`
+	Status    int    `header:"-" json:"status"`
+	RequestID string `header:"-" json:"request_id,omitempty"`
+}
+
+// ListCmd lists the journaled requests.
+func ListCmd(ch *cmdutil.Helper) *cobra.Command {
+	var since time.Duration
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List the recent mutating API requests",
+		Args:    cobra.NoArgs,
+		Aliases: []string{"ls"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := journal.Read(time.Now().Add(-since))
+			if err != nil {
+				return err
+			}
+
+			if len(entries) == 0 && ch.Printer.Format() == printer.Human {
+				ch.Printer.Printf("No changes were made in the last %s.\n", since)
+				return nil
+			}
+
+			res := make([]*entry, 0, len(entries))
+			for _, e := range entries {
+				res = append(res, &entry{
+					Time:      printer.GetMilliseconds(e.Time),
+					Command:   e.Command,
+					Method:    e.Method,
+					Resource:  e.Resource,
+					Result:    e.Result,
+					Reason:    e.Reason,
+					Status:    e.Status,
+					RequestID: e.RequestID,
+				})
+			}
+
+			return ch.Printer.PrintResource(res)
+		},
+	}
+
+	cmd.Flags().DurationVar(&since, "since", 24*time.Hour, "Show the requests made within this duration")
+
+	return cmd
+}
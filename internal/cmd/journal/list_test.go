@@ -0,0 +1,69 @@
+package journal
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/journal"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestJournal_ListCmd(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	now := time.Now().UTC()
+	c.Assert(journal.Append(&journal.Entry{
+		Time:     now.Add(-48 * time.Hour),
+		Command:  "pscale database create",
+		Method:   "POST",
+		Resource: "/organizations/org/databases",
+		Status:   201,
+		Result:   "ok",
+	}), qt.IsNil)
+	c.Assert(journal.Append(&journal.Entry{
+		Time:     now.Add(-time.Hour),
+		Command:  "pscale branch delete",
+		Method:   "DELETE",
+		Resource: "/organizations/org/databases/db/branches/dev",
+		Status:   204,
+		Result:   "ok",
+	}), qt.IsNil)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config:  &config.Config{},
+	}
+
+	cmd := ListCmd(ch)
+	cmd.SetArgs([]string{})
+	err := cmd.Execute()
+	c.Assert(err, qt.IsNil)
+
+	c.Assert(buf.String(), qt.JSONEquals, []*entry{{
+		Time:     printer.GetMilliseconds(now.Add(-time.Hour)),
+		Command:  "pscale branch delete",
+		Method:   "DELETE",
+		Resource: "/organizations/org/databases/db/branches/dev",
+		Result:   "ok",
+		Status:   204,
+	}})
+
+	buf.Reset()
+	cmd = ListCmd(ch)
+	cmd.SetArgs([]string{"--since", "72h"})
+	err = cmd.Execute()
+	c.Assert(err, qt.IsNil)
+	c.Assert(buf.String(), qt.Contains, "pscale database create")
+}
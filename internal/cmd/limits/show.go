@@ -0,0 +1,164 @@
+package limits
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/cost"
+	"github.com/planetscale/cli/internal/printer"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+const barWidth = 20
+
+// limitUsage is the usage of a single plan limit of a database.
+type limitUsage struct {
+	Database string  `header:"database" json:"database"`
+	Limit    string  `header:"limit" json:"limit"`
+	Used     string  `header:"used" json:"used"`
+	Max      string  `header:"max" json:"max"`
+	Usage    string  `header:"usage" json:"-"`
+	Percent  float64 `json:"percent"`
+}
+
+// ShowCmd lists the plan limits of databases alongside their current usage.
+func ShowCmd(ch *cmdutil.Helper) *cobra.Command {
+	var plan string
+
+	cmd := &cobra.Command{
+		Use:   "show [database...]",
+		Short: "Show the plan limits and current usage of databases",
+		Long: `Show the plan limits and current usage of databases.
+
+The limits of the configured plan are shown for the given databases, or all
+databases of the organization. Storage is approximated by the latest backup
+of each production branch. The number of open connections can't be queried
+and only the limit is shown.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			if !cmd.Flags().Changed("plan") {
+				plan = ch.Config.Plan
+			}
+
+			limits, err := ch.Config.PlanLimits(plan)
+			if err != nil {
+				return err
+			}
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			end := ch.Printer.PrintProgress("Fetching usage...")
+			defer end()
+
+			databases := args
+			if len(databases) == 0 {
+				dbs, err := client.Databases.List(ctx, &ps.ListDatabasesRequest{
+					Organization: ch.Config.Organization,
+				})
+				if err != nil {
+					switch cmdutil.ErrCode(err) {
+					case ps.ErrNotFound:
+						return fmt.Errorf("organization %s does not exist", printer.BoldBlue(ch.Config.Organization))
+					default:
+						return cmdutil.HandleError(err)
+					}
+				}
+
+				for _, db := range dbs {
+					databases = append(databases, db.Name)
+				}
+			}
+
+			var usages []*limitUsage
+			for _, db := range databases {
+				usage, err := cost.Collect(ctx, client, ch.Config.Organization, db)
+				if err != nil {
+					switch cmdutil.ErrCode(err) {
+					case ps.ErrNotFound:
+						return fmt.Errorf("database %s does not exist in organization %s",
+							printer.BoldBlue(db), printer.BoldBlue(ch.Config.Organization))
+					default:
+						return cmdutil.HandleError(err)
+					}
+				}
+
+				usages = append(usages, toLimitUsages(db, limits, usage)...)
+			}
+
+			end()
+
+			if len(usages) == 0 && ch.Printer.Format() == printer.Human {
+				ch.Printer.Printf("No databases have been created in %s yet.\n", printer.BoldBlue(ch.Config.Organization))
+				return nil
+			}
+
+			return ch.Printer.PrintResource(usages)
+		},
+	}
+
+	cmd.Flags().StringVar(&plan, "plan", "", "The plan to show the limits of, overrides the configured plan")
+	cmd.RegisterFlagCompletionFunc("plan", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) { // nolint:errcheck
+		return config.PlanNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return cmd
+}
+
+func toLimitUsages(db string, limits *config.Limits, usage *cost.Usage) []*limitUsage {
+	return []*limitUsage{
+		newLimitUsage(db, "production branches", float64(usage.ProductionBranches), float64(limits.ProductionBranches), "%g"),
+		newLimitUsage(db, "development branches", float64(usage.DevelopmentBranches), float64(limits.DevelopmentBranches), "%g"),
+		newLimitUsage(db, "storage", usage.StorageGB(), limits.StorageGB, "%.2f GB"),
+		{
+			Database: db,
+			Limit:    "connections",
+			Used:     "-",
+			Max:      maxString(float64(limits.Connections), "%g"),
+		},
+	}
+}
+
+func newLimitUsage(db, name string, used, max float64, format string) *limitUsage {
+	l := &limitUsage{
+		Database: db,
+		Limit:    name,
+		Used:     fmt.Sprintf(format, used),
+		Max:      maxString(max, format),
+	}
+
+	if max > 0 {
+		l.Percent = used / max * 100
+		l.Usage = bar(l.Percent)
+	}
+
+	return l
+}
+
+func maxString(max float64, format string) string {
+	if max <= 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf(format, max)
+}
+
+// bar renders the percentage as a progress bar, i.e: "[#####     ]  50%".
+func bar(percent float64) string {
+	filled := int(percent / 100 * barWidth)
+	if filled > barWidth {
+		filled = barWidth
+	}
+
+	b := fmt.Sprintf("[%s%s] %3.0f%%", strings.Repeat("#", filled), strings.Repeat(" ", barWidth-filled), percent)
+	if percent >= 100 {
+		return printer.BoldRed(b)
+	}
+	return b
+}
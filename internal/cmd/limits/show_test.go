@@ -0,0 +1,75 @@
+package limits
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/printer"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestLimits_ShowCmd(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	org := "planetscale"
+	db := "mydb"
+
+	branches := &mock.DatabaseBranchesService{
+		ListFn: func(ctx context.Context, req *ps.ListDatabaseBranchesRequest) ([]*ps.DatabaseBranch, error) {
+			c.Assert(req.Organization, qt.Equals, org)
+			c.Assert(req.Database, qt.Equals, db)
+			return []*ps.DatabaseBranch{
+				{Name: "main", Production: true},
+				{Name: "dev"},
+			}, nil
+		},
+	}
+	backups := &mock.BackupsService{
+		ListFn: func(ctx context.Context, req *ps.ListBackupsRequest) ([]*ps.Backup, error) {
+			return []*ps.Backup{{State: "success", Size: 1 << 30}}, nil
+		},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config: &config.Config{
+			Organization: org,
+			Plan:         "hobby",
+		},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{
+				DatabaseBranches: branches,
+				Backups:          backups,
+			}, nil
+		},
+	}
+
+	cmd := ShowCmd(ch)
+	cmd.SetArgs([]string{db})
+	err := cmd.Execute()
+
+	c.Assert(err, qt.IsNil)
+	c.Assert(branches.ListFnInvoked, qt.IsTrue)
+	c.Assert(buf.String(), qt.JSONEquals, []*limitUsage{
+		{Database: db, Limit: "production branches", Used: "1", Max: "1", Percent: 100},
+		{Database: db, Limit: "development branches", Used: "1", Max: "1", Percent: 100},
+		{Database: db, Limit: "storage", Used: "1.00 GB", Max: "5.00 GB", Percent: 20},
+		{Database: db, Limit: "connections", Used: "-", Max: "1000"},
+	})
+}
+
+func TestBar(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(bar(50), qt.Equals, "[##########          ]  50%")
+}
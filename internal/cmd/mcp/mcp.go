@@ -0,0 +1,285 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/spf13/cobra"
+)
+
+// protocolVersion is the Model Context Protocol version implemented.
+const protocolVersion = "2024-11-05"
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// MCPCmd serves the commands of the CLI as Model Context Protocol tools.
+func MCPCmd(ch *cmdutil.Helper, ver string) *cobra.Command {
+	var flags struct {
+		allowMutations bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Serve the CLI as a Model Context Protocol tool server over stdio",
+		Long: `Serve the CLI as a Model Context Protocol tool server over stdio.
+
+Coding assistants supporting the Model Context Protocol can run this command
+to query organizations, databases, branch schemas, deploy requests with their
+diffs and insights through the authenticated CLI. Each tool runs the matching
+command, so it behaves exactly as on the command line.
+
+Only reading tools are offered by default. With --allow-mutations, tools
+creating branches and opening deploy requests are offered too. Nothing is
+ever deployed or deleted.`,
+		Args:              cobra.NoArgs,
+		PersistentPreRunE: cmdutil.CheckAuthentication(ch.Config),
+		Example: `  pscale mcp
+  pscale mcp --org acme --allow-mutations`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s := &server{
+				ch:             ch,
+				version:        ver,
+				allowMutations: flags.allowMutations,
+			}
+			return s.serve(cmd.Context(), cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(&ch.Config.Organization, "org", ch.Config.Organization,
+		"The organization the tools use by default")
+	cmd.Flags().BoolVar(&flags.allowMutations, "allow-mutations", false,
+		"Offer the tools creating branches and deploy requests")
+
+	return cmd
+}
+
+// request is a JSON-RPC request, or a notification if it has no ID.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// toolResult is the result of a tool call. Failures of the command are
+// results too, so the assistant can see and act on them.
+type toolResult struct {
+	Content []*content `json:"content"`
+	IsError bool       `json:"isError,omitempty"`
+}
+
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type server struct {
+	ch             *cmdutil.Helper
+	version        string
+	allowMutations bool
+}
+
+// serve reads newline delimited requests from in and writes the responses
+// to out, until in is closed.
+func (s *server) serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	enc := json.NewEncoder(out)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			resp := &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: err.Error()}}
+			if err := enc.Encode(resp); err != nil {
+				return err
+			}
+			continue
+		}
+
+		result, rerr := s.handle(ctx, &req)
+		if len(req.ID) == 0 {
+			// notifications aren't answered
+			continue
+		}
+
+		resp := &response{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rerr}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *server) handle(ctx context.Context, req *request) (interface{}, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"protocolVersion": protocolVersion,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]interface{}{"name": "pscale", "version": s.version},
+		}, nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		list := make([]map[string]interface{}, 0, len(tools))
+		for _, t := range s.tools() {
+			list = append(list, map[string]interface{}{
+				"name":        t.name,
+				"description": t.description,
+				"inputSchema": t.inputSchema(),
+			})
+		}
+		return map[string]interface{}{"tools": list}, nil
+	case "tools/call":
+		var params struct {
+			Name      string                 `json:"name"`
+			Arguments map[string]interface{} `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+
+		t := s.tool(params.Name)
+		if t == nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown tool %q", params.Name)}
+		}
+
+		args, err := t.args(params.Arguments)
+		if err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		return s.call(ctx, t, stringArg(params.Arguments["org"]), args), nil
+	}
+
+	if strings.HasPrefix(req.Method, "notifications/") {
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q is not supported", req.Method)}
+}
+
+// tools returns the tools offered.
+func (s *server) tools() []*tool {
+	offered := make([]*tool, 0, len(tools))
+	for _, t := range tools {
+		if !t.mutation || s.allowMutations {
+			offered = append(offered, t)
+		}
+	}
+	return offered
+}
+
+func (s *server) tool(name string) *tool {
+	for _, t := range s.tools() {
+		if t.name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// call runs the command of the tool with a JSON printer, in the given
+// organization or the default one.
+func (s *server) call(ctx context.Context, t *tool, org string, args []string) *toolResult {
+	cfg := *s.ch.Config
+	if org != "" {
+		cfg.Organization = org
+	}
+
+	var out bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&out)
+
+	ch := *s.ch
+	ch.Config = &cfg
+	ch.Printer = p
+
+	var stderr bytes.Buffer
+	cmd := t.command(&ch)
+	cmd.SetArgs(append(args, t.flags...))
+	cmd.SetOut(&stderr)
+	cmd.SetErr(&stderr)
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	if err := cmd.ExecuteContext(ctx); err != nil {
+		return &toolResult{Content: []*content{{Type: "text", Text: err.Error()}}, IsError: true}
+	}
+
+	text := strings.TrimSpace(out.String())
+	if text == "" {
+		text = "The command succeeded without output."
+	}
+	return &toolResult{Content: []*content{{Type: "text", Text: text}}}
+}
+
+// args returns the command line arguments of the tool's command for the
+// arguments of the call.
+func (t *tool) args(arguments map[string]interface{}) ([]string, error) {
+	var positional, flags []string
+	for _, p := range t.params {
+		v := stringArg(arguments[p.name])
+		if v == "" {
+			if p.required {
+				return nil, fmt.Errorf("argument %q is required", p.name)
+			}
+			continue
+		}
+
+		if p.flag != "" {
+			flags = append(flags, "--"+p.flag+"="+v)
+			continue
+		}
+		if strings.HasPrefix(v, "-") {
+			return nil, fmt.Errorf("invalid value %q of argument %q", v, p.name)
+		}
+		positional = append(positional, v)
+	}
+
+	return append(positional, flags...), nil
+}
+
+// stringArg returns the argument as a string, numbers are formatted without
+// exponent.
+func stringArg(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,121 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/printer"
+
+	qt "github.com/frankban/quicktest"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+func TestMCP(t *testing.T) {
+	c := qt.New(t)
+
+	branches := &mock.DatabaseBranchesService{
+		ListFn: func(ctx context.Context, req *ps.ListDatabaseBranchesRequest) ([]*ps.DatabaseBranch, error) {
+			c.Assert(req.Organization, qt.Equals, "other")
+			c.Assert(req.Database, qt.Equals, "mydb")
+			return []*ps.DatabaseBranch{{Name: "main"}}, nil
+		},
+	}
+
+	format := printer.Human
+	ch := &cmdutil.Helper{
+		Printer: printer.NewPrinter(&format),
+		Config:  &config.Config{Organization: "planetscale", AccessToken: "token"},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{DatabaseBranches: branches}, nil
+		},
+	}
+
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"list_branches","arguments":{"org":"other","database":"mydb"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"list_branches","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"create_branch","arguments":{"database":"mydb","branch":"dev"}}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"resources/list"}`,
+	}, "\n")
+
+	var out bytes.Buffer
+	cmd := MCPCmd(ch, "1.0.0")
+	cmd.SetIn(strings.NewReader(in))
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	type resp struct {
+		ID     int `json:"id"`
+		Result struct {
+			ServerInfo struct {
+				Name string `json:"name"`
+			} `json:"serverInfo"`
+			Tools []struct {
+				Name string `json:"name"`
+			} `json:"tools"`
+			Content []*content `json:"content"`
+			IsError bool       `json:"isError"`
+		} `json:"result"`
+		Error *rpcError `json:"error"`
+	}
+
+	var resps []*resp
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var r resp
+		c.Assert(dec.Decode(&r), qt.IsNil)
+		resps = append(resps, &r)
+	}
+
+	// the notification isn't answered
+	c.Assert(resps, qt.HasLen, 6)
+
+	c.Assert(resps[0].ID, qt.Equals, 1)
+	c.Assert(resps[0].Result.ServerInfo.Name, qt.Equals, "pscale")
+
+	var names []string
+	for _, t := range resps[1].Result.Tools {
+		names = append(names, t.Name)
+	}
+	c.Assert(names, qt.Contains, "get_branch_schema")
+	c.Assert(names, qt.Not(qt.Contains), "create_branch")
+
+	c.Assert(resps[2].Result.IsError, qt.IsFalse)
+	c.Assert(resps[2].Result.Content[0].Text, qt.Contains, `"name": "main"`)
+	c.Assert(branches.ListFnInvoked, qt.IsTrue)
+
+	c.Assert(resps[3].Error.Code, qt.Equals, codeInvalidParams)
+	c.Assert(resps[3].Error.Message, qt.Equals, `argument "database" is required`)
+
+	// mutations aren't offered without --allow-mutations
+	c.Assert(resps[4].Error.Code, qt.Equals, codeInvalidParams)
+
+	c.Assert(resps[5].Error.Code, qt.Equals, codeMethodNotFound)
+}
+
+func TestToolArgs(t *testing.T) {
+	c := qt.New(t)
+
+	var dr *tool
+	for _, t := range tools {
+		if t.name == "get_deploy_request" {
+			dr = t
+		}
+	}
+
+	args, err := dr.args(map[string]interface{}{"database": "mydb", "number": float64(42)})
+	c.Assert(err, qt.IsNil)
+	c.Assert(args, qt.DeepEquals, []string{"mydb", "42"})
+
+	_, err = dr.args(map[string]interface{}{"database": "--web", "number": float64(42)})
+	c.Assert(err, qt.ErrorMatches, `invalid value "--web" of argument "database"`)
+}
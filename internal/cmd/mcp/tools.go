@@ -0,0 +1,167 @@
+package mcp
+
+import (
+	"github.com/planetscale/cli/internal/cmd/branch"
+	"github.com/planetscale/cli/internal/cmd/database"
+	"github.com/planetscale/cli/internal/cmd/deployrequest"
+	"github.com/planetscale/cli/internal/cmd/insights"
+	"github.com/planetscale/cli/internal/cmd/org"
+	"github.com/planetscale/cli/internal/cmdutil"
+
+	"github.com/spf13/cobra"
+)
+
+// tool is a CLI command exposed as a tool. The command is run with the JSON
+// printer, and its output is the result of the tool.
+type tool struct {
+	name        string
+	description string
+
+	// mutation tools change resources, they're only offered with
+	// --allow-mutations.
+	mutation bool
+
+	// org adds the org parameter, the organization the command runs in.
+	org bool
+
+	params  []param
+	command func(*cmdutil.Helper) *cobra.Command
+
+	// flags are always passed to the command.
+	flags []string
+}
+
+// param is a parameter of a tool, passed to the command as a positional
+// argument in the order of the parameters, or as a flag.
+type param struct {
+	name        string
+	description string
+	integer     bool
+	required    bool
+	flag        string
+}
+
+var (
+	databaseParam = param{name: "database", description: "The name of the database", required: true}
+	branchParam   = param{name: "branch", description: "The name of the branch", required: true}
+	numberParam   = param{name: "number", description: "The number of the deploy request", integer: true, required: true}
+)
+
+var tools = []*tool{
+	{
+		name:        "list_organizations",
+		description: "List the organizations the CLI has access to.",
+		command:     org.ListCmd,
+	},
+	{
+		name:        "list_databases",
+		description: "List the databases of the organization.",
+		org:         true,
+		command:     database.ListCmd,
+	},
+	{
+		name:        "list_branches",
+		description: "List the branches of a database.",
+		org:         true,
+		params:      []param{databaseParam},
+		command:     branch.ListCmd,
+	},
+	{
+		name:        "get_branch_schema",
+		description: "Get the schema of a branch, as the CREATE TABLE statement of each table.",
+		org:         true,
+		params:      []param{databaseParam, branchParam},
+		command:     branch.SchemaCmd,
+	},
+	{
+		name:        "list_deploy_requests",
+		description: "List the deploy requests of a database.",
+		org:         true,
+		params:      []param{databaseParam},
+		command:     deployrequest.ListCmd,
+	},
+	{
+		name:        "get_deploy_request",
+		description: "Get a deploy request with its state and deployment.",
+		org:         true,
+		params:      []param{databaseParam, numberParam},
+		command:     deployrequest.ShowCmd,
+	},
+	{
+		name:        "get_deploy_request_diff",
+		description: "Get the schema changes of a deploy request.",
+		org:         true,
+		params:      []param{databaseParam, numberParam},
+		command:     deployrequest.DiffCmd,
+	},
+	{
+		name:        "get_slow_queries",
+		description: "Get the slow queries of a branch aggregated by their fingerprint, with their count and latency percentiles.",
+		org:         true,
+		params: []param{
+			databaseParam,
+			branchParam,
+			{name: "since", description: "The duration the queries started within, such as 1h, 24h by default", flag: "since"},
+		},
+		command: insights.SlowQueriesExportCmd,
+		flags:   []string{"--digest"},
+	},
+	{
+		name:        "get_unused_indexes",
+		description: "Get the indexes of a branch that weren't used since the server started.",
+		org:         true,
+		params:      []param{databaseParam, branchParam},
+		command:     insights.UnusedIndexesCmd,
+	},
+	{
+		name:        "create_branch",
+		description: "Create a development branch of a database.",
+		mutation:    true,
+		org:         true,
+		params: []param{
+			databaseParam,
+			branchParam,
+			{name: "from", description: "The branch to create the branch from, the default branch by default", flag: "from"},
+		},
+		command: branch.CreateCmd,
+	},
+	{
+		name:        "create_deploy_request",
+		description: "Open a deploy request of a branch. It isn't deployed.",
+		mutation:    true,
+		org:         true,
+		params: []param{
+			databaseParam,
+			branchParam,
+			{name: "deploy_to", description: "The branch to deploy to, main by default", flag: "deploy-to"},
+		},
+		command: deployrequest.CreateCmd,
+	},
+}
+
+// inputSchema returns the JSON schema of the arguments of the tool.
+func (t *tool) inputSchema() map[string]interface{} {
+	params := t.params
+	if t.org {
+		params = append([]param{{name: "org", description: "The organization, the default organization of the CLI by default"}}, params...)
+	}
+
+	properties := make(map[string]interface{}, len(params))
+	required := make([]string, 0)
+	for _, p := range params {
+		typ := "string"
+		if p.integer {
+			typ = "integer"
+		}
+		properties[p.name] = map[string]interface{}{"type": typ, "description": p.description}
+		if p.required {
+			required = append(required, p.name)
+		}
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
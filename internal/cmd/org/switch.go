@@ -13,6 +13,7 @@ import (
 	survey "github.com/AlecAivazis/survey/v2"
 	"github.com/AlecAivazis/survey/v2/terminal"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
 )
 
 func SwitchCmd(ch *cmdutil.Helper) *cobra.Command {
@@ -20,30 +21,9 @@ func SwitchCmd(ch *cmdutil.Helper) *cobra.Command {
 		filepath string
 	}
 	cmd := &cobra.Command{
-		Use:   "switch <organization>",
-		Short: "Switch the currently active organization",
-		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			if len(args) != 0 {
-				return nil, cobra.ShellCompDirectiveNoFileComp
-			}
-
-			client, err := ch.Client()
-			if err != nil {
-				return nil, cobra.ShellCompDirectiveNoFileComp
-			}
-
-			orgs, err := client.Organizations.List(cmd.Context())
-			if err != nil {
-				return nil, cobra.ShellCompDirectiveNoFileComp
-			}
-
-			orgNames := make([]string, 0, len(orgs))
-			for _, org := range orgs {
-				orgNames = append(orgNames, org.Name)
-			}
-
-			return orgNames, cobra.ShellCompDirectiveNoFileComp
-		},
+		Use:               "switch <organization>",
+		Short:             "Switch the currently active organization",
+		ValidArgsFunction: cmdutil.OrganizationCompletion(ch),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 
@@ -128,23 +108,13 @@ func SwitchCmd(ch *cmdutil.Helper) *cobra.Command {
 				}
 			}
 
-			// check if a file already exists, we don't want to accidently
-			// overwrite other values of the file config
-			fileCfg, err := ch.ConfigFS.NewFileConfig(filePath)
-			if os.IsNotExist(err) {
-				// create a new file
-				fileCfg = &config.FileConfig{
-					Organization: organization,
-				}
-			} else {
-				fileCfg.Organization = organization
-				// TODO(fatih): check whether the branch/database exists for
-				// the given organization and warn the user. The
-				// branch/database combination will NOT be empty for a project
-				// configuratin residing inside a Git repository.
-			}
-
-			err = fileCfg.Write(filePath)
+			// only the organization is changed, the other values of an
+			// existing file are kept.
+			// TODO(fatih): check whether the branch/database exists for
+			// the given organization and warn the user. The
+			// branch/database combination will NOT be empty for a project
+			// configuratin residing inside a Git repository.
+			err = config.SetValues(filePath, yaml.MapSlice{{Key: "org", Value: organization}})
 			if err != nil {
 				return err
 			}
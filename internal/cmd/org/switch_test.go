@@ -57,3 +57,39 @@ func TestOrganization_SwitchCmd(t *testing.T) {
 	c.Assert(string(out), qt.Equals, fmt.Sprintf("org: %s\n", organization))
 	c.Assert(buf.String(), qt.Contains, "Successfully switched to organization")
 }
+
+func TestOrganization_SwitchCmd_KeepsValues(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	format := printer.Human
+	p := printer.NewPrinter(&format)
+	p.SetHumanOutput(&buf)
+
+	ch := &cmdutil.Helper{
+		Printer:  p,
+		ConfigFS: config.NewConfigFS(testutil.MemFS{}),
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{
+				Organizations: &mock.OrganizationsService{
+					GetFn: func(ctx context.Context, req *ps.GetOrganizationRequest) (*ps.Organization, error) {
+						return &ps.Organization{Name: req.Organization}, nil
+					},
+				},
+			}, nil
+		},
+	}
+
+	// keys that FileConfig doesn't know about are kept
+	configPath := filepath.Join(t.TempDir(), "pscale.yml")
+	err := os.WriteFile(configPath, []byte("org: acme\nmirror:\n  api-url: https://mirror.internal\nmax-concurrent-requests: 4\n"), 0644)
+	c.Assert(err, qt.IsNil)
+
+	cmd := SwitchCmd(ch)
+	cmd.SetArgs([]string{"planetscale", "--save-config", configPath})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	out, err := os.ReadFile(configPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, "org: planetscale\nmirror:\n  api-url: https://mirror.internal\nmax-concurrent-requests: 4\n")
+}
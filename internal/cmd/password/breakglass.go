@@ -0,0 +1,152 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/journal"
+	"github.com/planetscale/cli/internal/tunnel"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	exec "golang.org/x/sys/execabs"
+)
+
+const (
+	breakGlassRole       = "admin"
+	defaultBreakGlassTTL = 30 * time.Minute
+	maxBreakGlassTTL     = 12 * time.Hour
+)
+
+// startRevoker starts a detached 'pscale resume' revoking the password of
+// the operation once it expired. It authenticates with the credentials of
+// cfg.
+var startRevoker = func(op *config.Operation, cfg *config.Config) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"resume", op.ID}
+	if p := config.ActiveProfile(); p != "" {
+		args = append(args, "--profile", p)
+	}
+
+	cmd := exec.Command(exe, args...)
+	cmd.Env = append(os.Environ(), revokerEnv(cfg)...)
+	cmd.SysProcAttr = tunnel.Detached()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	return cmd.Process.Release()
+}
+
+// revokerEnv returns the environment passing the credentials and the API URL
+// of cfg to the revoker, as they may have been passed with flags. Secrets
+// are passed in the environment rather than the arguments, which other
+// users can see.
+func revokerEnv(cfg *config.Config) []string {
+	// the stored token of the user is read, and refreshed, by the revoker
+	// itself
+	accessToken := cfg.AccessToken
+	if cfg.Token != nil && cfg.Token.AccessToken == accessToken {
+		accessToken = ""
+	}
+
+	var env []string
+	for _, kv := range [][2]string{
+		{"PLANETSCALE_SERVICE_TOKEN", cfg.ServiceToken},
+		{"PLANETSCALE_SERVICE_TOKEN_ID", cfg.ServiceTokenID},
+		{"PLANETSCALE_API_TOKEN", accessToken},
+		{"PLANETSCALE_API_URL", cfg.BaseURL},
+	} {
+		if kv[1] != "" {
+			env = append(env, kv[0]+"="+kv[1])
+		}
+	}
+	return env
+}
+
+// validateBreakGlass checks the flags of a break-glass password.
+func validateBreakGlass(breakGlass bool, ttl time.Duration, reason string) error {
+	if !breakGlass {
+		if ttl != 0 || reason != "" {
+			return errors.New("--ttl and --reason can only be used with --break-glass")
+		}
+		return nil
+	}
+
+	if reason == "" {
+		return errors.New("a break-glass password requires a --reason, i.e. the incident it's needed for")
+	}
+
+	if ttl <= 0 || ttl > maxBreakGlassTTL {
+		return fmt.Errorf("--ttl must be positive and at most %s", maxBreakGlassTTL)
+	}
+
+	return nil
+}
+
+// scheduleRevocation persists the revocation of the break-glass password and
+// starts waiting for it in the background. If the background process dies,
+// i.e. because the machine is shut down, 'pscale resume' revokes the password.
+func scheduleRevocation(cfg *config.Config, pass *ps.DatabaseBranchPassword, req *ps.DatabaseBranchPasswordRequest, ttl time.Duration) (*config.Operation, error) {
+	expiresAt := pass.CreatedAt.Add(ttl)
+	if pass.CreatedAt.IsZero() {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	op := config.NewOperation(config.OperationRevoke, req.Organization, req.Database, req.Branch, 0)
+	op.ID += "." + pass.PublicID
+	op.PasswordID = pass.PublicID
+	op.ExpiresAt = &expiresAt
+
+	if err := op.Save(); err != nil {
+		return nil, err
+	}
+
+	if err := startRevoker(op, cfg); err != nil {
+		return op, fmt.Errorf("can't schedule the revocation, run 'pscale resume %s' to revoke the password at %s: %s",
+			op.ID, expiresAt.Format(time.RFC3339), err)
+	}
+
+	return op, nil
+}
+
+// WaitRevocation waits until the break-glass password of the operation
+// expired and deletes it. The operation is persisted until the password is
+// deleted, so 'pscale resume' can revoke it if the wait is interrupted.
+func WaitRevocation(ctx context.Context, client *ps.Client, op *config.Operation) error {
+	if op.ExpiresAt == nil || op.PasswordID == "" {
+		return fmt.Errorf("operation %s has no password to revoke", op.ID)
+	}
+
+	if d := time.Until(*op.ExpiresAt); d > 0 {
+		t := time.NewTimer(d)
+		defer t.Stop()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+
+	journal.SetReason("break-glass password expired")
+	err := client.Passwords.Delete(ctx, &ps.DeleteDatabaseBranchPasswordRequest{
+		Organization: op.Organization,
+		Database:     op.Database,
+		Branch:       op.Branch,
+		PasswordId:   op.PasswordID,
+	})
+	if err != nil && cmdutil.ErrCode(err) != ps.ErrNotFound {
+		return err
+	}
+
+	return op.Done()
+}
@@ -0,0 +1,135 @@
+package password
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/journal"
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestPassword_CreateCmd_BreakGlass(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+	defer journal.SetReason("")
+
+	var started *config.Operation
+	defer func(fn func(*config.Operation, *config.Config) error) { startRevoker = fn }(startRevoker)
+	startRevoker = func(op *config.Operation, cfg *config.Config) error {
+		started = op
+		return nil
+	}
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	createdAt := time.Now()
+	svc := &mock.PasswordsService{
+		CreateFn: func(ctx context.Context, req *ps.DatabaseBranchPasswordRequest) (*ps.DatabaseBranchPassword, error) {
+			c.Assert(req.Role, qt.Equals, "admin")
+			return &ps.DatabaseBranchPassword{PublicID: "pw1", Name: "incident", CreatedAt: createdAt}, nil
+		},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer:  p,
+		Config:   &config.Config{Organization: "planetscale"},
+		ConfigFS: config.NewConfigFS(testutil.OSFS{}),
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{Passwords: svc}, nil
+		},
+	}
+
+	cmd := CreateCmd(ch)
+	cmd.SetArgs([]string{"mydb", "main", "incident", "--break-glass", "--ttl", "15m", "--reason", "incident#123"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+	c.Assert(svc.CreateFnInvoked, qt.IsTrue)
+
+	c.Assert(started, qt.Not(qt.IsNil))
+	c.Assert(started.ID, qt.Equals, "revoke.planetscale.mydb.main.pw1")
+	c.Assert(started.ExpiresAt.Equal(createdAt.Add(15*time.Minute)), qt.IsTrue)
+
+	ops, err := ch.ConfigFS.Operations()
+	c.Assert(err, qt.IsNil)
+	c.Assert(ops, qt.HasLen, 1)
+	c.Assert(ops[0].PasswordID, qt.Equals, "pw1")
+}
+
+func TestPassword_CreateCmd_BreakGlassReason(t *testing.T) {
+	c := qt.New(t)
+
+	ch := &cmdutil.Helper{
+		Config: &config.Config{Organization: "planetscale"},
+	}
+
+	cmd := CreateCmd(ch)
+	cmd.SetArgs([]string{"mydb", "main", "incident", "--break-glass"})
+	c.Assert(cmd.Execute(), qt.ErrorMatches, "a break-glass password requires a --reason.*")
+
+	cmd = CreateCmd(ch)
+	cmd.SetArgs([]string{"mydb", "main", "incident", "--ttl", "1h"})
+	c.Assert(cmd.Execute(), qt.ErrorMatches, "--ttl and --reason can only be used with --break-glass")
+}
+
+func TestWaitRevocation(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+	defer journal.SetReason("")
+
+	expiresAt := time.Now().Add(-time.Minute)
+	op := config.NewOperation(config.OperationRevoke, "planetscale", "mydb", "main", 0)
+	op.PasswordID = "pw1"
+	op.ExpiresAt = &expiresAt
+	c.Assert(op.Save(), qt.IsNil)
+
+	svc := &mock.PasswordsService{
+		DeleteFn: func(ctx context.Context, req *ps.DeleteDatabaseBranchPasswordRequest) error {
+			c.Assert(req.Organization, qt.Equals, "planetscale")
+			c.Assert(req.Database, qt.Equals, "mydb")
+			c.Assert(req.Branch, qt.Equals, "main")
+			c.Assert(req.PasswordId, qt.Equals, "pw1")
+			return nil
+		},
+	}
+
+	err := WaitRevocation(context.Background(), &ps.Client{Passwords: svc}, op)
+	c.Assert(err, qt.IsNil)
+	c.Assert(svc.DeleteFnInvoked, qt.IsTrue)
+
+	ops, err := config.NewConfigFS(testutil.OSFS{}).Operations()
+	c.Assert(err, qt.IsNil)
+	c.Assert(ops, qt.HasLen, 0)
+}
+
+func TestRevokerEnv(t *testing.T) {
+	c := qt.New(t)
+
+	cfg := &config.Config{
+		BaseURL:        "https://api.example.com",
+		ServiceTokenID: "id",
+		ServiceToken:   "secret",
+	}
+	c.Assert(revokerEnv(cfg), qt.DeepEquals, []string{
+		"PLANETSCALE_SERVICE_TOKEN=secret",
+		"PLANETSCALE_SERVICE_TOKEN_ID=id",
+		"PLANETSCALE_API_URL=https://api.example.com",
+	})
+
+	// tokens passed with --api-token are forwarded, stored ones aren't
+	cfg = &config.Config{AccessToken: "explicit"}
+	c.Assert(revokerEnv(cfg), qt.DeepEquals, []string{"PLANETSCALE_API_TOKEN=explicit"})
+
+	cfg = &config.Config{AccessToken: "stored", Token: &config.Token{AccessToken: "stored"}}
+	c.Assert(revokerEnv(cfg), qt.IsNil)
+}
@@ -1,9 +1,12 @@
 package password
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/journal"
 	"github.com/planetscale/cli/internal/printer"
 	ps "github.com/planetscale/planetscale-go/planetscale"
 
@@ -12,20 +15,55 @@ import (
 
 func CreateCmd(ch *cmdutil.Helper) *cobra.Command {
 	var flags struct {
-		role string
+		role         string
+		dedupeWindow time.Duration
+		breakGlass   bool
+		ttl          time.Duration
+		reason       string
 	}
 
 	createReq := &ps.DatabaseBranchPasswordRequest{}
 	cmd := &cobra.Command{
-		Use:     "create <database> <branch> <name>",
-		Short:   "Create password to access a branch's data",
-		Args:    cmdutil.RequiredArgs("database", "branch", "name"),
-		Aliases: []string{"p"},
+		Use:   "create <database> <branch> [name]",
+		Short: "Create password to access a branch's data",
+		Long: `Create password to access a branch's data.
+
+If the name is omitted, it's generated from the naming template of the
+organization (see 'naming' in your config file).
+
+A break-glass password is an admin password for emergencies. It requires a
+reason, which is recorded in the journal ('pscale journal list'), and is
+revoked automatically once its --ttl expired.`,
+		Example: `Create a break-glass password for an incident:
+
+  pscale password create mydb main --break-glass --ttl 30m --reason "incident#123"`,
+		Args:              cmdutil.RequiredArgs("database", "branch"),
+		ValidArgsFunction: cmdutil.BranchCompletion(ch),
+		Aliases:           []string{"p"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			database := args[0]
 			branch := args[1]
-			name := args[2]
+
+			var name string
+			if len(args) > 2 {
+				name = args[2]
+			}
+
+			name, err := cmdutil.ResourceName(ch, "password", name, database)
+			if err != nil {
+				return err
+			}
+
+			if flags.breakGlass && flags.ttl == 0 {
+				flags.ttl = defaultBreakGlassTTL
+			}
+			if err := validateBreakGlass(flags.breakGlass, flags.ttl, flags.reason); err != nil {
+				return err
+			}
+			if flags.breakGlass && flags.role == "" {
+				flags.role = breakGlassRole
+			}
 
 			if flags.role != "" {
 				_, err := cmdutil.RoleFromString(flags.role)
@@ -45,6 +83,27 @@ func CreateCmd(ch *cmdutil.Helper) *cobra.Command {
 				return err
 			}
 
+			if flags.dedupeWindow > 0 {
+				existing, err := duplicatePassword(ctx, client, createReq, flags.dedupeWindow)
+				if err != nil {
+					return cmdutil.HandleError(err)
+				}
+
+				if existing != nil {
+					if ch.Printer.Format() == printer.Human && !ch.Printer.Quiet() {
+						ch.Printer.Printf("Password %s was already created in %s/%s at %s, skipping creation (its plain text can't be shown again).\n",
+							printer.BoldBlue(existing.Name), printer.BoldBlue(database), printer.BoldBlue(branch), existing.CreatedAt.Format(time.RFC3339))
+						return nil
+					}
+
+					return ch.Printer.PrintResource(toPassword(existing))
+				}
+			}
+
+			if flags.breakGlass {
+				journal.SetReason(fmt.Sprintf("break-glass for %s: %s", flags.ttl, flags.reason))
+			}
+
 			end := ch.Printer.PrintProgress(fmt.Sprintf("Creating password of %s/%s...", printer.BoldBlue(database), printer.BoldBlue(branch)))
 			defer end()
 
@@ -60,6 +119,21 @@ func CreateCmd(ch *cmdutil.Helper) *cobra.Command {
 			}
 
 			end()
+
+			if flags.breakGlass {
+				op, err := scheduleRevocation(ch.Config, pass, createReq, flags.ttl)
+				if err != nil && op == nil {
+					return fmt.Errorf("break-glass password %s was created but its revocation can't be scheduled, delete it manually: %s",
+						printer.BoldBlue(pass.PublicID), err)
+				}
+				if err != nil {
+					ch.Printer.Printf("%s\n", printer.BoldRed(err.Error()))
+				} else if ch.Printer.Format() == printer.Human {
+					ch.Printer.Printf("Break-glass password expires at %s and will be revoked automatically.\n",
+						op.ExpiresAt.Format(time.RFC3339))
+				}
+			}
+
 			if ch.Printer.Format() == printer.Human {
 				saveWarning := printer.BoldRed("Please save the values below as they will not be shown again")
 				ch.Printer.Printf("Password %s was successfully created in %s/%s.\n%s\n\n",
@@ -72,5 +146,30 @@ func CreateCmd(ch *cmdutil.Helper) *cobra.Command {
 	cmd.PersistentFlags().StringVar(&flags.role, "role",
 		"", "Role defines the access level, allowed values are : reader, writer, readwriter, admin. By default it is reader.")
 	cmd.PersistentFlags().MarkHidden("role")
+	cmdutil.DedupeWindowFlag(cmd, &flags.dedupeWindow)
+	cmd.Flags().BoolVar(&flags.breakGlass, "break-glass", false, "Create a short-lived admin password for emergencies, requires --reason")
+	cmd.Flags().DurationVar(&flags.ttl, "ttl", 0, "Time after which a break-glass password is revoked (default 30m)")
+	cmd.Flags().StringVar(&flags.reason, "reason", "", "Reason for a break-glass password, i.e. the incident it's needed for")
 	return cmd
 }
+
+// duplicatePassword returns the password with the same name that was created
+// within the window or nil if there's none.
+func duplicatePassword(ctx context.Context, client *ps.Client, req *ps.DatabaseBranchPasswordRequest, window time.Duration) (*ps.DatabaseBranchPassword, error) {
+	passwords, err := client.Passwords.List(ctx, &ps.ListDatabaseBranchPasswordRequest{
+		Organization: req.Organization,
+		Database:     req.Database,
+		Branch:       req.Branch,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range passwords {
+		if p.Name == req.DisplayName && cmdutil.IsDuplicate(p.CreatedAt, window) {
+			return p, nil
+		}
+	}
+
+	return nil, nil
+}
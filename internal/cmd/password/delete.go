@@ -19,10 +19,11 @@ func DeleteCmd(ch *cmdutil.Helper) *cobra.Command {
 	var force bool
 
 	cmd := &cobra.Command{
-		Use:     "delete <database> <branch> <password>",
-		Short:   "Delete a branch password",
-		Args:    cmdutil.RequiredArgs("database", "branch", "password"),
-		Aliases: []string{"rm"},
+		Use:               "delete <database> <branch> <password>",
+		Short:             "Delete a branch password",
+		Args:              cmdutil.RequiredArgs("database", "branch", "password"),
+		ValidArgsFunction: cmdutil.BranchCompletion(ch),
+		Aliases:           []string{"rm"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			database := args[0]
@@ -34,7 +35,12 @@ func DeleteCmd(ch *cmdutil.Helper) *cobra.Command {
 				return err
 			}
 
-			if !force {
+			password, err = cmdutil.ConfirmPassword(ctx, client, ch.Config.Organization, database, branch, password)
+			if err != nil {
+				return err
+			}
+
+			if !force && !cmdutil.AutoApproved(ctx, ch, client, database, branch) {
 				if ch.Printer.Format() != printer.Human {
 					return fmt.Errorf("cannot delete password with the output format %q (run with -force to override)", ch.Printer.Format())
 				}
@@ -28,6 +28,10 @@ func TestPassword_DeleteCmd(t *testing.T) {
 	password := "mypassword"
 
 	svc := &mock.PasswordsService{
+		GetFn: func(ctx context.Context, req *ps.GetDatabaseBranchPasswordRequest) (*ps.DatabaseBranchPassword, error) {
+			c.Assert(req.PasswordId, qt.Equals, password)
+			return &ps.DatabaseBranchPassword{PublicID: password, Name: "admin"}, nil
+		},
 		DeleteFn: func(ctx context.Context, req *ps.DeleteDatabaseBranchPasswordRequest) error {
 			c.Assert(req.Organization, qt.Equals, org)
 			c.Assert(req.Database, qt.Equals, db)
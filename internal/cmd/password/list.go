@@ -14,10 +14,11 @@ import (
 // ListCmd encapsulates the command for listing passwords for a branch.
 func ListCmd(ch *cmdutil.Helper) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "list <database> [branch]",
-		Short:   "List all passwords of a database",
-		Args:    cmdutil.RequiredArgs("database"),
-		Aliases: []string{"ls"},
+		Use:               "list <database> [branch]",
+		Short:             "List all passwords of a database",
+		Args:              cmdutil.RequiredArgs("database"),
+		ValidArgsFunction: cmdutil.DatabaseCompletion(ch),
+		Aliases:           []string{"ls"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
 			database := args[0]
@@ -0,0 +1,161 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Prefix is the prefix of the executables run as plugins, i.e. the plugin
+// "audit" is the executable pscale-audit.
+const Prefix = "pscale-"
+
+// Lookup returns the plugin the arguments run, if the command they name
+// isn't built in and a pscale-<name> executable is on PATH.
+func Lookup(root *cobra.Command, args []string) (string, string, bool) {
+	name := commandName(root.PersistentFlags(), args)
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", "", false
+	}
+
+	// "help" and "completion" are added by cobra itself
+	if name == "help" || name == "completion" || strings.HasPrefix(name, "__") {
+		return "", "", false
+	}
+	for _, c := range root.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return "", "", false
+		}
+	}
+
+	path, err := exec.LookPath(Prefix + name)
+	if err != nil {
+		return "", "", false
+	}
+	return name, path, true
+}
+
+// commandName returns the first argument that is neither a flag nor the
+// value of a flag.
+func commandName(flags *pflag.FlagSet, args []string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return ""
+		}
+		if !strings.HasPrefix(arg, "-") {
+			return arg
+		}
+		if strings.Contains(arg, "=") {
+			continue
+		}
+
+		var f *pflag.Flag
+		if strings.HasPrefix(arg, "--") {
+			f = flags.Lookup(arg[2:])
+		} else if len(arg) == 2 {
+			f = flags.ShorthandLookup(arg[1:])
+		}
+
+		// the next argument is the value of the flag
+		if f != nil && f.NoOptDefVal == "" {
+			i++
+		}
+	}
+	return ""
+}
+
+// PluginCmd returns the command running the plugin at path. The arguments
+// are passed to the plugin as they are, along with the resolved
+// organization, database, branch and credentials as environment variables.
+func PluginCmd(ch *cmdutil.Helper, name, path string) *cobra.Command {
+	return &cobra.Command{
+		Use:                name,
+		Short:              fmt.Sprintf("Run the %s%s plugin", Prefix, name),
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			env, err := environ(cmd, ch)
+			if err != nil {
+				return err
+			}
+
+			c := exec.CommandContext(cmd.Context(), path, args...)
+			c.Env = append(os.Environ(), env...)
+			c.Stdin = os.Stdin
+			c.Stdout = os.Stdout
+			c.Stderr = os.Stderr
+
+			err = c.Run()
+			var ee *exec.ExitError
+			if errors.As(err, &ee) {
+				return &cmdutil.Error{
+					Msg:      fmt.Sprintf("plugin %s%s exited with status %d", Prefix, name, ee.ExitCode()),
+					ExitCode: ee.ExitCode(),
+				}
+			}
+			return err
+		},
+	}
+}
+
+// environ returns the environment variables passed to a plugin. They're the
+// ones pscale reads itself, so plugins can run pscale commands as well.
+func environ(cmd *cobra.Command, ch *cmdutil.Helper) ([]string, error) {
+	cfg := ch.Config
+
+	org := cfg.Organization
+	if org == "" {
+		org = viper.GetString("org")
+	}
+
+	env := []string{"PLANETSCALE_API_URL=" + cfg.BaseURL}
+	for key, value := range map[string]string{
+		"PLANETSCALE_ORG":      org,
+		"PLANETSCALE_DATABASE": viper.GetString("database"),
+		"PLANETSCALE_BRANCH":   viper.GetString("branch"),
+	} {
+		if value != "" {
+			env = append(env, key+"="+value)
+		}
+	}
+
+	if exe, err := os.Executable(); err == nil {
+		env = append(env, "PSCALE_BIN="+exe)
+	}
+
+	serviceTokenID, serviceToken := cfg.ServiceTokenID, cfg.ServiceToken
+	if (serviceTokenID == "" || serviceToken == "") && cfg.CredentialSource != nil {
+		var err error
+		serviceTokenID, serviceToken, err = cfg.CredentialSource.ServiceToken()
+		if err != nil {
+			return nil, fmt.Errorf("couldn't fetch service token from %s: %s", cfg.CredentialSource.Name(), err)
+		}
+	}
+
+	if serviceTokenID != "" && serviceToken != "" {
+		return append(env,
+			"PLANETSCALE_SERVICE_TOKEN_ID="+serviceTokenID,
+			"PLANETSCALE_SERVICE_TOKEN="+serviceToken,
+		), nil
+	}
+
+	// the plugin may run for a while, hand it a token that doesn't expire
+	// right away
+	token, err := cfg.FreshAccessToken(cmd.Context())
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		env = append(env, "PLANETSCALE_API_TOKEN="+token)
+	}
+
+	return env, nil
+}
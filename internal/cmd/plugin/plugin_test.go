@@ -0,0 +1,73 @@
+package plugin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+)
+
+func testRoot() *cobra.Command {
+	root := &cobra.Command{Use: "pscale"}
+	root.PersistentFlags().String("org", "", "")
+	root.PersistentFlags().StringP("format", "f", "human", "")
+	root.PersistentFlags().Bool("debug", false, "")
+	root.AddCommand(&cobra.Command{Use: "branch", Aliases: []string{"branches"}})
+	return root
+}
+
+func TestCommandName(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"audit", "--flag"}, want: "audit"},
+		{args: []string{"--org", "acme", "audit"}, want: "audit"},
+		{args: []string{"--org=acme", "audit"}, want: "audit"},
+		{args: []string{"-f", "json", "audit"}, want: "audit"},
+		{args: []string{"--debug", "audit"}, want: "audit"},
+		{args: []string{"--", "audit"}, want: ""},
+		{args: []string{"--debug"}, want: ""},
+	}
+
+	root := testRoot()
+	for _, tt := range tests {
+		c := qt.New(t)
+		c.Assert(commandName(root.PersistentFlags(), tt.args), qt.Equals, tt.want, qt.Commentf("%v", tt.args))
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are looked up with PATHEXT on Windows")
+	}
+	c := qt.New(t)
+
+	dir := t.TempDir()
+	for _, name := range []string{"pscale-audit", "pscale-branch"} {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0755)
+		c.Assert(err, qt.IsNil)
+	}
+
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir)
+	defer os.Setenv("PATH", path)
+
+	root := testRoot()
+
+	name, plugin, ok := Lookup(root, []string{"--org", "acme", "audit", "run"})
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(name, qt.Equals, "audit")
+	c.Assert(plugin, qt.Equals, filepath.Join(dir, "pscale-audit"))
+
+	// built-in commands take precedence
+	_, _, ok = Lookup(root, []string{"branch"})
+	c.Assert(ok, qt.IsFalse)
+
+	_, _, ok = Lookup(root, []string{"missing"})
+	c.Assert(ok, qt.IsFalse)
+}
@@ -0,0 +1,179 @@
+package project
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+const envrcFile = ".envrc"
+
+// InitCmd is the command for initializing the PlanetScale configuration of a
+// project.
+func InitCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		database string
+		branch   string
+		direnv   bool
+		tunnel   bool
+		port     int
+		force    bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Initialize the PlanetScale configuration of the current project",
+		Long: `Initialize the PlanetScale configuration of the current project.
+
+The organization, database and branch are written to the project configuration
+file at the root of the git repository. The other values of an existing file
+are kept. With --direnv, an .envrc file is
+generated as well, which exports them as PLANETSCALE_ORG, PLANETSCALE_DATABASE
+and PLANETSCALE_BRANCH whenever direnv loads the project directory.`,
+		Args: cobra.NoArgs,
+		Example: `Initialize the project and generate an .envrc file:
+
+  pscale init --database mydb --branch dev --direnv
+
+Also start a tunnel to the branch on port 3309 when entering the directory:
+
+  pscale init --database mydb --branch dev --direnv --tunnel --port 3309`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.tunnel && !flags.direnv {
+				return errors.New("--tunnel requires --direnv")
+			}
+
+			if ch.Config.Organization == "" {
+				return errors.New("no organization is set, run 'pscale org switch' or pass --org")
+			}
+
+			cfg := config.FileConfig{
+				Organization: ch.Config.Organization,
+				Database:     flags.database,
+				Branch:       flags.branch,
+			}
+
+			cfgPath, err := config.ProjectConfigPath()
+			if err != nil {
+				return err
+			}
+
+			// both files are checked before either is written, so init
+			// isn't applied halfway
+			envrcPath := ""
+			if flags.direnv {
+				envrcPath = filepath.Join(filepath.Dir(cfgPath), envrcFile)
+				if _, err := os.Stat(envrcPath); err == nil && !flags.force {
+					return fmt.Errorf("%s already exists (run with --force to overwrite)", envrcPath)
+				}
+			}
+
+			// an existing project configuration keeps its other values, i.e.
+			// its environments and aliases
+			err = config.SetValues(cfgPath, yaml.MapSlice{
+				{Key: "org", Value: cfg.Organization},
+				{Key: "database", Value: cfg.Database},
+				{Key: "branch", Value: cfg.Branch},
+			})
+			if err != nil {
+				return errors.Wrap(err, "error writing project configuration file")
+			}
+
+			if envrcPath != "" {
+				port := 0
+				if flags.tunnel {
+					port = flags.port
+				}
+
+				if err := ioutil.WriteFile(envrcPath, envrc(&cfg, port), 0644); err != nil {
+					return errors.Wrap(err, "error writing .envrc file")
+				}
+			}
+
+			if ch.Printer.Format() != printer.Human {
+				res := map[string]string{
+					"org":      cfg.Organization,
+					"database": cfg.Database,
+					"branch":   cfg.Branch,
+					"config":   cfgPath,
+				}
+				if envrcPath != "" {
+					res["envrc"] = envrcPath
+				}
+				return ch.Printer.PrintResource(res)
+			}
+
+			ch.Printer.Printf("Project initialized for database %s and branch %s (organization: %s).\n",
+				printer.BoldBlue(cfg.Database), printer.BoldBlue(cfg.Branch), printer.BoldBlue(cfg.Organization))
+			if envrcPath != "" {
+				ch.Printer.Printf("Generated %s, run %s to load it.\n", envrcPath, printer.Bold("direnv allow"))
+			}
+
+			return nil
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(&ch.Config.Organization, "org", ch.Config.Organization,
+		"The organization for the current user")
+	cmd.Flags().StringVar(&flags.database, "database", ch.Config.Database, "The database this project is using")
+	cmd.Flags().StringVar(&flags.branch, "branch", "main", "The branch this project is using")
+	cmd.Flags().BoolVar(&flags.direnv, "direnv", false, "Generate an .envrc file that exports the project context")
+	cmd.Flags().BoolVar(&flags.tunnel, "tunnel", false, "Start a tunnel to the branch when direnv loads the .envrc file")
+	cmd.Flags().IntVar(&flags.port, "port", 3306, "Local port of the tunnel started via --tunnel")
+	cmd.Flags().BoolVar(&flags.force, "force", false, "Overwrite an existing .envrc file")
+
+	cmd.MarkFlagRequired("database") // nolint:errcheck
+
+	return cmd
+}
+
+// envrc returns the content of an .envrc file exporting the given
+// configuration. If port is non-zero, a tunnel is started on the given port.
+func envrc(cfg *config.FileConfig, port int) []byte {
+	var b bytes.Buffer
+	b.WriteString("# Generated by 'pscale init --direnv'.\n")
+	fmt.Fprintf(&b, "export PLANETSCALE_ORG=%s\n", shellQuote(cfg.Organization))
+	fmt.Fprintf(&b, "export PLANETSCALE_DATABASE=%s\n", shellQuote(cfg.Database))
+	fmt.Fprintf(&b, "export PLANETSCALE_BRANCH=%s\n", shellQuote(cfg.Branch))
+
+	if port == 0 {
+		return b.Bytes()
+	}
+
+	b.WriteString(`
+# pscale_tunnel starts 'pscale connect' in the background, unless a tunnel is
+# already running for this directory.
+pscale_tunnel() {
+  local dir pidfile
+  dir="$(direnv_layout_dir)"
+  pidfile="$dir/pscale-connect.pid"
+  if [ -f "$pidfile" ] && kill -0 "$(cat "$pidfile")" 2>/dev/null; then
+    return
+  fi
+  mkdir -p "$dir"
+  pscale connect "$PLANETSCALE_DATABASE" "$PLANETSCALE_BRANCH" --port "$1" >"$dir/pscale-connect.log" 2>&1 &
+  echo $! >"$pidfile"
+}
+`)
+	fmt.Fprintf(&b, "pscale_tunnel %d\n", port)
+	fmt.Fprintf(&b, "export PLANETSCALE_TUNNEL_ADDR=127.0.0.1:%d\n", port)
+
+	return b.Bytes()
+}
+
+// shellQuote quotes s for the shell. Nothing is expanded within single
+// quotes, a single quote ends them and is escaped outside of them.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,82 @@
+package project
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestProject_Envrc(t *testing.T) {
+	c := qt.New(t)
+
+	cfg := &config.FileConfig{
+		Organization: "acme",
+		Database:     "mydb",
+		Branch:       "it's-$HOME",
+	}
+
+	out := string(envrc(cfg, 0))
+	c.Assert(out, qt.Contains, `export PLANETSCALE_ORG='acme'`)
+	c.Assert(out, qt.Contains, `export PLANETSCALE_DATABASE='mydb'`)
+	c.Assert(out, qt.Contains, `export PLANETSCALE_BRANCH='it'\''s-$HOME'`)
+	c.Assert(out, qt.Not(qt.Contains), "pscale_tunnel")
+
+	out = string(envrc(cfg, 3309))
+	c.Assert(out, qt.Contains, "pscale_tunnel 3309\n")
+	c.Assert(out, qt.Contains, "export PLANETSCALE_TUNNEL_ADDR=127.0.0.1:3309\n")
+}
+
+func TestProject_InitCmd(t *testing.T) {
+	c := qt.New(t)
+
+	dir := t.TempDir()
+	c.Assert(os.Mkdir(filepath.Join(dir, ".git"), 0755), qt.IsNil)
+	project := filepath.Join(dir, ".pscale.yml")
+	existing := "org: acme\ndatabase: old\nenvironments:\n  staging:\n    branch: staging\n"
+	c.Assert(ioutil.WriteFile(project, []byte(existing), 0644), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, ".envrc"), []byte("# mine\n"), 0644), qt.IsNil)
+	wd, err := os.Getwd()
+	c.Assert(err, qt.IsNil)
+	c.Assert(os.Chdir(dir), qt.IsNil)
+	c.Cleanup(func() { os.Chdir(wd) }) // nolint:errcheck
+
+	var buf bytes.Buffer
+	format := printer.Human
+	p := printer.NewPrinter(&format)
+	p.SetHumanOutput(&buf)
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config:  &config.Config{Organization: "acme"},
+	}
+
+	// nothing is written if the .envrc file exists
+	cmd := InitCmd(ch)
+	cmd.SetArgs([]string{"--database", "mydb", "--branch", "dev", "--direnv"})
+	c.Assert(cmd.Execute(), qt.ErrorMatches, `.*\.envrc already exists \(run with --force to overwrite\)`)
+
+	out, err := ioutil.ReadFile(project)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, existing)
+
+	// the project config keeps its other values
+	cmd = InitCmd(ch)
+	cmd.SetArgs([]string{"--database", "mydb", "--branch", "dev", "--direnv", "--force"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	out, err = ioutil.ReadFile(project)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, "org: acme\ndatabase: mydb\nenvironments:\n  staging:\n    branch: staging\nbranch: dev\n")
+
+	out, err = ioutil.ReadFile(filepath.Join(dir, ".envrc"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Contains, "export PLANETSCALE_DATABASE='mydb'\n")
+}
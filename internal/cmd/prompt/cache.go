@@ -0,0 +1,100 @@
+package prompt
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/planetscale/cli/internal/config"
+)
+
+const cacheFile = "prompt-cache.json"
+
+// cacheEntry is the context resolved from the config files of a single
+// working directory.
+type cacheEntry struct {
+	Org        string    `json:"org"`
+	Database   string    `json:"database,omitempty"`
+	Branch     string    `json:"branch,omitempty"`
+	ResolvedAt time.Time `json:"resolved_at"`
+
+	// Files are the config files the entry was resolved from, including
+	// ones that didn't exist at the time. The entry is invalid once any of
+	// them is modified.
+	Files []string `json:"files"`
+}
+
+// valid reports whether none of the config files the entry depends on were
+// changed since it was resolved. It only stats files, which keeps the prompt
+// fast.
+func (e *cacheEntry) valid() bool {
+	for _, f := range e.Files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return false
+		}
+
+		if fi.ModTime().After(e.ResolvedAt) {
+			return false
+		}
+	}
+	return true
+}
+
+func cachePath() (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, cacheFile), nil
+}
+
+// readCache returns the cached entries keyed by working directory. A missing
+// or corrupted cache returns an empty set of entries.
+func readCache() map[string]*cacheEntry {
+	entries := make(map[string]*cacheEntry)
+
+	p, err := cachePath()
+	if err != nil {
+		return entries
+	}
+
+	out, err := ioutil.ReadFile(p)
+	if err != nil {
+		return entries
+	}
+
+	if err := json.Unmarshal(out, &entries); err != nil {
+		return make(map[string]*cacheEntry)
+	}
+
+	return entries
+}
+
+// writeCache stores the entry for the given working directory.
+func writeCache(dir string, entry *cacheEntry) error {
+	p, err := cachePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(path.Dir(p), 0771); err != nil {
+		return err
+	}
+
+	entries := readCache()
+	entries[dir] = entry
+
+	out, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(p, out, 0644)
+}
@@ -0,0 +1,226 @@
+package prompt
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultStaleAfter is the age after which a cached context is marked as
+// stale.
+const defaultStaleAfter = 24 * time.Hour
+
+// promptContext is the current context printed by the prompt.
+type promptContext struct {
+	Org      string `header:"org" json:"org"`
+	Database string `header:"database" json:"database"`
+	Branch   string `header:"branch" json:"branch"`
+	Stale    bool   `header:"stale" json:"stale"`
+}
+
+// String returns the context as "org/database/branch", omitting unset parts.
+// A stale context is suffixed with an asterisk.
+func (p *promptContext) String() string {
+	parts := make([]string, 0, 3)
+	for _, s := range []string{p.Org, p.Database, p.Branch} {
+		if s == "" {
+			break
+		}
+		parts = append(parts, s)
+	}
+
+	s := strings.Join(parts, "/")
+	if s != "" && p.Stale {
+		s += "*"
+	}
+	return s
+}
+
+// PromptCmd is the command for printing the current context for shell
+// prompts.
+func PromptCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		refresh    bool
+		staleAfter time.Duration
+	}
+
+	cmd := &cobra.Command{
+		Use:   "prompt",
+		Short: "Print the current organization, database and branch for shell prompts",
+		Long: `Print the current organization, database and branch for shell prompts.
+
+The context is resolved from the global and project configuration files and
+cached per directory, so the command is fast enough to run on every prompt.
+PLANETSCALE_ORG, PLANETSCALE_DATABASE and PLANETSCALE_BRANCH take precedence
+over the configuration files. Contexts that were resolved longer than
+--stale-after ago are suffixed with an asterisk.`,
+		Args: cobra.NoArgs,
+		Example: `Add the context to a bash prompt:
+
+  PS1='$(pscale prompt) \$ '
+
+Use it in a starship custom module:
+
+  [custom.pscale]
+  command = "pscale prompt"
+  when = "true"
+
+Print the context as JSON:
+
+  pscale prompt --format json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+
+			entry := readCache()[dir]
+			if flags.refresh || entry == nil || !entry.valid() {
+				entry, err = resolve(ch.ConfigFS)
+				if err != nil {
+					return err
+				}
+
+				if err := writeCache(dir, entry); err != nil && ch.Debug() {
+					ch.Printer.Printf("couldn't write prompt cache: %s\n", err)
+				}
+			}
+
+			return printContext(ch.Printer, entry, flags.staleAfter)
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.refresh, "refresh", false, "Resolve the context again instead of using the cache")
+	cmd.Flags().DurationVar(&flags.staleAfter, "stale-after", defaultStaleAfter,
+		"Mark the context as stale if it was resolved longer than the given duration ago")
+
+	return cmd
+}
+
+// Fast prints the cached context for the current directory without setting
+// up the CLI. It returns false if the arguments aren't supported by the fast
+// path or no valid cache entry exists, in which case the regular command has
+// to be run.
+func Fast(args []string, w io.Writer) bool {
+	format := printer.Human
+	for i := 0; i < len(args); i++ {
+		var value string
+		switch arg := args[i]; {
+		case arg == "--format" || arg == "-f":
+			if i+1 >= len(args) {
+				return false
+			}
+			i++
+			value = args[i]
+		case strings.HasPrefix(arg, "--format="):
+			value = strings.TrimPrefix(arg, "--format=")
+		default:
+			return false
+		}
+
+		if err := format.Set(value); err != nil {
+			return false
+		}
+	}
+
+	// the format might be overridden via the environment
+	if _, ok := os.LookupEnv(config.EnvPrefix + "FORMAT"); ok {
+		return false
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return false
+	}
+
+	entry := readCache()[dir]
+	if entry == nil || !entry.valid() {
+		return false
+	}
+
+	p := printer.NewPrinter(&format)
+	p.SetHumanOutput(w)
+	p.SetResourceOutput(w)
+
+	return printContext(p, entry, defaultStaleAfter) == nil
+}
+
+// resolve reads the context from the global and project configuration
+// files. The project configuration takes precedence.
+func resolve(cfs *config.ConfigFS) (*cacheEntry, error) {
+	globalPath, err := config.DefaultConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	projectPath, err := config.ProjectConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &cacheEntry{
+		ResolvedAt: time.Now(),
+		Files:      []string{globalPath, projectPath},
+	}
+
+	for _, p := range entry.Files {
+		cfg, err := cfs.NewFileConfig(p)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		if cfg.Organization != "" {
+			entry.Org = cfg.Organization
+		}
+		if cfg.Database != "" {
+			entry.Database = cfg.Database
+		}
+		if cfg.Branch != "" {
+			entry.Branch = cfg.Branch
+		}
+	}
+
+	return entry, nil
+}
+
+// printContext prints the context of the entry, with environment variables taking
+// precedence over the cached values.
+func printContext(p *printer.Printer, entry *cacheEntry, staleAfter time.Duration) error {
+	ctx := &promptContext{
+		Org:      entry.Org,
+		Database: entry.Database,
+		Branch:   entry.Branch,
+		Stale:    staleAfter > 0 && time.Since(entry.ResolvedAt) > staleAfter,
+	}
+
+	if v := os.Getenv(config.EnvPrefix + "ORG"); v != "" {
+		ctx.Org = v
+	}
+	if v := os.Getenv(config.EnvPrefix + "DATABASE"); v != "" {
+		ctx.Database = v
+	}
+	if v := os.Getenv(config.EnvPrefix + "BRANCH"); v != "" {
+		ctx.Branch = v
+	}
+
+	if p.Format() == printer.Human {
+		if s := ctx.String(); s != "" {
+			p.Println(s)
+		}
+		return nil
+	}
+
+	return p.PrintResource(ctx)
+}
@@ -0,0 +1,45 @@
+package prompt
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestPrompt_Resolve(t *testing.T) {
+	c := qt.New(t)
+
+	globalPath, err := config.DefaultConfigPath()
+	c.Assert(err, qt.IsNil)
+	projectPath, err := config.ProjectConfigPath()
+	c.Assert(err, qt.IsNil)
+
+	testfs := testutil.MemFS{
+		globalPath:  &fstest.MapFile{Data: []byte("org: global-org\ndatabase: other\n")},
+		projectPath: &fstest.MapFile{Data: []byte("org: acme\ndatabase: mydb\nbranch: dev\n")},
+	}
+
+	entry, err := resolve(config.NewConfigFS(testfs))
+	c.Assert(err, qt.IsNil)
+	c.Assert(entry.Org, qt.Equals, "acme")
+	c.Assert(entry.Database, qt.Equals, "mydb")
+	c.Assert(entry.Branch, qt.Equals, "dev")
+	c.Assert(entry.Files, qt.DeepEquals, []string{globalPath, projectPath})
+}
+
+func TestPrompt_String(t *testing.T) {
+	c := qt.New(t)
+
+	ctx := &promptContext{Org: "acme", Database: "mydb", Branch: "dev"}
+	c.Assert(ctx.String(), qt.Equals, "acme/mydb/dev")
+
+	ctx = &promptContext{Org: "acme", Branch: "dev", Stale: true}
+	c.Assert(ctx.String(), qt.Equals, "acme*")
+
+	ctx = &promptContext{Stale: true}
+	c.Assert(ctx.String(), qt.Equals, "")
+}
@@ -0,0 +1,177 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+
+	"github.com/spf13/cobra"
+)
+
+// scatterExitCode is the exit code of --fail-on-scatter if the query
+// scatters, so CI checks can tell it from failures.
+const scatterExitCode = 2
+
+// RouteCmd shows the keyspaces and shards a query is routed to.
+func RouteCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		failOnScatter bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "route <database> <branch> <query>",
+		Short: "Show which keyspaces and shards a query is routed to",
+		Long: fmt.Sprintf(`Show which keyspaces and shards a query is routed to.
+
+The routing plan of the query is read with VEXPLAIN PLAN, which doesn't run
+the query. Each route of the plan is listed with its keyspace and the shards
+it's sent to: one, some or all of them. A route sent to all shards of a
+sharded keyspace scatters, which is expensive at scale. With
+--fail-on-scatter, the command exits with status %d if the query scatters.`, scatterExitCode),
+		Args: cmdutil.RequiredArgs("database", "branch", "query"),
+		Example: `  pscale query route mydb main "SELECT * FROM users WHERE id = 1"
+  pscale query route mydb main "SELECT * FROM users WHERE email = 'a@example.com'" --fail-on-scatter`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch, query := args[0], args[1], args[2]
+
+			db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.ReaderRole)
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			plan, err := readPlan(ctx, db, query)
+			if err != nil {
+				return err
+			}
+
+			routes := planRoutes(plan)
+			scatters := false
+			for _, r := range routes {
+				scatters = scatters || r.Scatter
+			}
+
+			if ch.Printer.Format() != printer.Human {
+				if err := ch.Printer.PrintResource(map[string]interface{}{"routes": routes, "scatter": scatters}); err != nil {
+					return err
+				}
+			} else {
+				if err := ch.Printer.PrintResource(routes); err != nil {
+					return err
+				}
+				if scatters {
+					ch.Printer.Printf("\nThe query scatters to all shards, filter by the sharding key to route it to a single shard.\n")
+				}
+			}
+
+			if scatters && flags.failOnScatter {
+				return &cmdutil.Error{
+					Msg:      "the query scatters to all shards",
+					ExitCode: scatterExitCode,
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.failOnScatter, "fail-on-scatter", false,
+		fmt.Sprintf("Exit with status %d if the query scatters to all shards", scatterExitCode))
+
+	return cmd
+}
+
+// planNode is an operator of a Vitess query plan.
+type planNode struct {
+	OperatorType string `json:"OperatorType"`
+	Variant      string `json:"Variant"`
+	Keyspace     *struct {
+		Name    string `json:"Name"`
+		Sharded bool   `json:"Sharded"`
+	} `json:"Keyspace"`
+	Table  string      `json:"Table"`
+	Query  string      `json:"Query"`
+	Inputs []*planNode `json:"Inputs"`
+}
+
+// readPlan returns the routing plan of the query.
+func readPlan(ctx context.Context, db *sql.DB, query string) (*planNode, error) {
+	var out string
+	if err := db.QueryRowContext(ctx, "VEXPLAIN PLAN "+query).Scan(&out); err != nil {
+		return nil, fmt.Errorf("couldn't read the routing plan: %s", err)
+	}
+
+	plan := &planNode{}
+	if err := json.Unmarshal([]byte(out), plan); err != nil {
+		return nil, fmt.Errorf("couldn't parse the routing plan: %s", err)
+	}
+	return plan, nil
+}
+
+// route is an operator of a plan sending a query to a keyspace.
+type route struct {
+	Operator string `header:"operator" json:"operator"`
+	Keyspace string `header:"keyspace" json:"keyspace"`
+	Sharded  bool   `header:"sharded" json:"sharded"`
+	Variant  string `header:"variant" json:"variant"`
+	Shards   string `header:"shards" json:"shards"`
+	Scatter  bool   `header:"scatter" json:"scatter"`
+	Query    string `header:"query,n/a" json:"query,omitempty"`
+}
+
+// variantShards are the shards the variants of routes are sent to.
+var variantShards = map[string]string{
+	"Unsharded":     "one",
+	"Reference":     "one",
+	"DBA":           "one",
+	"Next":          "one",
+	"EqualUnique":   "one",
+	"Equal":         "some",
+	"IN":            "some",
+	"MultiEqual":    "some",
+	"SubShard":      "some",
+	"ByDestination": "some",
+	"Scatter":       "all",
+	"None":          "none",
+}
+
+// planRoutes returns the routes of the plan in the order they're executed.
+func planRoutes(n *planNode) []*route {
+	routes := make([]*route, 0)
+	var walk func(n *planNode)
+	walk = func(n *planNode) {
+		if n == nil {
+			return
+		}
+
+		if n.Keyspace != nil {
+			shards, ok := variantShards[n.Variant]
+			if !ok {
+				shards = "unknown"
+			}
+			if !n.Keyspace.Sharded && shards != "none" {
+				shards = "one"
+			}
+
+			routes = append(routes, &route{
+				Operator: n.OperatorType,
+				Keyspace: n.Keyspace.Name,
+				Sharded:  n.Keyspace.Sharded,
+				Variant:  n.Variant,
+				Shards:   shards,
+				Scatter:  n.Keyspace.Sharded && shards == "all",
+				Query:    n.Query,
+			})
+		}
+		for _, in := range n.Inputs {
+			walk(in)
+		}
+	}
+	walk(n)
+	return routes
+}
@@ -0,0 +1,45 @@
+package query
+
+import (
+	"encoding/json"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestPlanRoutes(t *testing.T) {
+	c := qt.New(t)
+
+	const out = `{
+  "OperatorType": "Join",
+  "Variant": "Join",
+  "Inputs": [
+    {
+      "OperatorType": "Route",
+      "Variant": "EqualUnique",
+      "Keyspace": {"Name": "commerce", "Sharded": true},
+      "Query": "select u.id from users as u where u.id = 1"
+    },
+    {
+      "OperatorType": "Route",
+      "Variant": "Scatter",
+      "Keyspace": {"Name": "commerce", "Sharded": true},
+      "Query": "select o.total from orders as o where o.user_id = :u_id"
+    },
+    {
+      "OperatorType": "Route",
+      "Variant": "Unsharded",
+      "Keyspace": {"Name": "lookup", "Sharded": false}
+    }
+  ]
+}`
+
+	plan := &planNode{}
+	c.Assert(json.Unmarshal([]byte(out), plan), qt.IsNil)
+
+	c.Assert(planRoutes(plan), qt.DeepEquals, []*route{
+		{Operator: "Route", Keyspace: "commerce", Sharded: true, Variant: "EqualUnique", Shards: "one", Query: "select u.id from users as u where u.id = 1"},
+		{Operator: "Route", Keyspace: "commerce", Sharded: true, Variant: "Scatter", Shards: "all", Scatter: true, Query: "select o.total from orders as o where o.user_id = :u_id"},
+		{Operator: "Route", Keyspace: "lookup", Variant: "Unsharded", Shards: "one"},
+	})
+}
@@ -0,0 +1,49 @@
+package release
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/spf13/cobra"
+)
+
+// LogCmd prints the log of a release run.
+func LogCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "log <run-id>",
+		Short: "Print the log of a release run",
+		Args:  cmdutil.RequiredArgs("run-id"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			run, err := config.ReadReleaseRun(args[0])
+			if err != nil {
+				if errors.Is(err, config.ErrNoReleaseRun) {
+					return fmt.Errorf("release run %s does not exist", printer.BoldBlue(args[0]))
+				}
+				return err
+			}
+
+			p, err := run.LogPath()
+			if err != nil {
+				return err
+			}
+
+			out, err := ioutil.ReadFile(p)
+			if err != nil {
+				return err
+			}
+
+			if ch.Printer.Format() != printer.Human {
+				return ch.Printer.PrintResource(map[string]interface{}{"run": run, "log": string(out)})
+			}
+			ch.Printer.Print(string(out))
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,151 @@
+package release
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/schemadiff"
+
+	"gopkg.in/yaml.v2"
+)
+
+// plan is a declarative release: a branch is created, the schema changes are
+// applied to it and deployed with a deploy request, and the deployed schema
+// is verified.
+type plan struct {
+	Org      string `yaml:"org"`
+	Database string `yaml:"database"`
+	Branch   string `yaml:"branch"`
+
+	// From is the branch the release branch is created from, DeployTo the
+	// branch it's deployed to. Both default to main.
+	From     string `yaml:"from"`
+	DeployTo string `yaml:"deploy_to"`
+
+	// Schema are the statements applied to the release branch, SchemaFile a
+	// file with statements separated by semicolons, relative to the plan.
+	Schema     []string `yaml:"schema"`
+	SchemaFile string   `yaml:"schema_file"`
+
+	Notes         string        `yaml:"notes"`
+	ChecksTimeout time.Duration `yaml:"checks_timeout"`
+	DeployTimeout time.Duration `yaml:"deploy_timeout"`
+
+	Verify []*verification `yaml:"verify"`
+
+	// RollbackVerify are the queries verifying a rollback of the release.
+	RollbackVerify []*config.RollbackQuery `yaml:"rollback_verify"`
+	Notify         struct {
+		Webhook string `yaml:"webhook"`
+	} `yaml:"notify"`
+
+	// digest identifies the content of the plan, so a run isn't resumed
+	// with a changed plan.
+	digest string
+}
+
+// defaultChecksTimeout is how long the branch and the checks of the deploy
+// request are waited for by default, defaultDeployTimeout how long the
+// deployment is.
+const (
+	defaultChecksTimeout = 10 * time.Minute
+	defaultDeployTimeout = time.Hour
+)
+
+// verification is a query run on the deployed branch. It must return a row,
+// of which the first column equals Expect if it's set.
+type verification struct {
+	Name   string `yaml:"name"`
+	Query  string `yaml:"query"`
+	Expect string `yaml:"expect"`
+}
+
+// readPlan reads and validates the plan file. The plan's organization
+// defaults to org.
+func readPlan(path, org string) (*plan, error) {
+	out, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &plan{}
+	if err := yaml.UnmarshalStrict(out, p); err != nil {
+		return nil, fmt.Errorf("can't unmarshal file %q: %s", path, err)
+	}
+
+	if p.SchemaFile != "" {
+		file := p.SchemaFile
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(path), file)
+		}
+
+		schema, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		p.Schema = append(p.Schema, schemadiff.SplitStatements(string(schema))...)
+		out = append(out, schema...)
+	}
+
+	sum := sha256.Sum256(out)
+	p.digest = hex.EncodeToString(sum[:])
+
+	if p.Org == "" {
+		p.Org = org
+	}
+	if p.From == "" {
+		p.From = "main"
+	}
+	if p.DeployTo == "" {
+		p.DeployTo = "main"
+	}
+	if p.ChecksTimeout == 0 {
+		p.ChecksTimeout = defaultChecksTimeout
+	}
+	if p.DeployTimeout == 0 {
+		p.DeployTimeout = defaultDeployTimeout
+	}
+
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("plan %q: %s", path, err)
+	}
+	return p, nil
+}
+
+func (p *plan) validate() error {
+	switch {
+	case p.Org == "":
+		return fmt.Errorf("no organization is set")
+	case p.Database == "":
+		return fmt.Errorf("database is required")
+	case p.Branch == "":
+		return fmt.Errorf("branch is required")
+	case p.Branch == p.DeployTo:
+		return fmt.Errorf("branch %q can't be deployed to itself", p.Branch)
+	case len(p.Schema) == 0:
+		return fmt.Errorf("schema or schema_file is required")
+	}
+
+	for i, v := range p.Verify {
+		if v.Query == "" {
+			return fmt.Errorf("verification %d has no query", i+1)
+		}
+		if v.Name == "" {
+			v.Name = fmt.Sprintf("verification %d", i+1)
+		}
+	}
+	for i, v := range p.RollbackVerify {
+		if v.Query == "" {
+			return fmt.Errorf("rollback verification %d has no query", i+1)
+		}
+		if v.Name == "" {
+			v.Name = fmt.Sprintf("rollback verification %d", i+1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package release
+
+import (
+	"github.com/planetscale/cli/internal/cmdutil"
+
+	"github.com/spf13/cobra"
+)
+
+// ReleaseCmd encapsulates the commands for running release plans.
+func ReleaseCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "release <command>",
+		Short:             "Run declarative release plans",
+		PersistentPreRunE: cmdutil.CheckAuthentication(ch.Config),
+	}
+
+	cmd.PersistentFlags().StringVar(&ch.Config.Organization, "org", ch.Config.Organization,
+		"The organization of the plans that don't set one")
+
+	cmd.AddCommand(RunCmd(ch))
+	cmd.AddCommand(LogCmd(ch))
+	cmd.AddCommand(RollbackCmd(ch))
+
+	return cmd
+}
@@ -0,0 +1,237 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmd/deployrequest"
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/schemadiff"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+// RollbackCmd is the command for rolling back a release or a deployment.
+func RollbackCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		keepCredentials bool
+		override        bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "rollback <run-id>",
+		Short: "Roll back a release or a deployment",
+		Long: `Roll back a release or a deployment.
+
+A rollback manifest is saved when a release run, or 'pscale deploy-request
+deploy --save-rollback', deploys a deploy request. It records the schema of
+the branch deployed to, when the deploy request was opened and the
+rollback_verify queries of the release plan. Deployments are identified by
+deploy-<database>-<number>.
+
+The rollback reverts the schema with a deploy request from a new branch,
+rollback-<number>, deletes the passwords of the branch created since the
+deploy request was opened, which have to be recreated, and runs the
+verification queries. It's logged and resumable like
+a release run, running the command again resumes it.`,
+		Args: cmdutil.RequiredArgs("run-id"),
+		Example: `  pscale release rollback mydb-20261015-093000
+  pscale release rollback deploy-mydb-7 --keep-credentials`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			id := args[0]
+
+			m, err := config.ReadRollback(id)
+			if err != nil {
+				if errors.Is(err, config.ErrNoRollback) {
+					return fmt.Errorf("rollback manifest %s does not exist", printer.BoldBlue(id))
+				}
+				return err
+			}
+
+			if err := cmdutil.CheckMaintenance(ch, m.Database, flags.override); err != nil {
+				return err
+			}
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			run, err := startRollback(ctx, client, m)
+			if err != nil {
+				return err
+			}
+
+			logPath, err := run.LogPath()
+			if err != nil {
+				return err
+			}
+			logFile, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+			if err != nil {
+				return err
+			}
+			defer logFile.Close()
+
+			ch.Config.Organization = m.Organization
+
+			r := &runner{
+				ch:              ch,
+				client:          client,
+				plan:            rollbackPlan(m),
+				run:             run,
+				steps:           rollbackSteps(m),
+				log:             io.MultiWriter(logOut, logFile),
+				rollback:        m,
+				keepCredentials: flags.keepCredentials,
+			}
+			if err := r.execute(ctx); err != nil {
+				return fmt.Errorf("%s\n\nResume the rollback with 'pscale release rollback %s'", err, id)
+			}
+
+			if ch.Printer.Format() != printer.Human {
+				return ch.Printer.PrintResource(run)
+			}
+			ch.Printer.Printf("Rolled back deploy request #%d of %s/%s.\n",
+				m.Number, printer.BoldBlue(m.Database), printer.BoldBlue(m.Branch))
+			return nil
+		},
+	}
+
+	cmdutil.MaintenanceFlag(cmd, &flags.override)
+	cmd.Flags().BoolVar(&flags.keepCredentials, "keep-credentials", false, "Don't delete the passwords created since the deploy request was opened")
+
+	return cmd
+}
+
+// startRollback returns the run rolling back the manifest, resuming it if it
+// was started before. A new run computes the statements reverting the
+// schema of the branch.
+func startRollback(ctx context.Context, client *ps.Client, m *config.Rollback) (*config.ReleaseRun, error) {
+	id := m.ID + "-rollback"
+	run, err := config.ReadReleaseRun(id)
+	if err == nil {
+		return run, nil
+	}
+	if !errors.Is(err, config.ErrNoReleaseRun) {
+		return nil, err
+	}
+
+	current, err := deployrequest.BranchSchema(ctx, client, m.Organization, m.Database, m.Branch)
+	if err != nil {
+		return nil, cmdutil.HandleError(err)
+	}
+
+	m.Statements, err = schemadiff.Statements(current, m.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't compute the statements reverting the schema: %s", err)
+	}
+	if err := m.Save(); err != nil {
+		return nil, err
+	}
+
+	run = &config.ReleaseRun{
+		ID:           id,
+		Plan:         m.ID,
+		Organization: m.Organization,
+		Database:     m.Database,
+		Branch:       rollbackBranch(m),
+		StartedAt:    time.Now().UTC(),
+	}
+	return run, run.Save()
+}
+
+// rollbackPlan returns the plan deploying the statements of the manifest
+// back to its branch.
+func rollbackPlan(m *config.Rollback) *plan {
+	p := &plan{
+		Org:           m.Organization,
+		Database:      m.Database,
+		Branch:        rollbackBranch(m),
+		From:          m.Branch,
+		DeployTo:      m.Branch,
+		Schema:        m.Statements,
+		Notes:         fmt.Sprintf("Rollback of deploy request #%d", m.Number),
+		ChecksTimeout: defaultChecksTimeout,
+		DeployTimeout: defaultDeployTimeout,
+	}
+	for _, q := range m.Verify {
+		p.Verify = append(p.Verify, &verification{Name: q.Name, Query: q.Query, Expect: q.Expect})
+	}
+	return p
+}
+
+func rollbackBranch(m *config.Rollback) string {
+	return fmt.Sprintf("rollback-%d", m.Number)
+}
+
+// rollbackSteps returns the steps of a rollback run. The schema is only
+// deployed if it changed.
+func rollbackSteps(m *config.Rollback) []step {
+	var steps []step
+	if len(m.Statements) != 0 {
+		steps = append(steps,
+			step{"create-branch", (*runner).createBranch},
+			step{"apply-schema", (*runner).applySchema},
+			step{"open-deploy-request", (*runner).openDeployRequest},
+			step{"wait-checks", (*runner).waitChecks},
+			step{"deploy", (*runner).deploy},
+		)
+	}
+	return append(steps,
+		step{"rotate-credentials", (*runner).rotateCredentials},
+		step{"verify", (*runner).verify},
+	)
+}
+
+func (r *runner) rotateCredentials(ctx context.Context) error {
+	passwords, err := r.client.Passwords.List(ctx, &ps.ListDatabaseBranchPasswordRequest{
+		Organization: r.rollback.Organization,
+		Database:     r.rollback.Database,
+		Branch:       r.rollback.Branch,
+	})
+	if err != nil {
+		return cmdutil.HandleError(err)
+	}
+
+	var creds []*ps.DatabaseBranchPassword
+	for _, p := range passwords {
+		if !p.CreatedAt.Before(r.rollback.OpenedAt) {
+			creds = append(creds, p)
+		}
+	}
+	if len(creds) == 0 {
+		r.logf("rotate-credentials: no credentials")
+		return nil
+	}
+
+	if r.keepCredentials {
+		r.logf("rotate-credentials: kept %d credentials", len(creds))
+		return nil
+	}
+
+	for _, c := range creds {
+		err := r.client.Passwords.Delete(ctx, &ps.DeleteDatabaseBranchPasswordRequest{
+			Organization: r.rollback.Organization,
+			Database:     r.rollback.Database,
+			Branch:       r.rollback.Branch,
+			PasswordId:   c.PublicID,
+		})
+		switch {
+		case cmdutil.ErrCode(err) == ps.ErrNotFound:
+			r.logf("rotate-credentials: password %s (%s) was deleted already", c.Name, c.PublicID)
+		case err != nil:
+			return cmdutil.HandleError(err)
+		default:
+			r.logf("rotate-credentials: deleted password %s (%s) with role %s, recreate it", c.Name, c.PublicID, c.Role)
+		}
+	}
+	return nil
+}
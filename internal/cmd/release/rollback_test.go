@@ -0,0 +1,111 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+func TestRollbackCmd_Credentials(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	m := &config.Rollback{
+		ID:           "deploy-mydb-7",
+		Organization: "planetscale",
+		Database:     "mydb",
+		Number:       7,
+		Branch:       "main",
+		Schema:       "CREATE TABLE t (id int);\n\n",
+		OpenedAt:     time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+	}
+	c.Assert(m.Save(), qt.IsNil)
+
+	var log bytes.Buffer
+	logOut = &log
+	defer func() { logOut = os.Stderr }()
+
+	branches := &mock.DatabaseBranchesService{
+		SchemaFn: func(ctx context.Context, req *ps.BranchSchemaRequest) ([]*ps.Diff, error) {
+			c.Check(req.Branch, qt.Equals, "main")
+			return []*ps.Diff{{Name: "t", Raw: "CREATE TABLE t (id int)"}}, nil
+		},
+	}
+	passwords := &mock.PasswordsService{
+		ListFn: func(ctx context.Context, req *ps.ListDatabaseBranchPasswordRequest) ([]*ps.DatabaseBranchPassword, error) {
+			c.Check(req.Branch, qt.Equals, "main")
+			return []*ps.DatabaseBranchPassword{
+				{PublicID: "pw0", Name: "app", Role: "reader", CreatedAt: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)},
+				{PublicID: "pw1", Name: "app-v2", Role: "readwriter", CreatedAt: time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)},
+			}, nil
+		},
+		DeleteFn: func(ctx context.Context, req *ps.DeleteDatabaseBranchPasswordRequest) error {
+			c.Check(req.PasswordId, qt.Equals, "pw1")
+			c.Check(req.Branch, qt.Equals, "main")
+			return nil
+		},
+	}
+
+	format := printer.Human
+	ch := &cmdutil.Helper{
+		Printer: printer.NewPrinter(&format),
+		Config:  &config.Config{Organization: "planetscale", AccessToken: "token"},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{DatabaseBranches: branches, Passwords: passwords}, nil
+		},
+	}
+
+	cmd := ReleaseCmd(ch)
+	cmd.SetArgs([]string{"rollback", "deploy-mydb-7"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	c.Assert(passwords.DeleteFnInvoked, qt.IsTrue)
+	c.Assert(log.String(), qt.Contains, "rotate-credentials: deleted password app-v2 (pw1) with role readwriter, recreate it")
+	c.Assert(log.String(), qt.Not(qt.Contains), "create-branch")
+
+	run, err := config.ReadReleaseRun("deploy-mydb-7-rollback")
+	c.Assert(err, qt.IsNil)
+	c.Assert(run.Done, qt.DeepEquals, []string{"rotate-credentials", "verify"})
+}
+
+func TestStartRollback(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	m := &config.Rollback{
+		ID:       "mydb-1",
+		Database: "mydb",
+		Number:   7,
+		Branch:   "main",
+		Schema:   "CREATE TABLE t (id int);\n\n",
+	}
+
+	branches := &mock.DatabaseBranchesService{
+		SchemaFn: func(ctx context.Context, req *ps.BranchSchemaRequest) ([]*ps.Diff, error) {
+			return []*ps.Diff{{Name: "t", Raw: "CREATE TABLE t (id int, c int)"}}, nil
+		},
+	}
+
+	run, err := startRollback(context.Background(), &ps.Client{DatabaseBranches: branches}, m)
+	c.Assert(err, qt.IsNil)
+	c.Assert(run.Branch, qt.Equals, "rollback-7")
+	c.Assert(m.Statements, qt.HasLen, 1)
+	c.Assert(rollbackSteps(m), qt.HasLen, 7)
+
+	// a started rollback is resumed with the statements it started with
+	branches.SchemaFnInvoked = false
+	_, err = startRollback(context.Background(), &ps.Client{DatabaseBranches: branches}, m)
+	c.Assert(err, qt.IsNil)
+	c.Assert(branches.SchemaFnInvoked, qt.IsFalse)
+}
@@ -0,0 +1,469 @@
+package release
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmd/deployrequest"
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+// pollInterval is the interval branches and deploy requests are polled with.
+// It's replaced in tests.
+var pollInterval = 5 * time.Second
+
+// logOut is where the run log is streamed to, besides the log file.
+var logOut io.Writer = os.Stderr
+
+// RunCmd is the command for running a release plan.
+func RunCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		resume   string
+		override bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "run <plan>",
+		Short: "Run a release plan",
+		Long: `Run a release plan.
+
+The plan is run as a sequence of steps: the release branch is created, the
+schema changes are applied to it, a deploy request is opened and its checks
+are waited for, it's deployed, the verification queries are run on the
+deployed branch and the webhook is notified. Before the deploy request is
+deployed, a rollback manifest is saved for 'pscale release rollback'.
+
+The run is logged to stderr and to a log file in the config directory, which
+'pscale release log' prints. Its state is saved after every step, so a failed
+or interrupted run is continued where it stopped with --resume. The plan
+can't change between the attempts of a run.
+
+  database: mydb
+  branch: add-orders-index
+  from: main
+  deploy_to: main
+  schema:
+    - ALTER TABLE orders ADD INDEX idx_created_at (created_at)
+  verify:
+    - name: index exists
+      query: SELECT COUNT(*) FROM information_schema.statistics WHERE index_name = 'idx_created_at'
+      expect: "1"
+  rollback_verify:
+    - query: SELECT COUNT(*) FROM information_schema.statistics WHERE index_name = 'idx_created_at'
+      expect: "0"
+  notify:
+    webhook: https://hooks.example.com/releases`,
+		Args: cmdutil.RequiredArgs("plan"),
+		Example: `  pscale release run release.yml
+  pscale release run release.yml --resume mydb-20261015-093000`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			p, err := readPlan(args[0], ch.Config.Organization)
+			if err != nil {
+				return err
+			}
+
+			if err := cmdutil.CheckMaintenance(ch, p.Database, flags.override); err != nil {
+				return err
+			}
+
+			run, err := startRun(args[0], p, flags.resume)
+			if err != nil {
+				return err
+			}
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			logPath, err := run.LogPath()
+			if err != nil {
+				return err
+			}
+			logFile, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+			if err != nil {
+				return err
+			}
+			defer logFile.Close()
+
+			// the plan's organization is used for connecting to its
+			// branches
+			ch.Config.Organization = p.Org
+
+			r := &runner{
+				ch:     ch,
+				client: client,
+				plan:   p,
+				run:    run,
+				steps:  releaseSteps,
+				log:    io.MultiWriter(logOut, logFile),
+			}
+			if err := r.execute(ctx); err != nil {
+				return fmt.Errorf("%s\n\nResume the release with 'pscale release run %s --resume %s'", err, args[0], run.ID)
+			}
+
+			if ch.Printer.Format() != printer.Human {
+				return ch.Printer.PrintResource(run)
+			}
+			ch.Printer.Printf("Release %s deployed branch %s to %s with deploy request #%d.\n",
+				printer.BoldBlue(run.ID), printer.BoldBlue(p.Branch), printer.BoldBlue(p.DeployTo), run.Number)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.resume, "resume", "", "Resume the run with this ID")
+	cmdutil.MaintenanceFlag(cmd, &flags.override)
+
+	return cmd
+}
+
+// startRun returns a new run of the plan, or the run to resume.
+func startRun(path string, p *plan, resume string) (*config.ReleaseRun, error) {
+	if resume == "" {
+		run := &config.ReleaseRun{
+			ID:           fmt.Sprintf("%s-%s", p.Database, time.Now().UTC().Format("20060102-150405")),
+			Plan:         path,
+			PlanDigest:   p.digest,
+			Organization: p.Org,
+			Database:     p.Database,
+			Branch:       p.Branch,
+			StartedAt:    time.Now().UTC(),
+		}
+		return run, run.Save()
+	}
+
+	run, err := config.ReadReleaseRun(resume)
+	if err != nil {
+		if errors.Is(err, config.ErrNoReleaseRun) {
+			return nil, fmt.Errorf("release run %s does not exist", printer.BoldBlue(resume))
+		}
+		return nil, err
+	}
+
+	if run.PlanDigest != p.digest {
+		return nil, fmt.Errorf("plan %s changed since release run %s started, start a new run instead", path, resume)
+	}
+	return run, nil
+}
+
+// step is a step of a release run.
+type step struct {
+	name string
+	run  func(*runner, context.Context) error
+}
+
+// releaseSteps are the steps of a release run.
+var releaseSteps = []step{
+	{"create-branch", (*runner).createBranch},
+	{"apply-schema", (*runner).applySchema},
+	{"open-deploy-request", (*runner).openDeployRequest},
+	{"wait-checks", (*runner).waitChecks},
+	{"deploy", (*runner).deploy},
+	{"verify", (*runner).verify},
+	{"notify", (*runner).notify},
+}
+
+// runner executes the steps of a run.
+type runner struct {
+	ch     *cmdutil.Helper
+	client *ps.Client
+	plan   *plan
+	run    *config.ReleaseRun
+	steps  []step
+	log    io.Writer
+
+	// rollback is the manifest executed by a rollback run.
+	rollback        *config.Rollback
+	keepCredentials bool
+}
+
+// logf writes a timestamped line to the run log.
+func (r *runner) logf(format string, args ...interface{}) {
+	fmt.Fprintf(r.log, "%s %s\n", time.Now().UTC().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
+
+// execute runs the steps that aren't done yet, saving the run after each of
+// them. The webhook is notified of a failed step.
+func (r *runner) execute(ctx context.Context) error {
+	r.logf("release %s of branch %s to %s/%s", r.run.ID, r.plan.Branch, r.plan.Database, r.plan.DeployTo)
+
+	for _, s := range r.steps {
+		if r.run.IsDone(s.name) {
+			r.logf("%s: already done", s.name)
+			continue
+		}
+
+		r.logf("%s: started", s.name)
+		if err := s.run(r, ctx); err != nil {
+			r.logf("%s: failed: %s", s.name, err)
+			if s.name != "notify" && r.plan.Notify.Webhook != "" {
+				if err := r.post(ctx, s.name, err); err != nil {
+					r.logf("notify: failed: %s", err)
+				}
+			}
+			return fmt.Errorf("release step %s failed: %s", s.name, err)
+		}
+
+		r.run.Done = append(r.run.Done, s.name)
+		if err := r.run.Save(); err != nil {
+			return err
+		}
+		r.logf("%s: done", s.name)
+	}
+
+	r.logf("release %s finished", r.run.ID)
+	return nil
+}
+
+func (r *runner) createBranch(ctx context.Context) error {
+	_, err := r.client.DatabaseBranches.Create(ctx, &ps.CreateDatabaseBranchRequest{
+		Organization: r.plan.Org,
+		Database:     r.plan.Database,
+		Name:         r.plan.Branch,
+		ParentBranch: r.plan.From,
+	})
+	if err != nil {
+		return cmdutil.HandleError(err)
+	}
+	r.logf("create-branch: created %s from %s, waiting until it's ready", r.plan.Branch, r.plan.From)
+
+	return poll(ctx, r.plan.ChecksTimeout, func() (bool, error) {
+		b, err := r.client.DatabaseBranches.Get(ctx, &ps.GetDatabaseBranchRequest{
+			Organization: r.plan.Org,
+			Database:     r.plan.Database,
+			Branch:       r.plan.Branch,
+		})
+		if err != nil {
+			return false, cmdutil.HandleError(err)
+		}
+		return b.Ready, nil
+	})
+}
+
+func (r *runner) applySchema(ctx context.Context) error {
+	db, closeDB, err := proxyutil.OpenBranch(ctx, r.ch, r.plan.Database, r.plan.Branch, cmdutil.AdministratorRole)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	for _, stmt := range r.plan.Schema {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to apply %q: %s", stmt, err)
+		}
+		r.logf("apply-schema: applied %s", stmt)
+	}
+	return nil
+}
+
+func (r *runner) openDeployRequest(ctx context.Context) error {
+	dr, err := r.client.DeployRequests.Create(ctx, &ps.CreateDeployRequestRequest{
+		Organization: r.plan.Org,
+		Database:     r.plan.Database,
+		Branch:       r.plan.Branch,
+		IntoBranch:   r.plan.DeployTo,
+		Notes:        r.plan.Notes,
+	})
+	if err != nil {
+		return cmdutil.HandleError(err)
+	}
+
+	r.run.Number = dr.Number
+	r.logf("open-deploy-request: opened deploy request #%d", dr.Number)
+	return nil
+}
+
+func (r *runner) waitChecks(ctx context.Context) error {
+	var dr *ps.DeployRequest
+	err := poll(ctx, r.plan.ChecksTimeout, func() (bool, error) {
+		var err error
+		dr, err = r.getDeployRequest(ctx)
+		if err != nil {
+			return false, err
+		}
+		return dr.Deployment != nil && dr.Deployment.State != "pending", nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !dr.Deployment.Deployable {
+		// deploy requests without changes aren't deployable but
+		// succeeded
+		if err := deployrequest.DeploymentError(dr); err != nil {
+			return err
+		}
+	}
+	r.logf("wait-checks: deploy request #%d is %s", dr.Number, dr.Deployment.State)
+	return nil
+}
+
+func (r *runner) deploy(ctx context.Context) error {
+	dr, err := r.getDeployRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !deployrequest.DeploymentFinished(dr) {
+		if dr.Deployment.State != "queued" && dr.Deployment.State != "in_progress" {
+			if r.rollback == nil {
+				if _, err := deployrequest.SaveRollback(ctx, r.client, r.run.ID, r.plan.Org, r.plan.Database, dr, r.plan.RollbackVerify); err != nil {
+					return fmt.Errorf("couldn't save the rollback manifest: %s", cmdutil.HandleError(err))
+				}
+				r.logf("deploy: saved the rollback manifest, roll back with 'pscale release rollback %s'", r.run.ID)
+			}
+
+			if _, err := r.client.DeployRequests.Deploy(ctx, &ps.PerformDeployRequest{
+				Organization: r.plan.Org,
+				Database:     r.plan.Database,
+				Number:       r.run.Number,
+			}); err != nil {
+				return cmdutil.HandleError(err)
+			}
+			r.logf("deploy: deploying deploy request #%d", r.run.Number)
+		}
+
+		waitCtx, cancel := context.WithTimeout(ctx, r.plan.DeployTimeout)
+		defer cancel()
+
+		op := config.NewOperation(config.OperationDeploy, r.plan.Org, r.plan.Database, "", r.run.Number)
+		if dr, err = deployrequest.WaitDeployment(waitCtx, r.client, op); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return fmt.Errorf("deploy request #%d wasn't deployed within %s", r.run.Number, r.plan.DeployTimeout)
+			}
+			return cmdutil.HandleError(err)
+		}
+	}
+
+	if err := deployrequest.DeploymentError(dr); err != nil {
+		return err
+	}
+	r.logf("deploy: deploy request #%d finished with state %s", r.run.Number, deployrequest.DeploymentState(dr))
+	return nil
+}
+
+func (r *runner) verify(ctx context.Context) error {
+	if len(r.plan.Verify) == 0 {
+		r.logf("verify: no verification queries")
+		return nil
+	}
+
+	db, closeDB, err := proxyutil.OpenBranch(ctx, r.ch, r.plan.Database, r.plan.DeployTo, cmdutil.ReaderRole)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	for _, v := range r.plan.Verify {
+		var value sql.NullString
+		err := db.QueryRowContext(ctx, v.Query).Scan(&value)
+		if err := checkVerification(v, value.String, err); err != nil {
+			return err
+		}
+		r.logf("verify: %s passed", v.Name)
+	}
+	return nil
+}
+
+// checkVerification returns an error if the verification query failed or
+// its result isn't the expected one.
+func checkVerification(v *verification, value string, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("%s returned no rows", v.Name)
+	case err != nil:
+		return fmt.Errorf("%s failed: %s", v.Name, err)
+	case v.Expect != "" && strings.TrimSpace(value) != v.Expect:
+		return fmt.Errorf("%s returned %q, expected %q", v.Name, value, v.Expect)
+	}
+	return nil
+}
+
+func (r *runner) notify(ctx context.Context) error {
+	if r.plan.Notify.Webhook == "" {
+		r.logf("notify: no webhook")
+		return nil
+	}
+	return r.post(ctx, "", nil)
+}
+
+// notification is the payload posted to the webhook.
+type notification struct {
+	ID       string `json:"id"`
+	Database string `json:"database"`
+	Branch   string `json:"branch"`
+	DeployTo string `json:"deploy_to"`
+	Number   uint64 `json:"number,omitempty"`
+	State    string `json:"state"`
+	Step     string `json:"step,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// post notifies the webhook of the finished run, or of the step that
+// failed.
+func (r *runner) post(ctx context.Context, failedStep string, stepErr error) error {
+	n := &notification{
+		ID:       r.run.ID,
+		Database: r.plan.Database,
+		Branch:   r.plan.Branch,
+		DeployTo: r.plan.DeployTo,
+		Number:   r.run.Number,
+		State:    "finished",
+	}
+	if stepErr != nil {
+		n.State, n.Step, n.Error = "failed", failedStep, stepErr.Error()
+	}
+
+	return cmdutil.PostWebhook(ctx, r.plan.Notify.Webhook, n)
+}
+
+func (r *runner) getDeployRequest(ctx context.Context) (*ps.DeployRequest, error) {
+	dr, err := r.client.DeployRequests.Get(ctx, &ps.GetDeployRequestRequest{
+		Organization: r.plan.Org,
+		Database:     r.plan.Database,
+		Number:       r.run.Number,
+	})
+	if err != nil {
+		return nil, cmdutil.HandleError(err)
+	}
+	return dr, nil
+}
+
+// poll calls done every pollInterval until it returns true, an error, or the
+// timeout is exceeded.
+func poll(ctx context.Context, timeout time.Duration, done func() (bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		ok, err := done()
+		if err != nil || ok {
+			return err
+		}
+
+		t := time.NewTimer(pollInterval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("timed out after %s", timeout)
+			}
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
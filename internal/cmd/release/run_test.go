@@ -0,0 +1,178 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+func TestRunCmd_Resume(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	var posted notification
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(json.NewDecoder(r.Body).Decode(&posted), qt.IsNil)
+	}))
+	defer srv.Close()
+
+	dir := c.TempDir()
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "schema.sql"), []byte("ALTER TABLE t ADD COLUMN c int;\n"), 0644), qt.IsNil)
+	file := filepath.Join(dir, "release.yml")
+	c.Assert(ioutil.WriteFile(file, []byte(`database: mydb
+branch: release
+schema_file: schema.sql
+notify:
+  webhook: `+srv.URL+`
+`), 0644), qt.IsNil)
+
+	p, err := readPlan(file, "planetscale")
+	c.Assert(err, qt.IsNil)
+
+	// the branch was created and the schema applied by a previous attempt
+	run := &config.ReleaseRun{
+		ID:           "mydb-1",
+		Plan:         file,
+		PlanDigest:   p.digest,
+		Organization: "planetscale",
+		Database:     "mydb",
+		Branch:       "release",
+		Done:         []string{"create-branch", "apply-schema"},
+	}
+	c.Assert(run.Save(), qt.IsNil)
+
+	var log bytes.Buffer
+	logOut = &log
+	pollInterval = time.Millisecond
+	defer func() {
+		logOut = os.Stderr
+		pollInterval = 5 * time.Second
+	}()
+
+	deployed := false
+	drs := &mock.DeployRequestsService{
+		CreateFn: func(ctx context.Context, req *ps.CreateDeployRequestRequest) (*ps.DeployRequest, error) {
+			c.Check(req.Branch, qt.Equals, "release")
+			c.Check(req.IntoBranch, qt.Equals, "main")
+			return &ps.DeployRequest{Number: 7}, nil
+		},
+		GetFn: func(ctx context.Context, req *ps.GetDeployRequestRequest) (*ps.DeployRequest, error) {
+			c.Check(req.Number, qt.Equals, uint64(7))
+			if deployed {
+				return &ps.DeployRequest{Number: 7, Deployment: &ps.Deployment{State: "complete"}}, nil
+			}
+			return &ps.DeployRequest{Number: 7, Deployment: &ps.Deployment{State: "ready", Deployable: true}}, nil
+		},
+		DeployFn: func(ctx context.Context, req *ps.PerformDeployRequest) (*ps.DeployRequest, error) {
+			deployed = true
+			return &ps.DeployRequest{Number: 7}, nil
+		},
+	}
+	branches := &mock.DatabaseBranchesService{
+		SchemaFn: func(ctx context.Context, req *ps.BranchSchemaRequest) ([]*ps.Diff, error) {
+			return []*ps.Diff{{Name: "t", Raw: "CREATE TABLE t (id int)"}}, nil
+		},
+	}
+	format := printer.JSON
+	ch := &cmdutil.Helper{
+		Printer: printer.NewPrinter(&format),
+		Config:  &config.Config{Organization: "planetscale", AccessToken: "token"},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{DeployRequests: drs, DatabaseBranches: branches}, nil
+		},
+	}
+
+	cmd := ReleaseCmd(ch)
+	cmd.SetArgs([]string{"run", file, "--resume", "mydb-1"})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	c.Assert(branches.CreateFnInvoked, qt.IsFalse)
+	c.Assert(drs.DeployFnInvoked, qt.IsTrue)
+	c.Assert(posted, qt.DeepEquals, notification{
+		ID: "mydb-1", Database: "mydb", Branch: "release", DeployTo: "main", Number: 7, State: "finished",
+	})
+	c.Assert(log.String(), qt.Contains, "create-branch: already done")
+	c.Assert(log.String(), qt.Contains, "deploy: deploy request #7 finished with state complete")
+
+	run, err = config.ReadReleaseRun("mydb-1")
+	c.Assert(err, qt.IsNil)
+	c.Assert(run.Number, qt.Equals, uint64(7))
+	c.Assert(run.Done, qt.HasLen, len(releaseSteps))
+
+	rollback, err := config.ReadRollback("mydb-1")
+	c.Assert(err, qt.IsNil)
+	c.Assert(rollback.Schema, qt.Equals, "CREATE TABLE t (id int);\n\n")
+}
+
+func TestRunCmd_ChangedPlan(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	file := filepath.Join(c.TempDir(), "release.yml")
+	c.Assert(ioutil.WriteFile(file, []byte("database: mydb\nbranch: release\nschema: [\"DROP TABLE t\"]\n"), 0644), qt.IsNil)
+
+	run := &config.ReleaseRun{ID: "mydb-1", PlanDigest: "other"}
+	c.Assert(run.Save(), qt.IsNil)
+
+	format := printer.Human
+	ch := &cmdutil.Helper{
+		Printer: printer.NewPrinter(&format),
+		Config:  &config.Config{Organization: "planetscale", AccessToken: "token"},
+	}
+
+	cmd := ReleaseCmd(ch)
+	cmd.SetArgs([]string{"run", file, "--resume", "mydb-1"})
+	c.Assert(cmd.Execute(), qt.ErrorMatches, `plan .* changed since release run mydb-1 started, start a new run instead`)
+}
+
+func TestReadPlan(t *testing.T) {
+	c := qt.New(t)
+
+	file := filepath.Join(c.TempDir(), "release.yml")
+	c.Assert(ioutil.WriteFile(file, []byte(`database: mydb
+branch: release
+schema:
+  - ALTER TABLE t ADD COLUMN c int
+verify:
+  - query: SELECT 1
+`), 0644), qt.IsNil)
+
+	p, err := readPlan(file, "planetscale")
+	c.Assert(err, qt.IsNil)
+	c.Assert(p.Org, qt.Equals, "planetscale")
+	c.Assert(p.From, qt.Equals, "main")
+	c.Assert(p.DeployTo, qt.Equals, "main")
+	c.Assert(p.Verify[0].Name, qt.Equals, "verification 1")
+
+	c.Assert(ioutil.WriteFile(file, []byte("database: mydb\nbranch: main\nschema: [\"DROP TABLE t\"]\n"), 0644), qt.IsNil)
+	_, err = readPlan(file, "planetscale")
+	c.Assert(err, qt.ErrorMatches, `plan .*: branch "main" can't be deployed to itself`)
+}
+
+func TestCheckVerification(t *testing.T) {
+	c := qt.New(t)
+
+	v := &verification{Name: "index exists", Expect: "1"}
+	c.Assert(checkVerification(v, "1", nil), qt.IsNil)
+	c.Assert(checkVerification(v, "0", nil), qt.ErrorMatches, `index exists returned "0", expected "1"`)
+	c.Assert(checkVerification(v, "", sql.ErrNoRows), qt.ErrorMatches, `index exists returned no rows`)
+	c.Assert(checkVerification(v, "", errors.New("boom")), qt.ErrorMatches, `index exists failed: boom`)
+}
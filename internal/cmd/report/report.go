@@ -0,0 +1,152 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/cost"
+	"github.com/planetscale/cli/internal/printer"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+// orgReport is the inventory of a single organization.
+type orgReport struct {
+	Organization       string  `header:"org" json:"org"`
+	Databases          int     `header:"databases" json:"databases"`
+	Branches           int     `header:"branches" json:"branches"`
+	StorageGB          float64 `header:"storage_gb" json:"storage_gb"`
+	OpenDeployRequests int     `header:"open_deploy_requests" json:"open_deploy_requests"`
+	StaleBranches      int     `header:"stale_branches" json:"stale_branches"`
+}
+
+// ReportCmd aggregates the inventory of multiple organizations.
+func ReportCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		orgs       []string
+		staleAfter time.Duration
+	}
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Aggregate databases, storage, deploy requests and stale branches across organizations",
+		Long: `Aggregate databases, storage, deploy requests and stale branches across organizations.
+
+Without --orgs all organizations of the current user are included. Storage is
+approximated by the latest backup of each production branch. Development
+branches that haven't been updated within --stale-after are reported as
+stale.`,
+		Example:           `  pscale report --orgs acme,acme-staging --format csv`,
+		Args:              cobra.NoArgs,
+		PersistentPreRunE: cmdutil.CheckAuthentication(ch.Config),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			orgs := flags.orgs
+			if len(orgs) == 0 {
+				all, err := client.Organizations.List(ctx)
+				if err != nil {
+					return cmdutil.HandleError(err)
+				}
+
+				for _, o := range all {
+					orgs = append(orgs, o.Name)
+				}
+			}
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Collecting the inventory of %d organizations...", len(orgs)))
+			defer end()
+
+			staleBefore := time.Now().Add(-flags.staleAfter)
+			reports := make([]*orgReport, 0, len(orgs))
+			for _, org := range orgs {
+				r, err := reportOrg(ctx, client, org, staleBefore)
+				if err != nil {
+					switch cmdutil.ErrCode(err) {
+					case ps.ErrNotFound:
+						return fmt.Errorf("organization %s does not exist", printer.BoldBlue(org))
+					default:
+						return cmdutil.HandleError(err)
+					}
+				}
+
+				reports = append(reports, r)
+			}
+
+			end()
+
+			return ch.Printer.PrintResource(reports)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&flags.orgs, "orgs", nil, "Comma separated organizations to include in the report")
+	cmd.Flags().DurationVar(&flags.staleAfter, "stale-after", 30*24*time.Hour,
+		"Development branches that haven't been updated within this duration are stale")
+
+	return cmd
+}
+
+func reportOrg(ctx context.Context, client *ps.Client, org string, staleBefore time.Time) (*orgReport, error) {
+	dbs, err := client.Databases.List(ctx, &ps.ListDatabasesRequest{Organization: org})
+	if err != nil {
+		return nil, err
+	}
+
+	r := &orgReport{Organization: org, Databases: len(dbs)}
+	var storage int64
+	for _, db := range dbs {
+		branches, err := client.DatabaseBranches.List(ctx, &ps.ListDatabaseBranchesRequest{
+			Organization: org,
+			Database:     db.Name,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		r.Branches += len(branches)
+		for _, b := range branches {
+			if !b.Production {
+				if b.UpdatedAt.Before(staleBefore) {
+					r.StaleBranches++
+				}
+				continue
+			}
+
+			backups, err := client.Backups.List(ctx, &ps.ListBackupsRequest{
+				Organization: org,
+				Database:     db.Name,
+				Branch:       b.Name,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			storage += cost.LatestBackupSize(backups)
+		}
+
+		drs, err := client.DeployRequests.List(ctx, &ps.ListDeployRequestsRequest{
+			Organization: org,
+			Database:     db.Name,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, dr := range drs {
+			if dr.State == "open" {
+				r.OpenDeployRequests++
+			}
+		}
+	}
+
+	r.StorageGB = float64(storage) / (1 << 30)
+	return r, nil
+}
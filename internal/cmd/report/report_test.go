@@ -0,0 +1,86 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/printer"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestReportCmd(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	now := time.Now()
+
+	databases := &mock.DatabaseService{
+		ListFn: func(ctx context.Context, req *ps.ListDatabasesRequest) ([]*ps.Database, error) {
+			if req.Organization == "empty" {
+				return nil, nil
+			}
+			return []*ps.Database{{Name: "mydb"}}, nil
+		},
+	}
+	branches := &mock.DatabaseBranchesService{
+		ListFn: func(ctx context.Context, req *ps.ListDatabaseBranchesRequest) ([]*ps.DatabaseBranch, error) {
+			return []*ps.DatabaseBranch{
+				{Name: "main", Production: true, UpdatedAt: now.Add(-90 * 24 * time.Hour)},
+				{Name: "old", UpdatedAt: now.Add(-60 * 24 * time.Hour)},
+				{Name: "new", UpdatedAt: now},
+			}, nil
+		},
+	}
+	backups := &mock.BackupsService{
+		ListFn: func(ctx context.Context, req *ps.ListBackupsRequest) ([]*ps.Backup, error) {
+			c.Assert(req.Branch, qt.Equals, "main")
+			return []*ps.Backup{{State: "success", Size: 2 << 30}}, nil
+		},
+	}
+	drs := &mock.DeployRequestsService{
+		ListFn: func(ctx context.Context, req *ps.ListDeployRequestsRequest) ([]*ps.DeployRequest, error) {
+			return []*ps.DeployRequest{{State: "open"}, {State: "closed"}}, nil
+		},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config:  &config.Config{AccessToken: "token"},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{
+				Databases:        databases,
+				DatabaseBranches: branches,
+				Backups:          backups,
+				DeployRequests:   drs,
+			}, nil
+		},
+	}
+
+	cmd := ReportCmd(ch)
+	cmd.SetArgs([]string{"--orgs", "acme,empty"})
+	err := cmd.Execute()
+
+	c.Assert(err, qt.IsNil)
+	c.Assert(buf.String(), qt.JSONEquals, []*orgReport{
+		{
+			Organization:       "acme",
+			Databases:          1,
+			Branches:           3,
+			StorageGB:          2,
+			OpenDeployRequests: 1,
+			StaleBranches:      1,
+		},
+		{Organization: "empty"},
+	})
+}
@@ -0,0 +1,160 @@
+package resume
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/planetscale/cli/internal/cmd/branch"
+	"github.com/planetscale/cli/internal/cmd/deployrequest"
+	"github.com/planetscale/cli/internal/cmd/password"
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+// operation returns a table-serializable operation model.
+type operation struct {
+	ID        string `header:"id" json:"id"`
+	Kind      string `header:"kind" json:"kind"`
+	Org       string `header:"org" json:"org"`
+	Database  string `header:"database" json:"database"`
+	StartedAt int64  `header:"started_at,timestamp(ms|utc|human)" json:"started_at"`
+}
+
+// result is the outcome of a resumed operation.
+type result struct {
+	ID    string `header:"id" json:"id"`
+	State string `header:"state" json:"state"`
+}
+
+// ResumeCmd re-attaches to operations whose wait was interrupted.
+func ResumeCmd(ch *cmdutil.Helper) *cobra.Command {
+	var list bool
+
+	cmd := &cobra.Command{
+		Use:   "resume [id]",
+		Short: "Resume waiting for an interrupted deployment or branch promotion",
+		Long: `Resume waiting for an interrupted deployment or branch promotion.
+
+Operations that are waited for, i.e. with 'pscale deploy-request deploy --wait',
+are recorded until they finish. The revocation of break-glass passwords is
+recorded too. If the wait is interrupted, for example by a
+dropped SSH connection, 'pscale resume' re-attaches to the operation. Without
+an id the only interrupted operation is resumed.`,
+		Args:              cobra.MaximumNArgs(1),
+		PersistentPreRunE: cmdutil.CheckAuthentication(ch.Config),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ops, err := ch.ConfigFS.Operations()
+			if err != nil {
+				return err
+			}
+
+			if list {
+				return printOperations(ch, ops)
+			}
+
+			var op *config.Operation
+			switch {
+			case len(args) == 1:
+				for _, o := range ops {
+					if o.ID == args[0] {
+						op = o
+					}
+				}
+
+				if op == nil {
+					return fmt.Errorf("operation %s does not exist (run 'pscale resume --list' to list interrupted operations)", printer.BoldBlue(args[0]))
+				}
+			case len(ops) == 0:
+				return errors.New("there are no interrupted operations to resume")
+			case len(ops) == 1:
+				op = ops[0]
+			default:
+				if err := printOperations(ch, ops); err != nil {
+					return err
+				}
+				return errors.New("there are multiple interrupted operations, pass the id of the one to resume")
+			}
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Resuming %s...", printer.BoldBlue(op.ID)))
+			defer end()
+
+			state, err := wait(cmd.Context(), client, op)
+			if err != nil {
+				switch cmdutil.ErrCode(err) {
+				case ps.ErrNotFound:
+					_ = op.Done()
+					return fmt.Errorf("%s does not exist anymore in database %s", op.Kind, printer.BoldBlue(op.Database))
+				default:
+					return cmdutil.HandleError(err)
+				}
+			}
+
+			end()
+
+			if ch.Printer.Format() == printer.Human {
+				ch.Printer.Printf("Operation %s finished with state %s.\n", printer.BoldBlue(op.ID), printer.BoldBlue(state))
+				return nil
+			}
+
+			return ch.Printer.PrintResource(&result{ID: op.ID, State: state})
+		},
+	}
+
+	cmd.Flags().BoolVar(&list, "list", false, "List the interrupted operations")
+
+	return cmd
+}
+
+func wait(ctx context.Context, client *ps.Client, op *config.Operation) (string, error) {
+	switch op.Kind {
+	case config.OperationDeploy:
+		dr, err := deployrequest.WaitDeployment(ctx, client, op)
+		if err != nil {
+			return "", err
+		}
+		return deployrequest.DeploymentState(dr), nil
+	case config.OperationPromote:
+		pr, err := branch.WaitPromotion(ctx, client, op)
+		if err != nil {
+			return "", err
+		}
+		return pr.State, nil
+	case config.OperationRevoke:
+		if err := password.WaitRevocation(ctx, client, op); err != nil {
+			return "", err
+		}
+		return "revoked", nil
+	}
+
+	return "", fmt.Errorf("unknown operation kind %q", op.Kind)
+}
+
+func printOperations(ch *cmdutil.Helper, ops []*config.Operation) error {
+	if len(ops) == 0 && ch.Printer.Format() == printer.Human {
+		ch.Printer.Println("There are no interrupted operations.")
+		return nil
+	}
+
+	out := make([]*operation, 0, len(ops))
+	for _, op := range ops {
+		out = append(out, &operation{
+			ID:        op.ID,
+			Kind:      string(op.Kind),
+			Org:       op.Organization,
+			Database:  op.Database,
+			StartedAt: printer.GetMilliseconds(op.StartedAt),
+		})
+	}
+
+	return ch.Printer.PrintResource(out)
+}
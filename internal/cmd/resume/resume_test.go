@@ -0,0 +1,68 @@
+package resume
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestResumeCmd(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	op := config.NewOperation(config.OperationDeploy, "planetscale", "mydb", "", 7)
+	c.Assert(op.Save(), qt.IsNil)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	finished := time.Now()
+	svc := &mock.DeployRequestsService{
+		GetFn: func(ctx context.Context, req *ps.GetDeployRequestRequest) (*ps.DeployRequest, error) {
+			c.Assert(req.Organization, qt.Equals, "planetscale")
+			c.Assert(req.Database, qt.Equals, "mydb")
+			c.Assert(req.Number, qt.Equals, uint64(7))
+
+			return &ps.DeployRequest{
+				Number:     7,
+				Deployment: &ps.Deployment{State: "complete", FinishedAt: &finished},
+			}, nil
+		},
+	}
+
+	cfs := config.NewConfigFS(testutil.OSFS{})
+	ch := &cmdutil.Helper{
+		Printer:  p,
+		Config:   &config.Config{AccessToken: "token"},
+		ConfigFS: cfs,
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{DeployRequests: svc}, nil
+		},
+	}
+
+	cmd := ResumeCmd(ch)
+	err := cmd.Execute()
+
+	c.Assert(err, qt.IsNil)
+	c.Assert(svc.GetFnInvoked, qt.IsTrue)
+	c.Assert(buf.String(), qt.JSONEquals, &result{ID: "deploy.planetscale.mydb.7", State: "complete"})
+
+	ops, err := cfs.Operations()
+	c.Assert(err, qt.IsNil)
+	c.Assert(ops, qt.HasLen, 0)
+
+	err = ResumeCmd(ch).Execute()
+	c.Assert(err, qt.ErrorMatches, "there are no interrupted operations to resume")
+}
@@ -16,32 +16,66 @@ limitations under the License.
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
+	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
+	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/fatih/color"
+	"github.com/planetscale/cli/internal/cmd/approve"
 	"github.com/planetscale/cli/internal/cmd/auditlog"
 	"github.com/planetscale/cli/internal/cmd/auth"
 	"github.com/planetscale/cli/internal/cmd/backup"
+	"github.com/planetscale/cli/internal/cmd/bench"
 	"github.com/planetscale/cli/internal/cmd/branch"
+	"github.com/planetscale/cli/internal/cmd/cert"
+	"github.com/planetscale/cli/internal/cmd/changelog"
+	configcmd "github.com/planetscale/cli/internal/cmd/config"
 	"github.com/planetscale/cli/internal/cmd/connect"
+	"github.com/planetscale/cli/internal/cmd/cost"
+	"github.com/planetscale/cli/internal/cmd/data"
 	"github.com/planetscale/cli/internal/cmd/database"
 	"github.com/planetscale/cli/internal/cmd/deployrequest"
+	"github.com/planetscale/cli/internal/cmd/edit"
+	"github.com/planetscale/cli/internal/cmd/insights"
+	"github.com/planetscale/cli/internal/cmd/inventory"
+	journalcmd "github.com/planetscale/cli/internal/cmd/journal"
+	"github.com/planetscale/cli/internal/cmd/limits"
+	"github.com/planetscale/cli/internal/cmd/mcp"
 	"github.com/planetscale/cli/internal/cmd/org"
 	"github.com/planetscale/cli/internal/cmd/password"
+	"github.com/planetscale/cli/internal/cmd/plugin"
+	"github.com/planetscale/cli/internal/cmd/project"
+	"github.com/planetscale/cli/internal/cmd/prompt"
+	"github.com/planetscale/cli/internal/cmd/query"
 	"github.com/planetscale/cli/internal/cmd/region"
+	"github.com/planetscale/cli/internal/cmd/release"
+	"github.com/planetscale/cli/internal/cmd/report"
+	"github.com/planetscale/cli/internal/cmd/resume"
+	"github.com/planetscale/cli/internal/cmd/schema"
 	"github.com/planetscale/cli/internal/cmd/shell"
 	"github.com/planetscale/cli/internal/cmd/signup"
 	"github.com/planetscale/cli/internal/cmd/token"
+	tunnelcmd "github.com/planetscale/cli/internal/cmd/tunnel"
 	"github.com/planetscale/cli/internal/cmd/version"
+	"github.com/planetscale/cli/internal/cmd/watch"
+	"github.com/planetscale/cli/internal/cmd/web"
 	"github.com/planetscale/cli/internal/cmdutil"
 	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/crash"
+	"github.com/planetscale/cli/internal/journal"
 	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/transport"
 	"github.com/planetscale/cli/internal/update"
 
 	ps "github.com/planetscale/planetscale-go/planetscale"
@@ -54,6 +88,16 @@ import (
 var (
 	cfgFile  string
 	replacer = strings.NewReplacer("-", "_", ".", "_")
+
+	// offline is set in air-gapped mode, see config.Mirror.
+	offline bool
+
+	// resolve are the DNS overrides passed with --resolve.
+	resolve []string
+
+	// locked is the layer of the locked config file of the machine, if
+	// there is one, see config.LockedLayer.
+	locked *config.Layer
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -66,10 +110,22 @@ var rootCmd = &cobra.Command{
 
 // Execute executes the command and returns the exit status of the finished
 // command.
-func Execute(ctx context.Context, ver, commit, buildDate string) int {
+func Execute(ctx context.Context, ver, commit, buildDate string) (exitCode int) {
 	var format printer.Format
 	var debug bool
 
+	defer func() {
+		if r := recover(); r != nil {
+			exitCode = crash.Handle(os.Stderr, config.NewConfigFS(osFS{}), r, ver, commit, buildDate)
+		}
+	}()
+
+	// the prompt runs on every shell prompt, serve it from the cache before
+	// setting up the CLI.
+	if len(os.Args) > 1 && os.Args[1] == "prompt" && prompt.Fast(os.Args[2:], os.Stdout) {
+		return 0
+	}
+
 	if _, ok := os.LookupEnv("PSCALE_DISABLE_DEV_WARNING"); !ok {
 		if commit == "" || ver == "" || buildDate == "" {
 			fmt.Fprintf(os.Stderr, "!! WARNING: You are using a self-compiled binary which is not officially supported.\n!! To dismiss this warning, set PSCALE_DISABLE_DEV_WARNING=true\n\n")
@@ -81,16 +137,23 @@ func Execute(ctx context.Context, ver, commit, buildDate string) int {
 		return 0
 	}
 
+	requestID := transport.LastRequestID()
+
 	// print any user specific messages first
 	switch format {
 	case printer.JSON:
-		fmt.Fprintf(os.Stderr, `{"error": "%s"}`, err)
+		printJSONError(os.Stderr, err, requestID)
 	default:
-		if err := update.CheckVersion(ctx, ver); err != nil && debug {
-			fmt.Fprintf(os.Stderr, "Updater error: %s\n", err)
+		if !offline {
+			if err := update.CheckVersion(ctx, ver); err != nil && debug {
+				fmt.Fprintf(os.Stderr, "Updater error: %s\n", err)
+			}
 		}
 
 		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		if requestID != "" {
+			fmt.Fprintf(os.Stderr, "Request ID: %s (include it when contacting support)\n", requestID)
+		}
 	}
 
 	// check if a sub command wants to return a specific exit code
@@ -105,10 +168,8 @@ func Execute(ctx context.Context, ver, commit, buildDate string) int {
 // runCmd adds all child commands to the root command, sets flags
 // appropriately, and runs the root command.
 func runCmd(ctx context.Context, ver, commit, buildDate string, format *printer.Format, debug *bool) error {
-	cobra.OnInitialize(initConfig)
-
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config",
-		"", "Config file (default is $HOME/.config/planetscale/pscale.yml)")
+		"", "Config file (default is pscale.yml in $XDG_CONFIG_HOME/planetscale, %AppData%\\planetscale on Windows, or $HOME/.config/planetscale)")
 	rootCmd.SilenceUsage = true
 	rootCmd.SilenceErrors = true
 
@@ -117,29 +178,114 @@ func runCmd(ctx context.Context, ver, commit, buildDate string, format *printer.
 	rootCmd.Version = v
 	rootCmd.Flags().Bool("version", false, "Show pscale version")
 
+	// the profile determines which credentials are read, hence it's
+	// resolved before the flags are parsed
+	globalCfg, _ := config.NewConfigFS(osFS{}).DefaultConfig()
+	if err := config.SetProfile(config.ResolveProfile(os.Args[1:], globalCfg)); err != nil {
+		return err
+	}
+
 	cfg, err := config.New()
 	if err != nil {
 		return err
 	}
+	cfg.RefreshToken = auth.TokenRefresher(cfg)
+
+	var debugFile string
+	cobra.OnInitialize(func() {
+		initConfig(cfg)
+		if err := applyDebugConfig(cfg, debug, debugFile, v); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	})
+
+	// the API advertises the minimum supported version with its responses,
+	// check the one seen last before running commands that may rely on
+	// removed endpoints
+	if len(os.Args) < 2 || !skipsMinVersionCheck(os.Args[1]) {
+		if err := update.CheckMinVersion(os.Stderr, ver); err != nil {
+			return err
+		}
+	}
 
 	rootCmd.PersistentFlags().StringVar(&cfg.BaseURL,
 		"api-url", ps.DefaultBaseURL, "The base URL for the PlanetScale API.")
 	rootCmd.PersistentFlags().StringVar(&cfg.AccessToken,
-		"api-token", cfg.AccessToken, "The API token to use for authenticating against the PlanetScale API.")
+		"api-token", "", "The API token to use for authenticating against the PlanetScale API.")
 
 	rootCmd.PersistentFlags().VarP(printer.NewFormatValue(printer.Human, format), "format", "f",
-		"Show output in a specific format. Possible values: [human, json, csv]")
+		"Show output in a specific format. Possible values: [human, json, csv, yaml], or dot for graphs")
 	if err := viper.BindPFlag("format", rootCmd.PersistentFlags().Lookup("format")); err != nil {
 		return err
 	}
 	rootCmd.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		return []string{"human", "json", "csv"}, cobra.ShellCompDirectiveDefault
+		return printer.Formats, cobra.ShellCompDirectiveDefault
 	})
 
-	rootCmd.PersistentFlags().BoolVar(debug, "debug", false, "Enable debug mode")
+	var noHeader bool
+	rootCmd.PersistentFlags().BoolVar(&noHeader, "no-header", false,
+		"Omit the header of tables and CSV output. Tables are printed plainly, with tab separated fields")
+	if err := viper.BindPFlag("no-header", rootCmd.PersistentFlags().Lookup("no-header")); err != nil {
+		return err
+	}
+
+	rootCmd.PersistentFlags().BoolVarP(&cfg.AssumeYes, "yes", "y", false,
+		"Confirm all prompts of deletions and schema changes, same as their --force flag")
+
+	var quiet bool
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false,
+		"Only print the identifiers of resources, one per line, i.e. for piping them to xargs")
+
+	var progressJSON bool
+	rootCmd.PersistentFlags().BoolVar(&progressJSON, "progress-json", false,
+		"Print the progress of long-running commands to stderr as newline delimited JSON events, instead of spinners")
+
+	timestampFormat := printer.TimestampFormat(printer.TimestampRelative)
+	rootCmd.PersistentFlags().Var(&timestampFormat, "timestamp-format",
+		"The format of timestamps in tables: relative, rfc3339, datetime, date, kitchen or a Go time layout")
+	if err := viper.BindPFlag("timestamp-format", rootCmd.PersistentFlags().Lookup("timestamp-format")); err != nil {
+		return err
+	}
+	var utc bool
+	rootCmd.PersistentFlags().BoolVar(&utc, "utc", false,
+		"Print the absolute timestamps of tables in UTC instead of the local timezone")
+	if err := viper.BindPFlag("utc", rootCmd.PersistentFlags().Lookup("utc")); err != nil {
+		return err
+	}
+
+	rootCmd.PersistentFlags().String("profile", config.ActiveProfile(),
+		"The profile to use for credentials, organization and API URL. Defaults to PSCALE_PROFILE or the current profile of the config file")
+
+	rootCmd.PersistentFlags().String("env", "",
+		"The environment of the project configuration to use, i.e. staging or production. Defaults to PLANETSCALE_ENV")
+	if err := viper.BindPFlag("env", rootCmd.PersistentFlags().Lookup("env")); err != nil {
+		return err
+	}
+
+	rootCmd.PersistentFlags().StringVar(&cfg.TraceHeader, "trace-header", "",
+		"A correlation ID, or a \"Name: value\" header, to send with all API requests")
+
+	rootCmd.PersistentFlags().Int("retries", 2,
+		"Number of times failed API requests are retried, with exponential backoff. Reads are retried on network and server errors, all requests when rate limited")
+	if err := viper.BindPFlag("retries.max", rootCmd.PersistentFlags().Lookup("retries")); err != nil {
+		return err
+	}
+	rootCmd.PersistentFlags().Bool("no-retry", false, "Don't retry failed API requests, same as --retries 0")
+	if err := viper.BindPFlag("no-retry", rootCmd.PersistentFlags().Lookup("no-retry")); err != nil {
+		return err
+	}
+
+	rootCmd.PersistentFlags().StringArrayVar(&resolve, "resolve", nil,
+		"Connect to addr instead of resolving host, given as host:port:addr. The port * matches any port. Can be repeated")
+
+	rootCmd.PersistentFlags().BoolVar(debug, "debug", false,
+		"Enable debug mode, which logs the API requests to stderr. Defaults to PSCALE_DEBUG")
 	if err := viper.BindPFlag("debug", rootCmd.PersistentFlags().Lookup("debug")); err != nil {
 		return err
 	}
+	rootCmd.PersistentFlags().StringVar(&debugFile, "debug-file", "",
+		"Write a full trace of the API requests, with redacted secrets, to the file, i.e. for support tickets")
 
 	ch := &cmdutil.Helper{
 		Printer:  printer.NewPrinter(format),
@@ -150,6 +296,17 @@ func runCmd(ctx context.Context, ver, commit, buildDate string, format *printer.
 		},
 	}
 	ch.SetDebug(debug)
+	ch.Printer.SetNoHeader(&noHeader)
+	ch.Printer.SetQuiet(&quiet)
+	ch.Printer.SetProgressJSON(&progressJSON)
+	ch.Printer.SetTimestampFormat(&timestampFormat, &utc)
+
+	if fileCfg, err := ch.ConfigFS.DefaultConfig(); err == nil {
+		cfg.CredentialSource, err = fileCfg.CredentialSourceConfig(config.ActiveProfile()).Source()
+		if err != nil {
+			return err
+		}
+	}
 
 	// service token flags. they are hidden for now.
 	rootCmd.PersistentFlags().StringVar(&cfg.ServiceTokenID,
@@ -166,9 +323,6 @@ func runCmd(ctx context.Context, ver, commit, buildDate string, format *printer.
 	rootCmd.PersistentFlags().MarkDeprecated("service-token-name", "use --service-token-id instead")
 	rootCmd.PersistentFlags().MarkHidden("service-token-name")
 
-	// We don't want to show the default value
-	rootCmd.PersistentFlags().Lookup("api-token").DefValue = ""
-
 	loginCmd := auth.LoginCmd(ch)
 	loginCmd.Hidden = true
 	logoutCmd := auth.LogoutCmd(ch)
@@ -176,26 +330,105 @@ func runCmd(ctx context.Context, ver, commit, buildDate string, format *printer.
 
 	rootCmd.AddCommand(loginCmd)
 	rootCmd.AddCommand(logoutCmd)
+	rootCmd.AddCommand(approve.ApproveCmd(ch))
 	rootCmd.AddCommand(auditlog.AuditLogCmd(ch))
 	rootCmd.AddCommand(auth.AuthCmd(ch))
 	rootCmd.AddCommand(backup.BackupCmd(ch))
+	rootCmd.AddCommand(bench.BenchCmd(ch))
 	rootCmd.AddCommand(branch.BranchCmd(ch))
+	rootCmd.AddCommand(cert.CertCmd(ch))
+	rootCmd.AddCommand(changelog.ChangelogCmd(ch))
+	rootCmd.AddCommand(configcmd.ConfigCmd(ch))
 	rootCmd.AddCommand(connect.ConnectCmd(ch))
+	rootCmd.AddCommand(cost.CostCmd(ch))
+	rootCmd.AddCommand(data.DataCmd(ch))
 	rootCmd.AddCommand(database.DatabaseCmd(ch))
 	rootCmd.AddCommand(deployrequest.DeployRequestCmd(ch))
+	rootCmd.AddCommand(edit.EditCmd(ch))
+	rootCmd.AddCommand(insights.InsightsCmd(ch))
+	rootCmd.AddCommand(inventory.InventoryCmd(ch))
+	rootCmd.AddCommand(journalcmd.JournalCmd(ch))
+	rootCmd.AddCommand(limits.LimitsCmd(ch))
+	rootCmd.AddCommand(mcp.MCPCmd(ch, ver))
 	rootCmd.AddCommand(org.OrgCmd(ch))
 	rootCmd.AddCommand(password.PasswordCmd(ch))
+	rootCmd.AddCommand(project.InitCmd(ch))
+	rootCmd.AddCommand(prompt.PromptCmd(ch))
+	rootCmd.AddCommand(query.QueryCmd(ch))
 	rootCmd.AddCommand(region.RegionCmd(ch))
+	rootCmd.AddCommand(release.ReleaseCmd(ch))
+	rootCmd.AddCommand(report.ReportCmd(ch))
+	rootCmd.AddCommand(resume.ResumeCmd(ch))
+	rootCmd.AddCommand(schema.SchemaCmd(ch))
 	rootCmd.AddCommand(shell.ShellCmd(ch))
 	rootCmd.AddCommand(signup.SignupCmd(ch))
 	rootCmd.AddCommand(token.TokenCmd(ch))
+	rootCmd.AddCommand(tunnelcmd.TunnelCmd(ch))
 	rootCmd.AddCommand(version.VersionCmd(ch, ver, commit, buildDate))
+	rootCmd.AddCommand(watch.WatchCmd(ch))
+	rootCmd.AddCommand(web.WebCmd(ch))
+
+	// commands that aren't built in run the pscale-<name> plugin on PATH
+	if name, path, ok := plugin.Lookup(rootCmd, os.Args[1:]); ok {
+		rootCmd.AddCommand(plugin.PluginCmd(ch, name, path))
+	}
+
+	registerOrgCompletion(rootCmd, ch)
+
+	// mutating requests are journaled along with the command making them
+	recordJournal := journal.Recorder(commandPath(os.Args[1:]))
+	cfg.ObserveResponse = func(resp *http.Response) {
+		update.RecordMinVersion(resp)
+		recordJournal(resp)
+	}
+
+	args, err := expandAliasArgs(os.Args[1:])
+	if err != nil {
+		return err
+	}
+	rootCmd.SetArgs(args)
+
+	// a template in the templates directory of the config replaces the
+	// tables printed by the command
+	if path, err := config.TemplatePath(commandPath(args)); err == nil {
+		ch.Printer.SetTemplate(path)
+	}
 
 	return rootCmd.ExecuteContext(ctx)
 }
 
+// printJSONError prints the error of a command run with --format json, and
+// the ID of the last API request if any.
+func printJSONError(w io.Writer, err error, requestID string) {
+	out, _ := json.Marshal(struct {
+		Error     string `json:"error"`
+		RequestID string `json:"request_id,omitempty"`
+	}{err.Error(), requestID})
+	fmt.Fprintf(w, "%s", out)
+}
+
+// commandPath returns the path of the command the given arguments run, i.e.
+// "pscale branch create".
+func commandPath(args []string) string {
+	cmd, _, err := rootCmd.Find(args)
+	if err != nil {
+		return rootCmd.Name()
+	}
+	return cmd.CommandPath()
+}
+
+// skipsMinVersionCheck returns whether the command runs regardless of the
+// minimum supported version, so users can still inspect and upgrade pscale.
+func skipsMinVersionCheck(command string) bool {
+	switch command {
+	case "version", "--version", "help", "--help", "-h", "completion", "__complete":
+		return true
+	}
+	return false
+}
+
 // initConfig reads in config file and ENV variables if set.
-func initConfig() {
+func initConfig(cfg *config.Config) {
 	if cfgFile != "" {
 		// Use config file from the flag.
 		viper.SetConfigFile(cfgFile)
@@ -207,7 +440,7 @@ func initConfig() {
 		}
 
 		// Order of preference for configuration files:
-		// (1) $HOME/.config/planetscale
+		// (1) the config directory of the platform, see config.ConfigDir
 		viper.AddConfigPath(defaultConfigDir)
 		viper.SetConfigName("pscale")
 		viper.SetConfigType("yml")
@@ -227,17 +460,430 @@ func initConfig() {
 		}
 	}
 
-	// Check for a project-local configuration file to merge in if the user
-	// has not specified a config file
-	if rootDir, err := config.RootGitRepoDir(); err == nil && cfgFile == "" {
-		viper.AddConfigPath(rootDir)
-		viper.SetConfigName(config.ProjectConfigFile())
-		viper.MergeInConfig() // nolint:errcheck
+	// Check for the nearest project-local configuration file to merge in if
+	// the user has not specified a config file
+	if projectPath, err := config.ProjectConfigPath(); err == nil && cfgFile == "" {
+		if err := mergeProjectConfig(projectPath); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	if err := applySystemConfig(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	applyTransportConfig(cfg)
+	if err := applyMirrorConfig(cfg); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := applyDNSConfig(cfg); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	applyProfile(cfg)
+	if err := applyEnvironment(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
 	}
+	if err := applyLockedConfig(cfg); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	applyOrgDefaults(cfg)
 
 	postInitCommands(rootCmd.Commands())
 }
 
+// mergeProjectConfig merges the project config file at path, with its
+// variables interpolated, see config.Interpolate.
+func mergeProjectConfig(path string) error {
+	out, err := ioutil.ReadFile(path)
+	if err != nil {
+		// the project config file is optional
+		return nil
+	}
+
+	out, err = config.InterpolateYAML(out)
+	if err != nil {
+		return fmt.Errorf("can't interpolate the project config file %s: %s", path, err)
+	}
+
+	viper.SetConfigType("yml")
+	viper.MergeConfig(bytes.NewReader(out)) // nolint:errcheck
+	return nil
+}
+
+// applySystemConfig reads the system config file of the machine, see
+// config.SystemConfigPath. Its values are set as defaults, so the config
+// files, the environment and the flags override them.
+func applySystemConfig() error {
+	path := config.SystemConfigPath()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	system := viper.New()
+	system.SetConfigFile(path)
+	system.SetConfigType("yml")
+	if err := system.ReadInConfig(); err != nil {
+		return fmt.Errorf("can't read the system config file: %s", err)
+	}
+
+	for _, k := range system.AllKeys() {
+		viper.SetDefault(k, system.Get(k))
+	}
+	return nil
+}
+
+// applyTransportConfig reads the timeouts, retries and limits of API
+// requests.
+func applyTransportConfig(cfg *config.Config) {
+	for k, v := range config.Defaults {
+		// the system config file may have set a default already
+		if !viper.IsSet(k) {
+			viper.SetDefault(k, v)
+		}
+	}
+
+	cfg.ReadTimeout = viper.GetDuration("timeouts.read")
+	cfg.MutateTimeout = viper.GetDuration("timeouts.mutate")
+	cfg.MaxRetries = viper.GetInt("retries.max")
+	cfg.RetryBackoff = viper.GetDuration("retries.backoff")
+	cfg.RetryMaxWait = viper.GetDuration("retries.max-wait")
+	if viper.GetBool("no-retry") {
+		cfg.MaxRetries = 0
+	}
+
+	cfg.Limiter = transport.NewLimiter(viper.GetInt("max-concurrent-requests"), viper.GetInt("max-requests-per-minute"))
+}
+
+// applyMirrorConfig enables the air-gapped mode if a mirror is configured.
+// Explicitly passed URLs still take precedence.
+func applyMirrorConfig(cfg *config.Config) error {
+	mirror, err := config.NewMirror(viper.GetString)
+	if err != nil {
+		return err
+	}
+
+	if mirror == nil {
+		return nil
+	}
+
+	cfg.Mirror = mirror
+	offline = true
+
+	if mirror.APIURL != "" && !rootCmd.PersistentFlags().Changed("api-url") {
+		cfg.BaseURL = mirror.APIURL
+	}
+	if mirror.AppURL != "" {
+		cmdutil.ApplicationURL = strings.TrimSuffix(mirror.AppURL, "/")
+	}
+	if mirror.DocsURL != "" {
+		cmdutil.DocsURL = strings.TrimSuffix(mirror.DocsURL, "/")
+	}
+
+	return nil
+}
+
+// applyDebugConfig enables the debug mode if it's set in the configuration
+// or with PSCALE_DEBUG, and logs the API requests in debug mode and to the
+// --debug-file.
+func applyDebugConfig(cfg *config.Config, debug *bool, debugFile, version string) error {
+	if env := os.Getenv("PSCALE_DEBUG"); env != "" {
+		if on, err := strconv.ParseBool(env); err == nil && on {
+			*debug = true
+		}
+	}
+	if viper.GetBool("debug") {
+		*debug = true
+	}
+
+	opts := &transport.DebugOptions{}
+	if *debug {
+		opts.Log = os.Stderr
+	}
+
+	if debugFile != "" {
+		f, err := os.OpenFile(debugFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return fmt.Errorf("can't open the debug file: %s", err)
+		}
+
+		// the file is written until pscale exits
+		fmt.Fprintf(f, "%s (%s/%s)\n\n", strings.TrimSpace(version), runtime.GOOS, runtime.GOARCH)
+		opts.Trace = f
+	}
+
+	if opts.Log != nil || opts.Trace != nil {
+		cfg.WrapTransport(func(rt http.RoundTripper) http.RoundTripper {
+			return transport.Debug(rt, *opts)
+		})
+	}
+	return nil
+}
+
+// applyDNSConfig reads the DNS overrides of the "dns.resolve" key and
+// --resolve, which take precedence, and the resolver of "dns.resolver".
+func applyDNSConfig(cfg *config.Config) error {
+	entries := append(viper.GetStringSlice("dns.resolve"), resolve...)
+
+	dns, err := config.NewDNS(entries, viper.GetString("dns.resolver"))
+	if err != nil {
+		return err
+	}
+	cfg.DNS = dns
+	return nil
+}
+
+// applyProfile applies the organization and API URL of the active profile.
+// Explicitly passed flags, the environment and the project configuration
+// still take precedence.
+func applyProfile(cfg *config.Config) {
+	name := config.ActiveProfile()
+	if name == "" {
+		return
+	}
+
+	fileCfg, err := globalFileConfig()
+	if err != nil {
+		return
+	}
+
+	profile := fileCfg.Profile(name)
+	if profile == nil {
+		return
+	}
+
+	if profile.BaseURL != "" && !rootCmd.PersistentFlags().Changed("api-url") {
+		cfg.BaseURL = profile.BaseURL
+	}
+	cfg.AutoApprove = profile.AutoApprove
+
+	if profile.Organization == "" || os.Getenv("PLANETSCALE_ORG") != "" {
+		return
+	}
+
+	if cfgFile == "" {
+		projectCfg, err := config.NewConfigFS(osFS{}).ProjectConfig()
+		if err == nil && projectCfg.Organization != "" {
+			return
+		}
+	}
+
+	viper.Set("org", profile.Organization)
+}
+
+// applyLockedConfig enforces the values of the locked config file of the
+// machine over all other sources. Flags contradicting them are rejected
+// rather than silently ignored.
+func applyLockedConfig(cfg *config.Config) error {
+	layer, err := config.NewConfigFS(osFS{}).LockedLayer(config.LockedConfigPath())
+	if err != nil {
+		return err
+	}
+	if layer == nil {
+		return nil
+	}
+	locked = layer
+
+	for k, v := range layer.Values {
+		viper.Set(k, v)
+	}
+
+	if v, ok := layer.Values["org"]; ok {
+		cfg.Organization = v
+	}
+	if v, ok := layer.Values["api-url"]; ok {
+		if f := rootCmd.PersistentFlags().Lookup("api-url"); f.Changed && f.Value.String() != v {
+			return lockedError("api-url")
+		}
+		cfg.BaseURL = v
+	}
+	return nil
+}
+
+// isLocked returns whether the key is enforced by the locked config file.
+func isLocked(key string) bool {
+	if locked == nil {
+		return false
+	}
+	_, ok := locked.Values[key]
+	return ok
+}
+
+func lockedError(key string) error {
+	return fmt.Errorf("%s is locked to %q by %s and can't be overridden", key, locked.Values[key], locked.Origin)
+}
+
+// applyEnvironment applies the organization, database and branch of the
+// environment selected with --env, defined in the project configuration or
+// the file passed with --config. Explicitly passed flags still take
+// precedence.
+func applyEnvironment() error {
+	name := viper.GetString("env")
+	if name == "" {
+		return nil
+	}
+
+	var fileCfg *config.FileConfig
+	var err error
+	if cfgFile != "" {
+		fileCfg, err = globalFileConfig()
+	} else {
+		fileCfg, err = config.NewConfigFS(osFS{}).ProjectConfig()
+	}
+	if err != nil {
+		return fmt.Errorf("can't select environment %q, no %s found: %s", name, config.ProjectConfigFile(), err)
+	}
+
+	env, err := fileCfg.Environment(name)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range map[string]string{
+		"org":      env.Organization,
+		"database": env.Database,
+		"branch":   env.Branch,
+	} {
+		if value != "" {
+			viper.Set(key, value)
+		}
+	}
+	return nil
+}
+
+// expandAliasArgs replaces an @name argument with the database and branch of
+// the alias, and passes its organization and role to the commands with those
+// flags. Explicitly passed flags still take precedence.
+func expandAliasArgs(args []string) ([]string, error) {
+	expanded, alias, err := config.ExpandAlias(args, lookupAlias)
+	if err != nil || alias == nil {
+		return args, err
+	}
+
+	cmd, _, err := rootCmd.Find(expanded)
+	if err != nil {
+		return expanded, nil
+	}
+
+	// the flags are inserted before "--", after which arguments are
+	// positional
+	end := len(expanded)
+	for i, arg := range expanded {
+		if arg == "--" {
+			end = i
+			break
+		}
+	}
+
+	var flags []string
+	for _, f := range []struct{ name, value string }{
+		{"org", alias.Organization},
+		{"role", alias.Role},
+	} {
+		if f.value == "" || cmd.Flag(f.name) == nil || hasFlagArg(expanded[:end], f.name) {
+			continue
+		}
+		flags = append(flags, "--"+f.name, f.value)
+	}
+
+	return append(append(append([]string{}, expanded[:end]...), flags...), expanded[end:]...), nil
+}
+
+// lookupAlias returns the alias defined in the project configuration, or
+// else in the global config file.
+func lookupAlias(name string) (*config.Alias, error) {
+	configFS := config.NewConfigFS(osFS{})
+	err := fmt.Errorf("alias @%s doesn't exist, no %s found", name, config.ProjectConfigFile())
+	for _, read := range []func() (*config.FileConfig, error){configFS.ProjectConfig, configFS.DefaultConfig} {
+		fileCfg, readErr := read()
+		if readErr != nil {
+			continue
+		}
+
+		var alias *config.Alias
+		if alias, err = fileCfg.Alias(name); err == nil {
+			return alias, nil
+		}
+	}
+	return nil, err
+}
+
+// hasFlagArg returns whether the flag is passed in the arguments.
+func hasFlagArg(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == "--"+name || strings.HasPrefix(arg, "--"+name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// globalFileConfig reads the global config file, or the one passed with
+// --config.
+func globalFileConfig() (*config.FileConfig, error) {
+	configFile := cfgFile
+	if configFile == "" {
+		var err error
+		configFile, err = config.DefaultConfigPath()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return config.NewConfigFS(osFS{}).NewFileConfig(configFile)
+}
+
+// applyOrgDefaults merges the defaults of the active organization defined in
+// the global or the system config file, so conventions follow the organization rather than
+// the machine. Explicitly passed flags still take precedence.
+func applyOrgDefaults(cfg *config.Config) {
+	org := cfg.Organization
+	if org == "" {
+		org = viper.GetString("org")
+	}
+
+	// the defaults of the system config file apply unless the user defines
+	// their own ones for the organization
+	var defaults *config.OrgDefaults
+	if systemCfg, err := config.NewConfigFS(osFS{}).NewFileConfig(config.SystemConfigPath()); err == nil {
+		defaults = systemCfg.OrgDefaults(org)
+	}
+	if fileCfg, err := globalFileConfig(); err == nil && fileCfg.OrgDefaults(org) != nil {
+		defaults = fileCfg.OrgDefaults(org)
+	}
+
+	// the policies of the locked config file replace the user's ones
+	if locked != nil {
+		lockedCfg, err := config.NewConfigFS(osFS{}).NewFileConfig(locked.Origin)
+		if err == nil && lockedCfg.OrgDefaults(org) != nil {
+			defaults = lockedCfg.OrgDefaults(org)
+		}
+	}
+
+	if defaults == nil {
+		return
+	}
+
+	if defaults.Region != "" && !isLocked("region") {
+		viper.Set("region", defaults.Region)
+	}
+	if defaults.Format != "" && !isLocked("format") {
+		viper.Set("format", defaults.Format)
+	}
+	cfg.ProtectedBranches = defaults.ProtectedBranches
+	cfg.Plan = defaults.Plan
+	cfg.Pricing = defaults.Pricing
+	cfg.Budget = defaults.Budget
+	cfg.Limits = defaults.Limits
+	cfg.Naming = defaults.Naming
+	cfg.Approval = defaults.Approval
+	cfg.Maintenance = defaults.Maintenance
+}
+
 // Hacky fix for getting Cobra required flags and Viper playing well together.
 // See: https://github.com/spf13/viper/issues/397
 func postInitCommands(commands []*cobra.Command) {
@@ -256,6 +902,15 @@ func presetRequiredFlags(cmd *cobra.Command) {
 	}
 
 	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed && isLocked(f.Name) && f.Value.String() != viper.GetString(f.Name) {
+			log.Fatal(lockedError(f.Name))
+		}
+
+		// explicitly passed flags always win
+		if f.Changed {
+			return
+		}
+
 		if viper.IsSet(f.Name) && viper.GetString(f.Name) != "" {
 			err = cmd.Flags().Set(f.Name, viper.GetString(f.Name))
 			if err != nil {
@@ -265,6 +920,18 @@ func presetRequiredFlags(cmd *cobra.Command) {
 	})
 }
 
+// registerOrgCompletion completes the --org flag of all commands defining it.
+func registerOrgCompletion(cmd *cobra.Command, ch *cmdutil.Helper) {
+	if cmd.PersistentFlags().Lookup("org") != nil {
+		// nolint:errcheck
+		cmd.RegisterFlagCompletionFunc("org", cmdutil.OrganizationFlagCompletion(ch))
+	}
+
+	for _, c := range cmd.Commands() {
+		registerOrgCompletion(c, ch)
+	}
+}
+
 // https://github.com/golang/go/issues/44286
 type osFS struct{}
 
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/spf13/cobra"
+)
+
+func TestRootCmd_Flags(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	args := os.Args
+	c.Cleanup(func() { os.Args = args })
+	os.Args = []string{"pscale", "--help"}
+	rootCmd.SetOut(ioutil.Discard)
+
+	var format printer.Format
+	var debug bool
+	err := runCmd(context.Background(), "1.0.0", "abc", "2021-01-01", &format, &debug)
+	c.Assert(err, qt.IsNil)
+
+	// merging the persistent flags of the parents panics if a command
+	// redefines one of their shorthands
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		c.Assert(func() { cmd.LocalFlags() }, qt.Not(qt.PanicMatches), ".*", qt.Commentf("command %q", cmd.CommandPath()))
+		for _, sub := range cmd.Commands() {
+			walk(sub)
+		}
+	}
+	walk(rootCmd)
+}
+
+func TestPrintJSONError(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	printJSONError(&buf, errors.New(`branch "main" not found`), "req-123")
+
+	var out map[string]string
+	c.Assert(json.Unmarshal(buf.Bytes(), &out), qt.IsNil)
+	c.Assert(out, qt.DeepEquals, map[string]string{
+		"error":      `branch "main" not found`,
+		"request_id": "req-123",
+	})
+
+	buf.Reset()
+	printJSONError(&buf, errors.New("line one\nline two"), "")
+	c.Assert(buf.String(), qt.Equals, `{"error":"line one\nline two"}`)
+}
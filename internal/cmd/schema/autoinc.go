@@ -0,0 +1,207 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+
+	"github.com/spf13/cobra"
+)
+
+// exhaustionExitCode is the exit code if any auto-increment column exceeds
+// the threshold, so scheduled checks can tell it from failures.
+const exhaustionExitCode = 2
+
+// AutoincCmd encapsulates the commands for the auto-increment columns of a
+// schema.
+func AutoincCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "autoinc <command>",
+		Short: "Inspect the auto-increment columns of the schema of a branch",
+	}
+
+	cmd.AddCommand(AutoincReportCmd(ch))
+
+	return cmd
+}
+
+// AutoincReportCmd shows how much of the range of their types the
+// auto-increment columns have used.
+func AutoincReportCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		threshold float64
+	}
+
+	cmd := &cobra.Command{
+		Use:   "report <database> <branch>",
+		Short: "Show the headroom of the auto-increment columns",
+		Long: fmt.Sprintf(`Show the headroom of the auto-increment columns.
+
+Each auto-increment column is listed with its current value, the maximum value
+of its type and the percentage of the range that is left, the columns with the
+least headroom first. If any column has used more than --threshold percent of
+its range, the command exits with status %d, for scheduled monitoring.`, exhaustionExitCode),
+		Args:    cmdutil.RequiredArgs("database", "branch"),
+		Example: `  pscale schema autoinc report mydb main --threshold 75`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+
+			db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.ReaderRole)
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Reading the auto-increment columns of %s", printer.BoldBlue(branch)))
+			columns, err := readAutoincColumns(ctx, db)
+			end()
+			if err != nil {
+				return err
+			}
+
+			if len(columns) == 0 && ch.Printer.Format() == printer.Human {
+				ch.Printer.Printf("Branch %s has no auto-increment columns.\n", printer.BoldBlue(branch))
+				return nil
+			}
+			if err := ch.Printer.PrintResource(columns); err != nil {
+				return err
+			}
+
+			var exceeding int
+			for _, c := range columns {
+				if 100-c.Headroom > flags.threshold {
+					exceeding++
+				}
+			}
+			if exceeding == 0 {
+				return nil
+			}
+
+			return &cmdutil.Error{
+				Msg:      fmt.Sprintf("%d auto-increment column(s) used more than %g%% of their range", exceeding, flags.threshold),
+				ExitCode: exhaustionExitCode,
+			}
+		},
+	}
+
+	cmd.Flags().Float64Var(&flags.threshold, "threshold", 80,
+		fmt.Sprintf("Exit with status %d if any column used more than this percentage of its range", exhaustionExitCode))
+
+	return cmd
+}
+
+// autoincColumn is an auto-increment column with the headroom of its type.
+type autoincColumn struct {
+	Table    string  `header:"table" json:"table"`
+	Column   string  `header:"column" json:"column"`
+	Type     string  `header:"type" json:"type"`
+	Current  uint64  `header:"current" json:"current"`
+	Max      uint64  `header:"max" json:"max"`
+	Headroom float64 `header:"headroom %" json:"headroom_percent"`
+}
+
+// readAutoincColumns returns the auto-increment columns of the database, the
+// ones with the least headroom first.
+func readAutoincColumns(ctx context.Context, db *sql.DB) ([]*autoincColumn, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// the AUTO_INCREMENT of information_schema.tables is cached for a day
+	// by default, the statement fails where it can't be changed
+	conn.ExecContext(ctx, "SET SESSION information_schema_stats_expiry = 0") // nolint:errcheck
+
+	rows, err := conn.QueryContext(ctx, `SELECT c.table_name, c.column_name, c.column_type, COALESCE(t.auto_increment, 1)
+FROM information_schema.columns c
+JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+WHERE c.table_schema = DATABASE() AND c.extra LIKE '%auto_increment%'
+ORDER BY c.table_name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make([]*autoincColumn, 0)
+	for rows.Next() {
+		var table, column, columnType string
+		var next uint64
+		if err := rows.Scan(&table, &column, &columnType, &next); err != nil {
+			return nil, err
+		}
+		columns = append(columns, newAutoincColumn(table, column, columnType, next))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(columns, func(i, j int) bool {
+		return columns[i].Headroom < columns[j].Headroom
+	})
+	return columns, nil
+}
+
+// newAutoincColumn returns the column, of which next is the next value.
+func newAutoincColumn(table, column, columnType string, next uint64) *autoincColumn {
+	c := &autoincColumn{
+		Table:  table,
+		Column: column,
+		Type:   columnType,
+		Max:    typeMax(columnType),
+	}
+	if next > 0 {
+		c.Current = next - 1
+	}
+
+	if c.Max > 0 {
+		used := float64(c.Current) / float64(c.Max) * 100
+		c.Headroom = math.Round((100-used)*100) / 100
+	}
+	return c
+}
+
+// typeMax returns the maximum value of the integer column type, such as
+// "int unsigned" or "bigint(20)", and 0 for other types.
+func typeMax(columnType string) uint64 {
+	columnType = strings.ToLower(columnType)
+	fields := strings.Fields(columnType)
+	if len(fields) == 0 {
+		return 0
+	}
+
+	base := fields[0]
+	if i := strings.IndexByte(base, '('); i >= 0 {
+		base = base[:i]
+	}
+	unsigned := strings.Contains(columnType, "unsigned")
+
+	var bits uint
+	switch base {
+	case "tinyint":
+		bits = 8
+	case "smallint":
+		bits = 16
+	case "mediumint":
+		bits = 24
+	case "int", "integer":
+		bits = 32
+	case "bigint":
+		bits = 64
+	default:
+		return 0
+	}
+
+	if unsigned {
+		return math.MaxUint64 >> (64 - bits)
+	}
+	return math.MaxUint64 >> (64 - bits + 1)
+}
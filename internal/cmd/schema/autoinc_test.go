@@ -0,0 +1,46 @@
+package schema
+
+import (
+	"math"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestTypeMax(t *testing.T) {
+	tests := []struct {
+		columnType string
+		want       uint64
+	}{
+		{columnType: "tinyint(4)", want: 127},
+		{columnType: "tinyint unsigned", want: 255},
+		{columnType: "smallint", want: 32767},
+		{columnType: "mediumint(8) unsigned zerofill", want: 16777215},
+		{columnType: "INT", want: 2147483647},
+		{columnType: "int unsigned", want: 4294967295},
+		{columnType: "bigint(20)", want: math.MaxInt64},
+		{columnType: "bigint unsigned", want: math.MaxUint64},
+		{columnType: "varchar(255)", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.columnType, func(t *testing.T) {
+			c := qt.New(t)
+			c.Assert(typeMax(tt.columnType), qt.Equals, tt.want)
+		})
+	}
+}
+
+func TestNewAutoincColumn(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert(newAutoincColumn("users", "id", "tinyint unsigned", 205), qt.DeepEquals, &autoincColumn{
+		Table:    "users",
+		Column:   "id",
+		Type:     "tinyint unsigned",
+		Current:  204,
+		Max:      255,
+		Headroom: 20,
+	})
+	c.Assert(newAutoincColumn("logs", "id", "bigint", 1).Headroom, qt.Equals, float64(100))
+}
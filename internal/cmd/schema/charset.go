@@ -0,0 +1,240 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+
+	"github.com/spf13/cobra"
+)
+
+// CharsetCmd encapsulates the commands for the character sets of a schema.
+func CharsetCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "charset <command>",
+		Short: "Inspect the character sets of the schema of a branch",
+	}
+
+	cmd.AddCommand(CharsetReportCmd(ch))
+
+	return cmd
+}
+
+// CharsetReportCmd lists the tables and columns not using utf8mb4.
+func CharsetReportCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		generateFix bool
+		collation   string
+		chunkSize   int64
+	}
+
+	cmd := &cobra.Command{
+		Use:   "report <database> <branch>",
+		Short: "List the tables and columns not using utf8mb4",
+		Long: `List the tables and columns not using utf8mb4.
+
+Tables are listed by their default character set, columns by their own. With
+--generate-fix, the ALTER TABLE statements converting the tables to utf8mb4
+are printed instead. Converting a table rebuilds it, the statements are
+grouped into chunks of at most --chunk-size of data, tables bigger than that
+get a chunk of their own. Apply the chunks one at a time, i.e. each in its own
+deploy request.`,
+		Args: cmdutil.RequiredArgs("database", "branch"),
+		Example: `  pscale schema charset report mydb main
+  pscale schema charset report mydb main --generate-fix --chunk-size 5000`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+
+			if !strings.HasPrefix(flags.collation, "utf8mb4_") {
+				return fmt.Errorf("collation %s isn't a utf8mb4 collation", printer.BoldBlue(flags.collation))
+			}
+
+			db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.ReaderRole)
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Reading the character sets of %s", printer.BoldBlue(branch)))
+			tables, err := readCharsetTables(ctx, db)
+			end()
+			if err != nil {
+				return err
+			}
+
+			if flags.generateFix {
+				chunks := fixChunks(tables, flags.collation, flags.chunkSize*1000*1000)
+				if ch.Printer.Format() != printer.Human {
+					return ch.Printer.PrintResource(chunks)
+				}
+
+				if len(chunks) == 0 {
+					ch.Printer.Printf("All tables and columns of branch %s use utf8mb4.\n", printer.BoldBlue(branch))
+					return nil
+				}
+				for i, c := range chunks {
+					if i > 0 {
+						ch.Printer.Println()
+					}
+					ch.Printer.Printf("-- chunk %d of %d: %d table(s), %s\n", i+1, len(chunks), len(c.Tables), printer.Bytes(c.Size))
+					for _, stmt := range c.Statements {
+						ch.Printer.Printf("%s;\n", stmt)
+					}
+				}
+				return nil
+			}
+
+			findings := charsetFindings(tables)
+			if len(findings) == 0 && ch.Printer.Format() == printer.Human {
+				ch.Printer.Printf("All tables and columns of branch %s use utf8mb4.\n", printer.BoldBlue(branch))
+				return nil
+			}
+			return ch.Printer.PrintResource(findings)
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.generateFix, "generate-fix", false, "Print the ALTER TABLE statements converting the tables to utf8mb4")
+	cmd.Flags().StringVar(&flags.collation, "collation", "utf8mb4_0900_ai_ci", "The collation the tables are converted to")
+	cmd.Flags().Int64Var(&flags.chunkSize, "chunk-size", 10000, "The maximum size in MB of the tables converted by one chunk of statements")
+
+	return cmd
+}
+
+// charsetTable is a table with its default character set and the columns
+// not using utf8mb4.
+type charsetTable struct {
+	name      string
+	charset   string
+	collation string
+	size      int64 // of the data and the indexes
+	columns   []charsetColumn
+}
+
+type charsetColumn struct {
+	name      string
+	charset   string
+	collation string
+}
+
+// needsFix returns whether the table or any of its columns don't use
+// utf8mb4.
+func (t *charsetTable) needsFix() bool {
+	return t.charset != "utf8mb4" || len(t.columns) > 0
+}
+
+// readCharsetTables returns the tables of the database in the order of
+// their names.
+func readCharsetTables(ctx context.Context, db *sql.DB) ([]*charsetTable, error) {
+	rows, err := db.QueryContext(ctx, `SELECT t.table_name, c.character_set_name, t.table_collation,
+  COALESCE(t.data_length, 0) + COALESCE(t.index_length, 0)
+FROM information_schema.tables t
+JOIN information_schema.collation_character_set_applicability c ON c.collation_name = t.table_collation
+WHERE t.table_schema = DATABASE() AND t.table_type = 'BASE TABLE'
+ORDER BY t.table_name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []*charsetTable
+	byName := make(map[string]*charsetTable)
+	for rows.Next() {
+		t := &charsetTable{}
+		if err := rows.Scan(&t.name, &t.charset, &t.collation, &t.size); err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+		byName[t.name] = t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.QueryContext(ctx, `SELECT table_name, column_name, character_set_name, collation_name
+FROM information_schema.columns
+WHERE table_schema = DATABASE() AND character_set_name IS NOT NULL AND character_set_name <> 'utf8mb4'
+ORDER BY table_name, ordinal_position`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table string
+		var c charsetColumn
+		if err := rows.Scan(&table, &c.name, &c.charset, &c.collation); err != nil {
+			return nil, err
+		}
+
+		// the columns of views are skipped
+		if t, ok := byName[table]; ok {
+			t.columns = append(t.columns, c)
+		}
+	}
+	return tables, rows.Err()
+}
+
+// charsetFinding is a table or column not using utf8mb4.
+type charsetFinding struct {
+	Table     string `header:"table" json:"table"`
+	Column    string `header:"column,n/a" json:"column,omitempty"`
+	Charset   string `header:"charset" json:"charset"`
+	Collation string `header:"collation" json:"collation"`
+}
+
+func charsetFindings(tables []*charsetTable) []*charsetFinding {
+	findings := make([]*charsetFinding, 0)
+	for _, t := range tables {
+		if t.charset != "utf8mb4" {
+			findings = append(findings, &charsetFinding{Table: t.name, Charset: t.charset, Collation: t.collation})
+		}
+		for _, c := range t.columns {
+			findings = append(findings, &charsetFinding{Table: t.name, Column: c.name, Charset: c.charset, Collation: c.collation})
+		}
+	}
+	return findings
+}
+
+// fixChunk is a group of statements converting tables to utf8mb4, which are
+// applied together.
+type fixChunk struct {
+	Tables     []string `json:"tables"`
+	Size       int64    `json:"size_bytes"`
+	Statements []string `json:"statements"`
+}
+
+// fixChunks returns the statements converting the tables that need it to
+// the collation, in chunks of tables of at most chunkSize bytes. Bigger
+// tables get a chunk of their own.
+func fixChunks(tables []*charsetTable, collation string, chunkSize int64) []*fixChunk {
+	charset := strings.SplitN(collation, "_", 2)[0]
+
+	chunks := make([]*fixChunk, 0)
+	var current *fixChunk
+	for _, t := range tables {
+		if !t.needsFix() {
+			continue
+		}
+
+		if current == nil || current.Size+t.size > chunkSize {
+			current = &fixChunk{}
+			chunks = append(chunks, current)
+		}
+
+		current.Tables = append(current.Tables, t.name)
+		current.Size += t.size
+		current.Statements = append(current.Statements, fmt.Sprintf("ALTER TABLE %s CONVERT TO CHARACTER SET %s COLLATE %s",
+			cmdutil.QuoteIdent(t.name), charset, collation))
+
+		if current.Size >= chunkSize {
+			current = nil
+		}
+	}
+	return chunks
+}
@@ -0,0 +1,56 @@
+package schema
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestCharsetFindings(t *testing.T) {
+	c := qt.New(t)
+
+	tables := []*charsetTable{
+		{name: "posts", charset: "utf8mb4", collation: "utf8mb4_0900_ai_ci"},
+		{name: "users", charset: "latin1", collation: "latin1_swedish_ci", columns: []charsetColumn{
+			{name: "name", charset: "latin1", collation: "latin1_swedish_ci"},
+		}},
+	}
+
+	c.Assert(charsetFindings(tables), qt.DeepEquals, []*charsetFinding{
+		{Table: "users", Charset: "latin1", Collation: "latin1_swedish_ci"},
+		{Table: "users", Column: "name", Charset: "latin1", Collation: "latin1_swedish_ci"},
+	})
+}
+
+func TestFixChunks(t *testing.T) {
+	c := qt.New(t)
+
+	tables := []*charsetTable{
+		{name: "a", charset: "utf8", size: 40},
+		{name: "b", charset: "utf8mb4", size: 500},
+		{name: "c", charset: "utf8mb4", size: 50, columns: []charsetColumn{{name: "x", charset: "utf8"}}},
+		{name: "big", charset: "latin1", size: 300},
+		{name: "d", charset: "latin1", size: 10},
+	}
+
+	c.Assert(fixChunks(tables, "utf8mb4_bin", 100), qt.DeepEquals, []*fixChunk{
+		{
+			Tables: []string{"a", "c"},
+			Size:   90,
+			Statements: []string{
+				"ALTER TABLE `a` CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_bin",
+				"ALTER TABLE `c` CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_bin",
+			},
+		},
+		{
+			Tables:     []string{"big"},
+			Size:       300,
+			Statements: []string{"ALTER TABLE `big` CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_bin"},
+		},
+		{
+			Tables:     []string{"d"},
+			Size:       10,
+			Statements: []string{"ALTER TABLE `d` CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_bin"},
+		},
+	})
+}
@@ -0,0 +1,258 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+
+	"github.com/spf13/cobra"
+)
+
+// FragmentationCmd encapsulates the commands for the fragmentation of the
+// tables of a schema.
+func FragmentationCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fragmentation <command>",
+		Short: "Inspect the free space of the tables of a branch",
+	}
+
+	cmd.AddCommand(FragmentationReportCmd(ch))
+
+	return cmd
+}
+
+// FragmentationReportCmd lists the tables with a lot of free space.
+func FragmentationReportCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		minFree      int64
+		minRatio     float64
+		generatePlan bool
+		chunkSize    int64
+	}
+
+	cmd := &cobra.Command{
+		Use:   "report <database> <branch>",
+		Short: "List the tables with a lot of free space",
+		Long: `List the tables with a lot of free space.
+
+A table is listed if at least --min-free MB and --min-ratio percent of its
+tablespace are free, most of the space freed by deleted rows is only reclaimed
+by rebuilding the table. The tables are listed by their free space.
+
+With --generate-plan, the ALTER TABLE statements rebuilding the tables are
+printed instead, in the order of the space they reclaim. The statements are
+grouped into chunks of at most --chunk-size of data, tables bigger than that
+get a chunk of their own. Apply each chunk in its own deploy request, from
+the development branch suggested for it. If maintenance windows are
+configured for the database, each chunk is scheduled in one of the next
+windows, otherwise schedule them when the traffic is low.`,
+		Args: cmdutil.RequiredArgs("database", "branch"),
+		Example: `  pscale schema fragmentation report mydb main
+  pscale schema fragmentation report mydb main --generate-plan --chunk-size 5000`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+
+			db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.ReaderRole)
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Reading the free space of the tables of %s", printer.BoldBlue(branch)))
+			tables, err := readFragmentedTables(ctx, db)
+			end()
+			if err != nil {
+				return err
+			}
+			tables = fragmented(tables, flags.minFree*1000*1000, flags.minRatio)
+
+			if !flags.generatePlan {
+				findings := fragmentationFindings(tables)
+				if len(findings) == 0 && ch.Printer.Format() == printer.Human {
+					ch.Printer.Printf("No table of branch %s has a lot of free space.\n", printer.BoldBlue(branch))
+					return nil
+				}
+				return ch.Printer.PrintResource(findings)
+			}
+
+			chunks := rebuildChunks(tables, flags.chunkSize*1000*1000)
+			scheduled := false
+			if m := ch.Config.MaintenanceFor(database); m != nil {
+				starts, err := m.NextWindows(time.Now(), len(chunks))
+				if err != nil {
+					return err
+				}
+				scheduleChunks(chunks, starts)
+				scheduled = len(starts) > 0
+			}
+
+			if ch.Printer.Format() != printer.Human {
+				return ch.Printer.PrintResource(chunks)
+			}
+
+			if len(chunks) == 0 {
+				ch.Printer.Printf("No table of branch %s has a lot of free space.\n", printer.BoldBlue(branch))
+				return nil
+			}
+			if !scheduled {
+				ch.Printer.Println("-- no maintenance windows are configured, apply the chunks when the traffic is low")
+				ch.Printer.Println()
+			}
+			for i, c := range chunks {
+				if i > 0 {
+					ch.Printer.Println()
+				}
+				ch.Printer.Printf("-- chunk %d of %d: %d table(s), %s, reclaims about %s\n",
+					i+1, len(chunks), len(c.Tables), printer.Bytes(c.Size), printer.Bytes(c.Free))
+				if c.ScheduledAt != nil {
+					ch.Printer.Printf("-- scheduled at %s\n", c.ScheduledAt.Format("2006-01-02 15:04 MST"))
+				}
+				ch.Printer.Printf("-- pscale branch create %s %s\n", database, c.Branch)
+				for _, stmt := range c.Statements {
+					ch.Printer.Printf("%s;\n", stmt)
+				}
+				ch.Printer.Printf("-- pscale deploy-request create %s %s\n", database, c.Branch)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Int64Var(&flags.minFree, "min-free", 100, "The minimum free space in MB of the tables listed")
+	cmd.Flags().Float64Var(&flags.minRatio, "min-ratio", 10, "The minimum percentage of free space of the tables listed")
+	cmd.Flags().BoolVar(&flags.generatePlan, "generate-plan", false, "Print the ALTER TABLE statements rebuilding the tables")
+	cmd.Flags().Int64Var(&flags.chunkSize, "chunk-size", 10000, "The maximum size in MB of the tables rebuilt by one chunk of statements")
+
+	return cmd
+}
+
+// fragmentedTable is a table with the free space of its tablespace.
+type fragmentedTable struct {
+	name string
+	size int64 // of the data and the indexes
+	free int64
+}
+
+// freeRatio returns the percentage of the tablespace that's free.
+func (t *fragmentedTable) freeRatio() float64 {
+	if t.size+t.free == 0 {
+		return 0
+	}
+	return float64(t.free) / float64(t.size+t.free) * 100
+}
+
+// readFragmentedTables returns the InnoDB tables of the database.
+func readFragmentedTables(ctx context.Context, db *sql.DB) ([]*fragmentedTable, error) {
+	rows, err := db.QueryContext(ctx, `SELECT table_name,
+  COALESCE(data_length, 0) + COALESCE(index_length, 0), COALESCE(data_free, 0)
+FROM information_schema.tables
+WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE' AND engine = 'InnoDB'
+ORDER BY table_name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []*fragmentedTable
+	for rows.Next() {
+		t := &fragmentedTable{}
+		if err := rows.Scan(&t.name, &t.size, &t.free); err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+// fragmented returns the tables with at least minFree bytes and minRatio
+// percent free, by their free space.
+func fragmented(tables []*fragmentedTable, minFree int64, minRatio float64) []*fragmentedTable {
+	var found []*fragmentedTable
+	for _, t := range tables {
+		if t.free >= minFree && t.freeRatio() >= minRatio {
+			found = append(found, t)
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return found[i].free > found[j].free })
+	return found
+}
+
+// fragmentationFinding is a table with a lot of free space.
+type fragmentationFinding struct {
+	Table     string  `header:"table" json:"table"`
+	Size      string  `header:"size" json:"-"`
+	SizeBytes int64   `json:"size_bytes"`
+	Free      string  `header:"free" json:"-"`
+	FreeBytes int64   `json:"free_bytes"`
+	FreeRatio float64 `header:"free %" json:"free_percent"`
+}
+
+func fragmentationFindings(tables []*fragmentedTable) []*fragmentationFinding {
+	findings := make([]*fragmentationFinding, 0, len(tables))
+	for _, t := range tables {
+		findings = append(findings, &fragmentationFinding{
+			Table:     t.name,
+			Size:      printer.Bytes(t.size),
+			SizeBytes: t.size,
+			Free:      printer.Bytes(t.free),
+			FreeBytes: t.free,
+			FreeRatio: float64(int64(t.freeRatio()*10)) / 10,
+		})
+	}
+	return findings
+}
+
+// rebuildChunk is a group of statements rebuilding tables, which are applied
+// together from a development branch.
+type rebuildChunk struct {
+	Tables      []string   `json:"tables"`
+	Size        int64      `json:"size_bytes"`
+	Free        int64      `json:"free_bytes"`
+	Branch      string     `json:"branch"`
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+	Statements  []string   `json:"statements"`
+}
+
+// rebuildChunks returns the statements rebuilding the tables, in chunks of
+// tables of at most chunkSize bytes. Bigger tables get a chunk of their own.
+// The tables keep their order, so the chunks reclaiming the most space come
+// first.
+func rebuildChunks(tables []*fragmentedTable, chunkSize int64) []*rebuildChunk {
+	chunks := make([]*rebuildChunk, 0)
+	var current *rebuildChunk
+	for _, t := range tables {
+		if current == nil || current.Size+t.size > chunkSize {
+			current = &rebuildChunk{Branch: fmt.Sprintf("rebuild-%d", len(chunks)+1)}
+			chunks = append(chunks, current)
+		}
+
+		current.Tables = append(current.Tables, t.name)
+		current.Size += t.size
+		current.Free += t.free
+		current.Statements = append(current.Statements, fmt.Sprintf("ALTER TABLE %s ENGINE=InnoDB", cmdutil.QuoteIdent(t.name)))
+
+		if current.Size >= chunkSize {
+			current = nil
+		}
+	}
+	return chunks
+}
+
+// scheduleChunks schedules the chunks in the order of the windows. The
+// chunks without a window stay unscheduled.
+func scheduleChunks(chunks []*rebuildChunk, starts []time.Time) {
+	for i := range chunks {
+		if i >= len(starts) {
+			return
+		}
+		t := starts[i]
+		chunks[i].ScheduledAt = &t
+	}
+}
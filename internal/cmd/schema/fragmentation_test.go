@@ -0,0 +1,69 @@
+package schema
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestFragmented(t *testing.T) {
+	c := qt.New(t)
+
+	tables := []*fragmentedTable{
+		{name: "a", size: 900, free: 100},
+		{name: "b", size: 100, free: 300},
+		{name: "c", size: 1000, free: 20},
+		{name: "d", size: 10, free: 0},
+	}
+
+	var names []string
+	for _, t := range fragmented(tables, 50, 10) {
+		names = append(names, t.name)
+	}
+	c.Assert(names, qt.DeepEquals, []string{"b", "a"})
+
+	c.Assert(fragmentationFindings(fragmented(tables, 300, 0)), qt.DeepEquals, []*fragmentationFinding{
+		{Table: "b", Size: "100 B", SizeBytes: 100, Free: "300 B", FreeBytes: 300, FreeRatio: 75},
+	})
+}
+
+func TestRebuildChunks(t *testing.T) {
+	c := qt.New(t)
+
+	tables := []*fragmentedTable{
+		{name: "big", size: 300, free: 200},
+		{name: "a", size: 40, free: 100},
+		{name: "b", size: 50, free: 60},
+		{name: "c", size: 20, free: 50},
+	}
+
+	chunks := rebuildChunks(tables, 100)
+	start := time.Date(2026, 10, 17, 2, 0, 0, 0, time.UTC)
+	scheduleChunks(chunks, []time.Time{start})
+
+	c.Assert(chunks, qt.DeepEquals, []*rebuildChunk{
+		{
+			Tables:      []string{"big"},
+			Size:        300,
+			Free:        200,
+			Branch:      "rebuild-1",
+			ScheduledAt: &start,
+			Statements:  []string{"ALTER TABLE `big` ENGINE=InnoDB"},
+		},
+		{
+			Tables:     []string{"a", "b"},
+			Size:       90,
+			Free:       160,
+			Branch:     "rebuild-2",
+			Statements: []string{"ALTER TABLE `a` ENGINE=InnoDB", "ALTER TABLE `b` ENGINE=InnoDB"},
+		},
+		{
+			Tables:     []string{"c"},
+			Size:       20,
+			Free:       50,
+			Branch:     "rebuild-3",
+			Statements: []string{"ALTER TABLE `c` ENGINE=InnoDB"},
+		},
+	})
+}
@@ -0,0 +1,424 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+// PartitionsCmd encapsulates the commands for the partitions of the tables of
+// a schema.
+func PartitionsCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "partitions <command>",
+		Short: "Inspect and rotate the partitions of the tables of a branch",
+	}
+
+	cmd.AddCommand(PartitionsShowCmd(ch))
+	cmd.AddCommand(PartitionsRotateCmd(ch))
+
+	return cmd
+}
+
+// PartitionsShowCmd shows the partitioning layout of the tables.
+func PartitionsShowCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show <database> <branch> [table]",
+		Short: "Show the partitions of the tables, or of a single table",
+		Args:  cobra.RangeArgs(2, 3),
+		Example: `  pscale schema partitions show mydb main
+  pscale schema partitions show mydb main events`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+			table := ""
+			if len(args) == 3 {
+				table = args[2]
+			}
+
+			db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.ReaderRole)
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Reading the partitions of %s", printer.BoldBlue(branch)))
+			partitions, err := readPartitions(ctx, db, table)
+			end()
+			if err != nil {
+				return err
+			}
+
+			if len(partitions) == 0 && ch.Printer.Format() == printer.Human {
+				if table != "" {
+					ch.Printer.Printf("Table %s isn't partitioned.\n", printer.BoldBlue(table))
+				} else {
+					ch.Printer.Printf("Branch %s has no partitioned tables.\n", printer.BoldBlue(branch))
+				}
+				return nil
+			}
+			return ch.Printer.PrintResource(partitions)
+		},
+	}
+
+	return cmd
+}
+
+// PartitionsRotateCmd generates the statements creating the upcoming and
+// dropping the expired partitions of a table partitioned by time.
+func PartitionsRotateCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		interval      string
+		create        int
+		retention     time.Duration
+		deployRequest bool
+		deployTo      string
+	}
+
+	cmd := &cobra.Command{
+		Use:   "rotate <database> <branch> <table>",
+		Short: "Generate the DDL creating upcoming and dropping expired partitions",
+		Long: `Generate the DDL creating upcoming and dropping expired partitions.
+
+The table must be partitioned by RANGE on TO_DAYS() or UNIX_TIMESTAMP() of a
+column, or by RANGE COLUMNS on a DATE or DATETIME column. Partitions of
+--interval are added until the current one and the --create following ones
+exist. A MAXVALUE partition is reorganized to make room for them. Partitions
+that only hold rows older than --retention are dropped, dropping their rows.
+
+The statements are printed for review. With --deploy-request, they're applied
+to the branch, which must be a development branch, and a deploy request into
+--deploy-to is opened.`,
+		Args: cmdutil.RequiredArgs("database", "branch", "table"),
+		Example: `Keep a week of daily partitions ahead and drop the ones older than 90 days:
+
+  pscale schema partitions rotate mydb main events --create 7 --retention 2160h
+
+Apply the changes to the dev branch and open a deploy request into main:
+
+  pscale schema partitions rotate mydb dev events --interval month --create 3 --deploy-request`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch, table := args[0], args[1], args[2]
+
+			if _, ok := intervals[flags.interval]; !ok {
+				return fmt.Errorf("unsupported interval %q, use day, week or month", flags.interval)
+			}
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			role := cmdutil.ReaderRole
+			if flags.deployRequest {
+				dbBranch, err := client.DatabaseBranches.Get(ctx, &ps.GetDatabaseBranchRequest{
+					Organization: ch.Config.Organization,
+					Database:     database,
+					Branch:       branch,
+				})
+				if err != nil {
+					return cmdutil.HandleError(err)
+				}
+				if dbBranch.Production {
+					return fmt.Errorf("branch %s is a production branch, rotate the partitions of a development branch to open a deploy request",
+						printer.BoldBlue(branch))
+				}
+				role = cmdutil.AdministratorRole
+			}
+
+			db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, role)
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			partitions, err := readPartitions(ctx, db, table)
+			if err != nil {
+				return err
+			}
+			if len(partitions) == 0 {
+				return fmt.Errorf("table %s isn't partitioned", printer.BoldBlue(table))
+			}
+
+			stmts, err := rotatePartitions(partitions, flags.interval, flags.create, flags.retention, time.Now().UTC())
+			if err != nil {
+				return err
+			}
+
+			if len(stmts) == 0 {
+				if ch.Printer.Format() != printer.Human {
+					return ch.Printer.PrintResource(map[string]interface{}{"statements": stmts, "applied": false})
+				}
+				ch.Printer.Printf("The partitions of table %s are up to date.\n", printer.BoldBlue(table))
+				return nil
+			}
+
+			if !flags.deployRequest {
+				if ch.Printer.Format() != printer.Human {
+					return ch.Printer.PrintResource(map[string]interface{}{"statements": stmts, "applied": false})
+				}
+				ch.Printer.Println("-- Review before applying: dropping a partition deletes its rows.")
+				for _, stmt := range stmts {
+					ch.Printer.Printf("%s;\n", stmt)
+				}
+				return nil
+			}
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Applying the partition changes to %s", printer.BoldBlue(branch)))
+			defer end()
+
+			for _, stmt := range stmts {
+				if _, err := db.ExecContext(ctx, stmt); err != nil {
+					return fmt.Errorf("failed to apply %q: %s", stmt, err)
+				}
+			}
+
+			dr, err := client.DeployRequests.Create(ctx, &ps.CreateDeployRequestRequest{
+				Organization: ch.Config.Organization,
+				Database:     database,
+				Branch:       branch,
+				IntoBranch:   flags.deployTo,
+			})
+			if err != nil {
+				return cmdutil.HandleError(err)
+			}
+			end()
+
+			if ch.Printer.Format() != printer.Human {
+				return ch.Printer.PrintResource(map[string]interface{}{"statements": stmts, "applied": true, "deploy_request": dr.Number})
+			}
+			ch.Printer.Printf("The partition changes were applied to branch %s and deploy request %s was created.\n",
+				printer.BoldBlue(branch), printer.BoldBlue(fmt.Sprintf("#%d", dr.Number)))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.interval, "interval", "day", "The time range of each partition: day, week or month")
+	cmd.Flags().IntVar(&flags.create, "create", 7, "The number of partitions to keep ahead of the current one")
+	cmd.Flags().DurationVar(&flags.retention, "retention", 0,
+		"Drop the partitions only holding rows older than this duration. By default no partitions are dropped")
+	cmd.Flags().BoolVar(&flags.deployRequest, "deploy-request", false,
+		"Apply the statements to the development branch and open a deploy request")
+	cmd.Flags().StringVar(&flags.deployTo, "deploy-to", "main", "The branch the deploy request deploys to. Used with --deploy-request")
+
+	return cmd
+}
+
+// partition is a partition of a table. The bound of RANGE partitions is the
+// value their rows are less than.
+type partition struct {
+	Table      string `header:"table" json:"table"`
+	Name       string `header:"partition" json:"name"`
+	Method     string `header:"method" json:"method"`
+	Expression string `header:"expression" json:"expression"`
+	Bound      string `header:"less than,n/a" json:"less_than,omitempty"`
+	Rows       int64  `header:"rows" json:"rows"`
+	Size       string `header:"size" json:"-"`
+	SizeBytes  int64  `json:"size_bytes"`
+}
+
+// readPartitions returns the partitions of the tables of the database, or of
+// the table if it's not empty, in order.
+func readPartitions(ctx context.Context, db *sql.DB, table string) ([]*partition, error) {
+	query := `SELECT table_name, partition_name, partition_method, COALESCE(partition_expression, ''),
+  COALESCE(partition_description, ''), table_rows, data_length + index_length
+FROM information_schema.partitions
+WHERE table_schema = DATABASE() AND partition_name IS NOT NULL`
+	var args []interface{}
+	if table != "" {
+		query += " AND table_name = ?"
+		args = append(args, table)
+	}
+	query += " ORDER BY table_name, partition_ordinal_position"
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	partitions := make([]*partition, 0)
+	for rows.Next() {
+		p := &partition{}
+		if err := rows.Scan(&p.Table, &p.Name, &p.Method, &p.Expression, &p.Bound, &p.Rows, &p.SizeBytes); err != nil {
+			return nil, err
+		}
+		p.Size = printer.Bytes(p.SizeBytes)
+		partitions = append(partitions, p)
+	}
+	return partitions, rows.Err()
+}
+
+// intervals are the supported partition intervals with the layout of the
+// names of their partitions.
+var intervals = map[string]string{
+	"day":   "p20060102",
+	"week":  "p20060102",
+	"month": "p200601",
+}
+
+// nextBound returns the bound of the partition following the one with the
+// bound t.
+func nextBound(t time.Time, interval string) time.Time {
+	switch interval {
+	case "week":
+		return t.AddDate(0, 0, 7)
+	case "month":
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// boundKind is how the bounds of time-based RANGE partitions are expressed.
+type boundKind int
+
+const (
+	boundDate     boundKind = iota // RANGE COLUMNS on a DATE or DATETIME column
+	boundToDays                    // RANGE on TO_DAYS() of a column
+	boundUnixTime                  // RANGE on UNIX_TIMESTAMP() of a column
+)
+
+func boundKindOf(p *partition) (boundKind, error) {
+	expr := strings.ToLower(p.Expression)
+	switch {
+	case p.Method == "RANGE COLUMNS" && !strings.Contains(expr, ","):
+		return boundDate, nil
+	case p.Method == "RANGE" && strings.HasPrefix(expr, "to_days("):
+		return boundToDays, nil
+	case p.Method == "RANGE" && strings.HasPrefix(expr, "unix_timestamp("):
+		return boundUnixTime, nil
+	}
+	return 0, fmt.Errorf("table %s is partitioned by %s on %s, only RANGE on TO_DAYS() or UNIX_TIMESTAMP() and RANGE COLUMNS on a date column can be rotated",
+		p.Table, p.Method, p.Expression)
+}
+
+// unixToDays is TO_DAYS('1970-01-01').
+const unixToDays = 719528
+
+func parseBound(kind boundKind, bound string) (time.Time, error) {
+	switch kind {
+	case boundToDays, boundUnixTime:
+		n, err := strconv.ParseInt(bound, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid partition bound %s", bound)
+		}
+		if kind == boundUnixTime {
+			return time.Unix(n, 0).UTC(), nil
+		}
+		return time.Unix((n-unixToDays)*24*60*60, 0).UTC(), nil
+	default:
+		s := strings.Trim(bound, "'")
+		for _, layout := range []string{"2006-01-02", "2006-01-02 15:04:05"} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("invalid partition bound %s", bound)
+	}
+}
+
+func formatBound(kind boundKind, t time.Time) string {
+	switch kind {
+	case boundToDays:
+		return strconv.FormatInt(t.Unix()/(24*60*60)+unixToDays, 10)
+	case boundUnixTime:
+		return strconv.FormatInt(t.Unix(), 10)
+	default:
+		return "'" + t.Format("2006-01-02") + "'"
+	}
+}
+
+// rotatePartitions returns the statements adding partitions to the table
+// until the partition containing now and create following ones exist, and
+// dropping the partitions with bounds before now minus the retention, if
+// it's not 0.
+func rotatePartitions(partitions []*partition, interval string, create int, retention time.Duration, now time.Time) ([]string, error) {
+	kind, err := boundKindOf(partitions[0])
+	if err != nil {
+		return nil, err
+	}
+
+	table := cmdutil.QuoteIdent(partitions[0].Table)
+	names := make(map[string]bool)
+	var last time.Time
+	var maxValue string
+	var expired []string
+	for _, p := range partitions {
+		names[p.Name] = true
+		if p.Bound == "MAXVALUE" {
+			maxValue = p.Name
+			continue
+		}
+
+		bound, err := parseBound(kind, p.Bound)
+		if err != nil {
+			return nil, err
+		}
+		if bound.After(last) {
+			last = bound
+		}
+		if retention > 0 && !bound.After(now.Add(-retention)) {
+			expired = append(expired, cmdutil.QuoteIdent(p.Name))
+		}
+	}
+	if last.IsZero() {
+		return nil, errors.New("the table has no partitions with a time bound to rotate from")
+	}
+	if len(expired) == len(names) {
+		// a table can't drop all of its partitions
+		expired = expired[:len(expired)-1]
+	}
+
+	ahead := 0 // partitions with rows after now, the current one included
+	for _, p := range partitions {
+		if p.Bound == "MAXVALUE" {
+			continue
+		}
+		if bound, _ := parseBound(kind, p.Bound); bound.After(now) {
+			ahead++
+		}
+	}
+
+	var added []string
+	for ahead <= create {
+		start := last
+		last = nextBound(last, interval)
+		name := start.Format(intervals[interval])
+		if names[name] {
+			return nil, fmt.Errorf("the partition %s for %s already exists", name, start.Format("2006-01-02"))
+		}
+		names[name] = true
+		added = append(added, fmt.Sprintf("PARTITION %s VALUES LESS THAN (%s)", cmdutil.QuoteIdent(name), formatBound(kind, last)))
+		if last.After(now) {
+			ahead++
+		}
+	}
+
+	var stmts []string
+	if len(added) > 0 {
+		if maxValue != "" {
+			added = append(added, fmt.Sprintf("PARTITION %s VALUES LESS THAN (MAXVALUE)", cmdutil.QuoteIdent(maxValue)))
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s REORGANIZE PARTITION %s INTO (%s)",
+				table, cmdutil.QuoteIdent(maxValue), strings.Join(added, ", ")))
+		} else {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD PARTITION (%s)", table, strings.Join(added, ", ")))
+		}
+	}
+	if len(expired) > 0 {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s DROP PARTITION %s", table, strings.Join(expired, ", ")))
+	}
+	return stmts, nil
+}
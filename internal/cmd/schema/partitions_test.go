@@ -0,0 +1,98 @@
+package schema
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestRotatePartitions(t *testing.T) {
+	now := time.Date(2021, 6, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		partitions []*partition
+		interval   string
+		create     int
+		retention  time.Duration
+		stmts      []string
+		err        string
+	}{
+		{
+			name: "range columns with maxvalue",
+			partitions: []*partition{
+				{Table: "events", Name: "p20210608", Method: "RANGE COLUMNS", Expression: "`created_at`", Bound: "'2021-06-09'"},
+				{Table: "events", Name: "p20210609", Method: "RANGE COLUMNS", Expression: "`created_at`", Bound: "'2021-06-10 00:00:00'"},
+				{Table: "events", Name: "p20210610", Method: "RANGE COLUMNS", Expression: "`created_at`", Bound: "'2021-06-11'"},
+				{Table: "events", Name: "pmax", Method: "RANGE COLUMNS", Expression: "`created_at`", Bound: "MAXVALUE"},
+			},
+			interval:  "day",
+			create:    2,
+			retention: 24 * time.Hour,
+			stmts: []string{
+				"ALTER TABLE `events` REORGANIZE PARTITION `pmax` INTO (" +
+					"PARTITION `p20210611` VALUES LESS THAN ('2021-06-12'), " +
+					"PARTITION `p20210612` VALUES LESS THAN ('2021-06-13'), " +
+					"PARTITION `pmax` VALUES LESS THAN (MAXVALUE))",
+				"ALTER TABLE `events` DROP PARTITION `p20210608`",
+			},
+		},
+		{
+			name: "to_days behind",
+			partitions: []*partition{
+				{Table: "logs", Name: "p202104", Method: "RANGE", Expression: "to_days(`created_at`)", Bound: "738276"},
+			},
+			interval: "month",
+			create:   1,
+			stmts: []string{
+				"ALTER TABLE `logs` ADD PARTITION (" +
+					"PARTITION `p202105` VALUES LESS THAN (738307), " +
+					"PARTITION `p202106` VALUES LESS THAN (738337), " +
+					"PARTITION `p202107` VALUES LESS THAN (738368))",
+			},
+		},
+		{
+			name: "unix timestamp up to date",
+			partitions: []*partition{
+				{Table: "logs", Name: "p20210607", Method: "RANGE", Expression: "unix_timestamp(`created_at`)", Bound: "1623628800"},
+			},
+			interval: "week",
+			create:   0,
+		},
+		{
+			name: "hash",
+			partitions: []*partition{
+				{Table: "users", Name: "p0", Method: "HASH", Expression: "`id`"},
+			},
+			interval: "day",
+			err:      "table users is partitioned by HASH on `id`, .*",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+
+			stmts, err := rotatePartitions(tt.partitions, tt.interval, tt.create, tt.retention, now)
+			if tt.err != "" {
+				c.Assert(err, qt.ErrorMatches, tt.err)
+				return
+			}
+			c.Assert(err, qt.IsNil)
+			c.Assert(stmts, qt.DeepEquals, tt.stmts)
+		})
+	}
+}
+
+func TestFormatBound(t *testing.T) {
+	c := qt.New(t)
+
+	// TO_DAYS('2007-10-07') from the MySQL reference manual
+	day := time.Date(2007, 10, 7, 0, 0, 0, 0, time.UTC)
+	c.Assert(formatBound(boundToDays, day), qt.Equals, "733321")
+
+	parsed, err := parseBound(boundToDays, "733321")
+	c.Assert(err, qt.IsNil)
+	c.Assert(parsed, qt.Equals, day)
+}
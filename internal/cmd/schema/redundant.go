@@ -0,0 +1,228 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+
+	"github.com/spf13/cobra"
+)
+
+// RedundantIndexesCmd lists the indexes that are covered by other indexes of
+// the same table.
+func RedundantIndexesCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "redundant-indexes <database> <branch>",
+		Short: "List the indexes that duplicate or are covered by other indexes",
+		Long: `List the indexes that duplicate or are covered by other indexes.
+
+An index is redundant if another index of the same table and type has the same
+columns, or starts with all of its columns in the same order. Unique indexes
+are only redundant if they duplicate another unique index, as they enforce a
+constraint. The size of each redundant index is shown, if it's available, with
+the total space dropping them would reclaim.`,
+		Args:    cmdutil.RequiredArgs("database", "branch"),
+		Example: `  pscale schema redundant-indexes mydb main`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+
+			db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.ReaderRole)
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Reading the indexes of %s", printer.BoldBlue(branch)))
+			indexes, err := readTableIndexes(ctx, db)
+			end()
+			if err != nil {
+				return err
+			}
+
+			redundant := redundantIndexes(indexes)
+			if ch.Printer.Format() != printer.Human {
+				return ch.Printer.PrintResource(redundant)
+			}
+
+			if len(redundant) == 0 {
+				ch.Printer.Printf("Branch %s has no redundant indexes.\n", printer.BoldBlue(branch))
+				return nil
+			}
+			if err := ch.Printer.PrintResource(redundant); err != nil {
+				return err
+			}
+
+			var total int64
+			for _, r := range redundant {
+				total += r.SizeBytes
+			}
+			if total > 0 {
+				ch.Printer.Printf("\nDropping the redundant indexes reclaims about %s.\n", printer.Bytes(total))
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// tableIndex is an index with its columns in order. Columns indexed by a
+// prefix have its length, such as "name(10)".
+type tableIndex struct {
+	table     string
+	name      string
+	indexType string
+	unique    bool
+	columns   []string
+	size      int64 // in bytes, 0 if unknown
+}
+
+// redundantIndex is an index covered by another index of its table.
+type redundantIndex struct {
+	Table     string `header:"table" json:"table"`
+	Index     string `header:"index" json:"index"`
+	Columns   string `header:"columns" json:"columns"`
+	Reason    string `header:"reason" json:"reason"`
+	CoveredBy string `header:"covered by" json:"covered_by"`
+	Size      string `header:"size,n/a" json:"-"`
+	SizeBytes int64  `json:"size_bytes,omitempty"`
+}
+
+// readTableIndexes returns the indexes of the database, ordered by table and
+// name. The sizes are only read if they're accessible.
+func readTableIndexes(ctx context.Context, db *sql.DB) ([]*tableIndex, error) {
+	rows, err := db.QueryContext(ctx, `SELECT table_name, index_name, index_type, non_unique, column_name, sub_part
+FROM information_schema.statistics
+WHERE table_schema = DATABASE() AND column_name IS NOT NULL
+ORDER BY table_name, index_name, seq_in_index`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []*tableIndex
+	for rows.Next() {
+		var table, name, indexType, column string
+		var nonUnique int
+		var subPart sql.NullInt64
+		if err := rows.Scan(&table, &name, &indexType, &nonUnique, &column, &subPart); err != nil {
+			return nil, err
+		}
+		if subPart.Valid {
+			column = fmt.Sprintf("%s(%d)", column, subPart.Int64)
+		}
+
+		if n := len(indexes); n > 0 && indexes[n-1].table == table && indexes[n-1].name == name {
+			indexes[n-1].columns = append(indexes[n-1].columns, column)
+			continue
+		}
+		indexes = append(indexes, &tableIndex{
+			table:     table,
+			name:      name,
+			indexType: indexType,
+			unique:    nonUnique == 0,
+			columns:   []string{column},
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// the size is the number of pages of the index
+	rows, err = db.QueryContext(ctx, `SELECT table_name, index_name, stat_value * @@innodb_page_size
+FROM mysql.innodb_index_stats
+WHERE database_name = DATABASE() AND stat_name = 'size'`)
+	if err != nil {
+		return indexes, nil
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, name string
+		var size int64
+		if err := rows.Scan(&table, &name, &size); err != nil {
+			return nil, err
+		}
+		for _, idx := range indexes {
+			if idx.table == table && idx.name == name {
+				idx.size = size
+			}
+		}
+	}
+	return indexes, rows.Err()
+}
+
+// redundantIndexes returns the indexes that duplicate, or are a prefix of,
+// another index of the same table and type. Of duplicates, the primary key,
+// then unique indexes, then the first by name are kept.
+func redundantIndexes(indexes []*tableIndex) []*redundantIndex {
+	redundant := make([]*redundantIndex, 0)
+	for _, idx := range indexes {
+		var cover *tableIndex
+		var reason string
+		for _, other := range indexes {
+			if other == idx || other.table != idx.table || other.indexType != idx.indexType {
+				continue
+			}
+
+			if equalColumns(idx.columns, other.columns) {
+				if keepsOver(other, idx) {
+					cover, reason = other, "duplicate"
+					break
+				}
+				continue
+			}
+			if !idx.unique && len(idx.columns) < len(other.columns) &&
+				equalColumns(idx.columns, other.columns[:len(idx.columns)]) && cover == nil {
+				cover, reason = other, "prefix"
+			}
+		}
+		if cover == nil {
+			continue
+		}
+
+		r := &redundantIndex{
+			Table:     idx.table,
+			Index:     idx.name,
+			Columns:   strings.Join(idx.columns, ", "),
+			Reason:    reason,
+			CoveredBy: cover.name,
+		}
+		if idx.size > 0 {
+			r.Size = printer.Bytes(idx.size)
+			r.SizeBytes = idx.size
+		}
+		redundant = append(redundant, r)
+	}
+	return redundant
+}
+
+// keepsOver reports whether of two indexes with the same columns, a is kept
+// rather than b.
+func keepsOver(a, b *tableIndex) bool {
+	if (a.name == "PRIMARY") != (b.name == "PRIMARY") {
+		return a.name == "PRIMARY"
+	}
+	if a.unique != b.unique {
+		return a.unique
+	}
+	return a.name < b.name
+}
+
+func equalColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
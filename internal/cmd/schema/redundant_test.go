@@ -0,0 +1,34 @@
+package schema
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestRedundantIndexes(t *testing.T) {
+	c := qt.New(t)
+
+	indexes := []*tableIndex{
+		{table: "items", name: "idx_sku", indexType: "BTREE", columns: []string{"sku"}},
+		{table: "items", name: "idx_sku_price", indexType: "BTREE", columns: []string{"SKU", "price"}},
+		{table: "items", name: "uq_sku", indexType: "BTREE", unique: true, columns: []string{"sku", "warehouse"}},
+		{table: "orders", name: "PRIMARY", indexType: "BTREE", unique: true, columns: []string{"id"}},
+		{table: "orders", name: "idx_id", indexType: "BTREE", columns: []string{"id"}, size: 16384},
+		{table: "orders", name: "idx_user", indexType: "BTREE", columns: []string{"user_id"}},
+		{table: "orders", name: "idx_user_created", indexType: "BTREE", columns: []string{"user_id", "created_at"}},
+		{table: "orders", name: "uq_user", indexType: "BTREE", unique: true, columns: []string{"user_id"}},
+		{table: "users", name: "a", indexType: "BTREE", columns: []string{"email"}},
+		{table: "users", name: "b", indexType: "BTREE", columns: []string{"email"}},
+		{table: "users", name: "ft", indexType: "FULLTEXT", columns: []string{"email"}},
+		{table: "users", name: "name", indexType: "BTREE", columns: []string{"name(10)"}},
+		{table: "users", name: "name_email", indexType: "BTREE", columns: []string{"name", "email"}},
+	}
+
+	c.Assert(redundantIndexes(indexes), qt.DeepEquals, []*redundantIndex{
+		{Table: "items", Index: "idx_sku", Columns: "sku", Reason: "prefix", CoveredBy: "idx_sku_price"},
+		{Table: "orders", Index: "idx_id", Columns: "id", Reason: "duplicate", CoveredBy: "PRIMARY", Size: "16.4 kB", SizeBytes: 16384},
+		{Table: "orders", Index: "idx_user", Columns: "user_id", Reason: "duplicate", CoveredBy: "uq_user"},
+		{Table: "users", Index: "b", Columns: "email", Reason: "duplicate", CoveredBy: "a"},
+	})
+}
@@ -0,0 +1,29 @@
+package schema
+
+import (
+	"github.com/planetscale/cli/internal/cmdutil"
+
+	"github.com/spf13/cobra"
+)
+
+// SchemaCmd encapsulates the commands inspecting the schema of a branch.
+func SchemaCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "schema <command>",
+		Short:             "Inspect the schema of a branch",
+		PersistentPreRunE: cmdutil.CheckAuthentication(ch.Config),
+	}
+
+	cmd.PersistentFlags().StringVar(&ch.Config.Organization, "org", ch.Config.Organization,
+		"The organization for the current user")
+	cmd.MarkPersistentFlagRequired("org") // nolint:errcheck
+
+	cmd.AddCommand(AutoincCmd(ch))
+	cmd.AddCommand(CharsetCmd(ch))
+	cmd.AddCommand(FragmentationCmd(ch))
+	cmd.AddCommand(PartitionsCmd(ch))
+	cmd.AddCommand(RedundantIndexesCmd(ch))
+	cmd.AddCommand(SequencesCmd(ch))
+
+	return cmd
+}
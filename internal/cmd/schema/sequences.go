@@ -0,0 +1,310 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/proxyutil"
+
+	"github.com/spf13/cobra"
+)
+
+// SequencesCmd encapsulates the commands for the Vitess sequences of sharded
+// keyspaces.
+func SequencesCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sequences <command>",
+		Short: "Inspect, bump and reserve the Vitess sequences of a branch",
+		Long: `Inspect, bump and reserve the Vitess sequences of a branch.
+
+Sequences generate the auto-increment values of sharded tables. They're
+tables of an unsharded keyspace with the comment 'vitess_sequence', of which
+the next_id column is the next value handed out. When importing rows with
+existing IDs into a sharded keyspace, the sequence must be moved past them.
+
+Sequences are named by their table, optionally qualified by their keyspace,
+such as "commerce.users_seq". Tablets cache blocks of values, values cached
+before a sequence is bumped are still handed out.`,
+	}
+
+	cmd.AddCommand(SequencesListCmd(ch))
+	cmd.AddCommand(SequencesBumpCmd(ch))
+	cmd.AddCommand(SequencesReserveCmd(ch))
+
+	return cmd
+}
+
+// SequencesListCmd lists the sequences with their next values.
+func SequencesListCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list <database> <branch>",
+		Short:   "List the sequences with their next values",
+		Args:    cmdutil.RequiredArgs("database", "branch"),
+		Aliases: []string{"ls"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+
+			db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.ReaderRole)
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			end := ch.Printer.PrintProgress(fmt.Sprintf("Reading the sequences of %s", printer.BoldBlue(branch)))
+			sequences, err := readSequences(ctx, db)
+			end()
+			if err != nil {
+				return err
+			}
+
+			if len(sequences) == 0 && ch.Printer.Format() == printer.Human {
+				ch.Printer.Printf("Branch %s has no sequences.\n", printer.BoldBlue(branch))
+				return nil
+			}
+			return ch.Printer.PrintResource(sequences)
+		},
+	}
+
+	return cmd
+}
+
+// SequencesBumpCmd moves the next value of a sequence forward.
+func SequencesBumpCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		to       int64
+		afterMax string
+	}
+
+	cmd := &cobra.Command{
+		Use:   "bump <database> <branch> <sequence>",
+		Short: "Move the next value of a sequence forward",
+		Long: `Move the next value of a sequence forward.
+
+The next value is set to --to, or past the largest value of a column with
+--after-max, such as the IDs of imported rows. It's never moved backwards, as
+that would hand out values that are already used.`,
+		Args: cmdutil.RequiredArgs("database", "branch", "sequence"),
+		Example: `  pscale schema sequences bump mydb main users_seq --to 1000000
+  pscale schema sequences bump mydb main lookup.users_seq --after-max commerce.users.id`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+
+			if (flags.to == 0) == (flags.afterMax == "") {
+				return errors.New("exactly one of --to and --after-max is required")
+			}
+
+			seq, err := quoteQualified(args[2])
+			if err != nil {
+				return err
+			}
+
+			db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.AdministratorRole)
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			target := flags.to
+			if flags.afterMax != "" {
+				if target, err = nextAfterMax(ctx, db, flags.afterMax); err != nil {
+					return err
+				}
+			}
+
+			previous, err := updateSequence(ctx, db, seq, func(next int64) (int64, error) {
+				return target, checkBump(next, target)
+			})
+			if err != nil {
+				return err
+			}
+
+			if ch.Printer.Format() != printer.Human {
+				return ch.Printer.PrintResource(map[string]interface{}{"sequence": args[2], "previous": previous, "next": target})
+			}
+			ch.Printer.Printf("The next value of sequence %s was moved from %d to %d.\n", printer.BoldBlue(args[2]), previous, target)
+			return nil
+		},
+	}
+
+	cmd.Flags().Int64Var(&flags.to, "to", 0, "The next value of the sequence")
+	cmd.Flags().StringVar(&flags.afterMax, "after-max", "",
+		"Move the sequence past the largest value of this column, as [keyspace.]table.column")
+
+	return cmd
+}
+
+// SequencesReserveCmd reserves a range of values of a sequence.
+func SequencesReserveCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		count int64
+	}
+
+	cmd := &cobra.Command{
+		Use:   "reserve <database> <branch> <sequence>",
+		Short: "Reserve a range of values of a sequence",
+		Long: `Reserve a range of values of a sequence.
+
+The sequence is moved forward by --count values, which are printed and won't
+be handed out by the sequence, so imported rows can use them as their IDs.`,
+		Args:    cmdutil.RequiredArgs("database", "branch", "sequence"),
+		Example: `  pscale schema sequences reserve mydb main users_seq --count 50000`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			database, branch := args[0], args[1]
+
+			if flags.count <= 0 {
+				return errors.New("--count must be positive")
+			}
+
+			seq, err := quoteQualified(args[2])
+			if err != nil {
+				return err
+			}
+
+			db, closeDB, err := proxyutil.OpenBranch(ctx, ch, database, branch, cmdutil.AdministratorRole)
+			if err != nil {
+				return err
+			}
+			defer closeDB()
+
+			first, err := updateSequence(ctx, db, seq, func(next int64) (int64, error) {
+				return next + flags.count, nil
+			})
+			if err != nil {
+				return err
+			}
+			last := first + flags.count - 1
+
+			if ch.Printer.Format() != printer.Human {
+				return ch.Printer.PrintResource(map[string]interface{}{"sequence": args[2], "first": first, "last": last})
+			}
+			ch.Printer.Printf("Reserved the values %d to %d of sequence %s.\n", first, last, printer.BoldBlue(args[2]))
+			return nil
+		},
+	}
+
+	cmd.Flags().Int64Var(&flags.count, "count", 0, "The number of values to reserve")
+
+	return cmd
+}
+
+// sequence is a Vitess sequence table.
+type sequence struct {
+	Keyspace string `header:"keyspace" json:"keyspace"`
+	Name     string `header:"sequence" json:"sequence"`
+	NextID   int64  `header:"next id" json:"next_id"`
+	Cache    int64  `header:"cache" json:"cache"`
+}
+
+// readSequences returns the sequences of all keyspaces.
+func readSequences(ctx context.Context, db *sql.DB) ([]*sequence, error) {
+	rows, err := db.QueryContext(ctx, `SELECT table_schema, table_name
+FROM information_schema.tables
+WHERE table_comment = 'vitess_sequence'
+ORDER BY table_schema, table_name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sequences := make([]*sequence, 0)
+	for rows.Next() {
+		s := &sequence{}
+		if err := rows.Scan(&s.Keyspace, &s.Name); err != nil {
+			return nil, err
+		}
+		sequences = append(sequences, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, s := range sequences {
+		query := fmt.Sprintf("SELECT next_id, cache FROM %s.%s WHERE id = 0", cmdutil.QuoteIdent(s.Keyspace), cmdutil.QuoteIdent(s.Name))
+		if err := db.QueryRowContext(ctx, query).Scan(&s.NextID, &s.Cache); err != nil {
+			return nil, fmt.Errorf("couldn't read sequence %s.%s: %s", s.Keyspace, s.Name, err)
+		}
+	}
+	return sequences, nil
+}
+
+// updateSequence sets the next value of the sequence to the one returned by
+// next for its current value, in a transaction, and returns the previous
+// value.
+func updateSequence(ctx context.Context, db *sql.DB, seq string, next func(int64) (int64, error)) (int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // nolint:errcheck
+
+	var current int64
+	err = tx.QueryRowContext(ctx, fmt.Sprintf("SELECT next_id FROM %s WHERE id = 0 FOR UPDATE", seq)).Scan(&current)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't read sequence %s: %s", seq, err)
+	}
+
+	value, err := next(current)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET next_id = ? WHERE id = 0", seq), value); err != nil {
+		return 0, err
+	}
+	return current, tx.Commit()
+}
+
+// nextAfterMax returns the value following the largest value of the column,
+// given as [keyspace.]table.column.
+func nextAfterMax(ctx context.Context, db *sql.DB, column string) (int64, error) {
+	i := strings.LastIndexByte(column, '.')
+	if i < 0 {
+		return 0, fmt.Errorf("invalid column %q, use [keyspace.]table.column", column)
+	}
+
+	table, err := quoteQualified(column[:i])
+	if err != nil {
+		return 0, err
+	}
+
+	var max sql.NullInt64
+	query := fmt.Sprintf("SELECT MAX(%s) FROM %s", cmdutil.QuoteIdent(column[i+1:]), table)
+	if err := db.QueryRowContext(ctx, query).Scan(&max); err != nil {
+		return 0, err
+	}
+	return max.Int64 + 1, nil
+}
+
+// checkBump returns an error if moving the next value of a sequence to
+// target would move it backwards.
+func checkBump(next, target int64) error {
+	if target < next {
+		return fmt.Errorf("the next value of the sequence is %d already, moving it back to %d would hand out used values", next, target)
+	}
+	return nil
+}
+
+// quoteQualified quotes the name, optionally qualified by a keyspace, such
+// as "commerce.users".
+func quoteQualified(name string) (string, error) {
+	parts := strings.Split(name, ".")
+	if len(parts) > 2 {
+		return "", fmt.Errorf("invalid name %q, use [keyspace.]name", name)
+	}
+
+	for i, p := range parts {
+		if p == "" {
+			return "", fmt.Errorf("invalid name %q, use [keyspace.]name", name)
+		}
+		parts[i] = cmdutil.QuoteIdent(p)
+	}
+	return strings.Join(parts, "."), nil
+}
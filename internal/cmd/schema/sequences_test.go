@@ -0,0 +1,34 @@
+package schema
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestCheckBump(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert(checkBump(100, 1000), qt.IsNil)
+	c.Assert(checkBump(100, 100), qt.IsNil)
+	c.Assert(checkBump(100, 99), qt.ErrorMatches,
+		"the next value of the sequence is 100 already, moving it back to 99 would hand out used values")
+}
+
+func TestQuoteQualified(t *testing.T) {
+	c := qt.New(t)
+
+	name, err := quoteQualified("users_seq")
+	c.Assert(err, qt.IsNil)
+	c.Assert(name, qt.Equals, "`users_seq`")
+
+	name, err = quoteQualified("lookup.users_seq")
+	c.Assert(err, qt.IsNil)
+	c.Assert(name, qt.Equals, "`lookup`.`users_seq`")
+
+	_, err = quoteQualified("a.b.c")
+	c.Assert(err, qt.ErrorMatches, `invalid name "a.b.c", use \[keyspace.\]name`)
+
+	_, err = quoteQualified("lookup.")
+	c.Assert(err, qt.ErrorMatches, `invalid name "lookup.", use \[keyspace.\]name`)
+}
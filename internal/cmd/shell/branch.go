@@ -0,0 +1,167 @@
+package shell
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+)
+
+// The mysql client handles its backslash commands itself, the session
+// intercepts the SWITCH BRANCH statement instead, which the client sends as
+// a query. The session connects to the other branch and authenticates with
+// the client's handshake response, so the client keeps its connection.
+
+var switchBranchRe = regexp.MustCompile("(?is)^\\s*SWITCH\\s+BRANCH\\s+`?([\\w.-]+)`?\\s*;?\\s*$")
+
+// clientCommand answers the commands of the client the session handles
+// itself: EXPORT, DIFF and the statements switching branches and named
+// sessions. It returns the response to the client and its connection from now
+// on, and false if the command isn't one of them.
+func (s *session) clientCommand(c *sessionConn, client net.Conn, payload []byte, auth []byte) ([]byte, *sessionConn, bool) {
+	if format, path, query, ok := parseExport(payload); ok {
+		return s.export(c, format, path, query), c, true
+	}
+
+	// the other commands connect to other branches
+	if s.proxyAddr == nil {
+		return nil, c, false
+	}
+
+	if branch, ok := parseSwitchBranch(payload); ok {
+		resp, next := s.switchTo(c, client, branch, auth)
+		return resp, next, true
+	}
+	if name, branch, ok := parseSwitchSession(payload); ok {
+		resp, next := s.switchSession(c, client, name, branch, auth)
+		return resp, next, true
+	}
+	if name, query, ok := parseDiff(payload); ok {
+		return s.diff(c, name, query, auth), c, true
+	}
+	if parseShowSessions(payload) {
+		s.showSessions()
+		return okPacket(1), c, true
+	}
+	return nil, c, false
+}
+
+// parseSwitchBranch returns the branch of a SWITCH BRANCH command.
+func parseSwitchBranch(payload []byte) (string, bool) {
+	if payload[0] != comQuery {
+		return "", false
+	}
+
+	m := switchBranchRe.FindSubmatch(payload[1:])
+	if m == nil {
+		return "", false
+	}
+	return string(m[1]), true
+}
+
+// switchTo replaces the connection c with one to the branch, restoring the
+// state of the session on it. It returns the response to the client and its
+// connection from now on, which is c if the switch failed.
+func (s *session) switchTo(c *sessionConn, client net.Conn, branch string, auth []byte) ([]byte, *sessionConn) {
+	// the connection to the old branch may be closed while switching
+	c.detach()
+
+	fail := func(err error) ([]byte, *sessionConn) {
+		c.attach(client)
+		select {
+		case <-c.closed:
+			client.Close()
+		default:
+		}
+		return errPacket(1, fmt.Sprintf("can't switch to branch %s: %s", branch, err)), c
+	}
+
+	if auth == nil {
+		return fail(errors.New("the handshake wasn't seen"))
+	}
+
+	addr, err := s.proxyAddr(branch)
+	if err != nil {
+		return fail(err)
+	}
+
+	next, err := dialUpstream(addr, auth)
+	if err != nil {
+		return fail(err)
+	}
+
+	s.mu.Lock()
+	s.branch = branch
+	s.upstream = addr
+	s.columns = nil
+	s.mu.Unlock()
+
+	c.server.Close()
+	<-c.closed
+	next.attach(client)
+	go next.forward(false)
+
+	msg := fmt.Sprintf("Switched to branch %s.%s", branch, s.restore(next))
+	fmt.Fprintf(s.warn, "%s\n", msg)
+	return okPacket(1), next
+}
+
+// dialUpstream connects to the proxy at addr and authenticates with the
+// handshake response of the client.
+func dialUpstream(addr string, auth []byte) (*sessionConn, error) {
+	server, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	_, greeting, err := readPacket(server)
+	if err == nil && isErr(greeting) {
+		err = errors.New(errMessage(greeting))
+	}
+	if err == nil {
+		err = writePacket(server, 1, auth)
+	}
+
+	var resp []byte
+	if err == nil {
+		_, resp, err = readPacket(server)
+	}
+	if err == nil && (len(resp) == 0 || resp[0] != 0x00) {
+		err = errors.New("authentication failed")
+		if isErr(resp) {
+			err = errors.New(errMessage(resp))
+		}
+	}
+
+	if err != nil {
+		server.Close()
+		return nil, err
+	}
+	return newSessionConn(server), nil
+}
+
+func okPacket(seq byte) []byte {
+	// no affected rows or insert ID, autocommit and no warnings
+	return appendPacket(nil, seq, []byte{0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00})
+}
+
+// errPacket returns an ER_UNKNOWN_ERROR with the message.
+func errPacket(seq byte, msg string) []byte {
+	p := []byte{0xff, 0, 0}
+	binary.LittleEndian.PutUint16(p[1:], 1105)
+	p = append(p, "#HY000"...)
+	return appendPacket(nil, seq, append(p, msg...))
+}
+
+// errMessage returns the message of an ERR packet.
+func errMessage(p []byte) string {
+	// the header, the error code and the SQL state
+	if len(p) > 9 && p[3] == '#' {
+		return string(p[9:])
+	}
+	if len(p) > 3 {
+		return string(p[3:])
+	}
+	return "unknown error"
+}
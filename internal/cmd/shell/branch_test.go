@@ -0,0 +1,78 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestSession_SwitchBranch(t *testing.T) {
+	c := qt.New(t)
+
+	main, other := &fakeServer{}, &fakeServer{}
+	mainUpstream, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, qt.IsNil)
+	defer mainUpstream.Close()
+	go main.serve(mainUpstream)
+
+	otherUpstream, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, qt.IsNil)
+	defer otherUpstream.Close()
+	go other.serve(otherUpstream)
+
+	var warn syncBuffer
+	sess, err := newSession(mainUpstream.Addr().String(), &warn)
+	c.Assert(err, qt.IsNil)
+	sess.proxyAddr = func(branch string) (string, error) {
+		if branch != "other" {
+			return "", errors.New("branch not found")
+		}
+		return otherUpstream.Addr().String(), nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sess.serve(ctx)
+
+	conn := dialSession(c, sess)
+	defer conn.Close()
+	command(c, conn, comInitDB, "mydb")
+
+	c.Assert(writePacket(conn, 0, append([]byte{comQuery}, "SWITCH BRANCH missing"...)), qt.IsNil)
+	_, resp, err := readPacket(conn)
+	c.Assert(err, qt.IsNil)
+	c.Assert(errMessage(resp), qt.Equals, "can't switch to branch missing: branch not found")
+
+	command(c, conn, comQuery, "switch branch `other`;")
+	command(c, conn, comQuery, "SELECT 1")
+
+	c.Assert(main.received(), qt.DeepEquals, []string{"mydb"})
+	c.Assert(other.received(), qt.DeepEquals, []string{"USE `mydb`", "SELECT 1"})
+	c.Assert(warn.String(), qt.Matches, `(?s).*Switched to branch other. The session was restored \(USE `+"`mydb`"+`\).*`)
+}
+
+func TestParseSwitchBranch(t *testing.T) {
+	tests := []struct {
+		stmt   string
+		branch string
+		ok     bool
+	}{
+		{stmt: "SWITCH BRANCH dev", branch: "dev", ok: true},
+		{stmt: "  switch branch `feature-1.2`;\n", branch: "feature-1.2", ok: true},
+		{stmt: "SWITCH BRANCH dev; SELECT 1"},
+		{stmt: "SELECT 'SWITCH BRANCH dev'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.stmt, func(t *testing.T) {
+			c := qt.New(t)
+
+			branch, ok := parseSwitchBranch(append([]byte{comQuery}, tt.stmt...))
+			c.Assert(branch, qt.Equals, tt.branch)
+			c.Assert(ok, qt.Equals, tt.ok)
+		})
+	}
+}
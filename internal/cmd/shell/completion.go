@@ -0,0 +1,177 @@
+package shell
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+// The mysql client completes the names of tables and columns with the
+// columns it lists for each table with COM_FIELD_LIST, which the PlanetScale
+// edge doesn't support. The session answers these commands itself, with the
+// columns of all tables it reads from information_schema at once. The client
+// lists the tables first whenever it rebuilds its completion, i.e. with
+// "rehash", which reads the columns again.
+
+// errMalformed is returned for responses that can't be parsed.
+var errMalformed = errors.New("malformed response")
+
+// invalidatingPrefixes are the statements after which the cached columns
+// are read again: the statements changing them, and the listing of the
+// tables with which the client rebuilds its completion.
+var invalidatingPrefixes = []string{"CREATE ", "ALTER ", "DROP ", "RENAME ", "SHOW TABLES"}
+
+// fieldList answers COM_FIELD_LIST for the table with the cached columns.
+// It returns false if the columns can't be read, the command is forwarded
+// to the server then.
+func (s *session) fieldList(c *sessionConn, arg []byte) ([]byte, bool) {
+	table := string(arg)
+	if i := bytes.IndexByte(arg, 0); i >= 0 {
+		table = string(arg[:i])
+	}
+
+	s.mu.Lock()
+	db := s.db
+	tables, ok := s.columns[db]
+	s.mu.Unlock()
+
+	if !ok {
+		var err error
+		if tables, err = readColumns(c, db); err != nil {
+			return nil, false
+		}
+
+		s.mu.Lock()
+		if s.columns == nil {
+			s.columns = make(map[string]map[string][]string)
+		}
+		s.columns[db] = tables
+		s.mu.Unlock()
+	}
+
+	var resp []byte
+	seq := byte(1)
+	for _, col := range tables[table] {
+		resp = appendPacket(resp, seq, columnDefinition(db, table, col))
+		seq++
+	}
+	resp = appendPacket(resp, seq, []byte{0xfe, 0x00, 0x00, 0x02, 0x00})
+	return resp, true
+}
+
+// invalidateColumns forgets the cached columns of the database after the
+// statement. s.mu is held.
+func (s *session) invalidateColumns(upper string) {
+	for _, p := range invalidatingPrefixes {
+		if strings.HasPrefix(upper, p) {
+			delete(s.columns, s.db)
+			return
+		}
+	}
+}
+
+// readColumns returns the columns of all tables of the database, the current
+// database if it's empty, in their order.
+func readColumns(c *sessionConn, db string) (map[string][]string, error) {
+	schema := "DATABASE()"
+	if db != "" {
+		schema = quoteString(db)
+	}
+	query := "SELECT table_name, column_name FROM information_schema.columns WHERE table_schema = " +
+		schema + " ORDER BY table_name, ordinal_position"
+
+	_, rows, err := queryRows(c, query)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make(map[string][]string)
+	for _, row := range rows {
+		if len(row) != 2 {
+			return nil, errMalformed
+		}
+		tables[row[0].String] = append(tables[row[0].String], row[1].String)
+	}
+	return tables, nil
+}
+
+// columnDefinition returns the definition of a text column as it's sent in
+// response to COM_FIELD_LIST.
+func columnDefinition(db, table, column string) []byte {
+	var p []byte
+	for _, s := range []string{"def", db, table, table, column, column} {
+		p = appendLenEncString(p, s)
+	}
+
+	p = append(p, 0x0c)
+	p = append(p, 0x21, 0x00)             // utf8_general_ci
+	p = append(p, 0x00, 0x01, 0x00, 0x00) // column length
+	p = append(p, 0xfd)                   // VAR_STRING
+	p = append(p, 0x00, 0x00)             // flags
+	p = append(p, 0x00)                   // decimals
+	p = append(p, 0x00, 0x00)             // filler
+	return append(p, 0xfb)                // no default value
+}
+
+func isEOF(p []byte) bool {
+	return len(p) > 0 && len(p) < 9 && p[0] == 0xfe
+}
+
+func isErr(p []byte) bool {
+	return len(p) > 0 && p[0] == 0xff
+}
+
+// readLenEnc reads a length-encoded integer.
+func readLenEnc(p []byte) (uint64, []byte, bool) {
+	if len(p) == 0 {
+		return 0, nil, false
+	}
+
+	switch b := p[0]; {
+	case b < 0xfb:
+		return uint64(b), p[1:], true
+	case b == 0xfc && len(p) >= 3:
+		return uint64(binary.LittleEndian.Uint16(p[1:])), p[3:], true
+	case b == 0xfd && len(p) >= 4:
+		return uint64(p[1]) | uint64(p[2])<<8 | uint64(p[3])<<16, p[4:], true
+	case b == 0xfe && len(p) >= 9:
+		return binary.LittleEndian.Uint64(p[1:]), p[9:], true
+	}
+	return 0, nil, false
+}
+
+// readLenEncString reads a length-encoded string, NULL is read as empty.
+func readLenEncString(p []byte) (string, []byte, bool) {
+	if len(p) > 0 && p[0] == 0xfb {
+		return "", p[1:], true
+	}
+
+	n, rest, ok := readLenEnc(p)
+	if !ok || uint64(len(rest)) < n {
+		return "", nil, false
+	}
+	return string(rest[:n]), rest[n:], true
+}
+
+func appendLenEncString(p []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 0xfb:
+		p = append(p, byte(n))
+	case n < 1<<16:
+		p = append(p, 0xfc, byte(n), byte(n>>8))
+	default:
+		p = append(p, 0xfd, byte(n), byte(n>>8), byte(n>>16))
+	}
+	return append(p, s...)
+}
+
+func appendPacket(b []byte, seq byte, payload []byte) []byte {
+	n := len(payload)
+	b = append(b, byte(n), byte(n>>8), byte(n>>16), seq)
+	return append(b, payload...)
+}
+
+func quoteString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
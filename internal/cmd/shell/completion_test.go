@@ -0,0 +1,89 @@
+package shell
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestSession_FieldList(t *testing.T) {
+	c := qt.New(t)
+
+	columns := [][2]string{{"posts", "id"}, {"users", "id"}, {"users", "email"}}
+	srv := &fakeServer{
+		respond: func(payload []byte) [][]byte {
+			if payload[0] != comQuery || !strings.Contains(string(payload), "information_schema.columns") {
+				return nil
+			}
+
+			eof := []byte{0xfe, 0x00, 0x00, 0x02, 0x00}
+			resp := [][]byte{{2}, columnDefinition("", "", "table_name"), columnDefinition("", "", "column_name"), eof}
+			for _, col := range columns {
+				resp = append(resp, appendLenEncString(appendLenEncString(nil, col[0]), col[1]))
+			}
+			return append(resp, eof)
+		},
+	}
+	upstream, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, qt.IsNil)
+	defer upstream.Close()
+	go srv.serve(upstream)
+
+	sess, err := newSession(upstream.Addr().String(), &syncBuffer{})
+	c.Assert(err, qt.IsNil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sess.serve(ctx)
+
+	conn := dialSession(c, sess)
+	defer conn.Close()
+	command(c, conn, comInitDB, "my'db")
+
+	c.Assert(fieldList(c, conn, "users"), qt.DeepEquals, []string{"id", "email"})
+	c.Assert(fieldList(c, conn, "posts"), qt.DeepEquals, []string{"id"})
+	c.Assert(fieldList(c, conn, "missing"), qt.HasLen, 0)
+
+	command(c, conn, comQuery, "ALTER TABLE users ADD name varchar(255)")
+	columns = append(columns, [2]string{"users", "name"})
+	c.Assert(fieldList(c, conn, "users"), qt.DeepEquals, []string{"id", "email", "name"})
+
+	var queries int
+	for _, cmd := range srv.received() {
+		if strings.Contains(cmd, "information_schema.columns") {
+			c.Assert(cmd, qt.Contains, `table_schema = 'my\'db'`)
+			queries++
+		}
+	}
+	c.Assert(queries, qt.Equals, 2)
+
+	command(c, conn, comQuery, "show tables")
+	c.Assert(fieldList(c, conn, "users"), qt.DeepEquals, []string{"id", "email", "name"})
+	c.Assert(srv.received()[len(srv.received())-1], qt.Contains, "information_schema.columns")
+}
+
+// fieldList sends COM_FIELD_LIST for the table and returns the names of the
+// columns of the response.
+func fieldList(c *qt.C, conn net.Conn, table string) []string {
+	c.Assert(writePacket(conn, 0, append([]byte{comFieldList}, table+"\x00"...)), qt.IsNil)
+
+	var names []string
+	for {
+		_, p, err := readPacket(conn)
+		c.Assert(err, qt.IsNil)
+		if isEOF(p) {
+			return names
+		}
+
+		var name string
+		for i := 0; i < 5; i++ {
+			var ok bool
+			name, p, ok = readLenEncString(p)
+			c.Assert(ok, qt.IsTrue)
+		}
+		names = append(names, name)
+	}
+}
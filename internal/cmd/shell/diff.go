@@ -0,0 +1,320 @@
+package shell
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+)
+
+// The DIFF statement runs a query on the current session and on another one
+// and prints the rows only one of them returns, i.e. to verify a deploy
+// request before deploying it:
+//
+//   DIFF dev SELECT id, status FROM orders WHERE id < 100;
+//
+// The other one is the named session, the favorite or the branch with the
+// name, in this order. Rows are compared as a whole, regardless of their
+// order.
+
+var diffRe = regexp.MustCompile("(?is)^\\s*DIFF\\s+`?([\\w.-]+)`?\\s+(.+?)[\\s;]*$")
+
+// parseDiff returns the name of the other session and the query of a DIFF
+// command.
+func parseDiff(payload []byte) (name, query string, ok bool) {
+	if payload[0] != comQuery {
+		return "", "", false
+	}
+
+	m := diffRe.FindSubmatch(payload[1:])
+	if m == nil {
+		return "", "", false
+	}
+	return string(m[1]), string(m[2]), true
+}
+
+// diff runs the query on the connection c of the current session and on the
+// one named name, and prints the difference of the results.
+func (s *session) diff(c *sessionConn, name, query string, auth []byte) []byte {
+	fail := func(err error) []byte {
+		return errPacket(1, fmt.Sprintf("can't diff with %s: %s", name, err))
+	}
+
+	s.mu.Lock()
+	current := fmt.Sprintf("%s (branch %s)", s.name, s.branch)
+	isCurrent := name == s.name
+	s.mu.Unlock()
+	if isCurrent {
+		return fail(errors.New("it's the current session"))
+	}
+
+	other, label, release, err := s.diffConn(name, auth)
+	if err != nil {
+		return fail(err)
+	}
+	defer release()
+
+	columns, rows, err := queryRows(c, query)
+	if err != nil {
+		return fail(fmt.Errorf("%s: %s", current, err))
+	}
+	otherColumns, otherRows, err := queryRows(other, query)
+	if err != nil {
+		return fail(fmt.Errorf("%s: %s", label, err))
+	}
+
+	fmt.Fprint(s.warn, diffRows(current, label, columns, otherColumns, rows, otherRows))
+	return okPacket(1)
+}
+
+// diffConn returns a connection to the named session, or a new one to the
+// branch of the favorite or the branch with the name, its description and a
+// function releasing it.
+func (s *session) diffConn(name string, auth []byte) (*sessionConn, string, func(), error) {
+	s.mu.Lock()
+	t, ok := s.tabs[name]
+	db := s.db
+	s.mu.Unlock()
+
+	branch, label := name, "branch "+name
+	switch {
+	case ok:
+		branch, label = t.branch, fmt.Sprintf("%s (branch %s)", name, t.branch)
+		if t.conn != nil {
+			select {
+			case <-t.conn.closed:
+			default:
+				return t.conn, label, func() {}, nil
+			}
+		}
+		if t.db != "" {
+			db = t.db
+		}
+	case s.favorites[name] != "":
+		branch = s.favorites[name]
+		label = fmt.Sprintf("%s (branch %s)", name, branch)
+	}
+
+	if auth == nil {
+		return nil, "", nil, errors.New("the handshake wasn't seen")
+	}
+
+	addr, err := s.proxyAddr(branch)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	conn, err := dialUpstream(addr, auth)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	go conn.forward(false)
+
+	release := func() {
+		conn.server.Close()
+		<-conn.closed
+	}
+	if db != "" {
+		if _, _, err := queryRows(conn, "USE "+cmdutil.QuoteIdent(db)); err != nil {
+			release()
+			return nil, "", nil, err
+		}
+	}
+	return conn, label, release, nil
+}
+
+// resultColumn is a column of a result set.
+type resultColumn struct {
+	name    string
+	numeric bool
+}
+
+// numericTypes are the column types of numbers, DECIMAL to DOUBLE, LONGLONG,
+// INT24, YEAR and NEWDECIMAL.
+var numericTypes = map[byte]bool{
+	0x00: true, 0x01: true, 0x02: true, 0x03: true, 0x04: true, 0x05: true,
+	0x08: true, 0x09: true, 0x0d: true, 0xf6: true,
+}
+
+// queryRows runs the query on the connection and returns the columns and the
+// rows of its result. The message of an ERR packet is returned as error.
+func queryRows(c *sessionConn, query string) ([]resultColumn, [][]sql.NullString, error) {
+	var (
+		columns   []resultColumn
+		rows      [][]sql.NullString
+		serverErr error
+	)
+	err := c.exchange(append([]byte{comQuery}, query...), func(next func() ([]byte, error)) error {
+		p, err := next()
+		if err != nil {
+			return err
+		}
+		if isErr(p) {
+			serverErr = errors.New(errMessage(p))
+			return nil
+		}
+		if len(p) > 0 && p[0] == 0x00 {
+			// an OK packet, the statement returns no result set
+			return nil
+		}
+
+		count, _, ok := readLenEnc(p)
+		if !ok {
+			return errMalformed
+		}
+
+		// the column definitions, followed by an EOF packet
+		for i := uint64(0); i <= count; i++ {
+			def, err := next()
+			if err != nil {
+				return err
+			}
+			if i == count {
+				break
+			}
+
+			// the catalog, schema, table and original table precede
+			// the name, followed by the original name, the length of
+			// the fixed fields, the character set and the length
+			for j := 0; j < 4 && def != nil; j++ {
+				_, def, _ = readLenEncString(def)
+			}
+			name, def, ok := readLenEncString(def)
+			if !ok {
+				return errMalformed
+			}
+			if _, def, ok = readLenEncString(def); !ok || len(def) < 8 {
+				return errMalformed
+			}
+			columns = append(columns, resultColumn{name: name, numeric: numericTypes[def[7]]})
+		}
+
+		for {
+			p, err := next()
+			if err != nil {
+				return err
+			}
+			if isEOF(p) {
+				return nil
+			}
+			if isErr(p) {
+				serverErr = errors.New(errMessage(p))
+				return nil
+			}
+
+			row := make([]sql.NullString, 0, count)
+			for i := uint64(0); i < count; i++ {
+				if len(p) > 0 && p[0] == 0xfb {
+					row = append(row, sql.NullString{})
+					p = p[1:]
+					continue
+				}
+
+				var v string
+				if v, p, ok = readLenEncString(p); !ok {
+					return errMalformed
+				}
+				row = append(row, sql.NullString{String: v, Valid: true})
+			}
+			rows = append(rows, row)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if serverErr != nil {
+		return nil, nil, serverErr
+	}
+	return columns, rows, nil
+}
+
+// diffRows returns the rows only one of the results has, prefixed with "-"
+// for the first and "+" for the second one, followed by a summary.
+func diffRows(from, to string, fromColumns, toColumns []resultColumn, fromRows, toRows [][]sql.NullString) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", from, to)
+
+	if fromNames, toNames := columnNames(fromColumns), columnNames(toColumns); fromNames == toNames {
+		fmt.Fprintf(&b, "  %s\n", fromNames)
+	} else {
+		fmt.Fprintf(&b, "- %s\n+ %s\n", fromNames, toNames)
+	}
+
+	removed := rowsNotIn(fromRows, toRows)
+	added := rowsNotIn(toRows, fromRows)
+	for _, row := range removed {
+		fmt.Fprintf(&b, "- %s\n", formatRow(row))
+	}
+	for _, row := range added {
+		fmt.Fprintf(&b, "+ %s\n", formatRow(row))
+	}
+
+	fmt.Fprintf(&b, "%s only in %s, %s only in %s, %s in both.\n",
+		rowCount(len(removed)), from, rowCount(len(added)), to, rowCount(len(fromRows)-len(removed)))
+	return b.String()
+}
+
+// rowsNotIn returns the rows of a that b doesn't have, a row that a has more
+// often than b counts as missing in b as many times.
+func rowsNotIn(a, b [][]sql.NullString) [][]sql.NullString {
+	counts := make(map[string]int, len(b))
+	for _, row := range b {
+		counts[rowKey(row)]++
+	}
+
+	var missing [][]sql.NullString
+	for _, row := range a {
+		key := rowKey(row)
+		if counts[key] > 0 {
+			counts[key]--
+			continue
+		}
+		missing = append(missing, row)
+	}
+	return missing
+}
+
+func rowKey(row []sql.NullString) string {
+	var b strings.Builder
+	for _, v := range row {
+		// the length keeps the values apart, whatever they contain
+		if v.Valid {
+			b.WriteString(strconv.Itoa(len(v.String)))
+			b.WriteByte(':')
+			b.WriteString(v.String)
+		} else {
+			b.WriteString("NULL")
+		}
+		b.WriteByte(';')
+	}
+	return b.String()
+}
+
+func columnNames(columns []resultColumn) string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.name
+	}
+	return strings.Join(names, " | ")
+}
+
+func formatRow(row []sql.NullString) string {
+	values := make([]string, len(row))
+	for i, v := range row {
+		values[i] = "NULL"
+		if v.Valid {
+			values[i] = v.String
+		}
+	}
+	return strings.Join(values, " | ")
+}
+
+func rowCount(n int) string {
+	if n == 1 {
+		return "1 row"
+	}
+	return fmt.Sprintf("%d rows", n)
+}
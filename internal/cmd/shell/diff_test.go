@@ -0,0 +1,101 @@
+package shell
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestSession_Diff(t *testing.T) {
+	c := qt.New(t)
+
+	servers := map[string]*fakeServer{
+		"main": resultSetServer("1,alice", "2,bob", "3,NULL"),
+		"dev":  resultSetServer("3,NULL", "2,robert", "1,alice", "4,dave"),
+	}
+	addrs := make(map[string]string)
+	for branch, srv := range servers {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		c.Assert(err, qt.IsNil)
+		defer l.Close()
+		go srv.serve(l)
+		addrs[branch] = l.Addr().String()
+	}
+
+	var warn syncBuffer
+	sess, err := newSession(addrs["main"], &warn)
+	c.Assert(err, qt.IsNil)
+	sess.name, sess.branch = "main", "main"
+	sess.proxyAddr = func(branch string) (string, error) {
+		return addrs[branch], nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sess.serve(ctx)
+
+	conn := dialSession(c, sess)
+	defer conn.Close()
+	command(c, conn, comInitDB, "mydb")
+	command(c, conn, comQuery, "DIFF dev SELECT id, name FROM t;")
+
+	c.Assert(servers["main"].received(), qt.DeepEquals, []string{"mydb", "SELECT id, name FROM t"})
+	c.Assert(servers["dev"].received(), qt.DeepEquals, []string{"USE `mydb`", "SELECT id, name FROM t"})
+	c.Assert(warn.String(), qt.Equals, `--- main (branch main)
++++ branch dev
+  id | name
+- 2 | bob
++ 2 | robert
++ 4 | dave
+1 row only in main (branch main), 2 rows only in branch dev, 2 rows in both.
+`)
+}
+
+func TestRowsNotIn(t *testing.T) {
+	c := qt.New(t)
+
+	row := func(values ...string) []sql.NullString {
+		r := make([]sql.NullString, len(values))
+		for i, v := range values {
+			r[i] = sql.NullString{String: v, Valid: v != "NULL"}
+		}
+		return r
+	}
+
+	a := [][]sql.NullString{row("1", "x"), row("1", "x"), row("2", "NULL"), row("3", "")}
+	b := [][]sql.NullString{row("1", "x"), row("2", "NULL"), row("3", "NULL")}
+	c.Assert(rowsNotIn(a, b), qt.DeepEquals, [][]sql.NullString{row("1", "x"), row("3", "")})
+	c.Assert(rowsNotIn(b, a), qt.DeepEquals, [][]sql.NullString{row("3", "NULL")})
+}
+
+// resultSetServer returns a fake server answering SELECT queries with the
+// rows of the columns id and name, the values of which are separated by
+// commas.
+func resultSetServer(rows ...string) *fakeServer {
+	return &fakeServer{
+		respond: func(payload []byte) [][]byte {
+			if payload[0] != comQuery || !strings.HasPrefix(string(payload[1:]), "SELECT") {
+				return nil
+			}
+
+			eof := []byte{0xfe, 0x00, 0x00, 0x02, 0x00}
+			resp := [][]byte{{2}, columnDefinition("", "t", "id"), columnDefinition("", "t", "name"), eof}
+			for _, row := range rows {
+				var p []byte
+				for _, v := range strings.Split(row, ",") {
+					if v == "NULL" {
+						p = append(p, 0xfb)
+						continue
+					}
+					p = appendLenEncString(p, v)
+				}
+				resp = append(resp, p)
+			}
+			return append(resp, eof)
+		},
+	}
+}
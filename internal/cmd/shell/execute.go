@@ -0,0 +1,108 @@
+package shell
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// readStatements returns the statements of --execute, which are either given
+// directly or read from the file at its path.
+func readStatements(execute string) (string, error) {
+	if fi, err := os.Stat(execute); err == nil && fi.Mode().IsRegular() {
+		out, err := ioutil.ReadFile(execute)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	return execute, nil
+}
+
+// executeToFile runs the statements on the database through the proxy at
+// addr and writes the result of the one returning rows to the file at the
+// path, in the format of its extension. It returns the number of rows.
+func executeToFile(ctx context.Context, addr, database, stmts, path string) (int, error) {
+	format, err := exportFormatOf(path)
+	if err != nil {
+		return 0, err
+	}
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/%s?multiStatements=true", addr, database))
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, stmts)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var (
+		columns []resultColumn
+		result  [][]sql.NullString
+	)
+	for {
+		types, err := rows.ColumnTypes()
+		if err != nil {
+			return 0, err
+		}
+
+		if len(types) > 0 {
+			if columns != nil {
+				return 0, errors.New("--out writes the result of one statement, but several statements return rows")
+			}
+
+			columns = make([]resultColumn, len(types))
+			for i, t := range types {
+				columns[i] = resultColumn{name: t.Name(), numeric: isNumericType(t.DatabaseTypeName())}
+			}
+
+			for rows.Next() {
+				row := make([]sql.NullString, len(columns))
+				dest := make([]interface{}, len(row))
+				for i := range row {
+					dest[i] = &row[i]
+				}
+				if err := rows.Scan(dest...); err != nil {
+					return 0, err
+				}
+				result = append(result, row)
+			}
+		}
+
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if columns == nil {
+		return 0, errors.New("--out requires a statement returning rows")
+	}
+
+	if err := writeExportFile(path, format, columns, result); err != nil {
+		return 0, err
+	}
+	return len(result), nil
+}
+
+// isNumericType returns whether the type name, as reported by the driver, is
+// the one of numbers.
+func isNumericType(name string) bool {
+	switch strings.TrimPrefix(name, "UNSIGNED ") {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "FLOAT", "DOUBLE", "DECIMAL", "YEAR":
+		return true
+	}
+	return false
+}
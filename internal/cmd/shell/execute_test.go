@@ -0,0 +1,30 @@
+package shell
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestReadStatements(t *testing.T) {
+	c := qt.New(t)
+
+	stmts, err := readStatements("SELECT 1")
+	c.Assert(err, qt.IsNil)
+	c.Assert(stmts, qt.Equals, "SELECT 1")
+
+	path := filepath.Join(t.TempDir(), "migrate.sql")
+	err = ioutil.WriteFile(path, []byte("SELECT 1;\nSELECT 2;\n"), 0600)
+	c.Assert(err, qt.IsNil)
+
+	stmts, err = readStatements(path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(stmts, qt.Equals, "SELECT 1;\nSELECT 2;")
+
+	// directories aren't read
+	stmts, err = readStatements(filepath.Dir(path))
+	c.Assert(err, qt.IsNil)
+	c.Assert(stmts, qt.Equals, filepath.Dir(path))
+}
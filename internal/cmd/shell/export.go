@@ -0,0 +1,173 @@
+package shell
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// The EXPORT statement writes the result of a query to a file, as CSV with a
+// header, as a JSON array of objects, in which numbers and NULL keep their
+// types, or as Parquet:
+//
+//   EXPORT CSV orders.csv SELECT * FROM orders;
+//   EXPORT JSON '/tmp/my orders.json' SELECT * FROM orders;
+//   EXPORT PARQUET orders.parquet SELECT * FROM orders;
+
+var exportRe = regexp.MustCompile(`(?is)^\s*EXPORT\s+(\w+)\s+('(?:[^'\\]|\\.)*'|\S+)\s+(.+?)[\s;]*$`)
+
+// exportFormats are the formats results are exported as.
+var exportFormats = []string{"csv", "json", "parquet"}
+
+// parseExport returns the format, the path and the query of an EXPORT
+// command.
+func parseExport(payload []byte) (format, path, query string, ok bool) {
+	if payload[0] != comQuery {
+		return "", "", "", false
+	}
+
+	m := exportRe.FindSubmatch(payload[1:])
+	if m == nil {
+		return "", "", "", false
+	}
+
+	path = string(m[2])
+	if strings.HasPrefix(path, "'") {
+		path = strings.NewReplacer(`\\`, `\`, `\'`, `'`).Replace(path[1 : len(path)-1])
+	}
+	return strings.ToLower(string(m[1])), path, string(m[3]), true
+}
+
+// export runs the query on the connection and writes its result to the file
+// at the path.
+func (s *session) export(c *sessionConn, format, path, query string) []byte {
+	fail := func(err error) []byte {
+		return errPacket(1, fmt.Sprintf("can't export to %s: %s", path, err))
+	}
+
+	if err := checkExportFormat(format); err != nil {
+		return fail(err)
+	}
+
+	columns, rows, err := queryRows(c, query)
+	if err != nil {
+		return fail(err)
+	}
+	if err := writeExportFile(path, format, columns, rows); err != nil {
+		return fail(err)
+	}
+
+	fmt.Fprintf(s.warn, "Exported %s to %s.\n", rowCount(len(rows)), path)
+	return okPacket(1)
+}
+
+// exportFormatOf returns the format of the file at the path, by its
+// extension.
+func exportFormatOf(path string) (string, error) {
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	if err := checkExportFormat(format); err != nil {
+		return "", fmt.Errorf("can't tell the format of %s: %s", path, err)
+	}
+	return format, nil
+}
+
+func checkExportFormat(format string) error {
+	for _, f := range exportFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported format %q, supported formats are: %s", format, strings.Join(exportFormats, ", "))
+}
+
+// writeExportFile writes the result to the file at the path in the format.
+func writeExportFile(path, format string, columns []resultColumn, rows [][]sql.NullString) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := writeExport(f, format, columns, rows); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeExport writes the result to w in the format.
+func writeExport(w io.Writer, format string, columns []resultColumn, rows [][]sql.NullString) error {
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		names := make([]string, len(columns))
+		for i, col := range columns {
+			names[i] = col.name
+		}
+		if err := cw.Write(names); err != nil {
+			return err
+		}
+
+		// CSV has no NULL, it's written as an empty field
+		record := make([]string, len(columns))
+		for _, row := range rows {
+			for i, v := range row {
+				record[i] = v.String
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case "json":
+		var b bytes.Buffer
+		b.WriteString("[")
+		for i, row := range rows {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n  {")
+			for j, v := range row {
+				if j > 0 {
+					b.WriteString(", ")
+				}
+				name, _ := json.Marshal(columns[j].name)
+				b.Write(name)
+				b.WriteString(": ")
+				b.Write(jsonValue(columns[j], v))
+			}
+			b.WriteString("}")
+		}
+		if len(rows) > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("]\n")
+		_, err := w.Write(b.Bytes())
+		return err
+	case "parquet":
+		return writeParquet(w, columns, rows)
+	}
+	return errors.New("unsupported format")
+}
+
+// jsonValue returns the value of the column as JSON, numbers are written as
+// they are.
+func jsonValue(col resultColumn, v sql.NullString) []byte {
+	if !v.Valid {
+		return []byte("null")
+	}
+	if col.numeric && json.Valid([]byte(v.String)) {
+		return []byte(v.String)
+	}
+
+	out, _ := json.Marshal(v.String)
+	return out
+}
@@ -0,0 +1,136 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestSession_Export(t *testing.T) {
+	c := qt.New(t)
+
+	srv := resultSetServer("1,alice", "2,NULL")
+	upstream, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, qt.IsNil)
+	defer upstream.Close()
+	go srv.serve(upstream)
+
+	var warn syncBuffer
+	sess, err := newSession(upstream.Addr().String(), &warn)
+	c.Assert(err, qt.IsNil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sess.serve(ctx)
+
+	conn := dialSession(c, sess)
+	defer conn.Close()
+
+	path := filepath.Join(c.TempDir(), "my users.csv")
+	command(c, conn, comQuery, "EXPORT CSV '"+path+"' SELECT id, name FROM users;")
+
+	out, err := ioutil.ReadFile(path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, "id,name\n1,alice\n2,\n")
+	c.Assert(srv.received(), qt.DeepEquals, []string{"SELECT id, name FROM users"})
+	c.Assert(warn.String(), qt.Equals, "Exported 2 rows to "+path+".\n")
+}
+
+func TestWriteExport(t *testing.T) {
+	columns := []resultColumn{{name: "id", numeric: true}, {name: "note"}}
+	rows := [][]sql.NullString{
+		{{String: "1", Valid: true}, {String: `say "hi", then go`, Valid: true}},
+		{{String: "2", Valid: true}, {}},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "csv",
+			want:   "id,note\n1,\"say \"\"hi\"\", then go\"\n2,\n",
+		},
+		{
+			format: "json",
+			want:   "[\n  {\"id\": 1, \"note\": \"say \\\"hi\\\", then go\"},\n  {\"id\": 2, \"note\": null}\n]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			c := qt.New(t)
+
+			var b bytes.Buffer
+			c.Assert(writeExport(&b, tt.format, columns, rows), qt.IsNil)
+			c.Assert(b.String(), qt.Equals, tt.want)
+		})
+	}
+}
+
+func TestExportFormatOf(t *testing.T) {
+	c := qt.New(t)
+
+	format, err := exportFormatOf("out/Orders.JSON")
+	c.Assert(err, qt.IsNil)
+	c.Assert(format, qt.Equals, "json")
+
+	format, err = exportFormatOf("orders.parquet")
+	c.Assert(err, qt.IsNil)
+	c.Assert(format, qt.Equals, "parquet")
+
+	_, err = exportFormatOf("orders.xlsx")
+	c.Assert(err, qt.ErrorMatches, `can't tell the format of orders.xlsx: unsupported format "xlsx", supported formats are: csv, json, parquet`)
+}
+
+func TestWriteParquet(t *testing.T) {
+	c := qt.New(t)
+
+	columns := []resultColumn{{name: "id", numeric: true}, {name: "price", numeric: true}, {name: "note"}}
+	rows := [][]sql.NullString{
+		{{String: "1", Valid: true}, {String: "2.50", Valid: true}, {String: "first", Valid: true}},
+		{{String: "2", Valid: true}, {}, {}},
+	}
+
+	var b bytes.Buffer
+	c.Assert(writeParquet(&b, columns, rows), qt.IsNil)
+
+	out := b.Bytes()
+	c.Assert(string(out[:4]), qt.Equals, "PAR1")
+	c.Assert(string(out[len(out)-4:]), qt.Equals, "PAR1")
+
+	// the footer ends with the length of the metadata
+	metaLen := int(binary.LittleEndian.Uint32(out[len(out)-8:]))
+	meta := out[len(out)-8-metaLen : len(out)-8]
+	for _, name := range []string{"schema", "id", "price", "note", "pscale"} {
+		c.Assert(bytes.Contains(meta, []byte(name)), qt.IsTrue, qt.Commentf("%s", name))
+	}
+
+	// the page of note has the definition levels of one value and one NULL,
+	// followed by the value
+	c.Assert(bytes.Contains(out, []byte("\x02\x01\x02\x00\x05\x00\x00\x00first")), qt.IsTrue)
+}
+
+func TestParquetType(t *testing.T) {
+	c := qt.New(t)
+
+	typeOf := func(numeric bool, values ...string) int32 {
+		var rows [][]sql.NullString
+		for _, v := range values {
+			rows = append(rows, []sql.NullString{{String: v, Valid: v != "NULL"}})
+		}
+		return parquetType(resultColumn{name: "x", numeric: numeric}, rows, 0)
+	}
+
+	c.Assert(typeOf(true, "1", "NULL", "-42"), qt.Equals, int32(parquetInt64))
+	c.Assert(typeOf(true, "1", "2.5"), qt.Equals, int32(parquetDouble))
+	c.Assert(typeOf(true, "1", "123456789012345678901234"), qt.Equals, int32(parquetByteArray))
+	c.Assert(typeOf(false, "1"), qt.Equals, int32(parquetByteArray))
+}
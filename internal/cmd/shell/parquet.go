@@ -0,0 +1,313 @@
+package shell
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+)
+
+// The Parquet files of EXPORT have a single row group with one uncompressed,
+// PLAIN encoded data page per column. All columns are optional. Numeric
+// columns are written as INT64 if all their values are integers that fit,
+// otherwise as DOUBLE, other columns as UTF-8 strings. See
+// https://github.com/apache/parquet-format.
+
+const parquetMagic = "PAR1"
+
+// Parquet physical types, repetitions, encodings and the converted type of
+// strings.
+const (
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetOptional = 1
+
+	parquetPlain = 0
+	parquetRLE   = 3
+
+	parquetUTF8 = 0
+)
+
+// Thrift compact protocol types.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// writeParquet writes the result to w as a Parquet file.
+func writeParquet(w io.Writer, columns []resultColumn, rows [][]sql.NullString) error {
+	var file bytes.Buffer
+	file.WriteString(parquetMagic)
+
+	types := make([]int32, len(columns))
+	chunks := make([]parquetChunk, len(columns))
+	for i, col := range columns {
+		types[i] = parquetType(col, rows, i)
+		if len(rows) == 0 {
+			continue
+		}
+
+		page := parquetPage(types[i], rows, i)
+		chunks[i].offset = int64(file.Len())
+
+		var header compactWriter
+		header.structBegin()
+		header.i32Field(1, 0) // DATA_PAGE
+		header.i32Field(2, int32(len(page)))
+		header.i32Field(3, int32(len(page)))
+		header.structField(5)
+		header.i32Field(1, int32(len(rows)))
+		header.i32Field(2, parquetPlain)
+		header.i32Field(3, parquetRLE)
+		header.i32Field(4, parquetRLE)
+		header.structEnd()
+		header.structEnd()
+
+		file.Write(header.buf.Bytes())
+		file.Write(page)
+		chunks[i].size = int64(file.Len()) - chunks[i].offset
+	}
+
+	var meta compactWriter
+	meta.structBegin()
+	meta.i32Field(1, 1)
+
+	meta.listField(2, thriftStruct, len(columns)+1)
+	meta.structBegin()
+	meta.binaryField(4, []byte("schema"))
+	meta.i32Field(5, int32(len(columns)))
+	meta.structEnd()
+	for i, col := range columns {
+		meta.structBegin()
+		meta.i32Field(1, types[i])
+		meta.i32Field(3, parquetOptional)
+		meta.binaryField(4, []byte(col.name))
+		if types[i] == parquetByteArray {
+			meta.i32Field(6, parquetUTF8)
+		}
+		meta.structEnd()
+	}
+
+	meta.i64Field(3, int64(len(rows)))
+
+	if len(rows) == 0 {
+		meta.listField(4, thriftStruct, 0)
+	} else {
+		var total int64
+		for _, c := range chunks {
+			total += c.size
+		}
+
+		meta.listField(4, thriftStruct, 1)
+		meta.structBegin()
+		meta.listField(1, thriftStruct, len(columns))
+		for i, col := range columns {
+			meta.structBegin()
+			meta.i64Field(2, chunks[i].offset)
+			meta.structField(3)
+			meta.i32Field(1, types[i])
+			meta.listField(2, thriftI32, 2)
+			meta.varint(zigzag(parquetPlain))
+			meta.varint(zigzag(parquetRLE))
+			meta.listField(3, thriftBinary, 1)
+			meta.binary([]byte(col.name))
+			meta.i32Field(4, 0) // UNCOMPRESSED
+			meta.i64Field(5, int64(len(rows)))
+			meta.i64Field(6, chunks[i].size)
+			meta.i64Field(7, chunks[i].size)
+			meta.i64Field(9, chunks[i].offset)
+			meta.structEnd()
+			meta.structEnd()
+		}
+		meta.i64Field(2, total)
+		meta.i64Field(3, int64(len(rows)))
+		meta.structEnd()
+	}
+
+	meta.binaryField(6, []byte("pscale"))
+	meta.structEnd()
+
+	file.Write(meta.buf.Bytes())
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(meta.buf.Len()))
+	file.Write(size[:])
+	file.WriteString(parquetMagic)
+
+	_, err := w.Write(file.Bytes())
+	return err
+}
+
+// parquetChunk is the position of the column chunk of a column in the file.
+type parquetChunk struct {
+	offset int64
+	size   int64
+}
+
+// parquetType returns the physical type of the i-th column.
+func parquetType(col resultColumn, rows [][]sql.NullString, i int) int32 {
+	if !col.numeric {
+		return parquetByteArray
+	}
+
+	typ := int32(parquetInt64)
+	for _, row := range rows {
+		v := row[i]
+		if !v.Valid {
+			continue
+		}
+		_, err := strconv.ParseInt(v.String, 10, 64)
+		if err == nil {
+			continue
+		}
+		// integers out of the range of INT64 are kept exactly as strings
+		if errors.Is(err, strconv.ErrRange) {
+			return parquetByteArray
+		}
+		if _, err := strconv.ParseFloat(v.String, 64); err != nil {
+			return parquetByteArray
+		}
+		typ = parquetDouble
+	}
+	return typ
+}
+
+// parquetPage returns the data page of the i-th column: the definition
+// levels telling NULL apart, followed by the values that aren't NULL.
+func parquetPage(typ int32, rows [][]sql.NullString, i int) []byte {
+	var levels bytes.Buffer
+	for start := 0; start < len(rows); {
+		valid := rows[start][i].Valid
+		end := start + 1
+		for end < len(rows) && rows[end][i].Valid == valid {
+			end++
+		}
+
+		// an RLE run of the definition level, which is 1 bit wide
+		writeUvarint(&levels, uint64(end-start)<<1)
+		if valid {
+			levels.WriteByte(1)
+		} else {
+			levels.WriteByte(0)
+		}
+		start = end
+	}
+
+	var page bytes.Buffer
+	var n [8]byte
+	binary.LittleEndian.PutUint32(n[:4], uint32(levels.Len()))
+	page.Write(n[:4])
+	page.Write(levels.Bytes())
+
+	for _, row := range rows {
+		v := row[i]
+		if !v.Valid {
+			continue
+		}
+
+		switch typ {
+		case parquetInt64:
+			x, _ := strconv.ParseInt(v.String, 10, 64)
+			binary.LittleEndian.PutUint64(n[:], uint64(x))
+			page.Write(n[:])
+		case parquetDouble:
+			x, _ := strconv.ParseFloat(v.String, 64)
+			binary.LittleEndian.PutUint64(n[:], math.Float64bits(x))
+			page.Write(n[:])
+		default:
+			binary.LittleEndian.PutUint32(n[:4], uint32(len(v.String)))
+			page.Write(n[:4])
+			page.WriteString(v.String)
+		}
+	}
+	return page.Bytes()
+}
+
+// compactWriter writes the Thrift compact protocol the metadata of Parquet
+// files is encoded with.
+type compactWriter struct {
+	buf bytes.Buffer
+
+	// last is the ID of the previous field of the current struct, stack
+	// the ones of the structs it's nested in.
+	last  int16
+	stack []int16
+}
+
+func (w *compactWriter) structBegin() {
+	w.stack = append(w.stack, w.last)
+	w.last = 0
+}
+
+func (w *compactWriter) structEnd() {
+	w.buf.WriteByte(0)
+	w.last = w.stack[len(w.stack)-1]
+	w.stack = w.stack[:len(w.stack)-1]
+}
+
+func (w *compactWriter) fieldHeader(id int16, typ byte) {
+	if delta := id - w.last; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.varint(zigzag(int64(id)))
+	}
+	w.last = id
+}
+
+func (w *compactWriter) i32Field(id int16, v int32) {
+	w.fieldHeader(id, thriftI32)
+	w.varint(zigzag(int64(v)))
+}
+
+func (w *compactWriter) i64Field(id int16, v int64) {
+	w.fieldHeader(id, thriftI64)
+	w.varint(zigzag(v))
+}
+
+func (w *compactWriter) binaryField(id int16, v []byte) {
+	w.fieldHeader(id, thriftBinary)
+	w.binary(v)
+}
+
+// structField begins a struct field, it's ended with structEnd.
+func (w *compactWriter) structField(id int16) {
+	w.fieldHeader(id, thriftStruct)
+	w.structBegin()
+}
+
+// listField begins a list field of n elements, which are written next.
+func (w *compactWriter) listField(id int16, elem byte, n int) {
+	w.fieldHeader(id, thriftList)
+	if n < 15 {
+		w.buf.WriteByte(byte(n)<<4 | elem)
+		return
+	}
+	w.buf.WriteByte(0xf0 | elem)
+	w.varint(uint64(n))
+}
+
+func (w *compactWriter) binary(v []byte) {
+	w.varint(uint64(len(v)))
+	w.buf.Write(v)
+}
+
+func (w *compactWriter) varint(v uint64) {
+	writeUvarint(&w.buf, v)
+}
+
+func writeUvarint(b *bytes.Buffer, v uint64) {
+	var n [binary.MaxVarintLen64]byte
+	b.Write(n[:binary.PutUvarint(n[:], v)])
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
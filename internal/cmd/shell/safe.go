@@ -0,0 +1,54 @@
+package shell
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/planetscale/cli/internal/sqlfmt"
+)
+
+// In safe mode the session rejects UPDATE and DELETE statements the first
+// time they're run, with an estimate of the rows they change, which it counts
+// with a SELECT COUNT(*) of the same WHERE clause. Running the same statement
+// again right away confirms it. The confirmation is given through the mysql
+// client, which owns the terminal.
+
+// confirmChange answers an UPDATE or DELETE command in safe mode that wasn't
+// confirmed yet. It returns false if the command is to be forwarded.
+func (s *session) confirmChange(c *sessionConn, payload []byte) ([]byte, bool) {
+	if !s.safe || payload[0] != comQuery {
+		return nil, false
+	}
+
+	stmt := strings.TrimSpace(string(payload[1:]))
+
+	s.mu.Lock()
+	confirmed := stmt == s.pending
+	s.pending = ""
+	s.mu.Unlock()
+
+	query, ok := sqlfmt.CountQuery(stmt)
+	if !ok || confirmed {
+		return nil, false
+	}
+
+	estimate := "an unknown number of rows"
+	if query != "" {
+		_, rows, err := queryRows(c, query)
+		switch {
+		case err != nil:
+			estimate += fmt.Sprintf(" (can't count them: %s)", err)
+		case len(rows) == 1 && len(rows[0]) == 1:
+			if n, err := strconv.Atoi(rows[0][0].String); err == nil {
+				estimate = rowCount(n)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.pending = stmt
+	s.mu.Unlock()
+
+	return errPacket(1, fmt.Sprintf("safe mode: the statement changes %s. Run it again to confirm", estimate)), true
+}
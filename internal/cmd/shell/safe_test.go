@@ -0,0 +1,65 @@
+package shell
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestSession_ConfirmChange(t *testing.T) {
+	c := qt.New(t)
+
+	srv := &fakeServer{
+		respond: func(payload []byte) [][]byte {
+			if !strings.HasPrefix(string(payload[1:]), "SELECT COUNT(*)") {
+				return nil
+			}
+
+			eof := []byte{0xfe, 0x00, 0x00, 0x02, 0x00}
+			return [][]byte{{1}, columnDefinition("", "", "COUNT(*)"), eof, appendLenEncString(nil, "42"), eof}
+		},
+	}
+	upstream, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, qt.IsNil)
+	defer upstream.Close()
+	go srv.serve(upstream)
+
+	sess, err := newSession(upstream.Addr().String(), &syncBuffer{})
+	c.Assert(err, qt.IsNil)
+	sess.safe = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sess.serve(ctx)
+
+	conn := dialSession(c, sess)
+	defer conn.Close()
+
+	// query sends the statement and returns the error message of the
+	// response, if any
+	query := func(stmt string) string {
+		c.Assert(writePacket(conn, 0, append([]byte{comQuery}, stmt...)), qt.IsNil)
+		_, resp, err := readPacket(conn)
+		c.Assert(err, qt.IsNil)
+		if isErr(resp) {
+			return errMessage(resp)
+		}
+		return ""
+	}
+
+	const del = "DELETE FROM users WHERE active = 0"
+	c.Assert(query(del), qt.Equals, "safe mode: the statement changes 42 rows. Run it again to confirm")
+	c.Assert(query("SELECT 1"), qt.Equals, "")
+	c.Assert(query(del), qt.Equals, "safe mode: the statement changes 42 rows. Run it again to confirm")
+	c.Assert(query(del), qt.Equals, "")
+
+	c.Assert(srv.received(), qt.DeepEquals, []string{
+		"SELECT COUNT(*) FROM users WHERE active = 0",
+		"SELECT 1",
+		"SELECT COUNT(*) FROM users WHERE active = 0",
+		del,
+	})
+}
@@ -0,0 +1,441 @@
+package shell
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+)
+
+const (
+	comInitDB    = 0x02
+	comQuery     = 0x03
+	comFieldList = 0x04
+
+	// the capabilities the session removes from the greeting of the server,
+	// so the traffic of the client stays inspectable. clientDeprecateEOF is
+	// in the upper half of the flags.
+	clientCompress     = 0x0020
+	clientSSL          = 0x0800
+	clientDeprecateEOF = 0x0100
+)
+
+var sqlModeRe = regexp.MustCompile(`(?is)^SET\s+(?:SESSION\s+|LOCAL\s+|@@SESSION\.|@@LOCAL\.|@@)?sql_mode\s*:?=\s*(.+)$`)
+
+// session sits between the mysql client and the proxy and keeps track of the
+// state of the client's session: its database and sql_mode. It also serves
+// the columns the client completes, see fieldList. The mysql client
+// reconnects whenever its connection drops, and the session restores the
+// state on the new connection before forwarding the client's first command.
+// Transactions can't be restored, the user is warned about lost ones instead.
+// The state is restored the same way when switching branches. The fields
+// from name on are the state of the current named session, the others are
+// kept in tabs, see tabs.go.
+type session struct {
+	listener net.Listener
+
+	// warn receives the warnings about reconnects.
+	warn io.Writer
+
+	// proxyAddr, if set, returns the address of a proxy to the branch, with
+	// which the session connects to other branches, see switchTo.
+	proxyAddr func(branch string) (string, error)
+
+	// favorites are the branches of the named sessions that can be opened
+	// by their name only.
+	favorites map[string]string
+
+	// safe is whether UPDATE and DELETE statements have to be confirmed,
+	// see confirmChange.
+	safe bool
+
+	mu       sync.Mutex
+	conns    int
+	pending  string
+	tabs     map[string]*tab
+	name     string
+	branch   string
+	upstream string
+	db       string
+	sqlMode  string
+	inTx     bool
+
+	// columns caches the columns of the tables of each database, for the
+	// completion of the mysql client
+	columns map[string]map[string][]string
+}
+
+// newSession returns a session listening on a random local port, forwarding
+// to the proxy at upstream.
+func newSession(upstream string, warn io.Writer) (*session, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	return &session{listener: l, upstream: upstream, warn: warn}, nil
+}
+
+// serve accepts the connections of the client until ctx is done.
+func (s *session) serve(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		s.listener.Close()
+	}()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns++
+		reconnect := s.conns > 1
+		s.mu.Unlock()
+
+		go s.handle(conn, reconnect)
+	}
+}
+
+func (s *session) handle(client net.Conn, reconnect bool) {
+	defer client.Close()
+
+	s.mu.Lock()
+	upstream := s.upstream
+	s.mu.Unlock()
+
+	server, err := net.Dial("tcp", upstream)
+	if err != nil {
+		return
+	}
+
+	c := newSessionConn(server)
+	c.attach(client)
+	go c.forward(true)
+	defer func() {
+		c.server.Close()
+		<-c.closed
+	}()
+
+	first := true
+	var auth []byte
+	for {
+		header, payload, err := readPacket(client)
+		if err != nil {
+			return
+		}
+
+		// the handshake response, with which the session authenticates to
+		// other branches
+		if first && auth == nil && header[3] == 1 {
+			auth = payload
+		}
+
+		// commands are the only packets of the client starting a new
+		// sequence, the first one follows the handshake
+		if header[3] == 0 && len(payload) > 0 {
+			if first && reconnect {
+				s.warnRestored(c, "the connection to the database was lost and re-established.")
+			}
+			first = false
+
+			if resp, next, ok := s.clientCommand(c, client, payload, auth); ok {
+				c = next
+				if _, err := client.Write(resp); err != nil {
+					return
+				}
+				select {
+				case <-c.closed:
+					return
+				default:
+				}
+				continue
+			}
+
+			if resp, ok := s.confirmChange(c, payload); ok {
+				if _, err := client.Write(resp); err != nil {
+					return
+				}
+				continue
+			}
+
+			s.track(payload)
+
+			if payload[0] == comFieldList {
+				if resp, ok := s.fieldList(c, payload[1:]); ok {
+					if _, err := client.Write(resp); err != nil {
+						return
+					}
+					continue
+				}
+			}
+		}
+
+		if _, err := c.server.Write(append(header, payload...)); err != nil {
+			return
+		}
+	}
+}
+
+// sessionConn is a connection of the client to the server, on which the
+// session can send its own commands in between the client's.
+type sessionConn struct {
+	server net.Conn
+
+	// the responses to the commands of the session are read by the
+	// goroutine forwarding the server's packets, and handed over instead
+	intercepting int32 // set atomically
+	handoff      chan []byte
+	abort        chan struct{}
+	abortOnce    sync.Once
+	closed       chan struct{}
+
+	// client receives the packets of the server. It's nil while the
+	// connection is detached, i.e. while it's replaced by one to another
+	// branch, the packets are dropped then.
+	mu     sync.Mutex
+	client net.Conn
+}
+
+func newSessionConn(server net.Conn) *sessionConn {
+	return &sessionConn{
+		server:  server,
+		handoff: make(chan []byte),
+		abort:   make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+}
+
+// attach forwards the packets of the server to the client from now on.
+func (c *sessionConn) attach(client net.Conn) {
+	c.mu.Lock()
+	c.client = client
+	c.mu.Unlock()
+}
+
+// detach stops forwarding the packets of the server, the client's connection
+// is kept open.
+func (c *sessionConn) detach() {
+	c.attach(nil)
+}
+
+// forward forwards the packets of the server to the attached client until
+// either connection is closed. greeting is whether the server greeting is
+// still to be read.
+func (c *sessionConn) forward(greeting bool) {
+	defer close(c.closed)
+	defer func() {
+		// the client reconnects once its connection is closed
+		c.mu.Lock()
+		if c.client != nil {
+			c.client.Close()
+		}
+		c.mu.Unlock()
+	}()
+
+	for {
+		header, payload, err := readPacket(c.server)
+		if err != nil {
+			return
+		}
+
+		if greeting {
+			clearCapabilities(payload, clientSSL|clientCompress, clientDeprecateEOF)
+			greeting = false
+		}
+
+		if atomic.LoadInt32(&c.intercepting) == 1 {
+			select {
+			case c.handoff <- payload:
+			case <-c.abort:
+				return
+			}
+			continue
+		}
+
+		c.mu.Lock()
+		client := c.client
+		c.mu.Unlock()
+		if client == nil {
+			continue
+		}
+
+		if _, err := client.Write(append(header, payload...)); err != nil {
+			return
+		}
+	}
+}
+
+// exchange sends the command to the server and calls read with a function
+// returning the packets of the response. The response has to be read to its
+// end.
+func (c *sessionConn) exchange(cmd []byte, read func(next func() ([]byte, error)) error) error {
+	atomic.StoreInt32(&c.intercepting, 1)
+	defer atomic.StoreInt32(&c.intercepting, 0)
+
+	if err := writePacket(c.server, 0, cmd); err != nil {
+		return err
+	}
+
+	err := read(func() ([]byte, error) {
+		select {
+		case p := <-c.handoff:
+			return p, nil
+		case <-c.closed:
+			return nil, io.ErrUnexpectedEOF
+		}
+	})
+	if err != nil {
+		// the rest of the response can't be told apart from the
+		// responses to the client's commands
+		c.abortOnce.Do(func() { close(c.abort) })
+		c.server.Close()
+	}
+	return err
+}
+
+// warnRestored restores the state of the session on a new connection and
+// warns about the event causing it.
+func (s *session) warnRestored(c *sessionConn, event string) {
+	fmt.Fprintf(s.warn, "\n%s %s%s\n", printer.BoldRed("Warning:"), event, s.restore(c))
+}
+
+// restore replays the state of the session on a new connection and returns
+// a description of it for the user, starting with a space.
+func (s *session) restore(c *sessionConn) string {
+	s.mu.Lock()
+	db, sqlMode, inTx := s.db, s.sqlMode, s.inTx
+	s.inTx = false
+	s.mu.Unlock()
+
+	var stmts, failed []string
+	if db != "" {
+		stmts = append(stmts, "USE "+cmdutil.QuoteIdent(db))
+	}
+	if sqlMode != "" {
+		stmts = append(stmts, "SET SESSION sql_mode = "+sqlMode)
+	}
+
+	for _, stmt := range stmts {
+		err := c.exchange(append([]byte{comQuery}, stmt...), func(next func() ([]byte, error)) error {
+			resp, err := next()
+			if err != nil {
+				return err
+			}
+			if len(resp) == 0 || resp[0] != 0x00 {
+				failed = append(failed, stmt)
+			}
+			return nil
+		})
+		if err != nil {
+			return ""
+		}
+	}
+
+	var msg string
+	if len(stmts) > len(failed) {
+		msg += fmt.Sprintf(" The session was restored (%s).", strings.Join(stmts, "; "))
+	}
+	if len(failed) > 0 {
+		msg += fmt.Sprintf(" Couldn't restore: %s.", strings.Join(failed, "; "))
+	}
+	if inTx {
+		msg += " The open transaction was rolled back, its changes are lost."
+	}
+	return msg
+}
+
+// track updates the state of the session with the command of the client.
+func (s *session) track(payload []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch payload[0] {
+	case comInitDB:
+		s.db = string(payload[1:])
+		return
+	case comQuery:
+	default:
+		return
+	}
+
+	stmt := strings.TrimRight(strings.TrimSpace(string(payload[1:])), "; \t\r\n")
+	upper := strings.ToUpper(stmt)
+
+	switch {
+	case strings.HasPrefix(upper, "USE "):
+		s.db = strings.Trim(strings.TrimSpace(stmt[4:]), "`")
+	case upper == "BEGIN" || strings.HasPrefix(upper, "BEGIN ") || strings.HasPrefix(upper, "START TRANSACTION"):
+		s.inTx = true
+	case upper == "COMMIT" || strings.HasPrefix(upper, "COMMIT ") ||
+		(strings.HasPrefix(upper, "ROLLBACK") && !strings.Contains(upper, " TO ")):
+		s.inTx = false
+	default:
+		s.invalidateColumns(upper)
+		if m := sqlModeRe.FindStringSubmatch(stmt); m != nil {
+			s.sqlMode = strings.TrimSpace(m[1])
+		}
+	}
+}
+
+// clearCapabilities removes the lower and upper capability flags from the
+// server greeting.
+func clearCapabilities(greeting []byte, lower, upper uint16) {
+	// protocol version, NUL terminated server version, connection ID and
+	// the first part of the auth data, followed by a filler
+	if len(greeting) == 0 || greeting[0] != 10 {
+		return
+	}
+	end := 1
+	for end < len(greeting) && greeting[end] != 0 {
+		end++
+	}
+
+	pos := end + 1 + 4 + 8 + 1
+	if pos+2 > len(greeting) {
+		return
+	}
+	caps := binary.LittleEndian.Uint16(greeting[pos:])
+	binary.LittleEndian.PutUint16(greeting[pos:], caps&^lower)
+
+	// followed by the character set and the status flags
+	pos += 2 + 1 + 2
+	if pos+2 > len(greeting) {
+		return
+	}
+	caps = binary.LittleEndian.Uint16(greeting[pos:])
+	binary.LittleEndian.PutUint16(greeting[pos:], caps&^upper)
+}
+
+func readPacket(r io.Reader) (header, payload []byte, err error) {
+	header = make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, nil, err
+	}
+
+	payload = make([]byte, int(header[0])|int(header[1])<<8|int(header[2])<<16)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, nil, err
+	}
+	return header, payload, nil
+}
+
+func writePacket(w io.Writer, seq byte, payload []byte) error {
+	if len(payload) >= 1<<24-1 {
+		return errors.New("packet too large")
+	}
+
+	header := []byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), seq}
+	_, err := w.Write(append(header, payload...))
+	return err
+}
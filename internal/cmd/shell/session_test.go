@@ -0,0 +1,202 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestSession_Restore(t *testing.T) {
+	c := qt.New(t)
+
+	srv := &fakeServer{}
+	upstream, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, qt.IsNil)
+	defer upstream.Close()
+	go srv.serve(upstream)
+
+	var warn syncBuffer
+	sess, err := newSession(upstream.Addr().String(), &warn)
+	c.Assert(err, qt.IsNil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sess.serve(ctx)
+
+	conn := dialSession(c, sess)
+	command(c, conn, comInitDB, "mydb")
+	command(c, conn, comQuery, "SET sql_mode = 'ANSI_QUOTES'")
+	command(c, conn, comQuery, "BEGIN")
+	command(c, conn, comQuery, "INSERT INTO t VALUES (1)")
+	conn.Close()
+
+	conn = dialSession(c, sess)
+	defer conn.Close()
+	command(c, conn, comQuery, "SELECT 1")
+
+	c.Assert(srv.received(), qt.DeepEquals, []string{
+		"mydb",
+		"SET sql_mode = 'ANSI_QUOTES'",
+		"BEGIN",
+		"INSERT INTO t VALUES (1)",
+		"USE `mydb`",
+		"SET SESSION sql_mode = 'ANSI_QUOTES'",
+		"SELECT 1",
+	})
+	c.Assert(warn.String(), qt.Matches, `(?s).*lost and re-established. The session was restored \(USE `+"`mydb`"+`; SET SESSION sql_mode = 'ANSI_QUOTES'\). The open transaction was rolled back.*`)
+}
+
+func TestSession_Track(t *testing.T) {
+	tests := []struct {
+		stmt    string
+		db      string
+		sqlMode string
+		inTx    bool
+	}{
+		{stmt: "USE `other`;", db: "other"},
+		{stmt: "set @@session.sql_mode := 'TRADITIONAL'", sqlMode: "'TRADITIONAL'"},
+		{stmt: "SET SESSION sql_mode = ''", sqlMode: "''"},
+		{stmt: "start transaction read only", inTx: true},
+		{stmt: "ROLLBACK TO SAVEPOINT a"},
+		{stmt: "SELECT * FROM t"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.stmt, func(t *testing.T) {
+			c := qt.New(t)
+
+			s := &session{}
+			s.track(append([]byte{comQuery}, tt.stmt...))
+			c.Assert(s.db, qt.Equals, tt.db)
+			c.Assert(s.sqlMode, qt.Equals, tt.sqlMode)
+			c.Assert(s.inTx, qt.Equals, tt.inTx)
+		})
+	}
+}
+
+func TestClearCapabilities(t *testing.T) {
+	c := qt.New(t)
+
+	g := greeting(clientSSL | clientCompress | 0x0200)
+	clearCapabilities(g, clientSSL|clientCompress, 0)
+	c.Assert(binary.LittleEndian.Uint16(g[len(g)-2:]), qt.Equals, uint16(0x0200))
+}
+
+func dialSession(c *qt.C, s *session) net.Conn {
+	conn, err := net.Dial("tcp", s.listener.Addr().String())
+	c.Assert(err, qt.IsNil)
+	c.Assert(conn.SetDeadline(time.Now().Add(5*time.Second)), qt.IsNil)
+
+	_, g, err := readPacket(conn)
+	c.Assert(err, qt.IsNil)
+	c.Assert(binary.LittleEndian.Uint16(g[len(g)-2:])&clientSSL, qt.Equals, uint16(0))
+
+	c.Assert(writePacket(conn, 1, make([]byte, 32)), qt.IsNil)
+	_, ok, err := readPacket(conn)
+	c.Assert(err, qt.IsNil)
+	c.Assert(ok[0], qt.Equals, byte(0x00))
+	return conn
+}
+
+func command(c *qt.C, conn net.Conn, cmd byte, arg string) {
+	c.Assert(writePacket(conn, 0, append([]byte{cmd}, arg...)), qt.IsNil)
+	_, ok, err := readPacket(conn)
+	c.Assert(err, qt.IsNil)
+	c.Assert(ok[0], qt.Equals, byte(0x00))
+}
+
+// greeting returns a server greeting with the capabilities, up to which
+// it's complete.
+func greeting(caps uint16) []byte {
+	g := append([]byte{10}, "8.0.23\x00"...)
+	g = append(g, make([]byte, 4+8+1)...)
+	return append(g, byte(caps), byte(caps>>8))
+}
+
+// fakeServer answers the handshake and all commands of its clients with OK
+// packets, and records the commands.
+type fakeServer struct {
+	mu       sync.Mutex
+	commands []string
+
+	// respond, if set, returns the packets answering a command instead of
+	// an OK packet
+	respond func(payload []byte) [][]byte
+}
+
+func (f *fakeServer) serve(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	ok := []byte{0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00}
+	if err := writePacket(conn, 0, greeting(clientSSL)); err != nil {
+		return
+	}
+	if _, _, err := readPacket(conn); err != nil {
+		return
+	}
+	if err := writePacket(conn, 2, ok); err != nil {
+		return
+	}
+
+	for {
+		_, payload, err := readPacket(conn)
+		if err != nil {
+			return
+		}
+
+		f.mu.Lock()
+		f.commands = append(f.commands, string(payload[1:]))
+		f.mu.Unlock()
+
+		resp := [][]byte{ok}
+		if f.respond != nil {
+			if r := f.respond(payload); r != nil {
+				resp = r
+			}
+		}
+		for i, p := range resp {
+			if err := writePacket(conn, byte(i+1), p); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (f *fakeServer) received() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.commands...)
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -4,15 +4,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
 	"time"
 
 	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
 	"github.com/planetscale/cli/internal/printer"
 	"github.com/planetscale/cli/internal/promptutil"
 	"github.com/planetscale/cli/internal/proxyutil"
+	"github.com/planetscale/cli/internal/sqlfmt"
 
 	"github.com/planetscale/sql-proxy/proxy"
 
@@ -28,13 +33,20 @@ func ShellCmd(ch *cmdutil.Helper) *cobra.Command {
 		localAddr  string
 		remoteAddr string
 		role       string
+		execute    string
+		formatSQL  bool
+		pager      string
+		sessions   map[string]string
+		out        string
+		safe       bool
 	}
 
 	cmd := &cobra.Command{
 		Use: "shell [database] [branch]",
 		// we only require database, because we deduct branch automatically
-		Args:  cmdutil.RequiredArgs("database"),
-		Short: "Open a MySQL shell instance to a database and branch",
+		Args:              cmdutil.RequiredArgs("database"),
+		ValidArgsFunction: cmdutil.BranchCompletion(ch),
+		Short:             "Open a MySQL shell instance to a database and branch",
 		Example: `The shell subcommand opens a secure MySQL shell instance to your database.
 
 It uses the MySQL command-line client ("mysql"), which needs to be installed.
@@ -47,7 +59,53 @@ If there are multiple branches for the given database, you'll be prompted to
 choose one. To open a shell instance to a specific branch, pass the branch as a
 second argument:
 
-  pscale shell mydatabase mybranch`,
+  pscale shell mydatabase mybranch
+
+If the connection to the database drops, the shell reconnects and restores the
+current database and sql_mode. Open transactions are rolled back, the shell
+warns about them.
+
+Tab completes SQL keywords and the names of tables and columns. Run "rehash"
+after changing the schema in another session.
+
+The history is kept per database, statements can span multiple lines and
+"\e" edits the current statement in $EDITOR. Results are paged with --pager.
+The mysql client handles "\u" (use) itself, to switch to another branch
+without restarting the shell, run:
+
+  SWITCH BRANCH otherbranch;
+
+Named sessions keep connections to several branches open at once, i.e. to
+compare the data of production and a development branch. SHOW SESSIONS lists
+them, environments of the project using the database and the sessions passed
+with --session are opened by their name only:
+
+  SWITCH SESSION dev BRANCH otherbranch;
+  SWITCH SESSION mybranch;
+
+DIFF runs a query on the current session and on another named session, or
+branch, and prints the rows only one of them returns:
+
+  DIFF dev SELECT id, status FROM orders WHERE id < 100;
+
+In safe mode, UPDATE and DELETE statements are rejected with an estimate of
+the rows they change the first time they're run, running them again confirms
+them. Safe mode is enabled with --safe, and by default for production branches.
+
+EXPORT writes the result of a query to a CSV, JSON or Parquet file:
+
+  EXPORT CSV orders.csv SELECT * FROM orders;
+
+To run statements without an interactive shell, pass them, or the path of a
+file with them, with --execute. --format-sql prints the statements
+pretty-printed before running them:
+
+  pscale shell mydatabase mybranch --execute migrate.sql --format-sql
+
+--out writes the result of the statements to a CSV, JSON or Parquet file, by
+its extension, instead of printing it:
+
+  pscale shell mydatabase mybranch --execute "SELECT * FROM orders" --out orders.json`,
 		PersistentPreRunE: cmdutil.CheckAuthentication(ch.Config),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx, cancel := context.WithCancel(cmd.Context())
@@ -55,7 +113,28 @@ second argument:
 
 			database := args[0]
 
-			if !printer.IsTTY || ch.Printer.Format() != printer.Human {
+			if flags.formatSQL && flags.execute == "" {
+				return errors.New("--format-sql requires --execute")
+			}
+
+			if flags.out != "" {
+				if flags.execute == "" {
+					return errors.New("--out requires --execute")
+				}
+				if _, err := exportFormatOf(flags.out); err != nil {
+					return err
+				}
+			}
+
+			var stmts string
+			if flags.execute != "" {
+				var err error
+				if stmts, err = readStatements(flags.execute); err != nil {
+					return err
+				}
+			}
+
+			if stmts == "" && (!printer.IsTTY || ch.Printer.Format() != printer.Human) {
 				if _, exists := os.LookupEnv("PSCALE_ALLOW_NONINTERACTIVE_SHELL"); !exists {
 					return errors.New("pscale shell only works in interactive mode")
 				}
@@ -91,77 +170,66 @@ second argument:
 				}
 			}
 
-			// check whether database and branch exist
-			_, err = client.DatabaseBranches.Get(ctx, &ps.GetDatabaseBranchRequest{
-				Organization: ch.Config.Organization,
-				Database:     database,
-				Branch:       branch,
-			})
-			if err != nil {
-				switch cmdutil.ErrCode(err) {
-				case ps.ErrNotFound:
-					return fmt.Errorf("database %s and branch %s does not exist in organization %s",
-						printer.BoldBlue(database), printer.BoldBlue(branch), printer.BoldBlue(ch.Config.Organization))
-				default:
-					return cmdutil.HandleError(err)
-				}
-			}
-
 			const localProxyAddr = "127.0.0.1"
 			localAddr := localProxyAddr + ":0"
 			if flags.localAddr != "" {
 				localAddr = flags.localAddr
 			}
 
-			proxyOpts := proxy.Options{
-				CertSource: proxyutil.NewRemoteCertSource(client, role),
-				LocalAddr:  localAddr,
-				RemoteAddr: flags.remoteAddr,
-				Instance:   fmt.Sprintf("%s/%s/%s", ch.Config.Organization, database, branch),
-				Logger:     cmdutil.NewZapLogger(ch.Debug()),
+			bp := &branchProxy{
+				ch:         ch,
+				client:     client,
+				database:   database,
+				role:       role,
+				remoteAddr: flags.remoteAddr,
 			}
 
-			proxyAddr := make(chan string, 1)
-			proxyError := make(chan error, 1)
-
-			go func() {
-				proxyError <- runProxy(ctx, ch, proxyOpts, proxyAddr)
-			}()
-
-			dbBranch, err := client.DatabaseBranches.Get(ctx, &ps.GetDatabaseBranchRequest{
-				Organization: ch.Config.Organization,
-				Database:     database,
-				Branch:       branch,
-			})
+			dbBranch, addr, err := bp.start(ctx, branch, localAddr)
 			if err != nil {
-				switch cmdutil.ErrCode(err) {
-				case ps.ErrNotFound:
-					return fmt.Errorf("branch %s does not exist in database %s (organization: %s)",
-						printer.BoldBlue(branch), printer.BoldBlue(database), printer.BoldBlue(ch.Config.Organization))
-				default:
-					return cmdutil.HandleError(err)
-				}
+				return err
 			}
+			defer bp.stop()
 
-			if !dbBranch.Ready {
-				return errors.New("database branch is not ready yet")
+			if flags.out != "" {
+				if flags.formatSQL {
+					ch.Printer.Println(sqlfmt.Format(stmts))
+					ch.Printer.Println()
+				}
+
+				n, err := executeToFile(ctx, addr, database, stmts, flags.out)
+				if err != nil {
+					return err
+				}
+				ch.Printer.Printf("Exported %s to %s.\n", rowCount(n), printer.BoldBlue(flags.out))
+				return nil
 			}
 
-			var addr string
-			select {
-			case err := <-proxyError:
+			// the mysql client reconnects through the session, which
+			// restores the state of the session on the new connection
+			sess, err := newSession(addr, os.Stderr)
+			if err != nil {
 				return err
-			case addr = <-proxyAddr:
-			case <-time.After(time.Second * 10):
-				return errors.New("proxy timeout retrieving the certs")
 			}
+			// proxies to other branches listen on random ports, the
+			// session is the client's only address
+			sess.proxyAddr = func(branch string) (string, error) {
+				_, addr, err := bp.start(ctx, branch, localProxyAddr+":0")
+				return addr, err
+			}
+			sess.name, sess.branch = branch, branch
+			sess.favorites = favoriteSessions(ch, database, flags.sessions)
+			// statements of --execute can't be confirmed
+			sess.safe = stmts == "" && (flags.safe || (dbBranch.Production && !cmd.Flags().Changed("safe")))
+			go sess.serve(ctx)
 
-			host, port, err := net.SplitHostPort(addr)
+			host, port, err := net.SplitHostPort(sess.listener.Addr().String())
 			if err != nil {
 				return err
 			}
 
 			mysqlArgs := []string{
+				"--reconnect",
+				"--auto-rehash", // complete the names of tables and columns
 				"-u",
 				"root",
 				"-c", // allow comments to pass to the server
@@ -171,6 +239,18 @@ second argument:
 				"-P", port,
 			}
 
+			if pager := resultPager(flags.pager, stmts != ""); pager != "" {
+				mysqlArgs = append(mysqlArgs, "--pager="+pager)
+			}
+
+			if stmts != "" {
+				if flags.formatSQL {
+					ch.Printer.Println(sqlfmt.Format(stmts))
+					ch.Printer.Println()
+				}
+				mysqlArgs = append(mysqlArgs, "-e", stmts)
+			}
+
 			historyFile, err := historyFilePath(ch.Config.Organization, database, branch)
 			if err != nil {
 				return err
@@ -199,11 +279,152 @@ second argument:
 		"PlanetScale Database remote network address. By default the remote address is populated automatically from the PlanetScale API.")
 	cmd.PersistentFlags().StringVar(&flags.role, "role",
 		"reader", "Role defines the access level, allowed values are : reader, writer, readwriter, admin. By default it is reader.")
+	cmd.Flags().StringVarP(&flags.execute, "execute", "e", "",
+		"Execute the statements, or the statements of the file at the path, and exit")
+	cmd.Flags().BoolVar(&flags.formatSQL, "format-sql", false,
+		"Print the statements of --execute pretty-printed before executing them")
+	cmd.Flags().StringVar(&flags.pager, "pager", "",
+		`Pager for the results of queries, "off" disables it. By default $PAGER, or less if it's installed`)
+	cmd.Flags().StringVar(&flags.out, "out", "",
+		"Write the result of --execute to the CSV, JSON or Parquet file at the path, by its extension")
+	cmd.Flags().BoolVar(&flags.safe, "safe", false,
+		"Require confirming UPDATE and DELETE statements, showing the number of rows they change. Enabled by default for production branches")
+	cmd.Flags().StringToStringVar(&flags.sessions, "session", nil,
+		"Named sessions that SWITCH SESSION opens by their name only, i.e. --session prod=main")
 	cmd.MarkPersistentFlagRequired("org") // nolint:errcheck
 	cmd.PersistentFlags().MarkHidden("role")
 	return cmd
 }
 
+// favoriteSessions returns the branches of the named sessions of the shell:
+// the environments of the project configuration using the database, and the
+// sessions passed with --session, which take precedence.
+func favoriteSessions(ch *cmdutil.Helper, database string, sessions map[string]string) map[string]string {
+	favorites := make(map[string]string)
+	if cfg, err := ch.ConfigFS.ProjectConfig(); err == nil {
+		for name, env := range cfg.Environments {
+			if env == nil || env.Branch == "" {
+				continue
+			}
+
+			org, db := env.Organization, env.Database
+			if org == "" {
+				org = cfg.Organization
+			}
+			if db == "" {
+				db = cfg.Database
+			}
+			if (org == "" || org == ch.Config.Organization) && db == database {
+				favorites[name] = env.Branch
+			}
+		}
+	}
+
+	for name, branch := range sessions {
+		favorites[name] = branch
+	}
+	return favorites
+}
+
+// branchProxy starts the proxies to the branches of a database.
+type branchProxy struct {
+	ch         *cmdutil.Helper
+	client     *ps.Client
+	database   string
+	role       cmdutil.PasswordRole
+	remoteAddr string
+
+	mu      sync.Mutex
+	proxies map[string]runningProxy
+}
+
+// runningProxy is a proxy to a branch.
+type runningProxy struct {
+	addr   string
+	cancel context.CancelFunc
+}
+
+// start runs a proxy to the branch listening on localAddr, unless one is
+// running already. It returns the branch and the address of the proxy.
+func (b *branchProxy) start(ctx context.Context, branch, localAddr string) (*ps.DatabaseBranch, string, error) {
+	org := b.ch.Config.Organization
+
+	dbBranch, err := b.client.DatabaseBranches.Get(ctx, &ps.GetDatabaseBranchRequest{
+		Organization: org,
+		Database:     b.database,
+		Branch:       branch,
+	})
+	if err != nil {
+		switch cmdutil.ErrCode(err) {
+		case ps.ErrNotFound:
+			return nil, "", fmt.Errorf("database %s and branch %s does not exist in organization %s",
+				printer.BoldBlue(b.database), printer.BoldBlue(branch), printer.BoldBlue(org))
+		default:
+			return nil, "", cmdutil.HandleError(err)
+		}
+	}
+
+	if !dbBranch.Ready {
+		return nil, "", errors.New("database branch is not ready yet")
+	}
+
+	b.mu.Lock()
+	running, ok := b.proxies[branch]
+	b.mu.Unlock()
+	if ok {
+		return dbBranch, running.addr, nil
+	}
+
+	remoteAddr, err := proxyutil.RemoteAddr(ctx, b.ch.Config, b.client, b.database, branch, b.remoteAddr)
+	if err != nil {
+		return nil, "", cmdutil.HandleError(err)
+	}
+
+	proxyOpts := proxy.Options{
+		CertSource: proxyutil.NewRemoteCertSource(b.client, b.role),
+		LocalAddr:  localAddr,
+		RemoteAddr: remoteAddr,
+		Instance:   fmt.Sprintf("%s/%s/%s", org, b.database, branch),
+		Logger:     cmdutil.NewZapLogger(b.ch.Debug()),
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	proxyAddr := make(chan string, 1)
+	proxyError := make(chan error, 1)
+
+	go func() {
+		proxyError <- runProxy(ctx, b.ch, proxyOpts, proxyAddr)
+	}()
+
+	select {
+	case err := <-proxyError:
+		cancel()
+		return nil, "", err
+	case addr := <-proxyAddr:
+		b.mu.Lock()
+		if b.proxies == nil {
+			b.proxies = make(map[string]runningProxy)
+		}
+		b.proxies[branch] = runningProxy{addr: addr, cancel: cancel}
+		b.mu.Unlock()
+		return dbBranch, addr, nil
+	case <-time.After(time.Second * 10):
+		cancel()
+		return nil, "", errors.New("proxy timeout retrieving the certs")
+	}
+}
+
+// stop stops the running proxies.
+func (b *branchProxy) stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for branch, p := range b.proxies {
+		p.cancel()
+		delete(b.proxies, branch)
+	}
+}
+
 // runProxy runs the sql-proxy with the given options.
 func runProxy(ctx context.Context, ch *cmdutil.Helper, proxyOpts proxy.Options, ready chan string) error {
 	p, err := proxy.NewClient(proxyOpts)
@@ -265,24 +486,53 @@ func formatMySQLBranch(database string, branch *ps.DatabaseBranch) string {
 	return fmt.Sprintf("%s/%s> ", database, branchStr)
 }
 
+// historyFilePath returns the history file of the database, which is shared
+// by its branches. A new history starts with the one the branch had in the
+// legacy ~/.pscale/history directory.
 func historyFilePath(org, db, branch string) (string, error) {
-	dir, err := homedir.Dir()
+	dir, err := config.ConfigDir()
 	if err != nil {
 		return "", err
 	}
 
-	historyDir := filepath.Join(dir, ".pscale", "history")
+	historyDir := filepath.Join(dir, "history")
+	if err := os.MkdirAll(historyDir, 0771); err != nil {
+		return "", err
+	}
 
-	_, err = os.Stat(historyDir)
-	if os.IsNotExist(err) {
-		err := os.MkdirAll(historyDir, 0771)
-		if err != nil {
-			return "", err
+	historyFile := filepath.Join(historyDir, fmt.Sprintf("%s.%s", org, db))
+	if _, err := os.Stat(historyFile); os.IsNotExist(err) {
+		if home, err := homedir.Dir(); err == nil {
+			legacy := filepath.Join(home, ".pscale", "history", fmt.Sprintf("%s.%s.%s", org, db, branch))
+			if out, err := ioutil.ReadFile(legacy); err == nil {
+				if err := ioutil.WriteFile(historyFile, out, 0600); err != nil {
+					return "", err
+				}
+			}
 		}
 	}
 
-	historyFilename := fmt.Sprintf("%s.%s.%s", org, db, branch)
-	historyFile := filepath.Join(historyDir, historyFilename)
-
 	return historyFile, nil
 }
+
+// resultPager returns the pager the mysql client pipes the results of
+// queries through, or "" for none. Statements run with --execute aren't
+// paged, and the client doesn't support pagers on Windows.
+func resultPager(pager string, execute bool) string {
+	if pager == "off" || execute || runtime.GOOS == "windows" || !printer.IsTTY {
+		return ""
+	}
+	if pager != "" {
+		return pager
+	}
+	if p := os.Getenv("PAGER"); p != "" {
+		return p
+	}
+
+	// less only pages results longer than the screen, and doesn't wrap
+	// the lines of wide tables
+	if _, err := exec.LookPath("less"); err == nil {
+		return "less -SFX"
+	}
+	return ""
+}
@@ -0,0 +1,199 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Named sessions let the user switch between branches within one shell, i.e.
+// to compare the data of production and a development branch. Each one has
+// its own connection and state, the connections of the sessions that aren't
+// current are kept open, detached from the client, so their transactions
+// survive switching back and forth:
+//
+//   SWITCH SESSION dev BRANCH mybranch;
+//   SWITCH SESSION main;
+//   SHOW SESSIONS;
+//
+// Favorites are named sessions known in advance, which are opened by their
+// name only.
+
+var (
+	switchSessionRe = regexp.MustCompile("(?is)^\\s*SWITCH\\s+SESSION\\s+`?([\\w.-]+)`?(?:\\s+BRANCH\\s+`?([\\w.-]+)`?)?\\s*;?\\s*$")
+	showSessionsRe  = regexp.MustCompile(`(?is)^\s*SHOW\s+SESSIONS\s*;?\s*$`)
+)
+
+// tab is a named session that isn't the current one.
+type tab struct {
+	branch   string
+	upstream string
+
+	// conn is the detached connection of the session, nil if it wasn't
+	// opened yet
+	conn *sessionConn
+
+	db      string
+	sqlMode string
+	inTx    bool
+	columns map[string]map[string][]string
+}
+
+// parseSwitchSession returns the name and, if given, the branch of a SWITCH
+// SESSION command.
+func parseSwitchSession(payload []byte) (name, branch string, ok bool) {
+	if payload[0] != comQuery {
+		return "", "", false
+	}
+
+	m := switchSessionRe.FindSubmatch(payload[1:])
+	if m == nil {
+		return "", "", false
+	}
+	return string(m[1]), string(m[2]), true
+}
+
+// parseShowSessions returns whether the command is SHOW SESSIONS.
+func parseShowSessions(payload []byte) bool {
+	return payload[0] == comQuery && showSessionsRe.Match(payload[1:])
+}
+
+// switchSession makes the named session the current one, opening it to the
+// branch, or the branch of the favorite with the name, if it's new. The
+// connection c is detached and kept for switching back. It returns the
+// response to the client and its connection from now on, which is c if the
+// switch failed.
+func (s *session) switchSession(c *sessionConn, client net.Conn, name, branch string, auth []byte) ([]byte, *sessionConn) {
+	fail := func(err error) ([]byte, *sessionConn) {
+		return errPacket(1, fmt.Sprintf("can't switch to session %s: %s", name, err)), c
+	}
+
+	s.mu.Lock()
+	current, currentBranch := s.name, s.branch
+	t, ok := s.tabs[name]
+	s.mu.Unlock()
+
+	if name == current {
+		if branch != "" && branch != currentBranch {
+			return s.switchTo(c, client, branch, auth)
+		}
+		return okPacket(1), c
+	}
+
+	switch {
+	case ok && branch != "" && branch != t.branch:
+		return fail(fmt.Errorf("it's connected to branch %s, switch branches within it instead", t.branch))
+	case !ok && branch == "":
+		if branch = s.favorites[name]; branch == "" {
+			return fail(fmt.Errorf("it doesn't exist, open it with SWITCH SESSION %s BRANCH <branch>", name))
+		}
+		fallthrough
+	case !ok:
+		t = &tab{branch: branch}
+	}
+
+	if auth == nil {
+		return fail(errors.New("the handshake wasn't seen"))
+	}
+
+	next, lost := t.conn, false
+	if next != nil {
+		select {
+		case <-next.closed:
+			next, lost = nil, true
+		default:
+		}
+	}
+
+	fresh := next == nil
+	if fresh {
+		if t.upstream == "" {
+			addr, err := s.proxyAddr(t.branch)
+			if err != nil {
+				return fail(err)
+			}
+			t.upstream = addr
+		}
+
+		var err error
+		if next, err = dialUpstream(t.upstream, auth); err != nil {
+			return fail(err)
+		}
+	}
+
+	c.detach()
+
+	s.mu.Lock()
+	if s.tabs == nil {
+		s.tabs = make(map[string]*tab)
+	}
+	s.tabs[s.name] = &tab{
+		branch:   s.branch,
+		upstream: s.upstream,
+		conn:     c,
+		db:       s.db,
+		sqlMode:  s.sqlMode,
+		inTx:     s.inTx,
+		columns:  s.columns,
+	}
+	delete(s.tabs, name)
+	s.name, s.branch, s.upstream = name, t.branch, t.upstream
+	s.db, s.sqlMode, s.inTx, s.columns = t.db, t.sqlMode, t.inTx, t.columns
+	s.mu.Unlock()
+
+	next.attach(client)
+
+	msg := fmt.Sprintf("Switched to session %s (branch %s).", name, t.branch)
+	if fresh {
+		go next.forward(false)
+		if lost {
+			msg += " Its connection to the database was lost and re-established."
+		}
+		msg += s.restore(next)
+	}
+	fmt.Fprintf(s.warn, "%s\n", msg)
+	return okPacket(1), next
+}
+
+// showSessions prints the named sessions and their branches, the current one
+// marked with an asterisk, followed by the favorites that aren't open.
+func (s *session) showSessions() {
+	s.mu.Lock()
+	branches := map[string]string{s.name: s.branch}
+	for name, t := range s.tabs {
+		branches[name] = t.branch
+	}
+	current := s.name
+	s.mu.Unlock()
+
+	names := make([]string, 0, len(branches)+len(s.favorites))
+	for name := range branches {
+		names = append(names, name)
+	}
+	open := len(names)
+	for name, branch := range s.favorites {
+		if _, ok := branches[name]; !ok {
+			branches[name] = branch
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[:open])
+	sort.Strings(names[open:])
+
+	var b strings.Builder
+	for i, name := range names {
+		mark := " "
+		if name == current {
+			mark = "*"
+		}
+		fmt.Fprintf(&b, "%s %s\t%s", mark, name, branches[name])
+		if i >= open {
+			b.WriteString("\t(favorite, not open)")
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprint(s.warn, b.String())
+}
@@ -0,0 +1,79 @@
+package shell
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestSession_SwitchSession(t *testing.T) {
+	c := qt.New(t)
+
+	servers := map[string]*fakeServer{"main": {}, "dev": {}}
+	addrs := make(map[string]string)
+	for branch, srv := range servers {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		c.Assert(err, qt.IsNil)
+		defer l.Close()
+		go srv.serve(l)
+		addrs[branch] = l.Addr().String()
+	}
+
+	var warn syncBuffer
+	sess, err := newSession(addrs["main"], &warn)
+	c.Assert(err, qt.IsNil)
+	sess.name, sess.branch = "main", "main"
+	sess.favorites = map[string]string{"staging": "dev"}
+	sess.proxyAddr = func(branch string) (string, error) {
+		return addrs[branch], nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sess.serve(ctx)
+
+	conn := dialSession(c, sess)
+	defer conn.Close()
+	command(c, conn, comInitDB, "mydb")
+	command(c, conn, comQuery, "BEGIN")
+
+	command(c, conn, comQuery, "SWITCH SESSION staging")
+	command(c, conn, comQuery, "SELECT 1")
+	command(c, conn, comQuery, "switch session `main`;")
+	command(c, conn, comQuery, "COMMIT")
+	command(c, conn, comQuery, "SHOW SESSIONS")
+
+	c.Assert(servers["main"].received(), qt.DeepEquals, []string{"mydb", "BEGIN", "COMMIT"})
+	c.Assert(servers["dev"].received(), qt.DeepEquals, []string{"SELECT 1"})
+	c.Assert(warn.String(), qt.Equals, "Switched to session staging (branch dev).\n"+
+		"Switched to session main (branch main).\n"+
+		"* main\tmain\n"+
+		"  staging\tdev\n")
+}
+
+func TestParseSwitchSession(t *testing.T) {
+	tests := []struct {
+		stmt   string
+		name   string
+		branch string
+		ok     bool
+	}{
+		{stmt: "SWITCH SESSION prod", name: "prod", ok: true},
+		{stmt: "switch session `dev` branch feature-1;\n", name: "dev", branch: "feature-1", ok: true},
+		{stmt: "SWITCH SESSION dev BRANCH"},
+		{stmt: "SWITCH BRANCH dev"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.stmt, func(t *testing.T) {
+			c := qt.New(t)
+
+			name, branch, ok := parseSwitchSession(append([]byte{comQuery}, tt.stmt...))
+			c.Assert(name, qt.Equals, tt.name)
+			c.Assert(branch, qt.Equals, tt.branch)
+			c.Assert(ok, qt.Equals, tt.ok)
+		})
+	}
+}
@@ -13,7 +13,6 @@ import (
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/planetscale/cli/internal/cmdutil"
 	"github.com/planetscale/cli/internal/printer"
-	"github.com/planetscale/planetscale-go/planetscale"
 	"github.com/spf13/cobra"
 )
 
@@ -84,7 +83,7 @@ func SignupCmd(ch *cmdutil.Helper) *cobra.Command {
 				return err
 			}
 
-			path := fmt.Sprintf("%sinternal/register", planetscale.DefaultBaseURL)
+			path := fmt.Sprintf("%s/internal/register", strings.TrimSuffix(ch.Config.BaseURL, "/"))
 			req, err := http.NewRequest("POST", path, &buf)
 			if err != nil {
 				return err
@@ -92,7 +91,7 @@ func SignupCmd(ch *cmdutil.Helper) *cobra.Command {
 			req.Header.Set("Content-Type", "application/json")
 			req.Header.Set("Accept", "application/json")
 
-			client := &http.Client{Timeout: time.Second * 15}
+			client := &http.Client{Transport: ch.Config.HTTPTransport(), Timeout: time.Second * 15}
 			resp, err := client.Do(req)
 			if err != nil {
 				return err
@@ -78,3 +78,49 @@ func TestServiceToken_ShowAccess(t *testing.T) {
 	}
 	c.Assert(buf.String(), qt.JSONEquals, res)
 }
+
+func TestServiceToken_ShowAccessSorted(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	orig := []*ps.ServiceTokenAccess{
+		{ID: "id-1", Access: "read_branch", Resource: ps.Database{Name: "zeta"}},
+		{ID: "id-2", Access: "read_branch", Resource: ps.Database{Name: "alpha"}},
+		{ID: "id-3", Access: "create_branch", Resource: ps.Database{Name: "zeta"}},
+		{ID: "id-4", Access: "read_branch", Resource: ps.Database{Name: "beta"}},
+	}
+
+	svc := &mock.ServiceTokenService{
+		GetAccessFn: func(ctx context.Context, req *ps.GetServiceTokenAccessRequest) ([]*ps.ServiceTokenAccess, error) {
+			return orig, nil
+		},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config: &config.Config{
+			Organization: "planetscale",
+		},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{
+				ServiceTokens: svc,
+			}, nil
+		},
+	}
+
+	cmd := ShowAccessCmd(ch)
+	cmd.SetArgs([]string{"123456"})
+	err := cmd.Execute()
+	c.Assert(err, qt.IsNil)
+
+	res := []*ServiceTokenAccess{
+		{Database: "alpha", Accesses: []string{"read_branch"}},
+		{Database: "beta", Accesses: []string{"read_branch"}},
+		{Database: "zeta", Accesses: []string{"read_branch", "create_branch"}},
+	}
+	c.Assert(buf.String(), qt.JSONEquals, res)
+}
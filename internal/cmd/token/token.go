@@ -2,6 +2,7 @@ package token
 
 import (
 	"encoding/json"
+	"sort"
 
 	"github.com/planetscale/cli/internal/cmdutil"
 	ps "github.com/planetscale/planetscale-go/planetscale"
@@ -11,8 +12,17 @@ import (
 // TokenCmd encapsulates the command for running snapshots.
 func TokenCmd(ch *cmdutil.Helper) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:               "service-token <action>",
-		Short:             "Create, list, and manage access for service tokens",
+		Use:   "service-token <action>",
+		Short: "Create, list, and manage access for service tokens",
+		Example: `Service tokens authenticate scripts and CI pipelines. With --format json the
+commands can provision a token end to end, i.e.:
+
+  id=$(pscale service-token create --org acme --format json | jq -r .id)
+  pscale service-token add-access "$id" read_branch create_branch --database mydb --org acme --format json
+
+The token is only shown when it's created. Use it with the --service-token-id
+and --service-token flags, or the PLANETSCALE_SERVICE_TOKEN_ID and
+PLANETSCALE_SERVICE_TOKEN environment variables.`,
 		PersistentPreRunE: cmdutil.CheckAuthentication(ch.Config),
 	}
 
@@ -82,5 +92,8 @@ func toServiceTokenAccesses(st []*ps.ServiceTokenAccess) []*ServiceTokenAccess {
 	for _, v := range data {
 		out = append(out, v)
 	}
+
+	// keep the output stable for scripts comparing it
+	sort.Slice(out, func(i, j int) bool { return out[i].Database < out[j].Database })
 	return out
 }
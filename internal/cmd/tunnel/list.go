@@ -0,0 +1,84 @@
+package tunnel
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/tunnel"
+
+	"github.com/spf13/cobra"
+)
+
+// ListCmd lists the tunnels running in the background.
+func ListCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List the tunnels running in the background",
+		Args:    cobra.NoArgs,
+		Aliases: []string{"ls"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tunnels, err := tunnel.List()
+			if err != nil {
+				return err
+			}
+
+			if len(tunnels) == 0 && ch.Printer.Format() == printer.Human {
+				ch.Printer.Println("No tunnels are running, start one with pscale connect --daemon.")
+				return nil
+			}
+
+			res := make([]*entry, 0, len(tunnels))
+			for _, t := range tunnels {
+				res = append(res, toEntry(t, time.Now()))
+			}
+
+			return ch.Printer.PrintResource(res)
+		},
+	}
+
+	return cmd
+}
+
+func toEntry(t *tunnel.Tunnel, now time.Time) *entry {
+	e := &entry{
+		ID:          t.ID,
+		Database:    t.Database,
+		Branch:      t.Branch,
+		Address:     strings.Join(t.Addrs, ", "),
+		Addrs:       t.Addrs,
+		Org:         t.Org,
+		PID:         t.PID,
+		StartedAt:   printer.GetMilliseconds(t.StartedAt),
+		Connections: "-",
+		Traffic:     "-",
+	}
+
+	// tunnels listening on several addresses don't count their traffic
+	if st := t.Stats; st != nil {
+		e.Connections = strconv.Itoa(st.ActiveConnections)
+		e.Traffic = fmt.Sprintf("%s in, %s out", printer.Bytes(st.BytesIn), printer.Bytes(st.BytesOut))
+		e.Stats = &stats{
+			BytesIn:           st.BytesIn,
+			BytesOut:          st.BytesOut,
+			ActiveConnections: st.ActiveConnections,
+			TotalConnections:  st.TotalConnections,
+			Connections:       make([]*connection, 0, len(st.Connections)),
+			UpdatedAt:         printer.GetMilliseconds(st.UpdatedAt),
+		}
+		for _, c := range st.Connections {
+			e.Stats.Connections = append(e.Stats.Connections, &connection{
+				ID:        c.ID,
+				StartedAt: printer.GetMilliseconds(c.StartedAt),
+				Duration:  now.Sub(c.StartedAt).Milliseconds(),
+				BytesIn:   c.BytesIn,
+				BytesOut:  c.BytesOut,
+			})
+		}
+	}
+
+	return e
+}
@@ -0,0 +1,112 @@
+package tunnel
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/testutil"
+	"github.com/planetscale/cli/internal/tunnel"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestTunnel_ListCmd(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	started := time.Now().UTC().Truncate(time.Second)
+	c.Assert(tunnel.Save(&tunnel.Tunnel{
+		ID:        "abc123",
+		PID:       os.Getpid(),
+		Org:       "planetscale",
+		Database:  "mydb",
+		Branch:    "main",
+		Addrs:     []string{"/tmp/main.sock", "127.0.0.1:3306"},
+		StartedAt: started,
+	}), qt.IsNil)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config:  &config.Config{},
+	}
+
+	cmd := ListCmd(ch)
+	cmd.SetArgs([]string{})
+	err := cmd.Execute()
+	c.Assert(err, qt.IsNil)
+
+	c.Assert(buf.String(), qt.JSONEquals, []*entry{{
+		ID:        "abc123",
+		Database:  "mydb",
+		Branch:    "main",
+		Addrs:     []string{"/tmp/main.sock", "127.0.0.1:3306"},
+		Org:       "planetscale",
+		PID:       os.Getpid(),
+		StartedAt: printer.GetMilliseconds(started),
+	}})
+}
+
+func TestTunnel_ListCmdStats(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	started := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	c.Assert(tunnel.Save(&tunnel.Tunnel{
+		ID:        "abc123",
+		PID:       os.Getpid(),
+		Org:       "planetscale",
+		Database:  "mydb",
+		Branch:    "main",
+		Addrs:     []string{"127.0.0.1:3306"},
+		StartedAt: started,
+		Stats: &tunnel.Stats{
+			BytesIn:           2048,
+			BytesOut:          512,
+			ActiveConnections: 1,
+			TotalConnections:  3,
+			Connections: []*tunnel.ConnStats{
+				{ID: 3, StartedAt: started, BytesIn: 1024, BytesOut: 256},
+			},
+			UpdatedAt: started,
+		},
+	}), qt.IsNil)
+
+	var buf bytes.Buffer
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config:  &config.Config{},
+	}
+
+	cmd := ListCmd(ch)
+	cmd.SetArgs([]string{})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	var res []*entry
+	c.Assert(json.Unmarshal(buf.Bytes(), &res), qt.IsNil)
+	c.Assert(res, qt.HasLen, 1)
+
+	st := res[0].Stats
+	c.Assert(st, qt.Not(qt.IsNil))
+	c.Assert(st.BytesIn, qt.Equals, int64(2048))
+	c.Assert(st.BytesOut, qt.Equals, int64(512))
+	c.Assert(st.ActiveConnections, qt.Equals, 1)
+	c.Assert(st.TotalConnections, qt.Equals, 3)
+	c.Assert(st.Connections, qt.HasLen, 1)
+	c.Assert(st.Connections[0].ID, qt.Equals, uint64(3))
+	c.Assert(st.Connections[0].Duration >= time.Minute.Milliseconds(), qt.IsTrue)
+}
@@ -0,0 +1,76 @@
+package tunnel
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/tunnel"
+
+	"github.com/spf13/cobra"
+)
+
+// stopTimeout is how long a tunnel has to shut down before it's killed.
+const stopTimeout = 5 * time.Second
+
+// StopCmd stops tunnels running in the background.
+func StopCmd(ch *cmdutil.Helper) *cobra.Command {
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "stop [id]",
+		Short: "Stop a tunnel running in the background",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if all == (len(args) == 1) {
+				return errors.New("give either the ID of a tunnel or --all")
+			}
+
+			var tunnels []*tunnel.Tunnel
+			if all {
+				var err error
+				tunnels, err = tunnel.List()
+				if err != nil {
+					return err
+				}
+			} else {
+				t, err := tunnel.Get(args[0])
+				if err != nil {
+					if errors.Is(err, tunnel.ErrNotFound) {
+						return fmt.Errorf("tunnel %s is not running", printer.BoldBlue(args[0]))
+					}
+					return err
+				}
+				tunnels = append(tunnels, t)
+			}
+
+			res := make([]*entry, 0, len(tunnels))
+			for _, t := range tunnels {
+				end := ch.Printer.PrintProgress(fmt.Sprintf("Stopping tunnel %s to %s/%s", printer.BoldBlue(t.ID), t.Database, t.Branch))
+				err := t.Stop(stopTimeout)
+				end()
+				if err != nil {
+					return err
+				}
+
+				ch.Printer.Printf("Tunnel %s to %s/%s was stopped.\n", printer.BoldBlue(t.ID), printer.BoldBlue(t.Database), printer.BoldBlue(t.Branch))
+				res = append(res, toEntry(t, time.Now()))
+			}
+
+			if ch.Printer.Format() == printer.Human {
+				if len(res) == 0 {
+					ch.Printer.Println("No tunnels are running.")
+				}
+				return nil
+			}
+
+			return ch.Printer.PrintResource(res)
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "Stop all tunnels running in the background")
+
+	return cmd
+}
@@ -0,0 +1,62 @@
+package tunnel
+
+import (
+	"github.com/planetscale/cli/internal/cmdutil"
+
+	"github.com/spf13/cobra"
+)
+
+// TunnelCmd encapsulates the commands for managing the tunnels started with
+// pscale connect --daemon.
+func TunnelCmd(ch *cmdutil.Helper) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tunnel <command>",
+		Short: "Manage the tunnels running in the background",
+		Long: `Manage the tunnels running in the background.
+
+Tunnels are started in the background with pscale connect --daemon, i.e.:
+
+  pscale connect mydatabase main --daemon --port 3306
+  pscale connect mydatabase dev --daemon --listen unix:///tmp/dev.sock`,
+	}
+
+	cmd.AddCommand(ListCmd(ch))
+	cmd.AddCommand(StopCmd(ch))
+
+	return cmd
+}
+
+// entry returns a table-serializable tunnel.
+type entry struct {
+	ID        string   `header:"id" json:"id"`
+	Database  string   `header:"database" json:"database"`
+	Branch    string   `header:"branch" json:"branch"`
+	Address   string   `header:"address" json:"-"`
+	Addrs     []string `header:"-" json:"addrs"`
+	Org       string   `header:"-" json:"org"`
+	PID       int      `header:"pid" json:"pid"`
+	StartedAt int64    `header:"started_at,timestamp(ms|utc|human)" json:"started_at"`
+
+	Connections string `header:"connections" json:"-"`
+	Traffic     string `header:"traffic" json:"-"`
+	Stats       *stats `header:"-" json:"stats,omitempty"`
+}
+
+// stats is the traffic of a tunnel, bytes in are received from the database.
+type stats struct {
+	BytesIn           int64         `json:"bytes_in"`
+	BytesOut          int64         `json:"bytes_out"`
+	ActiveConnections int           `json:"active_connections"`
+	TotalConnections  int           `json:"total_connections"`
+	Connections       []*connection `json:"connections"`
+	UpdatedAt         int64         `json:"updated_at"`
+}
+
+// connection is a connection open through a tunnel.
+type connection struct {
+	ID        uint64 `json:"id"`
+	StartedAt int64  `json:"started_at"`
+	Duration  int64  `json:"duration_ms"`
+	BytesIn   int64  `json:"bytes_in"`
+	BytesOut  int64  `json:"bytes_out"`
+}
@@ -2,22 +2,34 @@ package version
 
 import (
 	"fmt"
+	"io/ioutil"
+	"runtime"
 	"strings"
 
 	"github.com/planetscale/cli/internal/cmdutil"
 	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/update"
 
 	"github.com/spf13/cobra"
 )
 
 // VersionCmd encapsulates the commands for showing a version
 func VersionCmd(ch *cmdutil.Helper, ver, commit, buildDate string) *cobra.Command {
+	var flags struct {
+		verify bool
+		key    string
+	}
+
 	cmd := &cobra.Command{
 		Use: "version <command>",
 		// we can also show the version via `--version`, hence this doesn't
 		// need to be displayed.
 		Hidden: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.verify {
+				return verify(cmd, ch, ver, commit, buildDate, flags.key)
+			}
+
 			if ch.Printer.Format() == printer.Human {
 				ch.Printer.Println(Format(ver, commit, buildDate))
 				return nil
@@ -32,9 +44,84 @@ func VersionCmd(ch *cmdutil.Helper, ver, commit, buildDate string) *cobra.Comman
 		},
 	}
 
+	cmd.Flags().BoolVar(&flags.verify, "verify", false,
+		"Verify the running binary against the checksums published for its release and show its provenance")
+	cmd.Flags().StringVar(&flags.key, "key", "",
+		"Public key (cosign or minisign) the checksums of the release must be signed with. Used with --verify")
+
 	return cmd
 }
 
+// provenance describes where a verified binary comes from.
+type provenance struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+
+	*update.Verification
+}
+
+func verify(cmd *cobra.Command, ch *cmdutil.Helper, ver, commit, buildDate, keyPath string) error {
+	var key []byte
+	if keyPath != "" {
+		var err error
+		key, err = ioutil.ReadFile(keyPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	end := ch.Printer.PrintProgress("Verifying the pscale binary against its release...")
+	defer end()
+
+	opts := update.VerifyOptions{
+		Version:   ver,
+		PublicKey: key,
+		Transport: ch.Config.HTTPTransport(),
+	}
+	if ch.Config.Mirror != nil {
+		opts.ReleaseURL = ch.Config.Mirror.ReleasesURL
+	}
+
+	v, err := update.VerifyBinary(cmd.Context(), opts)
+	if err != nil {
+		return fmt.Errorf("verification failed: %s", err)
+	}
+
+	end()
+
+	p := &provenance{
+		Version:      strings.TrimPrefix(ver, "v"),
+		Commit:       commit,
+		BuildDate:    buildDate,
+		GoVersion:    runtime.Version(),
+		Platform:     runtime.GOOS + "/" + runtime.GOARCH,
+		Verification: v,
+	}
+
+	if ch.Printer.Format() != printer.Human {
+		return ch.Printer.PrintResource(p)
+	}
+
+	signature := p.Signature
+	if signature == "" {
+		signature = printer.Red("not checked, pass --key to verify the signature of the checksums")
+	}
+
+	ch.Printer.Printf("%s matches the binary published for release %s.\n\n",
+		printer.BoldBlue(p.Binary), printer.BoldBlue(p.Version))
+	ch.Printer.Printf("  Commit:     %s\n", p.Commit)
+	ch.Printer.Printf("  Build date: %s\n", p.BuildDate)
+	ch.Printer.Printf("  Go version: %s\n", p.GoVersion)
+	ch.Printer.Printf("  Platform:   %s\n", p.Platform)
+	ch.Printer.Printf("  SHA256:     %s\n", p.SHA256)
+	ch.Printer.Printf("  Archive:    %s\n", p.Archive)
+	ch.Printer.Printf("  Signature:  %s\n", signature)
+	return nil
+}
+
 // Format formats a version string with the given information.
 func Format(ver, commit, buildDate string) string {
 	if ver == "" && buildDate == "" && commit == "" {
@@ -0,0 +1,173 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmd/deployrequest"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"gopkg.in/yaml.v2"
+)
+
+// spec is the file listing the resources to watch.
+type spec struct {
+	// Interval is how often the resources are polled, it overrides
+	// --interval.
+	Interval  time.Duration `yaml:"interval"`
+	Resources []*resource   `yaml:"resources"`
+}
+
+// resource is a resource to watch. Kind is one of deploy-request, branch,
+// database, covering imports, and backup.
+type resource struct {
+	Name     string `yaml:"name"`
+	Kind     string `yaml:"kind"`
+	Org      string `yaml:"org"`
+	Database string `yaml:"database"`
+	Branch   string `yaml:"branch"`
+	Number   uint64 `yaml:"number"`
+	Backup   string `yaml:"backup"`
+}
+
+// readSpec reads and validates the spec file. Resources without an
+// organization use org.
+func readSpec(path, org string) (*spec, error) {
+	out, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &spec{}
+	if err := yaml.UnmarshalStrict(out, s); err != nil {
+		return nil, fmt.Errorf("can't unmarshal file %q: %s", path, err)
+	}
+	if len(s.Resources) == 0 {
+		return nil, fmt.Errorf("file %q doesn't list any resources", path)
+	}
+
+	for i, r := range s.Resources {
+		if r.Org == "" {
+			r.Org = org
+		}
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("resource %d of %q: %s", i+1, path, err)
+		}
+	}
+	return s, nil
+}
+
+// validate checks the fields of the kind are set and names the resource if
+// it has no name.
+func (r *resource) validate() error {
+	if r.Org == "" {
+		return fmt.Errorf("no organization is set")
+	}
+	if r.Database == "" {
+		return fmt.Errorf("database is required")
+	}
+
+	switch r.Kind {
+	case "deploy-request":
+		if r.Number == 0 {
+			return fmt.Errorf("number is required for deploy requests")
+		}
+		r.defaultName(fmt.Sprintf("deploy-request %s/%d", r.Database, r.Number))
+	case "branch":
+		if r.Branch == "" {
+			return fmt.Errorf("branch is required for branches")
+		}
+		r.defaultName(fmt.Sprintf("branch %s/%s", r.Database, r.Branch))
+	case "database":
+		r.defaultName(fmt.Sprintf("database %s", r.Database))
+	case "backup":
+		if r.Branch == "" || r.Backup == "" {
+			return fmt.Errorf("branch and backup are required for backups")
+		}
+		r.defaultName(fmt.Sprintf("backup %s/%s/%s", r.Database, r.Branch, r.Backup))
+	default:
+		return fmt.Errorf("unknown kind %q, use deploy-request, branch, database or backup", r.Kind)
+	}
+	return nil
+}
+
+func (r *resource) defaultName(name string) {
+	if r.Name == "" {
+		r.Name = name
+	}
+}
+
+// status is the state of a resource when it was polled. Done resources don't
+// change anymore, failed is set if they didn't succeed.
+type status struct {
+	state  string
+	done   bool
+	failed error
+}
+
+// poll returns the current status of the resource.
+func (r *resource) poll(ctx context.Context, client *ps.Client) (*status, error) {
+	switch r.Kind {
+	case "deploy-request":
+		dr, err := client.DeployRequests.Get(ctx, &ps.GetDeployRequestRequest{
+			Organization: r.Org,
+			Database:     r.Database,
+			Number:       r.Number,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		s := &status{state: deployrequest.DeploymentState(dr), done: deployrequest.DeploymentFinished(dr)}
+		if s.done {
+			s.failed = deployrequest.DeploymentError(dr)
+		}
+		return s, nil
+	case "branch":
+		b, err := client.DatabaseBranches.Get(ctx, &ps.GetDatabaseBranchRequest{
+			Organization: r.Org,
+			Database:     r.Database,
+			Branch:       r.Branch,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if b.Ready {
+			return &status{state: "ready", done: true}, nil
+		}
+		return &status{state: "pending"}, nil
+	case "database":
+		db, err := client.Databases.Get(ctx, &ps.GetDatabaseRequest{
+			Organization: r.Org,
+			Database:     r.Database,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &status{state: string(db.State), done: db.State == ps.DatabaseReady}, nil
+	default:
+		b, err := client.Backups.Get(ctx, &ps.GetBackupRequest{
+			Organization: r.Org,
+			Database:     r.Database,
+			Branch:       r.Branch,
+			Backup:       r.Backup,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		s := &status{state: b.State}
+		switch b.State {
+		case "success":
+			s.done = true
+		case "failed", "canceled":
+			s.done = true
+			s.failed = fmt.Errorf("backup %s", b.State)
+		}
+		return s, nil
+	}
+}
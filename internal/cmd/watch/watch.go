@@ -0,0 +1,266 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+// eventOut is where the dashboard is drawn, or the events are written to.
+var eventOut io.Writer = os.Stdout
+
+// WatchCmd is the command for watching multiple resources at once.
+func WatchCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		file     string
+		interval time.Duration
+		lines    int
+	}
+
+	cmd := &cobra.Command{
+		Use:   "watch --file <file>",
+		Short: "Watch deployments, imports, branches and backups at once",
+		Long: `Watch deployments, imports, branches and backups at once.
+
+The resources listed in the file are polled concurrently until all of them are
+done: deploy requests until they're deployed, databases until they're ready,
+which covers imports, branches until they're ready and backups until they
+finished. In a terminal, each resource is shown in its own pane with its
+latest events. Otherwise, and with --format json, the events are written as
+newline delimited JSON. The command fails if any resource didn't succeed.
+
+The file lists the resources with their kind:
+
+  interval: 10s
+  resources:
+    - kind: deploy-request
+      database: mydb
+      number: 42
+    - kind: database
+      name: import
+      database: newdb
+    - kind: branch
+      database: mydb
+      branch: release
+    - kind: backup
+      database: mydb
+      branch: main
+      backup: abc123`,
+		Args:              cobra.NoArgs,
+		PersistentPreRunE: cmdutil.CheckAuthentication(ch.Config),
+		Example: `  pscale watch --file cutover.yml
+  pscale watch --file cutover.yml --format json > cutover.ndjson`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := readSpec(flags.file, ch.Config.Organization)
+			if err != nil {
+				return err
+			}
+
+			interval := flags.interval
+			if s.Interval > 0 {
+				interval = s.Interval
+			}
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			panes := make([]*pane, 0, len(s.Resources))
+			for _, r := range s.Resources {
+				panes = append(panes, &pane{resource: r, state: "polling"})
+			}
+
+			dashboard := printer.IsTTY && ch.Printer.Format() == printer.Human
+			enc := json.NewEncoder(eventOut)
+			if dashboard {
+				render(eventOut, panes, flags.lines)
+			}
+
+			err = watchResources(cmd.Context(), client, s.Resources, interval, func(i int, e *event) error {
+				panes[i].update(e)
+				if dashboard {
+					render(eventOut, panes, flags.lines)
+					return nil
+				}
+				return enc.Encode(e)
+			})
+			if err != nil {
+				return err
+			}
+
+			failed := 0
+			for _, p := range panes {
+				if p.failed != "" {
+					failed++
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d resources didn't succeed", failed, len(panes))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.file, "file", "", "The file listing the resources to watch")
+	cmd.Flags().DurationVar(&flags.interval, "interval", 5*time.Second, "How often the resources are polled")
+	cmd.Flags().IntVar(&flags.lines, "lines", 5, "The number of events shown in the pane of each resource")
+	cmd.PersistentFlags().StringVar(&ch.Config.Organization, "org", ch.Config.Organization,
+		"The organization of the resources that don't set one")
+	cmd.MarkFlagRequired("file") // nolint:errcheck
+
+	return cmd
+}
+
+// event is a change of the state of a resource, or a failure to poll it.
+type event struct {
+	Time     time.Time `json:"time"`
+	Resource string    `json:"resource"`
+	Kind     string    `json:"kind"`
+	State    string    `json:"state,omitempty"`
+	Done     bool      `json:"done"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// watchResources polls the resources concurrently every interval until all
+// of them are done, and calls onEvent with the index of the resource for
+// every event. Polling errors are reported as events and retried, resources
+// that don't exist are done.
+func watchResources(ctx context.Context, client *ps.Client, resources []*resource, interval time.Duration, onEvent func(int, *event) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type indexed struct {
+		i int
+		e *event
+	}
+	events := make(chan indexed)
+
+	for i, r := range resources {
+		go func(i int, r *resource) {
+			var last string
+			for {
+				e := &event{Resource: r.Name, Kind: r.Kind}
+				s, err := r.poll(ctx, client)
+				switch {
+				case cmdutil.ErrCode(err) == ps.ErrNotFound:
+					e.State, e.Done, e.Error = "not_found", true, fmt.Sprintf("%s does not exist", r.Name)
+				case err != nil:
+					e.Error = cmdutil.HandleError(err).Error()
+				case s.state != last || s.done:
+					last = s.state
+					e.State, e.Done = s.state, s.done
+					if s.failed != nil {
+						e.Error = s.failed.Error()
+					}
+				default:
+					e = nil
+				}
+
+				if e != nil {
+					e.Time = time.Now().UTC()
+					select {
+					case events <- indexed{i, e}:
+					case <-ctx.Done():
+						return
+					}
+					if e.Done {
+						return
+					}
+				}
+
+				t := time.NewTimer(interval)
+				select {
+				case <-ctx.Done():
+					t.Stop()
+					return
+				case <-t.C:
+				}
+			}
+		}(i, r)
+	}
+
+	for remaining := len(resources); remaining > 0; {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ie := <-events:
+			if err := onEvent(ie.i, ie.e); err != nil {
+				return err
+			}
+			if ie.e.Done {
+				remaining--
+			}
+		}
+	}
+	return nil
+}
+
+// pane is the state of a resource on the dashboard.
+type pane struct {
+	resource *resource
+	state    string
+	done     bool
+	failed   string
+	events   []string
+}
+
+func (p *pane) update(e *event) {
+	line := e.Time.Local().Format("15:04:05") + " "
+	switch {
+	case e.State == "":
+		line += "error: " + e.Error
+	case e.Error != "":
+		line += e.State + ": " + e.Error
+	default:
+		line += e.State
+	}
+	p.events = append(p.events, line)
+
+	if e.State != "" {
+		p.state, p.done = e.State, e.Done
+		if e.Done && e.Error != "" {
+			p.failed = e.Error
+		}
+	}
+}
+
+// render redraws the dashboard, a pane for each resource with its state and
+// its latest events.
+func render(w io.Writer, panes []*pane, lines int) {
+	var b strings.Builder
+	b.WriteString("\033[H\033[2J")
+	for _, p := range panes {
+		state := p.state
+		switch {
+		case p.failed != "":
+			state = printer.BoldRed(state)
+		case p.done:
+			state = printer.BoldBlue(state)
+		}
+		fmt.Fprintf(&b, "%s  %s\n", printer.Bold(p.resource.Name), state)
+
+		events := p.events
+		if len(events) > lines {
+			events = events[len(events)-lines:]
+		}
+		for _, e := range events {
+			fmt.Fprintf(&b, "  %s\n", e)
+		}
+		for i := len(events); i < lines; i++ {
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprint(w, b.String())
+}
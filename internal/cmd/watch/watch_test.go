@@ -0,0 +1,95 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/printer"
+
+	qt "github.com/frankban/quicktest"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+func TestWatchCmd(t *testing.T) {
+	c := qt.New(t)
+
+	file := filepath.Join(c.TempDir(), "watch.yml")
+	err := ioutil.WriteFile(file, []byte(`interval: 1ms
+resources:
+  - kind: deploy-request
+    database: mydb
+    number: 7
+  - kind: branch
+    name: release branch
+    database: mydb
+    branch: release
+`), 0644)
+	c.Assert(err, qt.IsNil)
+
+	var buf bytes.Buffer
+	eventOut = &buf
+	defer func() { eventOut = os.Stdout }()
+
+	format := printer.JSON
+	p := printer.NewPrinter(&format)
+
+	var drPolls int
+	drs := &mock.DeployRequestsService{
+		GetFn: func(ctx context.Context, req *ps.GetDeployRequestRequest) (*ps.DeployRequest, error) {
+			c.Check(req.Organization, qt.Equals, "planetscale")
+			drPolls++
+			if drPolls < 3 {
+				return &ps.DeployRequest{Number: 7, Deployment: &ps.Deployment{State: "in_progress"}}, nil
+			}
+			return &ps.DeployRequest{Number: 7, Deployment: &ps.Deployment{State: "complete"}}, nil
+		},
+	}
+	branches := &mock.DatabaseBranchesService{
+		GetFn: func(ctx context.Context, req *ps.GetDatabaseBranchRequest) (*ps.DatabaseBranch, error) {
+			return &ps.DatabaseBranch{Name: req.Branch, Ready: true}, nil
+		},
+	}
+
+	ch := &cmdutil.Helper{
+		Printer: p,
+		Config:  &config.Config{Organization: "planetscale", AccessToken: "token"},
+		Client: func() (*ps.Client, error) {
+			return &ps.Client{DeployRequests: drs, DatabaseBranches: branches}, nil
+		},
+	}
+
+	cmd := WatchCmd(ch)
+	cmd.SetArgs([]string{"--file", file})
+	c.Assert(cmd.Execute(), qt.IsNil)
+
+	states := make(map[string][]string)
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var e event
+		c.Assert(dec.Decode(&e), qt.IsNil)
+		states[e.Resource] = append(states[e.Resource], e.State)
+	}
+	c.Assert(states, qt.DeepEquals, map[string][]string{
+		"deploy-request mydb/7": {"in_progress", "complete"},
+		"release branch":        {"ready"},
+	})
+}
+
+func TestReadSpec_Invalid(t *testing.T) {
+	c := qt.New(t)
+
+	file := filepath.Join(c.TempDir(), "watch.yml")
+	err := ioutil.WriteFile(file, []byte("resources:\n  - kind: branch\n    database: mydb\n"), 0644)
+	c.Assert(err, qt.IsNil)
+
+	_, err = readSpec(file, "planetscale")
+	c.Assert(err, qt.ErrorMatches, `resource 1 of ".*watch.yml": branch is required for branches`)
+}
@@ -0,0 +1,361 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/schemacache"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+//go:embed templates static
+var assets embed.FS
+
+// server serves the read-only web UI. Pages are rendered on the server, so
+// the UI works without JavaScript.
+type server struct {
+	client *ps.Client
+	cache  *cache
+	pages  map[string]*template.Template
+	static http.Handler
+}
+
+func newServer(client *ps.Client, ttl time.Duration) (*server, error) {
+	pages, err := parsePages()
+	if err != nil {
+		return nil, err
+	}
+
+	static, err := fs.Sub(assets, "static")
+	if err != nil {
+		return nil, err
+	}
+
+	return &server{
+		client: client,
+		cache:  newCache(ttl),
+		pages:  pages,
+		static: http.StripPrefix("/static/", http.FileServer(http.FS(static))),
+	}, nil
+}
+
+// parsePages parses each page of the templates directory together with the
+// layout.
+func parsePages() (map[string]*template.Template, error) {
+	funcs := template.FuncMap{
+		"orgPath":           orgPath,
+		"databasePath":      databasePath,
+		"branchPath":        branchPath,
+		"deployRequestPath": deployRequestPath,
+		"time": func(t time.Time) string {
+			if t.IsZero() {
+				return "n/a"
+			}
+			return t.UTC().Format("2006-01-02 15:04 UTC")
+		},
+	}
+
+	layout, err := template.New("layout.html").Funcs(funcs).ParseFS(assets, "templates/layout.html")
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := fs.Glob(assets, "templates/*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make(map[string]*template.Template, len(files))
+	for _, f := range files {
+		name := path.Base(f)
+		if name == "layout.html" {
+			continue
+		}
+
+		t, err := layout.Clone()
+		if err != nil {
+			return nil, err
+		}
+		if pages[strings.TrimSuffix(name, ".html")], err = t.ParseFS(assets, f); err != nil {
+			return nil, err
+		}
+	}
+	return pages, nil
+}
+
+// ServeHTTP routes the requests:
+//
+//	/                                               organizations
+//	/orgs/<org>                                     databases
+//	/orgs/<org>/databases/<db>                      branches and deploy requests
+//	/orgs/<org>/databases/<db>/branches/<branch>    branch and schema
+//	/orgs/<org>/databases/<db>/deploy-requests/<n>  deploy request and diff
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		s.error(w, http.StatusMethodNotAllowed, "The UI is read-only.")
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/static/") {
+		s.static.ServeHTTP(w, r)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	ctx := r.Context()
+	switch {
+	case r.URL.Path == "/":
+		s.organizations(ctx, w)
+	case len(parts) == 2 && parts[0] == "orgs":
+		s.organization(ctx, w, parts[1])
+	case len(parts) == 4 && parts[0] == "orgs" && parts[2] == "databases":
+		s.database(ctx, w, parts[1], parts[3])
+	case len(parts) == 6 && parts[0] == "orgs" && parts[2] == "databases" && parts[4] == "branches":
+		s.branch(ctx, w, parts[1], parts[3], parts[5])
+	case len(parts) == 6 && parts[0] == "orgs" && parts[2] == "databases" && parts[4] == "deploy-requests":
+		n, err := strconv.ParseUint(parts[5], 10, 64)
+		if err != nil {
+			s.error(w, http.StatusNotFound, fmt.Sprintf("%q is not a deploy request number.", parts[5]))
+			return
+		}
+		s.deployRequest(ctx, w, parts[1], parts[3], n)
+	default:
+		s.error(w, http.StatusNotFound, "Page not found.")
+	}
+}
+
+func (s *server) organizations(ctx context.Context, w http.ResponseWriter) {
+	orgs, err := s.cache.get("orgs", func() (interface{}, error) {
+		return s.client.Organizations.List(ctx)
+	})
+	if err != nil {
+		s.apiError(w, err)
+		return
+	}
+
+	s.render(w, "organizations", map[string]interface{}{
+		"Title":         "Organizations",
+		"Organizations": orgs,
+	})
+}
+
+func (s *server) organization(ctx context.Context, w http.ResponseWriter, org string) {
+	dbs, err := s.cache.get("databases/"+org, func() (interface{}, error) {
+		return s.client.Databases.List(ctx, &ps.ListDatabasesRequest{Organization: org})
+	})
+	if err != nil {
+		s.apiError(w, err)
+		return
+	}
+
+	s.render(w, "organization", map[string]interface{}{
+		"Title":     org,
+		"Org":       org,
+		"Databases": dbs,
+	})
+}
+
+func (s *server) database(ctx context.Context, w http.ResponseWriter, org, database string) {
+	branches, err := s.cache.get("branches/"+org+"/"+database, func() (interface{}, error) {
+		return s.client.DatabaseBranches.List(ctx, &ps.ListDatabaseBranchesRequest{
+			Organization: org,
+			Database:     database,
+		})
+	})
+	if err != nil {
+		s.apiError(w, err)
+		return
+	}
+
+	drs, err := s.cache.get("deploy-requests/"+org+"/"+database, func() (interface{}, error) {
+		drs, err := s.client.DeployRequests.List(ctx, &ps.ListDeployRequestsRequest{
+			Organization: org,
+			Database:     database,
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(drs, func(i, j int) bool { return drs[i].Number > drs[j].Number })
+		return drs, nil
+	})
+	if err != nil {
+		s.apiError(w, err)
+		return
+	}
+
+	s.render(w, "database", map[string]interface{}{
+		"Title":          org + "/" + database,
+		"Org":            org,
+		"Database":       database,
+		"Branches":       branches,
+		"DeployRequests": drs,
+	})
+}
+
+func (s *server) branch(ctx context.Context, w http.ResponseWriter, org, database, branch string) {
+	b, err := s.cache.get("branch/"+org+"/"+database+"/"+branch, func() (interface{}, error) {
+		return s.client.DatabaseBranches.Get(ctx, &ps.GetDatabaseBranchRequest{
+			Organization: org,
+			Database:     database,
+			Branch:       branch,
+		})
+	})
+	if err != nil {
+		s.apiError(w, err)
+		return
+	}
+
+	schema, err := s.cache.get("schema/"+org+"/"+database+"/"+branch, func() (interface{}, error) {
+		return schemacache.Schema(ctx, s.client, &ps.BranchSchemaRequest{
+			Organization: org,
+			Database:     database,
+			Branch:       branch,
+		}, false)
+	})
+	if err != nil {
+		s.apiError(w, err)
+		return
+	}
+
+	s.render(w, "branch", map[string]interface{}{
+		"Title":    org + "/" + database + "/" + branch,
+		"Org":      org,
+		"Database": database,
+		"Branch":   b,
+		"Schema":   schema,
+	})
+}
+
+func (s *server) deployRequest(ctx context.Context, w http.ResponseWriter, org, database string, number uint64) {
+	key := fmt.Sprintf("%s/%s/%d", org, database, number)
+	dr, err := s.cache.get("deploy-request/"+key, func() (interface{}, error) {
+		return s.client.DeployRequests.Get(ctx, &ps.GetDeployRequestRequest{
+			Organization: org,
+			Database:     database,
+			Number:       number,
+		})
+	})
+	if err != nil {
+		s.apiError(w, err)
+		return
+	}
+
+	diff, err := s.cache.get("diff/"+key, func() (interface{}, error) {
+		return s.client.DeployRequests.Diff(ctx, &ps.DiffRequest{
+			Organization: org,
+			Database:     database,
+			Number:       number,
+		})
+	})
+	if err != nil {
+		s.apiError(w, err)
+		return
+	}
+
+	s.render(w, "deploy-request", map[string]interface{}{
+		"Title":         fmt.Sprintf("%s/%s deploy request #%d", org, database, number),
+		"Org":           org,
+		"Database":      database,
+		"DeployRequest": dr,
+		"Diff":          diff,
+	})
+}
+
+// render renders the page into a buffer first, so a failing template
+// doesn't leave a half written page.
+func (s *server) render(w http.ResponseWriter, page string, data map[string]interface{}) {
+	var buf bytes.Buffer
+	if err := s.pages[page].ExecuteTemplate(&buf, "layout.html", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes()) // nolint:errcheck
+}
+
+func (s *server) apiError(w http.ResponseWriter, err error) {
+	if cmdutil.ErrCode(err) == ps.ErrNotFound {
+		s.error(w, http.StatusNotFound, "Not found, or the CLI's credentials can't access it.")
+		return
+	}
+	s.error(w, http.StatusBadGateway, fmt.Sprintf("The PlanetScale API returned an error: %s", err))
+}
+
+func (s *server) error(w http.ResponseWriter, status int, msg string) {
+	w.WriteHeader(status)
+	s.pages["error"].ExecuteTemplate(w, "layout.html", map[string]interface{}{ // nolint:errcheck
+		"Title":   http.StatusText(status),
+		"Message": msg,
+	})
+}
+
+func orgPath(org string) string {
+	return "/orgs/" + url.PathEscape(org)
+}
+
+func databasePath(org, database string) string {
+	return orgPath(org) + "/databases/" + url.PathEscape(database)
+}
+
+func branchPath(org, database, branch string) string {
+	return databasePath(org, database) + "/branches/" + url.PathEscape(branch)
+}
+
+func deployRequestPath(org, database string, number uint64) string {
+	return fmt.Sprintf("%s/deploy-requests/%d", databasePath(org, database), number)
+}
+
+// cache caches API responses in memory, so browsing around doesn't hit the
+// API on every page view.
+type cache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	fetchedAt time.Time
+	value     interface{}
+}
+
+func newCache(ttl time.Duration) *cache {
+	return &cache{ttl: ttl, now: time.Now, entries: make(map[string]*cacheEntry)}
+}
+
+// get returns the cached value of the key, or fetches and caches it if it's
+// missing or older than the TTL. Errors aren't cached.
+func (c *cache) get(key string, fetch func() (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && c.now().Sub(e.fetchedAt) < c.ttl {
+		return e.value, nil
+	}
+
+	v, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = &cacheEntry{fetchedAt: c.now(), value: v}
+	c.mu.Unlock()
+	return v, nil
+}
@@ -0,0 +1,141 @@
+package web
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+func TestServer(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	org := "planetscale"
+	db := "mydb"
+
+	orgs := &mock.OrganizationsService{
+		ListFn: func(ctx context.Context) ([]*ps.Organization, error) {
+			return []*ps.Organization{{Name: org}}, nil
+		},
+	}
+	databases := &mock.DatabaseService{
+		ListFn: func(ctx context.Context, req *ps.ListDatabasesRequest) ([]*ps.Database, error) {
+			c.Assert(req.Organization, qt.Equals, org)
+			return []*ps.Database{{Name: db}}, nil
+		},
+	}
+	branches := &mock.DatabaseBranchesService{
+		ListFn: func(ctx context.Context, req *ps.ListDatabaseBranchesRequest) ([]*ps.DatabaseBranch, error) {
+			return []*ps.DatabaseBranch{{Name: "main", Production: true}}, nil
+		},
+		GetFn: func(ctx context.Context, req *ps.GetDatabaseBranchRequest) (*ps.DatabaseBranch, error) {
+			if req.Branch != "main" {
+				return nil, &ps.Error{Code: ps.ErrNotFound}
+			}
+			return &ps.DatabaseBranch{Name: "main"}, nil
+		},
+		SchemaFn: func(ctx context.Context, req *ps.BranchSchemaRequest) ([]*ps.Diff, error) {
+			return []*ps.Diff{{Name: "users", Raw: "CREATE TABLE `users` (`id` int)"}}, nil
+		},
+	}
+	drs := &mock.DeployRequestsService{
+		ListFn: func(ctx context.Context, req *ps.ListDeployRequestsRequest) ([]*ps.DeployRequest, error) {
+			return []*ps.DeployRequest{{Number: 1, Branch: "dev", IntoBranch: "main", State: "open"}}, nil
+		},
+		GetFn: func(ctx context.Context, req *ps.GetDeployRequestRequest) (*ps.DeployRequest, error) {
+			return &ps.DeployRequest{Number: req.Number, Branch: "dev", IntoBranch: "main", Notes: "<b>notes</b>"}, nil
+		},
+		DiffFn: func(ctx context.Context, req *ps.DiffRequest) ([]*ps.Diff, error) {
+			return []*ps.Diff{{Name: "users", Raw: "+ `email` varchar(255)"}}, nil
+		},
+	}
+
+	s, err := newServer(&ps.Client{
+		Organizations:    orgs,
+		Databases:        databases,
+		DatabaseBranches: branches,
+		DeployRequests:   drs,
+	}, time.Minute)
+	c.Assert(err, qt.IsNil)
+
+	get := func(method, path string) (int, string) {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		body, err := ioutil.ReadAll(rec.Result().Body)
+		c.Assert(err, qt.IsNil)
+		return rec.Code, string(body)
+	}
+
+	code, body := get(http.MethodGet, "/")
+	c.Assert(code, qt.Equals, http.StatusOK)
+	c.Assert(body, qt.Contains, `href="/orgs/planetscale"`)
+
+	code, body = get(http.MethodGet, "/orgs/planetscale")
+	c.Assert(code, qt.Equals, http.StatusOK)
+	c.Assert(body, qt.Contains, `href="/orgs/planetscale/databases/mydb"`)
+
+	code, body = get(http.MethodGet, "/orgs/planetscale/databases/mydb")
+	c.Assert(code, qt.Equals, http.StatusOK)
+	c.Assert(body, qt.Contains, `href="/orgs/planetscale/databases/mydb/branches/main"`)
+	c.Assert(body, qt.Contains, `href="/orgs/planetscale/databases/mydb/deploy-requests/1"`)
+
+	code, body = get(http.MethodGet, "/orgs/planetscale/databases/mydb/branches/main")
+	c.Assert(code, qt.Equals, http.StatusOK)
+	c.Assert(body, qt.Contains, "CREATE TABLE `users` (`id` int)")
+
+	code, body = get(http.MethodGet, "/orgs/planetscale/databases/mydb/deploy-requests/1")
+	c.Assert(code, qt.Equals, http.StatusOK)
+	c.Assert(body, qt.Contains, "&lt;b&gt;notes&lt;/b&gt;")
+	c.Assert(body, qt.Contains, "`email` varchar(255)")
+
+	code, _ = get(http.MethodGet, "/orgs/planetscale/databases/mydb/branches/missing")
+	c.Assert(code, qt.Equals, http.StatusNotFound)
+
+	code, _ = get(http.MethodPost, "/orgs/planetscale")
+	c.Assert(code, qt.Equals, http.StatusMethodNotAllowed)
+
+	code, body = get(http.MethodGet, "/static/style.css")
+	c.Assert(code, qt.Equals, http.StatusOK)
+	c.Assert(body, qt.Contains, "border-collapse")
+
+	// the pages are served from the cache
+	get(http.MethodGet, "/")
+	c.Assert(orgs.ListFnInvoked, qt.IsTrue)
+	orgs.ListFnInvoked = false
+	get(http.MethodGet, "/")
+	c.Assert(orgs.ListFnInvoked, qt.IsFalse)
+}
+
+func TestCache(t *testing.T) {
+	c := qt.New(t)
+
+	now := time.Now()
+	cc := newCache(time.Minute)
+	cc.now = func() time.Time { return now }
+
+	fetches := 0
+	fetch := func() (interface{}, error) {
+		fetches++
+		return fetches, nil
+	}
+
+	v, err := cc.get("key", fetch)
+	c.Assert(err, qt.IsNil)
+	c.Assert(v, qt.Equals, 1)
+
+	v, _ = cc.get("key", fetch)
+	c.Assert(v, qt.Equals, 1)
+
+	now = now.Add(2 * time.Minute)
+	v, _ = cc.get("key", fetch)
+	c.Assert(v, qt.Equals, 2)
+}
@@ -0,0 +1,114 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/pkg/browser"
+	"github.com/spf13/cobra"
+)
+
+// WebCmd serves a local, read-only web UI of the organizations.
+func WebCmd(ch *cmdutil.Helper) *cobra.Command {
+	var flags struct {
+		listen   string
+		open     bool
+		cacheTTL time.Duration
+	}
+
+	cmd := &cobra.Command{
+		Use:   "web",
+		Short: "Serve a local, read-only web UI of your organizations",
+		Long: `Serve a local, read-only web UI of your organizations.
+
+The UI shows the organizations, databases, branches with their schema and
+deploy requests with their diff, using the credentials of the CLI. Nothing
+can be changed from it, so it can be shared with teammates who prefer a
+browser but don't have dashboard accounts. API responses are cached for
+--cache-ttl, and schemas in the schema cache of the CLI.
+
+Anyone who can reach the listen address can see everything the CLI's
+credentials can, so only listen on other interfaces than localhost on a
+trusted network.`,
+		Args:              cobra.NoArgs,
+		PersistentPreRunE: cmdutil.CheckAuthentication(ch.Config),
+		Example: `  pscale web --open
+  pscale web --org acme --listen 0.0.0.0:8090`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer cancel()
+
+			client, err := ch.Client()
+			if err != nil {
+				return err
+			}
+
+			s, err := newServer(client, flags.cacheTTL)
+			if err != nil {
+				return err
+			}
+
+			l, err := net.Listen("tcp", flags.listen)
+			if err != nil {
+				return fmt.Errorf("can't listen on %s: %s", flags.listen, err)
+			}
+
+			url := "http://" + l.Addr().String()
+			if ch.Config.Organization != "" {
+				url += orgPath(ch.Config.Organization)
+			}
+
+			if host, _, err := net.SplitHostPort(flags.listen); err == nil && !isLoopback(host) {
+				ch.Printer.Printf("%s the UI is reachable from other machines on %s.\n",
+					printer.BoldRed("Warning:"), printer.BoldBlue(flags.listen))
+			}
+			ch.Printer.Printf("Serving the web UI on %s, press Ctrl-C to stop.\n", printer.BoldBlue(url))
+
+			if flags.open {
+				if err := browser.OpenURL(url); err != nil {
+					ch.Printer.Printf("Can't open the browser: %s\n", err)
+				}
+			}
+
+			srv := &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				srv.Shutdown(shutdownCtx) // nolint:errcheck
+			}()
+
+			if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(&ch.Config.Organization, "org", ch.Config.Organization,
+		"The organization to open the UI on")
+	cmd.Flags().StringVar(&flags.listen, "listen", "127.0.0.1:8090", "The host:port to serve the UI on")
+	cmd.Flags().BoolVar(&flags.open, "open", false, "Open the UI in the browser")
+	cmd.Flags().DurationVar(&flags.cacheTTL, "cache-ttl", 30*time.Second, "How long API responses are cached")
+
+	return cmd
+}
+
+// isLoopback reports whether the host only accepts connections from this
+// machine.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,73 @@
+package cmdutil
+
+import (
+	"time"
+
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/spf13/cobra"
+)
+
+// AnnotationFlags are the flags shared by the commands annotating resources.
+type AnnotationFlags struct {
+	Note  string
+	Links []string
+	Clear bool
+}
+
+// Register adds the annotation flags to the command.
+func (f *AnnotationFlags) Register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.Note, "note", "", "Free-form note, replacing the existing note")
+	cmd.Flags().StringSliceVar(&f.Links, "link", nil, "Link to add, such as a ticket URL. Can be specified multiple times")
+	cmd.Flags().BoolVar(&f.Clear, "clear", false, "Remove the existing note and links")
+}
+
+// Changed reports whether any of the annotation flags were passed, i.e. the
+// annotation should be updated rather than shown.
+func (f *AnnotationFlags) Changed(cmd *cobra.Command) bool {
+	return cmd.Flags().Changed("note") || cmd.Flags().Changed("link") || f.Clear
+}
+
+// Apply returns the annotation updated with the flags. It returns nil if the
+// resulting annotation is empty.
+func (f *AnnotationFlags) Apply(cmd *cobra.Command, a *config.Annotation) *config.Annotation {
+	updated := &config.Annotation{}
+	if a != nil && !f.Clear {
+		*updated = *a
+	}
+
+	if cmd.Flags().Changed("note") {
+		updated.Note = f.Note
+	}
+	updated.Links = append(updated.Links, f.Links...)
+	updated.UpdatedAt = time.Now().UTC()
+
+	if updated.Note == "" && len(updated.Links) == 0 {
+		return nil
+	}
+	return updated
+}
+
+// PrintAnnotation prints the annotation of the named resource.
+func PrintAnnotation(p *printer.Printer, name string, a *config.Annotation) error {
+	if p.Format() != printer.Human {
+		if a == nil {
+			a = &config.Annotation{}
+		}
+		return p.PrintResource(a)
+	}
+
+	if a == nil {
+		p.Printf("%s has no annotations.\n", name)
+		return nil
+	}
+
+	if a.Note != "" {
+		p.Printf("%s\n", a.Note)
+	}
+	for _, l := range a.Links {
+		p.Printf("  %s\n", printer.BoldBlue(l))
+	}
+	return nil
+}
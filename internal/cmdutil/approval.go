@@ -0,0 +1,89 @@
+package cmdutil
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/approval"
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/spf13/cobra"
+)
+
+const defaultApprovalExpiry = 24 * time.Hour
+
+// ApprovalFlag registers the --approval flag of production-affecting
+// commands.
+func ApprovalFlag(cmd *cobra.Command, file *string) {
+	cmd.Flags().StringVar(file, "approval", "",
+		"Approval file countersigned with 'pscale approve', if the organization requires two-person approval")
+}
+
+// CheckApproval returns nil if the organization doesn't require approval of
+// the action, or if the given approval file approves it. Without an approval
+// file, an approval request is written to the working directory for a second
+// operator to countersign and an error is returned.
+func CheckApproval(ch *Helper, action *approval.Action, file string) error {
+	cfg := ch.Config.Approval
+	if cfg == nil || !cfg.Required {
+		return nil
+	}
+
+	// requests couldn't be approved by anyone
+	if len(cfg.Approvers) == 0 {
+		return fmt.Errorf("organization %s requires approval, but no trusted approvers are configured. Add the keys shown by 'pscale approve --show-key' to \"approval.approvers\"",
+			printer.BoldBlue(action.Organization))
+	}
+
+	key, err := approval.LoadKey()
+	if err != nil {
+		return err
+	}
+
+	if file != "" {
+		req, err := approval.Read(file)
+		if err != nil {
+			return err
+		}
+
+		if err := req.Verify(action, key.Public().(ed25519.PublicKey), cfg.Approvers, time.Now()); err != nil {
+			return fmt.Errorf("approval %s is not valid: %s", file, err)
+		}
+
+		ch.Printer.Printf("Approved by %s at %s.\n", printer.BoldBlue(req.Approver), req.ApprovedAt.Format(time.RFC3339))
+		return nil
+	}
+
+	expiry := cfg.Expiry
+	if expiry == 0 {
+		expiry = defaultApprovalExpiry
+	}
+
+	req := approval.NewRequest(action, Operator(), key, expiry)
+	file = fmt.Sprintf("approval-%s-%d.json", strings.ReplaceAll(action.Command, " ", "-"), req.CreatedAt.Unix())
+	if err := req.Write(file); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("organization %s requires a second operator to approve %q.\nSend %s to an approver to run 'pscale approve %s', then rerun the command with --approval %s",
+		printer.BoldBlue(action.Organization), action.String(), file, file, file)
+}
+
+// Operator returns the name of the operator running pscale.
+func Operator() string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		return name
+	}
+
+	return name + "@" + host
+}
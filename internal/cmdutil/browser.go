@@ -9,7 +9,12 @@ import (
 	exec "golang.org/x/sys/execabs"
 )
 
-const ApplicationURL = "https://app.planetscale.com"
+// ApplicationURL and DocsURL are the base URLs of the web application and the
+// documentation. They're replaced by the mirror in air-gapped mode.
+var (
+	ApplicationURL = "https://app.planetscale.com"
+	DocsURL        = "https://docs.planetscale.com"
+)
 
 // OpenBrowser opens a web browser at the specified url.
 func OpenBrowser(goos, url string) *exec.Cmd {
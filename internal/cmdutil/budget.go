@@ -0,0 +1,23 @@
+package cmdutil
+
+import (
+	"context"
+
+	"github.com/planetscale/cli/internal/cost"
+	"github.com/planetscale/cli/internal/printer"
+
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+// WarnBudget prints a warning if creating the given resource would push the
+// projected monthly cost of the organization over its configured budget.
+// The check is best-effort and never blocks the creation.
+func WarnBudget(ctx context.Context, ch *Helper, client *ps.Client, resource string, extra *cost.Usage) {
+	e, err := cost.CheckBudget(ctx, client, ch.Config, extra)
+	if err != nil || e == nil {
+		return
+	}
+
+	ch.Printer.Printf("%s creating %s projects a monthly cost of $%.2f, which exceeds the budget of $%.2f for organization %s.\n",
+		printer.BoldRed("Warning:"), resource, e.Total, ch.Config.Budget, printer.BoldBlue(ch.Config.Organization))
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/crash"
 	"github.com/planetscale/cli/internal/printer"
 
 	ps "github.com/planetscale/planetscale-go/planetscale"
@@ -108,6 +109,11 @@ func RequiredArgs(reqArgs ...string) cobra.PositionalArgs {
 // actionable error message.
 func CheckAuthentication(cfg *config.Config) func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
+		if cfg.ServiceToken == "" || cfg.ServiceTokenID == "" {
+			if err := cfg.LoadToken(); err != nil {
+				return err
+			}
+		}
 		if cfg.IsAuthenticated() {
 			return nil
 		}
@@ -134,9 +140,14 @@ func NewZapLogger(debug bool) *zap.Logger {
 		level = zap.DebugLevel
 	}
 
-	logger := zap.New(zapcore.NewCore(zapcore.NewConsoleEncoder(encoderCfg), os.Stdout, level))
+	core := zapcore.NewCore(zapcore.NewConsoleEncoder(encoderCfg), os.Stdout, level)
+
+	// keep the recent debug logs for crash reports
+	crashCfg := encoderCfg
+	crashCfg.EncodeLevel = zapcore.LowercaseLevelEncoder
+	crashCore := zapcore.NewCore(zapcore.NewConsoleEncoder(crashCfg), zapcore.AddSync(crash.Log), zap.DebugLevel)
 
-	return logger
+	return zap.New(zapcore.NewTee(core, crashCore))
 }
 
 // IsUnderHomebrew checks whether the given binary is under the homebrew path.
@@ -201,7 +212,7 @@ func MySQLClientPath() (string, error) {
 	}
 
 	msg := "couldn't find the 'mysql' command-line tool required to run this command."
-	installURL := "https://docs.planetscale.com/reference/planetscale-environment-setup"
+	installURL := DocsURL + "/reference/planetscale-environment-setup"
 
 	switch runtime.GOOS {
 	case "darwin":
@@ -209,11 +220,11 @@ func MySQLClientPath() (string, error) {
 			return "", fmt.Errorf("%s\nTo install, run: brew install mysql-client", msg)
 		}
 
-		installURL = "https://docs.planetscale.com/reference/planetscale-environment-setup#macos-instructions"
+		installURL = DocsURL + "/reference/planetscale-environment-setup#macos-instructions"
 	case "linux":
-		installURL = "https://docs.planetscale.com/reference/planetscale-environment-setup#linux-instructions"
+		installURL = DocsURL + "/reference/planetscale-environment-setup#linux-instructions"
 	case "windows":
-		installURL = "https://docs.planetscale.com/reference/planetscale-environment-setup#windows-instructions"
+		installURL = DocsURL + "/reference/planetscale-environment-setup#windows-instructions"
 	}
 
 	return "", fmt.Errorf("%s\nTo install, follow the instructions: %s", msg, installURL)
@@ -0,0 +1,268 @@
+package cmdutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/config"
+
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	completionDir = "completion"
+
+	// completionTimeout bounds the API requests of a completion, a stale
+	// cached result is used if it's exceeded.
+	completionTimeout = 2 * time.Second
+
+	// completionTTL is how long cached candidates are used without asking
+	// the API again.
+	completionTTL = 5 * time.Minute
+)
+
+// completionEntry is a cached list of completion candidates.
+type completionEntry struct {
+	FetchedAt  time.Time `json:"fetched_at"`
+	Candidates []string  `json:"candidates"`
+}
+
+// completionFetcher returns the candidates for a completion from the API.
+type completionFetcher func(ctx context.Context, client *ps.Client) ([]string, error)
+
+// DatabaseCompletion completes the database argument.
+func DatabaseCompletion(ch *Helper) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return completeDatabases(ch, cmd), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// BranchCompletion completes the database and branch arguments.
+func BranchCompletion(ch *Helper) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			return completeDatabases(ch, cmd), cobra.ShellCompDirectiveNoFileComp
+		case 1:
+			org := completionOrg(ch)
+			database := args[0]
+			return complete(ch, cmd, "branches."+org+"."+database, func(ctx context.Context, client *ps.Client) ([]string, error) {
+				branches, err := client.DatabaseBranches.List(ctx, &ps.ListDatabaseBranchesRequest{
+					Organization: org,
+					Database:     database,
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				candidates := make([]string, 0, len(branches))
+				for _, b := range branches {
+					candidates = append(candidates, b.Name)
+				}
+				return candidates, nil
+			}), cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// DeployRequestCompletion completes the database and deploy request number
+// arguments.
+func DeployRequestCompletion(ch *Helper) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			return completeDatabases(ch, cmd), cobra.ShellCompDirectiveNoFileComp
+		case 1:
+			org := completionOrg(ch)
+			database := args[0]
+			return complete(ch, cmd, "deploy-requests."+org+"."+database, func(ctx context.Context, client *ps.Client) ([]string, error) {
+				drs, err := client.DeployRequests.List(ctx, &ps.ListDeployRequestsRequest{
+					Organization: org,
+					Database:     database,
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				candidates := make([]string, 0, len(drs))
+				for _, dr := range drs {
+					if dr.State != "open" {
+						continue
+					}
+					candidates = append(candidates, fmt.Sprintf("%d\t%s → %s", dr.Number, dr.Branch, dr.IntoBranch))
+				}
+				return candidates, nil
+			}), cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// OrganizationCompletion completes the organization argument.
+func OrganizationCompletion(ch *Helper) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return completeOrgs(ch, cmd), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// OrganizationFlagCompletion completes the --org flag.
+func OrganizationFlagCompletion(ch *Helper) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeOrgs(ch, cmd), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func completeOrgs(ch *Helper, cmd *cobra.Command) []string {
+	return complete(ch, cmd, "orgs", func(ctx context.Context, client *ps.Client) ([]string, error) {
+		orgs, err := client.Organizations.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates := make([]string, 0, len(orgs))
+		for _, org := range orgs {
+			candidates = append(candidates, org.Name)
+		}
+		return candidates, nil
+	})
+}
+
+func completeDatabases(ch *Helper, cmd *cobra.Command) []string {
+	org := completionOrg(ch)
+	return complete(ch, cmd, "databases."+org, func(ctx context.Context, client *ps.Client) ([]string, error) {
+		databases, err := client.Databases.List(ctx, &ps.ListDatabasesRequest{
+			Organization: org,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		candidates := make([]string, 0, len(databases))
+		for _, db := range databases {
+			candidates = append(candidates, db.Name)
+		}
+		return candidates, nil
+	})
+}
+
+// completionOrg returns the organization to complete values of, from the
+// --org flag or the global config file.
+func completionOrg(ch *Helper) string {
+	if ch.Config.Organization != "" {
+		return ch.Config.Organization
+	}
+
+	cfg, err := ch.ConfigFS.DefaultConfig()
+	if err != nil {
+		return ""
+	}
+
+	return cfg.Organization
+}
+
+// complete returns the candidates cached under key if they're recent.
+// Otherwise they're fetched from the API and cached, falling back to the
+// stale cached candidates if the API can't be reached in time.
+func complete(ch *Helper, cmd *cobra.Command, key string, fetch completionFetcher) []string {
+	if p := config.ActiveProfile(); p != "" {
+		key = p + "." + key
+	}
+
+	cached, _ := readCompletion(key)
+	if cached != nil && time.Since(cached.FetchedAt) < completionTTL {
+		return cached.Candidates
+	}
+
+	stale := func() []string {
+		if cached == nil {
+			return nil
+		}
+		return cached.Candidates
+	}
+
+	client, err := ch.Client()
+	if err != nil {
+		return stale()
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, completionTimeout)
+	defer cancel()
+
+	candidates, err := fetch(ctx, client)
+	if err != nil {
+		return stale()
+	}
+
+	// caching is best-effort, the candidates are returned regardless
+	_ = writeCompletion(key, &completionEntry{FetchedAt: time.Now(), Candidates: candidates})
+	return candidates
+}
+
+func completionPath(key string) (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(key)
+	return path.Join(dir, completionDir, name+".json"), nil
+}
+
+func readCompletion(key string) (*completionEntry, error) {
+	p, err := completionPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &completionEntry{}
+	if err := json.Unmarshal(out, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func writeCompletion(key string, e *completionEntry) error {
+	p, err := completionPath(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(path.Dir(p), 0771); err != nil {
+		return err
+	}
+
+	out, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(p, out, 0600)
+}
@@ -0,0 +1,79 @@
+package cmdutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/planetscale/cli/internal/expr"
+
+	"github.com/spf13/cobra"
+)
+
+// ConditionFalseExitCode is the exit code of commands skipped because their
+// --if condition is false, so scripts can tell them from failures.
+const ConditionFalseExitCode = 3
+
+// ConditionFlag registers the --if flag of commands that can run
+// conditionally.
+func ConditionFlag(cmd *cobra.Command, cond *string) {
+	cmd.Flags().StringVar(cond, "if", "",
+		`Only run the command if the condition is true for the resource, such as 'ready && age>"30d"'. Exits with 3 if it's false`)
+}
+
+// CheckCondition returns an error with ConditionFalseExitCode if the --if
+// condition is false for the resource. The condition is evaluated against
+// the fields of the resource as returned by the API, with age and idle, the
+// durations since the resource was created and last updated.
+func CheckCondition(cond string, resource interface{}) error {
+	if cond == "" {
+		return nil
+	}
+
+	e, err := expr.Parse(cond)
+	if err != nil {
+		return fmt.Errorf("invalid --if condition: %s", err)
+	}
+
+	fields, err := conditionFields(resource, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ok, err := e.Eval(fields)
+	if err != nil {
+		return fmt.Errorf("can't evaluate the --if condition: %s", err)
+	}
+	if !ok {
+		return &Error{
+			Msg:      fmt.Sprintf("the condition %s is false, skipping", cond),
+			ExitCode: ConditionFalseExitCode,
+		}
+	}
+	return nil
+}
+
+// conditionFields returns the JSON fields of the resource, with the derived
+// age and idle fields.
+func conditionFields(resource interface{}, now time.Time) (map[string]interface{}, error) {
+	out, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(out, &fields); err != nil {
+		return nil, err
+	}
+
+	for field, derived := range map[string]string{"created_at": "age", "updated_at": "idle"} {
+		s, ok := fields[field].(string)
+		if _, exists := fields[derived]; !ok || exists {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			fields[derived] = now.Sub(t)
+		}
+	}
+	return fields, nil
+}
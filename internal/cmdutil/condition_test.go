@@ -0,0 +1,32 @@
+package cmdutil
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+func TestCheckCondition(t *testing.T) {
+	c := qt.New(t)
+
+	branch := &ps.DatabaseBranch{
+		Name:      "dev",
+		Ready:     true,
+		CreatedAt: time.Now().Add(-60 * 24 * time.Hour),
+		UpdatedAt: time.Now().Add(-time.Hour),
+	}
+
+	c.Assert(CheckCondition("", branch), qt.IsNil)
+	c.Assert(CheckCondition(`ready && age>"30d"`, branch), qt.IsNil)
+
+	err := CheckCondition(`ready && idle>"30d"`, branch)
+	var cmdErr *Error
+	c.Assert(errors.As(err, &cmdErr), qt.IsTrue)
+	c.Assert(cmdErr.ExitCode, qt.Equals, ConditionFalseExitCode)
+
+	c.Assert(CheckCondition(`ready &&`, branch), qt.ErrorMatches, "invalid --if condition: unexpected end of condition")
+	c.Assert(CheckCondition(`state == "ready"`, branch), qt.ErrorMatches, `can't evaluate the --if condition: field "state" doesn't exist`)
+}
@@ -0,0 +1,30 @@
+package cmdutil
+
+import (
+	"context"
+
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+// AutoApproved returns whether an action on the branch is confirmed without
+// prompting, because --yes is passed or the active profile auto-approves
+// actions on non-production branches. The branch is only fetched if needed,
+// if it can't be fetched, the action isn't auto-approved.
+func AutoApproved(ctx context.Context, ch *Helper, client *ps.Client, database, branch string) bool {
+	if ch.Config.AssumeYes {
+		return true
+	}
+	if !ch.Config.AutoApprove {
+		return false
+	}
+
+	b, err := client.DatabaseBranches.Get(ctx, &ps.GetDatabaseBranchRequest{
+		Organization: ch.Config.Organization,
+		Database:     database,
+		Branch:       branch,
+	})
+	if err != nil {
+		return false
+	}
+	return ch.Config.AutoApproves(b.Production)
+}
@@ -0,0 +1,19 @@
+package cmdutil
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// DedupeWindowFlag registers the --dedupe-window flag of create commands.
+func DedupeWindowFlag(cmd *cobra.Command, window *time.Duration) {
+	cmd.Flags().DurationVar(window, "dedupe-window", 0,
+		"Skip the creation if a resource with the same name was created within this duration, i.e. when a CI job is retried")
+}
+
+// IsDuplicate returns whether a resource created at the given time is a
+// duplicate of a resource that is about to be created within the window.
+func IsDuplicate(createdAt time.Time, window time.Duration) bool {
+	return window > 0 && !createdAt.IsZero() && time.Since(createdAt) <= window
+}
@@ -0,0 +1,61 @@
+package cmdutil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/expr"
+
+	ps "github.com/planetscale/planetscale-go/planetscale"
+	exec "golang.org/x/sys/execabs"
+)
+
+// These are replaced in tests.
+var (
+	now           = time.Now
+	gitCommitTime = func(rev string) (time.Time, error) {
+		out, err := exec.Command("git", "log", "-1", "--format=%cI", rev).Output()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%q is neither a date, a duration nor a git revision", rev)
+		}
+		return time.Parse(time.RFC3339, strings.TrimSpace(string(out)))
+	}
+)
+
+// ParseSince returns the time of a --since flag, which is a date, a duration
+// such as 30d, or a git revision such as the tag of a release.
+func ParseSince(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if d, err := expr.ParseDuration(s); err == nil {
+		return now().Add(-d), nil
+	}
+	return gitCommitTime(s)
+}
+
+// Deployers returns the names of the users who queued the deployments of
+// deploy requests by their ID, according to the audit log. It's empty if the
+// audit log can't be read, i.e. without the access to it.
+func Deployers(ctx context.Context, client *ps.Client, org string) map[string]string {
+	logs, err := client.AuditLogs.List(ctx, &ps.ListAuditLogsRequest{
+		Organization: org,
+		Events:       []ps.AuditLogEvent{ps.AuditLogEventDeployRequestQueued},
+	})
+	if err != nil {
+		return map[string]string{}
+	}
+
+	// the audit log is sorted by the newest event first, the oldest one
+	// queued the deployment that completed
+	byID := make(map[string]string, len(logs))
+	for _, l := range logs {
+		byID[l.AuditableID] = l.ActorDisplayName
+	}
+	return byID
+}
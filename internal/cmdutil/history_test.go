@@ -0,0 +1,56 @@
+package cmdutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/mock"
+
+	qt "github.com/frankban/quicktest"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+func TestParseSince(t *testing.T) {
+	c := qt.New(t)
+
+	c.Patch(&now, func() time.Time { return time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC) })
+	c.Patch(&gitCommitTime, func(rev string) (time.Time, error) {
+		c.Assert(rev, qt.Equals, "v1.2.0")
+		return time.Date(2026, 8, 15, 0, 0, 0, 0, time.UTC), nil
+	})
+
+	got, err := ParseSince("2026-09-01T10:00:00Z")
+	c.Assert(err, qt.IsNil)
+	c.Assert(got, qt.Equals, time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC))
+
+	got, err = ParseSince("30d")
+	c.Assert(err, qt.IsNil)
+	c.Assert(got, qt.Equals, time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC))
+
+	got, err = ParseSince("v1.2.0")
+	c.Assert(err, qt.IsNil)
+	c.Assert(got, qt.Equals, time.Date(2026, 8, 15, 0, 0, 0, 0, time.UTC))
+}
+
+func TestDeployers(t *testing.T) {
+	c := qt.New(t)
+
+	logs := &mock.AuditLogService{
+		ListFn: func(ctx context.Context, req *ps.ListAuditLogsRequest) ([]*ps.AuditLog, error) {
+			c.Assert(req.Events, qt.DeepEquals, []ps.AuditLogEvent{ps.AuditLogEventDeployRequestQueued})
+			return []*ps.AuditLog{
+				{AuditableID: "dr2", ActorDisplayName: "John Doe"},
+				{AuditableID: "dr2", ActorDisplayName: "Jane Doe"},
+			}, nil
+		},
+	}
+	c.Assert(Deployers(context.Background(), &ps.Client{AuditLogs: logs}, "planetscale"), qt.DeepEquals,
+		map[string]string{"dr2": "Jane Doe"})
+
+	logs.ListFn = func(ctx context.Context, req *ps.ListAuditLogsRequest) ([]*ps.AuditLog, error) {
+		return nil, errors.New("forbidden")
+	}
+	c.Assert(Deployers(context.Background(), &ps.Client{AuditLogs: logs}, "planetscale"), qt.DeepEquals, map[string]string{})
+}
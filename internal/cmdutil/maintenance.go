@@ -0,0 +1,40 @@
+package cmdutil
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/planetscale/cli/internal/printer"
+
+	"github.com/spf13/cobra"
+)
+
+// MaintenanceFlag registers the --override flag of commands checked against
+// the maintenance windows.
+func MaintenanceFlag(cmd *cobra.Command, override *bool) {
+	cmd.Flags().BoolVar(override, "override", false,
+		"Run the command outside the maintenance windows or inside a change freeze of the database")
+}
+
+// CheckMaintenance returns an error if the maintenance windows of the
+// database are enforced and don't allow changes now. If they aren't
+// enforced, or with override, a warning is printed instead.
+func CheckMaintenance(ch *Helper, database string, override bool) error {
+	m := ch.Config.MaintenanceFor(database)
+	if m == nil {
+		return nil
+	}
+
+	err := m.Check(time.Now())
+	switch {
+	case err == nil:
+		return nil
+	case m.Enforce && !override:
+		return fmt.Errorf("changes to database %s are not allowed now: %s (run with --override to proceed anyway)",
+			printer.BoldBlue(database), err)
+	}
+
+	ch.Printer.Printf("%s changes to database %s are not expected now: %s.\n",
+		printer.BoldRed("Warning:"), printer.BoldBlue(database), err)
+	return nil
+}
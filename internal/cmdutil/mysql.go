@@ -0,0 +1,24 @@
+package cmdutil
+
+import (
+	"math"
+	"strings"
+)
+
+// MySQLPort is the port of direct connections to PlanetScale.
+const MySQLPort = "3306"
+
+// QuoteIdent quotes a MySQL identifier, i.e. a table or column name, with
+// backticks.
+func QuoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// Percentile returns the nearest-rank percentile of the sorted values.
+func Percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,24 @@
+package cmdutil
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestQuoteIdent(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert(QuoteIdent("users"), qt.Equals, "`users`")
+	c.Assert(QuoteIdent("we`ird"), qt.Equals, "`we``ird`")
+}
+
+func TestPercentile(t *testing.T) {
+	c := qt.New(t)
+
+	sorted := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	c.Assert(Percentile(sorted, 0), qt.Equals, 1.0)
+	c.Assert(Percentile(sorted, 50), qt.Equals, 5.0)
+	c.Assert(Percentile(sorted, 95), qt.Equals, 10.0)
+	c.Assert(Percentile([]float64{42}, 99), qt.Equals, 42.0)
+}
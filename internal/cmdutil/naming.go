@@ -0,0 +1,26 @@
+package cmdutil
+
+import (
+	"github.com/planetscale/cli/internal/naming"
+)
+
+// ResourceName validates the name against the naming convention of the given
+// kind of resource ("database", "branch" or "password"). If name is empty a
+// name is generated from the configured template.
+func ResourceName(ch *Helper, kind, name, database string) (string, error) {
+	rule := ch.Config.Naming.Rule(kind)
+
+	if name == "" {
+		var err error
+		name, err = naming.Generate(rule, database)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if err := naming.Validate(rule, name); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
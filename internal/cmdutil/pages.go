@@ -0,0 +1,154 @@
+package cmdutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/planetscale/cli/internal/transport"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// DefaultPageSize is the number of items fetched per page of a list.
+	DefaultPageSize = 100
+
+	// pageWorkers is the number of pages fetched concurrently.
+	pageWorkers = 4
+
+	// maxPages guards against APIs ignoring the requested page, which
+	// would return the same full page forever.
+	maxPages = 1000
+)
+
+// Pages configures how the pages of a list are fetched.
+type Pages struct {
+	// Size is the number of items per page.
+	Size int
+	// Limit is the maximum number of items fetched, 0 fetches all.
+	Limit int
+}
+
+// PageFlags registers the --limit and --page-size flags of list commands.
+func PageFlags(cmd *cobra.Command, p *Pages) {
+	cmd.Flags().IntVar(&p.Limit, "limit", 0,
+		"List at most this many items, 0 lists all of them")
+	cmd.Flags().IntVar(&p.Size, "page-size", DefaultPageSize,
+		"Number of items fetched per API request, pages are fetched concurrently")
+}
+
+// FetchPages calls fetch with the pages of a list, until the API reports
+// there's no next page or the limit is reached. Without the pagination
+// metadata of the API, the list ends with the first page that isn't full.
+// The first page is fetched on its own, as most lists fit on it, the others
+// concurrently. fetch is called with a context requesting the page from the
+// API and returns the number of items on it.
+//
+// FetchPages returns the number of pages whose items make up the list, in
+// order from page 1. fetch may have been called with later pages, their
+// items have to be discarded.
+func FetchPages(ctx context.Context, p Pages, fetch func(ctx context.Context, page int) (int, error)) (int, error) {
+	if p.Size <= 0 {
+		return 0, errors.New("--page-size must be positive")
+	}
+	if p.Limit < 0 {
+		return 0, errors.New("--limit can't be negative")
+	}
+
+	lastPage := maxPages
+	if p.Limit > 0 {
+		lastPage = (p.Limit + p.Size - 1) / p.Size
+	}
+
+	// get fetches the page and returns whether it's the last one. A page
+	// with more items than requested means the API returned the whole
+	// list at once.
+	get := func(ctx context.Context, page int) (int, bool, error) {
+		info := &transport.PageInfo{}
+		n, err := fetch(transport.WithPage(ctx, transport.Page{Number: page, Size: p.Size, Info: info}), page)
+		if info.Known {
+			return n, !info.Next, err
+		}
+		return n, n != p.Size, err
+	}
+
+	n, end, err := get(ctx, 1)
+	if err != nil {
+		return 0, err
+	}
+	// the API may return fewer items per page than requested
+	if !end && n > 0 && n < p.Size && p.Limit > 0 {
+		lastPage = (p.Limit + n - 1) / n
+	}
+	if end || lastPage == 1 {
+		return 1, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		next     = 2
+		last     = lastPage
+		full     = true // whether no page up to last ended the list
+		firstErr error
+		errPage  int
+	)
+
+	// take returns the next page to fetch, or false once it's past the end
+	take := func() (int, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if firstErr != nil || next > last {
+			return 0, false
+		}
+		page := next
+		next++
+		return page, true
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < pageWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				page, ok := take()
+				if !ok {
+					return
+				}
+
+				_, end, err := get(ctx, page)
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					// the pages fetched concurrently fail with the
+					// cancellation of the first error
+					if firstErr == nil {
+						firstErr, errPage = err, page
+						cancel()
+					}
+				case end && page <= last:
+					last, full = page, false
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// errors of pages past the end of the list don't matter
+	if firstErr != nil && errPage <= last {
+		return 0, firstErr
+	}
+	if full && p.Limit == 0 {
+		return 0, fmt.Errorf("the list has more than %d pages, the API probably ignores the requested page", maxPages)
+	}
+	return last, nil
+}
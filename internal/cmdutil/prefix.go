@@ -0,0 +1,292 @@
+package cmdutil
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/planetscale/cli/internal/printer"
+
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	"github.com/AlecAivazis/survey/v2"
+)
+
+// prefixCandidate is a resource which can be referred to by a unique prefix
+// of any of its keys, such as its name or ID.
+type prefixCandidate struct {
+	// value is what a reference to the resource resolves to.
+	value string
+	keys  []string
+	// label describes the resource when a reference is ambiguous.
+	label string
+}
+
+// ResolveBranch returns the name of the branch of the database that ref is
+// the name of, or a unique prefix of. If ref is ambiguous, the user picks
+// the branch on terminals, otherwise the matching branches are listed in the
+// returned error. References matching no branch are returned as they are, so
+// the command reports the branch doesn't exist.
+func ResolveBranch(ctx context.Context, client *ps.Client, org, database, ref string) (string, error) {
+	return resolveBranch(ctx, client, org, database, ref, false)
+}
+
+// ConfirmBranch resolves ref like ResolveBranch for destructive commands: a
+// prefix only resolves to a branch once the user confirms it on a terminal,
+// otherwise the full name is required.
+func ConfirmBranch(ctx context.Context, client *ps.Client, org, database, ref string) (string, error) {
+	return resolveBranch(ctx, client, org, database, ref, true)
+}
+
+func resolveBranch(ctx context.Context, client *ps.Client, org, database, ref string, confirm bool) (string, error) {
+	// full names don't need the list of branches
+	_, err := client.DatabaseBranches.Get(ctx, &ps.GetDatabaseBranchRequest{
+		Organization: org,
+		Database:     database,
+		Branch:       ref,
+	})
+	if err == nil {
+		return ref, nil
+	}
+	if ErrCode(err) != ps.ErrNotFound {
+		return "", HandleError(err)
+	}
+
+	candidates, err := fetchCandidates(ctx, func(ctx context.Context) ([]*prefixCandidate, error) {
+		branches, err := client.DatabaseBranches.List(ctx, &ps.ListDatabaseBranchesRequest{
+			Organization: org,
+			Database:     database,
+		})
+
+		candidates := make([]*prefixCandidate, 0, len(branches))
+		for _, b := range branches {
+			candidates = append(candidates, &prefixCandidate{value: b.Name, keys: []string{b.Name}, label: b.Name})
+		}
+		return candidates, err
+	})
+	if err != nil {
+		switch ErrCode(err) {
+		case ps.ErrNotFound:
+			return "", fmt.Errorf("database %s does not exist in organization %s",
+				printer.BoldBlue(database), printer.BoldBlue(org))
+		default:
+			return "", HandleError(err)
+		}
+	}
+
+	return resolvePrefix("branch", ref, candidates, confirm)
+}
+
+// ResolvePassword returns the ID of the password of the branch that ref is
+// the name or ID of, or a unique prefix of either, just like ResolveBranch.
+func ResolvePassword(ctx context.Context, client *ps.Client, org, database, branch, ref string) (string, error) {
+	return resolvePassword(ctx, client, org, database, branch, ref, false)
+}
+
+// ConfirmPassword resolves ref like ResolvePassword, but requires the
+// confirmation of prefixes just like ConfirmBranch.
+func ConfirmPassword(ctx context.Context, client *ps.Client, org, database, branch, ref string) (string, error) {
+	return resolvePassword(ctx, client, org, database, branch, ref, true)
+}
+
+func resolvePassword(ctx context.Context, client *ps.Client, org, database, branch, ref string, confirm bool) (string, error) {
+	// full IDs don't need the list of passwords
+	_, err := client.Passwords.Get(ctx, &ps.GetDatabaseBranchPasswordRequest{
+		Organization: org,
+		Database:     database,
+		Branch:       branch,
+		PasswordId:   ref,
+	})
+	if err == nil {
+		return ref, nil
+	}
+	if ErrCode(err) != ps.ErrNotFound {
+		return "", HandleError(err)
+	}
+
+	candidates, err := fetchCandidates(ctx, func(ctx context.Context) ([]*prefixCandidate, error) {
+		passwords, err := client.Passwords.List(ctx, &ps.ListDatabaseBranchPasswordRequest{
+			Organization: org,
+			Database:     database,
+			Branch:       branch,
+		})
+
+		candidates := make([]*prefixCandidate, 0, len(passwords))
+		for _, p := range passwords {
+			candidates = append(candidates, &prefixCandidate{
+				value: p.PublicID,
+				keys:  []string{p.PublicID, p.Name},
+				label: fmt.Sprintf("%s (%s)", p.Name, p.PublicID),
+			})
+		}
+		return candidates, err
+	})
+	if err != nil {
+		switch ErrCode(err) {
+		case ps.ErrNotFound:
+			return "", fmt.Errorf("database %s or branch %s does not exist in organization %s",
+				printer.BoldBlue(database), printer.BoldBlue(branch), printer.BoldBlue(org))
+		default:
+			return "", HandleError(err)
+		}
+	}
+
+	return resolvePrefix("password", ref, candidates, confirm)
+}
+
+// ResolveDeployRequest returns the number of the deploy request of the
+// database that ref is the number of. Otherwise ref is resolved as the ID or
+// the branch of a deploy request, or a unique prefix of either, just like
+// ResolveBranch.
+func ResolveDeployRequest(ctx context.Context, client *ps.Client, org, database, ref string) (uint64, error) {
+	return resolveDeployRequest(ctx, client, org, database, ref, false)
+}
+
+// ConfirmDeployRequest resolves ref like ResolveDeployRequest, but requires
+// the confirmation of prefixes just like ConfirmBranch.
+func ConfirmDeployRequest(ctx context.Context, client *ps.Client, org, database, ref string) (uint64, error) {
+	return resolveDeployRequest(ctx, client, org, database, ref, true)
+}
+
+func resolveDeployRequest(ctx context.Context, client *ps.Client, org, database, ref string, confirm bool) (uint64, error) {
+	if n, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		return n, nil
+	}
+
+	// deploy requests can only be fetched by their number, so IDs and
+	// branches are looked up in the list
+	candidates, err := fetchCandidates(ctx, func(ctx context.Context) ([]*prefixCandidate, error) {
+		drs, err := client.DeployRequests.List(ctx, &ps.ListDeployRequestsRequest{
+			Organization: org,
+			Database:     database,
+		})
+
+		candidates := make([]*prefixCandidate, 0, len(drs))
+		for _, dr := range drs {
+			candidates = append(candidates, &prefixCandidate{
+				value: strconv.FormatUint(dr.Number, 10),
+				keys:  []string{dr.ID, dr.Branch},
+				label: fmt.Sprintf("#%d %s (%s)", dr.Number, dr.Branch, dr.State),
+			})
+		}
+		return candidates, err
+	})
+	if err != nil {
+		switch ErrCode(err) {
+		case ps.ErrNotFound:
+			return 0, fmt.Errorf("database %s does not exist in organization %s",
+				printer.BoldBlue(database), printer.BoldBlue(org))
+		default:
+			return 0, HandleError(err)
+		}
+	}
+
+	number, err := resolvePrefix("deploy request", ref, candidates, confirm)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := strconv.ParseUint(number, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("the argument <number> is invalid: no deploy request of %s has the number, ID or branch %q",
+			printer.BoldBlue(database), ref)
+	}
+	return n, nil
+}
+
+// fetchCandidates returns the candidates of all pages of a list, see
+// FetchPages. list returns the candidates of the page requested with ctx.
+func fetchCandidates(ctx context.Context, list func(ctx context.Context) ([]*prefixCandidate, error)) ([]*prefixCandidate, error) {
+	var mu sync.Mutex
+	byPage := make(map[int][]*prefixCandidate)
+	n, err := FetchPages(ctx, Pages{Size: DefaultPageSize}, func(ctx context.Context, page int) (int, error) {
+		candidates, err := list(ctx)
+		mu.Lock()
+		byPage[page] = candidates
+		mu.Unlock()
+		return len(candidates), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*prefixCandidate
+	for page := 1; page <= n; page++ {
+		candidates = append(candidates, byPage[page]...)
+	}
+	return candidates, nil
+}
+
+// resolvePrefix returns the value of the candidate with a key equal to ref,
+// or of the only candidate with a key starting with ref. If confirm is set,
+// the only candidate is returned once the user confirms it.
+func resolvePrefix(kind, ref string, candidates []*prefixCandidate, confirm bool) (string, error) {
+	var matches []*prefixCandidate
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		for _, key := range c.keys {
+			if key == ref {
+				return c.value, nil
+			}
+			if key != "" && strings.HasPrefix(key, ref) && !seen[c.value] {
+				seen[c.value] = true
+				matches = append(matches, c)
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return ref, nil
+	case 1:
+		if confirm {
+			return confirmPrefix(kind, ref, matches[0])
+		}
+		return matches[0].value, nil
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].label < matches[j].label })
+	labels := make([]string, 0, len(matches))
+	for _, m := range matches {
+		labels = append(labels, m.label)
+	}
+
+	if !printer.IsTTY {
+		return "", fmt.Errorf("%q matches more than one %s, use a longer prefix: %s",
+			ref, kind, strings.Join(labels, ", "))
+	}
+
+	var picked int
+	err := survey.AskOne(&survey.Select{
+		Message: fmt.Sprintf("%q matches more than one %s, select one:", ref, kind),
+		Options: labels,
+		VimMode: true,
+	}, &picked)
+	if err != nil {
+		return "", err
+	}
+	return matches[picked].value, nil
+}
+
+// confirmPrefix returns the value of the candidate ref is a prefix of, if the
+// user confirms it.
+func confirmPrefix(kind, ref string, match *prefixCandidate) (string, error) {
+	if !printer.IsTTY {
+		return "", fmt.Errorf("%q is only a prefix of %s %s, use its full name", ref, kind, match.label)
+	}
+
+	var confirmed bool
+	err := survey.AskOne(&survey.Confirm{
+		Message: fmt.Sprintf("%q is a prefix of %s %s, continue with it?", ref, kind, match.label),
+	}, &confirmed)
+	if err != nil {
+		return "", err
+	}
+	if !confirmed {
+		return "", fmt.Errorf("%s %s wasn't confirmed, use its full name", kind, match.label)
+	}
+	return match.value, nil
+}
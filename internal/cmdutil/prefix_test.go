@@ -0,0 +1,120 @@
+package cmdutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/planetscale/cli/internal/mock"
+	"github.com/planetscale/cli/internal/printer"
+	"github.com/planetscale/cli/internal/transport"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestResolvePrefix(t *testing.T) {
+	candidates := []*prefixCandidate{
+		{value: "1", keys: []string{"abc123", "add-users"}, label: "#1 add-users"},
+		{value: "2", keys: []string{"abd456", "add-orders"}, label: "#2 add-orders"},
+		{value: "3", keys: []string{"xyz789", "ab"}, label: "#3 ab"},
+	}
+
+	tests := []struct {
+		ref  string
+		want string
+		err  string
+	}{
+		{ref: "ab", want: "3"},
+		{ref: "abc", want: "1"},
+		{ref: "add-o", want: "2"},
+		{ref: "xy", want: "3"},
+		{ref: "missing", want: "missing"},
+		{ref: "add", err: `"add" matches more than one deploy request, use a longer prefix: #1 add-users, #2 add-orders`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			c := qt.New(t)
+
+			got, err := resolvePrefix("deploy request", tt.ref, candidates, false)
+			if tt.err != "" {
+				c.Assert(err, qt.ErrorMatches, tt.err)
+				return
+			}
+			c.Assert(err, qt.IsNil)
+			c.Assert(got, qt.Equals, tt.want)
+		})
+	}
+}
+
+func TestResolvePrefix_Confirm(t *testing.T) {
+	c := qt.New(t)
+	c.Patch(&printer.IsTTY, false)
+
+	candidates := []*prefixCandidate{
+		{value: "main", keys: []string{"main"}, label: "main"},
+		{value: "dev", keys: []string{"dev"}, label: "dev"},
+	}
+
+	got, err := resolvePrefix("branch", "main", candidates, true)
+	c.Assert(err, qt.IsNil)
+	c.Assert(got, qt.Equals, "main")
+
+	_, err = resolvePrefix("branch", "ma", candidates, true)
+	c.Assert(err, qt.ErrorMatches, `"ma" is only a prefix of branch main, use its full name`)
+}
+
+func TestResolveBranch_ListError(t *testing.T) {
+	c := qt.New(t)
+
+	client := &ps.Client{
+		DatabaseBranches: &mock.DatabaseBranchesService{
+			GetFn: func(ctx context.Context, req *ps.GetDatabaseBranchRequest) (*ps.DatabaseBranch, error) {
+				return nil, &ps.Error{Code: ps.ErrNotFound}
+			},
+			ListFn: func(ctx context.Context, req *ps.ListDatabaseBranchesRequest) ([]*ps.DatabaseBranch, error) {
+				return nil, errors.New("connection refused")
+			},
+		},
+	}
+
+	_, err := ResolveBranch(context.Background(), client, "planetscale", "mydb", "dev")
+	c.Assert(err, qt.ErrorMatches, "connection refused")
+}
+
+func TestResolveBranch(t *testing.T) {
+	c := qt.New(t)
+
+	// the second page holds the only match of the prefix
+	pages := map[int][]*ps.DatabaseBranch{2: {{Name: "feature-login"}}}
+	for i := 0; i < DefaultPageSize; i++ {
+		pages[1] = append(pages[1], &ps.DatabaseBranch{Name: fmt.Sprintf("branch-%d", i)})
+	}
+
+	svc := &mock.DatabaseBranchesService{
+		GetFn: func(ctx context.Context, req *ps.GetDatabaseBranchRequest) (*ps.DatabaseBranch, error) {
+			if req.Branch == "branch-1" {
+				return &ps.DatabaseBranch{Name: req.Branch}, nil
+			}
+			return nil, &ps.Error{Code: ps.ErrNotFound}
+		},
+		ListFn: func(ctx context.Context, req *ps.ListDatabaseBranchesRequest) ([]*ps.DatabaseBranch, error) {
+			page, ok := transport.PageFromContext(ctx)
+			c.Assert(ok, qt.IsTrue)
+			return pages[page.Number], nil
+		},
+	}
+	client := &ps.Client{DatabaseBranches: svc}
+
+	// full names are fetched on their own
+	branch, err := ResolveBranch(context.Background(), client, "planetscale", "mydb", "branch-1")
+	c.Assert(err, qt.IsNil)
+	c.Assert(branch, qt.Equals, "branch-1")
+	c.Assert(svc.ListFnInvoked, qt.IsFalse)
+
+	branch, err = ResolveBranch(context.Background(), client, "planetscale", "mydb", "feat")
+	c.Assert(err, qt.IsNil)
+	c.Assert(branch, qt.Equals, "feature-login")
+}
@@ -0,0 +1,34 @@
+package cmdutil
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestPostWebhook(t *testing.T) {
+	c := qt.New(t)
+
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Header.Get("Content-Type"), qt.Equals, "application/json")
+		c.Check(json.NewDecoder(r.Body).Decode(&got), qt.IsNil)
+	}))
+	defer srv.Close()
+
+	err := PostWebhook(context.Background(), srv.URL, map[string]interface{}{"branch": "main"})
+	c.Assert(err, qt.IsNil)
+	c.Assert(got, qt.DeepEquals, map[string]interface{}{"branch": "main"})
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	err = PostWebhook(context.Background(), srv.URL, nil)
+	c.Assert(err, qt.ErrorMatches, `the webhook responded with 500 Internal Server Error`)
+}
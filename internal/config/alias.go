@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Alias is a named target, such as "analytics", used as @analytics in place
+// of the database and branch arguments of commands.
+type Alias struct {
+	Organization string `yaml:"org,omitempty" json:"org,omitempty"`
+	Database     string `yaml:"database" json:"database"`
+	Branch       string `yaml:"branch,omitempty" json:"branch,omitempty"`
+
+	// Role is the role of the credentials of the commands with a --role
+	// flag, such as 'pscale connect'.
+	Role string `yaml:"role,omitempty" json:"role,omitempty"`
+}
+
+// Alias returns the alias with the given name.
+func (f *FileConfig) Alias(name string) (*Alias, error) {
+	if a, ok := f.Aliases[name]; ok && a != nil {
+		return a, nil
+	}
+
+	names := make([]string, 0, len(f.Aliases))
+	for n := range f.Aliases {
+		names = append(names, "@"+n)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("alias @%s doesn't exist, no aliases are defined", name)
+	}
+
+	sort.Strings(names)
+	return nil, fmt.Errorf("alias @%s doesn't exist, available aliases are: %s", name, strings.Join(names, ", "))
+}
+
+var aliasArg = regexp.MustCompile(`^@[\w-]+$`)
+
+// ExpandAlias replaces the first argument referring to an alias, such as
+// @analytics, with the database and branch of the alias, looked up with
+// lookup. Arguments following "--" are left as they are. The alias is nil if
+// no argument refers to one.
+func ExpandAlias(args []string, lookup func(name string) (*Alias, error)) ([]string, *Alias, error) {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !aliasArg.MatchString(arg) {
+			continue
+		}
+
+		a, err := lookup(arg[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		expanded := make([]string, 0, len(args)+1)
+		expanded = append(expanded, args[:i]...)
+		expanded = append(expanded, a.Database)
+		if a.Branch != "" {
+			expanded = append(expanded, a.Branch)
+		}
+		expanded = append(expanded, args[i+1:]...)
+		return expanded, a, nil
+	}
+	return args, nil, nil
+}
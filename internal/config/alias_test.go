@@ -0,0 +1,62 @@
+package config
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestExpandAlias(t *testing.T) {
+	cfg := &FileConfig{
+		Aliases: map[string]*Alias{
+			"analytics": {Organization: "acme", Database: "warehouse", Branch: "reporting", Role: "reader"},
+			"app":       {Database: "app"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expanded []string
+		alias    *Alias
+		err      string
+	}{
+		{
+			name:     "database and branch",
+			args:     []string{"connect", "@analytics", "--port", "3309"},
+			expanded: []string{"connect", "warehouse", "reporting", "--port", "3309"},
+			alias:    cfg.Aliases["analytics"],
+		},
+		{
+			name:     "database only",
+			args:     []string{"branch", "create", "@app", "feature"},
+			expanded: []string{"branch", "create", "app", "feature"},
+			alias:    cfg.Aliases["app"],
+		},
+		{
+			name:     "no alias",
+			args:     []string{"shell", "mydb", "main", "--", "@analytics"},
+			expanded: []string{"shell", "mydb", "main", "--", "@analytics"},
+		},
+		{
+			name: "unknown",
+			args: []string{"connect", "@nope"},
+			err:  "alias @nope doesn't exist, available aliases are: @analytics, @app",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+
+			expanded, alias, err := ExpandAlias(tt.args, cfg.Alias)
+			if tt.err != "" {
+				c.Assert(err, qt.ErrorMatches, tt.err)
+				return
+			}
+			c.Assert(err, qt.IsNil)
+			c.Assert(expanded, qt.DeepEquals, tt.expanded)
+			c.Assert(alias, qt.Equals, tt.alias)
+		})
+	}
+}
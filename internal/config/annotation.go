@@ -0,0 +1,123 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const annotationsName = "annotations.yml"
+
+// Annotation is a free-form note with links, such as ticket URLs, attached to
+// a branch or a deploy request. Annotations are only stored locally, the API
+// has no field for them.
+type Annotation struct {
+	Note      string    `yaml:"note,omitempty" json:"note,omitempty"`
+	Links     []string  `yaml:"links,omitempty" json:"links,omitempty"`
+	UpdatedAt time.Time `yaml:"updated-at" json:"updated_at"`
+}
+
+// String returns the note followed by the links of the annotation.
+func (a *Annotation) String() string {
+	if a == nil {
+		return ""
+	}
+
+	parts := make([]string, 0, len(a.Links)+1)
+	if a.Note != "" {
+		parts = append(parts, a.Note)
+	}
+	parts = append(parts, a.Links...)
+	return strings.Join(parts, " ")
+}
+
+// Annotations are all annotations, keyed by the resource they belong to.
+type Annotations struct {
+	Branches       map[string]*Annotation `yaml:"branches,omitempty"`
+	DeployRequests map[string]*Annotation `yaml:"deploy-requests,omitempty"`
+}
+
+// BranchKey returns the key of a branch in Annotations.Branches.
+func BranchKey(org, database, branch string) string {
+	return fmt.Sprintf("%s/%s/%s", org, database, branch)
+}
+
+// DeployRequestKey returns the key of a deploy request in
+// Annotations.DeployRequests.
+func DeployRequestKey(org, database string, number uint64) string {
+	return fmt.Sprintf("%s/%s/%d", org, database, number)
+}
+
+// AnnotationsPath returns the path of the annotations file.
+func AnnotationsPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, annotationsName), nil
+}
+
+// Annotations reads the annotations file. A missing file, or a nil ConfigFS,
+// returns empty annotations.
+func (c *ConfigFS) Annotations() (*Annotations, error) {
+	a := &Annotations{
+		Branches:       make(map[string]*Annotation),
+		DeployRequests: make(map[string]*Annotation),
+	}
+
+	if c == nil {
+		return a, nil
+	}
+
+	p, err := AnnotationsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := fs.ReadFile(c.fsys, p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return a, nil
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(out, a); err != nil {
+		return nil, fmt.Errorf("can't unmarshal file %q: %s", p, err)
+	}
+
+	if a.Branches == nil {
+		a.Branches = make(map[string]*Annotation)
+	}
+	if a.DeployRequests == nil {
+		a.DeployRequests = make(map[string]*Annotation)
+	}
+
+	return a, nil
+}
+
+// Write persists the annotations to the annotations file.
+func (a *Annotations) Write() error {
+	p, err := AnnotationsPath()
+	if err != nil {
+		return err
+	}
+
+	if err := ensureConfigDir(); err != nil {
+		return err
+	}
+
+	out, err := yaml.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("can't marshal annotations: %s", err)
+	}
+
+	return ioutil.WriteFile(p, out, 0644)
+}
@@ -0,0 +1,17 @@
+package config
+
+import "time"
+
+// Approval configures two-person approval of production-affecting commands.
+type Approval struct {
+	// Required enables the approval of production-affecting commands.
+	Required bool `yaml:"required,omitempty" json:"required,omitempty"`
+
+	// Approvers are the base64 encoded public keys of the operators that
+	// may approve requests, as shown by 'pscale approve --show-key'. If
+	// empty, no request is approved.
+	Approvers []string `yaml:"approvers,omitempty" json:"approvers,omitempty"`
+
+	// Expiry is how long an approval request is valid, 24h by default.
+	Expiry time.Duration `yaml:"expiry,omitempty" json:"expiry,omitempty"`
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const backfillsDir = "backfills"
+
+// ErrNoBackfillRun is returned if a backfill run doesn't exist.
+var ErrNoBackfillRun = errors.New("backfill run does not exist")
+
+// BackfillRun is the checkpoint of a chunked backfill. It's saved after every
+// batch, so an interrupted backfill continues with the next batch.
+type BackfillRun struct {
+	ID           string    `json:"id"`
+	Organization string    `json:"org"`
+	Database     string    `json:"database"`
+	Branch       string    `json:"branch"`
+	SQL          string    `json:"sql"`
+	StartedAt    time.Time `json:"started_at"`
+
+	// Start and End are the range of keys backfilled, Next the first key of
+	// the next batch.
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	Next  int64 `json:"next"`
+
+	// Rows is the number of rows affected so far.
+	Rows int64 `json:"rows"`
+}
+
+// BackfillID returns the ID of the backfill of the statement on the branch,
+// so running the same backfill again continues from its checkpoint.
+func BackfillID(org, database, branch, sql string) string {
+	sum := sha256.Sum256([]byte(sql))
+	return strings.Join([]string{org, database, branch, hex.EncodeToString(sum[:6])}, ".")
+}
+
+// BackfillsPath returns the directory the checkpoints of backfills are stored
+// in.
+func BackfillsPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, backfillsDir), nil
+}
+
+// ReadBackfillRun returns the run with the given ID.
+func ReadBackfillRun(id string) (*BackfillRun, error) {
+	dir, err := BackfillsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	p := path.Join(dir, id+".json")
+	out, err := ioutil.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoBackfillRun
+		}
+		return nil, err
+	}
+
+	var run BackfillRun
+	if err := json.Unmarshal(out, &run); err != nil {
+		return nil, fmt.Errorf("can't unmarshal file %q: %s", p, err)
+	}
+	return &run, nil
+}
+
+// Save persists the run.
+func (r *BackfillRun) Save() error {
+	dir, err := BackfillsPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0771); err != nil {
+		return fmt.Errorf("error creating backfills directory: %s", err)
+	}
+
+	out, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("can't marshal backfill run: %s", err)
+	}
+
+	return ioutil.WriteFile(path.Join(dir, r.ID+".json"), out, 0644)
+}
+
+// Remove deletes the persisted state of the run.
+func (r *BackfillRun) Remove() error {
+	dir, err := BackfillsPath()
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path.Join(dir, r.ID+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -3,20 +3,19 @@ package config
 import (
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"log"
+	"net/http"
 	"os"
 	"path"
-	"strings"
+	"path/filepath"
+	"time"
 
-	ps "github.com/planetscale/planetscale-go/planetscale"
+	"github.com/planetscale/cli/internal/transport"
 
-	"github.com/mitchellh/go-homedir"
-	exec "golang.org/x/sys/execabs"
+	ps "github.com/planetscale/planetscale-go/planetscale"
 )
 
 const (
-	defaultConfigPath = "~/.config/planetscale"
+	legacyConfigPath  = "~/.config/planetscale"
 	projectConfigName = ".pscale.yml"
 	configName        = "pscale.yml"
 	TokenFileMode     = 0600
@@ -28,73 +27,232 @@ type Config struct {
 	BaseURL      string
 	Organization string
 
+	// Token is the stored token of the logged in user, which is refreshed
+	// with RefreshToken before it expires. It's read by LoadToken.
+	Token        *Token
+	RefreshToken TokenRefresher
+
+	readToken   func() (string, error)
+	tokenLoaded bool
+	tokenErr    error
+
 	ServiceTokenID string
 	ServiceToken   string
 
+	// CredentialSource fetches the service token at runtime if no service
+	// token or access token is passed explicitly.
+	CredentialSource CredentialSource
+
 	// Project Configuration
 	Database string
 	Branch   string
+
+	// ProtectedBranches are branches of the active organization that can't
+	// be deleted.
+	ProtectedBranches []string
+
+	// Plan, Pricing and Budget of the active organization are used for cost
+	// estimates, Limits for showing the plan limits.
+	Plan    string
+	Pricing *Pricing
+	Budget  float64
+	Limits  *Limits
+
+	// Naming holds the naming conventions of the active organization.
+	Naming *Naming
+
+	// Approval configures two-person approval in the active organization.
+	Approval *Approval
+
+	// Maintenance are the maintenance windows of the databases of the
+	// active organization, by database name or "*" for all of them.
+	Maintenance map[string]*Maintenance
+
+	// AssumeYes skips all confirmation prompts, it's set with --yes.
+	// AutoApprove skips the prompts of actions on non-production branches,
+	// see the "auto-approve" key of profiles.
+	AssumeYes   bool
+	AutoApprove bool
+
+	// Timeouts and retries of API requests, see the "timeouts" and
+	// "retries" keys.
+	ReadTimeout   time.Duration
+	MutateTimeout time.Duration
+	MaxRetries    int
+	RetryBackoff  time.Duration
+	RetryMaxWait  time.Duration
+
+	// Limiter limits the API requests of all clients, see the
+	// "max-concurrent-requests" and "max-requests-per-minute" keys. It's
+	// nil if they aren't limited.
+	Limiter *transport.Limiter
+
+	// TraceHeader is injected into all API requests to correlate them with
+	// the caller.
+	TraceHeader string
+
+	// ObserveResponse is called with every API response.
+	ObserveResponse func(*http.Response)
+
+	// Transport sends the API requests, HTTPTransport is used if it's nil.
+	// Programs embedding the CLI can set it to send the requests their own
+	// way, i.e. to record or replay them.
+	Transport http.RoundTripper
+
+	// transportWrappers wrap Transport, see WrapTransport.
+	transportWrappers []func(http.RoundTripper) http.RoundTripper
+
+	// Mirror is set in air-gapped mode.
+	Mirror *Mirror
+
+	// DNS overrides the resolution of hostnames, if set.
+	DNS *DNS
 }
 
 func New() (*Config, error) {
-	var accessToken []byte
-	tokenPath, err := AccessTokenPath()
+	return &Config{
+		BaseURL:   ps.DefaultBaseURL,
+		readToken: readStoredToken,
+	}, nil
+}
+
+// readStoredToken returns the token stored in the token store of the
+// machine.
+func readStoredToken() (string, error) {
+	store, err := NewTokenStore()
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	return store.Read()
+}
+
+// LoadToken reads the stored token of the logged in user, unless a token
+// was passed explicitly. It's read on first use only, as reading it may
+// prompt for the passphrase of the encrypted token store.
+func (c *Config) LoadToken() error {
+	if c.tokenLoaded || c.AccessToken != "" || c.readToken == nil {
+		return c.tokenErr
 	}
+	c.tokenLoaded = true
 
-	stat, err := os.Stat(tokenPath)
+	stored, err := c.readToken()
 	if err != nil {
-		if !os.IsNotExist(err) {
-			log.Fatal(err)
-		}
-	} else {
-		if stat.Mode()&^TokenFileMode != 0 {
-			err = os.Chmod(tokenPath, TokenFileMode)
-			if err != nil {
-				log.Printf("Unable to change %v file mode to 0%o: %v", tokenPath, TokenFileMode, err)
-			}
-		}
-		accessToken, err = ioutil.ReadFile(tokenPath)
-		if err != nil {
-			log.Fatal(err)
-		}
+		c.tokenErr = err
+		return err
 	}
 
-	return &Config{
-		AccessToken: string(accessToken),
-		BaseURL:     ps.DefaultBaseURL,
-	}, nil
+	c.Token = DecodeToken(stored)
+	c.AccessToken = c.Token.AccessToken
+	return nil
 }
 
 func (c *Config) IsAuthenticated() bool {
-	return (c.ServiceToken != "" && c.ServiceTokenID != "") || c.AccessToken != ""
+	if c.ServiceToken == "" || c.ServiceTokenID == "" {
+		_ = c.LoadToken()
+	}
+	return (c.ServiceToken != "" && c.ServiceTokenID != "") || c.CredentialSource != nil || c.AccessToken != ""
+}
+
+// IsProtectedBranch returns whether the given branch is protected in the
+// active organization.
+func (c *Config) IsProtectedBranch(branch string) bool {
+	for _, b := range c.ProtectedBranches {
+		if b == branch {
+			return true
+		}
+	}
+	return false
+}
+
+// AutoApproves returns whether actions on a production or non-production
+// branch are confirmed without prompting.
+func (c *Config) AutoApproves(production bool) bool {
+	return c.AssumeYes || c.AutoApprove && !production
+}
+
+// WrapTransport wraps the transport of API requests, i.e. to add custom
+// authentication, caching or logging. Wrappers see each retry of a request
+// and the ones added later wrap the earlier ones. The retries, timeouts and
+// authentication of the client wrap all of them.
+func (c *Config) WrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
+	c.transportWrappers = append(c.transportWrappers, wrap)
 }
 
 // NewClientFromConfig creates a PlaentScale API client from our configuration
 func (c *Config) NewClientFromConfig(clientOpts ...ps.ClientOption) (*ps.Client, error) {
+	base := c.Transport
+	if base == nil {
+		base = c.HTTPTransport()
+	}
+
+	// the wrappers see the requests as they're sent, so every retry is seen
+	for _, wrap := range c.transportWrappers {
+		base = wrap(base)
+	}
+	if c.TraceHeader != "" {
+		base = transport.Trace(base, c.TraceHeader)
+	}
+	// every retry is sent within the budget
+	if c.Limiter != nil {
+		base = c.Limiter.Wrap(base)
+	}
+
+	var rt http.RoundTripper = transport.New(base, transport.Options{
+		ReadTimeout:   c.ReadTimeout,
+		MutateTimeout: c.MutateTimeout,
+		MaxRetries:    c.MaxRetries,
+		Backoff:       c.RetryBackoff,
+		MaxBackoff:    c.RetryMaxWait,
+		Observe:       c.ObserveResponse,
+	})
+
 	opts := []ps.ClientOption{
 		ps.WithBaseURL(c.BaseURL),
 	}
 
-	if c.ServiceToken != "" && c.ServiceTokenID != "" {
-		opts = append(opts, ps.WithServiceToken(c.ServiceTokenID, c.ServiceToken))
-	} else {
-		opts = append(opts, ps.WithAccessToken(c.AccessToken))
+	if (c.ServiceToken == "" || c.ServiceTokenID == "") && c.CredentialSource != nil && !c.explicitToken() {
+		id, token, err := c.CredentialSource.ServiceToken()
+		if err != nil {
+			return nil, fmt.Errorf("couldn't fetch service token from %s: %s", c.CredentialSource.Name(), err)
+		}
+
+		c.ServiceTokenID, c.ServiceToken = id, token
+	}
+
+	if c.ServiceToken == "" || c.ServiceTokenID == "" {
+		if err := c.LoadToken(); err != nil {
+			return nil, err
+		}
+	}
+
+	// the HTTP client has to be set before the authentication options, as
+	// they wrap its transport
+	switch {
+	case c.ServiceToken != "" && c.ServiceTokenID != "":
+		opts = append(opts, ps.WithHTTPClient(&http.Client{Transport: rt}),
+			ps.WithServiceToken(c.ServiceTokenID, c.ServiceToken))
+	case c.refreshesToken():
+		opts = append(opts, ps.WithHTTPClient(&http.Client{Transport: c.refreshingTransport(rt)}))
+	default:
+		opts = append(opts, ps.WithHTTPClient(&http.Client{Transport: rt}),
+			ps.WithAccessToken(c.AccessToken))
 	}
 	opts = append(opts, clientOpts...)
 
 	return ps.NewClient(opts...)
 }
 
-// ConfigDir is the directory for PlanetScale config.
-func ConfigDir() (string, error) {
-	dir, err := homedir.Expand(defaultConfigPath)
-	if err != nil {
-		return "", fmt.Errorf("can't expand path %q: %s", defaultConfigPath, err)
-	}
+// explicitToken reports whether an access token was passed explicitly, i.e.
+// with --api-token, rather than read from the token store.
+func (c *Config) explicitToken() bool {
+	return c.AccessToken != "" && !c.tokenLoaded
+}
 
-	return dir, nil
+// refreshesToken reports whether the stored token of the user is used and
+// can be refreshed. Tokens passed with --api-token are used as they are.
+func (c *Config) refreshesToken() bool {
+	return c.RefreshToken != nil && c.Token != nil && c.Token.RefreshToken != "" &&
+		c.AccessToken != "" && c.Token.AccessToken == c.AccessToken
 }
 
 // AccessTokenPath is the path for the access token file
@@ -104,27 +262,73 @@ func AccessTokenPath() (string, error) {
 		return "", err
 	}
 
-	return path.Join(dir, "access-token"), nil
+	return path.Join(dir, profileName("access-token")), nil
 }
 
-// ProjectConfigPath returns the path of a configuration inside a Git
-// repository.
+// ProjectConfigPath returns the path of the project configuration, which is
+// the nearest .pscale.yml in the working directory or its parents. Without
+// one, it's the path a new configuration is written to: the root of the Git
+// repository, or the working directory outside of one.
 func ProjectConfigPath() (string, error) {
-	basePath, err := RootGitRepoDir()
-	if err == nil {
-		return path.Join(basePath, projectConfigName), nil
+	wd, err := os.Getwd()
+	if err != nil {
+		return projectConfigName, nil
+	}
+
+	return projectConfigPath(wd, fileExists), nil
+}
+
+func projectConfigPath(dir string, exists func(path string) bool) string {
+	if p, ok := findUp(dir, projectConfigName, exists); ok {
+		return p
 	}
-	return path.Join("", projectConfigName), nil
+	if root, err := RootGitRepoDir(); err == nil {
+		return filepath.Join(root, projectConfigName)
+	}
+	return projectConfigName
 }
 
+// RootGitRepoDir returns the root directory of the Git repository of the
+// working directory. It's found without git, which is only run if there's no
+// .git in the working directory or its parents, i.e. with GIT_DIR set.
 func RootGitRepoDir() (string, error) {
-	var tl = []string{"rev-parse", "--show-toplevel"}
-	out, err := exec.Command("git", tl...).CombinedOutput()
+	wd, err := os.Getwd()
 	if err != nil {
+		return "", err
+	}
+
+	// .git is a file in worktrees and submodules
+	if p, ok := findUp(wd, ".git", fileExists); ok {
+		return filepath.Dir(p), nil
+	}
+
+	root, err := gitCommand("rev-parse", "--show-toplevel")
+	if err != nil || root == "" {
 		return "", errors.New("unable to find git root directory")
 	}
+	return root, nil
+}
+
+// findUp returns the path of name in dir or in the nearest of its parents
+// it exists in.
+func findUp(dir, name string, exists func(path string) bool) (string, bool) {
+	for {
+		p := filepath.Join(dir, name)
+		if exists(p) {
+			return p, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
 
-	return string(strings.TrimSuffix(string(out), "\n")), nil
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
 
 func ProjectConfigFile() string {
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestProjectConfigPath(t *testing.T) {
+	c := qt.New(t)
+
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	c.Assert(err, qt.IsNil)
+	sub := filepath.Join(root, "services", "api")
+	c.Assert(os.MkdirAll(sub, 0755), qt.IsNil)
+
+	// a worktree, where .git is a file
+	c.Assert(ioutil.WriteFile(filepath.Join(root, ".git"), []byte("gitdir: /elsewhere\n"), 0644), qt.IsNil)
+
+	wd, err := os.Getwd()
+	c.Assert(err, qt.IsNil)
+	c.Assert(os.Chdir(sub), qt.IsNil)
+	defer os.Chdir(wd) // nolint: errcheck
+
+	gitRoot, err := RootGitRepoDir()
+	c.Assert(err, qt.IsNil)
+	c.Assert(gitRoot, qt.Equals, root)
+
+	// without a config, new ones are written to the root of the repository
+	p, err := ProjectConfigPath()
+	c.Assert(err, qt.IsNil)
+	c.Assert(p, qt.Equals, filepath.Join(root, projectConfigName))
+
+	// the nearest config is used
+	servicesConfig := filepath.Join(root, "services", projectConfigName)
+	c.Assert(ioutil.WriteFile(servicesConfig, []byte("org: acme\n"), 0644), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(root, projectConfigName), []byte("org: other\n"), 0644), qt.IsNil)
+
+	p, err = ProjectConfigPath()
+	c.Assert(err, qt.IsNil)
+	c.Assert(p, qt.Equals, servicesConfig)
+}
+
+func TestFileConfig_Environment(t *testing.T) {
+	c := qt.New(t)
+
+	cfg := &FileConfig{
+		Organization: "acme",
+		Environments: map[string]*Environment{
+			"staging":    {Database: "app", Branch: "staging"},
+			"production": {Database: "app", Branch: "main", Organization: "acme-prod"},
+		},
+	}
+
+	env, err := cfg.Environment("production")
+	c.Assert(err, qt.IsNil)
+	c.Assert(env, qt.DeepEquals, &Environment{Database: "app", Branch: "main", Organization: "acme-prod"})
+
+	_, err = cfg.Environment("dev")
+	c.Assert(err, qt.ErrorMatches, `environment "dev" doesn't exist, available environments are: production, staging`)
+
+	_, err = (&FileConfig{}).Environment("dev")
+	c.Assert(err, qt.ErrorMatches, `environment "dev" doesn't exist, no environments are defined in .pscale.yml`)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestNewClientFromConfig_Transport(t *testing.T) {
+	c := qt.New(t)
+
+	var seen []string
+	cfg := &Config{
+		BaseURL:     "https://api.example.com",
+		AccessToken: "token",
+		TraceHeader: "ci-1234",
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			seen = append(seen, "transport "+req.Header.Get("Authorization")+" "+req.Header.Get("X-Correlation-Id"))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       ioutil.NopCloser(strings.NewReader(`{"data":[]}`)),
+				Request:    req,
+			}, nil
+		}),
+	}
+
+	for _, name := range []string{"inner", "outer"} {
+		name := name
+		cfg.WrapTransport(func(rt http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				seen = append(seen, name)
+				return rt.RoundTrip(req)
+			})
+		})
+	}
+
+	client, err := cfg.NewClientFromConfig()
+	c.Assert(err, qt.IsNil)
+
+	_, err = client.Organizations.List(context.Background())
+	c.Assert(err, qt.IsNil)
+	c.Assert(seen, qt.DeepEquals, []string{"outer", "inner", "transport Bearer token ci-1234"})
+}
+
+func TestConfig_LoadToken(t *testing.T) {
+	c := qt.New(t)
+
+	reads := 0
+	var readErr error
+	cfg := &Config{
+		BaseURL: "https://api.example.com",
+		readToken: func() (string, error) {
+			reads++
+			return `{"access_token":"stored","refresh_token":"refresh"}`, readErr
+		},
+	}
+
+	// the token is only read once it's needed
+	c.Assert(reads, qt.Equals, 0)
+	c.Assert(cfg.IsAuthenticated(), qt.IsTrue)
+	c.Assert(cfg.AccessToken, qt.Equals, "stored")
+	c.Assert(cfg.Token.RefreshToken, qt.Equals, "refresh")
+
+	_, err := cfg.NewClientFromConfig()
+	c.Assert(err, qt.IsNil)
+	c.Assert(reads, qt.Equals, 1)
+
+	// tokens passed explicitly don't read the stored token
+	cfg = &Config{AccessToken: "explicit", readToken: cfg.readToken}
+	c.Assert(cfg.LoadToken(), qt.IsNil)
+	c.Assert(cfg.AccessToken, qt.Equals, "explicit")
+	c.Assert(reads, qt.Equals, 1)
+
+	// the error of reading the token is returned by the client
+	readErr = errors.New("the access token is encrypted")
+	cfg = &Config{BaseURL: "https://api.example.com", readToken: cfg.readToken}
+	c.Assert(cfg.IsAuthenticated(), qt.IsFalse)
+	_, err = cfg.NewClientFromConfig()
+	c.Assert(err, qt.ErrorMatches, "the access token is encrypted")
+	c.Assert(reads, qt.Equals, 2)
+}
+
+// fakeSource is a credential source counting its fetches.
+type fakeSource struct{ fetches int }
+
+func (f *fakeSource) Name() string { return "fake" }
+
+func (f *fakeSource) ServiceToken() (string, string, error) {
+	f.fetches++
+	return "id", "secret", nil
+}
+
+func TestNewClientFromConfig_CredentialSource(t *testing.T) {
+	c := qt.New(t)
+
+	// the source is used over the stored token
+	source := &fakeSource{}
+	cfg := &Config{
+		BaseURL:          "https://api.example.com",
+		CredentialSource: source,
+		readToken: func() (string, error) {
+			return `{"access_token":"stored"}`, nil
+		},
+	}
+	c.Assert(cfg.IsAuthenticated(), qt.IsTrue)
+	_, err := cfg.NewClientFromConfig()
+	c.Assert(err, qt.IsNil)
+	c.Assert(source.fetches, qt.Equals, 1)
+	c.Assert(cfg.ServiceTokenID, qt.Equals, "id")
+	c.Assert(cfg.ServiceToken, qt.Equals, "secret")
+
+	// tokens passed explicitly are used as they are
+	source = &fakeSource{}
+	cfg = &Config{BaseURL: "https://api.example.com", AccessToken: "explicit", CredentialSource: source}
+	_, err = cfg.NewClientFromConfig()
+	c.Assert(err, qt.IsNil)
+	c.Assert(source.fetches, qt.Equals, 0)
+	c.Assert(cfg.ServiceToken, qt.Equals, "")
+
+	cfg = &Config{BaseURL: "https://api.example.com", ServiceTokenID: "flag-id", ServiceToken: "flag-secret", CredentialSource: source}
+	_, err = cfg.NewClientFromConfig()
+	c.Assert(err, qt.IsNil)
+	c.Assert(source.fetches, qt.Equals, 0)
+	c.Assert(cfg.ServiceTokenID, qt.Equals, "flag-id")
+}
+
+func TestFileConfig_CredentialSourceConfig(t *testing.T) {
+	c := qt.New(t)
+
+	top := &CredentialSourceConfig{Type: CredentialSourceBitwarden, Item: "pscale"}
+	work := &CredentialSourceConfig{Type: CredentialSourceBitwarden, Item: "pscale-work"}
+	cfg := &FileConfig{
+		CredentialSource: top,
+		Profiles: map[string]*Profile{
+			"work":     {CredentialSource: work},
+			"personal": {Organization: "me"},
+		},
+	}
+
+	c.Assert(cfg.CredentialSourceConfig(""), qt.Equals, top)
+	c.Assert(cfg.CredentialSourceConfig("work"), qt.Equals, work)
+	c.Assert(cfg.CredentialSourceConfig("personal"), qt.IsNil)
+	c.Assert(cfg.CredentialSourceConfig("missing"), qt.IsNil)
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	exec "golang.org/x/sys/execabs"
+)
+
+const (
+	// CredentialSourceOnePassword fetches the service token via the
+	// 1Password CLI ('op').
+	CredentialSourceOnePassword = "1password"
+
+	// CredentialSourceBitwarden fetches the service token via the Bitwarden
+	// CLI ('bw').
+	CredentialSourceBitwarden = "bitwarden"
+)
+
+// CredentialSource fetches the service token used to authenticate against the
+// PlanetScale API at runtime, so it never has to be stored on disk.
+type CredentialSource interface {
+	// Name returns a human readable name of the source.
+	Name() string
+
+	// ServiceToken returns the service token ID and the service token.
+	ServiceToken() (string, string, error)
+}
+
+// CredentialSourceConfig defines which credential source should be used and
+// where the service token is located.
+type CredentialSourceConfig struct {
+	// Type is the kind of the credential source, either "1password" or
+	// "bitwarden".
+	Type string `yaml:"type" json:"type"`
+
+	// ServiceTokenID and ServiceToken are 1Password secret references, i.e:
+	// "op://Engineering/pscale-ci/username".
+	ServiceTokenID string `yaml:"service-token-id,omitempty" json:"service-token-id,omitempty"`
+	ServiceToken   string `yaml:"service-token,omitempty" json:"service-token,omitempty"`
+
+	// Item is the Bitwarden item name or ID. The username of the item holds
+	// the service token ID and the password holds the service token.
+	Item string `yaml:"item,omitempty" json:"item,omitempty"`
+}
+
+// Source returns the CredentialSource for the configuration. A nil
+// CredentialSourceConfig returns a nil source.
+func (c *CredentialSourceConfig) Source() (CredentialSource, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	switch c.Type {
+	case CredentialSourceOnePassword:
+		if c.ServiceTokenID == "" || c.ServiceToken == "" {
+			return nil, errors.New("credential-source: 'service-token-id' and 'service-token' secret references are required for 1password")
+		}
+		return &onePasswordSource{idRef: c.ServiceTokenID, tokenRef: c.ServiceToken}, nil
+	case CredentialSourceBitwarden:
+		if c.Item == "" {
+			return nil, errors.New("credential-source: 'item' is required for bitwarden")
+		}
+		return &bitwardenSource{item: c.Item}, nil
+	default:
+		return nil, fmt.Errorf("credential-source: invalid type %q, allowed values are: %s, %s",
+			c.Type, CredentialSourceOnePassword, CredentialSourceBitwarden)
+	}
+}
+
+type onePasswordSource struct {
+	idRef    string
+	tokenRef string
+}
+
+func (o *onePasswordSource) Name() string { return CredentialSourceOnePassword }
+
+func (o *onePasswordSource) ServiceToken() (string, string, error) {
+	id, err := runCredentialHelper("op", "read", "--no-newline", o.idRef)
+	if err != nil {
+		return "", "", err
+	}
+
+	token, err := runCredentialHelper("op", "read", "--no-newline", o.tokenRef)
+	if err != nil {
+		return "", "", err
+	}
+
+	return id, token, nil
+}
+
+type bitwardenSource struct {
+	item string
+}
+
+func (b *bitwardenSource) Name() string { return CredentialSourceBitwarden }
+
+func (b *bitwardenSource) ServiceToken() (string, string, error) {
+	id, err := runCredentialHelper("bw", "get", "username", b.item)
+	if err != nil {
+		return "", "", err
+	}
+
+	token, err := runCredentialHelper("bw", "get", "password", b.item)
+	if err != nil {
+		return "", "", err
+	}
+
+	return id, token, nil
+}
+
+// credentialCommand runs a password manager CLI and returns its output. It's
+// replaced in tests.
+var credentialCommand = func(name string, args ...string) ([]byte, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// runCredentialHelper runs the given password manager CLI and returns its
+// trimmed output, which must be a single value.
+func runCredentialHelper(name string, args ...string) (string, error) {
+	stdout, err := credentialCommand(name, args...)
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", fmt.Errorf("couldn't find the %q command required by the configured credential source", name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %s", name, args[0], err)
+	}
+
+	out := strings.TrimSpace(string(stdout))
+	if out == "" {
+		return "", fmt.Errorf("%s %s returned an empty value", name, args[0])
+	}
+
+	// i.e. the fields of a whole item instead of one of them
+	if strings.ContainsAny(out, " \t\r\n") {
+		return "", fmt.Errorf("%s %s returned more than a single value", name, args[0])
+	}
+
+	return out, nil
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	exec "golang.org/x/sys/execabs"
+)
+
+// fakeCredentialCommand returns the output of the commands by their
+// arguments, or a missing command error for unknown commands.
+func fakeCredentialCommand(c *qt.C, outputs map[string]string) {
+	c.Patch(&credentialCommand, func(name string, args ...string) ([]byte, error) {
+		out, ok := outputs[name+" "+strings.Join(args, " ")]
+		if !ok {
+			return nil, &exec.Error{Name: name, Err: exec.ErrNotFound}
+		}
+		if strings.HasPrefix(out, "error: ") {
+			return nil, errors.New(out[len("error: "):])
+		}
+		return []byte(out), nil
+	})
+}
+
+func TestCredentialSource_OnePassword(t *testing.T) {
+	cfg := &CredentialSourceConfig{
+		Type:           CredentialSourceOnePassword,
+		ServiceTokenID: "op://Engineering/pscale-ci/username",
+		ServiceToken:   "op://Engineering/pscale-ci/password",
+	}
+
+	tests := []struct {
+		name      string
+		outputs   map[string]string
+		wantID    string
+		wantToken string
+		wantErr   string
+	}{
+		{
+			name: "success",
+			outputs: map[string]string{
+				"op read --no-newline op://Engineering/pscale-ci/username": "token-id",
+				"op read --no-newline op://Engineering/pscale-ci/password": "pscale_tkn_123\n",
+			},
+			wantID:    "token-id",
+			wantToken: "pscale_tkn_123",
+		},
+		{
+			name:    "missing binary",
+			wantErr: `couldn't find the "op" command required by the configured credential source`,
+		},
+		{
+			name: "failure",
+			outputs: map[string]string{
+				"op read --no-newline op://Engineering/pscale-ci/username": "error: exit status 1: [ERROR] could not read secret",
+			},
+			wantErr: `op read: exit status 1: \[ERROR\] could not read secret`,
+		},
+		{
+			name: "empty",
+			outputs: map[string]string{
+				"op read --no-newline op://Engineering/pscale-ci/username": "\n",
+			},
+			wantErr: "op read returned an empty value",
+		},
+		{
+			name: "malformed",
+			outputs: map[string]string{
+				"op read --no-newline op://Engineering/pscale-ci/username": "token-id",
+				"op read --no-newline op://Engineering/pscale-ci/password": "pscale_tkn_123\npscale_tkn_456",
+			},
+			wantErr: "op read returned more than a single value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+			fakeCredentialCommand(c, tt.outputs)
+
+			source, err := cfg.Source()
+			c.Assert(err, qt.IsNil)
+			c.Assert(source.Name(), qt.Equals, "1password")
+
+			id, token, err := source.ServiceToken()
+			if tt.wantErr != "" {
+				c.Assert(err, qt.ErrorMatches, tt.wantErr)
+				return
+			}
+			c.Assert(err, qt.IsNil)
+			c.Assert(id, qt.Equals, tt.wantID)
+			c.Assert(token, qt.Equals, tt.wantToken)
+		})
+	}
+}
+
+func TestCredentialSource_Bitwarden(t *testing.T) {
+	cfg := &CredentialSourceConfig{Type: CredentialSourceBitwarden, Item: "pscale-ci"}
+
+	tests := []struct {
+		name      string
+		outputs   map[string]string
+		wantID    string
+		wantToken string
+		wantErr   string
+	}{
+		{
+			name: "success",
+			outputs: map[string]string{
+				"bw get username pscale-ci": "token-id",
+				"bw get password pscale-ci": "pscale_tkn_123",
+			},
+			wantID:    "token-id",
+			wantToken: "pscale_tkn_123",
+		},
+		{
+			name:    "missing binary",
+			wantErr: `couldn't find the "bw" command required by the configured credential source`,
+		},
+		{
+			name: "locked vault",
+			outputs: map[string]string{
+				"bw get username pscale-ci": "error: exit status 1: You are not logged in.",
+			},
+			wantErr: "bw get: exit status 1: You are not logged in.",
+		},
+		{
+			name: "malformed",
+			outputs: map[string]string{
+				"bw get username pscale-ci": `{"object":"item", "name":"pscale-ci"}`,
+			},
+			wantErr: "bw get returned more than a single value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+			fakeCredentialCommand(c, tt.outputs)
+
+			source, err := cfg.Source()
+			c.Assert(err, qt.IsNil)
+			c.Assert(source.Name(), qt.Equals, "bitwarden")
+
+			id, token, err := source.ServiceToken()
+			if tt.wantErr != "" {
+				c.Assert(err, qt.ErrorMatches, tt.wantErr)
+				return
+			}
+			c.Assert(err, qt.IsNil)
+			c.Assert(id, qt.Equals, tt.wantID)
+			c.Assert(token, qt.Equals, tt.wantToken)
+		})
+	}
+}
+
+func TestCredentialSourceConfig_Source(t *testing.T) {
+	c := qt.New(t)
+
+	source, err := (*CredentialSourceConfig)(nil).Source()
+	c.Assert(err, qt.IsNil)
+	c.Assert(source, qt.IsNil)
+
+	_, err = (&CredentialSourceConfig{Type: CredentialSourceOnePassword}).Source()
+	c.Assert(err, qt.ErrorMatches, "credential-source: 'service-token-id' and 'service-token' secret references are required for 1password")
+
+	_, err = (&CredentialSourceConfig{Type: CredentialSourceBitwarden}).Source()
+	c.Assert(err, qt.ErrorMatches, "credential-source: 'item' is required for bitwarden")
+
+	_, err = (&CredentialSourceConfig{Type: "keychain"}).Source()
+	c.Assert(err, qt.ErrorMatches, `credential-source: invalid type "keychain", allowed values are: 1password, bitwarden`)
+}
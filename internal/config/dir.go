@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// configDirName is the name of the config directory in the platform's
+// directory for configuration.
+const configDirName = "planetscale"
+
+// ConfigDir is the directory for PlanetScale config: %AppData%\planetscale
+// on Windows, $XDG_CONFIG_HOME/planetscale if it's set and
+// ~/.config/planetscale otherwise. The config of older versions, which was
+// always in ~/.config/planetscale, is moved there on first use.
+func ConfigDir() (string, error) {
+	legacy, err := homedir.Expand(legacyConfigPath)
+	if err != nil {
+		return "", fmt.Errorf("can't expand path %q: %s", legacyConfigPath, err)
+	}
+
+	dir := platformConfigDir(runtime.GOOS, os.Getenv, legacy)
+	if dir == legacy {
+		return dir, nil
+	}
+
+	if err := migrateConfigDir(legacy, dir); err != nil {
+		// the config keeps working from where it is
+		return legacy, nil
+	}
+	return dir, nil
+}
+
+// platformConfigDir returns the config directory of the platform, legacy if
+// it has none.
+func platformConfigDir(goos string, getenv func(string) string, legacy string) string {
+	if goos == "windows" {
+		if appData := getenv("APPDATA"); appData != "" {
+			return filepath.Join(appData, configDirName)
+		}
+		return legacy
+	}
+
+	// relative paths are invalid and ignored, as per the XDG Base
+	// Directory Specification
+	if xdg := getenv("XDG_CONFIG_HOME"); xdg != "" && filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, configDirName)
+	}
+	return legacy
+}
+
+// migrateConfigDir moves the legacy config directory to dir, unless dir
+// already exists. The directory is moved as a whole, so the config and the
+// tokens are never split across both.
+func migrateConfigDir(legacy, dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	if _, err := os.Stat(legacy); os.IsNotExist(err) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0771); err != nil {
+		return err
+	}
+	return os.Rename(legacy, dir)
+}
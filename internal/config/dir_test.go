@@ -0,0 +1,79 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestPlatformConfigDir(t *testing.T) {
+	legacy := filepath.Join("/home/user", ".config", "planetscale")
+
+	tests := []struct {
+		name string
+		goos string
+		env  map[string]string
+		want string
+	}{
+		{"linux", "linux", nil, legacy},
+		{"xdg", "linux", map[string]string{"XDG_CONFIG_HOME": "/xdg"}, filepath.Join("/xdg", "planetscale")},
+		{"relative xdg", "darwin", map[string]string{"XDG_CONFIG_HOME": "xdg"}, legacy},
+		{"windows", "windows", map[string]string{"APPDATA": `C:\Users\user\AppData\Roaming`, "XDG_CONFIG_HOME": "/xdg"},
+			filepath.Join(`C:\Users\user\AppData\Roaming`, "planetscale")},
+		{"windows without appdata", "windows", nil, legacy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+
+			getenv := func(k string) string { return tt.env[k] }
+			c.Assert(platformConfigDir(tt.goos, getenv, legacy), qt.Equals, tt.want)
+		})
+	}
+}
+
+func TestConfigDir_Migrate(t *testing.T) {
+	c := qt.New(t)
+	home := testutil.TempHome(t)
+
+	legacy := filepath.Join(home, ".config", "planetscale")
+	c.Assert(os.MkdirAll(legacy, 0771), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(legacy, "pscale.yml"), []byte("org: planetscale\n"), 0600), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(legacy, "access-token"), []byte("token"), 0600), qt.IsNil)
+
+	platform := filepath.Join(home, "platform")
+	if runtime.GOOS == "windows" {
+		os.Setenv("APPDATA", platform)
+	} else {
+		os.Setenv("XDG_CONFIG_HOME", platform)
+	}
+
+	dir, err := ConfigDir()
+	c.Assert(err, qt.IsNil)
+	c.Assert(dir, qt.Equals, filepath.Join(platform, "planetscale"))
+
+	out, err := ioutil.ReadFile(filepath.Join(dir, "access-token"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, "token")
+	_, err = os.Stat(filepath.Join(dir, "pscale.yml"))
+	c.Assert(err, qt.IsNil)
+
+	_, err = os.Stat(legacy)
+	c.Assert(os.IsNotExist(err), qt.IsTrue)
+
+	// a legacy directory created later on, i.e. by an older version, is
+	// left alone
+	c.Assert(os.MkdirAll(legacy, 0771), qt.IsNil)
+	dir, err = ConfigDir()
+	c.Assert(err, qt.IsNil)
+	c.Assert(dir, qt.Equals, filepath.Join(platform, "planetscale"))
+	_, err = os.Stat(legacy)
+	c.Assert(err, qt.IsNil)
+}
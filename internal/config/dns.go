@@ -0,0 +1,127 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// DNS overrides how the hostnames of the PlanetScale services are resolved,
+// i.e. for split-horizon DNS or for testing against staging edges. It's
+// configured with the "dns" key and --resolve.
+type DNS struct {
+	// Overrides maps "host:port" to the "addr:port" connections are made to
+	// instead, the port "*" matches any port.
+	Overrides map[string]string
+
+	// Resolver is the "host:port" of the DNS server hostnames are resolved
+	// with instead of the system resolver.
+	Resolver string
+}
+
+// NewDNS returns the DNS overrides of the "host:port:addr" entries, as used
+// by curl's --resolve, and the resolver, or nil if there are none.
+func NewDNS(entries []string, resolver string) (*DNS, error) {
+	if len(entries) == 0 && resolver == "" {
+		return nil, nil
+	}
+
+	d := &DNS{Overrides: make(map[string]string)}
+	for _, e := range entries {
+		host, port, addr, err := parseResolve(e)
+		if err != nil {
+			return nil, err
+		}
+
+		target := addr
+		if port != "*" {
+			target = net.JoinHostPort(addr, port)
+		}
+		d.Overrides[net.JoinHostPort(strings.ToLower(host), port)] = target
+	}
+
+	if resolver != "" {
+		if _, _, err := net.SplitHostPort(resolver); err != nil {
+			resolver = net.JoinHostPort(resolver, "53")
+		}
+		d.Resolver = resolver
+	}
+
+	return d, nil
+}
+
+// parseResolve parses a "host:port:addr" entry. IPv6 addresses are
+// enclosed in brackets.
+func parseResolve(entry string) (host, port, addr string, err error) {
+	parts := strings.SplitN(entry, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("invalid DNS override %q, expected host:port:addr", entry)
+	}
+
+	host, port, addr = parts[0], parts[1], strings.TrimSuffix(strings.TrimPrefix(parts[2], "["), "]")
+	if net.ParseIP(addr) == nil {
+		return "", "", "", fmt.Errorf("invalid DNS override %q, %q isn't an IP address", entry, addr)
+	}
+	return host, port, addr, nil
+}
+
+// Lookup returns the address connections to addr are made to, or addr
+// itself if it isn't overridden. Hosts are resolved with the resolver, if
+// one is set.
+func (d *DNS) Lookup(ctx context.Context, addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	host = strings.ToLower(host)
+
+	if target, ok := d.Overrides[net.JoinHostPort(host, port)]; ok {
+		return target, nil
+	}
+	if ip, ok := d.Overrides[net.JoinHostPort(host, "*")]; ok {
+		return net.JoinHostPort(ip, port), nil
+	}
+
+	if d.Resolver == "" || net.ParseIP(host) != nil {
+		return addr, nil
+	}
+
+	ips, err := d.resolver().LookupIPAddr(ctx, host)
+	if err != nil {
+		return "", err
+	}
+	if len(ips) == 0 {
+		return "", fmt.Errorf("no addresses found for %s", host)
+	}
+	return net.JoinHostPort(ips[0].IP.String(), port), nil
+}
+
+func (d *DNS) resolver() *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, d.Resolver)
+		},
+	}
+}
+
+// DialContext connects to the address of Lookup. TLS connections still
+// verify the original host name, as it's the one requested.
+func (c *Config) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	if c.DNS != nil {
+		var err error
+		if addr, err = c.DNS.Lookup(ctx, addr); err != nil {
+			return nil, err
+		}
+	}
+
+	return dialer.DialContext(ctx, network, addr)
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestNewDNS(t *testing.T) {
+	c := qt.New(t)
+
+	dns, err := NewDNS(nil, "")
+	c.Assert(err, qt.IsNil)
+	c.Assert(dns, qt.IsNil)
+
+	dns, err = NewDNS([]string{
+		"aws.connect.psdb.cloud:3307:10.0.0.7",
+		"API.planetscale.com:*:[::1]",
+		"aws.connect.psdb.cloud:3307:10.0.0.8", // later entries take precedence
+	}, "10.0.0.53")
+	c.Assert(err, qt.IsNil)
+	c.Assert(dns.Resolver, qt.Equals, "10.0.0.53:53")
+
+	ctx := context.Background()
+	for addr, want := range map[string]string{
+		"aws.connect.psdb.cloud:3307": "10.0.0.8:3307",
+		"api.planetscale.com:443":     "[::1]:443",
+		"127.0.0.1:3306":              "127.0.0.1:3306",
+	} {
+		got, err := dns.Lookup(ctx, addr)
+		c.Assert(err, qt.IsNil)
+		c.Assert(got, qt.Equals, want, qt.Commentf(addr))
+	}
+
+	for _, entry := range []string{"host:3306", "host::10.0.0.1", "host:3306:db.internal"} {
+		_, err := NewDNS([]string{entry}, "")
+		c.Assert(err, qt.ErrorMatches, "invalid DNS override.*")
+	}
+}
+
+func TestConfig_DialContext(t *testing.T) {
+	c := qt.New(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, qt.IsNil)
+	defer l.Close()
+
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	c.Assert(err, qt.IsNil)
+
+	dns, err := NewDNS([]string{"edge.staging.invalid:" + port + ":127.0.0.1"}, "")
+	c.Assert(err, qt.IsNil)
+
+	cfg := &Config{DNS: dns}
+	conn, err := cfg.DialContext(context.Background(), "tcp", net.JoinHostPort("edge.staging.invalid", port))
+	c.Assert(err, qt.IsNil)
+	conn.Close()
+}
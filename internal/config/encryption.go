@@ -0,0 +1,131 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const encryptionsDir = "encryptions"
+
+// ErrNoEncryptionRun is returned if an encryption run doesn't exist.
+var ErrNoEncryptionRun = errors.New("encryption run does not exist")
+
+// EncryptionRun is the state of the encryption of a column. It's saved after
+// every step, and after every batch of the backfill, so an interrupted run
+// continues where it stopped.
+type EncryptionRun struct {
+	ID              string    `json:"id"`
+	Organization    string    `json:"org"`
+	Database        string    `json:"database"`
+	Branch          string    `json:"branch"`
+	Table           string    `json:"table"`
+	Column          string    `json:"column"`
+	EncryptedColumn string    `json:"encrypted_column"`
+	StartedAt       time.Time `json:"started_at"`
+
+	// Cursor is the primary key of the last row backfilled, Backfilled the
+	// number of rows backfilled.
+	Cursor     string `json:"cursor,omitempty"`
+	Backfilled int64  `json:"backfilled"`
+
+	// Done are the names of the finished steps.
+	Done []string `json:"done"`
+}
+
+// NewEncryptionRun returns a run with an ID derived from the column, so
+// encrypting the same column again continues the previous run.
+func NewEncryptionRun(org, database, branch, table, column, encrypted string) *EncryptionRun {
+	return &EncryptionRun{
+		ID:              strings.Join([]string{org, database, branch, table, column}, "."),
+		Organization:    org,
+		Database:        database,
+		Branch:          branch,
+		Table:           table,
+		Column:          column,
+		EncryptedColumn: encrypted,
+		StartedAt:       time.Now().UTC(),
+	}
+}
+
+// EncryptionsPath returns the directory the runs of column encryptions are
+// stored in.
+func EncryptionsPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, encryptionsDir), nil
+}
+
+// ReadEncryptionRun returns the run with the given ID.
+func ReadEncryptionRun(id string) (*EncryptionRun, error) {
+	dir, err := EncryptionsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	p := path.Join(dir, id+".json")
+	out, err := ioutil.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoEncryptionRun
+		}
+		return nil, err
+	}
+
+	var run EncryptionRun
+	if err := json.Unmarshal(out, &run); err != nil {
+		return nil, fmt.Errorf("can't unmarshal file %q: %s", p, err)
+	}
+	return &run, nil
+}
+
+// IsDone returns whether the step finished.
+func (r *EncryptionRun) IsDone(step string) bool {
+	for _, s := range r.Done {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// Save persists the run.
+func (r *EncryptionRun) Save() error {
+	dir, err := EncryptionsPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0771); err != nil {
+		return fmt.Errorf("error creating encryptions directory: %s", err)
+	}
+
+	out, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("can't marshal encryption run: %s", err)
+	}
+
+	return ioutil.WriteFile(path.Join(dir, r.ID+".json"), out, 0644)
+}
+
+// Remove deletes the persisted state of the run.
+func (r *EncryptionRun) Remove() error {
+	dir, err := EncryptionsPath()
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path.Join(dir, r.ID+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	exec "golang.org/x/sys/execabs"
+)
+
+// gitCommand runs git and returns its trimmed output. It's replaced in
+// tests.
+var gitCommand = func(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// GitBranch returns the current branch of the Git repository of the working
+// directory, or "HEAD" if no branch is checked out. The HEAD of the
+// repository is read directly, so it works without git installed, which is
+// only run if the repository can't be read, i.e. with GIT_DIR set.
+func GitBranch() (string, error) {
+	if os.Getenv("GIT_DIR") == "" {
+		if branch, err := readGitBranch(); err == nil {
+			return branch, nil
+		}
+	}
+
+	branch, err := gitCommand("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", errors.New("unable to find the current git branch")
+	}
+	return branch, nil
+}
+
+// readGitBranch reads the current branch from the HEAD of the repository.
+func readGitBranch() (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := gitDir(wd)
+	if err != nil {
+		return "", err
+	}
+
+	head, err := ioutil.ReadFile(filepath.Join(dir, "HEAD"))
+	if err != nil {
+		return "", err
+	}
+
+	ref := strings.TrimSpace(string(head))
+	if !strings.HasPrefix(ref, "ref: ") {
+		// a detached HEAD is a commit
+		return "HEAD", nil
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(ref, "ref: "), "refs/heads/"), nil
+}
+
+// gitDir returns the Git directory of the repository of dir. In worktrees
+// and submodules, .git is a file pointing to it.
+func gitDir(dir string) (string, error) {
+	p, ok := findUp(dir, ".git", fileExists)
+	if !ok {
+		return "", errors.New("unable to find git root directory")
+	}
+
+	fi, err := os.Stat(p)
+	if err != nil {
+		return "", err
+	}
+	if fi.IsDir() {
+		return p, nil
+	}
+
+	out, err := ioutil.ReadFile(p)
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(string(out))
+	if !strings.HasPrefix(line, "gitdir: ") {
+		return "", fmt.Errorf("%s doesn't point to a git directory", p)
+	}
+
+	gitdir := strings.TrimPrefix(line, "gitdir: ")
+	if !filepath.IsAbs(gitdir) {
+		gitdir = filepath.Join(filepath.Dir(p), gitdir)
+	}
+	return gitdir, nil
+}
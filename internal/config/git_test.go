@@ -0,0 +1,62 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestGitBranch(t *testing.T) {
+	c := qt.New(t)
+
+	var ran [][]string
+	c.Patch(&gitCommand, func(args ...string) (string, error) {
+		ran = append(ran, args)
+		return "", errors.New("git isn't installed")
+	})
+	c.Setenv("GIT_DIR", "")
+
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	c.Assert(err, qt.IsNil)
+	sub := filepath.Join(root, "app", "src")
+	c.Assert(os.MkdirAll(sub, 0755), qt.IsNil)
+
+	wd, err := os.Getwd()
+	c.Assert(err, qt.IsNil)
+	c.Assert(os.Chdir(sub), qt.IsNil)
+	defer os.Chdir(wd) // nolint: errcheck
+
+	_, err = GitBranch()
+	c.Assert(err, qt.ErrorMatches, "unable to find the current git branch")
+	_, err = RootGitRepoDir()
+	c.Assert(err, qt.ErrorMatches, "unable to find git root directory")
+	c.Assert(ran, qt.HasLen, 2)
+
+	gitDir := filepath.Join(root, ".git")
+	c.Assert(os.Mkdir(gitDir, 0755), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/feature/login\n"), 0644), qt.IsNil)
+
+	branch, err := GitBranch()
+	c.Assert(err, qt.IsNil)
+	c.Assert(branch, qt.Equals, "feature/login")
+
+	c.Assert(ioutil.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("8f3c2a1e\n"), 0644), qt.IsNil)
+	branch, err = GitBranch()
+	c.Assert(err, qt.IsNil)
+	c.Assert(branch, qt.Equals, "HEAD")
+
+	// a worktree, whose .git file points to its git directory
+	worktreeDir := filepath.Join(gitDir, "worktrees", "app")
+	c.Assert(os.MkdirAll(worktreeDir, 0755), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(worktreeDir, "HEAD"), []byte("ref: refs/heads/hotfix\n"), 0644), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(root, "app", ".git"), []byte("gitdir: ../.git/worktrees/app\n"), 0644), qt.IsNil)
+
+	branch, err = GitBranch()
+	c.Assert(err, qt.IsNil)
+	c.Assert(branch, qt.Equals, "hotfix")
+	c.Assert(ran, qt.HasLen, 2)
+}
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"io/fs"
 	"io/ioutil"
+	"os"
 	"path"
+	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 )
@@ -25,6 +28,111 @@ type FileConfig struct {
 	Organization string `yaml:"org" json:"org"`
 	Database     string `yaml:"database,omitempty" json:"database,omitempty"`
 	Branch       string `yaml:"branch,omitempty" json:"branch,omitempty"`
+
+	// SchemaDir is the directory of the .sql files defining the schema of
+	// the project, relative to the project root.
+	SchemaDir string `yaml:"schema-dir,omitempty" json:"schema-dir,omitempty"`
+
+	// Environments map the names selected with --env to the database and
+	// branch, and optionally the organization, they use.
+	Environments map[string]*Environment `yaml:"environments,omitempty" json:"environments,omitempty"`
+
+	// Aliases map the names used as @name in place of the database and
+	// branch arguments to their targets.
+	Aliases map[string]*Alias `yaml:"aliases,omitempty" json:"aliases,omitempty"`
+
+	// Watch are the queries checked for regressions by 'pscale insights
+	// watch'.
+	Watch []*WatchedQuery `yaml:"watch,omitempty" json:"watch,omitempty"`
+
+	// CredentialSource configures an external password manager to fetch the
+	// service token from. Profiles have their own, see Profile.
+	CredentialSource *CredentialSourceConfig `yaml:"credential-source,omitempty" json:"credential-source,omitempty"`
+
+	// Orgs contains defaults that are applied when the given organization
+	// is active.
+	Orgs map[string]*OrgDefaults `yaml:"orgs,omitempty" json:"orgs,omitempty"`
+
+	// CurrentProfile is the profile used if none is passed with --profile.
+	CurrentProfile string `yaml:"current-profile,omitempty" json:"current-profile,omitempty"`
+
+	// Profiles are the named accounts, see Profile.
+	Profiles map[string]*Profile `yaml:"profiles,omitempty" json:"profiles,omitempty"`
+}
+
+// OrgDefaults defines the conventions of an organization. They're merged
+// into the command flags whenever the organization is active.
+type OrgDefaults struct {
+	// Region is used for commands with a --region flag.
+	Region string `yaml:"region,omitempty" json:"region,omitempty"`
+
+	// Format is the default output format.
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+
+	// ProtectedBranches can't be deleted via the CLI.
+	ProtectedBranches []string `yaml:"protected-branches,omitempty" json:"protected-branches,omitempty"`
+
+	// Plan is the PlanetScale plan of the organization, used to estimate
+	// costs.
+	Plan string `yaml:"plan,omitempty" json:"plan,omitempty"`
+
+	// Pricing overrides the published pricing of the plan, i.e. for
+	// negotiated contracts.
+	Pricing *Pricing `yaml:"pricing,omitempty" json:"pricing,omitempty"`
+
+	// Limits overrides the published limits of the plan.
+	Limits *Limits `yaml:"limits,omitempty" json:"limits,omitempty"`
+
+	// Naming defines templates and validation rules for resource names.
+	Naming *Naming `yaml:"naming,omitempty" json:"naming,omitempty"`
+
+	// Budget is the monthly budget of the organization. Creating resources
+	// that would exceed it prints a warning.
+	Budget float64 `yaml:"budget,omitempty" json:"budget,omitempty"`
+
+	// Approval requires a second operator to approve production-affecting
+	// commands.
+	Approval *Approval `yaml:"approval,omitempty" json:"approval,omitempty"`
+
+	// Maintenance declares when deployments and destructive commands may
+	// run, by database name or "*" for all databases.
+	Maintenance map[string]*Maintenance `yaml:"maintenance,omitempty" json:"maintenance,omitempty"`
+}
+
+// Environment is a database and branch of a project, such as staging or
+// production. Empty fields fall back to the top-level fields of the file.
+type Environment struct {
+	Organization string `yaml:"org,omitempty" json:"org,omitempty"`
+	Database     string `yaml:"database,omitempty" json:"database,omitempty"`
+	Branch       string `yaml:"branch,omitempty" json:"branch,omitempty"`
+}
+
+// Environment returns the environment with the given name.
+func (f *FileConfig) Environment(name string) (*Environment, error) {
+	if env, ok := f.Environments[name]; ok && env != nil {
+		return env, nil
+	}
+
+	names := make([]string, 0, len(f.Environments))
+	for n := range f.Environments {
+		names = append(names, n)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("environment %q doesn't exist, no environments are defined in %s", name, projectConfigName)
+	}
+
+	sort.Strings(names)
+	return nil, fmt.Errorf("environment %q doesn't exist, available environments are: %s", name, strings.Join(names, ", "))
+}
+
+// OrgDefaults returns the defaults for the given organization or nil if none
+// are defined.
+func (f *FileConfig) OrgDefaults(org string) *OrgDefaults {
+	if f == nil || org == "" {
+		return nil
+	}
+
+	return f.Orgs[org]
 }
 
 // NewFileConfig reads the file config from the designated path and returns a
@@ -35,8 +143,12 @@ func (c *ConfigFS) NewFileConfig(path string) (*FileConfig, error) {
 		return nil, err
 	}
 
+	return parseFileConfig(path, out)
+}
+
+func parseFileConfig(path string, out []byte) (*FileConfig, error) {
 	var cfg FileConfig
-	err = yaml.Unmarshal(out, &cfg)
+	err := yaml.Unmarshal(out, &cfg)
 	if err != nil {
 		return nil, fmt.Errorf("can't unmarshal file %q: %s", path, err)
 	}
@@ -53,13 +165,47 @@ func (c *ConfigFS) DefaultConfig() (*FileConfig, error) {
 	return c.NewFileConfig(configFile)
 }
 
-// ProjectConfig returns the file config from the git project
+// ProjectConfig returns the file config of the project, the nearest
+// .pscale.yml in the working directory or its parents, with its variables
+// interpolated.
 func (c *ConfigFS) ProjectConfig() (*FileConfig, error) {
-	configFile, err := ProjectConfigPath()
+	configFile, out, err := c.readProjectConfig()
 	if err != nil {
 		return nil, err
 	}
-	return c.NewFileConfig(configFile)
+
+	out, err = InterpolateYAML(out)
+	if err != nil {
+		return nil, fmt.Errorf("can't interpolate file %q: %s", configFile, err)
+	}
+	return parseFileConfig(configFile, out)
+}
+
+// RawProjectConfig returns the file config of the project as it's written,
+// without interpolating its variables. Commands changing the file read it
+// with RawProjectConfig, so they don't write the values of the variables
+// back.
+func (c *ConfigFS) RawProjectConfig() (*FileConfig, error) {
+	configFile, out, err := c.readProjectConfig()
+	if err != nil {
+		return nil, err
+	}
+	return parseFileConfig(configFile, out)
+}
+
+func (c *ConfigFS) readProjectConfig() (string, []byte, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", nil, err
+	}
+
+	configFile := projectConfigPath(wd, func(p string) bool {
+		_, err := fs.Stat(c.fsys, p)
+		return err == nil
+	})
+
+	out, err := fs.ReadFile(c.fsys, configFile)
+	return configFile, out, err
 }
 
 // Write persists the file config at the designated path.
@@ -68,7 +214,7 @@ func (f *FileConfig) Write(path string) error {
 		return errors.New("path is empty")
 	}
 
-	if f.Organization == "" {
+	if f.Organization == "" && len(f.Profiles) == 0 {
 		return errors.New("fileconfig.Organization must be set")
 	}
 
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v2"
+)
+
+// variable matches the variables of project config values, such as
+// "${env:USER}" or "${git:branch}".
+var variable = regexp.MustCompile(`\$\{([a-z]+):([^}]*)\}`)
+
+// gitBranch is replaced in tests.
+var gitBranch = GitBranch
+
+// Interpolate replaces the variables of a project config value, so a single
+// committed file works for all developers and CI:
+//
+//	${env:VAR}    the value of the environment variable VAR, or an empty
+//	              string if it isn't set
+//	${git:branch} the current git branch
+func Interpolate(s string) (string, error) {
+	var err error
+	out := variable.ReplaceAllStringFunc(s, func(v string) string {
+		m := variable.FindStringSubmatch(v)
+		kind, name := m[1], m[2]
+
+		switch {
+		case kind == "env":
+			return os.Getenv(name)
+		case kind == "git" && name == "branch":
+			branch, gerr := gitBranch()
+			if gerr != nil && err == nil {
+				err = fmt.Errorf("can't interpolate %s: %s", v, gerr)
+			}
+			return branch
+		}
+
+		if err == nil {
+			err = fmt.Errorf("unknown variable %s, supported variables are ${env:VAR} and ${git:branch}", v)
+		}
+		return v
+	})
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// InterpolateYAML replaces the variables of the string values of a YAML
+// config file, see Interpolate.
+func InterpolateYAML(data []byte) ([]byte, error) {
+	if !variable.Match(data) {
+		return data, nil
+	}
+
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	raw, err := interpolateValue(raw)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(raw)
+}
+
+// Interpolate returns a copy of the layer with the variables of its values
+// replaced, see Interpolate.
+func (l *Layer) Interpolate() (*Layer, error) {
+	if l == nil {
+		return nil, nil
+	}
+
+	out := &Layer{
+		Source:  l.Source,
+		Origin:  l.Origin,
+		Values:  make(map[string]string, len(l.Values)),
+		origins: l.origins,
+	}
+	for k, v := range l.Values {
+		s, err := Interpolate(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", k, err)
+		}
+		out.Values[k] = s
+	}
+	return out, nil
+}
+
+func interpolateValue(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		return Interpolate(val)
+	case map[interface{}]interface{}:
+		for k, item := range val {
+			s, err := interpolateValue(item)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %s", k, err)
+			}
+			val[k] = s
+		}
+	case []interface{}:
+		for i, item := range val {
+			s, err := interpolateValue(item)
+			if err != nil {
+				return nil, err
+			}
+			val[i] = s
+		}
+	}
+	return v, nil
+}
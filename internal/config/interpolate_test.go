@@ -0,0 +1,91 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestInterpolate(t *testing.T) {
+	c := qt.New(t)
+
+	c.Setenv("PSCALE_TEST_USER", "jane")
+	c.Patch(&gitBranch, func() (string, error) { return "feature/login", nil })
+
+	tests := []struct {
+		in   string
+		want string
+		err  string
+	}{
+		{in: "main", want: "main"},
+		{in: "dev-${env:PSCALE_TEST_USER}", want: "dev-jane"},
+		{in: "${git:branch}", want: "feature/login"},
+		{in: "${env:PSCALE_TEST_UNSET}-${env:PSCALE_TEST_USER}", want: "-jane"},
+		{in: "${git:commit}", err: `unknown variable \${git:commit}.*`},
+		{in: "${vault:token}", err: `unknown variable \${vault:token}.*`},
+	}
+
+	for _, tt := range tests {
+		got, err := Interpolate(tt.in)
+		if tt.err != "" {
+			c.Assert(err, qt.ErrorMatches, tt.err)
+			continue
+		}
+		c.Assert(err, qt.IsNil)
+		c.Assert(got, qt.Equals, tt.want)
+	}
+
+	c.Patch(&gitBranch, func() (string, error) { return "", errors.New("unable to find the current git branch") })
+	_, err := Interpolate("${git:branch}")
+	c.Assert(err, qt.ErrorMatches, `can't interpolate \${git:branch}: unable to find the current git branch`)
+}
+
+func TestInterpolateYAML(t *testing.T) {
+	c := qt.New(t)
+
+	c.Setenv("PSCALE_TEST_USER", "jane")
+
+	out, err := InterpolateYAML([]byte(`org: acme
+branch: dev-${env:PSCALE_TEST_USER}
+environments:
+  ci:
+    branch: ci-${env:PSCALE_TEST_USER}
+`))
+	c.Assert(err, qt.IsNil)
+
+	cfg, err := parseFileConfig(".pscale.yml", out)
+	c.Assert(err, qt.IsNil)
+	c.Assert(cfg.Organization, qt.Equals, "acme")
+	c.Assert(cfg.Branch, qt.Equals, "dev-jane")
+	c.Assert(cfg.Environments["ci"].Branch, qt.Equals, "ci-jane")
+}
+
+func TestConfigFS_RawProjectConfig(t *testing.T) {
+	c := qt.New(t)
+
+	c.Setenv("PSCALE_TEST_USER", "jane")
+
+	dir := t.TempDir()
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, projectConfigName), []byte("org: acme\nbranch: ${env:PSCALE_TEST_USER}-dev\n"), 0644), qt.IsNil)
+	wd, err := os.Getwd()
+	c.Assert(err, qt.IsNil)
+	c.Assert(os.Chdir(dir), qt.IsNil)
+	defer os.Chdir(wd) // nolint: errcheck
+
+	cfs := NewConfigFS(testutil.OSFS{})
+
+	cfg, err := cfs.ProjectConfig()
+	c.Assert(err, qt.IsNil)
+	c.Assert(cfg.Branch, qt.Equals, "jane-dev")
+
+	// the file as it's written, for commands changing it
+	cfg, err = cfs.RawProjectConfig()
+	c.Assert(err, qt.IsNil)
+	c.Assert(cfg.Branch, qt.Equals, "${env:PSCALE_TEST_USER}-dev")
+}
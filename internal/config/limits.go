@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Limits describes the per database limits of a PlanetScale plan. A zero
+// value means unlimited.
+type Limits struct {
+	ProductionBranches  int     `yaml:"production-branches,omitempty" json:"production_branches"`
+	DevelopmentBranches int     `yaml:"development-branches,omitempty" json:"development_branches"`
+	StorageGB           float64 `yaml:"storage-gb,omitempty" json:"storage_gb"`
+	Connections         int     `yaml:"connections,omitempty" json:"connections"`
+}
+
+// PlansLimits contains the published limits of the PlanetScale plans. They
+// can be overridden per organization with the "limits" key.
+var PlansLimits = map[string]*Limits{
+	"hobby": {
+		ProductionBranches:  1,
+		DevelopmentBranches: 1,
+		StorageGB:           5,
+		Connections:         1000,
+	},
+	"scaler": {
+		ProductionBranches:  2,
+		DevelopmentBranches: 5,
+		Connections:         1000,
+	},
+	"team": {
+		ProductionBranches:  5,
+		DevelopmentBranches: 10,
+		Connections:         10000,
+	},
+}
+
+// PlanLimits returns the limits of the given plan. Limits configured for the
+// active organization take precedence over the published plans.
+func (c *Config) PlanLimits(plan string) (*Limits, error) {
+	if c.Limits != nil {
+		return c.Limits, nil
+	}
+
+	if plan == "" {
+		return nil, fmt.Errorf("no plan is configured for organization %q (set 'plan' for the organization in your config file or pass --plan)", c.Organization)
+	}
+
+	l, ok := PlansLimits[strings.ToLower(plan)]
+	if !ok {
+		return nil, fmt.Errorf("unknown plan %q, supported plans are: %s", plan, strings.Join(PlanNames(), ", "))
+	}
+
+	return l, nil
+}
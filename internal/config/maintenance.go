@@ -0,0 +1,262 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Maintenance configures when changes to a database may be made, such as
+// deployments and deletions.
+type Maintenance struct {
+	// Windows are the times changes are allowed in. Changes are allowed at
+	// any time if there are none.
+	Windows []*MaintenanceWindow `yaml:"windows,omitempty" json:"windows,omitempty"`
+
+	// Freezes are the times changes are forbidden in, such as a holiday
+	// change freeze. They take precedence over the windows.
+	Freezes []*MaintenanceWindow `yaml:"freezes,omitempty" json:"freezes,omitempty"`
+
+	// Enforce refuses changes outside the windows or inside a freeze,
+	// otherwise a warning is printed.
+	Enforce bool `yaml:"enforce,omitempty" json:"enforce,omitempty"`
+}
+
+// MaintenanceWindow is a recurring time of day on some days of the week,
+// optionally limited to a date range. Empty fields don't restrict the
+// window, so a freeze over the holidays only sets From and Until.
+type MaintenanceWindow struct {
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// Days are the days of the week, such as "sat" or "sunday".
+	Days []string `yaml:"days,omitempty" json:"days,omitempty"`
+
+	// Start and End are times of day as HH:MM. A window ending before it
+	// starts spans midnight.
+	Start string `yaml:"start,omitempty" json:"start,omitempty"`
+	End   string `yaml:"end,omitempty" json:"end,omitempty"`
+
+	// From and Until are the first and the last day of the window as
+	// YYYY-MM-DD.
+	From  string `yaml:"from,omitempty" json:"from,omitempty"`
+	Until string `yaml:"until,omitempty" json:"until,omitempty"`
+
+	// Timezone is the IANA name of the timezone of the window, UTC by
+	// default.
+	Timezone string `yaml:"timezone,omitempty" json:"timezone,omitempty"`
+}
+
+// MaintenanceFor returns the maintenance configuration of the database,
+// falling back to the one for all databases, "*".
+func (c *Config) MaintenanceFor(database string) *Maintenance {
+	if m, ok := c.Maintenance[database]; ok {
+		return m
+	}
+	return c.Maintenance["*"]
+}
+
+// Check returns an error describing why changes aren't allowed at t, or nil
+// if they are.
+func (m *Maintenance) Check(t time.Time) error {
+	for _, f := range m.Freezes {
+		in, err := f.Contains(t)
+		if err != nil {
+			return err
+		}
+		if in {
+			return fmt.Errorf("%s is inside the change freeze %s", t.Format(time.RFC3339), f)
+		}
+	}
+
+	if len(m.Windows) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(m.Windows))
+	for _, w := range m.Windows {
+		in, err := w.Contains(t)
+		if err != nil {
+			return err
+		}
+		if in {
+			return nil
+		}
+		names = append(names, w.String())
+	}
+	return fmt.Errorf("%s is outside the maintenance windows %s", t.Format(time.RFC3339), strings.Join(names, ", "))
+}
+
+// Contains returns whether t is inside the window.
+func (w *MaintenanceWindow) Contains(t time.Time) (bool, error) {
+	loc := time.UTC
+	if w.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(w.Timezone); err != nil {
+			return false, fmt.Errorf("invalid timezone of maintenance window %s: %s", w, err)
+		}
+	}
+	t = t.In(loc)
+
+	date := t.Format("2006-01-02")
+	if (w.From != "" && date < w.From) || (w.Until != "" && date > w.Until) {
+		return false, nil
+	}
+
+	if w.Start == "" && w.End == "" {
+		return w.onDay(t.Weekday())
+	}
+
+	start, err := minuteOfDay(w.Start)
+	if err != nil {
+		return false, fmt.Errorf("invalid start of maintenance window %s: %s", w, err)
+	}
+	end, err := minuteOfDay(w.End)
+	if err != nil {
+		return false, fmt.Errorf("invalid end of maintenance window %s: %s", w, err)
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	if start <= end {
+		if now < start || now >= end {
+			return false, nil
+		}
+		return w.onDay(t.Weekday())
+	}
+
+	// the window spans midnight, the time after midnight belongs to the
+	// window of the previous day
+	switch {
+	case now >= start:
+		return w.onDay(t.Weekday())
+	case now < end:
+		return w.onDay((t.Weekday() + 6) % 7)
+	}
+	return false, nil
+}
+
+func (w *MaintenanceWindow) onDay(day time.Weekday) (bool, error) {
+	if len(w.Days) == 0 {
+		return true, nil
+	}
+
+	for _, d := range w.Days {
+		name := strings.ToLower(d)
+		if len(name) > 3 {
+			name = name[:3]
+		}
+
+		wd, ok := weekdays[name]
+		if !ok {
+			return false, fmt.Errorf("invalid day %q of maintenance window %s", d, w)
+		}
+		if wd == day {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (w *MaintenanceWindow) String() string {
+	if w.Name != "" {
+		return w.Name
+	}
+
+	var parts []string
+	if len(w.Days) != 0 {
+		parts = append(parts, strings.Join(w.Days, ","))
+	}
+	if w.Start != "" || w.End != "" {
+		parts = append(parts, w.Start+"-"+w.End)
+	}
+	if w.From != "" || w.Until != "" {
+		parts = append(parts, w.From+".."+w.Until)
+	}
+	if w.Timezone != "" {
+		parts = append(parts, w.Timezone)
+	}
+	return strings.Join(parts, " ")
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// minuteOfDay parses a HH:MM time of day, an empty time is midnight.
+func minuteOfDay(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a time of day as HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// NextWindows returns when the next n maintenance windows open after from,
+// skipping the ones inside a freeze. If from is inside a window, it's the
+// first one. There are none if no windows are configured.
+func (m *Maintenance) NextWindows(from time.Time, n int) ([]time.Time, error) {
+	if len(m.Windows) == 0 {
+		return nil, nil
+	}
+
+	// invalid windows are reported by Contains, Check only tells whether
+	// changes are allowed afterwards
+	for _, windows := range [][]*MaintenanceWindow{m.Windows, m.Freezes} {
+		for _, w := range windows {
+			if _, err := w.Contains(from); err != nil {
+				return nil, err
+			}
+		}
+	}
+	allowed := func(t time.Time) bool { return m.Check(t) == nil }
+
+	var starts []time.Time
+	add := func(t time.Time) {
+		for _, s := range starts {
+			if s.Equal(t) {
+				return
+			}
+		}
+		starts = append(starts, t)
+	}
+
+	if allowed(from) {
+		add(from)
+	}
+
+	// the windows open at their start, on one of the next 60 days
+	for day := 0; day <= 60 && len(starts) < n; day++ {
+		var opening []time.Time
+		for _, w := range m.Windows {
+			loc := time.UTC
+			if w.Timezone != "" {
+				loc, _ = time.LoadLocation(w.Timezone)
+			}
+			start, _ := minuteOfDay(w.Start)
+
+			d := from.In(loc).AddDate(0, 0, day)
+			t := time.Date(d.Year(), d.Month(), d.Day(), start/60, start%60, 0, 0, loc)
+			if t.After(from) {
+				opening = append(opening, t)
+			}
+		}
+		sort.Slice(opening, func(i, j int) bool { return opening[i].Before(opening[j]) })
+
+		for _, t := range opening {
+			if allowed(t) && !allowed(t.Add(-time.Minute)) && len(starts) < n {
+				add(t)
+			}
+		}
+	}
+	return starts, nil
+}
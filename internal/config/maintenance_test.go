@@ -0,0 +1,103 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestMaintenanceCheck(t *testing.T) {
+	c := qt.New(t)
+
+	m := &Maintenance{
+		Windows: []*MaintenanceWindow{
+			{Days: []string{"tue", "Thursday"}, Start: "22:00", End: "02:00", Timezone: "Europe/Berlin"},
+			{Name: "weekend", Days: []string{"sat", "sun"}},
+		},
+		Freezes: []*MaintenanceWindow{
+			{Name: "holidays", From: "2026-12-20", Until: "2027-01-03"},
+		},
+	}
+
+	tests := []struct {
+		time string
+		err  string
+	}{
+		// Tuesday 22:30 in Berlin
+		{"2026-10-13T20:30:00Z", ""},
+		// Wednesday 01:30 in Berlin, in the window starting on Tuesday
+		{"2026-10-13T23:30:00Z", ""},
+		// Wednesday 02:30 in Berlin
+		{"2026-10-14T00:30:00Z", `2026-10-14T00:30:00Z is outside the maintenance windows tue,Thursday 22:00-02:00 Europe/Berlin, weekend`},
+		// Friday 01:00 in Berlin, after the window starting on Thursday
+		{"2026-10-15T23:00:00Z", ""},
+		{"2026-10-17T12:00:00Z", ""},
+		{"2026-12-26T12:00:00Z", `2026-12-26T12:00:00Z is inside the change freeze holidays`},
+	}
+
+	for _, tt := range tests {
+		now, err := time.Parse(time.RFC3339, tt.time)
+		c.Assert(err, qt.IsNil)
+
+		err = m.Check(now)
+		if tt.err == "" {
+			c.Assert(err, qt.IsNil, qt.Commentf(tt.time))
+		} else {
+			c.Assert(err, qt.ErrorMatches, tt.err, qt.Commentf(tt.time))
+		}
+	}
+
+	m.Windows[1].Days = []string{"someday"}
+	_, err := m.Windows[1].Contains(time.Now())
+	c.Assert(err, qt.ErrorMatches, `invalid day "someday" of maintenance window weekend`)
+}
+
+func TestMaintenanceFor(t *testing.T) {
+	c := qt.New(t)
+
+	all, prod := &Maintenance{}, &Maintenance{Enforce: true}
+	cfg := &Config{Maintenance: map[string]*Maintenance{"*": all, "prod": prod}}
+	c.Assert(cfg.MaintenanceFor("prod"), qt.Equals, prod)
+	c.Assert(cfg.MaintenanceFor("staging"), qt.Equals, all)
+	c.Assert((&Config{}).MaintenanceFor("prod"), qt.IsNil)
+}
+
+func TestMaintenanceNextWindows(t *testing.T) {
+	c := qt.New(t)
+
+	m := &Maintenance{
+		Windows: []*MaintenanceWindow{
+			{Days: []string{"sat"}, Start: "02:00", End: "04:00"},
+		},
+		Freezes: []*MaintenanceWindow{
+			{Name: "release", From: "2026-10-20", Until: "2026-10-25"},
+		},
+	}
+
+	parse := func(s string) time.Time {
+		t, err := time.Parse(time.RFC3339, s)
+		c.Assert(err, qt.IsNil)
+		return t
+	}
+
+	// the window of the 24th is inside the freeze
+	starts, err := m.NextWindows(parse("2026-10-14T12:00:00Z"), 3)
+	c.Assert(err, qt.IsNil)
+	c.Assert(starts, qt.DeepEquals, []time.Time{
+		parse("2026-10-17T02:00:00Z"),
+		parse("2026-10-31T02:00:00Z"),
+		parse("2026-11-07T02:00:00Z"),
+	})
+
+	starts, err = m.NextWindows(parse("2026-10-17T03:00:00Z"), 2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(starts, qt.DeepEquals, []time.Time{
+		parse("2026-10-17T03:00:00Z"),
+		parse("2026-10-31T02:00:00Z"),
+	})
+
+	starts, err = (&Maintenance{}).NextWindows(time.Now(), 2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(starts, qt.IsNil)
+}
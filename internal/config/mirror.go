@@ -0,0 +1,96 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/hashicorp/go-cleanhttp"
+)
+
+// Mirror configures the air-gapped mode of pscale. All network calls go
+// through internal mirrors of the PlanetScale services, or through a proxy,
+// and update checks are disabled. It's configured with the "mirror" key.
+type Mirror struct {
+	// APIURL replaces the PlanetScale API (--api-url).
+	APIURL string `yaml:"api-url,omitempty" json:"api-url,omitempty"`
+
+	// AuthURL replaces the PlanetScale Auth API used by 'auth login'.
+	AuthURL string `yaml:"auth-url,omitempty" json:"auth-url,omitempty"`
+
+	// AppURL replaces the web application opened with --web.
+	AppURL string `yaml:"app-url,omitempty" json:"app-url,omitempty"`
+
+	// DocsURL replaces the documentation linked in messages.
+	DocsURL string `yaml:"docs-url,omitempty" json:"docs-url,omitempty"`
+
+	// ReleasesURL replaces the GitHub releases used by 'version --verify'.
+	ReleasesURL string `yaml:"releases-url,omitempty" json:"releases-url,omitempty"`
+
+	// Proxy is an HTTP proxy all requests are sent through.
+	Proxy string `yaml:"proxy,omitempty" json:"proxy,omitempty"`
+}
+
+// NewMirror returns the mirror configured by the given lookup function for
+// the "mirror.*" keys, or nil if none of them are set.
+func NewMirror(lookup func(key string) string) (*Mirror, error) {
+	m := &Mirror{
+		APIURL:      lookup("mirror.api-url"),
+		AuthURL:     lookup("mirror.auth-url"),
+		AppURL:      lookup("mirror.app-url"),
+		DocsURL:     lookup("mirror.docs-url"),
+		ReleasesURL: lookup("mirror.releases-url"),
+		Proxy:       lookup("mirror.proxy"),
+	}
+
+	if *m == (Mirror{}) {
+		return nil, nil
+	}
+
+	for key, u := range map[string]string{
+		"mirror.api-url":      m.APIURL,
+		"mirror.auth-url":     m.AuthURL,
+		"mirror.app-url":      m.AppURL,
+		"mirror.docs-url":     m.DocsURL,
+		"mirror.releases-url": m.ReleasesURL,
+		"mirror.proxy":        m.Proxy,
+	} {
+		if u == "" {
+			continue
+		}
+
+		parsed, err := url.Parse(u)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid URL %q for %s", u, key)
+		}
+	}
+
+	return m, nil
+}
+
+// Offline returns whether pscale runs in air-gapped mode.
+func (c *Config) Offline() bool {
+	return c.Mirror != nil
+}
+
+// HTTPTransport returns the transport for requests to the PlanetScale
+// services, sending them through the proxy of the mirror if one is set and
+// connecting to the addresses of the DNS overrides.
+func (c *Config) HTTPTransport() *http.Transport {
+	t := cleanhttp.DefaultTransport()
+	if c.DNS != nil {
+		t.DialContext = c.DialContext
+	}
+	if c.Mirror != nil && c.Mirror.Proxy != "" {
+		if u, err := url.Parse(c.Mirror.Proxy); err == nil {
+			t.Proxy = http.ProxyURL(u)
+		}
+	}
+
+	return t
+}
+
+// HTTPClient returns a client using HTTPTransport.
+func (c *Config) HTTPClient() *http.Client {
+	return &http.Client{Transport: c.HTTPTransport()}
+}
@@ -0,0 +1,44 @@
+package config
+
+// Naming defines the naming conventions of the resources of an
+// organization.
+type Naming struct {
+	Database *NameRule `yaml:"database,omitempty" json:"database,omitempty"`
+	Branch   *NameRule `yaml:"branch,omitempty" json:"branch,omitempty"`
+	Password *NameRule `yaml:"password,omitempty" json:"password,omitempty"`
+
+	// DevBranch names the branch of each developer used by 'pscale branch
+	// dev', i.e: "{{user}}-{{git_branch}}".
+	DevBranch *NameRule `yaml:"dev-branch,omitempty" json:"dev-branch,omitempty"`
+}
+
+// NameRule is the naming convention of a single kind of resource.
+type NameRule struct {
+	// Template generates names if none is passed, i.e:
+	// "{{ticket}}-{{user}}-{{date}}".
+	Template string `yaml:"template,omitempty" json:"template,omitempty"`
+
+	// Pattern is a regular expression names have to match. If it's empty,
+	// names have to match the template.
+	Pattern string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+}
+
+// Rule returns the naming convention of the given kind of resource or nil if
+// none is defined.
+func (n *Naming) Rule(kind string) *NameRule {
+	if n == nil {
+		return nil
+	}
+
+	switch kind {
+	case "database":
+		return n.Database
+	case "branch":
+		return n.Branch
+	case "password":
+		return n.Password
+	case "dev-branch":
+		return n.DevBranch
+	}
+	return nil
+}
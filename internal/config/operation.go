@@ -0,0 +1,142 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const operationsDir = "operations"
+
+// OperationKind is the kind of a waited operation.
+type OperationKind string
+
+const (
+	OperationDeploy  OperationKind = "deploy"
+	OperationPromote OperationKind = "promote"
+	// OperationRevoke revokes a break-glass password once it expired.
+	OperationRevoke OperationKind = "revoke"
+)
+
+// Operation is an in-flight operation the CLI is waiting for. It's persisted
+// while waiting, so an interrupted wait can be resumed with 'pscale resume'.
+type Operation struct {
+	ID           string        `json:"id"`
+	Kind         OperationKind `json:"kind"`
+	Organization string        `json:"org"`
+	Database     string        `json:"database"`
+	Branch       string        `json:"branch,omitempty"`
+	Number       uint64        `json:"number,omitempty"`
+	StartedAt    time.Time     `json:"started_at"`
+
+	// PasswordID and ExpiresAt are set for revocations.
+	PasswordID string     `json:"password_id,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// NewOperation returns an operation with an ID derived from its target, so
+// waiting for the same target again replaces the previous state.
+func NewOperation(kind OperationKind, org, database, branch string, number uint64) *Operation {
+	ref := branch
+	if number != 0 {
+		ref = fmt.Sprintf("%d", number)
+	}
+
+	return &Operation{
+		ID:           strings.Join([]string{string(kind), org, database, ref}, "."),
+		Kind:         kind,
+		Organization: org,
+		Database:     database,
+		Branch:       branch,
+		Number:       number,
+		StartedAt:    time.Now(),
+	}
+}
+
+// OperationsPath returns the directory in-flight operations are stored in.
+func OperationsPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, operationsDir), nil
+}
+
+// Operations returns the in-flight operations, oldest first.
+func (c *ConfigFS) Operations() ([]*Operation, error) {
+	dir, err := OperationsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := fs.ReadDir(c.fsys, dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var ops []*Operation
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+
+		p := path.Join(dir, e.Name())
+		out, err := fs.ReadFile(c.fsys, p)
+		if err != nil {
+			return nil, err
+		}
+
+		var op Operation
+		if err := json.Unmarshal(out, &op); err != nil {
+			return nil, fmt.Errorf("can't unmarshal file %q: %s", p, err)
+		}
+		ops = append(ops, &op)
+	}
+
+	sort.Slice(ops, func(i, j int) bool { return ops[i].StartedAt.Before(ops[j].StartedAt) })
+	return ops, nil
+}
+
+// Save persists the operation.
+func (o *Operation) Save() error {
+	dir, err := OperationsPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0771); err != nil {
+		return fmt.Errorf("error creating operations directory: %s", err)
+	}
+
+	out, err := json.Marshal(o)
+	if err != nil {
+		return fmt.Errorf("can't marshal operation: %s", err)
+	}
+
+	return ioutil.WriteFile(path.Join(dir, o.ID+".json"), out, 0644)
+}
+
+// Done removes the persisted state of the operation.
+func (o *Operation) Done() error {
+	dir, err := OperationsPath()
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path.Join(dir, o.ID+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Pricing describes the monthly pricing of a PlanetScale plan. Rows read are
+// counted in billions, rows written in millions.
+type Pricing struct {
+	Base float64 `yaml:"base,omitempty" json:"base"`
+
+	IncludedStorageGB float64 `yaml:"included-storage-gb,omitempty" json:"included_storage_gb"`
+	StorageGB         float64 `yaml:"storage-gb,omitempty" json:"storage_gb"`
+
+	IncludedRowsRead float64 `yaml:"included-rows-read,omitempty" json:"included_rows_read"`
+	RowsRead         float64 `yaml:"rows-read,omitempty" json:"rows_read"`
+
+	IncludedRowsWritten float64 `yaml:"included-rows-written,omitempty" json:"included_rows_written"`
+	RowsWritten         float64 `yaml:"rows-written,omitempty" json:"rows_written"`
+
+	ProductionBranch  float64 `yaml:"production-branch,omitempty" json:"production_branch"`
+	DevelopmentBranch float64 `yaml:"development-branch,omitempty" json:"development_branch"`
+}
+
+// Plans contains the published pricing of the PlanetScale plans. It can be
+// overridden per organization with the "pricing" key.
+var Plans = map[string]*Pricing{
+	"hobby": {
+		IncludedStorageGB:   5,
+		IncludedRowsRead:    1,
+		IncludedRowsWritten: 10,
+	},
+	"scaler": {
+		Base:                29,
+		IncludedStorageGB:   10,
+		StorageGB:           2.5,
+		IncludedRowsRead:    100,
+		RowsRead:            1,
+		IncludedRowsWritten: 50,
+		RowsWritten:         1.5,
+	},
+	"team": {
+		Base:                599,
+		IncludedStorageGB:   100,
+		StorageGB:           2.5,
+		IncludedRowsRead:    500,
+		RowsRead:            1,
+		IncludedRowsWritten: 100,
+		RowsWritten:         1.5,
+	},
+}
+
+// PlanPricing returns the pricing of the given plan. A pricing configured for
+// the active organization takes precedence over the published plans.
+func (c *Config) PlanPricing(plan string) (*Pricing, error) {
+	if c.Pricing != nil {
+		return c.Pricing, nil
+	}
+
+	if plan == "" {
+		return nil, fmt.Errorf("no plan is configured for organization %q (set 'plan' for the organization in your config file or pass --plan)", c.Organization)
+	}
+
+	p, ok := Plans[strings.ToLower(plan)]
+	if !ok {
+		return nil, fmt.Errorf("unknown plan %q, supported plans are: %s", plan, strings.Join(PlanNames(), ", "))
+	}
+
+	return p, nil
+}
+
+// PlanNames returns the sorted names of the published plans.
+func PlanNames() []string {
+	names := make([]string, 0, len(Plans))
+	for name := range Plans {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const profileEnv = "PSCALE_PROFILE"
+
+// Profile is a named set of credentials, organization and API URL, so one
+// machine can be used with several PlanetScale accounts. Profiles are defined
+// in the "profiles" key of the global config file.
+type Profile struct {
+	Organization string `yaml:"org,omitempty" json:"org,omitempty"`
+	BaseURL      string `yaml:"api-url,omitempty" json:"api-url,omitempty"`
+
+	// AutoApprove confirms actions on non-production branches without
+	// prompting, i.e. for scripting against development accounts.
+	// Production branches and databases still prompt.
+	AutoApprove bool `yaml:"auto-approve,omitempty" json:"auto-approve,omitempty"`
+
+	// CredentialSource fetches the service token of the profile from a
+	// password manager. The top-level credential source of the file isn't
+	// used for profiles.
+	CredentialSource *CredentialSourceConfig `yaml:"credential-source,omitempty" json:"credential-source,omitempty"`
+}
+
+// activeProfile is the name of the profile in use. Credentials of the empty
+// profile are stored the same way as before profiles existed.
+var activeProfile string
+
+// SetProfile sets the active profile. It must be called before the token
+// store is opened.
+func SetProfile(name string) error {
+	if strings.ContainsAny(name, `/\. `) {
+		return fmt.Errorf("invalid profile name %q", name)
+	}
+
+	activeProfile = name
+	return nil
+}
+
+// ActiveProfile returns the name of the active profile, or an empty string
+// if no profile is in use.
+func ActiveProfile() string {
+	return activeProfile
+}
+
+// ResolveProfile returns the profile to use from the --profile flag in args,
+// the PSCALE_PROFILE environment variable or the current profile of the
+// given file config, in that order. The flag is looked up directly, as the
+// credentials are read before the flags are parsed.
+func ResolveProfile(args []string, fileCfg *FileConfig) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+
+		if arg == "--profile" && i+1 < len(args) {
+			return args[i+1]
+		}
+
+		if strings.HasPrefix(arg, "--profile=") {
+			return strings.TrimPrefix(arg, "--profile=")
+		}
+	}
+
+	if p := os.Getenv(profileEnv); p != "" {
+		return p
+	}
+
+	if fileCfg != nil {
+		return fileCfg.CurrentProfile
+	}
+
+	return ""
+}
+
+// CredentialSourceConfig returns the credential source of the given profile,
+// or the top-level one of the file without a profile.
+func (f *FileConfig) CredentialSourceConfig(profile string) *CredentialSourceConfig {
+	if profile == "" {
+		return f.CredentialSource
+	}
+
+	if p := f.Profile(profile); p != nil {
+		return p.CredentialSource
+	}
+	return nil
+}
+
+// Profile returns the profile with the given name or nil if it's not
+// defined.
+func (f *FileConfig) Profile(name string) *Profile {
+	if f == nil || name == "" {
+		return nil
+	}
+
+	return f.Profiles[name]
+}
+
+// ProfileNames returns the sorted names of all defined profiles.
+func (f *FileConfig) ProfileNames() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// profileName namespaces the given name of a credential with the active
+// profile.
+func profileName(name string) string {
+	if activeProfile == "" {
+		return name
+	}
+
+	return name + "." + activeProfile
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+)
+
+const releasesDir = "releases"
+
+// ErrNoReleaseRun is returned if a release run doesn't exist.
+var ErrNoReleaseRun = errors.New("release run does not exist")
+
+// ReleaseRun is the state of a run of a release plan. It's saved after every
+// step, so a failed or interrupted run can be resumed from the step it
+// stopped at.
+type ReleaseRun struct {
+	ID           string    `json:"id"`
+	Plan         string    `json:"plan"`
+	PlanDigest   string    `json:"plan_digest"`
+	Organization string    `json:"org"`
+	Database     string    `json:"database"`
+	Branch       string    `json:"branch"`
+	StartedAt    time.Time `json:"started_at"`
+
+	// Number is the deploy request opened by the run.
+	Number uint64 `json:"number,omitempty"`
+
+	// Done are the names of the finished steps.
+	Done []string `json:"done"`
+}
+
+// ReleasesPath returns the directory the runs of release plans and their
+// logs are stored in.
+func ReleasesPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, releasesDir), nil
+}
+
+// ReadReleaseRun returns the run with the given ID.
+func ReadReleaseRun(id string) (*ReleaseRun, error) {
+	dir, err := ReleasesPath()
+	if err != nil {
+		return nil, err
+	}
+
+	p := path.Join(dir, id+".json")
+	out, err := ioutil.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoReleaseRun
+		}
+		return nil, err
+	}
+
+	var run ReleaseRun
+	if err := json.Unmarshal(out, &run); err != nil {
+		return nil, fmt.Errorf("can't unmarshal file %q: %s", p, err)
+	}
+	return &run, nil
+}
+
+// IsDone returns whether the step finished.
+func (r *ReleaseRun) IsDone(step string) bool {
+	for _, s := range r.Done {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// Save persists the run.
+func (r *ReleaseRun) Save() error {
+	dir, err := ReleasesPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0771); err != nil {
+		return fmt.Errorf("error creating releases directory: %s", err)
+	}
+
+	out, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("can't marshal release run: %s", err)
+	}
+
+	return ioutil.WriteFile(path.Join(dir, r.ID+".json"), out, 0644)
+}
+
+// LogPath returns the path of the human-readable log of the run.
+func (r *ReleaseRun) LogPath() (string, error) {
+	dir, err := ReleasesPath()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, r.ID+".log"), nil
+}
@@ -0,0 +1,248 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Source describes where a configuration value originates from.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceSystem  Source = "system"
+	SourceGlobal  Source = "global"
+	SourceProject Source = "project"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+
+	// SourceEnvironment are the values of the project environment selected
+	// with --env.
+	SourceEnvironment Source = "environment"
+
+	// SourceLocked are the values enforced by the locked config file of the
+	// machine, see LockedLayer.
+	SourceLocked Source = "locked"
+)
+
+// EnvPrefix is the prefix of environment variables that override
+// configuration values.
+const EnvPrefix = "PLANETSCALE_"
+
+var envReplacer = strings.NewReplacer("-", "_", ".", "_")
+
+// Defaults are the values of configuration keys that aren't set anywhere.
+var Defaults = map[string]string{
+	"timeouts.read":    "30s",
+	"timeouts.mutate":  "60s",
+	"retries.max":      "2",
+	"retries.backoff":  "500ms",
+	"retries.max-wait": "30s",
+}
+
+// sensitiveSuffixes are the suffixes of the last segment of keys that are
+// redacted when configuration values are displayed, so nested keys such as
+// "profiles.work.service-token" are redacted too.
+var sensitiveSuffixes = []string{"token", "secret", "password", "passphrase"}
+
+// Layer is a single source of configuration values, such as a config file or
+// the environment.
+type Layer struct {
+	Source Source
+	// Origin is the file path, the environment variable or the flag the
+	// values are coming from.
+	Origin string
+	Values map[string]string
+	// origins overrides Origin for individual keys.
+	origins map[string]string
+}
+
+// Value is a resolved configuration value annotated with its source.
+type Value struct {
+	Key    string `header:"key" json:"key"`
+	Value  string `header:"value" json:"value"`
+	Source Source `header:"source" json:"source"`
+	Origin string `header:"origin" json:"origin"`
+}
+
+// NewLayer reads the YAML config file at the given path and returns its
+// values flattened into dotted keys, i.e: "orgs.acme.region".
+func (c *ConfigFS) NewLayer(source Source, path string) (*Layer, error) {
+	out, err := fs.ReadFile(c.fsys, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[interface{}]interface{}
+	if err := yaml.Unmarshal(out, &raw); err != nil {
+		return nil, fmt.Errorf("can't unmarshal file %q: %s", path, err)
+	}
+
+	values := make(map[string]string)
+	flatten("", raw, values)
+
+	return &Layer{Source: source, Origin: path, Values: values}, nil
+}
+
+// DefaultLayer returns the default configuration values.
+func DefaultLayer() *Layer {
+	values := make(map[string]string, len(Defaults))
+	for k, v := range Defaults {
+		values[k] = v
+	}
+
+	return &Layer{Source: SourceDefault, Origin: "default", Values: values}
+}
+
+// EnvLayer returns the configuration values that are overridden via
+// PLANETSCALE_* environment variables. The given keys are used to map
+// environment variables back to their configuration key.
+func EnvLayer(keys []string) *Layer {
+	known := make(map[string]string, len(keys))
+	for _, k := range keys {
+		known[EnvPrefix+strings.ToUpper(envReplacer.Replace(k))] = k
+	}
+
+	l := &Layer{
+		Source:  SourceEnv,
+		Values:  make(map[string]string),
+		origins: make(map[string]string),
+	}
+
+	for _, kv := range os.Environ() {
+		i := strings.Index(kv, "=")
+		if i < 0 || !strings.HasPrefix(kv[:i], EnvPrefix) {
+			continue
+		}
+
+		name, val := kv[:i], kv[i+1:]
+		key, ok := known[name]
+		if !ok {
+			key = strings.ToLower(strings.TrimPrefix(name, EnvPrefix))
+			key = strings.Replace(key, "_", "-", -1)
+		}
+
+		l.Values[key] = val
+		l.origins[key] = name
+	}
+
+	return l
+}
+
+// EnvironmentLayer returns the values of the environment with the given
+// name defined in the layer of a config file, or nil if there are none.
+func EnvironmentLayer(file *Layer, name string) *Layer {
+	if file == nil || name == "" {
+		return nil
+	}
+
+	prefix := "environments." + name + "."
+	l := &Layer{
+		Source: SourceEnvironment,
+		Origin: file.Origin,
+		Values: make(map[string]string),
+	}
+	for k, v := range file.Values {
+		if strings.HasPrefix(k, prefix) {
+			l.Values[strings.TrimPrefix(k, prefix)] = v
+		}
+	}
+
+	if len(l.Values) == 0 {
+		return nil
+	}
+	return l
+}
+
+// Keys returns the sorted keys of the layer.
+func (l *Layer) Keys() []string {
+	keys := make([]string, 0, len(l.Values))
+	for k := range l.Values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (l *Layer) origin(key string) string {
+	if o, ok := l.origins[key]; ok {
+		return o
+	}
+	return l.Origin
+}
+
+// Resolve merges the given layers into the effective configuration. Later
+// layers take precedence over earlier ones, nil layers are skipped.
+func Resolve(layers ...*Layer) []*Value {
+	merged := make(map[string]*Value)
+	for _, l := range layers {
+		if l == nil {
+			continue
+		}
+
+		for k, v := range l.Values {
+			merged[k] = &Value{
+				Key:    k,
+				Value:  v,
+				Source: l.Source,
+				Origin: l.origin(k),
+			}
+		}
+	}
+
+	values := make([]*Value, 0, len(merged))
+	for _, v := range merged {
+		values = append(values, v)
+	}
+
+	sort.Slice(values, func(i, j int) bool { return values[i].Key < values[j].Key })
+	return values
+}
+
+// Redact masks the values of sensitive keys, such as tokens.
+func Redact(key, value string) string {
+	if !sensitiveKey(key) || value == "" {
+		return value
+	}
+
+	return "********"
+}
+
+func sensitiveKey(key string) bool {
+	last := strings.ToLower(key[strings.LastIndex(key, ".")+1:])
+	for _, suffix := range sensitiveSuffixes {
+		if strings.HasSuffix(last, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func flatten(prefix string, in map[interface{}]interface{}, out map[string]string) {
+	for k, v := range in {
+		key := fmt.Sprintf("%v", k)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch val := v.(type) {
+		case map[interface{}]interface{}:
+			flatten(key, val, out)
+		case []interface{}:
+			items := make([]string, 0, len(val))
+			for _, item := range val {
+				items = append(items, fmt.Sprintf("%v", item))
+			}
+			out[key] = strings.Join(items, ",")
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprintf("%v", val)
+		}
+	}
+}
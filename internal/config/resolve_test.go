@@ -0,0 +1,32 @@
+package config
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "api-token", want: "********"},
+		{key: "service-token", want: "********"},
+		{key: "profiles.work.service-token", want: "********"},
+		{key: "webhooks.deploy.Secret", want: "********"},
+		{key: "mirror.proxy-password", want: "********"},
+		{key: "service-token-id", want: "value"},
+		{key: "profiles.work.org", want: "value"},
+		{key: "tokens.org", want: "value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			c := qt.New(t)
+			c.Assert(Redact(tt.key, "value"), qt.Equals, tt.want)
+		})
+	}
+
+	qt.Assert(t, Redact("api-token", ""), qt.Equals, "")
+}
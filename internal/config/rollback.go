@@ -0,0 +1,91 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+)
+
+// ErrNoRollback is returned if a rollback manifest doesn't exist.
+var ErrNoRollback = errors.New("rollback manifest does not exist")
+
+// Rollback is the manifest for rolling back a deployment. It's saved when a
+// deploy request is deployed, by a release run or 'pscale deploy-request
+// deploy --save-rollback', and stored with the release runs.
+type Rollback struct {
+	ID           string    `json:"id"`
+	Organization string    `json:"org"`
+	Database     string    `json:"database"`
+	Number       uint64    `json:"number"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	// Branch is the branch that was deployed to, Schema its schema before
+	// the deployment.
+	Branch string `json:"branch"`
+	Schema string `json:"schema"`
+
+	// OpenedAt is when the deploy request was opened. The passwords of the
+	// branch created since are deleted by the rollback.
+	OpenedAt time.Time `json:"opened_at"`
+
+	// Verify are the queries verifying the rolled back branch.
+	Verify []*RollbackQuery `json:"verify"`
+
+	// Statements revert the schema. They're set when the rollback starts,
+	// so a resumed rollback applies the same statements.
+	Statements []string `json:"statements,omitempty"`
+}
+
+// RollbackQuery is a query verifying a rollback. It must return a row, of
+// which the first column equals Expect if it's set.
+type RollbackQuery struct {
+	Name   string `json:"name" yaml:"name"`
+	Query  string `json:"query" yaml:"query"`
+	Expect string `json:"expect,omitempty" yaml:"expect"`
+}
+
+// ReadRollback returns the rollback manifest with the given ID.
+func ReadRollback(id string) (*Rollback, error) {
+	dir, err := ReleasesPath()
+	if err != nil {
+		return nil, err
+	}
+
+	p := path.Join(dir, id+".rollback.json")
+	out, err := ioutil.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoRollback
+		}
+		return nil, err
+	}
+
+	var r Rollback
+	if err := json.Unmarshal(out, &r); err != nil {
+		return nil, fmt.Errorf("can't unmarshal file %q: %s", p, err)
+	}
+	return &r, nil
+}
+
+// Save persists the rollback manifest.
+func (r *Rollback) Save() error {
+	dir, err := ReleasesPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0771); err != nil {
+		return fmt.Errorf("error creating releases directory: %s", err)
+	}
+
+	out, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("can't marshal rollback manifest: %s", err)
+	}
+
+	return ioutil.WriteFile(path.Join(dir, r.ID+".rollback.json"), out, 0600)
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SetValue sets the dotted key, i.e: "orgs.acme.region", to the given value
+// in the YAML config file at path. The file is created if it doesn't exist,
+// the order and the other values of an existing file are kept.
+func SetValue(path, key, value string) error {
+	return SetValues(path, yaml.MapSlice{{Key: key, Value: parseValue(value)}})
+}
+
+// SetValues sets the dotted keys to the given values, as SetValue does, but
+// without parsing them. A nil value removes the key. Commands rewriting the
+// config files use it so the keys they don't know about are kept.
+func SetValues(path string, values yaml.MapSlice) error {
+	out, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(out, &doc); err != nil {
+		return fmt.Errorf("can't unmarshal file %q: %s", path, err)
+	}
+
+	for _, item := range values {
+		key := fmt.Sprintf("%v", item.Key)
+		parts := strings.Split(key, ".")
+		for _, p := range parts {
+			if p == "" {
+				return fmt.Errorf("invalid key %q", key)
+			}
+		}
+
+		doc, err = setKey(doc, parts, item.Value)
+		if err != nil {
+			return fmt.Errorf("can't set %q in %q: %s", key, path, err)
+		}
+	}
+
+	out, err = yaml.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("can't marshal file %q: %s", path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0771); err != nil {
+		return fmt.Errorf("error creating config directory: %s", err)
+	}
+
+	return ioutil.WriteFile(path, out, 0644)
+}
+
+func setKey(m yaml.MapSlice, parts []string, value interface{}) (yaml.MapSlice, error) {
+	for i, item := range m {
+		if fmt.Sprintf("%v", item.Key) != parts[0] {
+			continue
+		}
+
+		if len(parts) == 1 {
+			if value == nil {
+				return append(m[:i], m[i+1:]...), nil
+			}
+
+			// keep lists as lists, values of lists are comma separated
+			// as with 'pscale config get'
+			if _, ok := item.Value.([]interface{}); ok {
+				if s, ok := value.(string); ok {
+					value = splitList(s)
+				}
+			}
+
+			m[i].Value = value
+			return m, nil
+		}
+
+		child, ok := item.Value.(yaml.MapSlice)
+		if !ok && item.Value != nil {
+			return nil, errors.New(parts[0] + " is not a map")
+		}
+
+		child, err := setKey(child, parts[1:], value)
+		if err != nil {
+			return nil, err
+		}
+
+		m[i].Value = child
+		return m, nil
+	}
+
+	if value == nil {
+		return m, nil
+	}
+
+	if len(parts) == 1 {
+		return append(m, yaml.MapItem{Key: parts[0], Value: value}), nil
+	}
+
+	child, err := setKey(nil, parts[1:], value)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(m, yaml.MapItem{Key: parts[0], Value: child}), nil
+}
+
+// parseValue returns booleans and numbers as such, so they're written
+// unquoted. All other values are kept as strings.
+func parseValue(value string) interface{} {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(value), &v); err != nil {
+		return value
+	}
+
+	switch v.(type) {
+	case bool, int, float64:
+		return v
+	}
+	return value
+}
+
+func splitList(s string) []interface{} {
+	list := []interface{}{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
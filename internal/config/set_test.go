@@ -0,0 +1,69 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"gopkg.in/yaml.v2"
+)
+
+func TestSetValue(t *testing.T) {
+	c := qt.New(t)
+
+	path := filepath.Join(t.TempDir(), "pscale.yml")
+	err := ioutil.WriteFile(path, []byte("org: acme\norgs:\n  acme:\n    protected-branches:\n    - main\n"), 0644)
+	c.Assert(err, qt.IsNil)
+
+	c.Assert(SetValue(path, "orgs.acme.protected-branches", "main, prod"), qt.IsNil)
+	c.Assert(SetValue(path, "orgs.acme.approval.required", "true"), qt.IsNil)
+	c.Assert(SetValue(path, "retries.max", "3"), qt.IsNil)
+	c.Assert(SetValue(path, "org", "planetscale"), qt.IsNil)
+
+	out, err := ioutil.ReadFile(path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, `org: planetscale
+orgs:
+  acme:
+    protected-branches:
+    - main
+    - prod
+    approval:
+      required: true
+retries:
+  max: 3
+`)
+
+	err = SetValue(path, "org.name", "acme")
+	c.Assert(err, qt.ErrorMatches, `can't set "org.name" in ".*": org is not a map`)
+
+	err = SetValue(path, "orgs..region", "us-east")
+	c.Assert(err, qt.ErrorMatches, `invalid key "orgs..region"`)
+}
+
+func TestSetValues(t *testing.T) {
+	c := qt.New(t)
+
+	path := filepath.Join(t.TempDir(), "pscale.yml")
+	err := ioutil.WriteFile(path, []byte("org: acme\nbranch: old\nmirror:\n  api-url: https://mirror.internal\n"), 0644)
+	c.Assert(err, qt.IsNil)
+
+	err = SetValues(path, yaml.MapSlice{
+		{Key: "org", Value: "1234"},
+		{Key: "branch", Value: nil},
+		{Key: "database", Value: nil},
+		{Key: "watch", Value: []string{"a", "b"}},
+	})
+	c.Assert(err, qt.IsNil)
+
+	out, err := ioutil.ReadFile(path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, `org: "1234"
+mirror:
+  api-url: https://mirror.internal
+watch:
+- a
+- b
+`)
+}
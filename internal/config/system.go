@@ -0,0 +1,76 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// lockedConfigName is the name of the config file in SystemConfigDir whose
+// values are enforced on the machine.
+const lockedConfigName = "config.yml"
+
+// systemConfigName is the name of the config file in SystemConfigDir with
+// the defaults of the machine.
+const systemConfigName = configName
+
+// SystemConfigDir is the directory of the machine-wide configuration managed
+// by administrators: %ProgramData%\pscale on Windows and /etc/pscale
+// otherwise.
+func SystemConfigDir() string {
+	if runtime.GOOS == "windows" {
+		programData := os.Getenv("ProgramData")
+		if programData == "" {
+			programData = `C:\ProgramData`
+		}
+		return filepath.Join(programData, "pscale")
+	}
+	return "/etc/pscale"
+}
+
+// SystemConfigPath returns the path of the machine-wide config file, whose
+// values are the defaults of all users of the machine. The global and the
+// project config files override them.
+func SystemConfigPath() string {
+	return filepath.Join(SystemConfigDir(), systemConfigName)
+}
+
+// LockedConfigPath returns the path of the locked config file, see
+// LockedLayer.
+func LockedConfigPath() string {
+	return filepath.Join(SystemConfigDir(), lockedConfigName)
+}
+
+// LockedLayer returns the values of the config file at path if it sets
+// "locked: true". Fleet-managed machines use it to enforce the organization,
+// the API URL and policies, its values override the config files, the
+// environment and the flags. The file must be owned by root and not be
+// writable by other users, otherwise an error is returned and pscale doesn't
+// run. It returns nil if the file doesn't exist or isn't locked.
+func (c *ConfigFS) LockedLayer(path string) (*Layer, error) {
+	fi, err := fs.Stat(c.fsys, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkSystemFileOwner(fi); err != nil {
+		return nil, fmt.Errorf("the locked config file %s %s. It enforces the policies of this machine, so pscale doesn't run until it's fixed", path, err)
+	}
+
+	l, err := c.NewLayer(SourceLocked, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.Values["locked"] != "true" {
+		return nil, nil
+	}
+	delete(l.Values, "locked")
+	return l, nil
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"testing/fstest"
+
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestConfigFS_LockedLayer(t *testing.T) {
+	c := qt.New(t)
+
+	fsys := fstest.MapFS{
+		"etc/pscale/config.yml": {Data: []byte(`locked: true
+org: acme
+api-url: https://api.acme.example/
+orgs:
+  acme:
+    protected-branches: [main]
+`)},
+		"etc/pscale/unlocked.yml": {Data: []byte("org: acme\n")},
+	}
+	cfs := NewConfigFS(fsys)
+
+	l, err := cfs.LockedLayer("etc/pscale/config.yml")
+	c.Assert(err, qt.IsNil)
+	c.Assert(l.Source, qt.Equals, SourceLocked)
+	c.Assert(l.Values, qt.DeepEquals, map[string]string{
+		"org":                          "acme",
+		"api-url":                      "https://api.acme.example/",
+		"orgs.acme.protected-branches": "main",
+	})
+
+	l, err = cfs.LockedLayer("etc/pscale/unlocked.yml")
+	c.Assert(err, qt.IsNil)
+	c.Assert(l, qt.IsNil)
+
+	l, err = cfs.LockedLayer("etc/pscale/missing.yml")
+	c.Assert(err, qt.IsNil)
+	c.Assert(l, qt.IsNil)
+}
+
+func TestConfigFS_LockedLayer_Writable(t *testing.T) {
+	c := qt.New(t)
+	if runtime.GOOS == "windows" {
+		c.Skip("file modes aren't checked on Windows")
+	}
+
+	p := filepath.Join(c.Mkdir(), "config.yml")
+	c.Assert(ioutil.WriteFile(p, []byte("locked: true\norg: acme\n"), 0644), qt.IsNil)
+	c.Assert(os.Chmod(p, 0666), qt.IsNil)
+
+	// the file isn't ignored, as the policies it enforces would be
+	_, err := NewConfigFS(testutil.OSFS{}).LockedLayer(p)
+	c.Assert(err, qt.ErrorMatches, `the locked config file .* (isn't owned by root|is writable by other users)\. It enforces the policies of this machine, so pscale doesn't run until it's fixed`)
+}
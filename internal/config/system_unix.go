@@ -0,0 +1,28 @@
+//go:build !windows
+// +build !windows
+
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"syscall"
+)
+
+// checkSystemFileOwner returns an error if the file could have been changed
+// by users other than root.
+func checkSystemFileOwner(fi fs.FileInfo) error {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		// not a file of the operating system
+		return nil
+	}
+
+	if st.Uid != 0 {
+		return errors.New("isn't owned by root")
+	}
+	if fi.Mode().Perm()&0022 != 0 {
+		return errors.New("is writable by other users")
+	}
+	return nil
+}
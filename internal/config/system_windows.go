@@ -0,0 +1,9 @@
+package config
+
+import "io/fs"
+
+// checkSystemFileOwner accepts all files, %ProgramData% is only writable by
+// administrators by default.
+func checkSystemFileOwner(fi fs.FileInfo) error {
+	return nil
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"path"
+	"strings"
+)
+
+const templatesDir = "templates"
+
+// TemplatePath returns the path of the custom output template of the
+// command, such as "pscale branch list", whose template is
+// templates/branch-list.tmpl in the config directory.
+func TemplatePath(command string) (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.Join(strings.Fields(strings.TrimPrefix(command, "pscale")), "-")
+	if name == "" {
+		name = "pscale"
+	}
+	return path.Join(dir, templatesDir, name+".tmpl"), nil
+}
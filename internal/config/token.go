@@ -0,0 +1,247 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+)
+
+const (
+	// TokenStorageFile stores the access token as a plain file inside the
+	// config directory.
+	TokenStorageFile = "file"
+
+	// TokenStorageHardware wraps the access token with a hardware-backed key
+	// (TPM2 on Linux, the Keychain/Secure Enclave on macOS) before it's
+	// persisted.
+	TokenStorageHardware = "hardware"
+
+	tokenStorageEnv = "PSCALE_TOKEN_STORAGE"
+)
+
+// TokenStore persists and retrieves the access token used for authenticating
+// against the PlanetScale API.
+type TokenStore interface {
+	// Read returns the stored access token. An empty token and a nil error
+	// is returned if no token is stored.
+	Read() (string, error)
+
+	// Write persists the given access token.
+	Write(token string) error
+
+	// Delete removes the stored access token. It's not an error if no token
+	// is stored.
+	Delete() error
+}
+
+// NewTokenStore returns the TokenStore that is configured for this machine via
+// the PSCALE_TOKEN_STORAGE environment variable.
+func NewTokenStore() (TokenStore, error) {
+	return OpenTokenStore(os.Getenv(tokenStorageEnv))
+}
+
+// OpenTokenStore returns the TokenStore for the given storage kind. If storage
+// is empty, the hardware-backed or encrypted store is used if such a token
+// already exists, or the encrypted store if PSCALE_KEYRING_PASSPHRASE is set.
+// Otherwise the token is stored in a plain file.
+func OpenTokenStore(storage string) (TokenStore, error) {
+	switch storage {
+	case "", TokenStorageFile, TokenStorageHardware, TokenStorageEncrypted:
+	default:
+		return nil, fmt.Errorf("invalid %s value %q, allowed values are: %s, %s, %s",
+			tokenStorageEnv, storage, TokenStorageFile, TokenStorageHardware, TokenStorageEncrypted)
+	}
+
+	sealedPath, err := sealedAccessTokenPath()
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedPath, err := encryptedAccessTokenPath()
+	if err != nil {
+		return nil, err
+	}
+
+	if storage == "" {
+		if _, err := os.Stat(sealedPath); err == nil {
+			storage = TokenStorageHardware
+		} else if _, err := os.Stat(encryptedPath); err == nil || os.Getenv(passphraseEnv) != "" {
+			storage = TokenStorageEncrypted
+		}
+	}
+
+	if storage == TokenStorageHardware {
+		s, err := hardwareSealer()
+		if err != nil {
+			return nil, err
+		}
+
+		return &hardwareTokenStore{path: sealedPath, sealer: s}, nil
+	}
+
+	tokenPath, err := AccessTokenPath()
+	if err != nil {
+		return nil, err
+	}
+
+	if storage == TokenStorageEncrypted {
+		return &encryptedTokenStore{
+			path:       encryptedPath,
+			plainPath:  tokenPath,
+			passphrase: readPassphrase,
+		}, nil
+	}
+
+	return &fileTokenStore{path: tokenPath}, nil
+}
+
+// fileTokenStore stores the access token as a file with restricted
+// permissions.
+type fileTokenStore struct {
+	path string
+}
+
+func (f *fileTokenStore) Read() (string, error) {
+	stat, err := os.Stat(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	if stat.Mode()&^TokenFileMode != 0 {
+		err = os.Chmod(f.path, TokenFileMode)
+		if err != nil {
+			log.Printf("Unable to change %v file mode to 0%o: %v", f.path, TokenFileMode, err)
+		}
+	}
+
+	accessToken, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return "", err
+	}
+
+	return string(accessToken), nil
+}
+
+func (f *fileTokenStore) Write(token string) error {
+	if err := ensureConfigDir(); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(f.path, []byte(token), TokenFileMode)
+}
+
+func (f *fileTokenStore) Delete() error {
+	err := os.Remove(f.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// sealer wraps and unwraps secrets with a key that never leaves the
+// platform's security hardware.
+type sealer interface {
+	Seal(plaintext []byte) ([]byte, error)
+	Unseal(sealed []byte) ([]byte, error)
+	Remove() error
+}
+
+// hardwareSealer returns the sealer of the platform. It's replaced in tests.
+var hardwareSealer = newHardwareSealer
+
+// hardwareTokenStore stores the access token sealed by a hardware-backed key.
+// Only the sealed blob is written to disk, hence copying the config
+// directory to another machine doesn't leak a usable credential.
+type hardwareTokenStore struct {
+	path   string
+	sealer sealer
+}
+
+func (h *hardwareTokenStore) Read() (string, error) {
+	sealed, err := ioutil.ReadFile(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	token, err := h.sealer.Unseal(sealed)
+	if err != nil {
+		return "", fmt.Errorf("can't unseal access token: %s", err)
+	}
+
+	return string(token), nil
+}
+
+func (h *hardwareTokenStore) Write(token string) error {
+	if err := ensureConfigDir(); err != nil {
+		return err
+	}
+
+	sealed, err := h.sealer.Seal([]byte(token))
+	if err != nil {
+		return fmt.Errorf("can't seal access token: %s", err)
+	}
+
+	return ioutil.WriteFile(h.path, sealed, TokenFileMode)
+}
+
+func (h *hardwareTokenStore) Delete() error {
+	if err := h.sealer.Remove(); err != nil {
+		return err
+	}
+
+	err := os.Remove(h.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// errHardwareUnsupported is returned if the platform has no supported
+// hardware-backed key storage.
+var errHardwareUnsupported = errors.New("hardware-backed token storage is not supported on this platform")
+
+func sealedAccessTokenPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, profileName("access-token")+".sealed"), nil
+}
+
+func encryptedAccessTokenPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, profileName("access-token")+".encrypted"), nil
+}
+
+func ensureConfigDir() error {
+	configDir, err := ConfigDir()
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stat(configDir)
+	if os.IsNotExist(err) {
+		err := os.MkdirAll(configDir, 0771)
+		if err != nil {
+			return fmt.Errorf("error creating config directory: %s", err)
+		}
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/mattn/go-isatty"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// TokenStorageEncrypted encrypts the access token with a passphrase
+	// before it's persisted, for machines without a keyring or security
+	// hardware.
+	TokenStorageEncrypted = "encrypted"
+
+	passphraseEnv = "PSCALE_KEYRING_PASSPHRASE"
+
+	kdfIterations = 600000
+
+	// maxKDFIterations bounds the iterations read from the token file, so a
+	// tampered file can't make the key derivation run for hours.
+	maxKDFIterations = 10 * kdfIterations
+)
+
+// encryptedToken is the content of the encrypted access token file.
+type encryptedToken struct {
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// encryptedTokenStore stores the access token encrypted with AES-GCM, using
+// a key derived from a passphrase. A plaintext token left from the file
+// storage is migrated on first use.
+type encryptedTokenStore struct {
+	path      string
+	plainPath string
+
+	// passphrase returns the passphrase, confirm is set if a new file is
+	// written.
+	passphrase func(confirm bool) (string, error)
+}
+
+func (e *encryptedTokenStore) Read() (string, error) {
+	out, err := ioutil.ReadFile(e.path)
+	if os.IsNotExist(err) {
+		return e.migrate()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var t encryptedToken
+	if err := json.Unmarshal(out, &t); err != nil {
+		return "", fmt.Errorf("can't parse encrypted access token %q: %s", e.path, err)
+	}
+
+	if t.KDF != "pbkdf2-sha256" {
+		return "", fmt.Errorf("unsupported key derivation %q in %q", t.KDF, e.path)
+	}
+
+	passphrase, err := e.passphrase(false)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := newGCM(passphrase, t.Salt, t.Iterations)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := gcm.Open(nil, t.Nonce, t.Ciphertext, nil)
+	if err != nil {
+		return "", errors.New("can't decrypt access token, the passphrase is wrong")
+	}
+
+	return string(token), nil
+}
+
+func (e *encryptedTokenStore) Write(token string) error {
+	if err := ensureConfigDir(); err != nil {
+		return err
+	}
+
+	passphrase, err := e.passphrase(true)
+	if err != nil {
+		return err
+	}
+
+	t := encryptedToken{
+		KDF:        "pbkdf2-sha256",
+		Iterations: kdfIterations,
+		Salt:       make([]byte, 16),
+	}
+
+	if _, err := rand.Read(t.Salt); err != nil {
+		return err
+	}
+
+	gcm, err := newGCM(passphrase, t.Salt, t.Iterations)
+	if err != nil {
+		return err
+	}
+
+	t.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(t.Nonce); err != nil {
+		return err
+	}
+	t.Ciphertext = gcm.Seal(nil, t.Nonce, []byte(token), nil)
+
+	out, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(e.path, out, TokenFileMode); err != nil {
+		return err
+	}
+
+	// don't leave a plaintext token behind
+	if err := os.Remove(e.plainPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (e *encryptedTokenStore) Delete() error {
+	for _, p := range []string{e.path, e.plainPath} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrate encrypts a plaintext access token of the file storage, which
+// removes the plaintext file.
+func (e *encryptedTokenStore) migrate() (string, error) {
+	token, err := (&fileTokenStore{path: e.plainPath}).Read()
+	if err != nil || token == "" {
+		return "", err
+	}
+
+	if err := e.Write(token); err != nil {
+		return "", fmt.Errorf("can't encrypt the existing access token: %s", err)
+	}
+
+	return token, nil
+}
+
+// readPassphrase returns the passphrase from PSCALE_KEYRING_PASSPHRASE or
+// prompts for it.
+func readPassphrase(confirm bool) (string, error) {
+	if p := os.Getenv(passphraseEnv); p != "" {
+		return p, nil
+	}
+
+	if !isatty.IsTerminal(os.Stdin.Fd()) {
+		return "", fmt.Errorf("the access token is encrypted, set %s to decrypt it", passphraseEnv)
+	}
+
+	var passphrase string
+	err := survey.AskOne(&survey.Password{Message: "Passphrase for the access token:"}, &passphrase,
+		survey.WithValidator(survey.Required))
+	if err != nil || !confirm {
+		return passphrase, err
+	}
+
+	var again string
+	err = survey.AskOne(&survey.Password{Message: "Confirm the passphrase:"}, &again)
+	if err != nil {
+		return "", err
+	}
+
+	if again != passphrase {
+		return "", errors.New("passphrases don't match")
+	}
+
+	return passphrase, nil
+}
+
+func newGCM(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if iterations <= 0 || iterations > maxKDFIterations || len(salt) == 0 {
+		return nil, errors.New("invalid key derivation parameters")
+	}
+
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, iterations, 32, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestNewGCM_Iterations(t *testing.T) {
+	c := qt.New(t)
+
+	salt := []byte("salt")
+	_, err := newGCM("passphrase", salt, 1)
+	c.Assert(err, qt.IsNil)
+
+	for _, n := range []int{0, maxKDFIterations + 1} {
+		_, err := newGCM("passphrase", salt, n)
+		c.Assert(err, qt.ErrorMatches, "invalid key derivation parameters")
+	}
+}
+
+func TestEncryptedTokenStore(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.Mkdir()
+	passphrase := "correct horse"
+	store := &encryptedTokenStore{
+		path:      filepath.Join(dir, "access-token.encrypted"),
+		plainPath: filepath.Join(dir, "access-token"),
+		passphrase: func(bool) (string, error) {
+			return passphrase, nil
+		},
+	}
+
+	// a plaintext token is migrated on first read
+	c.Assert(ioutil.WriteFile(store.plainPath, []byte("pscale_oauth_123"), TokenFileMode), qt.IsNil)
+
+	token, err := store.Read()
+	c.Assert(err, qt.IsNil)
+	c.Assert(token, qt.Equals, "pscale_oauth_123")
+
+	_, err = os.Stat(store.plainPath)
+	c.Assert(os.IsNotExist(err), qt.IsTrue)
+
+	out, err := ioutil.ReadFile(store.path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Not(qt.Contains), "pscale_oauth_123")
+
+	token, err = store.Read()
+	c.Assert(err, qt.IsNil)
+	c.Assert(token, qt.Equals, "pscale_oauth_123")
+
+	passphrase = "wrong"
+	_, err = store.Read()
+	c.Assert(err, qt.ErrorMatches, "can't decrypt access token, the passphrase is wrong")
+
+	c.Assert(store.Delete(), qt.IsNil)
+	token, err = store.Read()
+	c.Assert(err, qt.IsNil)
+	c.Assert(token, qt.Equals, "")
+}
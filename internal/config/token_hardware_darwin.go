@@ -0,0 +1,90 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	exec "golang.org/x/sys/execabs"
+)
+
+const (
+	keychainService = "planetscale-cli"
+	keychainAccount = "access-token"
+
+	// errSecItemNotFound is the exit status of `security` if the keychain
+	// item doesn't exist.
+	errSecItemNotFound = 44
+)
+
+// keychainSealer stores secrets in the login Keychain, which on T2 and Apple
+// silicon Macs is protected by keys held in the Secure Enclave. The sealed
+// blob written to disk is only a reference to the Keychain item.
+type keychainSealer struct{}
+
+func newHardwareSealer() (sealer, error) {
+	if _, err := exec.LookPath("security"); err != nil {
+		return nil, fmt.Errorf("%s: the 'security' command is missing", errHardwareUnsupported)
+	}
+
+	return &keychainSealer{}, nil
+}
+
+func (k *keychainSealer) Seal(plaintext []byte) ([]byte, error) {
+	secret, err := securityQuote(string(plaintext))
+	if err != nil {
+		return nil, err
+	}
+
+	// The secret is passed via the interactive mode on stdin so it doesn't
+	// show up in the process list.
+	script := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+		keychainService, profileName(keychainAccount), secret)
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(script)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("security add-generic-password: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return []byte(fmt.Sprintf("keychain:%s/%s\n", keychainService, profileName(keychainAccount))), nil
+}
+
+// securityQuote quotes an argument of a command of the interactive mode of
+// `security`, which splits its input lines into arguments like a shell:
+// within double quotes, backslashes escape the next character. Line breaks
+// end the command, so they can't be quoted.
+func securityQuote(s string) (string, error) {
+	if strings.ContainsAny(s, "\r\n\x00") {
+		return "", errors.New("the secret can't contain line breaks or NUL characters")
+	}
+
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(s) + `"`, nil
+}
+
+func (k *keychainSealer) Unseal(sealed []byte) ([]byte, error) {
+	out, err := exec.Command("security", "find-generic-password",
+		"-s", keychainService, "-a", profileName(keychainAccount), "-w").Output()
+	if err != nil {
+		return nil, fmt.Errorf("security find-generic-password: %s", err)
+	}
+
+	return bytes.TrimSuffix(out, []byte("\n")), nil
+}
+
+func (k *keychainSealer) Remove() error {
+	err := exec.Command("security", "delete-generic-password",
+		"-s", keychainService, "-a", profileName(keychainAccount)).Run()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok && ee.ExitCode() == errSecItemNotFound {
+			return nil
+		}
+		return fmt.Errorf("security delete-generic-password: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestSecurityQuote(t *testing.T) {
+	c := qt.New(t)
+
+	for in, want := range map[string]string{
+		"pscale_oauth_123": `"pscale_oauth_123"`,
+		`a "b" c`:          `"a \"b\" c"`,
+		`back\slash`:       `"back\\slash"`,
+		"tab\tand space":   "\"tab\tand space\"",
+		"é":                `"é"`,
+	} {
+		got, err := securityQuote(in)
+		c.Assert(err, qt.IsNil)
+		c.Assert(got, qt.Equals, want)
+	}
+
+	_, err := securityQuote("two\nlines")
+	c.Assert(err, qt.ErrorMatches, "the secret can't contain line breaks or NUL characters")
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	exec "golang.org/x/sys/execabs"
+)
+
+const credentialName = "pscale-access-token"
+
+// tpmSealer seals secrets with the machine's TPM2 chip via systemd-creds.
+type tpmSealer struct {
+	path string
+}
+
+func newHardwareSealer() (sealer, error) {
+	path, err := exec.LookPath("systemd-creds")
+	if err != nil {
+		return nil, fmt.Errorf("%s: systemd-creds is required to use the TPM2 chip", errHardwareUnsupported)
+	}
+
+	return &tpmSealer{path: path}, nil
+}
+
+func (t *tpmSealer) Seal(plaintext []byte) ([]byte, error) {
+	return t.run(plaintext, "encrypt", "--with-key=tpm2", "--name="+profileName(credentialName), "-", "-")
+}
+
+func (t *tpmSealer) Unseal(sealed []byte) ([]byte, error) {
+	return t.run(sealed, "decrypt", "--name="+profileName(credentialName), "-", "-")
+}
+
+// Remove is a no-op, the sealed blob is only usable with this machine's TPM
+// and is removed by the caller.
+func (t *tpmSealer) Remove() error { return nil }
+
+func (t *tpmSealer) run(input []byte, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(t.path, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("systemd-creds %s: %s: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package config
+
+func newHardwareSealer() (sealer, error) {
+	return nil, errHardwareUnsupported
+}
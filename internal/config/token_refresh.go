@@ -0,0 +1,156 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenRefreshMargin is how long before its expiry an access token is
+// refreshed, so it doesn't expire during a request.
+const tokenRefreshMargin = time.Minute
+
+// Token is the OAuth token of a logged in user. Tokens with a refresh token
+// are stored as JSON, the tokens of older versions as the plain access token.
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at,omitempty"`
+
+	// AuthURL is the Auth API the token was issued by.
+	AuthURL string `json:"auth_url,omitempty"`
+}
+
+// TokenRefresher exchanges the refresh token of the token for a new token.
+type TokenRefresher func(ctx context.Context, t *Token) (*Token, error)
+
+// DecodeToken returns the token stored in a token store.
+func DecodeToken(stored string) *Token {
+	stored = strings.TrimSpace(stored)
+	if strings.HasPrefix(stored, "{") {
+		t := &Token{}
+		if err := json.Unmarshal([]byte(stored), t); err == nil {
+			return t
+		}
+	}
+
+	return &Token{AccessToken: stored}
+}
+
+// Encode returns the token as it is written to a token store. Tokens that
+// can't be refreshed are written plainly, so older versions can read them.
+func (t *Token) Encode() (string, error) {
+	if t.RefreshToken == "" {
+		return t.AccessToken, nil
+	}
+
+	out, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// refreshable reports whether the token is about to expire and can be
+// refreshed.
+func (t *Token) refreshable(now time.Time) bool {
+	return t.RefreshToken != "" && !t.ExpiresAt.IsZero() && now.Add(tokenRefreshMargin).After(t.ExpiresAt)
+}
+
+// refreshingTransport authorizes requests with the access token. The token
+// is refreshed shortly before it expires and the new one is persisted, so
+// long running commands and later invocations keep working.
+type refreshingTransport struct {
+	rt      http.RoundTripper
+	refresh TokenRefresher
+	store   func() (TokenStore, error)
+
+	// onRefresh is called with every new token.
+	onRefresh func(*Token)
+
+	mu    sync.Mutex
+	token *Token
+}
+
+// refreshingTransport returns a transport authorizing requests with the
+// stored token of the user, which is kept up to date in c.
+func (c *Config) refreshingTransport(rt http.RoundTripper) *refreshingTransport {
+	return &refreshingTransport{
+		rt:        rt,
+		refresh:   c.RefreshToken,
+		store:     NewTokenStore,
+		onRefresh: func(t *Token) { c.Token, c.AccessToken = t, t.AccessToken },
+		token:     c.Token,
+	}
+}
+
+// FreshAccessToken returns the access token, refreshed first if it's about
+// to expire, so it can be handed to other processes.
+func (c *Config) FreshAccessToken(ctx context.Context) (string, error) {
+	if err := c.LoadToken(); err != nil {
+		return "", err
+	}
+	if !c.refreshesToken() {
+		return c.AccessToken, nil
+	}
+
+	return c.refreshingTransport(nil).accessToken(ctx)
+}
+
+func (t *refreshingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.accessToken(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return t.rt.RoundTrip(req)
+}
+
+// accessToken returns the current access token, refreshing it if needed.
+func (t *refreshingTransport) accessToken(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.token.refreshable(time.Now()) {
+		return t.token.AccessToken, nil
+	}
+
+	token, err := t.refresh(ctx, t.token)
+	if err != nil {
+		if time.Now().Before(t.token.ExpiresAt) {
+			// the current token is still valid, try again with the
+			// next request
+			return t.token.AccessToken, nil
+		}
+		return "", fmt.Errorf("the access token expired and can't be refreshed, please run 'pscale auth login': %s", err)
+	}
+
+	if token.RefreshToken == "" {
+		token.RefreshToken = t.token.RefreshToken
+	}
+	if token.AuthURL == "" {
+		token.AuthURL = t.token.AuthURL
+	}
+	t.token = token
+
+	if t.onRefresh != nil {
+		t.onRefresh(token)
+	}
+
+	// the new token is used regardless, a failure to persist it only
+	// means it's refreshed again by the next invocation
+	if store, err := t.store(); err == nil {
+		if encoded, err := token.Encode(); err == nil {
+			_ = store.Write(encoded)
+		}
+	}
+
+	return token.AccessToken, nil
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestToken_Encode(t *testing.T) {
+	c := qt.New(t)
+
+	// tokens of older versions are plain access tokens
+	c.Assert(DecodeToken("plain-token\n"), qt.DeepEquals, &Token{AccessToken: "plain-token"})
+
+	plain, err := (&Token{AccessToken: "plain-token"}).Encode()
+	c.Assert(err, qt.IsNil)
+	c.Assert(plain, qt.Equals, "plain-token")
+
+	token := &Token{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		ExpiresAt:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		AuthURL:      "https://auth.example.com/",
+	}
+	encoded, err := token.Encode()
+	c.Assert(err, qt.IsNil)
+	c.Assert(DecodeToken(encoded), qt.DeepEquals, token)
+}
+
+type memTokenStore struct {
+	token string
+}
+
+func (m *memTokenStore) Read() (string, error) { return m.token, nil }
+func (m *memTokenStore) Write(token string) error {
+	m.token = token
+	return nil
+}
+func (m *memTokenStore) Delete() error {
+	m.token = ""
+	return nil
+}
+
+func TestRefreshingTransport(t *testing.T) {
+	c := qt.New(t)
+
+	var auth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	refreshed := 0
+	store := &memTokenStore{}
+	rt := &refreshingTransport{
+		rt: http.DefaultTransport,
+		refresh: func(ctx context.Context, t *Token) (*Token, error) {
+			refreshed++
+			c.Assert(t.RefreshToken, qt.Equals, "refresh")
+			return &Token{AccessToken: "new", ExpiresAt: time.Now().Add(time.Hour)}, nil
+		},
+		store: func() (TokenStore, error) { return store, nil },
+		token: &Token{AccessToken: "old", RefreshToken: "refresh", ExpiresAt: time.Now().Add(30 * time.Second)},
+	}
+	client := &http.Client{Transport: rt}
+
+	for i := 0; i < 2; i++ {
+		res, err := client.Get(srv.URL)
+		c.Assert(err, qt.IsNil)
+		res.Body.Close()
+	}
+
+	c.Assert(refreshed, qt.Equals, 1)
+	c.Assert(auth, qt.DeepEquals, []string{"Bearer new", "Bearer new"})
+
+	// the refresh token is kept if the Auth API doesn't rotate it
+	stored := DecodeToken(store.token)
+	c.Assert(stored.AccessToken, qt.Equals, "new")
+	c.Assert(stored.RefreshToken, qt.Equals, "refresh")
+}
+
+func TestRefreshingTransport_Expired(t *testing.T) {
+	c := qt.New(t)
+
+	rt := &refreshingTransport{
+		rt: http.DefaultTransport,
+		refresh: func(ctx context.Context, t *Token) (*Token, error) {
+			return nil, errors.New("invalid_grant")
+		},
+		store: func() (TokenStore, error) { return &memTokenStore{}, nil },
+		token: &Token{AccessToken: "old", RefreshToken: "refresh", ExpiresAt: time.Now().Add(-time.Minute)},
+	}
+
+	_, err := rt.accessToken(context.Background())
+	c.Assert(err, qt.ErrorMatches, "the access token expired and can't be refreshed, please run 'pscale auth login': invalid_grant")
+
+	// a valid token is used if refreshing it early fails
+	rt.token.ExpiresAt = time.Now().Add(30 * time.Second)
+	token, err := rt.accessToken(context.Background())
+	c.Assert(err, qt.IsNil)
+	c.Assert(token, qt.Equals, "old")
+}
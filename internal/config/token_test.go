@@ -0,0 +1,173 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestFileTokenStore(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	p, err := AccessTokenPath()
+	c.Assert(err, qt.IsNil)
+	store := &fileTokenStore{path: p}
+
+	token, err := store.Read()
+	c.Assert(err, qt.IsNil)
+	c.Assert(token, qt.Equals, "")
+
+	c.Assert(store.Write("pscale_oauth_123"), qt.IsNil)
+	token, err = store.Read()
+	c.Assert(err, qt.IsNil)
+	c.Assert(token, qt.Equals, "pscale_oauth_123")
+
+	if runtime.GOOS != "windows" {
+		// too permissive modes are fixed on read
+		c.Assert(os.Chmod(p, 0644), qt.IsNil)
+		_, err = store.Read()
+		c.Assert(err, qt.IsNil)
+
+		stat, err := os.Stat(p)
+		c.Assert(err, qt.IsNil)
+		c.Assert(stat.Mode().Perm(), qt.Equals, os.FileMode(TokenFileMode))
+	}
+
+	c.Assert(store.Delete(), qt.IsNil)
+	_, err = os.Stat(p)
+	c.Assert(os.IsNotExist(err), qt.IsTrue)
+
+	// deleting a missing token isn't an error
+	c.Assert(store.Delete(), qt.IsNil)
+}
+
+// fakeSealer "seals" secrets by prefixing them.
+type fakeSealer struct {
+	err     error
+	removed bool
+}
+
+func (f *fakeSealer) Seal(plaintext []byte) ([]byte, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return append([]byte("sealed:"), plaintext...), nil
+}
+
+func (f *fakeSealer) Unseal(sealed []byte) ([]byte, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if !strings.HasPrefix(string(sealed), "sealed:") {
+		return nil, errors.New("not sealed")
+	}
+	return sealed[len("sealed:"):], nil
+}
+
+func (f *fakeSealer) Remove() error {
+	f.removed = true
+	return f.err
+}
+
+func TestHardwareTokenStore(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	p, err := sealedAccessTokenPath()
+	c.Assert(err, qt.IsNil)
+	sealer := &fakeSealer{}
+	store := &hardwareTokenStore{path: p, sealer: sealer}
+
+	token, err := store.Read()
+	c.Assert(err, qt.IsNil)
+	c.Assert(token, qt.Equals, "")
+
+	c.Assert(store.Write("pscale_oauth_123"), qt.IsNil)
+
+	// only the sealed token is written to disk
+	out, err := ioutil.ReadFile(p)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, "sealed:pscale_oauth_123")
+
+	token, err = store.Read()
+	c.Assert(err, qt.IsNil)
+	c.Assert(token, qt.Equals, "pscale_oauth_123")
+
+	sealer.err = errors.New("no TPM")
+	_, err = store.Read()
+	c.Assert(err, qt.ErrorMatches, "can't unseal access token: no TPM")
+	c.Assert(store.Write("pscale_oauth_456"), qt.ErrorMatches, "can't seal access token: no TPM")
+	c.Assert(store.Delete(), qt.ErrorMatches, "no TPM")
+
+	sealer.err = nil
+	c.Assert(store.Delete(), qt.IsNil)
+	c.Assert(sealer.removed, qt.IsTrue)
+	_, err = os.Stat(p)
+	c.Assert(os.IsNotExist(err), qt.IsTrue)
+}
+
+func TestOpenTokenStore(t *testing.T) {
+	tests := []struct {
+		name    string
+		storage string
+		files   []func() (string, error)
+		env     string
+		want    string
+		wantErr string
+	}{
+		{name: "file by default", want: "*config.fileTokenStore"},
+		{name: "file", storage: TokenStorageFile, files: []func() (string, error){encryptedAccessTokenPath}, want: "*config.fileTokenStore"},
+		{name: "hardware", storage: TokenStorageHardware, want: "*config.hardwareTokenStore"},
+		{name: "encrypted", storage: TokenStorageEncrypted, want: "*config.encryptedTokenStore"},
+		{name: "sealed token exists", files: []func() (string, error){sealedAccessTokenPath, encryptedAccessTokenPath}, want: "*config.hardwareTokenStore"},
+		{name: "encrypted token exists", files: []func() (string, error){encryptedAccessTokenPath}, want: "*config.encryptedTokenStore"},
+		{name: "passphrase", env: "secret", want: "*config.encryptedTokenStore"},
+		{name: "invalid", storage: "keyring", wantErr: `invalid PSCALE_TOKEN_STORAGE value "keyring", allowed values are: file, hardware, encrypted`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+			testutil.TempHome(t)
+			c.Setenv(passphraseEnv, tt.env)
+			c.Patch(&hardwareSealer, func() (sealer, error) { return &fakeSealer{}, nil })
+
+			c.Assert(ensureConfigDir(), qt.IsNil)
+			for _, path := range tt.files {
+				p, err := path()
+				c.Assert(err, qt.IsNil)
+				c.Assert(ioutil.WriteFile(p, []byte("token"), TokenFileMode), qt.IsNil)
+			}
+
+			store, err := OpenTokenStore(tt.storage)
+			if tt.wantErr != "" {
+				c.Assert(err, qt.ErrorMatches, tt.wantErr)
+				return
+			}
+			c.Assert(err, qt.IsNil)
+			c.Assert(fmt.Sprintf("%T", store), qt.Equals, tt.want)
+		})
+	}
+}
+
+func TestOpenTokenStore_HardwareUnsupported(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+	c.Patch(&hardwareSealer, func() (sealer, error) { return nil, errHardwareUnsupported })
+
+	_, err := OpenTokenStore(TokenStorageHardware)
+	c.Assert(err, qt.Equals, errHardwareUnsupported)
+
+	// the file store doesn't need the hardware
+	_, err = OpenTokenStore(TokenStorageFile)
+	c.Assert(err, qt.IsNil)
+}
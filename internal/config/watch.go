@@ -0,0 +1,19 @@
+package config
+
+import "time"
+
+// WatchedQuery is a query fingerprint that 'pscale insights watch' checks
+// for regressions, as printed by 'pscale insights slow-queries export
+// --digest'.
+type WatchedQuery struct {
+	Fingerprint string `yaml:"fingerprint" json:"fingerprint"`
+
+	// Name identifies the query in the output instead of the fingerprint.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// MaxP95 is the 95th percentile latency the query may not exceed.
+	MaxP95 time.Duration `yaml:"max-p95,omitempty" json:"max-p95,omitempty"`
+
+	// MaxErrorRate is the percentage of the executions that may fail.
+	MaxErrorRate float64 `yaml:"max-error-rate,omitempty" json:"max-error-rate,omitempty"`
+}
@@ -0,0 +1,181 @@
+// Package cost projects the monthly cost of an organization based on the
+// pricing of its plan.
+package cost
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/planetscale/cli/internal/config"
+
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+const gigabyte = 1 << 30
+
+// Usage is the projected monthly usage of one or more databases. RowsRead is
+// counted in billions, RowsWritten in millions.
+type Usage struct {
+	ProductionBranches  int
+	DevelopmentBranches int
+	StorageBytes        int64
+	RowsRead            float64
+	RowsWritten         float64
+}
+
+// Add adds the given usage to u.
+func (u *Usage) Add(o *Usage) {
+	u.ProductionBranches += o.ProductionBranches
+	u.DevelopmentBranches += o.DevelopmentBranches
+	u.StorageBytes += o.StorageBytes
+	u.RowsRead += o.RowsRead
+	u.RowsWritten += o.RowsWritten
+}
+
+// StorageGB returns the storage in gigabytes.
+func (u *Usage) StorageGB() float64 {
+	return float64(u.StorageBytes) / gigabyte
+}
+
+// Item is a single line of a cost estimate.
+type Item struct {
+	Name     string  `header:"item" json:"item"`
+	Usage    string  `header:"usage" json:"usage"`
+	Included string  `header:"included" json:"included"`
+	Cost     float64 `header:"cost" json:"cost"`
+}
+
+// Estimate is the projected monthly cost of an organization.
+type Estimate struct {
+	Items []*Item `json:"items"`
+	Total float64 `json:"total"`
+}
+
+// Calculate projects the monthly cost of the usage with the given pricing.
+// Usage within the included amounts of the plan is free.
+func Calculate(p *config.Pricing, u *Usage) *Estimate {
+	e := &Estimate{}
+	add := func(name, usage, included string, cost float64) {
+		cost = math.Round(cost*100) / 100
+		e.Items = append(e.Items, &Item{Name: name, Usage: usage, Included: included, Cost: cost})
+		e.Total += cost
+	}
+
+	add("plan", "", "", p.Base)
+	add("production branches", fmt.Sprintf("%d", u.ProductionBranches), "",
+		float64(u.ProductionBranches)*p.ProductionBranch)
+	add("development branches", fmt.Sprintf("%d", u.DevelopmentBranches), "",
+		float64(u.DevelopmentBranches)*p.DevelopmentBranch)
+	add("storage", fmt.Sprintf("%.2f GB", u.StorageGB()), fmt.Sprintf("%g GB", p.IncludedStorageGB),
+		overage(u.StorageGB(), p.IncludedStorageGB)*p.StorageGB)
+	add("rows read", fmt.Sprintf("%g billion", u.RowsRead), fmt.Sprintf("%g billion", p.IncludedRowsRead),
+		overage(u.RowsRead, p.IncludedRowsRead)*p.RowsRead)
+	add("rows written", fmt.Sprintf("%g million", u.RowsWritten), fmt.Sprintf("%g million", p.IncludedRowsWritten),
+		overage(u.RowsWritten, p.IncludedRowsWritten)*p.RowsWritten)
+
+	e.Total = math.Round(e.Total*100) / 100
+	return e
+}
+
+func overage(used, included float64) float64 {
+	if used <= included {
+		return 0
+	}
+	return used - included
+}
+
+// Collect returns the branch and storage usage of the given databases or of
+// all databases of the organization if none are given. The storage of a
+// database is approximated by the size of the latest completed backup of
+// each of its production branches.
+func Collect(ctx context.Context, client *ps.Client, org string, databases ...string) (*Usage, error) {
+	if len(databases) == 0 {
+		dbs, err := client.Databases.List(ctx, &ps.ListDatabasesRequest{Organization: org})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, db := range dbs {
+			databases = append(databases, db.Name)
+		}
+	}
+
+	usage := &Usage{}
+	for _, db := range databases {
+		branches, err := client.DatabaseBranches.List(ctx, &ps.ListDatabaseBranchesRequest{
+			Organization: org,
+			Database:     db,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, b := range branches {
+			if !b.Production {
+				usage.DevelopmentBranches++
+				continue
+			}
+
+			usage.ProductionBranches++
+
+			backups, err := client.Backups.List(ctx, &ps.ListBackupsRequest{
+				Organization: org,
+				Database:     db,
+				Branch:       b.Name,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			usage.StorageBytes += LatestBackupSize(backups)
+		}
+	}
+
+	return usage, nil
+}
+
+// LatestBackupSize returns the size of the most recently completed backup.
+func LatestBackupSize(backups []*ps.Backup) int64 {
+	var latest *ps.Backup
+	for _, b := range backups {
+		if b.State != "success" {
+			continue
+		}
+		if latest == nil || b.CompletedAt.After(latest.CompletedAt) {
+			latest = b
+		}
+	}
+
+	if latest == nil {
+		return 0
+	}
+	return latest.Size
+}
+
+// CheckBudget returns the estimate of the organization including the extra
+// usage if it exceeds the configured budget. It returns nil if no budget is
+// configured or the estimate is within the budget.
+func CheckBudget(ctx context.Context, client *ps.Client, cfg *config.Config, extra *Usage) (*Estimate, error) {
+	if cfg.Budget <= 0 {
+		return nil, nil
+	}
+
+	pricing, err := cfg.PlanPricing(cfg.Plan)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := Collect(ctx, client, cfg.Organization)
+	if err != nil {
+		return nil, err
+	}
+	usage.Add(extra)
+
+	e := Calculate(pricing, usage)
+	if e.Total <= cfg.Budget {
+		return nil, nil
+	}
+
+	return e, nil
+}
@@ -0,0 +1,104 @@
+package cost
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/mock"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestCalculate(t *testing.T) {
+	c := qt.New(t)
+
+	e := Calculate(config.Plans["scaler"], &Usage{
+		ProductionBranches:  1,
+		DevelopmentBranches: 3,
+		StorageBytes:        14 * gigabyte,
+		RowsRead:            120,
+		RowsWritten:         40,
+	})
+
+	costs := make(map[string]float64)
+	for _, item := range e.Items {
+		costs[item.Name] = item.Cost
+	}
+
+	c.Assert(costs, qt.DeepEquals, map[string]float64{
+		"plan":                 29,
+		"production branches":  0,
+		"development branches": 0,
+		"storage":              10,
+		"rows read":            20,
+		"rows written":         0,
+	})
+	c.Assert(e.Total, qt.Equals, 59.0)
+}
+
+func TestCollect(t *testing.T) {
+	c := qt.New(t)
+
+	now := time.Now()
+	branches := &mock.DatabaseBranchesService{
+		ListFn: func(ctx context.Context, req *ps.ListDatabaseBranchesRequest) ([]*ps.DatabaseBranch, error) {
+			c.Assert(req.Database, qt.Equals, "mydb")
+			return []*ps.DatabaseBranch{
+				{Name: "main", Production: true},
+				{Name: "dev"},
+			}, nil
+		},
+	}
+	backups := &mock.BackupsService{
+		ListFn: func(ctx context.Context, req *ps.ListBackupsRequest) ([]*ps.Backup, error) {
+			c.Assert(req.Branch, qt.Equals, "main")
+			return []*ps.Backup{
+				{State: "success", Size: 100, CompletedAt: now.Add(-time.Hour)},
+				{State: "success", Size: 200, CompletedAt: now},
+				{State: "running", Size: 300},
+			}, nil
+		},
+	}
+
+	client := &ps.Client{DatabaseBranches: branches, Backups: backups}
+	usage, err := Collect(context.Background(), client, "org", "mydb")
+	c.Assert(err, qt.IsNil)
+	c.Assert(usage, qt.DeepEquals, &Usage{
+		ProductionBranches:  1,
+		DevelopmentBranches: 1,
+		StorageBytes:        200,
+	})
+}
+
+func TestCheckBudget(t *testing.T) {
+	c := qt.New(t)
+
+	branches := &mock.DatabaseBranchesService{
+		ListFn: func(ctx context.Context, req *ps.ListDatabaseBranchesRequest) ([]*ps.DatabaseBranch, error) {
+			return []*ps.DatabaseBranch{{Name: "dev"}}, nil
+		},
+	}
+	databases := &mock.DatabaseService{
+		ListFn: func(ctx context.Context, req *ps.ListDatabasesRequest) ([]*ps.Database, error) {
+			return []*ps.Database{{Name: "mydb"}}, nil
+		},
+	}
+	client := &ps.Client{Databases: databases, DatabaseBranches: branches}
+
+	cfg := &config.Config{
+		Organization: "org",
+		Pricing:      &config.Pricing{Base: 10, DevelopmentBranch: 5},
+		Budget:       20,
+	}
+
+	e, err := CheckBudget(context.Background(), client, cfg, &Usage{DevelopmentBranches: 1})
+	c.Assert(err, qt.IsNil)
+	c.Assert(e, qt.IsNil)
+
+	e, err = CheckBudget(context.Background(), client, cfg, &Usage{DevelopmentBranches: 2})
+	c.Assert(err, qt.IsNil)
+	c.Assert(e.Total, qt.Equals, 25.0)
+}
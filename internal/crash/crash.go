@@ -0,0 +1,204 @@
+// Package crash writes crash reports when the CLI panics, so users can
+// attach them to an issue instead of pasting a raw Go panic.
+package crash
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/planetscale/cli/internal/config"
+)
+
+const (
+	crashesDir = "crashes"
+	maxLogs    = 200
+	issueURL   = "https://github.com/planetscale/cli/issues/new"
+
+	// ExitCode is the exit code of the CLI after a crash, the internal
+	// software error of sysexits.h. It's apart from the exit codes of
+	// commands, i.e. 2 for failed checks and 3 for false conditions.
+	ExitCode = 70
+)
+
+// Log keeps the most recent log lines of the CLI for crash reports.
+var Log = &ring{max: maxLogs}
+
+// Report is the content of a crash report.
+type Report struct {
+	Time      time.Time       `json:"time"`
+	Version   string          `json:"version"`
+	Commit    string          `json:"commit"`
+	BuildDate string          `json:"build_date"`
+	GoVersion string          `json:"go_version"`
+	Platform  string          `json:"platform"`
+	Args      []string        `json:"args"`
+	Panic     string          `json:"panic"`
+	Stack     string          `json:"stack"`
+	Config    []*config.Value `json:"config"`
+	Logs      []string        `json:"logs"`
+}
+
+// NewReport returns the crash report of the recovered panic value and the
+// stack trace it was recovered at.
+func NewReport(cfs *config.ConfigFS, recovered interface{}, stack []byte, ver, commit, buildDate string) *Report {
+	return &Report{
+		Time:      time.Now().UTC(),
+		Version:   ver,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Args:      sanitizeArgs(os.Args[1:]),
+		Panic:     fmt.Sprint(recovered),
+		Stack:     string(stack),
+		Config:    sanitizedConfig(cfs),
+		Logs:      Log.Lines(),
+	}
+}
+
+// Write persists the report to the crashes directory and returns its path.
+func (r *Report) Write() (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	dir = path.Join(dir, crashesDir)
+	if err := os.MkdirAll(dir, 0771); err != nil {
+		return "", fmt.Errorf("error creating crashes directory: %s", err)
+	}
+
+	out, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	p := path.Join(dir, fmt.Sprintf("crash-%s.json", r.Time.Format("20060102T150405Z")))
+	return p, ioutil.WriteFile(p, out, config.TokenFileMode)
+}
+
+// Handle writes a crash report of the recovered panic value and prints
+// instructions to w. It has to be called from the deferred function that
+// recovered, so the stack trace points to the panic. It returns ExitCode.
+func Handle(w io.Writer, cfs *config.ConfigFS, recovered interface{}, ver, commit, buildDate string) int {
+	r := NewReport(cfs, recovered, debug.Stack(), ver, commit, buildDate)
+
+	fmt.Fprintf(w, "Error: pscale crashed unexpectedly: %s\n", r.Panic)
+
+	p, err := r.Write()
+	if err != nil {
+		fmt.Fprintf(w, "Writing the crash report failed (%s):\n\n%s\n", err, r.Stack)
+		fmt.Fprintf(w, "Please open an issue at %s and include the output above.\n", issueURL)
+		return ExitCode
+	}
+
+	fmt.Fprintf(w, "A crash report was written to %s\n", p)
+	fmt.Fprintf(w, "Please open an issue at %s and attach the crash report.\n", issueURL)
+	return ExitCode
+}
+
+// sensitiveFlags are flags whose values are redacted in crash reports.
+var sensitiveFlags = []string{"token", "password", "secret"}
+
+func sanitizeArgs(args []string) []string {
+	out := make([]string, len(args))
+	redactNext := false
+	for i, arg := range args {
+		switch {
+		case redactNext:
+			out[i] = "********"
+			redactNext = false
+		case strings.HasPrefix(arg, "-") && isSensitive(arg):
+			if j := strings.Index(arg, "="); j > 0 {
+				out[i] = arg[:j+1] + "********"
+			} else {
+				out[i] = arg
+				redactNext = true
+			}
+		default:
+			out[i] = arg
+		}
+	}
+	return out
+}
+
+func isSensitive(flag string) bool {
+	for _, s := range sensitiveFlags {
+		if strings.Contains(strings.ToLower(flag), s) {
+			return true
+		}
+	}
+	return false
+}
+
+func sanitizedConfig(cfs *config.ConfigFS) []*config.Value {
+	layers := []*config.Layer{config.DefaultLayer()}
+
+	for _, l := range []struct {
+		source config.Source
+		path   func() (string, error)
+	}{
+		{config.SourceGlobal, config.DefaultConfigPath},
+		{config.SourceProject, config.ProjectConfigPath},
+	} {
+		p, err := l.path()
+		if err != nil {
+			continue
+		}
+
+		layer, err := cfs.NewLayer(l.source, p)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		layers = append(layers, layer)
+	}
+	layers = append(layers, config.EnvLayer(nil))
+
+	values := config.Resolve(layers...)
+	for _, v := range values {
+		v.Value = config.Redact(v.Key, v.Value)
+		if isSensitive(v.Key) && v.Value != "" {
+			v.Value = "********"
+		}
+	}
+	return values
+}
+
+// ring is an io.Writer keeping the last max lines written to it.
+type ring struct {
+	mu    sync.Mutex
+	max   int
+	lines []string
+}
+
+func (r *ring) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		r.lines = append(r.lines, line)
+	}
+	if len(r.lines) > r.max {
+		r.lines = r.lines[len(r.lines)-r.max:]
+	}
+
+	return len(p), nil
+}
+
+// Lines returns a copy of the kept lines.
+func (r *ring) Lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.lines...)
+}
@@ -0,0 +1,76 @@
+package crash
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestHandle(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	globalPath, err := config.DefaultConfigPath()
+	c.Assert(err, qt.IsNil)
+	cfs := config.NewConfigFS(testutil.MemFS{
+		globalPath: &fstest.MapFile{Data: []byte("org: acme\nservice-token: secret\n")},
+	})
+
+	Log.Write([]byte("debug\tfetching branches\n")) // nolint:errcheck
+
+	var buf bytes.Buffer
+	var code int
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				code = Handle(&buf, cfs, r, "v1.0.0", "abc", "2021-09-27")
+			}
+		}()
+		panic("boom")
+	}()
+
+	c.Assert(code, qt.Equals, ExitCode)
+	c.Assert(buf.String(), qt.Matches, `(?s)Error: pscale crashed unexpectedly: boom\nA crash report was written to .*crash-.*\.json\n.*`)
+
+	p := regexp.MustCompile(`written to (\S+)`).FindStringSubmatch(buf.String())[1]
+	out, err := ioutil.ReadFile(p)
+	c.Assert(err, qt.IsNil)
+
+	var r Report
+	c.Assert(json.Unmarshal(out, &r), qt.IsNil)
+	c.Assert(r.Panic, qt.Equals, "boom")
+	c.Assert(r.Version, qt.Equals, "v1.0.0")
+	c.Assert(r.Stack, qt.Contains, "crash.TestHandle")
+	c.Assert(r.Logs, qt.Contains, "debug\tfetching branches")
+
+	redacted := make(map[string]string)
+	for _, v := range r.Config {
+		redacted[v.Key] = v.Value
+	}
+	c.Assert(redacted["org"], qt.Equals, "acme")
+	c.Assert(redacted["service-token"], qt.Equals, "********")
+}
+
+func TestSanitizeArgs(t *testing.T) {
+	c := qt.New(t)
+
+	args := sanitizeArgs([]string{"branch", "list", "--service-token", "secret", "--api-token=secret", "--org", "acme"})
+	c.Assert(args, qt.DeepEquals, []string{"branch", "list", "--service-token", "********", "--api-token=********", "--org", "acme"})
+}
+
+func TestRing(t *testing.T) {
+	c := qt.New(t)
+
+	r := &ring{max: 2}
+	r.Write([]byte("a\nb\n")) // nolint:errcheck
+	r.Write([]byte("c\n"))    // nolint:errcheck
+	c.Assert(r.Lines(), qt.DeepEquals, []string{"b", "c"})
+}
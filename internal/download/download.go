@@ -0,0 +1,115 @@
+// Package download downloads artifacts to files, continuing interrupted
+// downloads where they stopped.
+package download
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// partSuffix is the suffix of the file a download is written to until it's
+// complete.
+const partSuffix = ".part"
+
+// File downloads the content at url to path. The content is written to
+// path.part first, and a download interrupted before is continued from the
+// end of that file with a range request, if the server supports them.
+//
+// If a SHA-256 checksum is given, the complete content is checked against
+// it before it's moved to path, and a file already at path is only
+// downloaded again if it doesn't match. A part file not matching is removed,
+// so the next download starts over.
+func File(ctx context.Context, client *http.Client, url, path, checksum string) error {
+	if checksum != "" {
+		if sum, err := fileChecksum(path); err == nil && strings.EqualFold(sum, checksum) {
+			return nil
+		}
+	}
+
+	part := path + partSuffix
+	if err := fetch(ctx, client, url, part); err != nil {
+		return err
+	}
+
+	if checksum != "" {
+		sum, err := fileChecksum(part)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(sum, checksum) {
+			os.Remove(part) // nolint:errcheck
+			return fmt.Errorf("checksum of %s doesn't match: got %s, want %s", url, sum, checksum)
+		}
+	}
+
+	return os.Rename(part, path)
+}
+
+// fetch writes the content at url to part, appending to what's already
+// there if the server returns the rest of it.
+func fetch(ctx context.Context, client *http.Client, url, part string) error {
+	var offset int64
+	if fi, err := os.Stat(part); err == nil {
+		offset = fi.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0 &&
+		strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)):
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// the part file is complete already
+		return nil
+	case resp.StatusCode == http.StatusOK:
+		// the server ignored the range, the download starts over
+		flags |= os.O_TRUNC
+	default:
+		return fmt.Errorf("error downloading %s: %s", url, resp.Status)
+	}
+
+	f, err := os.OpenFile(part, flags, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return fmt.Errorf("download of %s was interrupted, run the command again to continue it: %s", url, err)
+	}
+	return f.Close()
+}
+
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,74 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestFile(t *testing.T) {
+	c := qt.New(t)
+
+	content := bytes.Repeat([]byte("backup "), 1000)
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+
+	var ranges []string
+	ignoreRange := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if ignoreRange {
+			r.Header.Del("Range")
+		}
+		http.ServeContent(w, r, "backup", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	dir := c.Mkdir()
+	path := filepath.Join(dir, "backup.tar")
+	ctx := context.Background()
+
+	// an interrupted download continues from the end of the part file
+	c.Assert(ioutil.WriteFile(path+partSuffix, content[:3000], 0o644), qt.IsNil)
+	c.Assert(File(ctx, srv.Client(), srv.URL, path, checksum), qt.IsNil)
+	c.Assert(ranges, qt.DeepEquals, []string{"bytes=3000-"})
+	got, err := ioutil.ReadFile(path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(got, qt.DeepEquals, content)
+	_, err = os.Stat(path + partSuffix)
+	c.Assert(os.IsNotExist(err), qt.IsTrue)
+
+	// a complete download isn't downloaded again
+	c.Assert(File(ctx, srv.Client(), srv.URL, path, checksum), qt.IsNil)
+	c.Assert(ranges, qt.HasLen, 1)
+
+	// servers ignoring the range send everything again
+	c.Assert(os.Remove(path), qt.IsNil)
+	c.Assert(ioutil.WriteFile(path+partSuffix, content[:10], 0o644), qt.IsNil)
+	ignoreRange = true
+	c.Assert(File(ctx, srv.Client(), srv.URL, path, checksum), qt.IsNil)
+	got, err = ioutil.ReadFile(path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(got, qt.DeepEquals, content)
+
+	// a corrupt part file is removed
+	c.Assert(os.Remove(path), qt.IsNil)
+	c.Assert(ioutil.WriteFile(path+partSuffix, []byte("corrupt"), 0o644), qt.IsNil)
+	ignoreRange = false
+	err = File(ctx, srv.Client(), srv.URL, path, checksum)
+	c.Assert(err, qt.ErrorMatches, "checksum of .* doesn't match: got .*, want "+checksum)
+	_, err = os.Stat(path + partSuffix)
+	c.Assert(os.IsNotExist(err), qt.IsTrue)
+
+	c.Assert(File(ctx, srv.Client(), srv.URL, path, checksum), qt.IsNil)
+}
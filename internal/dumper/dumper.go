@@ -2,6 +2,7 @@ package dumper
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
@@ -44,6 +45,11 @@ type Config struct {
 	Selects              map[string]map[string]string
 	Filters              map[string]map[string]string
 
+	// Compress writes the schema and data files gzipped.
+	Compress bool
+	// Resume skips the files a previous restore of Outdir completed.
+	Resume bool
+
 	// Interval in millisecond.
 	IntervalMs int
 	Debug      bool
@@ -116,6 +122,8 @@ func (d *Dumper) Run(ctx context.Context) error {
 
 	// TODO(fatih): use errgroup
 	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var tableErr error
 	for i, database := range databases {
 		pool, err := NewPool(d.log, d.cfg.Threads/len(databases), d.cfg.Address, d.cfg.User, d.cfg.Password, d.cfg.SessionVars, database)
 		if err != nil {
@@ -150,6 +158,7 @@ func (d *Dumper) Run(ctx context.Context) error {
 				err := d.dumpTable(conn, database, table)
 				if err != nil {
 					d.log.Error("error dumping table", zap.Error(err))
+					errOnce.Do(func() { tableErr = fmt.Errorf("dumping table %s: %s", table, err) })
 				}
 
 			}(conn, database, table)
@@ -175,6 +184,10 @@ func (d *Dumper) Run(ctx context.Context) error {
 	}()
 
 	wg.Wait()
+	if tableErr != nil {
+		return tableErr
+	}
+
 	elapsed := time.Since(t)
 	d.log.Info(
 		"dumping all done",
@@ -199,7 +212,7 @@ func (d *Dumper) dumpTableSchema(conn *Connection, database string, table string
 
 	schema := qr.Rows[0][1].String() + ";\n"
 
-	file := fmt.Sprintf("%s/%s.%s-schema.sql", d.cfg.Outdir, database, table)
+	file := fmt.Sprintf("%s/%s.%s-schema%s", d.cfg.Outdir, database, table, d.ext())
 	err = writeFile(file, schema)
 	if err != nil {
 		return err
@@ -308,7 +321,7 @@ func (d *Dumper) dumpTable(conn *Connection, database string, table string) erro
 
 		if (chunkbytes / 1024 / 1024) >= d.cfg.ChunksizeInMB {
 			query := strings.Join(inserts, ";\n") + ";\n"
-			file := fmt.Sprintf("%s/%s.%s.%05d.sql", d.cfg.Outdir, database, table, fileNo)
+			file := fmt.Sprintf("%s/%s.%s.%05d%s", d.cfg.Outdir, database, table, fileNo, d.ext())
 			err = writeFile(file, query)
 			if err != nil {
 				return err
@@ -336,7 +349,7 @@ func (d *Dumper) dumpTable(conn *Connection, database string, table string) erro
 		}
 
 		query := strings.Join(inserts, ";\n") + ";\n"
-		file := fmt.Sprintf("%s/%s.%s.%05d.sql", d.cfg.Outdir, database, table, fileNo)
+		file := fmt.Sprintf("%s/%s.%s.%05d%s", d.cfg.Outdir, database, table, fileNo, d.ext())
 		err = writeFile(file, query)
 		if err != nil {
 			return err
@@ -358,6 +371,14 @@ func (d *Dumper) dumpTable(conn *Connection, database string, table string) erro
 	return nil
 }
 
+// ext returns the extension of the schema and data files.
+func (d *Dumper) ext() string {
+	if d.cfg.Compress {
+		return tableSuffix + gzipSuffix
+	}
+	return tableSuffix
+}
+
 func (d *Dumper) allTables(conn *Connection, database string) ([]string, error) {
 	qr, err := conn.Fetch(fmt.Sprintf("SHOW TABLES FROM `%s`", database))
 	if err != nil {
@@ -427,7 +448,8 @@ func (d *Dumper) generatedFields(conn *Connection, table string) (map[string]boo
 	return fields, nil
 }
 
-// writeFile used to write datas to file.
+// writeFile used to write datas to file. Files ending with ".gz" are
+// written gzipped.
 func writeFile(file string, data string) error {
 	flag := os.O_RDWR | os.O_TRUNC
 	if _, err := os.Stat(file); os.IsNotExist(err) {
@@ -439,14 +461,27 @@ func writeFile(file string, data string) error {
 	}
 	defer f.Close()
 
-	n, err := f.Write([]byte(data))
+	var w io.Writer = f
+	var zw *gzip.Writer
+	if strings.HasSuffix(file, gzipSuffix) {
+		zw = gzip.NewWriter(f)
+		w = zw
+	}
+
+	n, err := w.Write([]byte(data))
 	if err != nil {
 		return err
 	}
 	if n != len(data) {
 		return io.ErrShortWrite
 	}
-	return nil
+
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	}
+	return f.Close()
 }
 
 // escapeBytes used to escape the literal byte.
@@ -1,6 +1,7 @@
 package dumper
 
 import (
+	"compress/gzip"
 	"context"
 	"fmt"
 	"io/ioutil"
@@ -28,11 +29,13 @@ const (
 	dbSuffix     = "-schema-create.sql"
 	schemaSuffix = "-schema.sql"
 	tableSuffix  = ".sql"
+	gzipSuffix   = ".gz"
 )
 
 type Loader struct {
-	cfg *Config
-	log *zap.Logger
+	cfg      *Config
+	log      *zap.Logger
+	progress *progress
 }
 
 func NewLoader(cfg *Config) (*Loader, error) {
@@ -55,6 +58,16 @@ func (l *Loader) Run(ctx context.Context) error {
 		return err
 	}
 
+	l.progress, err = openProgress(l.cfg.Outdir, l.cfg.Resume)
+	if err != nil {
+		return err
+	}
+	defer l.progress.Close()
+
+	files.databases = l.progress.pending(files.databases)
+	files.schemas = l.progress.pending(files.schemas)
+	files.tables = l.progress.pending(files.tables)
+
 	// database.
 	conn := pool.Get()
 	if err := l.restoreDatabaseSchema(files.databases, conn); err != nil {
@@ -76,6 +89,8 @@ func (l *Loader) Run(ctx context.Context) error {
 	}
 
 	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var tableErr error
 	var bytes uint64
 	t := time.Now()
 	for _, table := range files.tables {
@@ -88,8 +103,9 @@ func (l *Loader) Run(ctx context.Context) error {
 			}()
 			r, err := l.restoreTable(table, conn)
 			if err != nil {
-				fmt.Printf("err = %+v\n", err)
-				// TODO(fatih) log error via logger
+				l.log.Error("error restoring table", zap.String("file", table), zap.Error(err))
+				errOnce.Do(func() { tableErr = fmt.Errorf("restoring %s: %s", filepath.Base(table), err) })
+				return
 			}
 
 			atomic.AddUint64(&bytes, uint64(r))
@@ -113,6 +129,10 @@ func (l *Loader) Run(ctx context.Context) error {
 	}()
 
 	wg.Wait()
+	if tableErr != nil {
+		return tableErr
+	}
+
 	elapsed := time.Since(t)
 	l.log.Info(
 		"restoring all done",
@@ -131,13 +151,14 @@ func (l *Loader) loadFiles(dir string) (*Files, error) {
 		}
 
 		if !info.IsDir() {
+			name := strings.TrimSuffix(path, gzipSuffix)
 			switch {
-			case strings.HasSuffix(path, dbSuffix):
+			case strings.HasSuffix(name, dbSuffix):
 				files.databases = append(files.databases, path)
-			case strings.HasSuffix(path, schemaSuffix):
+			case strings.HasSuffix(name, schemaSuffix):
 				files.schemas = append(files.schemas, path)
 			default:
-				if strings.HasSuffix(path, tableSuffix) {
+				if strings.HasSuffix(name, tableSuffix) {
 					files.tables = append(files.tables, path)
 				}
 			}
@@ -151,19 +172,23 @@ func (l *Loader) loadFiles(dir string) (*Files, error) {
 
 func (l *Loader) restoreDatabaseSchema(dbs []string, conn *Connection) error {
 	for _, db := range dbs {
-		base := filepath.Base(db)
+		base := strings.TrimSuffix(filepath.Base(db), gzipSuffix)
 		name := strings.TrimSuffix(base, dbSuffix)
 
-		data, err := ioutil.ReadFile(db)
+		data, err := readFile(db)
 		if err != nil {
 			return err
 		}
 
-		err = conn.Execute(string(data))
+		err = conn.Execute(data)
 		if err != nil {
 			return err
 		}
 
+		if err := l.progress.markDone(db); err != nil {
+			return err
+		}
+
 		l.log.Info("restoring database", zap.String("database", name))
 	}
 
@@ -172,7 +197,7 @@ func (l *Loader) restoreDatabaseSchema(dbs []string, conn *Connection) error {
 
 func (l *Loader) restoreTableSchema(overwrite bool, tables []string, conn *Connection) error {
 	for _, table := range tables {
-		base := filepath.Base(table)
+		base := strings.TrimSuffix(filepath.Base(table), gzipSuffix)
 		name := strings.TrimSuffix(base, schemaSuffix)
 		db := strings.Split(name, ".")[0]
 		tbl := strings.Split(name, ".")[1]
@@ -194,11 +219,10 @@ func (l *Loader) restoreTableSchema(overwrite bool, tables []string, conn *Conne
 			return err
 		}
 
-		data, err := ioutil.ReadFile(table)
+		query1, err := readFile(table)
 		if err != nil {
 			return err
 		}
-		query1 := string(data)
 		querys := strings.Split(query1, ";\n")
 		for _, query := range querys {
 			if !strings.HasPrefix(query, "/*") && query != "" {
@@ -221,6 +245,11 @@ func (l *Loader) restoreTableSchema(overwrite bool, tables []string, conn *Conne
 				}
 			}
 		}
+
+		if err := l.progress.markDone(table); err != nil {
+			return err
+		}
+
 		l.log.Info("restoring schema",
 			zap.String("database", db),
 			zap.String("table ", tbl),
@@ -233,7 +262,7 @@ func (l *Loader) restoreTableSchema(overwrite bool, tables []string, conn *Conne
 func (l *Loader) restoreTable(table string, conn *Connection) (int, error) {
 	bytes := 0
 	part := "0"
-	base := filepath.Base(table)
+	base := strings.TrimSuffix(filepath.Base(table), gzipSuffix)
 	name := strings.TrimSuffix(base, tableSuffix)
 	splits := strings.Split(name, ".")
 	db := splits[0]
@@ -259,22 +288,36 @@ func (l *Loader) restoreTable(table string, conn *Connection) (int, error) {
 		return 0, err
 	}
 
-	data, err := ioutil.ReadFile(table)
-
+	query1, err := readFile(table)
 	if err != nil {
 		return 0, err
 	}
-	query1 := string(data)
 	querys := strings.Split(query1, ";\n")
 	bytes = len(query1)
+
+	// a file is loaded in a single transaction, so a resumed restore
+	// doesn't insert the rows of a failed file twice
+	err = conn.Execute("BEGIN")
+	if err != nil {
+		return 0, err
+	}
 	for _, query := range querys {
 		if !strings.HasPrefix(query, "/*") && query != "" {
 			err = conn.Execute(query)
 			if err != nil {
+				_ = conn.Execute("ROLLBACK")
 				return 0, err
 			}
 		}
 	}
+	err = conn.Execute("COMMIT")
+	if err != nil {
+		return 0, err
+	}
+
+	if err := l.progress.markDone(table); err != nil {
+		return 0, err
+	}
 	l.log.Info(
 		"restoring tables done...",
 		zap.String("database", db),
@@ -284,3 +327,29 @@ func (l *Loader) restoreTable(table string, conn *Connection) (int, error) {
 	)
 	return bytes, nil
 }
+
+// readFile returns the content of a dump file, decompressing gzipped files.
+func readFile(file string) (string, error) {
+	if !strings.HasSuffix(file, gzipSuffix) {
+		data, err := ioutil.ReadFile(file)
+		return string(data), err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", file, err)
+	}
+	defer zr.Close()
+
+	data, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", file, err)
+	}
+	return string(data), nil
+}
@@ -2,6 +2,11 @@ package dumper
 
 import (
 	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 
 	qt "github.com/frankban/quicktest"
@@ -47,3 +52,102 @@ func TestLoader(t *testing.T) {
 	err = loader.Run(context.Background())
 	c.Assert(err, qt.IsNil)
 }
+
+func TestLoaderResume(t *testing.T) {
+	c := qt.New(t)
+
+	log := xlog.NewStdLog(xlog.Level(xlog.ERROR))
+	fakedbs := driver.NewTestHandler(log)
+	server, err := driver.MockMysqlServer(log, fakedbs)
+	c.Assert(err, qt.IsNil)
+	c.Cleanup(func() { server.Close() })
+
+	fakedbs.AddQueryPattern("create table .*", &sqltypes.Result{})
+	fakedbs.AddQueryPattern("use .*", &sqltypes.Result{})
+	fakedbs.AddQueryPattern("insert into .*", &sqltypes.Result{})
+	fakedbs.AddQueryPattern("set foreign_key_checks=.*", &sqltypes.Result{})
+	fakedbs.AddQueryPattern("begin|commit", &sqltypes.Result{})
+
+	dir := c.TempDir()
+	files := map[string]string{
+		"test.t1-schema.sql.gz": "CREATE TABLE `t1` (`a` int);\n",
+		"test.t1.00001.sql.gz":  "INSERT INTO `t1`(`a`) VALUES (1);\n",
+		"test.t1.00002.sql.gz":  "INSERT INTO `t1`(`a`) VALUES (2);\n",
+		"test.t1.00003.sql":     "INSERT INTO `t1`(`a`) VALUES (3);\n",
+	}
+	for name, data := range files {
+		c.Assert(writeFile(filepath.Join(dir, name), data), qt.IsNil)
+	}
+
+	// a previous restore loaded the schema and the first file
+	err = ioutil.WriteFile(filepath.Join(dir, progressFile), []byte("test.t1-schema.sql.gz\ntest.t1.00001.sql.gz\n"), 0o644)
+	c.Assert(err, qt.IsNil)
+
+	loader, err := NewLoader(&Config{
+		Outdir:     dir,
+		User:       "mock",
+		Password:   "mock",
+		Threads:    4,
+		Address:    server.Addr(),
+		IntervalMs: 500,
+		Resume:     true,
+	})
+	c.Assert(err, qt.IsNil)
+
+	err = loader.Run(context.Background())
+	c.Assert(err, qt.IsNil)
+
+	c.Assert(fakedbs.GetQueryCalledNum("create table `t1` (`a` int)"), qt.Equals, 0)
+	c.Assert(fakedbs.GetQueryCalledNum("insert into `t1`(`a`) values (1)"), qt.Equals, 0)
+	c.Assert(fakedbs.GetQueryCalledNum("insert into `t1`(`a`) values (2)"), qt.Equals, 1)
+	c.Assert(fakedbs.GetQueryCalledNum("insert into `t1`(`a`) values (3)"), qt.Equals, 1)
+
+	out, err := ioutil.ReadFile(filepath.Join(dir, progressFile))
+	c.Assert(err, qt.IsNil)
+	done := strings.Fields(string(out))
+	sort.Strings(done)
+	c.Assert(done, qt.DeepEquals, []string{
+		"test.t1-schema.sql.gz",
+		"test.t1.00001.sql.gz",
+		"test.t1.00002.sql.gz",
+		"test.t1.00003.sql",
+	})
+}
+
+func TestLoaderTableError(t *testing.T) {
+	c := qt.New(t)
+
+	log := xlog.NewStdLog(xlog.Level(xlog.ERROR))
+	fakedbs := driver.NewTestHandler(log)
+	server, err := driver.MockMysqlServer(log, fakedbs)
+	c.Assert(err, qt.IsNil)
+	c.Cleanup(func() { server.Close() })
+
+	fakedbs.AddQueryPattern("use .*", &sqltypes.Result{})
+	fakedbs.AddQueryErrorPattern("insert into `t2`.*", errors.New("duplicate entry"))
+	fakedbs.AddQueryPattern("insert into .*", &sqltypes.Result{})
+	fakedbs.AddQueryPattern("set foreign_key_checks=.*", &sqltypes.Result{})
+	fakedbs.AddQueryPattern("begin|commit|rollback", &sqltypes.Result{})
+
+	dir := c.TempDir()
+	c.Assert(writeFile(filepath.Join(dir, "test.t1.00001.sql"), "INSERT INTO `t1`(`a`) VALUES (1);\n"), qt.IsNil)
+	c.Assert(writeFile(filepath.Join(dir, "test.t2.00001.sql"), "INSERT INTO `t2`(`a`) VALUES (1);\n"), qt.IsNil)
+
+	loader, err := NewLoader(&Config{
+		Outdir:     dir,
+		User:       "mock",
+		Password:   "mock",
+		Threads:    1,
+		Address:    server.Addr(),
+		IntervalMs: 500,
+	})
+	c.Assert(err, qt.IsNil)
+
+	err = loader.Run(context.Background())
+	c.Assert(err, qt.ErrorMatches, "restoring test.t2.00001.sql: .*duplicate entry.*")
+	c.Assert(fakedbs.GetQueryCalledNum("rollback"), qt.Equals, 1)
+
+	out, err := ioutil.ReadFile(filepath.Join(dir, progressFile))
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, "test.t1.00001.sql\n")
+}
@@ -0,0 +1,95 @@
+package dumper
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// progressFile lists the files of a dump directory a restore completed, one
+// per line.
+const progressFile = "restore-progress"
+
+// progress records the files a restore completed, so an interrupted restore
+// can be resumed without loading them again.
+type progress struct {
+	mu   sync.Mutex
+	f    *os.File
+	done map[string]bool
+}
+
+// openProgress opens the progress file of dir. Unless resume is set, the
+// progress of a previous restore is discarded.
+func openProgress(dir string, resume bool) (*progress, error) {
+	path := filepath.Join(dir, progressFile)
+	p := &progress{done: map[string]bool{}}
+
+	flag := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if resume {
+		f, err := os.Open(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			s := bufio.NewScanner(f)
+			for s.Scan() {
+				if name := strings.TrimSpace(s.Text()); name != "" {
+					p.done[name] = true
+				}
+			}
+			f.Close()
+			if err := s.Err(); err != nil {
+				return nil, fmt.Errorf("can't read restore progress %q: %s", path, err)
+			}
+		}
+	} else {
+		flag |= os.O_TRUNC
+	}
+
+	f, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	p.f = f
+
+	return p, nil
+}
+
+// isDone reports whether the file was restored already.
+func (p *progress) isDone(file string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.done[filepath.Base(file)]
+}
+
+// markDone records that the file is restored.
+func (p *progress) markDone(file string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	name := filepath.Base(file)
+	p.done[name] = true
+
+	if _, err := p.f.WriteString(name + "\n"); err != nil {
+		return err
+	}
+	return p.f.Sync()
+}
+
+// pending returns the files that aren't restored yet.
+func (p *progress) pending(files []string) []string {
+	var left []string
+	for _, f := range files {
+		if !p.isDone(f) {
+			left = append(left, f)
+		}
+	}
+	return left
+}
+
+func (p *progress) Close() error {
+	return p.f.Close()
+}
@@ -0,0 +1,530 @@
+// Package expr evaluates simple conditions against the fields of a resource,
+// such as:
+//
+//	state=="ready" && age>"720h"
+//
+// Conditions compare fields, which may be nested as "deployment.state", to
+// strings, numbers, true, false and null with ==, !=, <, <=, > and >=, and
+// combine comparisons with &&, || and ! and parentheses. A field on its own
+// must be a boolean. Strings that are RFC 3339 timestamps are compared as
+// times, and durations, such as "12h", "30d" or "2w", as durations.
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Expr is a parsed condition.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse parses the condition.
+func Parse(s string) (*Expr, error) {
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s at offset %d", t, t.pos)
+	}
+
+	return &Expr{src: s, root: root}, nil
+}
+
+func (e *Expr) String() string { return e.src }
+
+// Eval evaluates the condition against the fields, as decoded from JSON.
+// Fields are values such as durations and times too.
+func (e *Expr) Eval(fields map[string]interface{}) (bool, error) {
+	return evalBool(e.root, fields)
+}
+
+type node interface {
+	eval(fields map[string]interface{}) (interface{}, error)
+}
+
+type orNode struct{ left, right node }
+
+func (n *orNode) eval(fields map[string]interface{}) (interface{}, error) {
+	l, err := evalBool(n.left, fields)
+	if err != nil || l {
+		return l, err
+	}
+	return evalBool(n.right, fields)
+}
+
+type andNode struct{ left, right node }
+
+func (n *andNode) eval(fields map[string]interface{}) (interface{}, error) {
+	l, err := evalBool(n.left, fields)
+	if err != nil || !l {
+		return l, err
+	}
+	return evalBool(n.right, fields)
+}
+
+type notNode struct{ operand node }
+
+func (n *notNode) eval(fields map[string]interface{}) (interface{}, error) {
+	v, err := evalBool(n.operand, fields)
+	return !v, err
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n *compareNode) eval(fields map[string]interface{}) (interface{}, error) {
+	l, err := n.left.eval(fields)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.right.eval(fields)
+	if err != nil {
+		return nil, err
+	}
+	return compare(n.op, l, r)
+}
+
+type fieldNode struct{ path string }
+
+func (n *fieldNode) eval(fields map[string]interface{}) (interface{}, error) {
+	var v interface{} = fields
+	for _, name := range strings.Split(n.path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("field %q doesn't exist", n.path)
+		}
+		if v, ok = m[name]; !ok {
+			return nil, fmt.Errorf("field %q doesn't exist", n.path)
+		}
+	}
+
+	if s, ok := v.(string); ok {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t, nil
+		}
+	}
+	return v, nil
+}
+
+type literalNode struct{ value interface{} }
+
+func (n *literalNode) eval(map[string]interface{}) (interface{}, error) { return n.value, nil }
+
+func evalBool(n node, fields map[string]interface{}) (bool, error) {
+	v, err := n.eval(fields)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s is not a boolean", format(v))
+	}
+	return b, nil
+}
+
+// compare compares the values, converting strings to the type of the other
+// value if it's a time, a duration or a number.
+func compare(op string, l, r interface{}) (bool, error) {
+	var err error
+	switch {
+	case isDuration(l) || isDuration(r):
+		l, r, err = convert(l, r, toDuration)
+	case isTime(l) || isTime(r):
+		l, r, err = convert(l, r, toTime)
+	case isNumber(l) && isString(r), isString(l) && isNumber(r):
+		l, r, err = convert(l, r, toNumber)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var c int
+	switch lv := l.(type) {
+	case time.Duration:
+		c = compareInt(int64(lv), int64(r.(time.Duration)))
+	case time.Time:
+		c = compareInt(lv.UnixNano(), r.(time.Time).UnixNano())
+	case float64:
+		rv, ok := r.(float64)
+		if !ok {
+			return equality(op, l, r)
+		}
+		switch {
+		case lv < rv:
+			c = -1
+		case lv > rv:
+			c = 1
+		}
+	case string:
+		rv, ok := r.(string)
+		if !ok {
+			return equality(op, l, r)
+		}
+		c = strings.Compare(lv, rv)
+	default:
+		return equality(op, l, r)
+	}
+
+	switch op {
+	case "==":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+// equality compares values that can't be ordered, such as booleans and null.
+func equality(op string, l, r interface{}) (bool, error) {
+	if !isScalar(l) || !isScalar(r) {
+		return false, fmt.Errorf("can't compare %s %s %s, objects and arrays can't be compared", format(l), op, format(r))
+	}
+
+	switch op {
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	}
+	return false, fmt.Errorf("can't compare %s %s %s", format(l), op, format(r))
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func convert(l, r interface{}, to func(interface{}) (interface{}, error)) (interface{}, interface{}, error) {
+	l, err := to(l)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err = to(r)
+	return l, r, err
+}
+
+func isDuration(v interface{}) bool { _, ok := v.(time.Duration); return ok }
+func isTime(v interface{}) bool     { _, ok := v.(time.Time); return ok }
+func isNumber(v interface{}) bool   { _, ok := v.(float64); return ok }
+func isString(v interface{}) bool   { _, ok := v.(string); return ok }
+
+func isScalar(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	return true
+}
+
+func toDuration(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case time.Duration:
+		return v, nil
+	case string:
+		return ParseDuration(v)
+	}
+	return nil, fmt.Errorf("%s is not a duration", format(v))
+}
+
+func toTime(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s is not a time as RFC 3339 or YYYY-MM-DD", format(v))
+}
+
+func toNumber(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not a number", format(v))
+}
+
+// ParseDuration parses a duration such as "12h", or days and weeks, such as
+// "30d" or "2w".
+func ParseDuration(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if strings.HasSuffix(s, suffix) {
+			v, err := strconv.Atoi(strings.TrimSuffix(s, suffix))
+			if err != nil {
+				return 0, fmt.Errorf("%q is not a duration", s)
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a duration", s)
+	}
+	return d, nil
+}
+
+func format(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenField
+	tokenString
+	tokenNumber
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of condition"
+	}
+	return strconv.Quote(t.text)
+}
+
+// operators are the operators, longest first.
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")"}
+
+func lex(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(s) && s[end] != byte(c) {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+
+			text := s[i+1 : end]
+			if c == '"' {
+				var err error
+				if text, err = strconv.Unquote(s[i : end+1]); err != nil {
+					return nil, fmt.Errorf("invalid string at offset %d", i)
+				}
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, pos: i})
+			i = end + 1
+		case c == '-' || c >= '0' && c <= '9':
+			end := i + 1
+			for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: s[i:end], pos: i})
+			i = end
+		case c == '_' || unicode.IsLetter(c):
+			end := i + 1
+			for end < len(s) && (s[end] == '_' || s[end] == '.' || unicode.IsLetter(rune(s[end])) || unicode.IsDigit(rune(s[end]))) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenField, text: s[i:end], pos: i})
+			i = end
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at offset %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(s)}), nil
+}
+
+// parser is a recursive descent parser of the grammar:
+//
+//	or         = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | primary
+//	primary    = "(" or ")" | comparison
+//	comparison = operand [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) operand ]
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokenOp && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if p.accept("!") {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+
+	if p.accept("(") {
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			t := p.peek()
+			return nil, fmt.Errorf("expected \")\" at offset %d, got %s", t.pos, t)
+		}
+		return n, nil
+	}
+
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if t.kind != tokenOp {
+		return left, nil
+	}
+	switch t.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.next()
+		right, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		return &compareNode{op: t.text, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) operand() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return &literalNode{value: t.text}, nil
+	case tokenNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s at offset %d", t, t.pos)
+		}
+		return &literalNode{value: f}, nil
+	case tokenField:
+		switch t.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		return &fieldNode{path: t.text}, nil
+	case tokenEOF:
+		return nil, errors.New("unexpected end of condition")
+	}
+	return nil, fmt.Errorf("unexpected %s at offset %d", t, t.pos)
+}
@@ -0,0 +1,90 @@
+package expr
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestEval(t *testing.T) {
+	fields := map[string]interface{}{
+		"name":       "dev",
+		"state":      "ready",
+		"ready":      true,
+		"production": false,
+		"number":     float64(42),
+		"notes":      nil,
+		"created_at": "2026-09-01T10:00:00Z",
+		"age":        45 * 24 * time.Hour,
+		"deployment": map[string]interface{}{"state": "complete"},
+	}
+
+	tests := []struct {
+		cond string
+		want bool
+		err  string
+	}{
+		{cond: `state=="ready"`, want: true},
+		{cond: `state!="ready"`, want: false},
+		{cond: `state=='ready' && age>"720h"`, want: true},
+		{cond: `age>"30d" && age<"7w"`, want: true},
+		{cond: `age<="2w"`, want: false},
+		{cond: `ready`, want: true},
+		{cond: `!production && ready`, want: true},
+		{cond: `production || number >= 42`, want: true},
+		{cond: `number > 42`, want: false},
+		{cond: `number == "42"`, want: true},
+		{cond: `notes == null`, want: true},
+		{cond: `ready == true`, want: true},
+		{cond: `created_at < "2026-10-01"`, want: true},
+		{cond: `created_at > "2026-09-01T12:00:00Z"`, want: false},
+		{cond: `deployment.state == "complete"`, want: true},
+		{cond: `!(state == "ready" || production)`, want: false},
+		{cond: `name < "main"`, want: true},
+		{cond: `state`, err: `"ready" is not a boolean`},
+		{cond: `status == "ready"`, err: `field "status" doesn't exist`},
+		{cond: `age > "soon"`, err: `"soon" is not a duration`},
+		{cond: `ready > false`, err: `can't compare true > false`},
+		{cond: `deployment == null`, err: `can't compare .*objects and arrays can't be compared`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cond, func(t *testing.T) {
+			c := qt.New(t)
+
+			e, err := Parse(tt.cond)
+			c.Assert(err, qt.IsNil)
+
+			got, err := e.Eval(fields)
+			if tt.err != "" {
+				c.Assert(err, qt.ErrorMatches, tt.err)
+				return
+			}
+			c.Assert(err, qt.IsNil)
+			c.Assert(got, qt.Equals, tt.want)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		cond string
+		err  string
+	}{
+		{cond: `state ==`, err: "unexpected end of condition"},
+		{cond: `(ready`, err: `expected "\)" at offset 6, got end of condition`},
+		{cond: `state = "ready"`, err: `unexpected '=' at offset 6`},
+		{cond: `state == "ready`, err: "unterminated string at offset 9"},
+		{cond: `ready ready`, err: `unexpected "ready" at offset 6`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cond, func(t *testing.T) {
+			c := qt.New(t)
+
+			_, err := Parse(tt.cond)
+			c.Assert(err, qt.ErrorMatches, tt.err)
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// Package journal keeps a local, append-only record of the mutating API
+// requests made by the CLI, to answer "what did I change?" after the fact.
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/planetscale/cli/internal/config"
+)
+
+const (
+	journalDir  = "journal"
+	journalName = "journal.jsonl"
+
+	// maxSize is the size after which the journal is rotated, maxFiles the
+	// number of rotated files that are kept.
+	maxSize  = 5 << 20
+	maxFiles = 3
+)
+
+// Entry is a single mutating API request.
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Command   string    `json:"command"`
+	Method    string    `json:"method"`
+	Resource  string    `json:"resource"`
+	Status    int       `json:"status"`
+	Result    string    `json:"result"`
+	RequestID string    `json:"request_id,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+// Path returns the path of the current journal file.
+func Path() (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, journalDir, journalName), nil
+}
+
+var (
+	mu     sync.Mutex
+	reason string
+)
+
+// SetReason sets the reason recorded with the following requests, such as
+// the incident a break-glass credential is created for.
+func SetReason(r string) {
+	mu.Lock()
+	defer mu.Unlock()
+	reason = r
+}
+
+// Append adds the entry to the journal, rotating it if it grew too large.
+func Append(e *Entry) error {
+	p, err := Path()
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := os.MkdirAll(path.Dir(p), 0771); err != nil {
+		return fmt.Errorf("error creating journal directory: %s", err)
+	}
+
+	if stat, err := os.Stat(p); err == nil && stat.Size() >= maxSize {
+		if err := rotate(p); err != nil {
+			return err
+		}
+	}
+
+	out, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(out, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// rotate moves journal.jsonl to journal.jsonl.1, journal.jsonl.1 to
+// journal.jsonl.2 and so on, dropping the oldest file.
+func rotate(p string) error {
+	for i := maxFiles; i > 0; i-- {
+		from := p
+		if i > 1 {
+			from = fmt.Sprintf("%s.%d", p, i-1)
+		}
+
+		err := os.Rename(from, fmt.Sprintf("%s.%d", p, i))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Read returns all entries since the given time, oldest first.
+func Read(since time.Time) ([]*Entry, error) {
+	p, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	entries := []*Entry{}
+	for i := maxFiles; i >= 0; i-- {
+		file := p
+		if i > 0 {
+			file = fmt.Sprintf("%s.%d", p, i)
+		}
+
+		if err := readFile(file, since, &entries); err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+func readFile(file string, since time.Time, entries *[]*Entry) error {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) != 0 {
+			e := &Entry{}
+			// partially written lines are skipped
+			if json.Unmarshal(line, e) == nil && !e.Time.Before(since) {
+				*entries = append(*entries, e)
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Recorder returns a function journaling the mutating requests of the given
+// responses, made by the given command. It can be used as
+// config.Config.ObserveResponse.
+func Recorder(command string) func(*http.Response) {
+	return func(resp *http.Response) {
+		req := resp.Request
+		if req == nil || !isMutation(req.Method) {
+			return
+		}
+
+		result := "ok"
+		if resp.StatusCode >= 400 {
+			result = "error: " + http.StatusText(resp.StatusCode)
+		}
+
+		requestID := resp.Header.Get("X-Request-Id")
+		if requestID == "" {
+			requestID = resp.Header.Get("X-Trace-Id")
+		}
+
+		mu.Lock()
+		r := reason
+		mu.Unlock()
+
+		// journaling is best-effort, it must not fail the command
+		_ = Append(&Entry{
+			Time:      time.Now().UTC(),
+			Command:   command,
+			Method:    req.Method,
+			Resource:  strings.TrimPrefix(req.URL.Path, "/v1"),
+			Status:    resp.StatusCode,
+			Result:    result,
+			RequestID: requestID,
+			Reason:    r,
+		})
+	}
+}
+
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
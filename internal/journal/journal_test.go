@@ -0,0 +1,66 @@
+package journal
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestRecorder(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	record := Recorder("pscale branch create")
+	u, err := url.Parse("https://api.planetscale.com/v1/organizations/org/databases/db/branches")
+	c.Assert(err, qt.IsNil)
+
+	record(&http.Response{StatusCode: 200, Request: &http.Request{Method: "GET", URL: u}})
+	record(&http.Response{StatusCode: 201, Request: &http.Request{Method: "POST", URL: u}})
+	record(&http.Response{
+		StatusCode: 422,
+		Header:     http.Header{"X-Request-Id": []string{"req-1"}},
+		Request:    &http.Request{Method: "POST", URL: u},
+	})
+
+	entries, err := Read(time.Now().Add(-time.Hour))
+	c.Assert(err, qt.IsNil)
+	c.Assert(entries, qt.HasLen, 2)
+	c.Assert(entries[0].Command, qt.Equals, "pscale branch create")
+	c.Assert(entries[0].Resource, qt.Equals, "/organizations/org/databases/db/branches")
+	c.Assert(entries[0].Result, qt.Equals, "ok")
+	c.Assert(entries[1].Result, qt.Equals, "error: Unprocessable Entity")
+	c.Assert(entries[1].RequestID, qt.Equals, "req-1")
+
+	entries, err = Read(time.Now().Add(time.Hour))
+	c.Assert(err, qt.IsNil)
+	c.Assert(entries, qt.HasLen, 0)
+}
+
+func TestAppend_Rotate(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	p, err := Path()
+	c.Assert(err, qt.IsNil)
+
+	old := &Entry{Time: time.Now().Add(-time.Minute), Command: "old"}
+	c.Assert(Append(old), qt.IsNil)
+	c.Assert(os.Truncate(p, maxSize), qt.IsNil)
+
+	c.Assert(Append(&Entry{Time: time.Now(), Command: "new"}), qt.IsNil)
+
+	_, err = os.Stat(p + ".1")
+	c.Assert(err, qt.IsNil)
+
+	entries, err := Read(time.Now().Add(-time.Hour))
+	c.Assert(err, qt.IsNil)
+	c.Assert(entries, qt.HasLen, 2)
+	c.Assert(entries[0].Command, qt.Equals, "old")
+	c.Assert(entries[1].Command, qt.Equals, "new")
+}
@@ -0,0 +1,155 @@
+// Package naming generates and validates resource names based on the naming
+// conventions of an organization.
+package naming
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/planetscale/cli/internal/config"
+)
+
+var (
+	invalidChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+	dashes       = regexp.MustCompile(`-{2,}`)
+	ticketRef    = regexp.MustCompile(`[A-Za-z][A-Za-z0-9]*-[0-9]+`)
+	action       = regexp.MustCompile(`{{[^}]*}}`)
+)
+
+// These are replaced in tests.
+var (
+	now         = time.Now
+	currentUser = func() (string, error) {
+		u, err := user.Current()
+		if err != nil {
+			return "", err
+		}
+		return u.Username, nil
+	}
+	gitBranch = config.GitBranch
+)
+
+// Generate renders the template of the rule. The template can use the
+// following functions:
+//
+//	{{ticket}}     the ticket reference of the git branch, i.e. "ABC-123", or
+//	               the value of PLANETSCALE_TICKET
+//	{{user}}       the name of the current user
+//	{{date}}       the current date, i.e. "20210927"
+//	{{git_branch}} the current git branch
+//	{{database}}   the database the resource is created in
+//
+// The result is lowercased and characters that aren't allowed in names are
+// replaced with dashes.
+func Generate(rule *config.NameRule, database string) (string, error) {
+	if rule == nil || rule.Template == "" {
+		return "", errors.New("no naming template is configured")
+	}
+
+	funcs := template.FuncMap{
+		"ticket":     ticket,
+		"user":       currentUser,
+		"date":       func() string { return now().Format("20060102") },
+		"git_branch": gitBranch,
+		"database":   func() string { return database },
+	}
+
+	tmpl, err := template.New("name").Funcs(funcs).Parse(rule.Template)
+	if err != nil {
+		return "", fmt.Errorf("invalid naming template %q: %s", rule.Template, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return "", fmt.Errorf("can't generate name from template %q: %s", rule.Template, err)
+	}
+
+	name := Sanitize(buf.String())
+	if name == "" {
+		return "", fmt.Errorf("template %q generated an empty name", rule.Template)
+	}
+
+	return name, nil
+}
+
+// Validate returns an error if the name doesn't follow the naming
+// convention.
+func Validate(rule *config.NameRule, name string) error {
+	if rule == nil || (rule.Pattern == "" && rule.Template == "") {
+		return nil
+	}
+
+	pattern := rule.Pattern
+	if pattern == "" {
+		pattern = templatePattern(rule.Template)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid naming pattern %q: %s", pattern, err)
+	}
+
+	if !re.MatchString(name) {
+		if rule.Pattern == "" {
+			return fmt.Errorf("name %q doesn't follow the naming template %q", name, rule.Template)
+		}
+		return fmt.Errorf("name %q doesn't match the naming pattern %q", name, rule.Pattern)
+	}
+
+	return nil
+}
+
+// Sanitize lowercases the name and replaces characters that aren't allowed
+// in resource names with dashes.
+func Sanitize(name string) string {
+	name = invalidChars.ReplaceAllString(strings.ToLower(name), "-")
+	name = dashes.ReplaceAllString(name, "-")
+	return strings.Trim(name, "-")
+}
+
+// templatePattern returns a regular expression matching the names the
+// template can generate.
+func templatePattern(tmpl string) string {
+	var b strings.Builder
+	b.WriteString("^")
+
+	last := 0
+	for _, loc := range action.FindAllStringIndex(tmpl, -1) {
+		b.WriteString(regexp.QuoteMeta(literal(tmpl[last:loc[0]])))
+		b.WriteString("[a-z0-9_-]+")
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(literal(tmpl[last:])))
+
+	b.WriteString("$")
+	return b.String()
+}
+
+func literal(s string) string {
+	return invalidChars.ReplaceAllString(strings.ToLower(s), "-")
+}
+
+func ticket() (string, error) {
+	if t := os.Getenv(config.EnvPrefix + "TICKET"); t != "" {
+		return t, nil
+	}
+
+	branch, err := gitBranch()
+	if err != nil {
+		return "", err
+	}
+
+	t := ticketRef.FindString(branch)
+	if t == "" {
+		return "", fmt.Errorf("git branch %q doesn't contain a ticket reference (set %sTICKET to pass one)", branch, config.EnvPrefix)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,57 @@
+package naming
+
+import (
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/config"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestGenerate(t *testing.T) {
+	c := qt.New(t)
+
+	defer func(n func() time.Time, u, g func() (string, error)) {
+		now, currentUser, gitBranch = n, u, g
+	}(now, currentUser, gitBranch)
+
+	now = func() time.Time { return time.Date(2021, 9, 27, 0, 0, 0, 0, time.UTC) }
+	currentUser = func() (string, error) { return "Jane.Doe", nil }
+	gitBranch = func() (string, error) { return "feature/ABC-123-add-users", nil }
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"{{ticket}}-{{user}}-{{date}}", "abc-123-jane-doe-20210927"},
+		{"{{git_branch}}", "feature-abc-123-add-users"},
+		{"{{database}}_dev", "mydb_dev"},
+	}
+
+	for _, tt := range tests {
+		name, err := Generate(&config.NameRule{Template: tt.template}, "mydb")
+		c.Assert(err, qt.IsNil)
+		c.Assert(name, qt.Equals, tt.want)
+		c.Assert(Validate(&config.NameRule{Template: tt.template}, name), qt.IsNil)
+	}
+
+	gitBranch = func() (string, error) { return "main", nil }
+	_, err := Generate(&config.NameRule{Template: "{{ticket}}"}, "mydb")
+	c.Assert(err, qt.ErrorMatches, `.*git branch "main" doesn't contain a ticket reference.*`)
+}
+
+func TestValidate(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert(Validate(nil, "anything"), qt.IsNil)
+
+	rule := &config.NameRule{Template: "{{ticket}}-{{date}}"}
+	c.Assert(Validate(rule, "abc-123-20210927"), qt.IsNil)
+	c.Assert(Validate(rule, "my_branch"), qt.ErrorMatches,
+		`name "my_branch" doesn't follow the naming template "{{ticket}}-{{date}}"`)
+
+	rule = &config.NameRule{Pattern: "^dev-"}
+	c.Assert(Validate(rule, "dev-users"), qt.IsNil)
+	c.Assert(Validate(rule, "users"), qt.ErrorMatches, `name "users" doesn't match the naming pattern "\^dev-"`)
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"reflect"
 	"strings"
 	"time"
 
@@ -28,8 +29,15 @@ const (
 	Human Format = iota
 	JSON
 	CSV
+	YAML
+	// Dot prints graphs in the Graphviz DOT language. It's only supported
+	// by resources implementing MarshalDot.
+	Dot
 )
 
+// Formats are the names of all formats.
+var Formats = []string{"human", "json", "csv", "yaml", "dot"}
+
 // NewFormatValue is used to define a flag that can be used to define a custom
 // flag via the flagset.Var() method.
 func NewFormatValue(val Format, p *Format) *Format {
@@ -45,6 +53,10 @@ func (f *Format) String() string {
 		return "json"
 	case CSV:
 		return "csv"
+	case YAML:
+		return "yaml"
+	case Dot:
+		return "dot"
 	}
 
 	return "unknown format"
@@ -59,9 +71,13 @@ func (f *Format) Set(s string) error {
 		v = JSON
 	case "csv":
 		v = CSV
+	case "yaml":
+		v = YAML
+	case "dot":
+		v = Dot
 	default:
 		return fmt.Errorf("failed to parse Format: %q. Valid values: %+v",
-			s, []string{"human", "json", "csv"})
+			s, Formats)
 	}
 
 	*f = Format(v)
@@ -77,13 +93,24 @@ type Printer struct {
 	humanOut    io.Writer
 	resourceOut io.Writer
 
-	format *Format
+	format   *Format
+	noHeader *bool
+	quiet    *bool
+
+	timestampFormat *TimestampFormat
+	utc             *bool
+
+	templatePath string
+
+	progressJSON *bool
+	events       *progress
 }
 
 // NewPrinter returns a new Printer for the given output and format.
 func NewPrinter(format *Format) *Printer {
 	return &Printer{
 		format: format,
+		events: &progress{out: os.Stderr, starts: make(map[string]time.Time), now: time.Now},
 	}
 }
 
@@ -106,6 +133,10 @@ func (p *Printer) Print(i ...interface{}) {
 // human, out returns ioutil.Discard, which means that any output will be
 // discarded
 func (p *Printer) out() io.Writer {
+	if p.Quiet() {
+		return ioutil.Discard
+	}
+
 	if p.humanOut != nil {
 		return p.humanOut
 	}
@@ -121,6 +152,10 @@ func (p *Printer) out() io.Writer {
 // function needs to be called in a defer or when it's decided to stop the
 // spinner
 func (p *Printer) PrintProgress(message string) func() {
+	if p.ProgressJSON() {
+		return p.printProgressEvents(message)
+	}
+
 	if !IsTTY {
 		fmt.Fprintln(p.out(), message)
 		return func() {}
@@ -149,12 +184,35 @@ func (p *Printer) SetHumanOutput(out io.Writer) {
 	p.humanOut = out
 }
 
+// SetNoHeader sets whether PrintResource omits the header of human readable
+// tables and CSV. Tables without a header are printed plainly, one record per
+// line with tab separated fields, for processing them with other tools.
+func (p *Printer) SetNoHeader(noHeader *bool) {
+	p.noHeader = noHeader
+}
+
+// SetQuiet sets whether PrintResource prints only the identifiers of
+// resources, one per line, whatever the format. Human readable messages are
+// discarded too, so the output can be piped to other commands as is.
+func (p *Printer) SetQuiet(quiet *bool) {
+	p.quiet = quiet
+}
+
+// Quiet returns whether only the identifiers of resources are printed.
+func (p *Printer) Quiet() bool {
+	return p.quiet != nil && *p.quiet
+}
+
 // SetResourceOutput sets the output for pringing resources via PrintResource.
 func (p *Printer) SetResourceOutput(out io.Writer) {
 	p.resourceOut = out
 }
 
 // PrintResource prints the given resource in the format it was specified.
+// Resources are structs, or slices of them, using "header" tags for the human
+// readable table and "json" tags for the other formats, hence every command
+// printing its resources via PrintResource supports all formats. A custom
+// template set with SetTemplate replaces the table.
 func (p *Printer) PrintResource(v interface{}) error {
 	if p.format == nil {
 		return errors.New("printer.Format is not set")
@@ -165,8 +223,25 @@ func (p *Printer) PrintResource(v interface{}) error {
 		out = p.resourceOut
 	}
 
+	if p.Quiet() {
+		printIDs(out, v)
+		return nil
+	}
+
+	noHeader := p.noHeader != nil && *p.noHeader
+
 	switch *p.format {
 	case Human:
+		if ok, err := p.printTemplate(out, v); ok {
+			return err
+		}
+
+		v = p.tableTimestamps(v)
+		if noHeader {
+			printPlain(out, v)
+			return nil
+		}
+
 		var b strings.Builder
 		tableprinter.Print(&b, v)
 		fmt.Fprintln(out, b.String())
@@ -188,12 +263,48 @@ func (p *Printer) PrintResource(v interface{}) error {
 			v = c.MarshalCSVValue()
 		}
 
+		if noHeader {
+			var b strings.Builder
+			if err := gocsv.MarshalWithoutHeaders(v, &b); err != nil {
+				return err
+			}
+			fmt.Fprintln(out, b.String())
+			return nil
+		}
+
 		buf, err := gocsv.MarshalString(v)
 		if err != nil {
 			return err
 		}
 		fmt.Fprintln(out, buf)
 		return nil
+	case YAML:
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		// converting from JSON keeps the field names and order consistent
+		// with the JSON output
+		y, err := jsonToYAML(buf)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(out, string(y))
+		return nil
+	case Dot:
+		type dotvaluer interface {
+			MarshalDot() string
+		}
+
+		d, ok := v.(dotvaluer)
+		if !ok {
+			return errors.New("the dot format is only supported by commands printing graphs, such as 'pscale branch tree'")
+		}
+
+		fmt.Fprint(out, d.MarshalDot())
+		return nil
 	}
 
 	return fmt.Errorf("unknown printer.Format: %T", *p.format)
@@ -220,6 +331,21 @@ func GetMillisecondsIfExists(timestamp *time.Time) *int64 {
 	return &numSeconds
 }
 
+// Bytes returns the byte count in a human readable form, i.e. 1.5 MB.
+func Bytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
+
 func Emoji(emoji string) string {
 	if IsTTY {
 		return emoji
@@ -248,3 +374,78 @@ func Bold(msg interface{}) string {
 	// the 'color' package already handles IsTTY gracefully
 	return color.New(color.Bold).Sprint(msg)
 }
+
+// printPlain prints the rows of the table of v without a header, borders and
+// padding.
+func printPlain(out io.Writer, v interface{}) {
+	val := reflect.ValueOf(v)
+	if k := val.Kind(); k == reflect.Interface || k == reflect.Ptr {
+		val = val.Elem()
+	}
+
+	parser := tableprinter.WhichParser(val.Type())
+	if parser == nil {
+		return
+	}
+
+	_, rows, _ := parser.Parse(val, nil)
+	for _, row := range rows {
+		fmt.Fprintln(out, strings.Join(row, "\t"))
+	}
+}
+
+// Identifier is implemented by resources whose identifier, printed by
+// PrintResource in quiet mode, isn't the first column of their table.
+type Identifier interface {
+	Identifier() string
+}
+
+// printIDs prints the identifier of each resource of v, one per line.
+func printIDs(out io.Writer, v interface{}) {
+	val := reflect.ValueOf(v)
+	if !val.IsValid() {
+		return
+	}
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		if id, ok := identifier(val); ok {
+			fmt.Fprintln(out, id)
+		}
+		return
+	}
+
+	for i := 0; i < val.Len(); i++ {
+		if id, ok := identifier(val.Index(i)); ok {
+			fmt.Fprintln(out, id)
+		}
+	}
+}
+
+// identifier returns the identifier of the resource, the value of its first
+// column if it doesn't implement Identifier.
+func identifier(val reflect.Value) (string, bool) {
+	if val.CanInterface() {
+		if i, ok := val.Interface().(Identifier); ok {
+			return i.Identifier(), true
+		}
+	}
+
+	for val.Kind() == reflect.Interface || val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return "", false
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return fmt.Sprint(val.Interface()), true
+	}
+
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		header := field.Tag.Get("header")
+		if field.PkgPath != "" || header == "" || header == "-" {
+			continue
+		}
+		return fmt.Sprint(val.Field(i).Interface()), true
+	}
+	return "", false
+}
@@ -0,0 +1,223 @@
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+type testResource struct {
+	Name    string `header:"name" json:"name"`
+	Shards  int    `header:"shards" json:"shards"`
+	Primary bool   `header:"primary" json:"primary"`
+	Notes   *bool  `header:"-" json:"notes"`
+}
+
+func TestPrintResource_YAML(t *testing.T) {
+	c := qt.New(t)
+
+	var buf bytes.Buffer
+	format := YAML
+	p := NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+
+	err := p.PrintResource([]*testResource{
+		{Name: "zeta", Shards: 2, Primary: true},
+		{Name: "alpha"},
+	})
+	c.Assert(err, qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, `- name: zeta
+  shards: 2
+  primary: true
+  notes: null
+- name: alpha
+  shards: 0
+  primary: false
+  notes: null
+`)
+}
+
+func TestPrintResource_NoHeader(t *testing.T) {
+	c := qt.New(t)
+
+	res := []*testResource{
+		{Name: "zeta", Shards: 2, Primary: true},
+		{Name: "alpha"},
+	}
+
+	var buf bytes.Buffer
+	format := Human
+	noHeader := true
+	p := NewPrinter(&format)
+	p.SetNoHeader(&noHeader)
+	p.SetResourceOutput(&buf)
+
+	c.Assert(p.PrintResource(res), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "zeta\t2\tYes\nalpha\t0\tNo\n")
+
+	buf.Reset()
+	format = CSV
+	c.Assert(p.PrintResource(res), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "zeta,2,true,\nalpha,0,false,\n\n")
+}
+
+func TestPrintResource_TimestampFormat(t *testing.T) {
+	c := qt.New(t)
+
+	type branch struct {
+		Name      string `header:"name" json:"name"`
+		CreatedAt int64  `header:"created_at,timestamp(ms|utc|human)" json:"created_at"`
+		DeletedAt *int64 `header:"deleted_at,timestamp(ms|utc|human),n/a" json:"deleted_at"`
+	}
+
+	deleted := int64(1612345678000)
+	res := []*branch{
+		{Name: "main", CreatedAt: 1612345678000},
+		{Name: "dev", CreatedAt: 1612345678000, DeletedAt: &deleted},
+	}
+
+	var buf bytes.Buffer
+	format := Human
+	noHeader := true
+	timestampFormat := TimestampFormat("rfc3339")
+	utc := true
+	p := NewPrinter(&format)
+	p.SetNoHeader(&noHeader)
+	p.SetTimestampFormat(&timestampFormat, &utc)
+	p.SetResourceOutput(&buf)
+
+	c.Assert(p.PrintResource(res), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "main\t2021-02-03T09:47:58Z\tn/a\ndev\t2021-02-03T09:47:58Z\t2021-02-03T09:47:58Z\n")
+
+	buf.Reset()
+	c.Assert(timestampFormat.Set("Jan 2 15:04"), qt.IsNil)
+	c.Assert(p.PrintResource(res[0]), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "main\tFeb 3 09:47\tn/a\n")
+
+	// JSON keeps the timestamps as they are
+	buf.Reset()
+	format = JSON
+	c.Assert(p.PrintResource(res[0]), qt.IsNil)
+	c.Assert(buf.String(), qt.Contains, `"created_at": 1612345678000`)
+
+	c.Assert(timestampFormat.Set("iso"), qt.ErrorMatches, `failed to parse timestamp format: "iso".*`)
+}
+
+type testNumbered struct {
+	ID     string `header:"id" json:"id"`
+	Number uint64 `header:"number" json:"number"`
+}
+
+func (n *testNumbered) Identifier() string {
+	return fmt.Sprintf("#%d", n.Number)
+}
+
+func TestPrintResource_Quiet(t *testing.T) {
+	c := qt.New(t)
+
+	var buf, human bytes.Buffer
+	format := JSON
+	quiet := true
+	p := NewPrinter(&format)
+	p.SetQuiet(&quiet)
+	p.SetResourceOutput(&buf)
+	p.SetHumanOutput(&human)
+
+	p.Printf("Creating...\n")
+	c.Assert(p.PrintResource([]*testResource{{Name: "zeta"}, {Name: "alpha"}}), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "zeta\nalpha\n")
+	c.Assert(human.String(), qt.Equals, "")
+
+	buf.Reset()
+	c.Assert(p.PrintResource(&testNumbered{ID: "abc", Number: 7}), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "#7\n")
+}
+
+func TestPrintResource_Template(t *testing.T) {
+	c := qt.New(t)
+
+	dir := c.TempDir()
+	path := filepath.Join(dir, "database-list.tmpl")
+	err := ioutil.WriteFile(path, []byte(`{{range .}}{{upper .Name}}: {{.Shards}} shard(s)
+{{end}}`), 0o644)
+	c.Assert(err, qt.IsNil)
+
+	res := []*testResource{{Name: "zeta", Shards: 2}, {Name: "alpha"}}
+
+	var buf bytes.Buffer
+	format := Human
+	p := NewPrinter(&format)
+	p.SetResourceOutput(&buf)
+	p.SetTemplate(path)
+
+	c.Assert(p.PrintResource(res), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "ZETA: 2 shard(s)\nALPHA: 0 shard(s)\n")
+
+	// the other formats aren't templated
+	buf.Reset()
+	format = JSON
+	c.Assert(p.PrintResource(res[:1]), qt.IsNil)
+	c.Assert(buf.String(), qt.Contains, `"name": "zeta"`)
+
+	// without a template, the table is printed
+	buf.Reset()
+	format = Human
+	p.SetTemplate(filepath.Join(dir, "branch-list.tmpl"))
+	c.Assert(p.PrintResource(res), qt.IsNil)
+	c.Assert(buf.String(), qt.Contains, "NAME")
+
+	c.Assert(ioutil.WriteFile(path, []byte(`{{.Name`), 0o644), qt.IsNil)
+	p.SetTemplate(path)
+	c.Assert(p.PrintResource(res), qt.ErrorMatches, `invalid output template .*database-list.tmpl: .*`)
+}
+
+func TestPrintProgress_JSON(t *testing.T) {
+	c := qt.New(t)
+
+	var events, human bytes.Buffer
+	format := Human
+	progressJSON := true
+	p := NewPrinter(&format)
+	p.SetHumanOutput(&human)
+	p.SetProgressJSON(&progressJSON)
+	p.SetProgressOutput(&events)
+
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	p.events.now = func() time.Time { return now }
+
+	end := p.PrintProgress(fmt.Sprintf("Creating branch %s", BoldBlue("dev")))
+	now = now.Add(2 * time.Second)
+	end()
+
+	p.Progress("backfill", 0, 400)
+	now = now.Add(10 * time.Second)
+	p.Progress("backfill", 100, 400)
+
+	c.Assert(human.String(), qt.Equals, "")
+	c.Assert(events.String(), qt.Equals, `{"time":"2026-10-15T12:00:00Z","event":"start","phase":"Creating branch dev","elapsed_seconds":0}
+{"time":"2026-10-15T12:00:02Z","event":"end","phase":"Creating branch dev","elapsed_seconds":2}
+{"time":"2026-10-15T12:00:02Z","event":"progress","phase":"backfill","percent":0,"elapsed_seconds":0}
+{"time":"2026-10-15T12:00:12Z","event":"progress","phase":"backfill","percent":25,"eta_seconds":30,"elapsed_seconds":10}
+`)
+
+	// without progress events, Progress prints nothing
+	events.Reset()
+	progressJSON = false
+	p.Progress("backfill", 200, 400)
+	c.Assert(events.String(), qt.Equals, "")
+}
+
+func TestBytes(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert(Bytes(0), qt.Equals, "0 B")
+	c.Assert(Bytes(999), qt.Equals, "999 B")
+	c.Assert(Bytes(1000), qt.Equals, "1.0 kB")
+	c.Assert(Bytes(1500000), qt.Equals, "1.5 MB")
+	c.Assert(Bytes(3200000000), qt.Equals, "3.2 GB")
+}
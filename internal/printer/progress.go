@@ -0,0 +1,119 @@
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// progressEvent is an event of the progress of a long-running command,
+// printed as a line of JSON.
+type progressEvent struct {
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"`
+	Phase string    `json:"phase"`
+
+	Percent        *float64 `json:"percent,omitempty"`
+	ETASeconds     *float64 `json:"eta_seconds,omitempty"`
+	ElapsedSeconds float64  `json:"elapsed_seconds"`
+}
+
+// ansiEscape matches the escape sequences coloring messages.
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// progress keeps track of the phases of the progress events.
+type progress struct {
+	mu     sync.Mutex
+	out    io.Writer
+	starts map[string]time.Time
+	now    func() time.Time
+}
+
+// SetProgressJSON sets whether the progress of long-running commands is
+// printed to stderr as newline delimited JSON events, instead of spinners,
+// so other programs can show it.
+func (p *Printer) SetProgressJSON(progressJSON *bool) {
+	p.progressJSON = progressJSON
+}
+
+// ProgressJSON returns whether progress events are printed.
+func (p *Printer) ProgressJSON() bool {
+	return p.progressJSON != nil && *p.progressJSON
+}
+
+// SetProgressOutput sets the output of progress events.
+func (p *Printer) SetProgressOutput(out io.Writer) {
+	p.events.out = out
+}
+
+// Progress reports that done of the total units of work of the phase are
+// done, such as the rows of a backfill. It's only printed as an event, with
+// the percentage done and the estimated time left, if progress events are
+// enabled. The phase starts with its first report.
+func (p *Printer) Progress(phase string, done, total int64) {
+	if !p.ProgressJSON() {
+		return
+	}
+
+	ev := p.events.event("progress", phase)
+	if total > 0 {
+		percent := math.Round(float64(done)/float64(total)*1000) / 10
+		ev.Percent = &percent
+	}
+	if done > 0 && total >= done {
+		eta := math.Round(ev.ElapsedSeconds / float64(done) * float64(total-done))
+		ev.ETASeconds = &eta
+	}
+	p.events.print(ev)
+}
+
+// printProgressEvents prints the start of the phase, and returns the function
+// printing its end.
+func (p *Printer) printProgressEvents(message string) func() {
+	phase := ansiEscape.ReplaceAllString(message, "")
+
+	events := p.events
+	events.print(events.event("start", phase))
+	return func() {
+		events.print(events.event("end", phase))
+
+		events.mu.Lock()
+		delete(events.starts, phase)
+		events.mu.Unlock()
+	}
+}
+
+// event returns an event of the phase, starting the phase if it's new.
+func (e *progress) event(name, phase string) *progressEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	start, ok := e.starts[phase]
+	if !ok {
+		start = now
+		e.starts[phase] = start
+	}
+
+	return &progressEvent{
+		Time:           now.UTC(),
+		Event:          name,
+		Phase:          phase,
+		ElapsedSeconds: math.Round(now.Sub(start).Seconds()*10) / 10,
+	}
+}
+
+func (e *progress) print(ev *progressEvent) {
+	buf, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	fmt.Fprintln(e.out, string(buf))
+}
@@ -0,0 +1,56 @@
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// SetTemplate sets the path of a custom template replacing the human
+// readable tables printed by PrintResource. Nothing changes if there's no
+// template at the path.
+func (p *Printer) SetTemplate(path string) {
+	p.templatePath = path
+}
+
+// templateFuncs are the functions available to custom templates, in
+// addition to the builtin ones of text/template.
+var templateFuncs = template.FuncMap{
+	"bytes": Bytes,
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"json": func(v interface{}) (string, error) {
+		buf, err := json.Marshal(v)
+		return string(buf), err
+	},
+}
+
+// printTemplate renders the resource with the custom template, and returns
+// whether there is one.
+func (p *Printer) printTemplate(out io.Writer, v interface{}) (bool, error) {
+	if p.templatePath == "" {
+		return false, nil
+	}
+
+	text, err := ioutil.ReadFile(p.templatePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	tmpl, err := template.New(p.templatePath).Funcs(templateFuncs).Parse(string(text))
+	if err != nil {
+		return true, fmt.Errorf("invalid output template %s: %s", p.templatePath, err)
+	}
+	if err := tmpl.Execute(out, v); err != nil {
+		return true, fmt.Errorf("can't render output template %s: %s", p.templatePath, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,241 @@
+package printer
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TimestampRelative is the default timestamp format of human readable
+// tables, printing the time relative to now, i.e. "3 hours ago".
+const TimestampRelative = "relative"
+
+// timestampLayouts are the named layouts accepted by --timestamp-format, in
+// addition to relative and Go layouts.
+var timestampLayouts = map[string]string{
+	"rfc3339":  time.RFC3339,
+	"datetime": "2006-01-02 15:04:05",
+	"date":     "2006-01-02",
+	"kitchen":  time.Kitchen,
+}
+
+// timestampOption matches the timestamp option of "header" tags, such as
+// "timestamp(ms|utc|human)".
+var timestampOption = regexp.MustCompile(`,timestamp\([^)]*\)`)
+
+// timestampFormat defines how PrintResource prints the timestamps of human
+// readable tables.
+type timestampFormat struct {
+	layout string
+	utc    bool
+}
+
+// TimestampFormat is the format of the timestamps of human readable tables,
+// either relative, the name of a layout, such as rfc3339, or a Go time
+// layout. It's used to define the --timestamp-format flag via the
+// flagset.Var() method.
+type TimestampFormat string
+
+func (f *TimestampFormat) String() string {
+	return string(*f)
+}
+
+func (f *TimestampFormat) Set(s string) error {
+	if _, ok := timestampLayouts[s]; !ok && s != TimestampRelative {
+		// Go layouts format some part of the time, everything else is
+		// most likely a typo of a named format
+		if time.Date(2021, 3, 8, 9, 10, 11, 0, time.UTC).Format(s) == s {
+			return fmt.Errorf("failed to parse timestamp format: %q. Valid values: %s, %s or a Go time layout such as \"Jan 2 15:04\"",
+				s, TimestampRelative, strings.Join(timestampLayoutNames(), ", "))
+		}
+	}
+
+	*f = TimestampFormat(s)
+	return nil
+}
+
+func (f *TimestampFormat) Type() string {
+	return "string"
+}
+
+// SetTimestampFormat sets how PrintResource prints the timestamps of human
+// readable tables. Absolute timestamps are printed in the local timezone,
+// unless utc is true. The timestamps of the other formats are left as they
+// are.
+func (p *Printer) SetTimestampFormat(format *TimestampFormat, utc *bool) {
+	p.timestampFormat = format
+	p.utc = utc
+}
+
+func timestampLayoutNames() []string {
+	names := make([]string, 0, len(timestampLayouts))
+	for name := range timestampLayouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// tableTimestamps returns v with the timestamps of its table formatted as
+// set with SetTimestampFormat. It returns v as is for relative timestamps,
+// which the table printer formats itself.
+func (p *Printer) tableTimestamps(v interface{}) interface{} {
+	if p.timestampFormat == nil || *p.timestampFormat == "" || *p.timestampFormat == TimestampRelative {
+		return v
+	}
+
+	layout, ok := timestampLayouts[string(*p.timestampFormat)]
+	if !ok {
+		layout = string(*p.timestampFormat)
+	}
+
+	f := &timestampFormat{layout: layout, utc: p.utc != nil && *p.utc}
+	val := reflect.ValueOf(v)
+	if !val.IsValid() {
+		return v
+	}
+	return f.value(val).Interface()
+}
+
+// format formats the timestamp in milliseconds, zero and negative ones are
+// printed as empty cells, just like the table printer does.
+func (f *timestampFormat) format(ms int64) string {
+	if ms <= 0 {
+		return ""
+	}
+
+	t := time.Unix(0, ms*int64(time.Millisecond))
+	if f.utc {
+		t = t.UTC()
+	}
+	return t.Format(f.layout)
+}
+
+// value returns v converted to the type returned by timestampType, with the
+// timestamps formatted.
+func (f *timestampFormat) value(v reflect.Value) reflect.Value {
+	typ := timestampType(v.Type())
+	if typ == v.Type() {
+		return v
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Zero(typ)
+		}
+		out := reflect.New(typ.Elem())
+		out.Elem().Set(f.value(v.Elem()))
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(typ)
+		}
+		out := reflect.MakeSlice(typ, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(f.value(v.Index(i)))
+		}
+		return out
+	case reflect.Struct:
+		out := reflect.New(typ).Elem()
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			src := v.FieldByName(field.Name)
+
+			switch {
+			case field.Type == src.Type():
+				out.Field(i).Set(src)
+			case src.Kind() == reflect.Int64:
+				out.Field(i).SetString(f.format(src.Int()))
+			case src.Kind() == reflect.Ptr && src.Type().Elem().Kind() == reflect.Int64:
+				if !src.IsNil() {
+					out.Field(i).SetString(f.format(src.Elem().Int()))
+				}
+			default:
+				out.Field(i).Set(f.value(src))
+			}
+		}
+		return out
+	}
+
+	return v
+}
+
+var (
+	timestampTypesMu sync.Mutex
+	timestampTypes   = make(map[reflect.Type]reflect.Type)
+)
+
+// timestampType returns typ with its timestamp fields, int64 fields with a
+// timestamp option in their "header" tag, replaced by string fields without
+// the option, so they can hold the formatted timestamps. It returns typ as
+// is if it has no timestamp fields.
+func timestampType(typ reflect.Type) reflect.Type {
+	timestampTypesMu.Lock()
+	defer timestampTypesMu.Unlock()
+
+	return timestampTypeLocked(typ)
+}
+
+func timestampTypeLocked(typ reflect.Type) reflect.Type {
+	if t, ok := timestampTypes[typ]; ok {
+		return t
+	}
+
+	out := typ
+	switch typ.Kind() {
+	case reflect.Ptr:
+		if elem := timestampTypeLocked(typ.Elem()); elem != typ.Elem() {
+			out = reflect.PtrTo(elem)
+		}
+	case reflect.Slice:
+		if elem := timestampTypeLocked(typ.Elem()); elem != typ.Elem() {
+			out = reflect.SliceOf(elem)
+		}
+	case reflect.Struct:
+		// guard against recursive types, they are left as they are
+		timestampTypes[typ] = typ
+
+		var fields []reflect.StructField
+		changed := false
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if field.PkgPath != "" {
+				// unexported fields aren't printed
+				continue
+			}
+
+			header := field.Tag.Get("header")
+			isTimestamp := timestampOption.MatchString(header) &&
+				(field.Type.Kind() == reflect.Int64 ||
+					field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Int64)
+
+			switch {
+			case isTimestamp:
+				header = timestampOption.ReplaceAllString(header, "")
+				field.Type = reflect.TypeOf("")
+				field.Tag = reflect.StructTag(fmt.Sprintf("header:%q", header))
+				changed = true
+			default:
+				if t := timestampTypeLocked(field.Type); t != field.Type {
+					field.Type = t
+					changed = true
+				}
+			}
+
+			field.Anonymous = false
+			fields = append(fields, field)
+		}
+
+		if changed {
+			out = reflect.StructOf(fields)
+		}
+	}
+
+	timestampTypes[typ] = out
+	return out
+}
@@ -0,0 +1,77 @@
+package printer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// jsonToYAML converts the given JSON document to YAML. The order of object
+// keys is kept, as opposed to unmarshaling into a map.
+func jsonToYAML(b []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	v, err := decodeYAMLValue(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(v)
+}
+
+func decodeYAMLValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			m := yaml.MapSlice{}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				val, err := decodeYAMLValue(dec)
+				if err != nil {
+					return nil, err
+				}
+
+				m = append(m, yaml.MapItem{Key: key, Value: val})
+			}
+
+			_, err := dec.Token() // '}'
+			return m, err
+		case '[':
+			s := []interface{}{}
+			for dec.More() {
+				val, err := decodeYAMLValue(dec)
+				if err != nil {
+					return nil, err
+				}
+
+				s = append(s, val)
+			}
+
+			_, err := dec.Token() // ']'
+			return s, err
+		}
+
+		return nil, fmt.Errorf("unexpected delimiter %q", t)
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		return t.Float64()
+	default:
+		// strings, booleans and null
+		return t, nil
+	}
+}
@@ -0,0 +1,84 @@
+package proxyutil
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/printer"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+	"github.com/planetscale/sql-proxy/proxy"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// OpenBranch connects to the branch through a local proxy with a certificate
+// of the role. The connection is closed, and the proxy stopped, with the
+// returned function.
+func OpenBranch(ctx context.Context, ch *cmdutil.Helper, database, branch string, role cmdutil.PasswordRole) (*sql.DB, func(), error) {
+	client, err := ch.Client()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dbBranch, err := client.DatabaseBranches.Get(ctx, &ps.GetDatabaseBranchRequest{
+		Organization: ch.Config.Organization,
+		Database:     database,
+		Branch:       branch,
+	})
+	if err != nil {
+		switch cmdutil.ErrCode(err) {
+		case ps.ErrNotFound:
+			return nil, nil, fmt.Errorf("branch %s does not exist in database %s (organization: %s)",
+				printer.BoldBlue(branch), printer.BoldBlue(database), printer.BoldBlue(ch.Config.Organization))
+		default:
+			return nil, nil, cmdutil.HandleError(err)
+		}
+	}
+
+	if !dbBranch.Ready {
+		return nil, nil, errors.New("database branch is not ready yet, please try again in a few minutes")
+	}
+
+	remoteAddr, err := RemoteAddr(ctx, ch.Config, client, database, branch, "")
+	if err != nil {
+		return nil, nil, cmdutil.HandleError(err)
+	}
+
+	p, err := proxy.NewClient(proxy.Options{
+		CertSource: NewRemoteCertSource(client, role),
+		LocalAddr:  "127.0.0.1:0",
+		RemoteAddr: remoteAddr,
+		Instance:   fmt.Sprintf("%s/%s/%s", ch.Config.Organization, database, branch),
+		Logger:     cmdutil.NewZapLogger(ch.Debug()),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't create proxy client: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		if err := p.Run(ctx); err != nil && ch.Debug() {
+			ch.Printer.Println("proxy error: ", err)
+		}
+	}()
+
+	addr, err := p.LocalAddr()
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	db, err := sql.Open("mysql", fmt.Sprintf("root@tcp(%s)/%s", addr.String(), database))
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	return db, func() {
+		db.Close()
+		cancel()
+	}, nil
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/planetscale/cli/internal/cmdutil"
+	"github.com/planetscale/cli/internal/config"
 
 	nanoid "github.com/matoous/go-nanoid/v2"
 
@@ -28,6 +29,38 @@ func NewRemoteCertSource(client *ps.Client, role cmdutil.PasswordRole) *RemoteCe
 	}
 }
 
+// proxyPort is the port of the PlanetScale edge the proxy connects to.
+const proxyPort = 3307
+
+// RemoteAddr returns the address the proxy of the branch connects to. It's
+// the explicitly passed one or, if the DNS is overridden, the overridden
+// address of the access host of the branch. Otherwise it's empty, as the
+// proxy connects to the access host by default.
+func RemoteAddr(ctx context.Context, cfg *config.Config, client *ps.Client, database, branch, explicit string) (string, error) {
+	if explicit != "" || cfg.DNS == nil {
+		return explicit, nil
+	}
+
+	b, err := client.DatabaseBranches.Get(ctx, &ps.GetDatabaseBranchRequest{
+		Organization: cfg.Organization,
+		Database:     database,
+		Branch:       branch,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	addr := AccessAddr(b.AccessHostURL)
+	resolved, err := cfg.DNS.Lookup(ctx, addr)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %s", addr, err)
+	}
+	if resolved == addr {
+		return "", nil
+	}
+	return resolved, nil
+}
+
 const publicIdAlphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
 const publicIdLength = 6
 
@@ -60,7 +93,7 @@ func (r *RemoteCertSource) Cert(ctx context.Context, org, db, branch string) (*p
 		ClientCert: tlsPair,
 		AccessHost: cert.Branch.AccessHostURL,
 		Ports: proxy.RemotePorts{
-			Proxy: 3307,
+			Proxy: proxyPort,
 			MySQL: 3306,
 		},
 	}, nil
@@ -0,0 +1,99 @@
+package proxyutil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/proxy"
+)
+
+// AccessAddr returns the address of the PlanetScale edge the proxy of a
+// branch with the given access host connects to.
+func AccessAddr(accessHost string) string {
+	return net.JoinHostPort(accessHost, strconv.Itoa(proxyPort))
+}
+
+// SOCKS5Dialer returns a dialer connecting through the SOCKS5 proxy, given
+// as host:port or as a socks5:// URL with optional credentials.
+func SOCKS5Dialer(proxyAddr string) (proxy.ContextDialer, error) {
+	if !strings.Contains(proxyAddr, "://") {
+		proxyAddr = "socks5://" + proxyAddr
+	}
+
+	u, err := url.Parse(proxyAddr)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid SOCKS5 proxy %q, expected host:port or socks5://[user:password@]host:port", proxyAddr)
+	}
+	if u.Scheme != "socks5" && u.Scheme != "socks5h" {
+		return nil, fmt.Errorf("unsupported SOCKS5 proxy scheme %q, supported schemes are: socks5, socks5h", u.Scheme)
+	}
+
+	d, err := proxy.FromURL(u, proxy.Direct)
+	if err != nil {
+		return nil, err
+	}
+	return d.(proxy.ContextDialer), nil
+}
+
+// ForwardSOCKS5 forwards the connections to a local address through the
+// SOCKS5 proxy to remoteAddr until ctx is done, and returns the local
+// address. The proxy connects to it in place of the remote address, the TLS
+// connection is still established with the remote end. Connections the
+// SOCKS5 proxy refuses are passed to onError.
+func ForwardSOCKS5(ctx context.Context, proxyAddr, remoteAddr string, onError func(error)) (string, error) {
+	dialer, err := SOCKS5Dialer(proxyAddr)
+	if err != nil {
+		return "", err
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go forward(ctx, dialer, conn, remoteAddr, onError)
+		}
+	}()
+
+	return l.Addr().String(), nil
+}
+
+func forward(ctx context.Context, dialer proxy.ContextDialer, conn net.Conn, remoteAddr string, onError func(error)) {
+	defer conn.Close()
+
+	remote, err := dialer.DialContext(ctx, "tcp", remoteAddr)
+	if err != nil {
+		if ctx.Err() == nil && onError != nil {
+			onError(fmt.Errorf("can't connect to %s through the SOCKS5 proxy: %s", remoteAddr, err))
+		}
+		return
+	}
+	defer remote.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		io.Copy(remote, conn) // nolint: errcheck
+		remote.Close()
+	}()
+
+	io.Copy(conn, remote) // nolint: errcheck
+	conn.Close()
+	<-done
+}
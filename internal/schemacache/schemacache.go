@@ -0,0 +1,91 @@
+// Package schemacache caches branch schemas locally, so repeated schema
+// lookups of an unchanged branch don't refetch the whole schema.
+package schemacache
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/planetscale/cli/internal/config"
+
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+// entry is the cached schema of a branch at the time it was last updated.
+type entry struct {
+	UpdatedAt time.Time  `json:"updated_at"`
+	Schemas   []*ps.Diff `json:"schemas"`
+}
+
+// Path returns the path of the cached schema of the branch.
+func Path(org, database, branch string) (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, "cache", "schemas", org, database, branch+".json"), nil
+}
+
+// Schema returns the schema of the branch. The cached schema is returned as
+// long as the branch wasn't updated since it was cached, unless refresh is
+// set. Failures to read or write the cache are ignored.
+func Schema(ctx context.Context, client *ps.Client, req *ps.BranchSchemaRequest, refresh bool) ([]*ps.Diff, error) {
+	branch, err := client.DatabaseBranches.Get(ctx, &ps.GetDatabaseBranchRequest{
+		Organization: req.Organization,
+		Database:     req.Database,
+		Branch:       req.Branch,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := Path(req.Organization, req.Database, req.Branch)
+	if err != nil {
+		return client.DatabaseBranches.Schema(ctx, req)
+	}
+
+	if !refresh {
+		if e := read(p); e != nil && e.UpdatedAt.Equal(branch.UpdatedAt) {
+			return e.Schemas, nil
+		}
+	}
+
+	schemas, err := client.DatabaseBranches.Schema(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	write(p, &entry{UpdatedAt: branch.UpdatedAt, Schemas: schemas})
+	return schemas, nil
+}
+
+func read(p string) *entry {
+	out, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil
+	}
+
+	var e entry
+	if err := json.Unmarshal(out, &e); err != nil {
+		return nil
+	}
+	return &e
+}
+
+func write(p string, e *entry) {
+	if err := os.MkdirAll(path.Dir(p), 0771); err != nil {
+		return
+	}
+
+	out, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	_ = ioutil.WriteFile(p, out, 0644)
+}
@@ -0,0 +1,556 @@
+package schemadiff
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type definitionKind int
+
+const (
+	column definitionKind = iota
+	primaryKey
+	index
+	foreignKey
+	check
+)
+
+func (k definitionKind) String() string {
+	switch k {
+	case primaryKey:
+		return "primary key"
+	case index:
+		return "index"
+	case foreignKey:
+		return "foreign key"
+	case check:
+		return "check constraint"
+	}
+	return "column"
+}
+
+// definition is a single column, index or constraint of a table.
+type definition struct {
+	kind definitionKind
+	name string
+	sql  string
+}
+
+// key identifies the definition across two versions of the same table.
+// Column and index names are case insensitive in MySQL and unnamed
+// constraints are identified by their definition.
+func (d *definition) key() string {
+	if d.name == "" {
+		return fmt.Sprintf("%d:%s", d.kind, d.sql)
+	}
+	return fmt.Sprintf("%d:%s", d.kind, strings.ToLower(d.name))
+}
+
+// Table is a CREATE TABLE statement split into its definitions.
+type Table struct {
+	Name string
+
+	columns []*definition
+	indexes []*definition
+	options string
+}
+
+var autoIncrementOption = regexp.MustCompile(`(?i)\s*AUTO_INCREMENT=\d+`)
+
+// ParseCreateTable parses a CREATE TABLE statement as returned by SHOW CREATE
+// TABLE.
+func ParseCreateTable(stmt string) (*Table, error) {
+	stmt = strings.TrimSuffix(strings.TrimSpace(stmt), ";")
+
+	rest, ok := trimPrefixFold(stmt, "CREATE TABLE")
+	if !ok {
+		return nil, fmt.Errorf("not a CREATE TABLE statement: %q", firstLine(stmt))
+	}
+	rest, _ = trimPrefixFold(strings.TrimSpace(rest), "IF NOT EXISTS")
+
+	name, rest := identifier(strings.TrimSpace(rest))
+	if name == "" {
+		return nil, fmt.Errorf("missing table name: %q", firstLine(stmt))
+	}
+
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, "(") {
+		return nil, fmt.Errorf("table %s: missing table definition", name)
+	}
+
+	end := matchingParen(rest)
+	if end < 0 {
+		return nil, fmt.Errorf("table %s: unbalanced parentheses", name)
+	}
+
+	t := &Table{
+		Name:    name,
+		options: strings.TrimSpace(rest[end+1:]),
+	}
+
+	for _, def := range splitTopLevel(rest[1:end], ',') {
+		def = strings.TrimSpace(def)
+		if def == "" {
+			continue
+		}
+
+		d := parseDefinition(def)
+		if d.kind == column {
+			t.columns = append(t.columns, d)
+		} else {
+			t.indexes = append(t.indexes, d)
+		}
+	}
+
+	return t, nil
+}
+
+func parseDefinition(def string) *definition {
+	upper := strings.ToUpper(def)
+	d := &definition{sql: def}
+
+	// constraints are classified by the keyword after their optional name
+	if strings.HasPrefix(upper, "CONSTRAINT") {
+		rest := strings.TrimSpace(def[len("CONSTRAINT"):])
+		if !constraintKeyword(rest) {
+			d.name, rest = identifier(rest)
+			rest = strings.TrimSpace(rest)
+		}
+		def, upper = rest, strings.ToUpper(rest)
+	}
+
+	switch {
+	case strings.HasPrefix(upper, "PRIMARY KEY"):
+		d.kind = primaryKey
+	case strings.HasPrefix(upper, "FOREIGN KEY"):
+		d.kind = foreignKey
+	case strings.HasPrefix(upper, "CHECK"):
+		d.kind = check
+	case d.name != "" && strings.HasPrefix(upper, "UNIQUE"):
+		// the index is named after the constraint, unless it's named
+		// explicitly
+		d.kind = index
+		if name := indexName(def, upper); name != "" {
+			d.name = name
+		}
+	default:
+		if name := indexName(def, upper); name != "" || isIndex(upper) {
+			d.kind = index
+			d.name = name
+			return d
+		}
+
+		d.kind = column
+		d.name, _ = identifier(def)
+	}
+
+	return d
+}
+
+// indexPrefixes start the definitions of indexes.
+var indexPrefixes = []string{
+	"UNIQUE KEY", "UNIQUE INDEX", "UNIQUE",
+	"FULLTEXT KEY", "FULLTEXT INDEX", "FULLTEXT",
+	"SPATIAL KEY", "SPATIAL INDEX", "SPATIAL",
+	"KEY", "INDEX",
+}
+
+func isIndex(upper string) bool {
+	for _, prefix := range indexPrefixes {
+		if strings.HasPrefix(upper, prefix+" ") || strings.HasPrefix(upper, prefix+"(") {
+			return true
+		}
+	}
+	return false
+}
+
+// indexName returns the name of the index defined by def, or an empty
+// string if it's unnamed.
+func indexName(def, upper string) string {
+	for _, prefix := range indexPrefixes {
+		if !strings.HasPrefix(upper, prefix+" ") && !strings.HasPrefix(upper, prefix+"(") {
+			continue
+		}
+
+		rest := strings.TrimSpace(def[len(prefix):])
+		if strings.HasPrefix(rest, "(") || constraintKeyword(rest) {
+			return ""
+		}
+		name, _ := identifier(rest)
+		return name
+	}
+	return ""
+}
+
+// constraintKeyword reports whether s starts with a keyword of a constraint
+// or index definition rather than with a name.
+func constraintKeyword(s string) bool {
+	upper := strings.ToUpper(s)
+	for _, kw := range []string{"PRIMARY KEY", "FOREIGN KEY", "UNIQUE", "CHECK", "KEY", "INDEX", "USING"} {
+		if strings.HasPrefix(upper, kw) && (len(upper) == len(kw) || strings.ContainsAny(upper[len(kw):len(kw)+1], " \t\n(")) {
+			return true
+		}
+	}
+	return false
+}
+
+// AlterTable returns the ALTER TABLE statement migrating the from table to
+// the to table, or an empty string if both are equal. Migrations that can't
+// be told apart from destructive ones, such as renamed columns, return an
+// error rather than statements dropping data.
+func AlterTable(from, to *Table) (string, error) {
+	var clauses []string
+
+	fromIndexes := definitionsByKey(from.indexes)
+	toIndexes := definitionsByKey(to.indexes)
+
+	for _, d := range from.indexes {
+		if n, ok := toIndexes[d.key()]; !ok || n.sql != d.sql {
+			clause, err := dropClause(to.Name, d)
+			if err != nil {
+				return "", err
+			}
+			clauses = append(clauses, clause)
+		}
+	}
+
+	fromColumns := definitionsByKey(from.columns)
+	toColumns := definitionsByKey(to.columns)
+
+	for _, d := range from.columns {
+		if _, ok := toColumns[d.key()]; ok {
+			continue
+		}
+
+		// a dropped column defined like an added one was probably
+		// renamed, dropping it would lose its data
+		for _, n := range to.columns {
+			if _, ok := fromColumns[n.key()]; !ok && columnType(n) == columnType(d) {
+				return "", fmt.Errorf("table %s: column %s may have been renamed to %s, which can't be told apart from dropping it; rename it with ALTER TABLE ... RENAME COLUMN instead",
+					to.Name, d.name, n.name)
+			}
+		}
+
+		clause, err := dropClause(to.Name, d)
+		if err != nil {
+			return "", err
+		}
+		clauses = append(clauses, clause)
+	}
+
+	for i, d := range to.columns {
+		o, ok := fromColumns[d.key()]
+		switch {
+		case !ok:
+			position := " FIRST"
+			if i > 0 {
+				position = " AFTER " + QuoteIdentifier(to.columns[i-1].name)
+			}
+			clauses = append(clauses, "ADD COLUMN "+d.sql+position)
+		case o.sql != d.sql:
+			clauses = append(clauses, "MODIFY COLUMN "+d.sql)
+		}
+	}
+
+	for _, d := range to.indexes {
+		if o, ok := fromIndexes[d.key()]; !ok || o.sql != d.sql {
+			clauses = append(clauses, "ADD "+d.sql)
+		}
+	}
+
+	fromOptions := autoIncrementOption.ReplaceAllString(from.options, "")
+	toOptions := autoIncrementOption.ReplaceAllString(to.options, "")
+	if fromOptions != toOptions && toOptions != "" {
+		clauses = append(clauses, toOptions)
+	}
+
+	if len(clauses) == 0 {
+		return "", nil
+	}
+
+	return fmt.Sprintf("ALTER TABLE %s\n  %s", QuoteIdentifier(to.Name), strings.Join(clauses, ",\n  ")), nil
+}
+
+// columnType returns the definition of the column without its name.
+func columnType(d *definition) string {
+	_, rest := identifier(d.sql)
+	return strings.TrimSpace(rest)
+}
+
+func dropClause(table string, d *definition) (string, error) {
+	switch d.kind {
+	case column:
+		return "DROP COLUMN " + QuoteIdentifier(d.name), nil
+	case primaryKey:
+		return "DROP PRIMARY KEY", nil
+	}
+
+	// unnamed constraints and indexes are named by MySQL, which can't be
+	// told from their definition
+	if d.name == "" {
+		return "", fmt.Errorf("table %s: can't drop the unnamed %s %q, name it in the schema", table, d.kind, d.sql)
+	}
+
+	switch d.kind {
+	case foreignKey:
+		return "DROP FOREIGN KEY " + QuoteIdentifier(d.name), nil
+	case check:
+		return "DROP CHECK " + QuoteIdentifier(d.name), nil
+	default:
+		return "DROP INDEX " + QuoteIdentifier(d.name), nil
+	}
+}
+
+// Statements returns the DDL statements that migrate the tables defined by
+// the old schema to the new schema. Both schemas are a sequence of CREATE
+// TABLE statements.
+func Statements(old, new string) ([]string, error) {
+	oldTables, err := parseTables(old)
+	if err != nil {
+		return nil, err
+	}
+
+	newTables, err := parseTables(new)
+	if err != nil {
+		return nil, err
+	}
+
+	oldByName := make(map[string]*Table, len(oldTables))
+	for _, t := range oldTables {
+		oldByName[strings.ToLower(t.Name)] = t
+	}
+
+	newByName := make(map[string]*Table, len(newTables))
+	for _, t := range newTables {
+		newByName[strings.ToLower(t.Name)] = t
+	}
+
+	var stmts []string
+	for _, t := range oldTables {
+		if _, ok := newByName[strings.ToLower(t.Name)]; !ok {
+			stmts = append(stmts, "DROP TABLE "+QuoteIdentifier(t.Name))
+		}
+	}
+
+	for _, t := range newTables {
+		o, ok := oldByName[strings.ToLower(t.Name)]
+		if !ok {
+			stmts = append(stmts, t.String())
+			continue
+		}
+
+		alter, err := AlterTable(o, t)
+		if err != nil {
+			return nil, err
+		}
+		if alter != "" {
+			stmts = append(stmts, alter)
+		}
+	}
+
+	return stmts, nil
+}
+
+// String returns the CREATE TABLE statement of the table.
+func (t *Table) String() string {
+	defs := make([]string, 0, len(t.columns)+len(t.indexes))
+	for _, d := range t.columns {
+		defs = append(defs, d.sql)
+	}
+	for _, d := range t.indexes {
+		defs = append(defs, d.sql)
+	}
+
+	stmt := fmt.Sprintf("CREATE TABLE %s (\n  %s\n)", QuoteIdentifier(t.Name), strings.Join(defs, ",\n  "))
+	if t.options != "" {
+		stmt += " " + t.options
+	}
+	return stmt
+}
+
+func parseTables(schema string) ([]*Table, error) {
+	var tables []*Table
+	for _, stmt := range SplitStatements(schema) {
+		t, err := ParseCreateTable(stmt)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// SplitStatements splits the given SQL into its statements. Comments are
+// removed and semicolons inside quotes are ignored.
+func SplitStatements(sql string) []string {
+	var (
+		stmts []string
+		cur   strings.Builder
+		quote rune
+	)
+
+	runes := []rune(sql)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		if quote != 0 {
+			cur.WriteRune(r)
+			if r == '\\' && quote != '`' && i+1 < len(runes) {
+				i++
+				cur.WriteRune(runes[i])
+			} else if r == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch {
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+			cur.WriteRune(r)
+		case r == '#' || (r == '-' && i+2 < len(runes) && runes[i+1] == '-' && (runes[i+2] == ' ' || runes[i+2] == '\t')):
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			cur.WriteRune('\n')
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				i++
+			}
+			i++
+		case r == ';':
+			if s := strings.TrimSpace(cur.String()); s != "" {
+				stmts = append(stmts, s)
+			}
+			cur.Reset()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+
+	if s := strings.TrimSpace(cur.String()); s != "" {
+		stmts = append(stmts, s)
+	}
+
+	return stmts
+}
+
+// QuoteIdentifier quotes the given MySQL identifier with backticks.
+func QuoteIdentifier(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+func definitionsByKey(defs []*definition) map[string]*definition {
+	m := make(map[string]*definition, len(defs))
+	for _, d := range defs {
+		m[d.key()] = d
+	}
+	return m
+}
+
+// identifier returns the leading, optionally backtick quoted, identifier of
+// s and the remaining string.
+func identifier(s string) (string, string) {
+	if strings.HasPrefix(s, "`") {
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			if s[i] == '`' {
+				if i+1 < len(s) && s[i+1] == '`' {
+					b.WriteByte('`')
+					i++
+					continue
+				}
+				return b.String(), s[i+1:]
+			}
+			b.WriteByte(s[i])
+		}
+		return "", s
+	}
+
+	end := strings.IndexAny(s, " \t\n(")
+	if end < 0 {
+		return s, ""
+	}
+	return s[:end], s[end:]
+}
+
+// matchingParen returns the index of the parenthesis closing the one s starts
+// with, or -1.
+func matchingParen(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitTopLevel splits s at every sep that is neither quoted nor nested in
+// parentheses.
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func trimPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,129 @@
+package schemadiff
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+const usersTable = "CREATE TABLE `users` (\n" +
+	"  `id` bigint NOT NULL AUTO_INCREMENT,\n" +
+	"  `email` varchar(255) DEFAULT NULL,\n" +
+	"  `name` varchar(64) NOT NULL,\n" +
+	"  PRIMARY KEY (`id`),\n" +
+	"  KEY `idx_name` (`name`)\n" +
+	") ENGINE=InnoDB AUTO_INCREMENT=42 DEFAULT CHARSET=utf8mb4"
+
+func TestAlterTable(t *testing.T) {
+	c := qt.New(t)
+
+	from, err := ParseCreateTable(usersTable)
+	c.Assert(err, qt.IsNil)
+	c.Assert(from.Name, qt.Equals, "users")
+
+	to, err := ParseCreateTable("CREATE TABLE `users` (\n" +
+		"  `id` bigint NOT NULL AUTO_INCREMENT,\n" +
+		"  `email` varchar(320) NOT NULL,\n" +
+		"  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  UNIQUE KEY `idx_email` (`email`)\n" +
+		") ENGINE=InnoDB AUTO_INCREMENT=50 DEFAULT CHARSET=utf8mb4")
+	c.Assert(err, qt.IsNil)
+
+	alter, err := AlterTable(from, to)
+	c.Assert(err, qt.IsNil)
+	c.Assert(alter, qt.Equals, "ALTER TABLE `users`\n"+
+		"  DROP INDEX `idx_name`,\n"+
+		"  DROP COLUMN `name`,\n"+
+		"  MODIFY COLUMN `email` varchar(320) NOT NULL,\n"+
+		"  ADD COLUMN `created_at` datetime DEFAULT CURRENT_TIMESTAMP AFTER `email`,\n"+
+		"  ADD UNIQUE KEY `idx_email` (`email`)")
+
+	alter, err = AlterTable(from, from)
+	c.Assert(err, qt.IsNil)
+	c.Assert(alter, qt.Equals, "")
+}
+
+func TestAlterTable_Constraints(t *testing.T) {
+	c := qt.New(t)
+
+	from, err := ParseCreateTable("CREATE TABLE `posts` (\n" +
+		"  `id` bigint NOT NULL,\n" +
+		"  `user_id` bigint NOT NULL,\n" +
+		"  `slug` varchar(64) NOT NULL,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  CONSTRAINT `uniq_slug` UNIQUE (`slug`),\n" +
+		"  CONSTRAINT `fk_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`),\n" +
+		"  CONSTRAINT `chk_id` CHECK (`id` > 0)\n" +
+		")")
+	c.Assert(err, qt.IsNil)
+
+	to, err := ParseCreateTable("CREATE TABLE `posts` (\n" +
+		"  `id` bigint NOT NULL,\n" +
+		"  `user_id` bigint NOT NULL,\n" +
+		"  `slug` varchar(64) NOT NULL,\n" +
+		"  PRIMARY KEY (`id`)\n" +
+		")")
+	c.Assert(err, qt.IsNil)
+
+	alter, err := AlterTable(from, to)
+	c.Assert(err, qt.IsNil)
+	c.Assert(alter, qt.Equals, "ALTER TABLE `posts`\n"+
+		"  DROP INDEX `uniq_slug`,\n"+
+		"  DROP FOREIGN KEY `fk_user`,\n"+
+		"  DROP CHECK `chk_id`")
+
+	// MySQL names unnamed foreign keys itself
+	from, err = ParseCreateTable("CREATE TABLE `posts` (\n" +
+		"  `id` bigint NOT NULL,\n" +
+		"  `user_id` bigint NOT NULL,\n" +
+		"  `slug` varchar(64) NOT NULL,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  FOREIGN KEY (`user_id`) REFERENCES `users` (`id`)\n" +
+		")")
+	c.Assert(err, qt.IsNil)
+
+	_, err = AlterTable(from, to)
+	c.Assert(err, qt.ErrorMatches, "table posts: can't drop the unnamed foreign key .*")
+}
+
+func TestAlterTable_Rename(t *testing.T) {
+	c := qt.New(t)
+
+	from, err := ParseCreateTable(usersTable)
+	c.Assert(err, qt.IsNil)
+
+	renamed := "CREATE TABLE `users` (\n" +
+		"  `id` bigint NOT NULL AUTO_INCREMENT,\n" +
+		"  `email` varchar(255) DEFAULT NULL,\n" +
+		"  `full_name` varchar(64) NOT NULL,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  KEY `idx_name` (`name`)\n" +
+		") ENGINE=InnoDB AUTO_INCREMENT=42 DEFAULT CHARSET=utf8mb4"
+	to, err := ParseCreateTable(renamed)
+	c.Assert(err, qt.IsNil)
+
+	_, err = AlterTable(from, to)
+	c.Assert(err, qt.ErrorMatches, "table users: column name may have been renamed to full_name.*")
+
+	_, err = Statements(usersTable, renamed)
+	c.Assert(err, qt.ErrorMatches, "table users: column name may have been renamed to full_name.*")
+}
+
+func TestStatements(t *testing.T) {
+	c := qt.New(t)
+
+	old := usersTable + ";\n\nCREATE TABLE `posts` (\n  `id` bigint NOT NULL,\n  PRIMARY KEY (`id`)\n);\n"
+	new := "-- the users table\n" + usersTable + ";\n\n" +
+		"CREATE TABLE `tags` (\n  `name` varchar(32) NOT NULL COMMENT 'a;b',\n  PRIMARY KEY (`name`)\n);\n"
+
+	stmts, err := Statements(old, new)
+	c.Assert(err, qt.IsNil)
+	c.Assert(stmts, qt.DeepEquals, []string{
+		"DROP TABLE `posts`",
+		"CREATE TABLE `tags` (\n  `name` varchar(32) NOT NULL COMMENT 'a;b',\n  PRIMARY KEY (`name`)\n)",
+	})
+
+	_, err = Statements(old, "DROP TABLE `users`;")
+	c.Assert(err, qt.ErrorMatches, "not a CREATE TABLE statement: .*")
+}
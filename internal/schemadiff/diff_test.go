@@ -0,0 +1,21 @@
+package schemadiff
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestLines(t *testing.T) {
+	c := qt.New(t)
+
+	lines := Lines("a\nb\nc\n", "a\nc\nd\n")
+	c.Assert(lines, qt.DeepEquals, []Line{
+		{Op: Equal, Text: "a"},
+		{Op: Delete, Text: "b"},
+		{Op: Equal, Text: "c"},
+		{Op: Insert, Text: "d"},
+	})
+	c.Assert(HasChanges(lines), qt.IsTrue)
+	c.Assert(HasChanges(Lines("a\n", "a")), qt.IsFalse)
+}
@@ -0,0 +1,267 @@
+package schemadiff
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/planetscale/cli/internal/printer"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+// Format is the rendering format of a diff.
+type Format string
+
+const (
+	// FormatUnified renders the diff as a colorized unified diff.
+	FormatUnified Format = "unified"
+	// FormatSideBySide renders the old and new schema next to each other.
+	FormatSideBySide Format = "side-by-side"
+	// FormatHTML renders the diff as a self-contained HTML document.
+	FormatHTML Format = "html"
+)
+
+// Formats are all supported diff formats.
+var Formats = []string{string(FormatUnified), string(FormatSideBySide), string(FormatHTML)}
+
+// ParseFormat parses the given diff format.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatUnified, FormatSideBySide, FormatHTML:
+		return f, nil
+	}
+	return "", fmt.Errorf("invalid diff format %q, allowed values are: %s", s, strings.Join(Formats, ", "))
+}
+
+// TableDiff is the diff of a single table.
+type TableDiff struct {
+	Name  string
+	Lines []Line
+}
+
+// ParseUnified parses a diff in which every line is prefixed with "+", "-" or
+// a space, as returned by the PlanetScale API.
+func ParseUnified(raw string) []Line {
+	var lines []Line
+	for _, txt := range splitLines(strings.TrimSpace(raw)) {
+		switch {
+		case strings.HasPrefix(txt, "+"):
+			lines = append(lines, Line{Op: Insert, Text: txt[1:]})
+		case strings.HasPrefix(txt, "-"):
+			lines = append(lines, Line{Op: Delete, Text: txt[1:]})
+		default:
+			lines = append(lines, Line{Op: Equal, Text: strings.TrimPrefix(txt, " ")})
+		}
+	}
+	return lines
+}
+
+// Render writes the diffs in the given format. The title is only used by the
+// HTML format.
+func Render(w io.Writer, format Format, title string, diffs []*TableDiff) error {
+	switch format {
+	case FormatHTML:
+		return writeHTML(w, title, diffs)
+	case FormatSideBySide:
+		for _, df := range diffs {
+			fmt.Fprintln(w, "--", printer.BoldBlue(df.Name), "--")
+			writeSideBySide(w, df.Lines)
+		}
+	default:
+		for _, df := range diffs {
+			fmt.Fprintln(w, "--", printer.BoldBlue(df.Name), "--")
+			WriteUnified(w, df.Lines, -1)
+		}
+	}
+	return nil
+}
+
+// row is a single row of a side-by-side diff.
+type row struct {
+	Left, Right       string
+	HasLeft, HasRight bool
+	Changed           bool
+
+	// LeftHTML and RightHTML are only set for the HTML format.
+	LeftHTML, RightHTML template.HTML
+
+	// leftSegs and rightSegs are set if the row is a modified line that can
+	// be highlighted word by word.
+	leftSegs, rightSegs []segment
+}
+
+// rows pairs deleted lines with the inserted lines following them, so
+// modified lines end up on the same row.
+func rows(lines []Line) []row {
+	var (
+		out               []row
+		deleted, inserted []string
+	)
+
+	flush := func() {
+		n := len(deleted)
+		if len(inserted) > n {
+			n = len(inserted)
+		}
+		for i := 0; i < n; i++ {
+			r := row{Changed: true}
+			if i < len(deleted) {
+				r.Left, r.HasLeft = deleted[i], true
+			}
+			if i < len(inserted) {
+				r.Right, r.HasRight = inserted[i], true
+			}
+			if r.HasLeft && r.HasRight {
+				r.leftSegs, r.rightSegs, _ = wordDiff(r.Left, r.Right)
+			}
+			out = append(out, r)
+		}
+		deleted, inserted = nil, nil
+	}
+
+	for _, l := range lines {
+		switch l.Op {
+		case Delete:
+			if len(inserted) > 0 {
+				flush()
+			}
+			deleted = append(deleted, l.Text)
+		case Insert:
+			inserted = append(inserted, l.Text)
+		default:
+			flush()
+			out = append(out, row{Left: l.Text, Right: l.Text, HasLeft: true, HasRight: true})
+		}
+	}
+	flush()
+
+	return out
+}
+
+// maxSideBySideWidth caps the width of the left column, so a single long
+// line doesn't push the new schema off the screen.
+const maxSideBySideWidth = 80
+
+func writeSideBySide(w io.Writer, lines []Line) {
+	rs := rows(lines)
+
+	width := 0
+	for _, r := range rs {
+		if n := utf8.RuneCountInString(r.Left); n > width {
+			width = n
+		}
+	}
+	if width > maxSideBySideWidth {
+		width = maxSideBySideWidth
+	}
+
+	for _, r := range rs {
+		marker := " "
+		switch {
+		case r.Changed && r.HasLeft && r.HasRight:
+			marker = "|"
+		case r.Changed && r.HasLeft:
+			marker = "<"
+		case r.Changed:
+			marker = ">"
+		}
+
+		left, right := r.Left, r.Right
+		switch {
+		case r.leftSegs != nil:
+			left = colorize(r.leftSegs, deleteColor, deleteEmphasisColor)
+			right = colorize(r.rightSegs, insertColor, insertEmphasisColor)
+		case r.Changed:
+			left = deleteColor.Sprint(left)
+			right = insertColor.Sprint(right)
+		}
+
+		if pad := width - utf8.RuneCountInString(r.Left); pad > 0 {
+			left += strings.Repeat(" ", pad)
+		}
+
+		fmt.Fprintln(w, strings.TrimRight(fmt.Sprintf("%s %s %s", left, marker, right), " "))
+	}
+}
+
+var htmlTemplate = template.Must(template.New("diff").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292f; }
+h1 { font-size: 1.4em; }
+h2 { font-size: 1.1em; margin-top: 2em; }
+table { border-collapse: collapse; width: 100%; font-family: SFMono-Regular, Consolas, Menlo, monospace; font-size: 0.85em; }
+td { padding: 0 0.5em; white-space: pre-wrap; vertical-align: top; border: 1px solid #d0d7de; width: 50%; }
+td.del { background: #ffebe9; }
+td.ins { background: #e6ffec; }
+td.empty { background: #f6f8fa; }
+td.del span { background: #ffc1c0; }
+td.ins span { background: #abf2bc; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Tables}}<h2>{{.Name}}</h2>
+<table>
+{{range .Rows}}<tr>{{if .HasLeft}}<td class="{{if .Changed}}del{{end}}">{{.LeftHTML}}</td>{{else}}<td class="empty"></td>{{end}}{{if .HasRight}}<td class="{{if .Changed}}ins{{end}}">{{.RightHTML}}</td>{{else}}<td class="empty"></td>{{end}}</tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+type htmlTable struct {
+	Name string
+	Rows []row
+}
+
+// writeHTML writes the diffs as a self-contained HTML document with the
+// old and new schema side by side.
+func writeHTML(w io.Writer, title string, diffs []*TableDiff) error {
+	data := struct {
+		Title  string
+		Tables []htmlTable
+	}{Title: title}
+
+	for _, df := range diffs {
+		rs := rows(df.Lines)
+		for i := range rs {
+			rs[i].LeftHTML = htmlSegments(rs[i].leftSegs, rs[i].Left)
+			rs[i].RightHTML = htmlSegments(rs[i].rightSegs, rs[i].Right)
+		}
+		data.Tables = append(data.Tables, htmlTable{Name: df.Name, Rows: rs})
+	}
+
+	return htmlTemplate.Execute(w, data)
+}
+
+// FromDiffs converts the diffs returned by the PlanetScale API.
+func FromDiffs(diffs []*ps.Diff) []*TableDiff {
+	out := make([]*TableDiff, 0, len(diffs))
+	for _, df := range diffs {
+		out = append(out, &TableDiff{Name: df.Name, Lines: ParseUnified(df.Raw)})
+	}
+	return out
+}
+
+// htmlSegments returns the escaped text with changed segments wrapped in a
+// span, so they can be highlighted.
+func htmlSegments(segs []segment, text string) template.HTML {
+	if segs == nil {
+		return template.HTML(template.HTMLEscapeString(text))
+	}
+
+	var b strings.Builder
+	for _, s := range segs {
+		if s.changed {
+			b.WriteString("<span>" + template.HTMLEscapeString(s.text) + "</span>")
+		} else {
+			b.WriteString(template.HTMLEscapeString(s.text))
+		}
+	}
+	return template.HTML(b.String())
+}
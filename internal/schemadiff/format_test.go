@@ -0,0 +1,58 @@
+package schemadiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	qt "github.com/frankban/quicktest"
+)
+
+const rawDiff = " CREATE TABLE `users` (\n" +
+	"-  `email` varchar(255),\n" +
+	"+  `email` varchar(320) NOT NULL,\n" +
+	"+  `name` varchar(64),\n" +
+	"   PRIMARY KEY (`id`)\n" +
+	" )"
+
+func TestRender_SideBySide(t *testing.T) {
+	c := qt.New(t)
+
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	var b strings.Builder
+	err := Render(&b, FormatSideBySide, "", []*TableDiff{{Name: "users", Lines: ParseUnified(rawDiff)}})
+	c.Assert(err, qt.IsNil)
+	c.Assert(b.String(), qt.Equals, "-- users --\n"+
+		"CREATE TABLE `users` (    CREATE TABLE `users` (\n"+
+		"  `email` varchar(255), |   `email` varchar(320) NOT NULL,\n"+
+		"                        >   `name` varchar(64),\n"+
+		"  PRIMARY KEY (`id`)        PRIMARY KEY (`id`)\n"+
+		")                         )\n")
+}
+
+func TestRender_HTML(t *testing.T) {
+	c := qt.New(t)
+
+	var b strings.Builder
+	err := Render(&b, FormatHTML, "Deploy request mydb/1", []*TableDiff{{Name: "users", Lines: ParseUnified(rawDiff)}})
+	c.Assert(err, qt.IsNil)
+
+	out := b.String()
+	c.Assert(out, qt.Contains, "<title>Deploy request mydb/1</title>")
+	c.Assert(out, qt.Contains, `<td class="del">  `+"`email` varchar(<span>255</span>),</td>")
+	c.Assert(out, qt.Contains, `<td class="empty"></td><td class="ins">  `+"`name` varchar(64),</td>")
+}
+
+func TestParseFormat(t *testing.T) {
+	c := qt.New(t)
+
+	f, err := ParseFormat("side-by-side")
+	c.Assert(err, qt.IsNil)
+	c.Assert(f, qt.Equals, FormatSideBySide)
+
+	_, err = ParseFormat("word")
+	c.Assert(err, qt.ErrorMatches, `invalid diff format "word".*`)
+}
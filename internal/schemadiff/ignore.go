@@ -0,0 +1,225 @@
+package schemadiff
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/planetscale/cli/internal/config"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+// IgnoreFile is the name of the file in the root of a project that defines
+// which schema differences are ignored.
+const IgnoreFile = ".pscale-diffignore"
+
+var commentClause = regexp.MustCompile(`(?i)\s*COMMENT(\s*=)?\s*'(?:[^'\\]|\\.|'')*'`)
+
+// Ignore defines the tables, columns and attributes that are excluded from
+// schema diffs.
+type Ignore struct {
+	tables        []string
+	columns       [][2]string
+	autoIncrement bool
+	comments      bool
+}
+
+// ParseIgnore parses ignore rules, one per line. Empty lines and lines
+// starting with '#' are skipped. The supported rules are:
+//
+//	table:<pattern>                    ignore tables matching the pattern
+//	column:<table pattern>.<pattern>   ignore matching columns
+//	auto_increment                     ignore AUTO_INCREMENT counters
+//	comments                           ignore COMMENT clauses
+//
+// A rule without a prefix is a table pattern. Patterns use the syntax of
+// path.Match, i.e: "tmp_*".
+func ParseIgnore(r io.Reader) (*Ignore, error) {
+	ig := &Ignore{}
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		rule := strings.TrimSpace(scanner.Text())
+		if rule == "" || strings.HasPrefix(rule, "#") {
+			continue
+		}
+
+		var patterns []string
+		switch {
+		case strings.EqualFold(rule, "auto_increment"):
+			ig.autoIncrement = true
+			continue
+		case strings.EqualFold(rule, "comments"):
+			ig.comments = true
+			continue
+		case strings.HasPrefix(rule, "column:"):
+			pattern := strings.TrimPrefix(rule, "column:")
+			i := strings.LastIndex(pattern, ".")
+			if i < 0 {
+				return nil, fmt.Errorf("line %d: column rule %q must be of the form column:<table>.<column>", n, rule)
+			}
+			ig.columns = append(ig.columns, [2]string{pattern[:i], pattern[i+1:]})
+			patterns = []string{pattern[:i], pattern[i+1:]}
+		default:
+			pattern := strings.TrimPrefix(rule, "table:")
+			ig.tables = append(ig.tables, pattern)
+			patterns = []string{pattern}
+		}
+
+		for _, p := range patterns {
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, fmt.Errorf("line %d: invalid pattern %q: %s", n, p, err)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return ig, nil
+}
+
+// ProjectIgnore returns the ignore rules of the current project. If the
+// project has no ignore file, nothing is ignored.
+func ProjectIgnore() (*Ignore, error) {
+	cfgPath, err := config.ProjectConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path.Join(path.Dir(cfgPath), IgnoreFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &Ignore{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	ig, err := ParseIgnore(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", IgnoreFile, err)
+	}
+	return ig, nil
+}
+
+// Apply removes the ignored differences from the diffs. Tables without any
+// remaining differences are removed.
+func (ig *Ignore) Apply(diffs []*TableDiff) []*TableDiff {
+	if ig == nil {
+		return diffs
+	}
+
+	out := make([]*TableDiff, 0, len(diffs))
+	for _, df := range diffs {
+		if ig.ignoresTable(df.Name) {
+			continue
+		}
+
+		if len(ig.columns) == 0 && !ig.autoIncrement && !ig.comments {
+			out = append(out, df)
+			continue
+		}
+
+		var old, new strings.Builder
+		for _, l := range df.Lines {
+			text, ok := ig.normalize(df.Name, l.Text)
+			if !ok {
+				continue
+			}
+
+			if l.Op != Insert {
+				old.WriteString(text + "\n")
+			}
+			if l.Op != Delete {
+				new.WriteString(text + "\n")
+			}
+		}
+
+		lines := Lines(old.String(), new.String())
+		if HasChanges(df.Lines) && !HasChanges(lines) {
+			continue
+		}
+		out = append(out, &TableDiff{Name: df.Name, Lines: lines})
+	}
+
+	return out
+}
+
+// ApplyDiffs removes the ignored differences from diffs returned by the
+// PlanetScale API. The HTML representation of modified diffs is dropped as
+// it doesn't reflect the ignore rules.
+func (ig *Ignore) ApplyDiffs(diffs []*ps.Diff) []*ps.Diff {
+	if ig == nil || ig.empty() {
+		return diffs
+	}
+
+	byName := make(map[string]*ps.Diff, len(diffs))
+	for _, df := range diffs {
+		byName[df.Name] = df
+	}
+
+	var out []*ps.Diff
+	for _, td := range ig.Apply(FromDiffs(diffs)) {
+		orig := byName[td.Name]
+
+		lines := make([]string, 0, len(td.Lines))
+		for _, l := range td.Lines {
+			lines = append(lines, l.String())
+		}
+
+		raw := strings.Join(lines, "\n")
+		if strings.TrimSpace(raw) == strings.TrimSpace(orig.Raw) {
+			out = append(out, orig)
+			continue
+		}
+
+		out = append(out, &ps.Diff{Name: orig.Name, Raw: raw})
+	}
+
+	return out
+}
+
+func (ig *Ignore) empty() bool {
+	return len(ig.tables) == 0 && len(ig.columns) == 0 && !ig.autoIncrement && !ig.comments
+}
+
+func (ig *Ignore) ignoresTable(table string) bool {
+	for _, p := range ig.tables {
+		if ok, _ := path.Match(p, table); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize strips the ignored attributes from the line. It returns false if
+// the whole line is ignored.
+func (ig *Ignore) normalize(table, line string) (string, bool) {
+	if len(ig.columns) > 0 && strings.HasPrefix(strings.TrimSpace(line), "`") {
+		column, _ := identifier(strings.TrimSpace(line))
+		for _, c := range ig.columns {
+			tableOK, _ := path.Match(c[0], table)
+			columnOK, _ := path.Match(c[1], column)
+			if tableOK && columnOK {
+				return "", false
+			}
+		}
+	}
+
+	if ig.autoIncrement {
+		line = autoIncrementOption.ReplaceAllString(line, "")
+	}
+	if ig.comments {
+		line = commentClause.ReplaceAllString(line, "")
+	}
+
+	return line, true
+}
@@ -0,0 +1,52 @@
+package schemadiff
+
+import (
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	ps "github.com/planetscale/planetscale-go/planetscale"
+)
+
+func TestIgnore_ApplyDiffs(t *testing.T) {
+	c := qt.New(t)
+
+	ig, err := ParseIgnore(strings.NewReader(`# noisy tables
+tmp_*
+column:users.updated_at
+auto_increment
+comments
+`))
+	c.Assert(err, qt.IsNil)
+
+	diffs := []*ps.Diff{
+		{Name: "tmp_import", Raw: "+CREATE TABLE `tmp_import` (\n+  `id` bigint\n+)"},
+		{Name: "users", Raw: " CREATE TABLE `users` (\n" +
+			"-  `name` varchar(64) COMMENT 'old',\n" +
+			"+  `name` varchar(64) COMMENT 'new',\n" +
+			"+  `updated_at` datetime,\n" +
+			"-) ENGINE=InnoDB AUTO_INCREMENT=10\n" +
+			"+) ENGINE=InnoDB AUTO_INCREMENT=12"},
+		{Name: "posts", Raw: " CREATE TABLE `posts` (\n" +
+			"-  `title` varchar(64),\n" +
+			"+  `title` varchar(255),\n" +
+			" )"},
+	}
+
+	got := ig.ApplyDiffs(diffs)
+	c.Assert(got, qt.HasLen, 1)
+	c.Assert(got[0], qt.Equals, diffs[2])
+}
+
+func TestIgnore_Empty(t *testing.T) {
+	c := qt.New(t)
+
+	ig, err := ParseIgnore(strings.NewReader("\n# nothing to ignore\n"))
+	c.Assert(err, qt.IsNil)
+
+	diffs := []*ps.Diff{{Name: "foo"}, {Name: "bar"}}
+	c.Assert(ig.ApplyDiffs(diffs), qt.DeepEquals, diffs)
+
+	_, err = ParseIgnore(strings.NewReader("column:users"))
+	c.Assert(err, qt.ErrorMatches, "line 1: column rule .* must be of the form column:<table>.<column>")
+}
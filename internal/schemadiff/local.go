@@ -0,0 +1,121 @@
+package schemadiff
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ReadSchemaDir reads the tables defined by the .sql files in dir and its
+// subdirectories. Statements other than CREATE TABLE, such as inserts of
+// seed data, are skipped.
+func ReadSchemaDir(fsys fs.FS, dir string) ([]*Table, error) {
+	var tables []*Table
+	defined := map[string]string{}
+
+	err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(p) != ".sql" {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+
+		for _, stmt := range SplitStatements(string(data)) {
+			if _, ok := trimPrefixFold(stmt, "CREATE TABLE"); !ok {
+				continue
+			}
+
+			t, err := ParseCreateTable(stmt)
+			if err != nil {
+				return fmt.Errorf("%s: %s", p, err)
+			}
+
+			name := strings.ToLower(t.Name)
+			if other, ok := defined[name]; ok {
+				return fmt.Errorf("table %s is defined in both %s and %s", t.Name, other, p)
+			}
+			defined[name] = p
+
+			tables = append(tables, t)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tables, nil
+}
+
+// CompareTables returns the diffs of the tables that differ between old
+// and new, sorted by name. Tables that only exist in one of them are
+// entirely deleted or inserted.
+func CompareTables(old, new []*Table) []*TableDiff {
+	oldByName := make(map[string]*Table, len(old))
+	newByName := make(map[string]*Table, len(new))
+	var names []string
+
+	for _, t := range old {
+		name := strings.ToLower(t.Name)
+		oldByName[name] = t
+		names = append(names, name)
+	}
+	for _, t := range new {
+		name := strings.ToLower(t.Name)
+		newByName[name] = t
+		if _, ok := oldByName[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var diffs []*TableDiff
+	for _, name := range names {
+		o, n := oldByName[name], newByName[name]
+
+		var oldStmt, newStmt string
+		display := name
+		if o != nil {
+			oldStmt = normalizeTable(o)
+			display = o.Name
+		}
+		if n != nil {
+			newStmt = normalizeTable(n)
+			display = n.Name
+		}
+
+		lines := Lines(oldStmt, newStmt)
+		if HasChanges(lines) {
+			diffs = append(diffs, &TableDiff{Name: display, Lines: lines})
+		}
+	}
+
+	return diffs
+}
+
+// normalizeTable returns the CREATE TABLE statement of the table without
+// the differences MySQL doesn't preserve, such as the whitespace within
+// definitions, or that don't change the schema, such as the current
+// AUTO_INCREMENT value.
+func normalizeTable(t *Table) string {
+	n := &Table{
+		Name:    t.Name,
+		options: strings.Join(strings.Fields(autoIncrementOption.ReplaceAllString(t.options, "")), " "),
+	}
+	for _, d := range t.columns {
+		n.columns = append(n.columns, &definition{kind: d.kind, name: d.name, sql: strings.Join(strings.Fields(d.sql), " ")})
+	}
+	for _, d := range t.indexes {
+		n.indexes = append(n.indexes, &definition{kind: d.kind, name: d.name, sql: strings.Join(strings.Fields(d.sql), " ")})
+	}
+
+	return n.String()
+}
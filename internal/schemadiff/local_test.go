@@ -0,0 +1,87 @@
+package schemadiff
+
+import (
+	"testing"
+	"testing/fstest"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestReadSchemaDir(t *testing.T) {
+	c := qt.New(t)
+
+	fsys := fstest.MapFS{
+		"schema/users.sql": {Data: []byte(usersTable + ";\n")},
+		"schema/shop/orders.sql": {Data: []byte("-- orders of the users\n" +
+			"CREATE TABLE orders (\n  id bigint NOT NULL,\n  PRIMARY KEY (id)\n);\n" +
+			"INSERT INTO orders VALUES (1);\n")},
+		"schema/README.md": {Data: []byte("CREATE TABLE ignored (id int)")},
+	}
+
+	tables, err := ReadSchemaDir(fsys, "schema")
+	c.Assert(err, qt.IsNil)
+	c.Assert(tables, qt.HasLen, 2)
+	c.Assert(tables[0].Name, qt.Equals, "orders")
+	c.Assert(tables[1].Name, qt.Equals, "users")
+
+	fsys["schema/more_users.sql"] = &fstest.MapFile{Data: []byte(usersTable)}
+	_, err = ReadSchemaDir(fsys, "schema")
+	c.Assert(err, qt.ErrorMatches, "table users is defined in both schema/more_users.sql and schema/users.sql")
+}
+
+func TestCompareTables(t *testing.T) {
+	c := qt.New(t)
+
+	parse := func(stmt string) *Table {
+		t, err := ParseCreateTable(stmt)
+		c.Assert(err, qt.IsNil)
+		return t
+	}
+
+	users := parse(usersTable)
+	orders := parse("CREATE TABLE `orders` (\n  `id` bigint NOT NULL\n)")
+
+	// the AUTO_INCREMENT value and the whitespace don't matter
+	localUsers := parse("CREATE TABLE `users` (\n" +
+		"  `id`  bigint NOT NULL AUTO_INCREMENT,\n" +
+		"  `email` varchar(255) DEFAULT NULL,\n" +
+		"  `name` varchar(64) NOT NULL,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  KEY `idx_name` (`name`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4")
+	c.Assert(CompareTables([]*Table{users}, []*Table{localUsers}), qt.HasLen, 0)
+
+	changedUsers := parse("CREATE TABLE `users` (\n" +
+		"  `id` bigint NOT NULL AUTO_INCREMENT,\n" +
+		"  `email` varchar(320) NOT NULL,\n" +
+		"  `name` varchar(64) NOT NULL,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  KEY `idx_name` (`name`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4")
+	invoices := parse("CREATE TABLE invoices (\n  id bigint NOT NULL\n)")
+
+	diffs := CompareTables([]*Table{users, orders}, []*Table{invoices, changedUsers})
+	c.Assert(diffs, qt.HasLen, 3)
+
+	c.Assert(diffs[0].Name, qt.Equals, "invoices")
+	for _, l := range diffs[0].Lines {
+		c.Assert(l.Op, qt.Equals, Insert)
+	}
+
+	c.Assert(diffs[1].Name, qt.Equals, "orders")
+	for _, l := range diffs[1].Lines {
+		c.Assert(l.Op, qt.Equals, Delete)
+	}
+
+	c.Assert(diffs[2].Name, qt.Equals, "users")
+	var changed []Line
+	for _, l := range diffs[2].Lines {
+		if l.Op != Equal {
+			changed = append(changed, l)
+		}
+	}
+	c.Assert(changed, qt.DeepEquals, []Line{
+		{Op: Delete, Text: "  `email` varchar(255) DEFAULT NULL,"},
+		{Op: Insert, Text: "  `email` varchar(320) NOT NULL,"},
+	})
+}
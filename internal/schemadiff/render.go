@@ -0,0 +1,70 @@
+package schemadiff
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/fatih/color"
+)
+
+var (
+	insertColor = color.New(color.FgGreen).Add(color.Bold)
+	deleteColor = color.New(color.FgRed).Add(color.Bold)
+	hunkColor   = color.New(color.FgCyan)
+)
+
+// WriteUnified writes the lines in unified diff format with inserted lines
+// in green and deleted lines in red. Only context lines around changes are
+// written, a negative context writes all lines.
+func WriteUnified(w io.Writer, lines []Line, context int) {
+	visible := visibleLines(lines, context)
+	segs := pairSegments(lines)
+
+	prev := -1
+	for i, l := range lines {
+		if !visible[i] {
+			continue
+		}
+
+		if context >= 0 && prev != i-1 {
+			fmt.Fprintln(w, hunkColor.Sprint("@@"))
+		}
+		prev = i
+
+		s, paired := segs[i]
+		switch {
+		case l.Op == Insert && paired:
+			fmt.Fprintln(w, insertColor.Sprint("+")+colorize(s, insertColor, insertEmphasisColor))
+		case l.Op == Delete && paired:
+			fmt.Fprintln(w, deleteColor.Sprint("-")+colorize(s, deleteColor, deleteEmphasisColor))
+		case l.Op == Insert:
+			fmt.Fprintln(w, insertColor.Sprint(l.String()))
+		case l.Op == Delete:
+			fmt.Fprintln(w, deleteColor.Sprint(l.String()))
+		default:
+			fmt.Fprintln(w, l.String())
+		}
+	}
+}
+
+// visibleLines marks the lines that are within context lines of a change.
+func visibleLines(lines []Line, context int) []bool {
+	visible := make([]bool, len(lines))
+	for i, l := range lines {
+		if context < 0 {
+			visible[i] = true
+			continue
+		}
+
+		if l.Op == Equal {
+			continue
+		}
+
+		for j := i - context; j <= i+context; j++ {
+			if j >= 0 && j < len(lines) {
+				visible[j] = true
+			}
+		}
+	}
+	return visible
+}
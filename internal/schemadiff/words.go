@@ -0,0 +1,143 @@
+package schemadiff
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/fatih/color"
+)
+
+var (
+	insertEmphasisColor = color.New(color.FgGreen, color.Bold, color.ReverseVideo)
+	deleteEmphasisColor = color.New(color.FgRed, color.Bold, color.ReverseVideo)
+)
+
+// minSimilarity is the minimum share of unchanged tokens for two lines to be
+// highlighted word by word. Lines that differ more are shown as a whole.
+const minSimilarity = 0.5
+
+// segment is a part of a line that either changed or didn't change compared
+// to the line it's paired with.
+type segment struct {
+	text    string
+	changed bool
+}
+
+// wordDiff returns the segments of a deleted and an inserted line, so
+// changed words, such as a column type or default, can be highlighted. It
+// returns false if the lines are too different to be compared.
+func wordDiff(old, new string) ([]segment, []segment, bool) {
+	ops := lcsDiff(tokenize(old), tokenize(new))
+
+	var equal, total int
+	for _, op := range ops {
+		if strings.TrimSpace(op.Text) == "" {
+			continue
+		}
+		if op.Op == Equal {
+			equal += 2
+			total += 2
+		} else {
+			total++
+		}
+	}
+
+	if total == 0 || float64(equal)/float64(total) < minSimilarity {
+		return nil, nil, false
+	}
+
+	var oldSegs, newSegs []segment
+	for _, op := range ops {
+		switch op.Op {
+		case Equal:
+			oldSegs = appendSegment(oldSegs, op.Text, false)
+			newSegs = appendSegment(newSegs, op.Text, false)
+		case Delete:
+			oldSegs = appendSegment(oldSegs, op.Text, true)
+		case Insert:
+			newSegs = appendSegment(newSegs, op.Text, true)
+		}
+	}
+
+	return oldSegs, newSegs, true
+}
+
+// appendSegment appends the text, merging it into the last segment if both
+// have the same state.
+func appendSegment(segs []segment, text string, changed bool) []segment {
+	if n := len(segs); n > 0 && segs[n-1].changed == changed {
+		segs[n-1].text += text
+		return segs
+	}
+	return append(segs, segment{text: text, changed: changed})
+}
+
+// tokenize splits the line into words, whitespace and single punctuation
+// characters.
+func tokenize(s string) []string {
+	var tokens []string
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		j := i + 1
+		switch {
+		case isWord(runes[i]):
+			for j < len(runes) && isWord(runes[j]) {
+				j++
+			}
+		case unicode.IsSpace(runes[i]):
+			for j < len(runes) && unicode.IsSpace(runes[j]) {
+				j++
+			}
+		}
+		tokens = append(tokens, string(runes[i:j]))
+		i = j
+	}
+	return tokens
+}
+
+func isWord(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// pairSegments pairs runs of deleted lines with the inserted lines following
+// them and returns the word level segments of both, keyed by line index.
+func pairSegments(lines []Line) map[int][]segment {
+	segs := make(map[int][]segment)
+	for i := 0; i < len(lines); {
+		if lines[i].Op != Delete {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(lines) && lines[i].Op == Delete {
+			i++
+		}
+		mid := i
+		for i < len(lines) && lines[i].Op == Insert {
+			i++
+		}
+
+		for k := 0; start+k < mid && mid+k < i; k++ {
+			oldSegs, newSegs, ok := wordDiff(lines[start+k].Text, lines[mid+k].Text)
+			if ok {
+				segs[start+k] = oldSegs
+				segs[mid+k] = newSegs
+			}
+		}
+	}
+	return segs
+}
+
+// colorize renders the segments with changed segments emphasized.
+func colorize(segs []segment, base, emphasis *color.Color) string {
+	var b strings.Builder
+	for _, s := range segs {
+		if s.changed {
+			b.WriteString(emphasis.Sprint(s.text))
+		} else {
+			b.WriteString(base.Sprint(s.text))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package schemadiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	qt "github.com/frankban/quicktest"
+)
+
+func TestWordDiff(t *testing.T) {
+	c := qt.New(t)
+
+	oldSegs, newSegs, ok := wordDiff("  `status` int DEFAULT '0',", "  `status` tinyint DEFAULT '1',")
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(markSegments(oldSegs), qt.Equals, "  `status` [int] DEFAULT '[0]',")
+	c.Assert(markSegments(newSegs), qt.Equals, "  `status` [tinyint] DEFAULT '[1]',")
+
+	_, _, ok = wordDiff("  KEY `idx_a` (`a`),", "  `created_at` datetime NOT NULL,")
+	c.Assert(ok, qt.IsFalse)
+}
+
+func TestWriteUnified_WordLevel(t *testing.T) {
+	c := qt.New(t)
+
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	var b strings.Builder
+	WriteUnified(&b, Lines("`id` int NOT NULL\n", "`id` bigint NOT NULL\n"), -1)
+
+	c.Assert(b.String(), qt.Contains, deleteEmphasisColor.Sprint("int"))
+	c.Assert(b.String(), qt.Contains, insertEmphasisColor.Sprint("bigint"))
+}
+
+// markSegments encloses changed segments in brackets.
+func markSegments(segs []segment) string {
+	var b strings.Builder
+	for _, s := range segs {
+		if s.changed {
+			b.WriteString("[" + s.text + "]")
+		} else {
+			b.WriteString(s.text)
+		}
+	}
+	return b.String()
+}
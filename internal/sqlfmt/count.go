@@ -0,0 +1,115 @@
+package sqlfmt
+
+import "strings"
+
+// clausePart is a word at the top level of a statement, with the text
+// following it up to the next one.
+type clausePart struct {
+	word string // in upper case
+	text string // starting with the word as written
+}
+
+// CountQuery returns a query counting the rows the UPDATE or DELETE statement
+// changes: a SELECT COUNT(*) of its tables with the same WHERE clause, and the
+// same ORDER BY and LIMIT clauses if it has a LIMIT. Multiple-table
+// statements count the rows of the join, which is an estimate.
+//
+// ok reports whether the statement is an UPDATE or DELETE. The query is empty
+// if it can't be built, i.e. for statements starting with WITH.
+func CountQuery(stmt string) (query string, ok bool) {
+	var parts []clausePart
+	depth := 0
+	for _, t := range tokenize(strings.TrimRight(strings.TrimSpace(stmt), "; \t\r\n")) {
+		switch {
+		case t.kind == tokenComment, len(parts) == 0 && t.kind == tokenSpace:
+			continue
+		case t.text == "(":
+			depth++
+		case t.text == ")":
+			depth--
+		case depth == 0 && t.kind == tokenWord:
+			parts = append(parts, clausePart{word: t.upper(), text: t.text})
+			continue
+		}
+
+		if len(parts) == 0 {
+			return "", false
+		}
+		parts[len(parts)-1].text += t.text
+	}
+	if len(parts) == 0 {
+		return "", false
+	}
+	if parts[0].word == "WITH" {
+		for _, p := range parts {
+			if p.word == "UPDATE" || p.word == "DELETE" {
+				return "", true
+			}
+		}
+	}
+	if parts[0].word != "UPDATE" && parts[0].word != "DELETE" {
+		return "", false
+	}
+
+	var tables string
+	var found bool
+	if parts[0].word == "UPDATE" {
+		tables, found = clause(parts, "UPDATE", "SET")
+		for _, modifier := range []string{"LOW_PRIORITY", "IGNORE"} {
+			tables = trimWord(tables, modifier)
+		}
+	} else if tables, found = clause(parts, "USING", "WHERE"); !found {
+		tables, found = clause(parts, "FROM", "WHERE", "ORDER", "LIMIT")
+	}
+	if !found || tables == "" {
+		return "", true
+	}
+
+	from := " FROM " + tables
+	if where, ok := clause(parts, "WHERE", "ORDER", "LIMIT"); ok {
+		from += " WHERE " + where
+	}
+
+	limit, ok := clause(parts, "LIMIT")
+	if !ok {
+		return "SELECT COUNT(*)" + from, true
+	}
+
+	inner := "SELECT 1" + from
+	if order, ok := clause(parts, "ORDER", "LIMIT"); ok {
+		inner += " ORDER " + order
+	}
+	return "SELECT COUNT(*) FROM (" + inner + " LIMIT " + limit + ") AS t", true
+}
+
+// clause returns the text from the first part starting with the word, without
+// it, up to the first following part starting with one of the words in end.
+func clause(parts []clausePart, word string, end ...string) (string, bool) {
+	start := -1
+	var b strings.Builder
+	for i, p := range parts {
+		if start < 0 {
+			if p.word == word {
+				start = i
+				b.WriteString(p.text[len(p.word):])
+			}
+			continue
+		}
+
+		for _, e := range end {
+			if p.word == e {
+				return strings.TrimSpace(b.String()), true
+			}
+		}
+		b.WriteString(p.text)
+	}
+	return strings.TrimSpace(b.String()), start >= 0
+}
+
+// trimWord removes the word from the start of the text, if it starts with it.
+func trimWord(text, word string) string {
+	if len(text) > len(word) && strings.EqualFold(text[:len(word)], word) && text[len(word)] == ' ' {
+		return strings.TrimSpace(text[len(word):])
+	}
+	return text
+}
@@ -0,0 +1,66 @@
+package sqlfmt
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestCountQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		stmt  string
+		query string
+		ok    bool
+	}{
+		{
+			name:  "update",
+			stmt:  "update low_priority users set name = 'where', age = (select 1) where id in (select id from banned);",
+			query: "SELECT COUNT(*) FROM users WHERE id in (select id from banned)",
+			ok:    true,
+		},
+		{
+			name:  "update without where",
+			stmt:  "UPDATE users SET active = 0",
+			query: "SELECT COUNT(*) FROM users",
+			ok:    true,
+		},
+		{
+			name:  "multiple-table update",
+			stmt:  "UPDATE users u JOIN orders o ON o.user_id = u.id SET u.total = o.total WHERE o.status = 'paid'",
+			query: "SELECT COUNT(*) FROM users u JOIN orders o ON o.user_id = u.id WHERE o.status = 'paid'",
+			ok:    true,
+		},
+		{
+			name:  "delete with limit",
+			stmt:  "/* cleanup */ DELETE FROM logs WHERE created_at < '2021-01-01' ORDER BY created_at LIMIT 1000",
+			query: "SELECT COUNT(*) FROM (SELECT 1 FROM logs WHERE created_at < '2021-01-01' ORDER BY created_at LIMIT 1000) AS t",
+			ok:    true,
+		},
+		{
+			name:  "delete using",
+			stmt:  "DELETE FROM t1 USING t1 JOIN t2 ON t1.id = t2.id WHERE t2.x = 1",
+			query: "SELECT COUNT(*) FROM t1 JOIN t2 ON t1.id = t2.id WHERE t2.x = 1",
+			ok:    true,
+		},
+		{
+			name: "with",
+			stmt: "WITH old AS (SELECT id FROM logs) DELETE FROM logs WHERE id IN (SELECT id FROM old)",
+			ok:   true,
+		},
+		{
+			name: "select",
+			stmt: "SELECT * FROM users WHERE name = 'update'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+
+			query, ok := CountQuery(tt.stmt)
+			c.Assert(query, qt.Equals, tt.query)
+			c.Assert(ok, qt.Equals, tt.ok)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package sqlfmt
+
+import "strings"
+
+// Fingerprint returns the statement with its literals replaced by ?, so the
+// executions of a statement with different values share it. Comments are
+// removed, words are lower cased, backticks are removed from plain
+// identifiers and white space is collapsed. Lists of literals are replaced by
+// ?+, as in "id in (?+)", which also matches the (...) of the digest texts of
+// performance_schema.
+func Fingerprint(stmt string) string {
+	type piece struct {
+		text  string
+		space bool // preceded by a space
+	}
+
+	var pieces []piece
+	space := false
+	for _, t := range tokenize(strings.TrimRight(strings.TrimSpace(stmt), "; \t\r\n")) {
+		text := t.text
+		switch t.kind {
+		case tokenSpace, tokenComment:
+			space = len(pieces) > 0
+			continue
+		case tokenString, tokenNumber:
+			text = "?"
+		case tokenWord:
+			text = strings.ToLower(text)
+		case tokenIdent:
+			if name := strings.Trim(text, "`"); name != "" && strings.IndexFunc(name, isNotWordRune) < 0 {
+				text = strings.ToLower(name)
+			}
+		}
+
+		n := len(pieces)
+		switch {
+		case text == "?" && n >= 2 && pieces[n-1].text == "," && (pieces[n-2].text == "?" || pieces[n-2].text == "?+"):
+			pieces = pieces[:n-1]
+			pieces[n-2].text = "?+"
+		case text == "," || text == ")" || (n > 0 && pieces[n-1].text == "("):
+			pieces = append(pieces, piece{text: text})
+		default:
+			pieces = append(pieces, piece{text: text, space: space})
+		}
+		space = false
+	}
+
+	var b strings.Builder
+	for _, p := range pieces {
+		if p.space {
+			b.WriteByte(' ')
+		}
+		b.WriteString(p.text)
+	}
+	return strings.ReplaceAll(b.String(), "(...)", "(?+)")
+}
+
+func isNotWordRune(r rune) bool {
+	return !isWordRune(r)
+}
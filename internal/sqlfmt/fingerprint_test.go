@@ -0,0 +1,42 @@
+package sqlfmt
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		stmt        string
+		fingerprint string
+	}{
+		{
+			stmt:        "SELECT * FROM users WHERE id = 42;",
+			fingerprint: "select * from users where id = ?",
+		},
+		{
+			stmt:        "select *\n  from   users /* api */ where name = 'it''s' and age > 1.5",
+			fingerprint: "select * from users where name = ? and age > ?",
+		},
+		{
+			stmt:        "SELECT id FROM `Orders` WHERE user_id IN ( 1 , 2, 3 ) AND status IN ('paid')",
+			fingerprint: "select id from orders where user_id in (?+) and status in (?)",
+		},
+		{
+			stmt:        "SELECT * FROM `users` WHERE `id` IN (...) AND `first name` = ?",
+			fingerprint: "select * from users where id in (?+) and `first name` = ?",
+		},
+		{
+			stmt:        "INSERT INTO logs (a, b) VALUES (1, 'x')",
+			fingerprint: "insert into logs (a, b) values (?+)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.stmt, func(t *testing.T) {
+			c := qt.New(t)
+			c.Assert(Fingerprint(tt.stmt), qt.Equals, tt.fingerprint)
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package sqlfmt
+
+import "strings"
+
+const indentUnit = "  "
+
+// selectIndent aligns the columns of the select list under the first one.
+const selectIndent = "       "
+
+// keywords are written in upper case.
+var keywords = wordSet(`
+	ADD ALL ALTER AND AS ASC AUTO_INCREMENT BEGIN BETWEEN BY CASE CHANGE
+	CHARACTER COLLATE COLUMN COMMIT CONSTRAINT CREATE CROSS DATABASE DEFAULT
+	DELETE DESC DESCRIBE DISTINCT DIV DROP DUPLICATE ELSE END ENGINE EXISTS
+	EXPLAIN FALSE FOR FOREIGN FROM GROUP HAVING IGNORE IN INDEX INNER INSERT
+	INTERVAL INTO IS JOIN KEY LEFT LIKE LIMIT LOCK MOD MODIFY NATURAL NOT NULL
+	OFFSET ON OR ORDER OUTER OVER PARTITION PRIMARY RECURSIVE REFERENCES
+	REGEXP RENAME REPLACE RIGHT ROLLBACK SCHEMA SELECT SET SHARE SHOW START
+	STRAIGHT_JOIN TABLE THEN TO TRANSACTION TRUE TRUNCATE UNION UNIQUE
+	UNSIGNED UPDATE USE USING VALUES VIEW WHEN WHERE WINDOW WITH XOR
+`)
+
+// functions are written in upper case too, but are directly followed by
+// their arguments. This includes the types taking a length.
+var functions = wordSet(`
+	AVG BIGINT BINARY CAST CHAR COALESCE CONCAT CONVERT COUNT DATE DATETIME
+	DECIMAL DOUBLE ENUM FLOAT GROUP_CONCAT IF IFNULL INT JSON_EXTRACT LEFT
+	LENGTH LOWER MAX MEDIUMINT MIN NOW NULLIF RIGHT SMALLINT SUBSTRING SUM
+	TIME TIMESTAMP TINYINT UPPER VARBINARY VARCHAR
+`)
+
+// manipulating are the statements whose clauses are broken onto their own
+// lines. Other statements are kept on a single line.
+var manipulating = wordSet("DELETE INSERT REPLACE SELECT UPDATE WITH")
+
+// tablePrefixes are followed by a table name, which is kept as it is, as
+// table names are case sensitive.
+var tablePrefixes = wordSet("FROM INTO JOIN STRAIGHT_JOIN TABLE UPDATE")
+
+// joinPrefixes are the words that can precede JOIN.
+var joinPrefixes = wordSet("CROSS INNER LEFT NATURAL OUTER RIGHT")
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+// Format pretty-prints the statements: keywords are upper-cased and the
+// clauses of queries start on their own lines, with their conditions and the
+// select list broken onto indented lines. Subqueries are indented. Literals,
+// identifiers and comments are kept as they are. Statements are separated by
+// an empty line.
+//
+// Format doesn't validate the statements, it formats anything that tokenizes.
+func Format(sql string) string {
+	f := &formatter{tokens: tokenize(sql)}
+	f.reset()
+
+	for i, t := range f.tokens {
+		f.write(i, t)
+	}
+	return f.b.String()
+}
+
+// frame is the state of the statement, or of the parentheses it's in.
+type frame struct {
+	indent string
+	// breaks is whether clauses start on new lines, which is only the case
+	// for subqueries and manipulating statements
+	breaks bool
+	// clause is the current clause of the frame, i.e. "WHERE"
+	clause  string
+	between bool
+}
+
+type formatter struct {
+	tokens []token
+	b      strings.Builder
+
+	stack []frame
+	// first is the first word of the current statement
+	first string
+	// prev is the last token written, comments aside
+	prev token
+	// table is whether prev is a table name
+	table bool
+
+	// the separation of the next token from the previous one
+	sep       bool
+	breakLine bool
+	indent    string
+	noSpace   bool
+}
+
+// reset starts a new statement.
+func (f *formatter) reset() {
+	f.stack = []frame{{}}
+	f.first = ""
+	f.prev = token{}
+}
+
+func (f *formatter) top() *frame {
+	return &f.stack[len(f.stack)-1]
+}
+
+// lineBreak starts the next token on a new line with the indentation.
+func (f *formatter) lineBreak(indent string) {
+	if f.b.Len() > 0 {
+		f.breakLine = true
+		f.indent = indent
+	}
+}
+
+// emit writes the text, preceded by the pending line break, or a space if
+// space is set.
+func (f *formatter) emit(text string, space bool) {
+	switch {
+	case f.breakLine:
+		f.b.WriteString("\n" + f.indent)
+	case space && !f.noSpace && f.b.Len() > 0:
+		f.b.WriteByte(' ')
+	}
+	f.breakLine, f.noSpace = false, false
+	f.b.WriteString(text)
+}
+
+// next returns the token following the i-th one, spaces and comments aside.
+func (f *formatter) next(i int) token {
+	for _, t := range f.tokens[i+1:] {
+		if t.kind != tokenSpace && t.kind != tokenComment {
+			return t
+		}
+	}
+	return token{}
+}
+
+func (f *formatter) write(i int, t token) {
+	switch t.kind {
+	case tokenSpace:
+		return
+	case tokenComment:
+		f.emit(strings.TrimSpace(t.text), true)
+		if !strings.HasPrefix(t.text, "/*") {
+			f.lineBreak(f.top().indent)
+		}
+		return
+	}
+
+	if f.sep {
+		f.b.WriteByte('\n')
+		f.lineBreak("")
+		f.sep = false
+	}
+
+	top := f.top()
+	word := t.upper()
+	prev := f.prev.upper()
+	defer func() {
+		f.prev = t
+		f.table = t.kind != tokenPunct && tablePrefixes[prev]
+	}()
+
+	if t.kind == tokenPunct {
+		f.punct(t, top, prev)
+		return
+	}
+
+	if word == "" {
+		f.emit(t.text, true)
+		return
+	}
+
+	if f.first == "" {
+		f.first = word
+		top.breaks = manipulating[word]
+	}
+
+	text := t.text
+	keyword := keywords[word] || functions[word]
+	if keyword && !tablePrefixes[prev] && f.prev.text != "." &&
+		!(f.prev.text == "," && top.clause == "FROM") {
+		text = word
+	}
+
+	if word == "SELECT" && f.prev.text == "(" && f.stack[0].breaks {
+		// a subquery
+		parent := f.stack[len(f.stack)-2]
+		top.breaks = true
+		top.indent = parent.indent + indentUnit
+		top.clause = word
+		f.lineBreak(top.indent)
+		f.emit(text, true)
+		return
+	}
+
+	if !keyword || !top.breaks {
+		f.emit(text, true)
+		return
+	}
+
+	switch {
+	case f.isClause(i, word, prev):
+		top.clause = word
+		f.lineBreak(top.indent)
+	case word == "BETWEEN":
+		top.between = true
+	case word == "AND" && top.between:
+		top.between = false
+	case (word == "AND" || word == "OR") && (top.clause == "WHERE" || top.clause == "HAVING"):
+		f.lineBreak(top.indent + indentUnit)
+	}
+	f.emit(text, true)
+}
+
+// isClause reports whether the i-th token, the keyword, starts a clause.
+func (f *formatter) isClause(i int, word, prev string) bool {
+	switch word {
+	case "SELECT", "FROM":
+		return prev != "DELETE"
+	case "WHERE", "GROUP", "ORDER", "HAVING", "LIMIT", "UNION":
+		return true
+	case "VALUES":
+		return f.prev.text != "=" && f.prev.text != ","
+	case "SET":
+		return (f.first == "UPDATE" || f.first == "INSERT") && prev != "CHARACTER"
+	case "JOIN", "STRAIGHT_JOIN":
+		return !joinPrefixes[prev]
+	case "CROSS", "INNER", "NATURAL":
+		return true
+	case "LEFT", "RIGHT":
+		next := f.next(i).upper()
+		return next == "JOIN" || next == "OUTER"
+	}
+	return false
+}
+
+func (f *formatter) punct(t token, top *frame, prev string) {
+	switch t.text {
+	case ";":
+		f.emit(";", false)
+		f.sep = true
+		f.reset()
+	case "(":
+		// function calls are directly followed by their arguments
+		space := f.prev.kind != tokenWord && f.prev.kind != tokenIdent && f.prev.text != "("
+		if f.table || (f.prev.kind == tokenWord && keywords[prev] && !functions[prev]) {
+			space = true
+		}
+		f.emit("(", space)
+		f.noSpace = true
+		f.stack = append(f.stack, frame{indent: top.indent})
+	case ")":
+		if len(f.stack) > 1 {
+			f.stack = f.stack[:len(f.stack)-1]
+		}
+		if top.breaks {
+			f.lineBreak(f.top().indent)
+		}
+		f.emit(")", false)
+	case ",":
+		f.emit(",", false)
+		if top.breaks && top.clause == "SELECT" {
+			f.lineBreak(top.indent + selectIndent)
+		}
+	case ".":
+		f.emit(".", false)
+		f.noSpace = true
+	case "-", "+", "~", "!":
+		f.emit(t.text, true)
+		// unary operators are directly followed by their operand
+		if t.text == "~" || t.text == "!" || f.prev.text == "" ||
+			(f.prev.kind == tokenPunct && f.prev.text != ")") || keywords[prev] {
+			f.noSpace = true
+		}
+	default:
+		f.emit(t.text, true)
+	}
+}
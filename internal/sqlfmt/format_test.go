@@ -0,0 +1,132 @@
+package sqlfmt
+
+import (
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{
+			name: "query",
+			sql: "select a, count(*) as n from `users` u left join orders o on o.user_id = u.id " +
+				"where u.age between 18 and 65 and o.total > -1 or u.name like 'a;b' group by a order by n desc limit 10",
+			want: []string{
+				"SELECT a,",
+				"       COUNT(*) AS n",
+				"FROM `users` u",
+				"LEFT JOIN orders o ON o.user_id = u.id",
+				"WHERE u.age BETWEEN 18 AND 65",
+				"  AND o.total > -1",
+				"  OR u.name LIKE 'a;b'",
+				"GROUP BY a",
+				"ORDER BY n DESC",
+				"LIMIT 10",
+			},
+		},
+		{
+			name: "subquery",
+			sql:  "select * from t where id in (select id from s where x=1)",
+			want: []string{
+				"SELECT *",
+				"FROM t",
+				"WHERE id IN (",
+				"  SELECT id",
+				"  FROM s",
+				"  WHERE x = 1",
+				")",
+			},
+		},
+		{
+			name: "statements",
+			sql:  "update t set a=1 where id=2;delete from t where a = 1;",
+			want: []string{
+				"UPDATE t",
+				"SET a = 1",
+				"WHERE id = 2;",
+				"",
+				"DELETE FROM t",
+				"WHERE a = 1;",
+			},
+		},
+		{
+			name: "insert",
+			sql:  "insert into t (a,b) values (1,'x'),(2,\"it''s\")",
+			want: []string{
+				"INSERT INTO t (a, b)",
+				`VALUES (1, 'x'), (2, "it''s")`,
+			},
+		},
+		{
+			name: "table names",
+			sql:  "select date(created_at) from date, Time",
+			want: []string{
+				"SELECT DATE(created_at)",
+				"FROM date, Time",
+			},
+		},
+		{
+			name: "union",
+			sql:  "select left(name, 3) from t union all select name from s",
+			want: []string{
+				"SELECT LEFT(name, 3)",
+				"FROM t",
+				"UNION ALL",
+				"SELECT name",
+				"FROM s",
+			},
+		},
+		{
+			name: "comments",
+			sql:  "-- users\nselect 1 -- one\nfrom dual /* done */",
+			want: []string{
+				"-- users",
+				"SELECT 1 -- one",
+				"FROM dual /* done */",
+			},
+		},
+		{
+			name: "definition",
+			sql:  "create table t (id bigint unsigned not null auto_increment, name varchar(255), primary key (id))",
+			want: []string{
+				"CREATE TABLE t (id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT, name VARCHAR(255), PRIMARY KEY (id))",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c := qt.New(t)
+			c.Assert(Format(tt.sql), qt.Equals, strings.Join(tt.want, "\n"))
+		})
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	c := qt.New(t)
+
+	sql := "SELECT 'a\\'b', `c``d` FROM t WHERE x <> 1.5 -- end"
+	var texts []string
+	for _, tok := range tokenize(sql) {
+		if tok.kind != tokenSpace {
+			texts = append(texts, tok.text)
+		}
+	}
+	c.Assert(texts, qt.DeepEquals, []string{
+		"SELECT", `'a\'b'`, ",", "`c``d`", "FROM", "t", "WHERE", "x", "<>", "1.5", "-- end",
+	})
+
+	// the tokens make up the input
+	var b strings.Builder
+	for _, tok := range tokenize(sql + " 'unterminated") {
+		b.WriteString(tok.text)
+	}
+	c.Assert(b.String(), qt.Equals, sql+" 'unterminated")
+}
@@ -0,0 +1,121 @@
+// Package sqlfmt pretty-prints and rewrites MySQL statements.
+package sqlfmt
+
+import (
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenSpace tokenKind = iota
+	tokenComment
+	tokenString
+	tokenIdent // quoted with backticks
+	tokenNumber
+	tokenWord
+	tokenPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// upper returns the text of a word in upper case, and "" for other tokens.
+func (t token) upper() string {
+	if t.kind != tokenWord {
+		return ""
+	}
+	return strings.ToUpper(t.text)
+}
+
+// tokenize splits the statements into tokens. The tokens make up the input
+// exactly, unterminated strings and comments run until its end.
+func tokenize(sql string) []token {
+	var tokens []token
+	rs := []rune(sql)
+
+	for i := 0; i < len(rs); {
+		start := i
+		kind := tokenPunct
+		r := rs[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			kind = tokenSpace
+			for i < len(rs) && unicode.IsSpace(rs[i]) {
+				i++
+			}
+		case r == '#' || (r == '-' && i+1 < len(rs) && rs[i+1] == '-' && (i+2 == len(rs) || unicode.IsSpace(rs[i+2]))):
+			kind = tokenComment
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			kind = tokenComment
+			i += 2
+			for i < len(rs) && !(rs[i] == '*' && i+1 < len(rs) && rs[i+1] == '/') {
+				i++
+			}
+			i += 2
+			if i > len(rs) {
+				i = len(rs)
+			}
+		case r == '\'' || r == '"' || r == '`':
+			kind = tokenString
+			if r == '`' {
+				kind = tokenIdent
+			}
+			i = scanQuoted(rs, i)
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(rs) && unicode.IsDigit(rs[i+1])):
+			kind = tokenNumber
+			for i < len(rs) && (unicode.IsDigit(rs[i]) || unicode.IsLetter(rs[i]) || rs[i] == '.') {
+				i++
+			}
+		case isWordRune(r):
+			kind = tokenWord
+			for i < len(rs) && isWordRune(rs[i]) {
+				i++
+			}
+		default:
+			i++
+			// multi character operators
+			if i < len(rs) {
+				switch string(rs[start : i+1]) {
+				case "<=", ">=", "<>", "!=", ":=", "||", "&&", "<<", ">>", "->":
+					i++
+				}
+			}
+		}
+
+		tokens = append(tokens, token{kind: kind, text: string(rs[start:i])})
+	}
+
+	return tokens
+}
+
+// scanQuoted returns the end of the quoted string starting at i. Quotes are
+// escaped by doubling them, or with a backslash in strings.
+func scanQuoted(rs []rune, i int) int {
+	quote := rs[i]
+	i++
+	for i < len(rs) {
+		switch {
+		case rs[i] == '\\' && quote != '`':
+			i += 2
+		case rs[i] == quote && i+1 < len(rs) && rs[i+1] == quote:
+			i += 2
+		case rs[i] == quote:
+			return i + 1
+		default:
+			i++
+		}
+	}
+	return len(rs)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r == '$' || r == '@' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,42 @@
+package testutil
+
+import (
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// OSFS is an fs.FS reading from the actual file system with absolute paths.
+type OSFS struct{}
+
+func (OSFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+// TempHome points HOME to a temporary directory for the duration of the
+// test, so commands can write to the config directory. The platform's config
+// directory is unset, so it's always ~/.config/planetscale.
+func TempHome(t testing.TB) string {
+	dir := t.TempDir()
+
+	vars := []string{"HOME", "XDG_CONFIG_HOME", "APPDATA"}
+	saved := make(map[string]string)
+	for _, v := range vars {
+		saved[v] = os.Getenv(v)
+	}
+	t.Cleanup(func() {
+		for _, v := range vars {
+			os.Setenv(v, saved[v])
+		}
+		homedir.Reset()
+	})
+
+	os.Setenv("HOME", dir)
+	os.Setenv("XDG_CONFIG_HOME", "")
+	os.Setenv("APPDATA", "")
+	homedir.Reset()
+
+	return dir
+}
@@ -0,0 +1,222 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxTracedBody is the size up to which bodies are written to the trace.
+const maxTracedBody = 64 << 10
+
+const redacted = "********"
+
+// sensitiveFields are the fields of JSON bodies redacted in the trace, i.e.
+// the plain text of created passwords.
+var sensitiveFields = []string{"password", "plain_text", "token", "secret", "private_key", "certificate"}
+
+// DebugOptions configure the logging of API requests.
+type DebugOptions struct {
+	// Log receives the method and URL, the headers and the timing of each
+	// request, along with the status and rate limits of its response.
+	Log io.Writer
+
+	// Trace receives everything Log does plus all response headers and the
+	// bodies, i.e. for attaching to support tickets.
+	Trace io.Writer
+}
+
+type debugTransport struct {
+	rt   http.RoundTripper
+	opts DebugOptions
+
+	mu sync.Mutex
+}
+
+// Debug returns a RoundTripper logging the requests sent with rt. Secrets in
+// headers and bodies are redacted.
+func Debug(rt http.RoundTripper, opts DebugOptions) http.RoundTripper {
+	return &debugTransport{rt: rt, opts: opts}
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if t.opts.Trace != nil && req.Body != nil && req.Body != http.NoBody {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		reqBody = body
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	start := time.Now()
+	resp, err := t.rt.RoundTrip(req)
+	took := time.Since(start).Round(time.Millisecond)
+
+	var respBody []byte
+	if t.opts.Trace != nil && resp != nil {
+		body, rerr := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if rerr != nil {
+			return nil, rerr
+		}
+
+		respBody = body
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.opts.Log != nil {
+		writeExchange(t.opts.Log, req, nil, resp, nil, took, err, false)
+	}
+	if t.opts.Trace != nil {
+		writeExchange(t.opts.Trace, req, reqBody, resp, respBody, took, err, true)
+	}
+
+	return resp, err
+}
+
+func writeExchange(w io.Writer, req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, took time.Duration, err error, full bool) {
+	if full {
+		fmt.Fprintf(w, "%s ", time.Now().UTC().Format(time.RFC3339Nano))
+	}
+	fmt.Fprintf(w, "--> %s %s\n", req.Method, req.URL)
+	writeHeaders(w, req.Header, nil)
+	if full {
+		writeBody(w, reqBody)
+	}
+
+	if err != nil {
+		fmt.Fprintf(w, "<-- error after %s: %s\n\n", took, err)
+		return
+	}
+
+	fmt.Fprintf(w, "<-- %s (%s)\n", resp.Status, took)
+	if full {
+		writeHeaders(w, resp.Header, nil)
+		writeBody(w, respBody)
+	} else {
+		writeHeaders(w, resp.Header, isDiagnosticHeader)
+	}
+	fmt.Fprintln(w)
+}
+
+// writeHeaders writes the sorted headers for which include returns true,
+// all of them if it's nil.
+func writeHeaders(w io.Writer, h http.Header, include func(name string) bool) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		if include == nil || include(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := strings.Join(h.Values(name), ", ")
+		if isSensitiveHeader(name) {
+			value = redacted
+		}
+		fmt.Fprintf(w, "    %s: %s\n", name, value)
+	}
+}
+
+func writeBody(w io.Writer, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+
+	truncated := len(body) > maxTracedBody
+	if truncated {
+		body = body[:maxTracedBody]
+	}
+
+	fmt.Fprintf(w, "\n%s\n", redactBody(body))
+	if truncated {
+		fmt.Fprintf(w, "[truncated after %d bytes]\n", maxTracedBody)
+	}
+	fmt.Fprintln(w)
+}
+
+// isDiagnosticHeader returns whether the response header is logged without
+// a full trace: the rate limits and the request ID.
+func isDiagnosticHeader(name string) bool {
+	lower := strings.ToLower(name)
+	if strings.Contains(lower, "ratelimit") || strings.Contains(lower, "rate-limit") || lower == "retry-after" {
+		return true
+	}
+
+	for _, h := range requestIDHeaders {
+		if strings.EqualFold(name, h) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSensitiveHeader(name string) bool {
+	lower := strings.ToLower(name)
+	switch lower {
+	case "authorization", "proxy-authorization", "cookie", "set-cookie":
+		return true
+	}
+	return strings.Contains(lower, "token") || strings.Contains(lower, "secret")
+}
+
+// redactBody masks the sensitive fields of JSON bodies. Other bodies are
+// returned as they are.
+func redactBody(body []byte) []byte {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+
+	out, err := json.MarshalIndent(redactValue(v), "", "  ")
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+func redactValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, field := range val {
+			if isSensitiveField(k) {
+				if s, ok := field.(string); ok && s != "" {
+					val[k] = redacted
+				}
+				continue
+			}
+			val[k] = redactValue(field)
+		}
+	case []interface{}:
+		for i := range val {
+			val[i] = redactValue(val[i])
+		}
+	}
+	return v
+}
+
+func isSensitiveField(name string) bool {
+	lower := strings.ToLower(name)
+	for _, f := range sensitiveFields {
+		if strings.Contains(lower, f) {
+			return true
+		}
+	}
+	return false
+}
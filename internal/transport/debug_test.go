@@ -0,0 +1,59 @@
+package transport
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestDebug(t *testing.T) {
+	c := qt.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		c.Assert(string(body), qt.Equals, `{"name":"dev"}`)
+
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.Header().Set("X-Request-Id", "req-123")
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"name":"dev","plain_text":"pscale_pw_secret","nested":[{"access_token":"tok"}]}`)) // nolint: errcheck
+	}))
+	defer srv.Close()
+
+	var log, trace bytes.Buffer
+	client := &http.Client{Transport: Debug(http.DefaultTransport, DebugOptions{Log: &log, Trace: &trace})}
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/v1/branches", strings.NewReader(`{"name":"dev"}`))
+	c.Assert(err, qt.IsNil)
+	req.Header.Set("Authorization", "Bearer pscale_tkn_secret")
+
+	resp, err := client.Do(req)
+	c.Assert(err, qt.IsNil)
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, qt.IsNil)
+	resp.Body.Close()
+
+	// the body is still passed on as it is
+	c.Assert(string(body), qt.Contains, "pscale_pw_secret")
+
+	c.Assert(log.String(), qt.Contains, "--> POST "+srv.URL+"/v1/branches\n")
+	c.Assert(log.String(), qt.Contains, "    Authorization: ********\n")
+	c.Assert(log.String(), qt.Matches, `(?s).*<-- 201 Created \([0-9.]+m?s\)\n.*`)
+	c.Assert(log.String(), qt.Contains, "    X-Ratelimit-Remaining: 42\n    X-Request-Id: req-123\n")
+	c.Assert(log.String(), qt.Not(qt.Contains), "Set-Cookie")
+	c.Assert(log.String(), qt.Not(qt.Contains), `"name"`)
+
+	c.Assert(trace.String(), qt.Contains, "    Set-Cookie: ********\n")
+	c.Assert(trace.String(), qt.Contains, `"name": "dev"`)
+	c.Assert(trace.String(), qt.Contains, `"plain_text": "********"`)
+	c.Assert(trace.String(), qt.Contains, `"access_token": "********"`)
+	for _, secret := range []string{"pscale_tkn_secret", "pscale_pw_secret", "session=abc"} {
+		c.Assert(log.String()+trace.String(), qt.Not(qt.Contains), secret)
+	}
+}
@@ -0,0 +1,116 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Limiter limits the number of concurrent API requests and the number of
+// requests per minute. The RoundTrippers it wraps share its budget, so
+// commands creating many clients, or sending requests from many goroutines,
+// stay within it as a whole.
+type Limiter struct {
+	// slots has a slot for each concurrent request, it's nil if the
+	// concurrency isn't limited.
+	slots chan struct{}
+
+	// interval is the time between the starts of requests, zero if the
+	// rate isn't limited. next is the earliest start of the next request.
+	interval time.Duration
+	mu       sync.Mutex
+	next     time.Time
+
+	// now and sleep are replaced in tests.
+	now   func() time.Time
+	sleep func(context.Context, time.Duration) error
+}
+
+// NewLimiter returns a limiter allowing maxConcurrent requests at a time and
+// perMinute requests per minute, spread evenly over the minute. A zero value
+// doesn't limit the respective budget, and no limiter is returned if neither
+// is limited.
+func NewLimiter(maxConcurrent, perMinute int) *Limiter {
+	if maxConcurrent <= 0 && perMinute <= 0 {
+		return nil
+	}
+
+	l := &Limiter{now: time.Now, sleep: sleep}
+	if maxConcurrent > 0 {
+		l.slots = make(chan struct{}, maxConcurrent)
+	}
+	if perMinute > 0 {
+		l.interval = time.Minute / time.Duration(perMinute)
+	}
+	return l
+}
+
+// Wrap returns a RoundTripper sending the requests with rt within the
+// budget of the limiter.
+func (l *Limiter) Wrap(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		release, err := l.acquire(req.Context())
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			release()
+			return nil, err
+		}
+
+		// the request takes its slot until its body is read
+		resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+		return resp, nil
+	})
+}
+
+// acquire waits for a slot and the next start of a request, and returns the
+// function releasing the slot.
+func (l *Limiter) acquire(ctx context.Context) (func(), error) {
+	release := func() {}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		var once sync.Once
+		release = func() { once.Do(func() { <-l.slots }) }
+	}
+
+	if l.interval > 0 {
+		l.mu.Lock()
+		now := l.now()
+		start := l.next
+		if start.Before(now) {
+			start = now
+		}
+		l.next = start.Add(l.interval)
+		l.mu.Unlock()
+
+		if wait := start.Sub(now); wait > 0 {
+			if err := l.sleep(ctx, wait); err != nil {
+				release()
+				return nil, err
+			}
+		}
+	}
+
+	return release, nil
+}
+
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,88 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestLimiter_Concurrency(t *testing.T) {
+	c := qt.New(t)
+
+	var mu sync.Mutex
+	var current, max int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		current++
+		if current > max {
+			max = current
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		current--
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	l := NewLimiter(2, 0)
+
+	// the clients share the budget of the limiter
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client := &http.Client{Transport: l.Wrap(http.DefaultTransport)}
+			resp, err := client.Get(srv.URL)
+			c.Check(err, qt.IsNil)
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	c.Assert(max, qt.Equals, 2)
+}
+
+func TestLimiter_Rate(t *testing.T) {
+	c := qt.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	var waits []time.Duration
+	l := NewLimiter(0, 120)
+	l.now = func() time.Time { return now }
+	l.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+
+	client := &http.Client{Transport: l.Wrap(http.DefaultTransport)}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(srv.URL)
+		c.Assert(err, qt.IsNil)
+		resp.Body.Close()
+	}
+	c.Assert(waits, qt.DeepEquals, []time.Duration{500 * time.Millisecond, time.Second})
+
+	// the budget isn't saved up while no requests are sent
+	now = now.Add(time.Minute)
+	waits = nil
+	resp, err := client.Get(srv.URL)
+	c.Assert(err, qt.IsNil)
+	resp.Body.Close()
+	c.Assert(waits, qt.IsNil)
+
+	c.Assert(NewLimiter(0, 0), qt.IsNil)
+}
@@ -0,0 +1,88 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+)
+
+type pageKey struct{}
+
+// Page is a page of a list requested from the API, numbered from 1.
+type Page struct {
+	Number int
+	Size   int
+
+	// Info is set to the pagination of the response, if it's not nil.
+	Info *PageInfo
+}
+
+// PageInfo is the pagination metadata of a page returned by the API.
+type PageInfo struct {
+	// Known is set if the response had pagination metadata.
+	Known bool
+	// Next is set if there's a page after it.
+	Next bool
+}
+
+// WithPage returns a context requesting the page of the lists fetched with
+// it. The API client doesn't expose pagination, the page is added to the
+// query of the request instead.
+func WithPage(ctx context.Context, p Page) context.Context {
+	return context.WithValue(ctx, pageKey{}, p)
+}
+
+// PageFromContext returns the page requested with ctx, if any.
+func PageFromContext(ctx context.Context) (Page, bool) {
+	p, ok := ctx.Value(pageKey{}).(Page)
+	return p, ok
+}
+
+// withPageQuery adds the page requested with the context of the request to
+// its query.
+func withPageQuery(req *http.Request) *http.Request {
+	p, ok := PageFromContext(req.Context())
+	if !ok || req.Method != http.MethodGet {
+		return req
+	}
+
+	req = req.Clone(req.Context())
+	q := req.URL.Query()
+	q.Set("page", strconv.Itoa(p.Number))
+	if p.Size > 0 {
+		q.Set("per_page", strconv.Itoa(p.Size))
+	}
+	req.URL.RawQuery = q.Encode()
+	return req
+}
+
+// readPageInfo sets the pagination metadata of the page requested with the
+// context of the request. The body of the response is read for it and
+// replaced by a copy.
+func readPageInfo(req *http.Request, resp *http.Response) error {
+	p, ok := PageFromContext(req.Context())
+	if !ok || p.Info == nil || resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var list struct {
+		NextPage json.RawMessage `json:"next_page"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil || list.NextPage == nil {
+		return nil
+	}
+
+	p.Info.Known = true
+	p.Info.Next = string(list.NextPage) != "null"
+	return nil
+}
@@ -0,0 +1,74 @@
+package transport
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestRoundTrip_Page(t *testing.T) {
+	c := qt.New(t)
+
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: New(http.DefaultTransport, Options{})}
+
+	ctx := WithPage(context.Background(), Page{Number: 3, Size: 50})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/v1/organizations?q=x", nil)
+	c.Assert(err, qt.IsNil)
+	resp, err := client.Do(req)
+	c.Assert(err, qt.IsNil)
+	resp.Body.Close()
+	c.Assert(query, qt.Equals, "page=3&per_page=50&q=x")
+
+	req, err = http.NewRequest(http.MethodGet, srv.URL, nil)
+	c.Assert(err, qt.IsNil)
+	resp, err = client.Do(req)
+	c.Assert(err, qt.IsNil)
+	resp.Body.Close()
+	c.Assert(query, qt.Equals, "")
+}
+
+func TestRoundTrip_PageInfo(t *testing.T) {
+	c := qt.New(t)
+
+	body := `{"type":"list","next_page":2,"data":[]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body)) // nolint:errcheck
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: New(http.DefaultTransport, Options{})}
+
+	get := func() *PageInfo {
+		info := &PageInfo{}
+		ctx := WithPage(context.Background(), Page{Number: 1, Size: 10, Info: info})
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		c.Assert(err, qt.IsNil)
+		resp, err := client.Do(req)
+		c.Assert(err, qt.IsNil)
+		defer resp.Body.Close()
+
+		// the body can still be read
+		out, err := ioutil.ReadAll(resp.Body)
+		c.Assert(err, qt.IsNil)
+		c.Assert(string(out), qt.Equals, body)
+		return info
+	}
+
+	c.Assert(get(), qt.DeepEquals, &PageInfo{Known: true, Next: true})
+
+	body = `{"type":"list","next_page":null,"data":[]}`
+	c.Assert(get(), qt.DeepEquals, &PageInfo{Known: true})
+
+	body = `{"data":[]}`
+	c.Assert(get(), qt.DeepEquals, &PageInfo{})
+}
@@ -0,0 +1,294 @@
+// Package transport provides the HTTP transport of the PlanetScale API
+// client, which applies the configured timeouts and retries.
+package transport
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTraceHeader is the header a correlation ID is sent with if no
+// header name is given.
+const DefaultTraceHeader = "X-Correlation-Id"
+
+// requestIDHeaders are the response headers the API request ID is read from.
+var requestIDHeaders = []string{"X-Request-Id", "X-Trace-Id"}
+
+var (
+	mu            sync.Mutex
+	lastRequestID string
+
+	// rnd randomizes the backoff, guarded by mu.
+	rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// LastRequestID returns the request ID of the last failed API request, so it
+// can be included in error messages.
+func LastRequestID() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return lastRequestID
+}
+
+// recordRequestID remembers the request ID of a failed response. Successful
+// responses reset it, so a request that succeeded after a retry isn't
+// reported.
+func recordRequestID(resp *http.Response) {
+	var id string
+	if resp.StatusCode >= http.StatusBadRequest {
+		for _, h := range requestIDHeaders {
+			if id = resp.Header.Get(h); id != "" {
+				break
+			}
+		}
+	}
+
+	mu.Lock()
+	lastRequestID = id
+	mu.Unlock()
+}
+
+// ParseTraceHeader parses a "Name: value" header. A plain value is sent with
+// DefaultTraceHeader.
+func ParseTraceHeader(s string) (name, value string) {
+	if i := strings.Index(s, ":"); i > 0 {
+		return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+	}
+	return DefaultTraceHeader, strings.TrimSpace(s)
+}
+
+// Trace returns a RoundTripper sending the "Name: value" header or
+// correlation ID with all requests sent with rt.
+func Trace(rt http.RoundTripper, header string) http.RoundTripper {
+	name, value := ParseTraceHeader(header)
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.Header.Set(name, value)
+		return rt.RoundTrip(req)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Options configure the timeouts and retries of API requests. A zero value
+// disables the respective behavior.
+type Options struct {
+	// ReadTimeout is the timeout of requests that don't modify resources.
+	ReadTimeout time.Duration
+	// MutateTimeout is the timeout of requests that modify resources.
+	MutateTimeout time.Duration
+
+	// MaxRetries is the number of times a failed request is retried.
+	MaxRetries int
+	// Backoff is the wait before the first retry, it doubles with each
+	// retry and is randomized by up to half, so concurrent clients don't
+	// retry in lockstep.
+	Backoff time.Duration
+	// MaxBackoff caps the wait between retries. Responses asking to retry
+	// after a longer time with Retry-After aren't retried.
+	MaxBackoff time.Duration
+
+	// Observe is called with every response, i.e. to inspect headers.
+	Observe func(*http.Response)
+}
+
+type transport struct {
+	rt   http.RoundTripper
+	opts Options
+
+	// sleep and jitter are replaced in tests.
+	sleep  func(context.Context, time.Duration) error
+	jitter func(time.Duration) time.Duration
+}
+
+// New returns a RoundTripper applying the options to requests sent with rt.
+func New(rt http.RoundTripper, opts Options) http.RoundTripper {
+	return &transport{rt: rt, opts: opts, sleep: sleep, jitter: jitter}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = withPageQuery(req)
+
+	read := isRead(req.Method)
+	timeout := t.opts.MutateTimeout
+	if read {
+		timeout = t.opts.ReadTimeout
+	}
+
+	backoff := t.opts.Backoff
+	for attempt := 0; ; attempt++ {
+		r := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+
+		resp, err := t.roundTrip(r, timeout)
+		if resp != nil {
+			recordRequestID(resp)
+			if t.opts.Observe != nil {
+				t.opts.Observe(resp)
+			}
+		}
+
+		if attempt >= t.opts.MaxRetries || !retryable(req, read, resp, err) {
+			if err == nil {
+				if err := readPageInfo(req, resp); err != nil {
+					return nil, err
+				}
+			}
+			return resp, err
+		}
+
+		wait := t.jitter(backoff)
+		if t.opts.MaxBackoff > 0 && wait > t.opts.MaxBackoff {
+			wait = t.opts.MaxBackoff
+		}
+		if after, ok := retryAfter(resp, time.Now()); ok {
+			if t.opts.MaxBackoff > 0 && after > t.opts.MaxBackoff {
+				return resp, err
+			}
+			wait = after
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body) // nolint:errcheck
+			resp.Body.Close()
+		}
+
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
+
+func (t *transport) roundTrip(req *http.Request, timeout time.Duration) (*http.Response, error) {
+	if timeout <= 0 {
+		return t.rt.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err := t.rt.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// the timeout applies until the body is read
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// retryable returns whether the request can be retried. Requests that don't
+// modify resources are retried on network errors and server errors, other
+// requests only if the API rejected them because of rate limiting.
+func retryable(req *http.Request, read bool, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	if err != nil {
+		return read
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return read
+	}
+
+	return false
+}
+
+// retryAfter returns the wait the response asks for with Retry-After, given
+// in seconds or as an HTTP date.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	v := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(v); err == nil {
+		d := at.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+
+	return 0, false
+}
+
+// jitter returns a random wait between half of d and d.
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+
+	mu.Lock()
+	defer mu.Unlock()
+	return half + time.Duration(rnd.Int63n(int64(d-half)+1))
+}
+
+func isRead(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,208 @@
+package transport
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestRoundTrip_Retries(t *testing.T) {
+	c := qt.New(t)
+
+	tests := []struct {
+		name     string
+		method   string
+		statuses []int
+		want     int
+		attempts int
+	}{
+		{"read retried on server error", http.MethodGet, []int{503, 502, 200}, 200, 3},
+		{"read retried on internal error", http.MethodGet, []int{500, 200}, 200, 2},
+		{"read gives up after max retries", http.MethodGet, []int{503, 503, 503, 503}, 503, 3},
+		{"mutation not retried on server error", http.MethodPost, []int{503, 200}, 503, 1},
+		{"mutation retried when rate limited", http.MethodPost, []int{429, 201}, 201, 2},
+		{"client errors not retried", http.MethodGet, []int{404, 200}, 404, 1},
+	}
+
+	for _, tt := range tests {
+		c.Run(tt.name, func(c *qt.C) {
+			var attempts int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost {
+					body, _ := ioutil.ReadAll(r.Body)
+					c.Assert(string(body), qt.Equals, `{"name":"dev"}`)
+				}
+
+				w.WriteHeader(tt.statuses[attempts])
+				attempts++
+			}))
+			defer srv.Close()
+
+			var backoffs []time.Duration
+			rt := New(http.DefaultTransport, Options{MaxRetries: 2, Backoff: time.Second}).(*transport)
+			rt.sleep = func(ctx context.Context, d time.Duration) error {
+				backoffs = append(backoffs, d)
+				return nil
+			}
+			rt.jitter = func(d time.Duration) time.Duration { return d }
+
+			req, err := http.NewRequest(tt.method, srv.URL, strings.NewReader(`{"name":"dev"}`))
+			c.Assert(err, qt.IsNil)
+
+			resp, err := (&http.Client{Transport: rt}).Do(req)
+			c.Assert(err, qt.IsNil)
+			resp.Body.Close()
+
+			c.Assert(resp.StatusCode, qt.Equals, tt.want)
+			c.Assert(attempts, qt.Equals, tt.attempts)
+			if attempts == 3 {
+				c.Assert(backoffs, qt.DeepEquals, []time.Duration{time.Second, 2 * time.Second})
+			}
+		})
+	}
+}
+
+func TestRoundTrip_RetryAfter(t *testing.T) {
+	c := qt.New(t)
+
+	tests := []struct {
+		name       string
+		retryAfter string
+		want       int
+		backoffs   []time.Duration
+	}{
+		{"seconds", "3", 200, []time.Duration{3 * time.Second}},
+		{"longer than the max backoff", "120", 429, nil},
+		{"invalid", "soon", 200, []time.Duration{time.Second}},
+	}
+
+	for _, tt := range tests {
+		c.Run(tt.name, func(c *qt.C) {
+			var attempts int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				if attempts == 1 {
+					w.Header().Set("Retry-After", tt.retryAfter)
+					w.WriteHeader(http.StatusTooManyRequests)
+				}
+			}))
+			defer srv.Close()
+
+			var backoffs []time.Duration
+			rt := New(http.DefaultTransport, Options{MaxRetries: 2, Backoff: time.Second, MaxBackoff: time.Minute}).(*transport)
+			rt.sleep = func(ctx context.Context, d time.Duration) error {
+				backoffs = append(backoffs, d)
+				return nil
+			}
+			rt.jitter = func(d time.Duration) time.Duration { return d }
+
+			resp, err := (&http.Client{Transport: rt}).Get(srv.URL)
+			c.Assert(err, qt.IsNil)
+			resp.Body.Close()
+
+			c.Assert(resp.StatusCode, qt.Equals, tt.want)
+			c.Assert(backoffs, qt.DeepEquals, tt.backoffs)
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	c := qt.New(t)
+
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	resp := &http.Response{Header: http.Header{}}
+
+	resp.Header.Set("Retry-After", now.Add(10*time.Second).Format(http.TimeFormat))
+	d, ok := retryAfter(resp, now)
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(d, qt.Equals, 10*time.Second)
+
+	resp.Header.Set("Retry-After", now.Add(-time.Minute).Format(http.TimeFormat))
+	d, ok = retryAfter(resp, now)
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(d, qt.Equals, time.Duration(0))
+
+	resp.Header.Del("Retry-After")
+	_, ok = retryAfter(resp, now)
+	c.Assert(ok, qt.IsFalse)
+}
+
+func TestJitter(t *testing.T) {
+	c := qt.New(t)
+
+	for i := 0; i < 100; i++ {
+		d := jitter(time.Second)
+		c.Assert(d >= 500*time.Millisecond && d <= time.Second, qt.IsTrue, qt.Commentf("got %s", d))
+	}
+}
+
+func TestRoundTrip_Timeout(t *testing.T) {
+	c := qt.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.Write([]byte("ok")) // nolint:errcheck
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: New(http.DefaultTransport, Options{
+		ReadTimeout:   10 * time.Millisecond,
+		MutateTimeout: time.Second,
+	})}
+
+	_, err := client.Get(srv.URL)
+	c.Assert(err, qt.ErrorMatches, ".*context deadline exceeded.*")
+
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader(""))
+	c.Assert(err, qt.IsNil)
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(body), qt.Equals, "ok")
+}
+
+func TestRoundTrip_TraceHeader(t *testing.T) {
+	c := qt.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Header.Get("X-Source"), qt.Equals, "ci-1234")
+		w.Header().Set("X-Request-Id", "req-"+r.URL.Path[1:])
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: New(Trace(http.DefaultTransport, "X-Source: ci-1234"), Options{})}
+
+	resp, err := client.Get(srv.URL + "/missing")
+	c.Assert(err, qt.IsNil)
+	resp.Body.Close()
+	c.Assert(LastRequestID(), qt.Equals, "req-missing")
+
+	resp, err = client.Get(srv.URL + "/found")
+	c.Assert(err, qt.IsNil)
+	resp.Body.Close()
+	c.Assert(LastRequestID(), qt.Equals, "")
+}
+
+func TestParseTraceHeader(t *testing.T) {
+	c := qt.New(t)
+
+	name, value := ParseTraceHeader("ci-1234")
+	c.Assert(name, qt.Equals, DefaultTraceHeader)
+	c.Assert(value, qt.Equals, "ci-1234")
+
+	name, value = ParseTraceHeader("X-Source: ci-1234")
+	c.Assert(name, qt.Equals, "X-Source")
+	c.Assert(value, qt.Equals, "ci-1234")
+}
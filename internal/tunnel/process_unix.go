@@ -0,0 +1,25 @@
+//go:build !windows
+// +build !windows
+
+package tunnel
+
+import (
+	"os"
+	"syscall"
+)
+
+// Detached starts a process in its own session, so background processes
+// like tunnels keep running when the terminal they were started from is
+// closed.
+func Detached() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
+
+func running(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+func interrupt(p *os.Process) error {
+	return p.Signal(os.Interrupt)
+}
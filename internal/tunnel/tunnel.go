@@ -0,0 +1,296 @@
+// Package tunnel keeps the state of the tunnels pscale connect runs in the
+// background, so they can be listed and stopped later on.
+package tunnel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/config"
+
+	nanoid "github.com/matoous/go-nanoid/v2"
+)
+
+const (
+	tunnelsDir = "tunnels"
+	stateExt   = ".json"
+	logExt     = ".log"
+
+	idAlphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+	idLength   = 6
+)
+
+// EnvID is the environment variable passing the ID of a background tunnel
+// to its process.
+const EnvID = "PSCALE_TUNNEL_ID"
+
+// ErrNotFound is returned for tunnels that aren't running.
+var ErrNotFound = errors.New("tunnel not found")
+
+// Tunnel is a tunnel running in the background.
+type Tunnel struct {
+	ID        string    `json:"id"`
+	PID       int       `json:"pid"`
+	Org       string    `json:"org"`
+	Database  string    `json:"database"`
+	Branch    string    `json:"branch"`
+	Addrs     []string  `json:"addrs"`
+	StartedAt time.Time `json:"started_at"`
+
+	// Stats is the traffic of the tunnel, updated while it's running.
+	Stats *Stats `json:"stats,omitempty"`
+}
+
+// Stats is the traffic through a tunnel. Bytes in are received from the
+// database, bytes out are sent to it.
+type Stats struct {
+	BytesIn           int64        `json:"bytes_in"`
+	BytesOut          int64        `json:"bytes_out"`
+	ActiveConnections int          `json:"active_connections"`
+	TotalConnections  int          `json:"total_connections"`
+	Connections       []*ConnStats `json:"connections,omitempty"`
+	UpdatedAt         time.Time    `json:"updated_at"`
+}
+
+// ConnStats is the traffic of a connection open through a tunnel.
+type ConnStats struct {
+	ID        uint64    `json:"id"`
+	StartedAt time.Time `json:"started_at"`
+	BytesIn   int64     `json:"bytes_in"`
+	BytesOut  int64     `json:"bytes_out"`
+}
+
+// Dir returns the directory of the state files and logs of the tunnels.
+func Dir() (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(dir, tunnelsDir), nil
+}
+
+// NewID returns a new, random ID for a tunnel.
+func NewID() string {
+	return nanoid.MustGenerate(idAlphabet, idLength)
+}
+
+// LogPath returns the path of the log of the tunnel with the given ID.
+func LogPath(id string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, id+logExt), nil
+}
+
+func statePath(id string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, id+stateExt), nil
+}
+
+// Start runs pscale with the given arguments in the background as the
+// tunnel with the given ID. Its output is written to the log of the tunnel.
+func Start(id string, args []string) (*os.Process, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("can't find the pscale executable: %s", err)
+	}
+
+	logPath, err := LogPath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(logPath), 0771); err != nil {
+		return nil, fmt.Errorf("error creating tunnels directory: %s", err)
+	}
+
+	log, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer log.Close()
+
+	cmd := exec.Command(exe, args...)
+	cmd.Env = append(os.Environ(), EnvID+"="+id)
+	cmd.Stdout = log
+	cmd.Stderr = log
+	cmd.SysProcAttr = Detached()
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("can't start the tunnel: %s", err)
+	}
+
+	return cmd.Process, nil
+}
+
+// Save writes the state of the tunnel.
+func Save(t *Tunnel) error {
+	p, err := statePath(t.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0771); err != nil {
+		return fmt.Errorf("error creating tunnels directory: %s", err)
+	}
+
+	out, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// the state is written by the tunnel and read by other processes, so
+	// it's replaced atomically
+	tmp := p + ".tmp"
+	if err := ioutil.WriteFile(tmp, out, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+// Remove removes the state and the log of the tunnel with the given ID.
+func Remove(id string) error {
+	p, err := statePath(id)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	logPath, err := LogPath(id)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(logPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Get returns the running tunnel with the given ID.
+func Get(id string) (*Tunnel, error) {
+	p, err := statePath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := read(p)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !running(t.PID) {
+		_ = Remove(t.ID)
+		return nil, ErrNotFound
+	}
+
+	return t, nil
+}
+
+// List returns the running tunnels, from the oldest to the newest. The
+// state of tunnels whose process exited without cleaning up is removed.
+func List() ([]*Tunnel, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tunnels []*Tunnel
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), stateExt) {
+			continue
+		}
+
+		t, err := read(filepath.Join(dir, f.Name()))
+		if err != nil {
+			continue // being written or removed
+		}
+
+		if !running(t.PID) {
+			_ = Remove(t.ID)
+			continue
+		}
+
+		tunnels = append(tunnels, t)
+	}
+
+	sort.Slice(tunnels, func(i, j int) bool {
+		return tunnels[i].StartedAt.Before(tunnels[j].StartedAt)
+	})
+
+	return tunnels, nil
+}
+
+// Stop asks the tunnel to shut down and waits up to timeout for its
+// process to exit. The tunnel is killed if it doesn't.
+func (t *Tunnel) Stop(timeout time.Duration) error {
+	p, err := os.FindProcess(t.PID)
+	if err != nil {
+		return err
+	}
+
+	if err := interrupt(p); err != nil {
+		if !running(t.PID) {
+			return Remove(t.ID)
+		}
+		return fmt.Errorf("can't stop tunnel %s: %s", t.ID, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for running(t.PID) {
+		if time.Now().After(deadline) {
+			if err := p.Kill(); err != nil {
+				return fmt.Errorf("can't stop tunnel %s: %s", t.ID, err)
+			}
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// the tunnel removes its state when shutting down gracefully
+	return Remove(t.ID)
+}
+
+func read(p string) (*Tunnel, error) {
+	data, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Tunnel{}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("invalid tunnel state %s: %s", p, err)
+	}
+
+	return t, nil
+}
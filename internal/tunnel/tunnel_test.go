@@ -0,0 +1,71 @@
+package tunnel
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestTunnel_State(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	tunnels, err := List()
+	c.Assert(err, qt.IsNil)
+	c.Assert(tunnels, qt.HasLen, 0)
+
+	// a process which exited without removing its state
+	exited := exec.Command(os.Args[0], "-test.run=^$")
+	c.Assert(exited.Run(), qt.IsNil)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	running := &Tunnel{
+		ID:        NewID(),
+		PID:       os.Getpid(),
+		Org:       "org",
+		Database:  "db",
+		Branch:    "main",
+		Addrs:     []string{"127.0.0.1:3306"},
+		StartedAt: now,
+	}
+	newer := &Tunnel{
+		ID:        NewID(),
+		PID:       os.Getpid(),
+		Org:       "org",
+		Database:  "db",
+		Branch:    "dev",
+		Addrs:     []string{"unix:///tmp/dev.sock"},
+		StartedAt: now.Add(time.Minute),
+	}
+	stale := &Tunnel{
+		ID:        NewID(),
+		PID:       exited.Process.Pid,
+		Org:       "org",
+		Database:  "db",
+		Branch:    "old",
+		StartedAt: now.Add(-time.Hour),
+	}
+	for _, tt := range []*Tunnel{newer, stale, running} {
+		c.Assert(Save(tt), qt.IsNil)
+	}
+
+	tunnels, err = List()
+	c.Assert(err, qt.IsNil)
+	c.Assert(tunnels, qt.DeepEquals, []*Tunnel{running, newer})
+
+	_, err = Get(stale.ID)
+	c.Assert(err, qt.Equals, ErrNotFound)
+
+	got, err := Get(newer.ID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(got, qt.DeepEquals, newer)
+
+	c.Assert(Remove(newer.ID), qt.IsNil)
+	_, err = Get(newer.ID)
+	c.Assert(err, qt.Equals, ErrNotFound)
+}
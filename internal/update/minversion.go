@@ -0,0 +1,160 @@
+package update
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/planetscale/cli/internal/config"
+	"gopkg.in/yaml.v2"
+)
+
+// MinVersionHeader is the response header the API advertises the minimum
+// supported CLI version with.
+const MinVersionHeader = "X-Pscale-Min-Version"
+
+const (
+	// minVersionRecheck is how often the advertised minimum version is
+	// persisted again if it didn't change. Commands aren't blocked by a
+	// state older than that, so their API responses recheck it.
+	minVersionRecheck = 24 * time.Hour
+
+	// minVersionGrace is how long unsupported versions are warned about
+	// before commands are blocked.
+	minVersionGrace = 7 * 24 * time.Hour
+
+	// minVersionExpiry is the age after which the state is ignored, i.e. if
+	// the API stopped advertising a minimum version.
+	minVersionExpiry = 7 * 24 * time.Hour
+)
+
+// MinVersionState stores the minimum CLI version last advertised by the API.
+type MinVersionState struct {
+	MinVersion string    `yaml:"min_version"`
+	CheckedAt  time.Time `yaml:"checked_at"`
+
+	// Since is when the API started advertising the minimum version.
+	Since time.Time `yaml:"since,omitempty"`
+}
+
+// RecordMinVersion persists the minimum version advertised in the API
+// response. It's cheap to call for every response, as the state is only
+// written once a day or when the advertised version changes.
+func RecordMinVersion(resp *http.Response) {
+	minVersion := resp.Header.Get(MinVersionHeader)
+	if minVersion == "" {
+		return
+	}
+
+	path, err := minVersionStatePath()
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	since := now
+	state, _ := getMinVersionState(path)
+	if state != nil && state.MinVersion == minVersion {
+		if now.Sub(state.CheckedAt) < minVersionRecheck {
+			return
+		}
+		if !state.Since.IsZero() {
+			since = state.Since
+		}
+	}
+
+	_ = setMinVersionState(path, &MinVersionState{MinVersion: minVersion, CheckedAt: now, Since: since})
+}
+
+// CheckMinVersion checks the build version against the minimum version the
+// API last advertised. Unsupported versions are warned about on w for a
+// grace period, then an error with an upgrade hint is returned. Commands
+// still run once a day, so a lowered minimum version is noticed. Setting
+// PSCALE_SKIP_VERSION_CHECK skips the check.
+func CheckMinVersion(w io.Writer, buildVersion string) error {
+	if _, exists := os.LookupEnv("PSCALE_SKIP_VERSION_CHECK"); exists || buildVersion == "" {
+		return nil
+	}
+
+	path, err := minVersionStatePath()
+	if err != nil {
+		return nil
+	}
+
+	return checkMinVersion(w, buildVersion, path, time.Now())
+}
+
+func checkMinVersion(w io.Writer, buildVersion, path string, now time.Time) error {
+	state, err := getMinVersionState(path)
+	if err != nil || state.MinVersion == "" || now.Sub(state.CheckedAt) > minVersionExpiry {
+		return nil
+	}
+
+	minVersion, err := version.NewVersion(state.MinVersion)
+	if err != nil {
+		return nil
+	}
+
+	current, err := version.NewVersion(buildVersion)
+	if err != nil {
+		return nil
+	}
+
+	if !current.LessThan(minVersion) {
+		return nil
+	}
+
+	since := state.Since
+	if since.IsZero() {
+		since = state.CheckedAt
+	}
+	if now.Sub(since) < minVersionGrace || now.Sub(state.CheckedAt) > minVersionRecheck {
+		fmt.Fprintf(w, "Warning: pscale %s is no longer supported by the PlanetScale API, the minimum supported version is %s.\n"+
+			"Please upgrade: https://github.com/planetscale/cli/releases/latest\n\n", buildVersion, state.MinVersion)
+		return nil
+	}
+
+	return fmt.Errorf("pscale %s is no longer supported by the PlanetScale API, the minimum supported version is %s.\n"+
+		"Please upgrade: https://github.com/planetscale/cli/releases/latest (set PSCALE_SKIP_VERSION_CHECK to skip this check)",
+		buildVersion, state.MinVersion)
+}
+
+func getMinVersionState(path string) (*MinVersionState, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var state MinVersionState
+	if err := yaml.Unmarshal(content, &state); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+func setMinVersionState(path string, state *MinVersionState) error {
+	content, err := yaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0771); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, content, 0600)
+}
+
+func minVersionStatePath() (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "min-version.yml"), nil
+}
@@ -0,0 +1,81 @@
+package update
+
+import (
+	"bytes"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/planetscale/cli/internal/testutil"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestCheckMinVersion(t *testing.T) {
+	c := qt.New(t)
+
+	path := filepath.Join(c.Mkdir(), "min-version.yml")
+	now := time.Now()
+	var buf bytes.Buffer
+
+	// no state yet
+	c.Assert(checkMinVersion(&buf, "v0.50.0", path, now), qt.IsNil)
+
+	// unsupported versions are warned about first
+	err := setMinVersionState(path, &MinVersionState{MinVersion: "v0.60.0", CheckedAt: now, Since: now.Add(-time.Hour)})
+	c.Assert(err, qt.IsNil)
+
+	c.Assert(checkMinVersion(&buf, "v0.60.0", path, now), qt.IsNil)
+	c.Assert(checkMinVersion(&buf, "v0.61.2", path, now), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "")
+	c.Assert(checkMinVersion(&buf, "v0.50.0", path, now), qt.IsNil)
+	c.Assert(buf.String(), qt.Matches, `(?s)Warning: pscale v0.50.0 is no longer supported by the PlanetScale API.*`)
+
+	// and blocked after the grace period
+	err = setMinVersionState(path, &MinVersionState{MinVersion: "v0.60.0", CheckedAt: now, Since: now.Add(-minVersionGrace)})
+	c.Assert(err, qt.IsNil)
+	c.Assert(checkMinVersion(&buf, "v0.50.0", path, now), qt.ErrorMatches,
+		`(?s)pscale v0.50.0 is no longer supported by the PlanetScale API, the minimum supported version is v0.60.0.*`)
+
+	// a day later, commands run again so their responses recheck the
+	// minimum version
+	buf.Reset()
+	c.Assert(checkMinVersion(&buf, "v0.50.0", path, now.Add(minVersionRecheck+time.Minute)), qt.IsNil)
+	c.Assert(buf.String(), qt.Not(qt.Equals), "")
+
+	// and the state expires if it isn't refreshed
+	buf.Reset()
+	c.Assert(checkMinVersion(&buf, "v0.50.0", path, now.Add(minVersionExpiry+time.Minute)), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "")
+}
+
+func TestRecordMinVersion(t *testing.T) {
+	c := qt.New(t)
+	testutil.TempHome(t)
+
+	path, err := minVersionStatePath()
+	c.Assert(err, qt.IsNil)
+
+	RecordMinVersion(&http.Response{Header: http.Header{}})
+	_, err = getMinVersionState(path)
+	c.Assert(err, qt.Not(qt.IsNil))
+
+	RecordMinVersion(&http.Response{Header: http.Header{MinVersionHeader: []string{"v0.60.0"}}})
+	state, err := getMinVersionState(path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(state.MinVersion, qt.Equals, "v0.60.0")
+
+	c.Assert(state.Since.IsZero(), qt.IsFalse)
+
+	// the time the minimum version was first advertised is kept
+	since := state.Since.Add(-time.Hour)
+	state.Since, state.CheckedAt = since, state.CheckedAt.Add(-minVersionRecheck)
+	c.Assert(setMinVersionState(path, state), qt.IsNil)
+
+	RecordMinVersion(&http.Response{Header: http.Header{MinVersionHeader: []string{"v0.60.0"}}})
+	state, err = getMinVersionState(path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(state.Since.Equal(since), qt.IsTrue)
+	c.Assert(time.Since(state.CheckedAt) < time.Minute, qt.IsTrue)
+}
@@ -0,0 +1,390 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/planetscale/cli/internal/config"
+	"github.com/planetscale/cli/internal/download"
+)
+
+// DefaultReleaseURL is the base URL release assets are downloaded from.
+const DefaultReleaseURL = "https://github.com/planetscale/cli/releases/download"
+
+// executable returns the path of the running binary.
+var executable = os.Executable
+
+// Verification is the result of verifying the running binary against the
+// assets of its release.
+type Verification struct {
+	Binary    string `json:"binary"`
+	SHA256    string `json:"sha256"`
+	Archive   string `json:"archive"`
+	Checksums string `json:"checksums"`
+
+	// Signature describes how the checksums file was authenticated, it's
+	// empty if no public key was given.
+	Signature string `json:"signature,omitempty"`
+}
+
+// VerifyOptions configure VerifyBinary.
+type VerifyOptions struct {
+	// Version is the release the binary is verified against.
+	Version string
+
+	// PublicKey the checksums of the release must be signed with.
+	PublicKey []byte
+
+	// ReleaseURL replaces DefaultReleaseURL, i.e. for mirrors.
+	ReleaseURL string
+
+	// Transport is used to download the release assets.
+	Transport http.RoundTripper
+
+	// DownloadDir is the directory the archive is downloaded to, instead of
+	// the downloads directory of the config.
+	DownloadDir string
+}
+
+// VerifyBinary verifies that the running binary is the one published for the
+// given release. The checksums of a release cover the archives, hence the
+// archive of the current platform is downloaded, checked against the
+// checksums and the binary in it compared with the running one.
+//
+// If a public key is set, the checksums file must carry a valid signature of
+// that key. Both cosign (PEM encoded ECDSA keys) and minisign keys are
+// supported.
+func VerifyBinary(ctx context.Context, opts VerifyOptions) (*Verification, error) {
+	if opts.Version == "" {
+		return nil, errors.New("can't verify a binary built from source")
+	}
+
+	bin, err := executable()
+	if err != nil {
+		return nil, fmt.Errorf("can't find the running binary: %s", err)
+	}
+
+	binary, err := ioutil.ReadFile(bin)
+	if err != nil {
+		return nil, err
+	}
+
+	releaseURL := DefaultReleaseURL
+	if opts.ReleaseURL != "" {
+		releaseURL = strings.TrimSuffix(opts.ReleaseURL, "/")
+	}
+
+	client := &http.Client{Transport: opts.Transport, Timeout: time.Minute}
+	ver := strings.TrimPrefix(opts.Version, "v")
+	base := fmt.Sprintf("%s/v%s", releaseURL, ver)
+	v := &Verification{
+		Binary:    bin,
+		SHA256:    sha256Hex(binary),
+		Archive:   archiveName(ver, runtime.GOOS, runtime.GOARCH),
+		Checksums: fmt.Sprintf("pscale_%s_checksums.txt", ver),
+	}
+
+	checksums, err := fetch(ctx, client, base+"/"+v.Checksums)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts.PublicKey) != 0 {
+		v.Signature, err = verifySignature(ctx, client, base+"/"+v.Checksums, checksums, opts.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	want, err := lookupChecksum(checksums, v.Archive)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := downloadArchive(ctx, client, base+"/"+v.Archive, opts.DownloadDir, want)
+	if err != nil {
+		return nil, err
+	}
+
+	published, err := extractBinary(v.Archive, archive)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(published, binary) {
+		return nil, fmt.Errorf("binary %s (sha256 %s) doesn't match the binary published in %s (sha256 %s)",
+			v.Binary, v.SHA256, v.Archive, sha256Hex(published))
+	}
+
+	return v, nil
+}
+
+// archiveName returns the name of the release archive of the given platform,
+// following the naming of .goreleaser.yml.
+func archiveName(ver, goos, goarch string) string {
+	ext := "tar.gz"
+	if goos == "windows" {
+		ext = "zip"
+	}
+
+	if goos == "darwin" {
+		goos = "macOS"
+	}
+
+	return fmt.Sprintf("pscale_%s_%s_%s.%s", ver, goos, goarch, ext)
+}
+
+// lookupChecksum returns the checksum of the given file from a checksums
+// file in the format of sha256sum.
+func lookupChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == name {
+			return fields[0], nil
+		}
+	}
+
+	return "", fmt.Errorf("no checksum published for %s", name)
+}
+
+// extractBinary returns the pscale binary from the given release archive.
+func extractBinary(name string, archive []byte) ([]byte, error) {
+	if strings.HasSuffix(name, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range zr.File {
+			if path.Base(f.Name) != "pscale.exe" {
+				continue
+			}
+
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+
+			return ioutil.ReadAll(rc)
+		}
+
+		return nil, fmt.Errorf("no pscale binary in %s", name)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no pscale binary in %s", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if hdr.Typeflag == tar.TypeReg && path.Base(hdr.Name) == "pscale" {
+			return ioutil.ReadAll(tr)
+		}
+	}
+}
+
+// verifySignature verifies the signature of the file at the given URL with
+// the public key and returns a description of the verified signature.
+func verifySignature(ctx context.Context, client *http.Client, url string, content, publicKey []byte) (string, error) {
+	if strings.HasPrefix(string(publicKey), "untrusted comment:") {
+		sig, err := fetch(ctx, client, url+".minisig")
+		if err != nil {
+			return "", err
+		}
+
+		keyID, err := verifyMinisign(content, sig, publicKey)
+		if err != nil {
+			return "", fmt.Errorf("invalid minisign signature of %s: %s", path.Base(url), err)
+		}
+
+		return fmt.Sprintf("minisign (key ID %s)", keyID), nil
+	}
+
+	sig, err := fetch(ctx, client, url+".sig")
+	if err != nil {
+		return "", err
+	}
+
+	if err := verifyCosign(content, sig, publicKey); err != nil {
+		return "", fmt.Errorf("invalid cosign signature of %s: %s", path.Base(url), err)
+	}
+
+	return "cosign", nil
+}
+
+// verifyCosign verifies a base64 encoded signature created with 'cosign
+// sign-blob' for a PEM encoded ECDSA public key.
+func verifyCosign(content, sig, publicKey []byte) error {
+	block, _ := pem.Decode(publicKey)
+	if block == nil {
+		return errors.New("public key is not PEM encoded")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return err
+	}
+
+	key, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.New("public key is not an ECDSA key")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(content)
+	if !ecdsa.VerifyASN1(key, digest[:], raw) {
+		return errors.New("signature doesn't match")
+	}
+
+	return nil
+}
+
+// verifyMinisign verifies a minisign signature and returns the ID of the key
+// it was created with. Only non-prehashed (legacy) signatures are supported,
+// as prehashing relies on BLAKE2b.
+func verifyMinisign(content, sig, publicKey []byte) (string, error) {
+	keyLines := lines(publicKey)
+	if len(keyLines) < 2 {
+		return "", errors.New("malformed public key")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(keyLines[1])
+	if err != nil || len(key) != 2+8+ed25519.PublicKeySize || string(key[:2]) != "Ed" {
+		return "", errors.New("malformed public key")
+	}
+
+	sigLines := lines(sig)
+	if len(sigLines) < 4 {
+		return "", errors.New("malformed signature")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(sigLines[1])
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return "", errors.New("malformed signature")
+	}
+
+	switch string(raw[:2]) {
+	case "Ed":
+	case "ED":
+		return "", errors.New("prehashed signatures are not supported")
+	default:
+		return "", errors.New("malformed signature")
+	}
+
+	if !bytes.Equal(raw[2:10], key[2:10]) {
+		return "", errors.New("signature was created with a different key")
+	}
+
+	pub := ed25519.PublicKey(key[10:])
+	if !ed25519.Verify(pub, content, raw[10:]) {
+		return "", errors.New("signature doesn't match")
+	}
+
+	// the global signature covers the signature and the trusted comment
+	trusted := strings.TrimPrefix(sigLines[2], "trusted comment: ")
+	global, err := base64.StdEncoding.DecodeString(sigLines[3])
+	if err != nil {
+		return "", errors.New("malformed signature")
+	}
+
+	msg := append(append([]byte{}, raw[10:]...), trusted...)
+	if !ed25519.Verify(pub, msg, global) {
+		return "", errors.New("trusted comment doesn't match")
+	}
+
+	id := make([]byte, 8)
+	for i := range id {
+		id[i] = key[9-i] // key IDs are little endian
+	}
+
+	return strings.ToUpper(hex.EncodeToString(id)), nil
+}
+
+func lines(b []byte) []string {
+	return strings.Split(strings.TrimSpace(strings.ReplaceAll(string(b), "\r\n", "\n")), "\n")
+}
+
+func fetch(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error downloading %s: %s", url, resp.Status)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// downloadArchive downloads the archive to the directory, continuing an
+// interrupted download, and returns its content once it matches the
+// checksum.
+func downloadArchive(ctx context.Context, client *http.Client, url, dir, checksum string) ([]byte, error) {
+	if dir == "" {
+		configDir, err := config.ConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = path.Join(configDir, "downloads")
+	}
+	if err := os.MkdirAll(dir, 0771); err != nil {
+		return nil, fmt.Errorf("error creating downloads directory: %s", err)
+	}
+
+	dest := path.Join(dir, path.Base(url))
+	if err := download.File(ctx, client, url, dest, checksum); err != nil {
+		return nil, err
+	}
+	defer os.Remove(dest) // nolint:errcheck
+
+	return ioutil.ReadFile(dest)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
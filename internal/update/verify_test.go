@@ -0,0 +1,152 @@
+package update
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestVerifyBinary(t *testing.T) {
+	c := qt.New(t)
+
+	binary := []byte("pscale binary")
+	bin := filepath.Join(c.Mkdir(), "pscale")
+	c.Assert(ioutil.WriteFile(bin, binary, 0755), qt.IsNil)
+
+	archive := tarGz(c, "pscale", binary)
+	name := archiveName("0.90.0", runtime.GOOS, runtime.GOARCH)
+	checksums := []byte(fmt.Sprintf("%s  other.tar.gz\n%s  %s\n", sha256Hex(nil), sha256Hex(archive), name))
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, qt.IsNil)
+	digest := sha256.Sum256(checksums)
+	ecSig, err := ecdsa.SignASN1(rand.Reader, ecKey, digest[:])
+	c.Assert(err, qt.IsNil)
+	der, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	c.Assert(err, qt.IsNil)
+	cosignKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	minisignKey, minisig := minisign(c, checksums)
+
+	files := map[string][]byte{
+		"/v0.90.0/pscale_0.90.0_checksums.txt":         checksums,
+		"/v0.90.0/pscale_0.90.0_checksums.txt.sig":     []byte(base64.StdEncoding.EncodeToString(ecSig)),
+		"/v0.90.0/pscale_0.90.0_checksums.txt.minisig": minisig,
+		"/v0.90.0/" + name:                             archive,
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(b)
+	}))
+	defer srv.Close()
+
+	oldExecutable := executable
+	executable = func() (string, error) { return bin, nil }
+	defer func() { executable = oldExecutable }()
+
+	verify := func(ver string, key []byte) (*Verification, error) {
+		return VerifyBinary(context.Background(), VerifyOptions{
+			Version:     ver,
+			PublicKey:   key,
+			ReleaseURL:  srv.URL + "/",
+			DownloadDir: c.Mkdir(),
+		})
+	}
+
+	v, err := verify("v0.90.0", nil)
+	c.Assert(err, qt.IsNil)
+	c.Assert(v.Archive, qt.Equals, name)
+	c.Assert(v.SHA256, qt.Equals, sha256Hex(binary))
+	c.Assert(v.Signature, qt.Equals, "")
+
+	v, err = verify("v0.90.0", cosignKey)
+	c.Assert(err, qt.IsNil)
+	c.Assert(v.Signature, qt.Equals, "cosign")
+
+	v, err = verify("v0.90.0", minisignKey)
+	c.Assert(err, qt.IsNil)
+	c.Assert(v.Signature, qt.Equals, "minisign (key ID 0807060504030201)")
+
+	otherKey, _ := minisign(c, []byte("other"))
+	_, err = verify("v0.90.0", otherKey)
+	c.Assert(err, qt.ErrorMatches, "invalid minisign signature of pscale_0.90.0_checksums.txt: signature doesn't match")
+
+	c.Assert(ioutil.WriteFile(bin, []byte("tampered"), 0755), qt.IsNil)
+	_, err = verify("v0.90.0", nil)
+	c.Assert(err, qt.ErrorMatches, "binary .* doesn't match the binary published in .*")
+
+	_, err = verify("v0.91.0", nil)
+	c.Assert(err, qt.ErrorMatches, "error downloading .*: 404 Not Found")
+
+	_, err = verify("", nil)
+	c.Assert(err, qt.ErrorMatches, "can't verify a binary built from source")
+}
+
+func TestArchiveName(t *testing.T) {
+	c := qt.New(t)
+
+	c.Assert(archiveName("0.90.0", "darwin", "arm64"), qt.Equals, "pscale_0.90.0_macOS_arm64.tar.gz")
+	c.Assert(archiveName("0.90.0", "linux", "amd64"), qt.Equals, "pscale_0.90.0_linux_amd64.tar.gz")
+	c.Assert(archiveName("0.90.0", "windows", "amd64"), qt.Equals, "pscale_0.90.0_windows_amd64.zip")
+}
+
+func tarGz(c *qt.C, name string, content []byte) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	c.Assert(tw.WriteHeader(&tar.Header{Name: "README.md", Mode: 0644, Size: 2, Typeflag: tar.TypeReg}), qt.IsNil)
+	_, err := tw.Write([]byte("hi"))
+	c.Assert(err, qt.IsNil)
+
+	c.Assert(tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}), qt.IsNil)
+	_, err = tw.Write(content)
+	c.Assert(err, qt.IsNil)
+
+	c.Assert(tw.Close(), qt.IsNil)
+	c.Assert(gz.Close(), qt.IsNil)
+	return buf.Bytes()
+}
+
+// minisign returns a new minisign public key and a legacy signature of the
+// given content.
+func minisign(c *qt.C, content []byte) ([]byte, []byte) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	c.Assert(err, qt.IsNil)
+
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	key := append(append([]byte("Ed"), keyID...), pub...)
+	sig := append(append([]byte("Ed"), keyID...), ed25519.Sign(priv, content)...)
+
+	trusted := "timestamp:1650000000"
+	global := ed25519.Sign(priv, append(append([]byte{}, sig[10:]...), trusted...))
+
+	publicKey := fmt.Sprintf("untrusted comment: minisign public key\n%s\n", base64.StdEncoding.EncodeToString(key))
+	signature := fmt.Sprintf("untrusted comment: signature\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(sig), trusted, base64.StdEncoding.EncodeToString(global))
+
+	return []byte(publicKey), []byte(signature)
+}